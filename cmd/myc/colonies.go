@@ -27,7 +27,7 @@ var coloniesListCmd = &cobra.Command{
 			return err
 		}
 
-		pool, err := db.NewPool(context.Background(), cfg.DatabaseURL)
+		pool, err := db.NewSchemaPool(context.Background(), cfg.DatabaseURL, cfg.DBSchema)
 		if err != nil {
 			return err
 		}
@@ -58,6 +58,76 @@ var coloniesListCmd = &cobra.Command{
 	},
 }
 
+var moveSchemaFrom string
+
+var coloniesMoveSchemaCmd = &cobra.Command{
+	Use:   "move-schema <colony-id> <schema>",
+	Short: "Move a colony and its index into a dedicated schema",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+
+		pool, err := db.NewPool(context.Background(), cfg.DatabaseURL)
+		if err != nil {
+			return err
+		}
+		defer pool.Close()
+
+		copied, err := db.MoveProjectToSchema(context.Background(), pool, args[0], moveSchemaFrom, args[1])
+		if err != nil {
+			return fmt.Errorf("moving colony: %w", err)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "TABLE\tROWS")
+		for _, t := range db.ProjectTables() {
+			fmt.Fprintf(w, "%s\t%d\n", t, copied[t])
+		}
+		w.Flush()
+		fmt.Printf("Moved colony %s from %s to %s. Set DB_SCHEMA=%s to serve it.\n", args[0], moveSchemaFrom, args[1], args[1])
+
+		return nil
+	},
+}
+
+var coloniesMigrateCmd = &cobra.Command{
+	Use:   "migrate [schema...]",
+	Short: "Apply pending migrations to the given schemas, or every schema with colonies",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+
+		pool, err := db.NewPool(context.Background(), cfg.DatabaseURL)
+		if err != nil {
+			return err
+		}
+		defer pool.Close()
+
+		schemas := args
+		if len(schemas) == 0 {
+			if schemas, err = db.ListSchemas(context.Background(), pool); err != nil {
+				return err
+			}
+		}
+		for _, schema := range schemas {
+			if err := db.Migrate(context.Background(), pool, schema); err != nil {
+				return fmt.Errorf("migrating %s: %w", schema, err)
+			}
+			fmt.Printf("Schema %s is up to date.\n", schema)
+		}
+
+		return nil
+	},
+}
+
 func init() {
 	coloniesCmd.AddCommand(coloniesListCmd)
+	coloniesMoveSchemaCmd.Flags().StringVar(&moveSchemaFrom, "from", "public", "schema the colony is in now")
+	coloniesCmd.AddCommand(coloniesMoveSchemaCmd)
+	coloniesCmd.AddCommand(coloniesMigrateCmd)
 }
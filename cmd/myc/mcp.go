@@ -5,11 +5,12 @@ import (
 	"log/slog"
 
 	mcpserver "github.com/mark3labs/mcp-go/server"
-	openai "github.com/sashabaranov/go-openai"
 	"github.com/spf13/cobra"
 
 	"github.com/maximilianfalco/mycelium/internal/config"
 	"github.com/maximilianfalco/mycelium/internal/db"
+	"github.com/maximilianfalco/mycelium/internal/engine"
+	"github.com/maximilianfalco/mycelium/internal/indexer"
 	"github.com/maximilianfalco/mycelium/internal/mcp"
 )
 
@@ -22,18 +23,37 @@ var mcpCmd = &cobra.Command{
 			return err
 		}
 
-		pool, err := db.NewPool(context.Background(), cfg.DatabaseURL)
+		pool, err := db.NewSchemaPool(context.Background(), cfg.DatabaseURL, cfg.DBSchema)
 		if err != nil {
 			return err
 		}
 		defer pool.Close()
 
-		var oaiClient *openai.Client
-		if cfg.OpenAIAPIKey != "" {
-			oaiClient = openai.NewClient(cfg.OpenAIAPIKey)
+		if cfg.DBSchema != "" {
+			if err := db.EnsureSchema(context.Background(), pool, cfg.DBSchema); err != nil {
+				return err
+			}
 		}
 
-		s := mcp.NewServer(pool, oaiClient)
+		if err := engine.SetSimilarityMetric(cfg.SimilarityMetric); err != nil {
+			return err
+		}
+		engine.SetHubInDegree(cfg.HubInDegree)
+		engine.SetSearchKinds(cfg.SearchKinds)
+		engine.SetBranchWorkspaces(cfg.BranchWorkspaces)
+		engine.SetGeneratedExpansion(cfg.ExpandGenerated)
+		if err := engine.EnsureVectorIndex(context.Background(), pool); err != nil {
+			return err
+		}
+
+		oaiClient := indexer.NewEmbeddingClient(cfg)
+
+		var cache engine.ContextCache
+		if cfg.ContextCacheSize > 0 {
+			cache = engine.NewLRUContextCache(cfg.ContextCacheSize)
+		}
+
+		s := mcp.NewServer(pool, oaiClient, cache)
 
 		slog.Info("starting MCP server (stdio)")
 		return mcpserver.ServeStdio(s)
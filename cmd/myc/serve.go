@@ -9,6 +9,7 @@ import (
 	"github.com/maximilianfalco/mycelium/internal/api"
 	"github.com/maximilianfalco/mycelium/internal/config"
 	"github.com/maximilianfalco/mycelium/internal/db"
+	"github.com/maximilianfalco/mycelium/internal/engine"
 )
 
 var serveCmd = &cobra.Command{
@@ -20,12 +21,29 @@ var serveCmd = &cobra.Command{
 			return err
 		}
 
-		pool, err := db.NewPool(context.Background(), cfg.DatabaseURL)
+		pool, err := db.NewSchemaPool(context.Background(), cfg.DatabaseURL, cfg.DBSchema)
 		if err != nil {
 			return err
 		}
 		defer pool.Close()
 
+		if cfg.DBSchema != "" {
+			if err := db.EnsureSchema(context.Background(), pool, cfg.DBSchema); err != nil {
+				return err
+			}
+		}
+
+		if err := engine.SetSimilarityMetric(cfg.SimilarityMetric); err != nil {
+			return err
+		}
+		engine.SetHubInDegree(cfg.HubInDegree)
+		engine.SetSearchKinds(cfg.SearchKinds)
+		engine.SetBranchWorkspaces(cfg.BranchWorkspaces)
+		engine.SetGeneratedExpansion(cfg.ExpandGenerated)
+		if err := engine.EnsureVectorIndex(context.Background(), pool); err != nil {
+			return err
+		}
+
 		slog.Info("starting API server", "port", cfg.ServerPort)
 		return api.Run(pool, cfg, cfg.ServerPort)
 	},
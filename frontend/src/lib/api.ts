@@ -86,6 +86,7 @@ export interface SemanticSearchResult {
   qualifiedName: string;
   filePath: string;
   kind: string;
+  kindGroup?: string;
   similarity: number;
   signature: string;
   sourceCode?: string;
@@ -191,6 +192,7 @@ export interface GraphVizNode {
   name: string;
   qualifiedName: string;
   kind: string;
+  kindGroup: string;
   filePath: string;
   sourceAlias: string;
   degree: number;
@@ -209,6 +211,7 @@ export interface GraphVizData {
     nodeCount: number;
     edgeCount: number;
     kinds: Record<string, number>;
+    kindGroups: Record<string, number>;
   };
 }
 
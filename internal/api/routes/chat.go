@@ -43,7 +43,11 @@ func streamChat(pool *pgxpool.Pool, oaiClient *openai.Client, cfg *config.Config
 			return
 		}
 
-		result, err := engine.ChatStream(r.Context(), pool, oaiClient, req.Message, projectID, cfg.ChatModel, cfg.MaxContextTokens, req.History)
+		var opts []engine.AssembleOption
+		if v := r.URL.Query().Get("expandGenerated"); v != "" {
+			opts = append(opts, engine.WithGeneratedExpansion(v == "true"))
+		}
+		result, err := engine.ChatStream(r.Context(), pool, oaiClient, req.Message, projectID, cfg.ChatModel, cfg.MaxContextTokens, req.History, opts...)
 		if err != nil {
 			writeError(w, http.StatusInternalServerError, err.Error())
 			return
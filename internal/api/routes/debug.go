@@ -19,10 +19,7 @@ import (
 func DebugRoutes(cfg *config.Config) chi.Router {
 	r := chi.NewRouter()
 
-	var oaiClient *openai.Client
-	if cfg.OpenAIAPIKey != "" {
-		oaiClient = openai.NewClient(cfg.OpenAIAPIKey)
-	}
+	oaiClient := indexer.NewEmbeddingClient(cfg)
 
 	r.Post("/crawl", debugCrawl())
 	r.Post("/parse", debugParse())
@@ -166,17 +163,21 @@ func debugResolve() http.HandlerFunc {
 			allNodes,
 			allFiles,
 			req.Path,
+			indexer.WithPackages(wsInfo.Packages),
+			indexer.WithTSBaseURLs(wsInfo.TSBaseURLs),
 		)
 
 		writeJSON(w, http.StatusOK, map[string]any{
-			"workspace":   wsInfo,
-			"filesCount":  len(allFiles),
-			"nodesCount":  len(allNodes),
-			"edgesCount":  len(allEdges),
-			"resolved":    resolveResult.Resolved,
-			"unresolved":  resolveResult.Unresolved,
-			"dependsOn":   resolveResult.DependsOn,
-			"parseErrors": parseErrors,
+			"workspace":               wsInfo,
+			"filesCount":              len(allFiles),
+			"nodesCount":              len(allNodes),
+			"edgesCount":              len(allEdges),
+			"resolved":                resolveResult.Resolved,
+			"unresolved":              resolveResult.Unresolved,
+			"dependsOn":               resolveResult.DependsOn,
+			"dependsOnThroughBarrels": resolveResult.DependsOnThroughBarrels,
+			"normalizedCalls":         resolveResult.NormalizedCalls,
+			"parseErrors":             parseErrors,
 		})
 	}
 }
@@ -346,7 +347,7 @@ func debugChanges() http.HandlerFunc {
 			return
 		}
 
-		cs, err := indexer.DetectChanges(r.Context(), req.Path, req.LastIndexedCommit, nil, 100, false)
+		cs, err := indexer.DetectChanges(r.Context(), req.Path, req.LastIndexedCommit, nil, 100, false, indexer.SubmodulesSkip)
 		if err != nil {
 			writeError(w, http.StatusBadRequest, fmt.Sprintf("detecting changes: %v", err))
 			return
@@ -1,10 +1,16 @@
 package routes
 
 import (
+	"context"
+	"io"
+	"log/slog"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	openai "github.com/sashabaranov/go-openai"
 
 	"github.com/maximilianfalco/mycelium/internal/engine"
 )
@@ -41,3 +47,453 @@ func getGraphNodeDetail(pool *pgxpool.Pool) http.HandlerFunc {
 		writeJSON(w, http.StatusOK, detail)
 	}
 }
+
+func getNodeAtPosition(pool *pgxpool.Pool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		projectID := chi.URLParam(r, "id")
+		file := r.URL.Query().Get("file")
+		line, err := strconv.Atoi(r.URL.Query().Get("line"))
+		if file == "" || err != nil || line <= 0 {
+			writeError(w, http.StatusBadRequest, "file and a positive line are required")
+			return
+		}
+
+		node, err := engine.GetNodeAtPosition(r.Context(), pool, projectID, file, line)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if node == nil {
+			writeError(w, http.StatusNotFound, "no node at position")
+			return
+		}
+
+		writeJSON(w, http.StatusOK, node)
+	}
+}
+
+func gotoDefinition(pool *pgxpool.Pool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		projectID := chi.URLParam(r, "id")
+		q := r.URL.Query()
+		file, symbol := q.Get("file"), q.Get("symbol")
+		line, err := strconv.Atoi(q.Get("line"))
+		if file == "" || symbol == "" || err != nil || line <= 0 {
+			writeError(w, http.StatusBadRequest, "file, a positive line, and symbol are required")
+			return
+		}
+
+		node, err := engine.GotoDefinition(r.Context(), pool, projectID, file, line, symbol)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if node == nil {
+			writeError(w, http.StatusNotFound, "definition not found")
+			return
+		}
+
+		writeJSON(w, http.StatusOK, node)
+	}
+}
+
+func findCallersMatching(pool *pgxpool.Pool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pattern := r.URL.Query().Get("pattern")
+		if pattern == "" {
+			writeError(w, http.StatusBadRequest, "pattern is required")
+			return
+		}
+		var opts []engine.CallerMatchOption
+		if r.URL.Query().Get("external") == "only" {
+			opts = append(opts, engine.ExternalCalleesOnly())
+		}
+
+		calls, err := engine.FindCallersMatching(r.Context(), pool, chi.URLParam(r, "id"), pattern, opts...)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, calls)
+	}
+}
+
+func getIncomingCalls(pool *pgxpool.Pool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		calls, err := engine.IncomingCalls(r.Context(), pool, chi.URLParam(r, "nodeId"))
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, calls)
+	}
+}
+
+func getOutgoingCalls(pool *pgxpool.Pool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		calls, err := engine.OutgoingCalls(r.Context(), pool, chi.URLParam(r, "nodeId"))
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, calls)
+	}
+}
+
+func getImplementers(pool *pgxpool.Pool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		nodes, err := engine.GetImplementers(r.Context(), pool, chi.URLParam(r, "nodeId"))
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, nodes)
+	}
+}
+
+func getInterfacesImplemented(pool *pgxpool.Pool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		nodes, err := engine.GetInterfacesImplemented(r.Context(), pool, chi.URLParam(r, "nodeId"))
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, nodes)
+	}
+}
+
+func getBlastRadius(pool *pgxpool.Pool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		nodeID := chi.URLParam(r, "nodeId")
+		depth, _ := strconv.Atoi(r.URL.Query().Get("depth"))
+
+		count, err := engine.BlastRadius(r.Context(), pool, nodeID, depth)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"nodeId": nodeID, "blastRadius": count})
+	}
+}
+
+func getReachableExternals(pool *pgxpool.Pool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		nodeID := chi.URLParam(r, "nodeId")
+		depth, _ := strconv.Atoi(r.URL.Query().Get("depth"))
+
+		externals, err := engine.GetReachableExternals(r.Context(), pool, nodeID, depth)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"nodeId": nodeID, "externals": externals})
+	}
+}
+
+func getRelatedSymbols(pool *pgxpool.Pool, oaiClient *openai.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		nodeID := chi.URLParam(r, "nodeId")
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+		related, err := engine.GetRelatedSymbols(r.Context(), pool, oaiClient, nodeID, limit)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if related == nil {
+			writeError(w, http.StatusNotFound, "node not found")
+			return
+		}
+		writeJSON(w, http.StatusOK, related)
+	}
+}
+
+func getNodeCard(pool *pgxpool.Pool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		card, err := engine.GetNodeCard(r.Context(), pool, chi.URLParam(r, "nodeId"))
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if card == nil {
+			writeError(w, http.StatusNotFound, "node not found")
+			return
+		}
+		writeJSON(w, http.StatusOK, card)
+	}
+}
+
+func getLowestCommonCaller(pool *pgxpool.Pool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		a, b := r.URL.Query().Get("a"), r.URL.Query().Get("b")
+		if a == "" || b == "" {
+			writeError(w, http.StatusBadRequest, "a and b node IDs are required")
+			return
+		}
+		depth, _ := strconv.Atoi(r.URL.Query().Get("depth"))
+
+		node, err := engine.LowestCommonCaller(r.Context(), pool, a, b, depth)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if node == nil {
+			writeError(w, http.StatusNotFound, "no common caller found")
+			return
+		}
+		writeJSON(w, http.StatusOK, node)
+	}
+}
+
+func getProjectTree(pool *pgxpool.Pool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		projectID := chi.URLParam(r, "id")
+
+		tree, err := engine.GetProjectTree(r.Context(), pool, projectID)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if tree == nil {
+			writeError(w, http.StatusNotFound, "project not found")
+			return
+		}
+
+		writeJSON(w, http.StatusOK, tree)
+	}
+}
+
+func diffIndexRuns(pool *pgxpool.Pool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		projectID := chi.URLParam(r, "id")
+		if fromBranch, toBranch := r.URL.Query().Get("fromBranch"), r.URL.Query().Get("toBranch"); fromBranch != "" || toBranch != "" {
+			if fromBranch == "" || toBranch == "" {
+				writeError(w, http.StatusBadRequest, "fromBranch and toBranch are both required")
+				return
+			}
+			diff, err := engine.DiffBranches(r.Context(), pool, projectID, fromBranch, toBranch)
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+			if diff == nil {
+				writeError(w, http.StatusNotFound, "no index manifest for one or both branches")
+				return
+			}
+			writeJSON(w, http.StatusOK, diff)
+			return
+		}
+
+		from := r.URL.Query().Get("from")
+		to := r.URL.Query().Get("to")
+		if from == "" || to == "" {
+			writeError(w, http.StatusBadRequest, "from and to commits are required")
+			return
+		}
+
+		diff, err := engine.DiffIndexRuns(r.Context(), pool, projectID, from, to)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if diff == nil {
+			writeError(w, http.StatusNotFound, "no index manifest for one or both commits")
+			return
+		}
+
+		writeJSON(w, http.StatusOK, diff)
+	}
+}
+
+func findDuplicates(pool *pgxpool.Pool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		projectID := chi.URLParam(r, "id")
+		var threshold float64
+		if v := r.URL.Query().Get("threshold"); v != "" {
+			t, err := strconv.ParseFloat(v, 64)
+			if err != nil || t <= 0 || t > 1 {
+				writeError(w, http.StatusBadRequest, "threshold must be a number in (0, 1]")
+				return
+			}
+			threshold = t
+		}
+		var minSize int
+		if v := r.URL.Query().Get("minSize"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, "minSize must be an integer")
+				return
+			}
+			minSize = n
+		}
+
+		clusters, err := engine.FindDuplicateClusters(r.Context(), pool, projectID, threshold, minSize)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, clusters)
+	}
+}
+
+func findStructuralDuplicates(pool *pgxpool.Pool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		groups, err := engine.FindStructuralDuplicates(r.Context(), pool, chi.URLParam(r, "id"))
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, groups)
+	}
+}
+
+func getUnusedExports(pool *pgxpool.Pool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var opts []engine.ReportOption
+		if r.URL.Query().Get("fixtures") == "true" {
+			opts = append(opts, engine.IncludeFixtures())
+		}
+		nodes, err := engine.GetUnusedExports(r.Context(), pool, chi.URLParam(r, "id"), opts...)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, nodes)
+	}
+}
+
+func getOrphanFiles(pool *pgxpool.Pool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var opts []engine.ReportOption
+		if r.URL.Query().Get("fixtures") == "true" {
+			opts = append(opts, engine.IncludeFixtures())
+		}
+		files, err := engine.GetOrphanFiles(r.Context(), pool, chi.URLParam(r, "id"), opts...)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, files)
+	}
+}
+
+func getMostImported(pool *pgxpool.Pool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		var opts []engine.ReportOption
+		if r.URL.Query().Get("externals") == "true" {
+			opts = append(opts, engine.IncludeExternals())
+		}
+
+		counts, err := engine.GetMostImported(r.Context(), pool, chi.URLParam(r, "id"), limit, opts...)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, counts)
+	}
+}
+
+func getLargestFiles(pool *pgxpool.Pool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		var opts []engine.ReportOption
+		if r.URL.Query().Get("externals") == "true" {
+			opts = append(opts, engine.IncludeExternals())
+		}
+
+		files, err := engine.GetLargestFiles(r.Context(), pool, chi.URLParam(r, "id"), limit, opts...)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, files)
+	}
+}
+
+func getDeepImportViolations(pool *pgxpool.Pool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		violations, err := engine.GetDeepImportViolations(r.Context(), pool, chi.URLParam(r, "id"))
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, violations)
+	}
+}
+
+func getNodesByOwner(pool *pgxpool.Pool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		owner := r.URL.Query().Get("owner")
+		if owner == "" {
+			writeError(w, http.StatusBadRequest, "owner is required")
+			return
+		}
+
+		nodes, err := engine.GetNodesByOwner(r.Context(), pool, chi.URLParam(r, "id"), owner)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, nodes)
+	}
+}
+
+func getDeletedNodes(pool *pgxpool.Pool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var since time.Time
+		if v := r.URL.Query().Get("since"); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, "since must be an RFC 3339 timestamp")
+				return
+			}
+			since = t
+		}
+
+		nodes, err := engine.GetDeletedNodes(r.Context(), pool, chi.URLParam(r, "id"), since)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, nodes)
+	}
+}
+
+func exportNodesCSV(pool *pgxpool.Pool) http.HandlerFunc {
+	return exportCSV(pool, "nodes.csv", engine.ExportNodesCSV)
+}
+
+func exportEdgesCSV(pool *pgxpool.Pool) http.HandlerFunc {
+	return exportCSV(pool, "edges.csv", engine.ExportEdgesCSV)
+}
+
+// exportCSV streams a project export as a CSV download. A failure before
+// the first row is a JSON error; after it the status is already sent, so
+// the download is cut short and the error logged.
+func exportCSV(pool *pgxpool.Pool, filename string, export func(context.Context, *pgxpool.Pool, string, io.Writer) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sw := &startedWriter{ResponseWriter: w}
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+		if err := export(r.Context(), pool, chi.URLParam(r, "id"), sw); err != nil {
+			if !sw.started {
+				w.Header().Del("Content-Disposition")
+				writeError(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+			slog.Error("csv export failed", "file", filename, "error", err)
+		}
+	}
+}
+
+// startedWriter records whether anything has been written to the response.
+type startedWriter struct {
+	http.ResponseWriter
+	started bool
+}
+
+func (w *startedWriter) Write(p []byte) (int, error) {
+	w.started = true
+	return w.ResponseWriter.Write(p)
+}
@@ -3,6 +3,7 @@ package routes
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
@@ -13,6 +14,7 @@ import (
 
 	"github.com/maximilianfalco/mycelium/internal/config"
 	"github.com/maximilianfalco/mycelium/internal/indexer"
+	"github.com/maximilianfalco/mycelium/internal/projects"
 )
 
 var statusStore = indexer.NewStatusStore()
@@ -20,13 +22,13 @@ var statusStore = indexer.NewStatusStore()
 func IndexingRoutes(pool *pgxpool.Pool, cfg *config.Config) chi.Router {
 	r := chi.NewRouter()
 
-	var oaiClient *openai.Client
-	if cfg.OpenAIAPIKey != "" {
-		oaiClient = openai.NewClient(cfg.OpenAIAPIKey)
-	}
+	oaiClient := indexer.NewEmbeddingClient(cfg)
 
 	r.Post("/", triggerIndex(pool, cfg, oaiClient))
 	r.Get("/status", getIndexStatus(pool))
+	r.Post("/cancel", cancelIndex())
+	r.Post("/file", indexFile(pool, cfg, oaiClient))
+	r.Post("/edges", rebuildEdges(pool, cfg))
 
 	return r
 }
@@ -61,14 +63,20 @@ func triggerIndex(pool *pgxpool.Pool, cfg *config.Config, oaiClient *openai.Clie
 		statusStore.Set(jobID, status)
 
 		// Run indexing in background — use a detached context so the job
-		// isn't cancelled when the HTTP response is sent.
+		// isn't cancelled when the HTTP response is sent, only via /cancel.
+		ctx, cancel := context.WithCancel(context.Background())
+		statusStore.SetCancel(jobID, cancel)
 		force := body.Force
 		go func() {
-			result := indexer.IndexProject(context.Background(), pool, cfg, oaiClient, projectID, status, force)
+			defer statusStore.ClearCancel(jobID)
+			result := indexer.IndexProject(ctx, pool, cfg, oaiClient, projectID, status, force)
 			now := time.Now()
 			status.DoneAt = &now
 			status.Result = result
-			if len(result.Errors) > 0 {
+			if result.Cancelled {
+				status.Status = "cancelled"
+				status.Error = "cancelled by user"
+			} else if len(result.Errors) > 0 {
 				status.Status = "failed"
 				status.Error = result.Errors[0]
 			} else {
@@ -85,6 +93,119 @@ func triggerIndex(pool *pgxpool.Pool, cfg *config.Config, oaiClient *openai.Clie
 	}
 }
 
+// indexFile re-indexes a single file of one of the project's sources and
+// waits for it, unlike the background full run.
+func indexFile(pool *pgxpool.Pool, cfg *config.Config, oaiClient *openai.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		projectID := chi.URLParam(r, "id")
+
+		var body struct {
+			SourceID string `json:"sourceId"`
+			Path     string `json:"path"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		if body.SourceID == "" || body.Path == "" {
+			writeError(w, http.StatusBadRequest, "sourceId and path are required")
+			return
+		}
+
+		source, err := projects.GetSource(r.Context(), pool, body.SourceID)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if source == nil || source.ProjectID != projectID {
+			writeError(w, http.StatusNotFound, "source not found")
+			return
+		}
+
+		result, err := indexer.IndexFile(r.Context(), pool, cfg, oaiClient, source.ID, body.Path)
+		if errors.Is(err, indexer.ErrIndexingInProgress) {
+			writeError(w, http.StatusConflict, err.Error())
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusUnprocessableEntity, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, result)
+	}
+}
+
+// rebuildEdges recomputes the project's edges of the requested kinds, or all
+// edges when kinds is empty, without reindexing nodes.
+func rebuildEdges(pool *pgxpool.Pool, cfg *config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		projectID := chi.URLParam(r, "id")
+
+		var body struct {
+			Kinds []string `json:"kinds"`
+		}
+		if r.ContentLength > 0 {
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				writeError(w, http.StatusBadRequest, "invalid request body")
+				return
+			}
+		}
+
+		project, err := projects.GetProject(r.Context(), pool, projectID)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if project == nil {
+			writeError(w, http.StatusNotFound, "project not found")
+			return
+		}
+
+		result, err := indexer.RebuildEdges(r.Context(), pool, cfg, projectID, body.Kinds)
+		if errors.Is(err, indexer.ErrIndexingInProgress) {
+			writeError(w, http.StatusConflict, err.Error())
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, result)
+	}
+}
+
+// cancelIndex stops the project's running job, or the one named by ?jobId=.
+func cancelIndex() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		projectID := chi.URLParam(r, "id")
+
+		var job *indexer.IndexStatus
+		if jobID := r.URL.Query().Get("jobId"); jobID != "" {
+			job = statusStore.Get(jobID)
+			if job != nil && job.ProjectID != projectID {
+				job = nil
+			}
+		} else {
+			job = statusStore.GetByProject(projectID)
+		}
+		if job == nil {
+			writeError(w, http.StatusNotFound, "no indexing job found for this project")
+			return
+		}
+
+		if job.Status != "running" || !statusStore.CancelJob(job.JobID) {
+			writeError(w, http.StatusConflict, "indexing job is not running")
+			return
+		}
+
+		writeJSON(w, http.StatusAccepted, map[string]string{
+			"status":    "cancelling",
+			"projectId": projectID,
+			"jobId":     job.JobID,
+		})
+	}
+}
+
 func getIndexStatus(pool *pgxpool.Pool) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		projectID := chi.URLParam(r, "id")
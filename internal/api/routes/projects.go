@@ -6,19 +6,16 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
-	openai "github.com/sashabaranov/go-openai"
 
 	"github.com/maximilianfalco/mycelium/internal/config"
+	"github.com/maximilianfalco/mycelium/internal/indexer"
 	"github.com/maximilianfalco/mycelium/internal/projects"
 )
 
 func ProjectRoutes(pool *pgxpool.Pool, cfg *config.Config) chi.Router {
 	r := chi.NewRouter()
 
-	var oaiClient *openai.Client
-	if cfg.OpenAIAPIKey != "" {
-		oaiClient = openai.NewClient(cfg.OpenAIAPIKey)
-	}
+	oaiClient := indexer.NewEmbeddingClient(cfg)
 
 	r.Post("/", createProject(pool))
 	r.Get("/", listProjects(pool))
@@ -34,7 +31,32 @@ func ProjectRoutes(pool *pgxpool.Pool, cfg *config.Config) chi.Router {
 		r.Delete("/sources/{sourceID}", removeSource(pool))
 
 		r.Get("/graph", getProjectGraph(pool))
+		r.Get("/graph/node-at", getNodeAtPosition(pool))
+		r.Get("/graph/definition", gotoDefinition(pool))
 		r.Get("/graph/node/{nodeId}", getGraphNodeDetail(pool))
+		r.Get("/graph/node/{nodeId}/incoming-calls", getIncomingCalls(pool))
+		r.Get("/graph/node/{nodeId}/outgoing-calls", getOutgoingCalls(pool))
+		r.Get("/graph/node/{nodeId}/implementers", getImplementers(pool))
+		r.Get("/graph/node/{nodeId}/implements", getInterfacesImplemented(pool))
+		r.Get("/graph/node/{nodeId}/blast-radius", getBlastRadius(pool))
+		r.Get("/graph/node/{nodeId}/externals", getReachableExternals(pool))
+		r.Get("/graph/node/{nodeId}/related", getRelatedSymbols(pool, oaiClient))
+		r.Get("/graph/node/{nodeId}/card", getNodeCard(pool))
+		r.Get("/graph/common-caller", getLowestCommonCaller(pool))
+		r.Get("/graph/callers", findCallersMatching(pool))
+		r.Get("/tree", getProjectTree(pool))
+		r.Get("/diff", diffIndexRuns(pool))
+		r.Get("/duplicates", findDuplicates(pool))
+		r.Get("/structural-duplicates", findStructuralDuplicates(pool))
+		r.Get("/unused-exports", getUnusedExports(pool))
+		r.Get("/orphan-files", getOrphanFiles(pool))
+		r.Get("/most-imported", getMostImported(pool))
+		r.Get("/largest-files", getLargestFiles(pool))
+		r.Get("/deep-imports", getDeepImportViolations(pool))
+		r.Get("/owned-nodes", getNodesByOwner(pool))
+		r.Get("/deleted-nodes", getDeletedNodes(pool))
+		r.Get("/export/nodes.csv", exportNodesCSV(pool))
+		r.Get("/export/edges.csv", exportEdgesCSV(pool))
 
 		r.Mount("/index", IndexingRoutes(pool, cfg))
 		r.Mount("/chat", ChatRoutes(pool, oaiClient, cfg))
@@ -152,6 +174,9 @@ func addSource(pool *pgxpool.Pool) http.HandlerFunc {
 		}
 		if req.SourceType == "" {
 			req.SourceType = "directory"
+			if projects.IsRemotePath(req.Path) {
+				req.SourceType = "git_remote"
+			}
 		}
 
 		s, err := projects.AddSource(r.Context(), pool, projectID, req.Path, req.SourceType, req.IsCode, req.Alias)
@@ -9,6 +9,7 @@ import (
 	openai "github.com/sashabaranov/go-openai"
 
 	"github.com/maximilianfalco/mycelium/internal/engine"
+	"github.com/maximilianfalco/mycelium/internal/indexer/parsers"
 )
 
 func SearchRoutes(pool *pgxpool.Pool, oaiClient *openai.Client) chi.Router {
@@ -27,6 +28,12 @@ func semanticSearch(pool *pgxpool.Pool, oaiClient *openai.Client) http.HandlerFu
 			ProjectID string   `json:"projectId"`
 			Limit     int      `json:"limit"`
 			Kinds     []string `json:"kinds"`
+			Alpha     *float64 `json:"alpha"`
+			Embedding string   `json:"embedding"`
+			// Branch limits the search to one branch indexed with BRANCH_WORKSPACES.
+			Branch string `json:"branch"`
+			// Fixtures lets test fixtures and mocks match.
+			Fixtures bool `json:"fixtures"`
 		}
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			writeError(w, http.StatusBadRequest, "invalid request body")
@@ -40,12 +47,26 @@ func semanticSearch(pool *pgxpool.Pool, oaiClient *openai.Client) http.HandlerFu
 			writeError(w, http.StatusBadRequest, "projectId is required")
 			return
 		}
+		alpha := engine.DefaultHybridAlpha
+		if req.Alpha != nil {
+			alpha = *req.Alpha
+		}
+		switch req.Embedding {
+		case "", engine.EmbeddingFull, engine.EmbeddingSignature, engine.EmbeddingAuto:
+		default:
+			writeError(w, http.StatusBadRequest, "embedding must be full, signature, or auto")
+			return
+		}
 		if oaiClient == nil {
 			writeError(w, http.StatusServiceUnavailable, "OpenAI API key not configured")
 			return
 		}
 
-		results, err := engine.HybridSearch(r.Context(), pool, oaiClient, req.Query, req.ProjectID, req.Limit, req.Kinds)
+		opts := []engine.SearchOption{engine.WithEmbedding(req.Embedding), engine.OnBranch(req.Branch)}
+		if req.Fixtures {
+			opts = append(opts, engine.SearchFixtures())
+		}
+		results, err := engine.HybridSearch(r.Context(), pool, oaiClient, req.Query, req.ProjectID, req.Limit, req.Kinds, alpha, opts...)
 		if err != nil {
 			writeError(w, http.StatusInternalServerError, err.Error())
 			return
@@ -63,6 +84,15 @@ func structuralSearch(pool *pgxpool.Pool) http.HandlerFunc {
 			Limit     int      `json:"limit"`
 			Kinds     []string `json:"kinds"`
 			QueryType string   `json:"queryType"`
+			EdgeKinds []string `json:"edgeKinds"`
+			// BlastRadius annotates each result with its transitive dependent count.
+			BlastRadius bool `json:"blastRadius"`
+			// SkipGenerated keeps dependency walks out of generated files.
+			SkipGenerated bool `json:"skipGenerated"`
+			// SkipTypeOnly keeps dependency walks off type-only imports.
+			SkipTypeOnly bool `json:"skipTypeOnly"`
+			// Branch looks the symbol up on one branch indexed with BRANCH_WORKSPACES.
+			Branch string `json:"branch"`
 		}
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			writeError(w, http.StatusBadRequest, "invalid request body")
@@ -78,7 +108,7 @@ func structuralSearch(pool *pgxpool.Pool) http.HandlerFunc {
 		}
 
 		// Look up the node by qualified name
-		node, err := engine.FindNodeByQualifiedName(r.Context(), pool, req.ProjectID, req.Query)
+		node, err := engine.FindNodeByQualifiedNameOnBranch(r.Context(), pool, req.ProjectID, req.Branch, req.Query)
 		if err != nil {
 			writeError(w, http.StatusInternalServerError, err.Error())
 			return
@@ -93,20 +123,32 @@ func structuralSearch(pool *pgxpool.Pool) http.HandlerFunc {
 			queryType = "callers"
 		}
 
+		var traversal []engine.TraversalOption
+		if req.SkipGenerated {
+			traversal = append(traversal, engine.SkipGenerated())
+		}
+		if req.SkipTypeOnly {
+			traversal = append(traversal, engine.SkipTypeOnly())
+		}
+
 		var results []engine.NodeResult
 		switch queryType {
 		case "callers":
 			results, err = engine.GetCallers(r.Context(), pool, node.NodeID, req.Limit)
 		case "callees":
 			results, err = engine.GetCallees(r.Context(), pool, node.NodeID, req.Limit)
+		case "referencers":
+			results, err = engine.GetReferencers(r.Context(), pool, node.NodeID, req.Limit)
 		case "importers":
 			results, err = engine.GetImporters(r.Context(), pool, node.NodeID, req.Limit)
 		case "dependencies":
-			results, err = engine.GetDependencies(r.Context(), pool, node.NodeID, 5, req.Limit)
+			results, err = engine.GetDependencies(r.Context(), pool, node.NodeID, 5, req.Limit, req.EdgeKinds, traversal...)
 		case "dependents":
-			results, err = engine.GetDependents(r.Context(), pool, node.NodeID, 5, req.Limit)
+			results, err = engine.GetDependents(r.Context(), pool, node.NodeID, 5, req.Limit, req.EdgeKinds, traversal...)
 		case "file":
 			results, err = engine.GetFileContext(r.Context(), pool, node.FilePath, req.ProjectID)
+		case "siblings":
+			results, err = engine.GetSiblings(r.Context(), pool, node.NodeID)
 		default:
 			writeError(w, http.StatusBadRequest, "unknown queryType: "+queryType)
 			return
@@ -117,7 +159,7 @@ func structuralSearch(pool *pgxpool.Pool) http.HandlerFunc {
 			return
 		}
 
-		// Apply kind filter if specified
+		// Apply kind filter if specified; entries may be kinds or kind groups
 		if len(req.Kinds) > 0 {
 			kindSet := make(map[string]bool, len(req.Kinds))
 			for _, k := range req.Kinds {
@@ -125,13 +167,20 @@ func structuralSearch(pool *pgxpool.Pool) http.HandlerFunc {
 			}
 			filtered := make([]engine.NodeResult, 0, len(results))
 			for _, nr := range results {
-				if kindSet[nr.Kind] {
+				if kindSet[nr.Kind] || kindSet[parsers.KindGroup(nr.Kind)] {
 					filtered = append(filtered, nr)
 				}
 			}
 			results = filtered
 		}
 
+		if req.BlastRadius {
+			if err := engine.AnnotateBlastRadius(r.Context(), pool, results, 5); err != nil {
+				writeError(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+		}
+
 		writeJSON(w, http.StatusOK, results)
 	}
 }
@@ -15,7 +15,7 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/maximilianfalco/mycelium/internal/api/routes"
 	"github.com/maximilianfalco/mycelium/internal/config"
-	"github.com/sashabaranov/go-openai"
+	"github.com/maximilianfalco/mycelium/internal/indexer"
 )
 
 func init() {
@@ -94,10 +94,7 @@ func NewServer(pool *pgxpool.Pool, cfg *config.Config, port string) *http.Server
 		w.Write([]byte(`{"status":"ok"}`))
 	})
 
-	var oaiClient *openai.Client
-	if cfg.OpenAIAPIKey != "" {
-		oaiClient = openai.NewClient(cfg.OpenAIAPIKey)
-	}
+	oaiClient := indexer.NewEmbeddingClient(cfg)
 
 	r.Mount("/projects", routes.ProjectRoutes(pool, cfg))
 	r.Post("/scan", routes.ScanHandler())
@@ -0,0 +1,157 @@
+package db
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"slices"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// migrationFiles are the incremental migrations, each safe to run again on
+// a schema that already has it.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migration is one file of migrations/, applied in name order.
+type migration struct {
+	name string
+	sql  string
+}
+
+func loadMigrations() ([]migration, error) {
+	names, err := fs.Glob(migrationFiles, "migrations/*.sql")
+	if err != nil {
+		return nil, fmt.Errorf("listing migrations: %w", err)
+	}
+	slices.Sort(names)
+	migrations := make([]migration, 0, len(names))
+	for _, name := range names {
+		sql, err := migrationFiles.ReadFile(name)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", name, err)
+		}
+		migrations = append(migrations, migration{name: name[len("migrations/"):], sql: string(sql)})
+	}
+	return migrations, nil
+}
+
+// migrationLockKey serializes Migrate runs across processes, so two servers
+// starting at once don't apply the same migration side by side.
+const migrationLockKey = 0x6d796365 // "myce"
+
+// Migrate applies to schema, in order, the migrations its schema_migrations
+// table doesn't list, recording each. A schema from before that table
+// existed gets every migration once. Each runs with the search_path set to
+// schema, so unqualified DDL lands there and never in public, then the
+// schemas resolving pgvector (see extensionSearchPath).
+func Migrate(ctx context.Context, pool *pgxpool.Pool, schema string) error {
+	if err := ValidateSchemaName(schema); err != nil {
+		return err
+	}
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquiring connection: %w", err)
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, migrationLockKey); err != nil {
+		return fmt.Errorf("locking migrations: %w", err)
+	}
+	defer conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockKey)
+
+	if _, err := conn.Exec(ctx, createMigrationsTableSQL(schema)); err != nil {
+		return fmt.Errorf("creating %s.schema_migrations: %w", schema, err)
+	}
+	rows, err := conn.Query(ctx, `SELECT name FROM `+schema+`.schema_migrations`)
+	if err != nil {
+		return fmt.Errorf("reading applied migrations of %s: %w", schema, err)
+	}
+	applied, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return fmt.Errorf("reading applied migrations of %s: %w", schema, err)
+	}
+	ext, err := vectorSchema(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if slices.Contains(applied, m.name) {
+			continue
+		}
+		// Simple protocol, so a migration's own BEGIN/COMMIT works as written
+		if _, err := conn.Exec(ctx, `SET search_path TO `+schema+`, `+extensionSearchPath(ext)+`;`+"\n"+m.sql); err != nil {
+			// Best effort: a session left inside the migration's failed
+			// transaction isn't idle, so the pool destroys it on release
+			conn.Exec(context.Background(), `RESET ALL`)
+			return fmt.Errorf("applying %s to schema %s: %w", m.name, schema, err)
+		}
+		if _, err := conn.Exec(ctx, `RESET ALL`); err != nil {
+			return fmt.Errorf("resetting session after %s: %w", m.name, err)
+		}
+		if _, err := conn.Exec(ctx,
+			`INSERT INTO `+schema+`.schema_migrations (name) VALUES ($1) ON CONFLICT DO NOTHING`, m.name,
+		); err != nil {
+			return fmt.Errorf("recording %s for schema %s: %w", m.name, schema, err)
+		}
+		slog.Info("applied migration", "schema", schema, "migration", m.name)
+	}
+	return nil
+}
+
+// markMigrated records every migration as applied to schema, for tables
+// just created from schema.sql.
+func markMigrated(ctx context.Context, tx pgx.Tx, schema string) error {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+	names := make([]string, len(migrations))
+	for i, m := range migrations {
+		names[i] = m.name
+	}
+	if _, err := tx.Exec(ctx, createMigrationsTableSQL(schema)); err != nil {
+		return fmt.Errorf("creating %s.schema_migrations: %w", schema, err)
+	}
+	if _, err := tx.Exec(ctx,
+		`INSERT INTO `+schema+`.schema_migrations (name) SELECT unnest($1::text[]) ON CONFLICT DO NOTHING`, names,
+	); err != nil {
+		return fmt.Errorf("recording migrations for schema %s: %w", schema, err)
+	}
+	return nil
+}
+
+func createMigrationsTableSQL(schema string) string {
+	return `CREATE TABLE IF NOT EXISTS ` + schema + `.schema_migrations (
+		name TEXT PRIMARY KEY,
+		applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`
+}
+
+// ListSchemas returns the schemas holding mycelium tables, public included
+// when it does.
+func ListSchemas(ctx context.Context, pool *pgxpool.Pool) ([]string, error) {
+	rows, err := pool.Query(ctx, `
+		SELECT table_schema FROM information_schema.tables
+		WHERE table_name = 'nodes' AND table_schema NOT IN ('pg_catalog', 'information_schema')
+		ORDER BY table_schema`)
+	if err != nil {
+		return nil, fmt.Errorf("listing schemas: %w", err)
+	}
+	schemas, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("listing schemas: %w", err)
+	}
+	return schemas, nil
+}
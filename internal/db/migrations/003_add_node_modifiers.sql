@@ -0,0 +1,5 @@
+-- Migration: Add language-specific modifiers (async, static, visibility, receiver kind) to nodes
+-- Run once on existing databases:
+--   docker exec mycelium-db-1 psql -U mycelium -d mycelium -f /dev/stdin < internal/db/migrations/003_add_node_modifiers.sql
+
+ALTER TABLE nodes ADD COLUMN IF NOT EXISTS modifiers TEXT[];
@@ -0,0 +1,7 @@
+-- Migration: Track rename/move provenance on nodes
+-- Run once on existing databases:
+--   docker exec mycelium-db-1 psql -U mycelium -d mycelium -f /dev/stdin < internal/db/migrations/004_add_node_renamed_from.sql
+
+-- ID of the node this one replaced when it was detected as moved or renamed.
+-- Not a foreign key: the old node is deleted in the same run.
+ALTER TABLE nodes ADD COLUMN IF NOT EXISTS renamed_from TEXT;
@@ -0,0 +1,7 @@
+-- Migration: Track public API surface on nodes (export visibility, @internal/@public tags, deprecation)
+-- Run once on existing databases:
+--   docker exec mycelium-db-1 psql -U mycelium -d mycelium -f /dev/stdin < internal/db/migrations/005_add_node_api_surface.sql
+
+ALTER TABLE nodes ADD COLUMN IF NOT EXISTS exported BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE nodes ADD COLUMN IF NOT EXISTS release_tag TEXT;
+ALTER TABLE nodes ADD COLUMN IF NOT EXISTS deprecated BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,33 @@
+-- Migration: Add per-commit graph manifests for diffing index runs
+-- Run once on existing databases:
+--   docker exec mycelium-db-1 psql -U mycelium -d mycelium -f /dev/stdin < internal/db/migrations/006_add_index_manifests.sql
+
+-- Per-commit snapshot of a source's graph, for diffing index runs.
+-- The live nodes/edges tables are overwritten in place, so history lives here.
+CREATE TABLE IF NOT EXISTS index_manifests (
+    source_id TEXT NOT NULL REFERENCES project_sources(id) ON DELETE CASCADE,
+    commit_sha TEXT NOT NULL,
+    recorded_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (source_id, commit_sha)
+);
+
+CREATE TABLE IF NOT EXISTS manifest_nodes (
+    source_id TEXT NOT NULL,
+    commit_sha TEXT NOT NULL,
+    file_path TEXT NOT NULL,
+    qualified_name TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    body_hash TEXT,
+    PRIMARY KEY (source_id, commit_sha, file_path, qualified_name),
+    FOREIGN KEY (source_id, commit_sha) REFERENCES index_manifests(source_id, commit_sha) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS manifest_edges (
+    source_id TEXT NOT NULL,
+    commit_sha TEXT NOT NULL,
+    source_qname TEXT NOT NULL,
+    target_qname TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    PRIMARY KEY (source_id, commit_sha, source_qname, target_qname, kind),
+    FOREIGN KEY (source_id, commit_sha) REFERENCES index_manifests(source_id, commit_sha) ON DELETE CASCADE
+);
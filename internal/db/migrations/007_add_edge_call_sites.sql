@@ -0,0 +1,5 @@
+-- Migration: Keep every call-site line on an edge, not just the first one
+-- Run once on existing databases:
+--   docker exec mycelium-db-1 psql -U mycelium -d mycelium -f /dev/stdin < internal/db/migrations/007_add_edge_call_sites.sql
+
+ALTER TABLE edges ADD COLUMN IF NOT EXISTS call_sites INTEGER[];
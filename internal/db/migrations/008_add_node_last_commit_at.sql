@@ -0,0 +1,5 @@
+-- Migration: Record when each node's file was last committed, for recency ranking
+-- Run once on existing databases:
+--   docker exec mycelium-db-1 psql -U mycelium -d mycelium -f /dev/stdin < internal/db/migrations/008_add_node_last_commit_at.sql
+
+ALTER TABLE nodes ADD COLUMN IF NOT EXISTS last_commit_at TIMESTAMP;
@@ -0,0 +1,22 @@
+-- Migration: Rewrite node IDs to the canonical {workspaceID}::{filePath}::{qualifiedName} form
+-- Run once on existing databases:
+--   docker exec mycelium-db-1 psql -U mycelium -d mycelium -f /dev/stdin < internal/db/migrations/009_canonical_node_ids.sql
+
+BEGIN;
+
+-- Let edges and unresolved refs follow their nodes to the new IDs
+ALTER TABLE edges
+    DROP CONSTRAINT IF EXISTS edges_source_id_fkey,
+    DROP CONSTRAINT IF EXISTS edges_target_id_fkey,
+    ADD CONSTRAINT edges_source_id_fkey FOREIGN KEY (source_id) REFERENCES nodes(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    ADD CONSTRAINT edges_target_id_fkey FOREIGN KEY (target_id) REFERENCES nodes(id) ON DELETE CASCADE ON UPDATE CASCADE;
+
+ALTER TABLE unresolved_refs
+    DROP CONSTRAINT IF EXISTS unresolved_refs_source_node_id_fkey,
+    ADD CONSTRAINT unresolved_refs_source_node_id_fkey FOREIGN KEY (source_node_id) REFERENCES nodes(id) ON DELETE CASCADE ON UPDATE CASCADE;
+
+UPDATE nodes
+SET id = workspace_id || '::' || file_path || '::' || COALESCE(qualified_name, name)
+WHERE id <> workspace_id || '::' || file_path || '::' || COALESCE(qualified_name, name);
+
+COMMIT;
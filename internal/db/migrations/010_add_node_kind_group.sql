@@ -0,0 +1,15 @@
+-- Migration: Store a language-neutral kind group next to each node's kind
+-- Run once on existing databases:
+--   docker exec mycelium-db-1 psql -U mycelium -d mycelium -f /dev/stdin < internal/db/migrations/010_add_node_kind_group.sql
+
+ALTER TABLE nodes ADD COLUMN IF NOT EXISTS kind_group TEXT;
+CREATE INDEX IF NOT EXISTS idx_nodes_kind_group ON nodes(kind_group);
+
+-- Backfill with the same mapping as parsers.KindGroup
+UPDATE nodes SET kind_group = CASE
+    WHEN kind IN ('class', 'struct', 'interface', 'type_alias', 'enum', 'type', 'input', 'union', 'scalar') THEN 'type'
+    WHEN kind IN ('function', 'method') THEN 'callable'
+    WHEN kind IN ('field', 'variable', 'const') THEN 'value'
+    WHEN kind IN ('package', 'module') THEN 'module'
+END
+WHERE kind_group IS NULL;
@@ -0,0 +1,12 @@
+-- Migration: Flag nodes from machine-generated files so context expansion can skip them
+-- Run once on existing databases:
+--   docker exec mycelium-db-1 psql -U mycelium -d mycelium -f /dev/stdin < internal/db/migrations/011_add_node_generated.sql
+
+ALTER TABLE nodes ADD COLUMN IF NOT EXISTS generated BOOLEAN NOT NULL DEFAULT false;
+
+-- Backfill files named like codegen output (parsers.IsGeneratedPath). Files
+-- recognized by a marker comment are flagged on their next forced reindex.
+UPDATE nodes SET generated = true
+WHERE NOT generated
+  AND (file_path ~ '(\.pb\.go|\.pb\.gw\.go|_gen\.go|\.gen\.go|\.gen\.tsx?|\.generated\.tsx?|_pb\.js|_pb\.d\.ts)$'
+       OR file_path ~ '(^|/)zz_generated[^/]*$');
@@ -0,0 +1,13 @@
+-- Migration: Store an optional signature-only embedding next to the full one
+-- Run once on existing databases:
+--   docker exec mycelium-db-1 psql -U mycelium -d mycelium -f /dev/stdin < internal/db/migrations/012_add_node_signature_embedding.sql
+
+ALTER TABLE nodes ADD COLUMN IF NOT EXISTS embedding_sig vector(1536);
+
+-- Match idx_nodes_embedding's ops class if SIMILARITY_METRIC isn't cosine;
+-- myc serve / myc mcp rebuild it on startup either way.
+CREATE INDEX IF NOT EXISTS idx_nodes_embedding_sig ON nodes
+    USING ivfflat (embedding_sig vector_cosine_ops)
+    WITH (lists = 100);
+
+-- Vectors are filled in by the next index run with EMBED_SIGNATURES=true.
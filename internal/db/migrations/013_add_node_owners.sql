@@ -0,0 +1,7 @@
+-- Migration: Store each node's CODEOWNERS owners
+-- Run once on existing databases:
+--   docker exec mycelium-db-1 psql -U mycelium -d mycelium -f /dev/stdin < internal/db/migrations/013_add_node_owners.sql
+
+ALTER TABLE nodes ADD COLUMN IF NOT EXISTS owners TEXT[];
+
+-- Owners are filled in by the next index run of each source.
@@ -0,0 +1,12 @@
+-- Migration: Index a source's git branches into separate workspaces
+-- Run once on existing databases:
+--   docker exec mycelium-db-1 psql -U mycelium -d mycelium -f /dev/stdin < internal/db/migrations/014_add_workspace_branch.sql
+
+ALTER TABLE workspaces ADD COLUMN IF NOT EXISTS branch TEXT;
+ALTER TABLE workspaces ADD COLUMN IF NOT EXISTS last_indexed_commit TEXT;
+ALTER TABLE workspaces ADD COLUMN IF NOT EXISTS last_indexed_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_workspaces_branch ON workspaces(project_id, branch);
+
+-- Existing workspaces keep branch NULL; with BRANCH_WORKSPACES on, the next
+-- index run of each source creates a workspace for its checked-out branch.
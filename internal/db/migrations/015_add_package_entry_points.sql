@@ -0,0 +1,9 @@
+-- Migration: Store each package's entry points for deep-import checks
+-- Run once on existing databases:
+--   docker exec mycelium-db-1 psql -U mycelium -d mycelium -f /dev/stdin < internal/db/migrations/015_add_package_entry_points.sql
+
+ALTER TABLE packages ADD COLUMN IF NOT EXISTS entry_points TEXT[];
+
+-- Entry points, and imports edges between files of the same source, are
+-- written by the next index run that stores the source's graph. Run a forced
+-- reindex before relying on GetDeepImportViolations.
@@ -0,0 +1,9 @@
+-- Migration: Store a structural hash per node for exact duplicate detection
+-- Run once on existing databases:
+--   docker exec mycelium-db-1 psql -U mycelium -d mycelium -f /dev/stdin < internal/db/migrations/016_add_node_structural_hash.sql
+
+ALTER TABLE nodes ADD COLUMN IF NOT EXISTS structural_hash TEXT;
+CREATE INDEX IF NOT EXISTS idx_nodes_structural_hash ON nodes(structural_hash);
+
+-- Existing nodes get a hash when their file is next parsed. Run a forced
+-- reindex before relying on FindStructuralDuplicates.
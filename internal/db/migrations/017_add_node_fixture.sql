@@ -0,0 +1,8 @@
+-- Migration: Mark nodes parsed from test fixture and mock directories
+-- Run once on existing databases:
+--   docker exec mycelium-db-1 psql -U mycelium -d mycelium -f /dev/stdin < internal/db/migrations/017_add_node_fixture.sql
+
+ALTER TABLE nodes ADD COLUMN IF NOT EXISTS fixture BOOLEAN NOT NULL DEFAULT false;
+
+-- With the default FIXTURES=skip, nodes already indexed from fixture
+-- directories are deleted as stale on the next forced reindex.
@@ -0,0 +1,35 @@
+-- Migration: Tombstone tables for SOFT_DELETE
+-- Run once on existing databases:
+--   docker exec mycelium-db-1 psql -U mycelium -d mycelium -f /dev/stdin < internal/db/migrations/018_add_tombstones.sql
+
+-- Tombstones of nodes, and the edges touching them, removed as stale while
+-- SOFT_DELETE is on. Kept apart from nodes/edges so live queries never see
+-- them; a node deleted, re-added, and deleted again has one row per deletion.
+CREATE TABLE IF NOT EXISTS deleted_nodes (
+    id TEXT NOT NULL,
+    workspace_id TEXT NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    file_path TEXT NOT NULL,
+    name TEXT NOT NULL,
+    qualified_name TEXT,
+    kind TEXT NOT NULL,
+    signature TEXT,
+    start_line INTEGER,
+    end_line INTEGER,
+    source_code TEXT,
+    docstring TEXT,
+    body_hash TEXT,
+    deleted_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id, deleted_at)
+);
+
+CREATE TABLE IF NOT EXISTS deleted_edges (
+    workspace_id TEXT NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    source_id TEXT NOT NULL,
+    target_id TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    line_number INTEGER,
+    deleted_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_deleted_nodes_workspace ON deleted_nodes(workspace_id, deleted_at);
+CREATE INDEX IF NOT EXISTS idx_deleted_edges_workspace ON deleted_edges(workspace_id, deleted_at);
@@ -0,0 +1,8 @@
+-- Migration: Per-project graph version for cache invalidation
+-- Run once on existing databases:
+--   docker exec mycelium-db-1 psql -U mycelium -d mycelium -f /dev/stdin < internal/db/migrations/019_add_project_graph_version.sql
+
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS graph_version BIGINT NOT NULL DEFAULT 0;
+
+-- Every transaction that writes a project's graph bumps it, so caches in
+-- other processes (the MCP server's assembled contexts) notice the change.
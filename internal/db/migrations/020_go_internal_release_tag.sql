@@ -0,0 +1,8 @@
+-- Migration: Tag Go nodes under internal/ as go_internal rather than internal
+-- Run once on existing databases:
+--   docker exec mycelium-db-1 psql -U mycelium -d mycelium -f /dev/stdin < internal/db/migrations/020_go_internal_release_tag.sql
+
+-- The Go parser reads no doc tags, so every "internal" on a Go file came
+-- from the internal/ path fallback.
+UPDATE nodes SET release_tag = 'go_internal'
+WHERE release_tag = 'internal' AND file_path LIKE '%.go';
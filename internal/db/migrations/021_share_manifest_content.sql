@@ -0,0 +1,102 @@
+-- Migration: Store commit manifests per file, sharing unchanged file content across commits
+-- Run once on existing databases:
+--   docker exec mycelium-db-1 psql -U mycelium -d mycelium -f /dev/stdin < internal/db/migrations/021_share_manifest_content.sql
+
+BEGIN;
+
+-- Set the per-commit tables aside, if this schema still has them
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_schema = current_schema() AND table_name = 'manifest_nodes' AND column_name = 'commit_sha'
+    ) THEN
+        ALTER TABLE manifest_nodes RENAME TO manifest_nodes_v1;
+        ALTER INDEX manifest_nodes_pkey RENAME TO manifest_nodes_v1_pkey;
+        ALTER TABLE manifest_edges RENAME TO manifest_edges_v1;
+        ALTER INDEX manifest_edges_pkey RENAME TO manifest_edges_v1_pkey;
+    END IF;
+END $$;
+
+-- One row per file of a manifest. content_hash names the file's nodes and
+-- outgoing edges in manifest_nodes/manifest_edges, stored once per distinct
+-- content, so a file unchanged between commits costs one row here.
+CREATE TABLE IF NOT EXISTS manifest_files (
+    source_id TEXT NOT NULL,
+    commit_sha TEXT NOT NULL,
+    file_path TEXT NOT NULL,
+    content_hash TEXT NOT NULL,
+    PRIMARY KEY (source_id, commit_sha, file_path),
+    FOREIGN KEY (source_id, commit_sha) REFERENCES index_manifests(source_id, commit_sha) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS manifest_nodes (
+    source_id TEXT NOT NULL REFERENCES project_sources(id) ON DELETE CASCADE,
+    content_hash TEXT NOT NULL,
+    qualified_name TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    body_hash TEXT,
+    PRIMARY KEY (source_id, content_hash, qualified_name)
+);
+
+CREATE TABLE IF NOT EXISTS manifest_edges (
+    source_id TEXT NOT NULL REFERENCES project_sources(id) ON DELETE CASCADE,
+    content_hash TEXT NOT NULL,
+    source_qname TEXT NOT NULL,
+    target_qname TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    PRIMARY KEY (source_id, content_hash, source_qname, target_qname, kind)
+);
+
+CREATE INDEX IF NOT EXISTS idx_manifest_files_content ON manifest_files(source_id, content_hash);
+
+-- Convert the old manifests, hashing each file the way RecordManifest does.
+-- An old edge belongs to the file its source node had in the same manifest.
+DO $$
+BEGIN
+    IF to_regclass('manifest_nodes_v1') IS NULL THEN
+        RETURN;
+    END IF;
+
+    CREATE TEMP TABLE manifest_edge_files ON COMMIT DROP AS
+    SELECT e.*, (
+        SELECT min(n.file_path) FROM manifest_nodes_v1 n
+        WHERE n.source_id = e.source_id AND n.commit_sha = e.commit_sha AND n.qualified_name = e.source_qname
+    ) AS file_path
+    FROM manifest_edges_v1 e;
+
+    INSERT INTO manifest_files (source_id, commit_sha, file_path, content_hash)
+    SELECT fn.source_id, fn.commit_sha, fn.file_path, md5(fn.entries || E'\n\n' || COALESCE(fe.entries, ''))
+    FROM (
+        SELECT n.source_id, n.commit_sha, n.file_path,
+               string_agg(DISTINCT n.qualified_name || E'\t' || n.kind || E'\t' || COALESCE(n.body_hash, ''), E'\n'
+                          ORDER BY n.qualified_name || E'\t' || n.kind || E'\t' || COALESCE(n.body_hash, '')) AS entries
+        FROM manifest_nodes_v1 n
+        GROUP BY n.source_id, n.commit_sha, n.file_path
+    ) fn
+    LEFT JOIN (
+        SELECT e.source_id, e.commit_sha, e.file_path,
+               string_agg(DISTINCT e.source_qname || E'\t' || e.target_qname || E'\t' || e.kind, E'\n'
+                          ORDER BY e.source_qname || E'\t' || e.target_qname || E'\t' || e.kind) AS entries
+        FROM manifest_edge_files e
+        WHERE e.file_path IS NOT NULL
+        GROUP BY e.source_id, e.commit_sha, e.file_path
+    ) fe ON fe.source_id = fn.source_id AND fe.commit_sha = fn.commit_sha AND fe.file_path = fn.file_path
+    ON CONFLICT DO NOTHING;
+
+    INSERT INTO manifest_nodes (source_id, content_hash, qualified_name, kind, body_hash)
+    SELECT n.source_id, mf.content_hash, n.qualified_name, n.kind, n.body_hash
+    FROM manifest_nodes_v1 n
+    JOIN manifest_files mf ON mf.source_id = n.source_id AND mf.commit_sha = n.commit_sha AND mf.file_path = n.file_path
+    ON CONFLICT DO NOTHING;
+
+    INSERT INTO manifest_edges (source_id, content_hash, source_qname, target_qname, kind)
+    SELECT e.source_id, mf.content_hash, e.source_qname, e.target_qname, e.kind
+    FROM manifest_edge_files e
+    JOIN manifest_files mf ON mf.source_id = e.source_id AND mf.commit_sha = e.commit_sha AND mf.file_path = e.file_path
+    ON CONFLICT DO NOTHING;
+
+    DROP TABLE manifest_edges_v1, manifest_nodes_v1;
+END $$;
+
+COMMIT;
@@ -0,0 +1,18 @@
+-- Migration: Flag the members of generated nodes as generated, following contains edges
+-- Run once on existing databases:
+--   docker exec mycelium-db-1 psql -U mycelium -d mycelium -f /dev/stdin < internal/db/migrations/022_backfill_generated_members.sql
+
+-- 011 matched file paths only, which misses a generated class's methods and
+-- fields wherever their own row doesn't carry the match. Walk down from every
+-- generated node instead; package nodes span files, so they don't seed it.
+WITH RECURSIVE generated_tree AS (
+    SELECT id FROM nodes WHERE generated AND kind <> 'package'
+    UNION
+    SELECT e.target_id
+    FROM edges e
+    JOIN generated_tree g ON e.source_id = g.id
+    WHERE e.kind = 'contains'
+)
+UPDATE nodes SET generated = true
+WHERE NOT generated
+  AND id IN (SELECT id FROM generated_tree);
@@ -10,10 +10,44 @@ import (
 )
 
 func NewPool(ctx context.Context, databaseURL string) (*pgxpool.Pool, error) {
+	return NewSchemaPool(ctx, databaseURL, "")
+}
+
+// NewSchemaPool is NewPool with every connection's search_path set to schema
+// then ExtensionSchema, so all the unqualified queries in the indexer and
+// engine read and write that schema's tables and nothing else. public is
+// left off, so a table missing from the schema fails loudly instead of
+// falling through to the shared one, unless pgvector is installed there:
+// the schema holding pgvector always ends the path. An empty schema keeps
+// the default search_path, plus ExtensionSchema. See EnsureSchema for
+// creating one.
+func NewSchemaPool(ctx context.Context, databaseURL, schema string) (*pgxpool.Pool, error) {
 	config, err := pgxpool.ParseConfig(databaseURL)
 	if err != nil {
 		return nil, fmt.Errorf("parsing database URL: %w", err)
 	}
+	if schema != "" {
+		if err := ValidateSchemaName(schema); err != nil {
+			return nil, err
+		}
+	}
+
+	// The path is a startup parameter, so the extension is located over a
+	// connection of its own before the pool opens any
+	conn, err := pgx.ConnectConfig(ctx, config.ConnConfig.Copy())
+	if err != nil {
+		return nil, fmt.Errorf("connecting to database: %w", err)
+	}
+	ext, err := vectorSchema(ctx, conn)
+	conn.Close(ctx)
+	if err != nil {
+		return nil, err
+	}
+	searchPath := `"$user", public, ` + extensionSearchPath(ext)
+	if schema != "" {
+		searchPath = schema + ", " + extensionSearchPath(ext)
+	}
+	config.ConnConfig.RuntimeParams["search_path"] = searchPath
 
 	// Register pgvector types so pgx can encode/decode vector columns
 	config.AfterConnect = func(ctx context.Context, conn *pgx.Conn) error {
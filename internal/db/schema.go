@@ -0,0 +1,269 @@
+package db
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// schemaDDL creates every table and index. Docker applies it to the default
+// schema on first start; EnsureSchema applies it to dedicated ones.
+//
+//go:embed schema.sql
+var schemaDDL string
+
+var schemaNamePattern = regexp.MustCompile(`^[a-z_][a-z0-9_]{0,62}$`)
+
+// ValidateSchemaName checks that name is a plain lowercase Postgres
+// identifier, so it can be spliced into DDL and search_path unquoted.
+func ValidateSchemaName(name string) error {
+	if !schemaNamePattern.MatchString(name) || strings.HasPrefix(name, "pg_") {
+		return fmt.Errorf("invalid schema name %q: want lowercase letters, digits, and underscores, not starting with a digit or pg_", name)
+	}
+	return nil
+}
+
+// ExtensionSchema holds the pgvector extension when mycelium installs it,
+// shared by every schema without putting public on their search_path.
+const ExtensionSchema = "extensions"
+
+// rowQuerier is what vectorSchema needs of a pool, connection, or
+// transaction.
+type rowQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// vectorSchema returns the schema pgvector is installed in, or "" before it
+// is installed.
+func vectorSchema(ctx context.Context, q rowQuerier) (string, error) {
+	var schema string
+	err := q.QueryRow(ctx,
+		`SELECT COALESCE((SELECT extnamespace::regnamespace::text FROM pg_extension WHERE extname = 'vector'), '')`,
+	).Scan(&schema)
+	if err != nil {
+		return "", fmt.Errorf("locating vector extension: %w", err)
+	}
+	return schema, nil
+}
+
+// extensionSearchPath is the end of a search_path that resolves pgvector's
+// types and operators: ExtensionSchema, then ext, the schema pgvector is
+// installed in, when it's another one such as public.
+func extensionSearchPath(ext string) string {
+	if ext == "" || ext == ExtensionSchema {
+		return ExtensionSchema
+	}
+	return ExtensionSchema + ", " + ext
+}
+
+// EnsureSchema creates schema with the full set of mycelium tables unless it
+// already has them, then applies any migrations it's missing (see Migrate).
+// The pgvector extension is created in ExtensionSchema first if the
+// database doesn't have it yet; an existing install stays where it is.
+func EnsureSchema(ctx context.Context, pool *pgxpool.Pool, schema string) error {
+	if err := ValidateSchemaName(schema); err != nil {
+		return err
+	}
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := ensureSchema(ctx, tx, schema); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("committing schema %s: %w", schema, err)
+	}
+	return Migrate(ctx, pool, schema)
+}
+
+// ensureSchema creates schema and its tables if they don't exist. Tables it
+// creates are current, so every migration is recorded as applied to them.
+func ensureSchema(ctx context.Context, tx pgx.Tx, schema string) error {
+	ext, err := ensureVectorExtension(ctx, tx)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `CREATE SCHEMA IF NOT EXISTS `+schema); err != nil {
+		return fmt.Errorf("creating schema %s: %w", schema, err)
+	}
+
+	var exists bool
+	if err := tx.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM information_schema.tables WHERE table_schema = $1 AND table_name = 'nodes')`, schema,
+	).Scan(&exists); err != nil {
+		return fmt.Errorf("checking schema %s: %w", schema, err)
+	}
+	if exists {
+		return nil
+	}
+
+	if _, err := tx.Exec(ctx, `SET LOCAL search_path TO `+schema+`, `+extensionSearchPath(ext)); err != nil {
+		return fmt.Errorf("switching to schema %s: %w", schema, err)
+	}
+	if _, err := tx.Exec(ctx, schemaDDL); err != nil {
+		return fmt.Errorf("creating tables in schema %s: %w", schema, err)
+	}
+	if _, err := tx.Exec(ctx, `SET LOCAL search_path TO DEFAULT`); err != nil {
+		return fmt.Errorf("restoring search_path: %w", err)
+	}
+	return markMigrated(ctx, tx, schema)
+}
+
+// ensureVectorExtension returns the schema pgvector is installed in,
+// installing it in ExtensionSchema first if the database has none. An
+// existing install, e.g. in public by schema.sql, is left alone: moving it
+// would break every other client of the database expecting it there, and
+// only the extension's owner may.
+func ensureVectorExtension(ctx context.Context, tx pgx.Tx) (string, error) {
+	current, err := vectorSchema(ctx, tx)
+	if err != nil || current != "" {
+		return current, err
+	}
+	if _, err := tx.Exec(ctx, `CREATE SCHEMA IF NOT EXISTS `+ExtensionSchema); err != nil {
+		return "", fmt.Errorf("creating schema %s: %w", ExtensionSchema, err)
+	}
+	if _, err := tx.Exec(ctx, `CREATE EXTENSION IF NOT EXISTS vector SCHEMA `+ExtensionSchema); err != nil {
+		return "", fmt.Errorf("creating vector extension: %w", err)
+	}
+	return ExtensionSchema, nil
+}
+
+// projectTables lists the tables holding a project's data in foreign key
+// order, each with the condition selecting the project's rows ($1 is the
+// project ID, {schema} the schema being read). The manifest tables hang off
+// project_sources.
+var projectTables = []struct{ name, where string }{
+	{"projects", `id = $1`},
+	{"project_sources", `project_id = $1`},
+	{"workspaces", `project_id = $1`},
+	{"packages", `workspace_id IN (SELECT id FROM {schema}.workspaces WHERE project_id = $1)`},
+	{"nodes", `workspace_id IN (SELECT id FROM {schema}.workspaces WHERE project_id = $1)`},
+	{"edges", `source_id IN (SELECT n.id FROM {schema}.nodes n JOIN {schema}.workspaces w ON n.workspace_id = w.id WHERE w.project_id = $1)`},
+	{"unresolved_refs", `source_node_id IN (SELECT n.id FROM {schema}.nodes n JOIN {schema}.workspaces w ON n.workspace_id = w.id WHERE w.project_id = $1)`},
+	{"deleted_nodes", `workspace_id IN (SELECT id FROM {schema}.workspaces WHERE project_id = $1)`},
+	{"deleted_edges", `workspace_id IN (SELECT id FROM {schema}.workspaces WHERE project_id = $1)`},
+	{"index_manifests", `source_id IN (SELECT id FROM {schema}.project_sources WHERE project_id = $1)`},
+	{"manifest_files", `source_id IN (SELECT id FROM {schema}.project_sources WHERE project_id = $1)`},
+	{"manifest_nodes", `source_id IN (SELECT id FROM {schema}.project_sources WHERE project_id = $1)`},
+	{"manifest_edges", `source_id IN (SELECT id FROM {schema}.project_sources WHERE project_id = $1)`},
+}
+
+// ProjectTables returns the names of the tables MoveProjectToSchema copies,
+// in the order it copies them.
+func ProjectTables() []string {
+	names := make([]string, len(projectTables))
+	for i, t := range projectTables {
+		names[i] = t.name
+	}
+	return names
+}
+
+// MoveProjectToSchema is the migration path from the shared schema to a
+// dedicated one: it creates the target schema if needed, copies every row of
+// the project from the "from" schema (usually "public"), and deletes the
+// originals, all in one transaction. Both schemas are migrated first.
+// Columns are matched by name, so a source schema built up by migrations,
+// with columns in a different order, copies cleanly; generated columns and
+// serial IDs are recomputed. Edges are selected by their source node;
+// cross-source resolution only links nodes within one project, so their
+// targets always move too. Returns the rows copied per table.
+func MoveProjectToSchema(ctx context.Context, pool *pgxpool.Pool, projectID, from, to string) (map[string]int64, error) {
+	for _, s := range []string{from, to} {
+		if err := ValidateSchemaName(s); err != nil {
+			return nil, err
+		}
+	}
+	if from == to {
+		return nil, fmt.Errorf("project is already in schema %s", to)
+	}
+
+	// Rows are copied column by column, so both sides need every migration
+	if err := Migrate(ctx, pool, from); err != nil {
+		return nil, err
+	}
+	if err := EnsureSchema(ctx, pool, to); err != nil {
+		return nil, err
+	}
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var found bool
+	if err := tx.QueryRow(ctx, fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM %s.projects WHERE id = $1)`, from), projectID).Scan(&found); err != nil {
+		return nil, fmt.Errorf("looking up project %s: %w", projectID, err)
+	}
+	if !found {
+		return nil, fmt.Errorf("project %s not found in schema %s", projectID, from)
+	}
+
+	copied := make(map[string]int64, len(projectTables))
+	for _, t := range projectTables {
+		cols, err := copyableColumns(ctx, tx, to, t.name)
+		if err != nil {
+			return nil, err
+		}
+		list := strings.Join(cols, ", ")
+		where := strings.ReplaceAll(t.where, "{schema}", from)
+		tag, err := tx.Exec(ctx, fmt.Sprintf(
+			`INSERT INTO %s.%s (%s) SELECT %s FROM %s.%s WHERE %s`,
+			to, t.name, list, list, from, t.name, where,
+		), projectID)
+		if err != nil {
+			return nil, fmt.Errorf("copying %s: %w", t.name, err)
+		}
+		copied[t.name] = tag.RowsAffected()
+	}
+
+	// Everything else cascades from the project row
+	if _, err := tx.Exec(ctx, fmt.Sprintf(`DELETE FROM %s.projects WHERE id = $1`, from), projectID); err != nil {
+		return nil, fmt.Errorf("deleting project %s from schema %s: %w", projectID, from, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("committing move: %w", err)
+	}
+	return copied, nil
+}
+
+// copyableColumns returns the columns of schema.table that an INSERT may
+// set: everything except generated columns and serial IDs.
+func copyableColumns(ctx context.Context, tx pgx.Tx, schema, table string) ([]string, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT column_name FROM information_schema.columns
+		WHERE table_schema = $1 AND table_name = $2
+		  AND is_generated = 'NEVER'
+		  AND COALESCE(column_default, '') NOT LIKE 'nextval(%'
+		ORDER BY ordinal_position`, schema, table)
+	if err != nil {
+		return nil, fmt.Errorf("listing columns of %s.%s: %w", schema, table, err)
+	}
+	defer rows.Close()
+
+	var cols []string
+	for rows.Next() {
+		var c string
+		if err := rows.Scan(&c); err != nil {
+			return nil, fmt.Errorf("scanning column of %s.%s: %w", schema, table, err)
+		}
+		cols = append(cols, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(cols) == 0 {
+		return nil, fmt.Errorf("table %s.%s not found", schema, table)
+	}
+	return cols, nil
+}
@@ -6,6 +6,8 @@ CREATE TABLE projects (
     name TEXT NOT NULL,
     description TEXT,
     settings JSONB DEFAULT '{}',
+    -- bumped by every transaction that writes the project's graph
+    graph_version BIGINT NOT NULL DEFAULT 0,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
@@ -33,6 +35,9 @@ CREATE TABLE workspaces (
     path TEXT NOT NULL,
     workspace_type TEXT,
     package_manager TEXT,
+    branch TEXT, -- git branch with BRANCH_WORKSPACES; NULL for the source's single workspace
+    last_indexed_commit TEXT, -- per-branch change detection; project_sources tracks the last run overall
+    last_indexed_at TIMESTAMP,
     indexed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
@@ -43,18 +48,20 @@ CREATE TABLE packages (
     name TEXT NOT NULL,
     path TEXT NOT NULL,
     version TEXT,
+    entry_points TEXT[], -- source-relative files other packages may import it through; "*" wildcards from package.json exports
     indexed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
 -- Every code symbol
 CREATE TABLE nodes (
-    id TEXT PRIMARY KEY,
+    id TEXT PRIMARY KEY, -- {workspace_id}::{file_path}::{qualified_name}
     workspace_id TEXT NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
     package_id TEXT REFERENCES packages(id) ON DELETE SET NULL,
     file_path TEXT NOT NULL,
     name TEXT NOT NULL,
     qualified_name TEXT,
     kind TEXT NOT NULL,
+    kind_group TEXT, -- language-neutral kind: type, callable, value, or module (parsers.KindGroup)
     language TEXT,
     signature TEXT,
     start_line INTEGER,
@@ -62,17 +69,29 @@ CREATE TABLE nodes (
     source_code TEXT,
     docstring TEXT,
     body_hash TEXT,
-    embedding vector(1536),
+    structural_hash TEXT, -- hash of the AST shape with identifiers, comments, and whitespace left out; NULL for non-code nodes
+    modifiers TEXT[],
+    exported BOOLEAN NOT NULL DEFAULT false,
+    release_tag TEXT, -- "public", "beta", "alpha", "internal" from doc tags, "go_internal" under a Go internal/ directory; NULL when untagged
+    deprecated BOOLEAN NOT NULL DEFAULT false,
+    generated BOOLEAN NOT NULL DEFAULT false, -- from a machine-generated file; skipped by context expansion
+    fixture BOOLEAN NOT NULL DEFAULT false, -- from a test fixture or mock directory indexed with FIXTURES=tag
+    owners TEXT[], -- CODEOWNERS owners of the node's file (@org/team, @user, email); NULL when unowned
+    embedding vector(1536), -- signature + docstring + body
+    embedding_sig vector(1536), -- signature only; NULL unless EMBED_SIGNATURES is on
+    renamed_from TEXT, -- previous node ID when detected as moved/renamed (not an FK; the old node is deleted)
+    last_commit_at TIMESTAMP, -- last git commit touching the node's file (UTC); NULL outside git
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
 -- Relationships between nodes
 CREATE TABLE edges (
-    source_id TEXT NOT NULL REFERENCES nodes(id) ON DELETE CASCADE,
-    target_id TEXT NOT NULL REFERENCES nodes(id) ON DELETE CASCADE,
+    source_id TEXT NOT NULL REFERENCES nodes(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    target_id TEXT NOT NULL REFERENCES nodes(id) ON DELETE CASCADE ON UPDATE CASCADE,
     kind TEXT NOT NULL,
     weight FLOAT DEFAULT 1.0,
     line_number INTEGER,
+    call_sites INTEGER[], -- every line the edge occurs on (e.g. each call of the same callee); NULL means just line_number
     metadata JSONB,
     PRIMARY KEY (source_id, target_id, kind)
 );
@@ -80,33 +99,113 @@ CREATE TABLE edges (
 -- Imports/calls that couldn't be resolved
 CREATE TABLE unresolved_refs (
     id SERIAL PRIMARY KEY,
-    source_node_id TEXT NOT NULL REFERENCES nodes(id) ON DELETE CASCADE,
+    source_node_id TEXT NOT NULL REFERENCES nodes(id) ON DELETE CASCADE ON UPDATE CASCADE,
     raw_import TEXT NOT NULL,
     kind TEXT NOT NULL,
     line_number INTEGER,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
+-- Tombstones of nodes, and the edges touching them, removed as stale while
+-- SOFT_DELETE is on. Kept apart from nodes/edges so live queries never see
+-- them; a node deleted, re-added, and deleted again has one row per deletion.
+CREATE TABLE deleted_nodes (
+    id TEXT NOT NULL,
+    workspace_id TEXT NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    file_path TEXT NOT NULL,
+    name TEXT NOT NULL,
+    qualified_name TEXT,
+    kind TEXT NOT NULL,
+    signature TEXT,
+    start_line INTEGER,
+    end_line INTEGER,
+    source_code TEXT,
+    docstring TEXT,
+    body_hash TEXT,
+    deleted_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id, deleted_at)
+);
+
+CREATE TABLE deleted_edges (
+    workspace_id TEXT NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    source_id TEXT NOT NULL,
+    target_id TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    line_number INTEGER,
+    deleted_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Per-commit snapshot of a source's graph, for diffing index runs.
+-- The live nodes/edges tables are overwritten in place, so history lives here.
+CREATE TABLE index_manifests (
+    source_id TEXT NOT NULL REFERENCES project_sources(id) ON DELETE CASCADE,
+    commit_sha TEXT NOT NULL,
+    recorded_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (source_id, commit_sha)
+);
+
+-- One row per file of a manifest. content_hash names the file's nodes and
+-- outgoing edges in manifest_nodes/manifest_edges, stored once per distinct
+-- content, so a file unchanged between commits costs one row here.
+CREATE TABLE manifest_files (
+    source_id TEXT NOT NULL,
+    commit_sha TEXT NOT NULL,
+    file_path TEXT NOT NULL,
+    content_hash TEXT NOT NULL,
+    PRIMARY KEY (source_id, commit_sha, file_path),
+    FOREIGN KEY (source_id, commit_sha) REFERENCES index_manifests(source_id, commit_sha) ON DELETE CASCADE
+);
+
+CREATE TABLE manifest_nodes (
+    source_id TEXT NOT NULL REFERENCES project_sources(id) ON DELETE CASCADE,
+    content_hash TEXT NOT NULL,
+    qualified_name TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    body_hash TEXT,
+    PRIMARY KEY (source_id, content_hash, qualified_name)
+);
+
+CREATE TABLE manifest_edges (
+    source_id TEXT NOT NULL REFERENCES project_sources(id) ON DELETE CASCADE,
+    content_hash TEXT NOT NULL,
+    source_qname TEXT NOT NULL,
+    target_qname TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    PRIMARY KEY (source_id, content_hash, source_qname, target_qname, kind)
+);
+
 -- Indexes
 CREATE INDEX idx_project_sources_project ON project_sources(project_id);
 CREATE INDEX idx_workspaces_project ON workspaces(project_id);
+CREATE INDEX idx_workspaces_branch ON workspaces(project_id, branch);
 CREATE INDEX idx_nodes_workspace ON nodes(workspace_id);
 CREATE INDEX idx_nodes_package ON nodes(package_id);
 CREATE INDEX idx_nodes_file ON nodes(file_path);
 CREATE INDEX idx_nodes_kind ON nodes(kind);
+CREATE INDEX idx_nodes_kind_group ON nodes(kind_group);
 CREATE INDEX idx_nodes_name ON nodes(name);
 CREATE INDEX idx_nodes_qualified ON nodes(qualified_name);
+CREATE INDEX idx_nodes_structural_hash ON nodes(structural_hash);
 
 CREATE INDEX idx_edges_source ON edges(source_id);
 CREATE INDEX idx_edges_target ON edges(target_id);
 CREATE INDEX idx_edges_kind ON edges(kind);
 
+CREATE INDEX idx_deleted_nodes_workspace ON deleted_nodes(workspace_id, deleted_at);
+CREATE INDEX idx_deleted_edges_workspace ON deleted_edges(workspace_id, deleted_at);
+CREATE INDEX idx_manifest_files_content ON manifest_files(source_id, content_hash);
+
 -- Vector similarity search (IVFFlat)
 -- Note: IVFFlat requires rows to exist before building the index.
 -- This index will be created empty and rebuilt after first data load.
+-- The ops class follows SIMILARITY_METRIC; EnsureVectorIndex rebuilds it
+-- with vector_ip_ops or vector_l2_ops when another metric is selected.
 CREATE INDEX idx_nodes_embedding ON nodes
     USING ivfflat (embedding vector_cosine_ops)
     WITH (lists = 100);
+CREATE INDEX idx_nodes_embedding_sig ON nodes
+    USING ivfflat (embedding_sig vector_cosine_ops)
+    WITH (lists = 100);
 
 -- Full-text search support (hybrid search with RRF)
 -- Generated column auto-maintained by Postgres on insert/update.
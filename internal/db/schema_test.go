@@ -0,0 +1,39 @@
+package db
+
+import "testing"
+
+func TestValidateSchemaName(t *testing.T) {
+	for _, name := range []string{"acme", "tenant_42", "_scratch"} {
+		if err := ValidateSchemaName(name); err != nil {
+			t.Errorf("ValidateSchemaName(%q) = %v, want nil", name, err)
+		}
+	}
+	for _, name := range []string{"", "Acme", "42tenant", "pg_catalog", "a-b", "acme; DROP TABLE nodes", "x234567890123456789012345678901234567890123456789012345678901234"} {
+		if err := ValidateSchemaName(name); err == nil {
+			t.Errorf("ValidateSchemaName(%q) = nil, want error", name)
+		}
+	}
+}
+
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		t.Fatalf("loadMigrations: %v", err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("expected embedded migrations")
+	}
+	if migrations[0].name != "002_add_search_vector.sql" {
+		t.Errorf("first migration = %q, want 002_add_search_vector.sql", migrations[0].name)
+	}
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i-1].name >= migrations[i].name {
+			t.Errorf("migrations out of order: %q before %q", migrations[i-1].name, migrations[i].name)
+		}
+	}
+	for _, m := range migrations {
+		if m.sql == "" {
+			t.Errorf("migration %s is empty", m.name)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// GenerateAPISummary renders a package's public API (see GetPublicAPI) as a
+// signature-only document in the style of a declaration file: one section per
+// file, each node's docstring as a doc comment above its signature, and
+// members indented under their type. Source bodies are left out. The output
+// depends only on the indexed graph, so it is stable across runs.
+func GenerateAPISummary(ctx context.Context, pool *pgxpool.Pool, packageID string) (string, error) {
+	var name, path, version string
+	err := pool.QueryRow(ctx,
+		`SELECT name, path, COALESCE(version, '') FROM packages WHERE id = $1`, packageID,
+	).Scan(&name, &path, &version)
+	if err != nil {
+		return "", fmt.Errorf("looking up package %s: %w", packageID, err)
+	}
+
+	nodes, err := GetPublicAPI(ctx, pool, packageID)
+	if err != nil {
+		return "", fmt.Errorf("getting public API of %s: %w", packageID, err)
+	}
+	return formatAPISummary(name, path, version, nodes), nil
+}
+
+// formatAPISummary lays out nodes, which arrive ordered by file path and
+// line. Members store their type's qualified name as file path, so a node
+// whose file path names another node in the set is nested under it.
+func formatAPISummary(name, path, version string, nodes []NodeResult) string {
+	byQName := make(map[string]bool, len(nodes))
+	for _, n := range nodes {
+		byQName[n.QualifiedName] = true
+	}
+
+	var files []string
+	topLevel := make(map[string][]NodeResult)
+	members := make(map[string][]NodeResult)
+	for _, n := range nodes {
+		if byQName[n.FilePath] {
+			members[n.FilePath] = append(members[n.FilePath], n)
+			continue
+		}
+		if _, ok := topLevel[n.FilePath]; !ok {
+			files = append(files, n.FilePath)
+		}
+		topLevel[n.FilePath] = append(topLevel[n.FilePath], n)
+	}
+
+	var b strings.Builder
+	header := name
+	if version != "" {
+		header += "@" + version
+	}
+	fmt.Fprintf(&b, "// API summary: %s\n", header)
+	if path != "" && path != "." {
+		fmt.Fprintf(&b, "// Path: %s\n", path)
+	}
+	if len(files) == 0 {
+		b.WriteString("\n// (no public API)\n")
+		return b.String()
+	}
+
+	for _, file := range files {
+		fmt.Fprintf(&b, "\n// --- %s ---\n", file)
+		for _, n := range topLevel[file] {
+			b.WriteString("\n")
+			writeAPIEntry(&b, n, "")
+			for _, m := range members[n.QualifiedName] {
+				writeAPIEntry(&b, m, "  ")
+			}
+		}
+	}
+	return b.String()
+}
+
+// writeAPIEntry writes one node's doc comment and signature at the given
+// indent. Nodes without a stored signature fall back to "kind name".
+func writeAPIEntry(b *strings.Builder, n NodeResult, indent string) {
+	doc := strings.TrimSpace(n.Docstring)
+	if n.Deprecated && !strings.Contains(doc, "@deprecated") && !strings.Contains(doc, "Deprecated:") {
+		if doc != "" {
+			doc += "\n"
+		}
+		doc += "@deprecated"
+	}
+	if doc != "" {
+		fmt.Fprintf(b, "%s/**\n", indent)
+		for _, line := range strings.Split(doc, "\n") {
+			fmt.Fprintf(b, "%s * %s\n", indent, strings.TrimRight(line, " \t"))
+		}
+		fmt.Fprintf(b, "%s */\n", indent)
+	}
+
+	sig := strings.TrimSpace(n.Signature)
+	if sig == "" {
+		sig = n.Kind + " " + n.QualifiedName
+	}
+	for _, line := range strings.Split(sig, "\n") {
+		fmt.Fprintf(b, "%s%s\n", indent, line)
+	}
+}
@@ -0,0 +1,51 @@
+package engine
+
+import "testing"
+
+func TestFormatAPISummary(t *testing.T) {
+	nodes := []NodeResult{
+		{QualifiedName: "Store", FilePath: "src/store.ts", Kind: "class", Signature: "export class Store", Docstring: "In-memory key/value store."},
+		{QualifiedName: "Store.get", FilePath: "Store", Kind: "method", Signature: "get(key: string): string"},
+		{QualifiedName: "Store.put", FilePath: "Store", Kind: "method", Signature: "put(key: string, value: string): void", Deprecated: true},
+		{QualifiedName: "createStore", FilePath: "src/store.ts", Kind: "function", Signature: "export function createStore(): Store", Docstring: "Creates a store.\n@beta"},
+		{QualifiedName: "VERSION", FilePath: "src/version.ts", Kind: "variable"},
+	}
+
+	got := formatAPISummary("@t/store", "packages/store", "1.2.0", nodes)
+	want := `// API summary: @t/store@1.2.0
+// Path: packages/store
+
+// --- src/store.ts ---
+
+/**
+ * In-memory key/value store.
+ */
+export class Store
+  get(key: string): string
+  /**
+   * @deprecated
+   */
+  put(key: string, value: string): void
+
+/**
+ * Creates a store.
+ * @beta
+ */
+export function createStore(): Store
+
+// --- src/version.ts ---
+
+variable VERSION
+`
+	if got != want {
+		t.Errorf("unexpected summary:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestFormatAPISummary_Empty(t *testing.T) {
+	got := formatAPISummary("root", ".", "", nil)
+	want := "// API summary: root\n\n// (no public API)\n"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
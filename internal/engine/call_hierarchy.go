@@ -0,0 +1,250 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// CallHierarchyItem is one end of a call, shaped like LSP's CallHierarchyItem.
+// StartLine/EndLine span the whole declaration.
+type CallHierarchyItem struct {
+	NodeID        string `json:"nodeId"`
+	Name          string `json:"name"`
+	QualifiedName string `json:"qualifiedName"`
+	Kind          string `json:"kind"`
+	FilePath      string `json:"filePath"`
+	Signature     string `json:"signature,omitempty"`
+	StartLine     int    `json:"startLine"`
+	EndLine       int    `json:"endLine"`
+	SourceAlias   string `json:"sourceAlias,omitempty"`
+}
+
+// CallRange is the position of one call site. Edges only record lines, so
+// StartLine and EndLine are currently always equal.
+type CallRange struct {
+	StartLine int `json:"startLine"`
+	EndLine   int `json:"endLine"`
+}
+
+// IncomingCall mirrors LSP's CallHierarchyIncomingCall: a caller and the call
+// sites inside it that reach the target node.
+type IncomingCall struct {
+	From       CallHierarchyItem `json:"from"`
+	FromRanges []CallRange       `json:"fromRanges"`
+}
+
+// OutgoingCall mirrors LSP's CallHierarchyOutgoingCall: a callee and the call
+// sites inside the source node that reach it. As in LSP, the ranges are
+// relative to the caller, not the callee.
+type OutgoingCall struct {
+	To         CallHierarchyItem `json:"to"`
+	FromRanges []CallRange       `json:"fromRanges"`
+}
+
+// IncomingCalls returns one entry per node that calls nodeID, each with every
+// call site recorded on the "calls" edge.
+func IncomingCalls(ctx context.Context, pool *pgxpool.Pool, nodeID string) ([]IncomingCall, error) {
+	items, ranges, err := queryCallHierarchy(ctx, pool, `
+		SELECT n.id, n.name, COALESCE(n.qualified_name, n.name), n.kind, n.file_path,
+		       COALESCE(n.signature, ''), COALESCE(n.start_line, 0), COALESCE(n.end_line, 0), COALESCE(ps.alias, ''),
+		       COALESCE(e.call_sites, ARRAY[e.line_number])
+		FROM edges e
+		JOIN nodes n ON e.source_id = n.id
+		JOIN workspaces ws ON n.workspace_id = ws.id
+		LEFT JOIN project_sources ps ON ws.source_id = ps.id
+		WHERE e.target_id = $1 AND e.kind = 'calls'
+		ORDER BY n.file_path, n.start_line`, nodeID)
+	if err != nil {
+		return nil, err
+	}
+
+	calls := make([]IncomingCall, len(items))
+	for i := range items {
+		calls[i] = IncomingCall{From: items[i], FromRanges: ranges[i]}
+	}
+	return calls, nil
+}
+
+// OutgoingCalls returns one entry per node that nodeID calls, each with every
+// call site recorded on the "calls" edge.
+func OutgoingCalls(ctx context.Context, pool *pgxpool.Pool, nodeID string) ([]OutgoingCall, error) {
+	items, ranges, err := queryCallHierarchy(ctx, pool, `
+		SELECT n.id, n.name, COALESCE(n.qualified_name, n.name), n.kind, n.file_path,
+		       COALESCE(n.signature, ''), COALESCE(n.start_line, 0), COALESCE(n.end_line, 0), COALESCE(ps.alias, ''),
+		       COALESCE(e.call_sites, ARRAY[e.line_number])
+		FROM edges e
+		JOIN nodes n ON e.target_id = n.id
+		JOIN workspaces ws ON n.workspace_id = ws.id
+		LEFT JOIN project_sources ps ON ws.source_id = ps.id
+		WHERE e.source_id = $1 AND e.kind = 'calls'
+		ORDER BY n.file_path, n.start_line`, nodeID)
+	if err != nil {
+		return nil, err
+	}
+
+	calls := make([]OutgoingCall, len(items))
+	for i := range items {
+		calls[i] = OutgoingCall{To: items[i], FromRanges: ranges[i]}
+	}
+	return calls, nil
+}
+
+// queryCallHierarchy scans counterpart nodes and their call-site lines. The
+// edges primary key already makes each counterpart unique.
+func queryCallHierarchy(ctx context.Context, pool *pgxpool.Pool, sql, nodeID string) ([]CallHierarchyItem, [][]CallRange, error) {
+	rows, err := pool.Query(ctx, sql, nodeID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("querying call hierarchy: %w", err)
+	}
+	defer rows.Close()
+
+	var items []CallHierarchyItem
+	var ranges [][]CallRange
+	for rows.Next() {
+		var it CallHierarchyItem
+		var lines []*int32
+		if err := rows.Scan(&it.NodeID, &it.Name, &it.QualifiedName, &it.Kind, &it.FilePath,
+			&it.Signature, &it.StartLine, &it.EndLine, &it.SourceAlias, &lines); err != nil {
+			return nil, nil, fmt.Errorf("scanning call hierarchy row: %w", err)
+		}
+		items = append(items, it)
+		ranges = append(ranges, callRanges(lines))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("iterating call hierarchy rows: %w", err)
+	}
+	return items, ranges, nil
+}
+
+// callRanges turns call-site lines into ranges, dropping unknown (NULL or 0)
+// positions.
+func callRanges(lines []*int32) []CallRange {
+	out := []CallRange{}
+	for _, l := range lines {
+		if l == nil || *l <= 0 {
+			continue
+		}
+		out = append(out, CallRange{StartLine: int(*l), EndLine: int(*l)})
+	}
+	return out
+}
+
+// CallEdge is one call site found by FindCallersMatching. External callees
+// have no node: Callee is the call as written (axios.get) and CalleeID is
+// empty. Methods report the file of their class.
+type CallEdge struct {
+	CallerID    string `json:"callerId"`
+	CallerQName string `json:"callerQName"`
+	FilePath    string `json:"filePath"`
+	Line        int    `json:"line"`
+	CalleeID    string `json:"calleeId,omitempty"`
+	Callee      string `json:"callee"`
+	External    bool   `json:"external,omitempty"`
+	SourceAlias string `json:"sourceAlias,omitempty"`
+}
+
+// CallerMatchOption customizes FindCallersMatching.
+type CallerMatchOption func(*callerMatchOptions)
+
+type callerMatchOptions struct {
+	externalOnly bool
+}
+
+// ExternalCalleesOnly limits FindCallersMatching to calls into external
+// imports, e.g. every use of a third-party client being replaced.
+func ExternalCalleesOnly() CallerMatchOption {
+	return func(o *callerMatchOptions) {
+		o.externalOnly = true
+	}
+}
+
+// FindCallersMatching returns every call site in the project whose callee
+// matches calleePattern, for refactors like "everything still calling the old
+// getUser". The pattern is a glob over the callee's qualified name: * matches
+// any run of characters and ? any one (UserService.*, *.getUser). A pattern
+// without a dot also matches the callee's last segment, so getUser finds
+// UserService.getUser. Besides resolved calls, calls through a name imported
+// from outside the index (axios.get, or format from date-fns) are matched as
+// written and flagged External. Ordered by source, file, and line.
+func FindCallersMatching(ctx context.Context, pool *pgxpool.Pool, projectID, calleePattern string, opts ...CallerMatchOption) ([]CallEdge, error) {
+	o := &callerMatchOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	like := globToLike(calleePattern)
+	bySegment := !strings.Contains(calleePattern, ".")
+
+	located := `
+		SELECT s.id, COALESCE(s.qualified_name, s.name) AS qname,
+		       CASE WHEN s.kind = 'method' THEN COALESCE(parent.file_path, s.file_path) ELSE s.file_path END AS file_path,
+		       COALESCE(ps.alias, '') AS alias
+		FROM nodes s
+		JOIN workspaces ws ON s.workspace_id = ws.id
+		LEFT JOIN project_sources ps ON ws.source_id = ps.id
+		LEFT JOIN edges c ON s.kind = 'method' AND c.target_id = s.id AND c.kind = 'contains'
+		LEFT JOIN nodes parent ON c.source_id = parent.id
+		WHERE ws.project_id = $1`
+
+	sql := `
+		WITH callers AS (` + located + `)
+		SELECT c.id, c.qname, c.file_path, COALESCE(ur.line_number, 0), '', ur.raw_import, true, c.alias
+		FROM unresolved_refs ur
+		JOIN callers c ON ur.source_node_id = c.id
+		WHERE ur.kind = 'calls'
+		  AND (ur.raw_import LIKE $2 OR ($3 AND regexp_replace(ur.raw_import, '^.*\.', '') LIKE $2))`
+	if !o.externalOnly {
+		sql += `
+		UNION
+		SELECT c.id, c.qname, c.file_path, COALESCE(site, 0), t.id, COALESCE(t.qualified_name, t.name), false, c.alias
+		FROM edges e
+		JOIN callers c ON e.source_id = c.id
+		JOIN nodes t ON e.target_id = t.id
+		CROSS JOIN LATERAL unnest(COALESCE(e.call_sites, ARRAY[e.line_number])) AS site
+		WHERE e.kind = 'calls'
+		  AND (COALESCE(t.qualified_name, t.name) LIKE $2 OR ($3 AND t.name LIKE $2))`
+	}
+	sql += `
+		ORDER BY 8, 3, 4, 6`
+
+	rows, err := pool.Query(ctx, sql, projectID, like, bySegment)
+	if err != nil {
+		return nil, fmt.Errorf("finding matching callers: %w", err)
+	}
+	defer rows.Close()
+
+	calls := []CallEdge{}
+	for rows.Next() {
+		var c CallEdge
+		if err := rows.Scan(&c.CallerID, &c.CallerQName, &c.FilePath, &c.Line, &c.CalleeID, &c.Callee, &c.External, &c.SourceAlias); err != nil {
+			return nil, fmt.Errorf("scanning call edge: %w", err)
+		}
+		calls = append(calls, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating call edges: %w", err)
+	}
+	return calls, nil
+}
+
+// globToLike turns a glob (* and ?) into a LIKE pattern, escaping LIKE's own
+// wildcards so they match literally.
+func globToLike(glob string) string {
+	var b strings.Builder
+	for _, r := range glob {
+		switch r {
+		case '*':
+			b.WriteByte('%')
+		case '?':
+			b.WriteByte('_')
+		case '%', '_', '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,19 @@
+package engine
+
+import "testing"
+
+func TestGlobToLike(t *testing.T) {
+	tests := map[string]string{
+		"getUser":          "getUser",
+		"UserService.*":    "UserService.%",
+		"get?ser":          "get_ser",
+		"100%_done":        `100\%\_done`,
+		`back\slash`:       `back\\slash`,
+		"*.client.*.fetch": "%.client.%.fetch",
+	}
+	for glob, want := range tests {
+		if got := globToLike(glob); got != want {
+			t.Errorf("globToLike(%q) = %q, want %q", glob, got, want)
+		}
+	}
+}
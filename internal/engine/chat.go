@@ -107,15 +107,16 @@ func needsCodeContext(ctx context.Context, client *openai.Client, query string)
 
 // ChatStream assembles context and opens a streaming chat completion.
 // The caller is responsible for reading from Stream and closing it.
-// history contains previous messages in the conversation (oldest first).
-func ChatStream(ctx context.Context, pool *pgxpool.Pool, client *openai.Client, query string, projectID string, model string, maxContextTokens int, history []ChatMessage) (*ChatStreamResult, error) {
+// history contains previous messages in the conversation (oldest first);
+// opts apply to the context assembly.
+func ChatStream(ctx context.Context, pool *pgxpool.Pool, client *openai.Client, query string, projectID string, model string, maxContextTokens int, history []ChatMessage, opts ...AssembleOption) (*ChatStreamResult, error) {
 	var systemContent string
 	var sources []Source
 
 	projectCtx := buildProjectContext(ctx, pool, projectID)
 
 	if needsCodeContext(ctx, client, query) {
-		assembled, err := AssembleContext(ctx, pool, client, query, projectID, maxContextTokens)
+		assembled, err := AssembleContext(ctx, pool, client, query, projectID, maxContextTokens, opts...)
 		if err != nil {
 			return nil, fmt.Errorf("assemble context: %w", err)
 		}
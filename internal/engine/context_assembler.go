@@ -3,8 +3,13 @@ package engine
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	openai "github.com/sashabaranov/go-openai"
@@ -23,12 +28,17 @@ type ContextNode struct {
 	Docstring     string   `json:"docstring,omitempty"`
 	Similarity    float64  `json:"similarity"`
 	Score         float64  `json:"score"`
-	CalledBy   []string `json:"calledBy,omitempty"`
-	Calls      []string `json:"calls,omitempty"`
-	ImportedBy []string `json:"importedBy,omitempty"`
-	Imports    []string `json:"imports,omitempty"`
+	CalledBy      []string `json:"calledBy,omitempty"`
+	Calls         []string `json:"calls,omitempty"`
+	ImportedBy    []string `json:"importedBy,omitempty"`
+	Imports       []string `json:"imports,omitempty"`
 	FullSource    bool     `json:"fullSource"`
 	SourceAlias   string   `json:"sourceAlias,omitempty"`
+	// Annotated is true for the top-ranked nodes whose caller, callee, and
+	// import lines were looked up (see WithAnnotationLimit). The lists of
+	// other nodes are empty because they weren't fetched, not because the
+	// node has no relationships.
+	Annotated bool `json:"annotated,omitempty"`
 }
 
 // AssembledContext is the result of combining semantic + structural search
@@ -38,58 +48,501 @@ type AssembledContext struct {
 	Text       string        `json:"text"`
 	TokenCount int           `json:"tokenCount"`
 	TokenLimit int           `json:"tokenLimit"`
+	// BelowMinScore counts the ranked nodes WithMinIncludedScore left out.
+	BelowMinScore int `json:"belowMinScore,omitempty"`
+}
+
+// AssembleOption customizes context assembly. Options are applied in order
+// over the defaults returned by defaultAssembleOptions.
+type AssembleOption func(*assembleOptions)
+
+type assembleOptions struct {
+	formatter ContextFormatter
+	alpha     float64
+	edgeKinds []string
+	kinds     []string
+
+	expandGenerated bool
+	embedding       string
+	decompose       bool
+	branch          string
+
+	annotationLimit int
+	compact         bool
+
+	recencyBoost    float64
+	recencyHalfLife time.Duration
+	docstringBoost  float64
+	minScore        float64
+
+	cache ContextCache
+}
+
+func defaultAssembleOptions() *assembleOptions {
+	generatedExpansionMu.RLock()
+	defer generatedExpansionMu.RUnlock()
+	return &assembleOptions{
+		formatter:       MarkdownFormatter{},
+		alpha:           DefaultHybridAlpha,
+		annotationLimit: DefaultAnnotationLimit,
+		expandGenerated: generatedExpansionV,
+	}
+}
+
+func resolveAssembleOptions(opts []AssembleOption) *assembleOptions {
+	o := defaultAssembleOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithFormatter selects how the assembled context text is rendered.
+// Defaults to MarkdownFormatter.
+func WithFormatter(f ContextFormatter) AssembleOption {
+	return func(o *assembleOptions) {
+		if f != nil {
+			o.formatter = f
+		}
+	}
+}
+
+// WithEdgeKinds restricts graph expansion around search hits to the given
+// edge kinds — e.g. {"calls"} for call flow, {"uses_type", "implements"} for
+// type impact. Defaults to DefaultTraversalEdgeKinds.
+func WithEdgeKinds(kinds []string) AssembleOption {
+	return func(o *assembleOptions) {
+		if len(kinds) > 0 {
+			o.edgeKinds = kinds
+		}
+	}
+}
+
+// WithKinds restricts the search hits that seed the context to nodes of the
+// given kinds. Entries may be precise kinds ("struct", "method") or
+// language-neutral kind groups ("type", "callable", "value", "module").
+// Nodes reached by graph expansion are not filtered. Defaults to the
+// search scope set by SetSearchKinds.
+func WithKinds(kinds []string) AssembleOption {
+	return func(o *assembleOptions) {
+		o.kinds = kinds
+	}
+}
+
+// WithGeneratedExpansion controls whether graph expansion around search hits
+// walks into nodes from machine-generated files. Off unless
+// SetGeneratedExpansion says otherwise, so stubs and codegen output only
+// appear when they match the query themselves.
+func WithGeneratedExpansion(expand bool) AssembleOption {
+	return func(o *assembleOptions) {
+		o.expandGenerated = expand
+	}
+}
+
+var (
+	generatedExpansionMu sync.RWMutex
+	generatedExpansionV  bool
+)
+
+// SetGeneratedExpansion sets the default WithGeneratedExpansion overrides
+// per call. EXPAND_GENERATED sets it.
+func SetGeneratedExpansion(expand bool) {
+	generatedExpansionMu.Lock()
+	defer generatedExpansionMu.Unlock()
+	generatedExpansionV = expand
+}
+
+// WithSearchEmbedding selects which embedding the search that seeds the
+// context compares the query against: EmbeddingFull, EmbeddingSignature, or
+// EmbeddingAuto to decide from the query's shape. Defaults to EmbeddingFull.
+func WithSearchEmbedding(variant string) AssembleOption {
+	return func(o *assembleOptions) {
+		o.embedding = variant
+	}
+}
+
+// WithBranch seeds the context from the nodes of one branch indexed with
+// BRANCH_WORKSPACES, as OnBranch does for a search. Defaults to each
+// source's default branch (see SetBranchWorkspaces).
+func WithBranch(branch string) AssembleOption {
+	return func(o *assembleOptions) {
+		o.branch = branch
+	}
+}
+
+// DefaultAnnotationLimit is how many of the top-ranked nodes get caller,
+// callee, and import annotations, fetched in four bulk queries.
+// CompactAnnotationLimit is the WithCompactContext default.
+const (
+	DefaultAnnotationLimit = 20
+	CompactAnnotationLimit = 5
+)
+
+// WithAnnotationLimit annotates only the top n ranked nodes with their
+// callers, callees, importers, and imports; 0 annotates none. Defaults to
+// DefaultAnnotationLimit.
+func WithAnnotationLimit(n int) AssembleOption {
+	return func(o *assembleOptions) {
+		o.annotationLimit = max(n, 0)
+	}
+}
+
+// WithCompactContext trims large contexts: only the top
+// CompactAnnotationLimit nodes are annotated (a later WithAnnotationLimit
+// overrides that), and every node below them is rendered signature-only,
+// without docstring or relationship lines. Saves tokens, and some time in
+// the annotation queries.
+func WithCompactContext() AssembleOption {
+	return func(o *assembleOptions) {
+		o.compact = true
+		o.annotationLimit = CompactAnnotationLimit
+	}
+}
+
+// WithQueryDecomposition makes AssembleContext split a compound question
+// into sub-queries with SplitQuery and search each one, as
+// AssembleContextMulti does. Off by default.
+func WithQueryDecomposition() AssembleOption {
+	return func(o *assembleOptions) {
+		o.decompose = true
+	}
+}
+
+// WithAlpha sets the hybrid search weight between keyword (0) and semantic (1)
+// ranking — low for exact symbol lookups, high for conceptual questions.
+// Values outside [0, 1] are clamped. Defaults to DefaultHybridAlpha.
+func WithAlpha(alpha float64) AssembleOption {
+	return func(o *assembleOptions) {
+		o.alpha = clampAlpha(alpha)
+	}
+}
+
+// WithRecencyBoost ranks nodes in recently committed files higher: a node's
+// score is multiplied by 1 + boost for a file committed just now, decaying
+// back towards 1 with the given half-life (DefaultRecencyHalfLife if <= 0).
+// Useful on an active feature branch, where fresh code matters more than
+// stable foundations. Nodes indexed outside git are unaffected. Off by default.
+func WithRecencyBoost(boost float64, halfLife time.Duration) AssembleOption {
+	return func(o *assembleOptions) {
+		o.recencyBoost = max(boost, 0)
+		o.recencyHalfLife = halfLife
+	}
+}
+
+// WithDocstringBoost ranks documented nodes higher: a node's score is
+// multiplied by 1 + boost when it has a meaningful docstring, and divided by
+// it when the docstring is only generated boilerplate or a placeholder (see
+// docstringMultiplier). Authors tend to document the functions they meant to
+// be used. Off by default.
+func WithDocstringBoost(boost float64) AssembleOption {
+	return func(o *assembleOptions) {
+		o.docstringBoost = max(boost, 0)
+	}
+}
+
+// WithMinIncludedScore drops ranked nodes whose combined score is below
+// score before the token budget is spent. A graph-expanded node scores its
+// seed's similarity times a hop weight of 0.35–0.7, so around a modest hit
+// the hop-2 nodes add little and can still crowd out a better hop-1 node on
+// a tight budget. The best-ranked search hit is always kept. Off (0) by
+// default.
+func WithMinIncludedScore(score float64) AssembleOption {
+	return func(o *assembleOptions) {
+		o.minScore = max(score, 0)
+	}
 }
 
 // AssembleContext runs semantic search, expands results via graph traversal,
 // deduplicates, ranks, and produces a formatted context string within the
 // given token budget.
-func AssembleContext(ctx context.Context, pool *pgxpool.Pool, client *openai.Client, query string, projectID string, maxTokens int) (*AssembledContext, error) {
+func AssembleContext(ctx context.Context, pool *pgxpool.Pool, client *openai.Client, query string, projectID string, maxTokens int, opts ...AssembleOption) (*AssembledContext, error) {
+	o := resolveAssembleOptions(opts)
+	queries := []string{query}
+	if o.decompose {
+		if parts := SplitQuery(query); len(parts) > 0 {
+			queries = parts
+		}
+	}
+	return assembleQueries(ctx, pool, client, queries, projectID, maxTokens, o)
+}
+
+// AssembleContextMulti is like AssembleContext for a question that has
+// already been split into sub-queries: each one is embedded and searched on
+// its own, and the hits are merged (keeping each node's best similarity)
+// before graph expansion, so every part of the question seeds the context.
+func AssembleContextMulti(ctx context.Context, pool *pgxpool.Pool, client *openai.Client, queries []string, projectID string, maxTokens int, opts ...AssembleOption) (*AssembledContext, error) {
+	var nonEmpty []string
+	for _, q := range queries {
+		if q = strings.TrimSpace(q); q != "" {
+			nonEmpty = append(nonEmpty, q)
+		}
+	}
+	if len(nonEmpty) == 0 {
+		return nil, fmt.Errorf("no queries given")
+	}
+	return assembleQueries(ctx, pool, client, nonEmpty, projectID, maxTokens, resolveAssembleOptions(opts))
+}
+
+func assembleQueries(ctx context.Context, pool *pgxpool.Pool, client *openai.Client, queries []string, projectID string, maxTokens int, o *assembleOptions) (*AssembledContext, error) {
 	if maxTokens <= 0 {
 		maxTokens = 8000
 	}
+	if o.cache == nil {
+		return assembleContext(ctx, pool, client, queries, projectID, maxTokens, o)
+	}
 
+	version, err := projectIndexVersion(ctx, pool, projectID)
+	if err != nil {
+		slog.Warn("context cache disabled for query", "project", projectID, "error", err)
+		return assembleContext(ctx, pool, client, queries, projectID, maxTokens, o)
+	}
+	// \x1f isn't whitespace, so it survives the key's query normalization
+	// and sub-queries can't collide with a single query of the same words.
+	key := contextCacheKey(projectID, strings.Join(queries, "\x1f"), maxTokens, o, version)
+	if cached, ok := o.cache.Get(ctx, key); ok {
+		slog.Debug("context cache hit", "project", projectID)
+		return cached, nil
+	}
+
+	assembled, err := assembleContext(ctx, pool, client, queries, projectID, maxTokens, o)
+	if err != nil {
+		return nil, err
+	}
+	o.cache.Set(ctx, key, assembled)
+	return assembled, nil
+}
+
+func assembleContext(ctx context.Context, pool *pgxpool.Pool, client *openai.Client, queries []string, projectID string, maxTokens int, o *assembleOptions) (*AssembledContext, error) {
 	nodeCount := getProjectNodeCount(ctx, pool, projectID)
 	searchLimit := dynamicSearchLimit(nodeCount)
 
-	semanticResults, err := HybridSearch(ctx, pool, client, query, projectID, searchLimit, nil)
-	if err != nil {
-		return nil, fmt.Errorf("semantic search: %w", err)
+	var semanticResults []SearchResult
+	if len(queries) == 1 {
+		results, err := HybridSearch(ctx, pool, client, queries[0], projectID, searchLimit, o.kinds, o.alpha, WithEmbedding(o.embedding), OnBranch(o.branch))
+		if err != nil {
+			return nil, fmt.Errorf("semantic search: %w", err)
+		}
+		semanticResults = results
+	} else {
+		vecs, err := indexer.EmbedTexts(ctx, client, queries)
+		if err != nil {
+			return nil, fmt.Errorf("embedding queries: %w", err)
+		}
+		sets := make([][]SearchResult, 0, len(queries))
+		for i, q := range queries {
+			results, err := HybridSearchWithVector(ctx, pool, vecs[i], q, projectID, searchLimit, o.kinds, o.alpha, WithEmbedding(o.embedding), OnBranch(o.branch))
+			if err != nil {
+				return nil, fmt.Errorf("semantic search for %q: %w", q, err)
+			}
+			sets = append(sets, results)
+		}
+		semanticResults = mergeSearchResults(sets)
 	}
 
 	if len(semanticResults) == 0 {
 		return &AssembledContext{
 			Nodes:      []ContextNode{},
-			Text:       "No relevant code found.",
+			Text:       o.formatter.FormatContext(nil),
 			TokenCount: 0,
 			TokenLimit: maxTokens,
 		}, nil
 	}
 
-	return assembleFromResults(ctx, pool, semanticResults, maxTokens)
+	return assembleFromResults(ctx, pool, semanticResults, maxTokens, o)
 }
 
 // AssembleContextWithVector is like AssembleContext but uses a pre-computed
 // query vector instead of calling the OpenAI API. Useful for testing.
-func AssembleContextWithVector(ctx context.Context, pool *pgxpool.Pool, queryVec []float32, projectID string, maxTokens int) (*AssembledContext, error) {
+func AssembleContextWithVector(ctx context.Context, pool *pgxpool.Pool, queryVec []float32, projectID string, maxTokens int, opts ...AssembleOption) (*AssembledContext, error) {
+	return AssembleContextWithVectors(ctx, pool, [][]float32{queryVec}, projectID, maxTokens, opts...)
+}
+
+// AssembleContextWithVectors is like AssembleContextMulti but uses one
+// pre-computed vector per sub-query instead of calling the OpenAI API.
+func AssembleContextWithVectors(ctx context.Context, pool *pgxpool.Pool, queryVecs [][]float32, projectID string, maxTokens int, opts ...AssembleOption) (*AssembledContext, error) {
 	if maxTokens <= 0 {
 		maxTokens = 8000
 	}
+	o := resolveAssembleOptions(opts)
 
-	semanticResults, err := SemanticSearchWithVector(ctx, pool, queryVec, projectID, 10, nil)
-	if err != nil {
-		return nil, fmt.Errorf("semantic search: %w", err)
+	sets := make([][]SearchResult, 0, len(queryVecs))
+	for _, vec := range queryVecs {
+		results, err := SemanticSearchWithVector(ctx, pool, vec, projectID, 10, o.kinds, WithEmbedding(o.embedding), OnBranch(o.branch))
+		if err != nil {
+			return nil, fmt.Errorf("semantic search: %w", err)
+		}
+		sets = append(sets, results)
 	}
+	semanticResults := mergeSearchResults(sets)
 
 	if len(semanticResults) == 0 {
 		return &AssembledContext{
 			Nodes:      []ContextNode{},
-			Text:       "No relevant code found.",
+			Text:       o.formatter.FormatContext(nil),
+			TokenCount: 0,
+			TokenLimit: maxTokens,
+		}, nil
+	}
+
+	return assembleFromResults(ctx, pool, semanticResults, maxTokens, o)
+}
+
+// maxChangeSeeds caps how many nodes of the changed files seed
+// AssembleContextForChange. Each seed costs a handful of graph queries, and
+// a sweeping diff would otherwise spend the budget on its own nodes alone.
+const maxChangeSeeds = 50
+
+// AssembleContextForChange assembles the context around a changeset instead
+// of a query: the nodes defined in changedFiles (paths relative to their
+// source root, matched in every source of the project) seed the context at
+// full similarity, then graph expansion, ranking, and the token budget work
+// as in AssembleContext. No embedding is needed, so it suits CI and review
+// bots that have a diff rather than a question. WithKinds narrows the seeds;
+// options that only affect search are ignored. Seeds are taken in the order
+// of changedFiles, then by line, up to maxChangeSeeds.
+func AssembleContextForChange(ctx context.Context, pool *pgxpool.Pool, projectID string, changedFiles []string, maxTokens int, opts ...AssembleOption) (*AssembledContext, error) {
+	var files []string
+	for _, f := range changedFiles {
+		if f = strings.TrimPrefix(filepath.ToSlash(strings.TrimSpace(f)), "./"); f != "" {
+			files = append(files, f)
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no changed files given")
+	}
+	if maxTokens <= 0 {
+		maxTokens = 8000
+	}
+	o := resolveAssembleOptions(opts)
+
+	seeds, err := changedFileNodes(ctx, pool, projectID, files, o.kinds, o.branch)
+	if err != nil {
+		return nil, err
+	}
+	if len(seeds) == 0 {
+		return &AssembledContext{
+			Nodes:      []ContextNode{},
+			Text:       o.formatter.FormatContext(nil),
 			TokenCount: 0,
 			TokenLimit: maxTokens,
 		}, nil
 	}
 
-	return assembleFromResults(ctx, pool, semanticResults, maxTokens)
+	return assembleFromResults(ctx, pool, seeds, maxTokens, o)
+}
+
+// changedFileNodes returns the nodes defined in files as search results
+// with similarity 1, methods matched through the file of their class. Only
+// branch's workspaces are read, or the default branch's when it is empty.
+func changedFileNodes(ctx context.Context, pool *pgxpool.Pool, projectID string, files, kinds []string, branch string) ([]SearchResult, error) {
+	sql := `
+		SELECT n.id, COALESCE(n.qualified_name, n.name), COALESCE(parent.file_path, n.file_path),
+		       n.kind, COALESCE(n.kind_group, ''), COALESCE(n.signature, ''), COALESCE(n.source_code, ''),
+		       COALESCE(n.docstring, ''), COALESCE(n.release_tag, ''), COALESCE(ps.alias, '')
+		FROM nodes n
+		JOIN workspaces ws ON n.workspace_id = ws.id
+		LEFT JOIN project_sources ps ON ws.source_id = ps.id
+		LEFT JOIN edges c ON n.kind = 'method' AND c.target_id = n.id AND c.kind = 'contains'
+		LEFT JOIN nodes parent ON c.source_id = parent.id
+		JOIN unnest($2::text[]) WITH ORDINALITY AS f(path, pos) ON f.path = COALESCE(parent.file_path, n.file_path)
+		WHERE ws.project_id = $1`
+	args := []any{projectID, files}
+	if len(kinds) > 0 {
+		sql += kindFilterSQL(len(args) + 1)
+		args = append(args, kinds)
+	}
+	branchSQL, branchArgs := branchScopeSQL(branch, len(args)+1)
+	sql += branchSQL
+	args = append(args, branchArgs...)
+	sql += fmt.Sprintf(`
+		ORDER BY f.pos, n.start_line, n.id
+		LIMIT %d`, maxChangeSeeds)
+
+	rows, err := pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("querying changed nodes: %w", err)
+	}
+	defer rows.Close()
+
+	var results []SearchResult
+	for rows.Next() {
+		r := SearchResult{Similarity: 1, SemanticScore: 1}
+		if err := rows.Scan(&r.NodeID, &r.QualifiedName, &r.FilePath, &r.Kind, &r.KindGroup, &r.Signature, &r.SourceCode, &r.Docstring, &r.ReleaseTag, &r.SourceAlias); err != nil {
+			return nil, fmt.Errorf("scanning changed node: %w", err)
+		}
+		results = append(results, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating changed nodes: %w", err)
+	}
+	return results, nil
+}
+
+// mergeSearchResults combines the hits of several sub-queries into one seed
+// set, keeping each node once with its highest similarity, ordered by
+// similarity and then qualified name. A single set is returned as is.
+func mergeSearchResults(sets [][]SearchResult) []SearchResult {
+	if len(sets) == 1 {
+		return sets[0]
+	}
+	index := make(map[string]int)
+	var merged []SearchResult
+	for _, set := range sets {
+		for _, r := range set {
+			if i, ok := index[r.NodeID]; ok {
+				if r.Similarity > merged[i].Similarity {
+					merged[i] = r
+				}
+				continue
+			}
+			index[r.NodeID] = len(merged)
+			merged = append(merged, r)
+		}
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		if merged[i].Similarity != merged[j].Similarity {
+			return merged[i].Similarity > merged[j].Similarity
+		}
+		if merged[i].QualifiedName != merged[j].QualifiedName {
+			return merged[i].QualifiedName < merged[j].QualifiedName
+		}
+		return merged[i].NodeID < merged[j].NodeID
+	})
+	return merged
+}
+
+// subQuerySplitters separate the parts of a compound question.
+var (
+	subQuerySentence    = regexp.MustCompile(`[?;\n]+`)
+	subQueryConjunction = regexp.MustCompile(`(?i)[,\s]+(?:and|also|as well as|plus)\s+`)
+	subQueryLead        = regexp.MustCompile(`(?i)^(?:and|also|plus)\s+`)
+)
+
+// SplitQuery decomposes a compound question into sub-queries: it splits on
+// question marks, semicolons, and newlines, then on conjunctions such as
+// "and" when every resulting part is at least three words long, so "how does
+// auth work and where are sessions stored" splits but "read and write files"
+// doesn't. A leading conjunction is dropped from each part. A query with a single part comes back as a one-element slice;
+// a blank query yields nil.
+func SplitQuery(query string) []string {
+	var parts []string
+	for _, sentence := range subQuerySentence.Split(query, -1) {
+		sentence = subQueryLead.ReplaceAllString(strings.TrimSpace(sentence), "")
+		if sentence == "" {
+			continue
+		}
+		clauses := subQueryConjunction.Split(sentence, -1)
+		for _, c := range clauses {
+			if len(strings.Fields(c)) < 3 {
+				clauses = []string{sentence}
+				break
+			}
+		}
+		for _, c := range clauses {
+			parts = append(parts, strings.TrimSpace(c))
+		}
+	}
+	return parts
 }
 
 func getProjectNodeCount(ctx context.Context, pool *pgxpool.Pool, projectID string) int {
@@ -127,18 +580,121 @@ type scoredNode struct {
 	similarity    float64
 	weight        float64
 	sourceAlias   string
+	releaseTag    string
+	// seed marks a direct search hit. It outlives a graph hop replacing
+	// similarity and weight with a higher combined score.
+	seed bool
+}
+
+// internalPenalty scales down the score of nodes tagged internal so public
+// API surfaces ahead of the helpers behind it.
+const internalPenalty = 0.5
+
+type rankedNode struct {
+	scoredNode
+	score float64
+}
+
+// rankNodes orders nodes by combined score: similarity × weight, internal API
+// penalized, and optionally boosted for recently committed files and for
+// documentation. Ties break on qualified name, then node ID, so the order
+// never depends on map iteration.
+func rankNodes(seen map[string]*scoredNode, o *assembleOptions, commitTimes map[string]time.Time, now time.Time) []rankedNode {
+	ranked := make([]rankedNode, 0, len(seen))
+	for _, sn := range seen {
+		score := sn.similarity * sn.weight
+		if sn.releaseTag == "internal" {
+			score *= internalPenalty
+		}
+		score *= recencyMultiplier(commitTimes[sn.nodeID], now, o.recencyBoost, o.recencyHalfLife)
+		score *= docstringMultiplier(sn.docstring, sn.qualifiedName, o.docstringBoost)
+		ranked = append(ranked, rankedNode{
+			scoredNode: *sn,
+			score:      score,
+		})
+	}
+
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].score != ranked[j].score {
+			return ranked[i].score > ranked[j].score
+		}
+		if ranked[i].qualifiedName != ranked[j].qualifiedName {
+			return ranked[i].qualifiedName < ranked[j].qualifiedName
+		}
+		return ranked[i].nodeID < ranked[j].nodeID
+	})
+	return ranked
+}
+
+// dropBelowScore removes the nodes scoring under minScore from ranked,
+// keeping its order and the best-ranked seed (a direct search hit) whatever
+// its score. Returns the kept nodes and how many were dropped.
+func dropBelowScore(ranked []rankedNode, minScore float64) ([]rankedNode, int) {
+	if minScore <= 0 {
+		return ranked, 0
+	}
+	kept := make([]rankedNode, 0, len(ranked))
+	seedKept := false
+	for _, rn := range ranked {
+		if rn.score >= minScore || (rn.seed && !seedKept) {
+			kept = append(kept, rn)
+			seedKept = seedKept || rn.seed
+		}
+	}
+	return kept, len(ranked) - len(kept)
+}
+
+// generatedDocMarkers flag docstrings written by tools rather than authors.
+var generatedDocMarkers = []string{"code generated", "do not edit", "auto-generated", "autogenerated", "generated by"}
+
+// placeholderDocs are docstrings that document nothing.
+var placeholderDocs = map[string]bool{"fixme": true, "tbd": true, "xxx": true, "...": true}
+
+// docstringMultiplier is the factor a node's score is scaled by for its
+// documentation: 1 + boost for a meaningful docstring, 1 / (1 + boost) for
+// generated boilerplate, a placeholder, or one that only repeats the node's
+// name, and 1 for no docstring at all — plenty of languages and node kinds
+// have none to give.
+func docstringMultiplier(docstring, qualifiedName string, boost float64) float64 {
+	if boost <= 0 {
+		return 1
+	}
+	doc := strings.ToLower(strings.TrimSpace(docstring))
+	if doc == "" {
+		return 1
+	}
+	for _, marker := range generatedDocMarkers {
+		if strings.Contains(doc, marker) {
+			return 1 / (1 + boost)
+		}
+	}
+	doc = strings.TrimRight(doc, ".!: ")
+	name := strings.ToLower(qualifiedName)
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	if doc == "" || placeholderDocs[doc] || doc == name || strings.HasPrefix(doc, "todo") {
+		return 1 / (1 + boost)
+	}
+	return 1 + boost
 }
 
 // assembleFromResults is the shared core: expands semantic results via graph,
 // deduplicates, ranks by combined score, and assembles the token-budgeted output.
-func assembleFromResults(ctx context.Context, pool *pgxpool.Pool, semanticResults []SearchResult, maxTokens int) (*AssembledContext, error) {
+func assembleFromResults(ctx context.Context, pool *pgxpool.Pool, semanticResults []SearchResult, maxTokens int, o *assembleOptions) (*AssembledContext, error) {
 	seen := make(map[string]*scoredNode)
+	var traversal []TraversalOption
+	if !o.expandGenerated {
+		traversal = append(traversal, SkipGenerated())
+	}
 
 	// Step 1: Seed with semantic hits (weight 1.0) and expand via graph
 	for _, sr := range semanticResults {
 		if existing, ok := seen[sr.NodeID]; ok {
-			if sr.Similarity > existing.similarity {
+			existing.seed = true
+			if sr.Similarity > existing.similarity*existing.weight {
 				existing.similarity = sr.Similarity
+				existing.weight = 1.0
 			}
 		} else {
 			seen[sr.NodeID] = &scoredNode{
@@ -152,16 +708,18 @@ func assembleFromResults(ctx context.Context, pool *pgxpool.Pool, semanticResult
 				similarity:    sr.Similarity,
 				weight:        1.0,
 				sourceAlias:   sr.SourceAlias,
+				releaseTag:    sr.ReleaseTag,
+				seed:          true,
 			}
 		}
 
-		// Hop 1: outgoing dependencies (calls, imports, uses_type) — top 5
-		hop1, _ := GetDependencies(ctx, pool, sr.NodeID, 1, 5)
+		// Hop 1: outgoing dependencies (calls, imports, uses_type by default) — top 5
+		hop1, _ := GetDependencies(ctx, pool, sr.NodeID, 1, 5, o.edgeKinds, traversal...)
 		for _, n := range hop1 {
 			addOrUpdate(seen, n, sr.Similarity, 0.7)
 
 			// Hop 2: one more hop from hop-1 nodes — top 3, reduced fan-out
-			hop2, _ := GetDependencies(ctx, pool, n.NodeID, 1, 3)
+			hop2, _ := GetDependencies(ctx, pool, n.NodeID, 1, 3, o.edgeKinds, traversal...)
 			for _, n2 := range hop2 {
 				addOrUpdate(seen, n2, sr.Similarity, 0.4)
 			}
@@ -169,38 +727,45 @@ func assembleFromResults(ctx context.Context, pool *pgxpool.Pool, semanticResult
 
 		// Reverse hop: who imports/calls/uses this node? — top 3
 		// Critical for cross-repo questions (e.g., finding consumers of a library)
-		dependents, _ := GetDependents(ctx, pool, sr.NodeID, 1, 3)
+		dependents, _ := GetDependents(ctx, pool, sr.NodeID, 1, 3, o.edgeKinds, traversal...)
 		for _, n := range dependents {
 			addOrUpdate(seen, n, sr.Similarity, 0.6)
 		}
-	}
-
-	// Step 2: Rank by combined score (similarity × weight)
-	type rankedNode struct {
-		scoredNode
-		score float64
-	}
 
-	ranked := make([]rankedNode, 0, len(seen))
-	for _, sn := range seen {
-		ranked = append(ranked, rankedNode{
-			scoredNode: *sn,
-			score:      sn.similarity * sn.weight,
-		})
+		// Peers: the other methods of a method's class — first 5 in source
+		// order. Weighted below the graph hops, so they only fill leftover budget
+		if sr.Kind == "method" {
+			siblings, _ := GetSiblings(ctx, pool, sr.NodeID)
+			for i, n := range siblings {
+				if i == 5 {
+					break
+				}
+				addOrUpdate(seen, n, sr.Similarity, 0.35)
+			}
+		}
 	}
 
-	sort.Slice(ranked, func(i, j int) bool {
-		if ranked[i].score != ranked[j].score {
-			return ranked[i].score > ranked[j].score
+	// Step 2: Rank by combined score
+	var commitTimes map[string]time.Time
+	if o.recencyBoost > 0 {
+		ids := make([]string, 0, len(seen))
+		for id := range seen {
+			ids = append(ids, id)
 		}
-		return ranked[i].qualifiedName < ranked[j].qualifiedName
-	})
+		var err error
+		commitTimes, err = loadLastCommitTimes(ctx, pool, ids)
+		if err != nil {
+			slog.Warn("recency boost skipped", "error", err)
+		}
+	}
+	ranked := rankNodes(seen, o, commitTimes, time.Now())
+	ranked, belowMinScore := dropBelowScore(ranked, o.minScore)
+	if belowMinScore > 0 {
+		slog.Debug("dropped nodes below min score", "minScore", o.minScore, "dropped", belowMinScore, "kept", len(ranked))
+	}
 
 	// Step 3: Fetch relationship annotations for the top nodes
-	annotationLimit := 20
-	if len(ranked) < annotationLimit {
-		annotationLimit = len(ranked)
-	}
+	annotationLimit := min(o.annotationLimit, len(ranked))
 
 	type annotations struct {
 		calledBy   []string
@@ -210,27 +775,41 @@ func assembleFromResults(ctx context.Context, pool *pgxpool.Pool, semanticResult
 	}
 	nodeAnnotations := make(map[string]*annotations)
 
-	for i := 0; i < annotationLimit; i++ {
-		nodeID := ranked[i].nodeID
-		callers, _ := GetCallers(ctx, pool, nodeID, 3)
-		callees, _ := GetCallees(ctx, pool, nodeID, 3)
-		importers, _ := GetImporters(ctx, pool, nodeID, 3)
-		imported, _ := getRelated(ctx, pool, nodeID, "imports", "outgoing", 3)
-
-		ann := &annotations{}
-		for _, c := range callers {
-			ann.calledBy = append(ann.calledBy, nodeLabel(c))
+	if annotationLimit > 0 {
+		ids := make([]string, annotationLimit)
+		for i := range ids {
+			ids[i] = ranked[i].nodeID
 		}
-		for _, c := range callees {
-			ann.calls = append(ann.calls, nodeLabel(c))
+		// One bulk query per relationship rather than four per node. A
+		// failed one leaves that relationship out rather than failing the
+		// whole context.
+		related := func(edgeKind, direction string) map[string][]NodeResult {
+			byNode, err := getRelatedBatch(ctx, pool, ids, edgeKind, direction, 3)
+			if err != nil {
+				slog.Debug("fetching annotations failed", "kind", edgeKind, "direction", direction, "error", err)
+			}
+			return byNode
 		}
-		for _, c := range importers {
-			ann.importedBy = append(ann.importedBy, nodeLabel(c))
+		callers := related("calls", "incoming")
+		callees := related("calls", "outgoing")
+		importers := related("imports", "incoming")
+		imported := related("imports", "outgoing")
+
+		labels := func(nodes []NodeResult) []string {
+			var out []string
+			for _, n := range nodes {
+				out = append(out, nodeLabel(n))
+			}
+			return out
 		}
-		for _, c := range imported {
-			ann.imports = append(ann.imports, nodeLabel(c))
+		for _, id := range ids {
+			nodeAnnotations[id] = &annotations{
+				calledBy:   labels(callers[id]),
+				calls:      labels(callees[id]),
+				importedBy: labels(importers[id]),
+				imports:    labels(imported[id]),
+			}
 		}
-		nodeAnnotations[nodeID] = ann
 	}
 
 	// Step 4: Greedy token-budgeted assembly
@@ -259,13 +838,18 @@ func assembleFromResults(ctx context.Context, pool *pgxpool.Pool, semanticResult
 			node.Calls = ann.calls
 			node.ImportedBy = ann.importedBy
 			node.Imports = ann.imports
+			node.Annotated = true
+		} else if o.compact {
+			node.Docstring = ""
+			fullSource = false
+			node.FullSource = false
 		}
 
 		if fullSource {
 			node.SourceCode = rn.sourceCode
 		}
 
-		formatted := formatNode(node)
+		formatted := o.formatter.FormatNode(node)
 		nodeTokens, err := indexer.CountTokens(formatted)
 		if err != nil {
 			nodeTokens = len(formatted) / 4
@@ -276,7 +860,7 @@ func assembleFromResults(ctx context.Context, pool *pgxpool.Pool, semanticResult
 			if fullSource && rn.sourceCode != "" {
 				node.SourceCode = ""
 				node.FullSource = false
-				formatted = formatNode(node)
+				formatted = o.formatter.FormatNode(node)
 				nodeTokens, err = indexer.CountTokens(formatted)
 				if err != nil {
 					nodeTokens = len(formatted) / 4
@@ -294,17 +878,18 @@ func assembleFromResults(ctx context.Context, pool *pgxpool.Pool, semanticResult
 	}
 
 	// Step 5: Format the full context string
-	text := formatContext(contextNodes)
+	text := o.formatter.FormatContext(contextNodes)
 	finalTokens, err := indexer.CountTokens(text)
 	if err != nil {
 		finalTokens = totalTokens + headerTokens
 	}
 
 	return &AssembledContext{
-		Nodes:      contextNodes,
-		Text:       text,
-		TokenCount: finalTokens,
-		TokenLimit: maxTokens,
+		Nodes:         contextNodes,
+		Text:          text,
+		TokenCount:    finalTokens,
+		TokenLimit:    maxTokens,
+		BelowMinScore: belowMinScore,
 	}, nil
 }
 
@@ -316,7 +901,7 @@ func nodeLabel(n NodeResult) string {
 }
 
 // addOrUpdate inserts a graph-discovered node into the seen map, or updates
-// it if the new combined score is higher.
+// it if the new combined score is higher. A seed stays one.
 func addOrUpdate(seen map[string]*scoredNode, n NodeResult, similarity, weight float64) {
 	combined := similarity * weight
 	if existing, ok := seen[n.NodeID]; ok {
@@ -336,81 +921,7 @@ func addOrUpdate(seen map[string]*scoredNode, n NodeResult, similarity, weight f
 			similarity:    similarity,
 			weight:        weight,
 			sourceAlias:   n.SourceAlias,
+			releaseTag:    n.ReleaseTag,
 		}
 	}
 }
-
-func formatContext(nodes []ContextNode) string {
-	if len(nodes) == 0 {
-		return "No relevant code found."
-	}
-
-	// Group nodes by source alias for clear repo boundaries
-	groups := make(map[string][]ContextNode)
-	var order []string
-	for _, n := range nodes {
-		alias := n.SourceAlias
-		if alias == "" {
-			alias = "(unknown)"
-		}
-		if _, exists := groups[alias]; !exists {
-			order = append(order, alias)
-		}
-		groups[alias] = append(groups[alias], n)
-	}
-
-	var b strings.Builder
-	b.WriteString("## Relevant Code\n\n")
-
-	if len(order) == 1 && order[0] == "(unknown)" {
-		// Single source or no aliases — flat list (backwards compatible)
-		for _, n := range nodes {
-			b.WriteString(formatNode(n))
-			b.WriteString("\n")
-		}
-	} else {
-		for _, alias := range order {
-			b.WriteString(fmt.Sprintf("## Source: %s\n\n", alias))
-			for _, n := range groups[alias] {
-				b.WriteString(formatNode(n))
-				b.WriteString("\n")
-			}
-		}
-	}
-
-	return b.String()
-}
-
-func formatNode(n ContextNode) string {
-	var b strings.Builder
-
-	if n.SourceAlias != "" {
-		b.WriteString(fmt.Sprintf("### %s — %s [source: %s] (similarity: %.2f)\n", n.FilePath, n.QualifiedName, n.SourceAlias, n.Similarity))
-	} else {
-		b.WriteString(fmt.Sprintf("### %s — %s (similarity: %.2f)\n", n.FilePath, n.QualifiedName, n.Similarity))
-	}
-	b.WriteString(fmt.Sprintf("Signature: %s\n", n.Signature))
-
-	if n.Docstring != "" {
-		b.WriteString(fmt.Sprintf("Docstring: %s\n", n.Docstring))
-	}
-
-	if len(n.ImportedBy) > 0 {
-		b.WriteString(fmt.Sprintf("Imported by: %s\n", strings.Join(n.ImportedBy, ", ")))
-	}
-	if len(n.Imports) > 0 {
-		b.WriteString(fmt.Sprintf("Imports: %s\n", strings.Join(n.Imports, ", ")))
-	}
-	if len(n.CalledBy) > 0 {
-		b.WriteString(fmt.Sprintf("Called by: %s\n", strings.Join(n.CalledBy, ", ")))
-	}
-	if len(n.Calls) > 0 {
-		b.WriteString(fmt.Sprintf("Calls: %s\n", strings.Join(n.Calls, ", ")))
-	}
-
-	if n.FullSource && n.SourceCode != "" {
-		b.WriteString(fmt.Sprintf("\n```\n%s\n```\n", n.SourceCode))
-	}
-
-	return b.String()
-}
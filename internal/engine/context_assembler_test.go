@@ -1,6 +1,12 @@
 package engine
 
-import "testing"
+import (
+	"fmt"
+	"math"
+	"slices"
+	"testing"
+	"time"
+)
 
 func TestDynamicSearchLimit(t *testing.T) {
 	tests := []struct {
@@ -27,3 +33,258 @@ func TestDynamicSearchLimit(t *testing.T) {
 		}
 	}
 }
+
+func TestWithAlpha(t *testing.T) {
+	tests := []struct {
+		alpha float64
+		want  float64
+	}{
+		{0, 0},
+		{0.3, 0.3},
+		{1, 1},
+		{-0.5, 0},
+		{2, 1},
+	}
+	for _, tt := range tests {
+		o := resolveAssembleOptions([]AssembleOption{WithAlpha(tt.alpha)})
+		if o.alpha != tt.want {
+			t.Errorf("WithAlpha(%v) = %v, want %v", tt.alpha, o.alpha, tt.want)
+		}
+	}
+
+	if o := resolveAssembleOptions(nil); o.alpha != DefaultHybridAlpha {
+		t.Errorf("default alpha = %v, want %v", o.alpha, DefaultHybridAlpha)
+	}
+}
+
+func TestWithEdgeKinds(t *testing.T) {
+	o := resolveAssembleOptions([]AssembleOption{WithEdgeKinds([]string{"calls"})})
+	if len(o.edgeKinds) != 1 || o.edgeKinds[0] != "calls" {
+		t.Errorf("WithEdgeKinds(calls) = %v", o.edgeKinds)
+	}
+
+	// Empty falls back to the traversal default (nil → DefaultTraversalEdgeKinds)
+	if o := resolveAssembleOptions([]AssembleOption{WithEdgeKinds(nil)}); o.edgeKinds != nil {
+		t.Errorf("WithEdgeKinds(nil) = %v, want nil", o.edgeKinds)
+	}
+}
+
+func TestWithCompactContext(t *testing.T) {
+	o := resolveAssembleOptions(nil)
+	if o.compact || o.annotationLimit != DefaultAnnotationLimit {
+		t.Errorf("expected non-compact with %d annotations by default, got %v/%d", DefaultAnnotationLimit, o.compact, o.annotationLimit)
+	}
+	o = resolveAssembleOptions([]AssembleOption{WithCompactContext()})
+	if !o.compact || o.annotationLimit != CompactAnnotationLimit {
+		t.Errorf("expected compact with %d annotations, got %v/%d", CompactAnnotationLimit, o.compact, o.annotationLimit)
+	}
+	o = resolveAssembleOptions([]AssembleOption{WithCompactContext(), WithAnnotationLimit(8)})
+	if !o.compact || o.annotationLimit != 8 {
+		t.Errorf("expected a later limit to override compact's, got %v/%d", o.compact, o.annotationLimit)
+	}
+	if o = resolveAssembleOptions([]AssembleOption{WithAnnotationLimit(-1)}); o.annotationLimit != 0 {
+		t.Errorf("expected a negative limit to clamp to 0, got %d", o.annotationLimit)
+	}
+}
+
+func TestSetGeneratedExpansion(t *testing.T) {
+	if o := resolveAssembleOptions(nil); o.expandGenerated {
+		t.Error("expected generated expansion off by default")
+	}
+	SetGeneratedExpansion(true)
+	t.Cleanup(func() { SetGeneratedExpansion(false) })
+	if o := resolveAssembleOptions(nil); !o.expandGenerated {
+		t.Error("expected SetGeneratedExpansion(true) to become the default")
+	}
+	if o := resolveAssembleOptions([]AssembleOption{WithGeneratedExpansion(false)}); o.expandGenerated {
+		t.Error("expected WithGeneratedExpansion(false) to override the default")
+	}
+}
+
+func TestRecencyMultiplier(t *testing.T) {
+	now := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	tests := []struct {
+		name       string
+		lastCommit time.Time
+		boost      float64
+		want       float64
+	}{
+		{"just committed", now, 0.5, 1.5},
+		{"one half-life", now.Add(-14 * day), 0.5, 1.25},
+		{"two half-lives", now.Add(-28 * day), 1, 1.25},
+		{"future commit", now.Add(day), 0.5, 1.5},
+		{"no commit time", time.Time{}, 0.5, 1},
+		{"boost off", now, 0, 1},
+	}
+	for _, tt := range tests {
+		got := recencyMultiplier(tt.lastCommit, now, tt.boost, DefaultRecencyHalfLife)
+		if math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s: recencyMultiplier = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestWithRecencyBoost(t *testing.T) {
+	if o := resolveAssembleOptions(nil); o.recencyBoost != 0 {
+		t.Errorf("default recencyBoost = %v, want 0", o.recencyBoost)
+	}
+	o := resolveAssembleOptions([]AssembleOption{WithRecencyBoost(-1, 0)})
+	if o.recencyBoost != 0 {
+		t.Errorf("negative boost should clamp to 0, got %v", o.recencyBoost)
+	}
+	// Zero half-life falls back to the default inside recencyDecay.
+	if got := recencyDecay(DefaultRecencyHalfLife, 0); math.Abs(got-0.5) > 1e-9 {
+		t.Errorf("recencyDecay with default half-life = %v, want 0.5", got)
+	}
+}
+
+func TestDocstringMultiplier(t *testing.T) {
+	tests := []struct {
+		name, doc string
+		want      float64
+	}{
+		{"documented", "Validates a session token and returns its claims.", 1.5},
+		{"undocumented", "", 1},
+		{"generated", "Code generated by protoc-gen-go. DO NOT EDIT.", 1 / 1.5},
+		{"placeholder", "TODO: document", 1 / 1.5},
+		{"repeats name", "ValidateToken.", 1 / 1.5},
+	}
+	for _, tt := range tests {
+		if got := docstringMultiplier(tt.doc, "auth.ValidateToken", 0.5); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s: docstringMultiplier = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if got := docstringMultiplier("Validates a token.", "ValidateToken", 0); got != 1 {
+		t.Errorf("zero boost should leave scores alone, got %v", got)
+	}
+}
+
+func TestRankNodes_DocstringBoost(t *testing.T) {
+	seen := map[string]*scoredNode{
+		"a": {nodeID: "a", qualifiedName: "parseRaw", similarity: 0.80, weight: 1},
+		"b": {nodeID: "b", qualifiedName: "Parse", similarity: 0.75, weight: 1, docstring: "Parse reads a config file and applies defaults."},
+		"c": {nodeID: "c", qualifiedName: "parseGenerated", similarity: 0.70, weight: 1, docstring: "Code generated by stringer. DO NOT EDIT."},
+	}
+	order := func(ranked []rankedNode) []string {
+		var names []string
+		for _, rn := range ranked {
+			names = append(names, rn.qualifiedName)
+		}
+		return names
+	}
+	now := time.Now()
+
+	if got := order(rankNodes(seen, resolveAssembleOptions(nil), nil, now)); !slices.Equal(got, []string{"parseRaw", "Parse", "parseGenerated"}) {
+		t.Errorf("without the boost ranking should follow similarity, got %v", got)
+	}
+
+	o := resolveAssembleOptions([]AssembleOption{WithDocstringBoost(0.2)})
+	if got := order(rankNodes(seen, o, nil, now)); !slices.Equal(got, []string{"Parse", "parseRaw", "parseGenerated"}) {
+		t.Errorf("with the boost the documented node should lead, got %v", got)
+	}
+
+	if o := resolveAssembleOptions([]AssembleOption{WithDocstringBoost(-1)}); o.docstringBoost != 0 {
+		t.Errorf("negative boost should clamp to 0, got %v", o.docstringBoost)
+	}
+}
+
+func TestRankNodes_TieBreak(t *testing.T) {
+	// Same score and name in two sources: order comes from the node ID,
+	// not from map iteration
+	seen := map[string]*scoredNode{
+		"web::src/a.ts::parse": {nodeID: "web::src/a.ts::parse", qualifiedName: "parse", similarity: 0.8, weight: 1},
+		"api::src/a.ts::parse": {nodeID: "api::src/a.ts::parse", qualifiedName: "parse", similarity: 0.8, weight: 1},
+		"cli::src/b.ts::parse": {nodeID: "cli::src/b.ts::parse", qualifiedName: "parse", similarity: 0.8, weight: 1},
+	}
+	want := []string{"api::src/a.ts::parse", "cli::src/b.ts::parse", "web::src/a.ts::parse"}
+	for range 20 {
+		var got []string
+		for _, rn := range rankNodes(seen, resolveAssembleOptions(nil), nil, time.Now()) {
+			got = append(got, rn.nodeID)
+		}
+		if !slices.Equal(got, want) {
+			t.Fatalf("ranked = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestDropBelowScore(t *testing.T) {
+	ranked := []rankedNode{
+		{scoredNode: scoredNode{qualifiedName: "hop1", weight: 0.7}, score: 0.42},
+		{scoredNode: scoredNode{qualifiedName: "seed", weight: 1, seed: true}, score: 0.40},
+		{scoredNode: scoredNode{qualifiedName: "hop2", weight: 0.4}, score: 0.16},
+		{scoredNode: scoredNode{qualifiedName: "seed2", weight: 1, seed: true}, score: 0.15},
+	}
+	names := func(rs []rankedNode) []string {
+		var out []string
+		for _, rn := range rs {
+			out = append(out, rn.qualifiedName)
+		}
+		return out
+	}
+
+	kept, dropped := dropBelowScore(ranked, 0.3)
+	if got := names(kept); !slices.Equal(got, []string{"hop1", "seed"}) || dropped != 2 {
+		t.Errorf("dropBelowScore(0.3) = %v, %d dropped; want [hop1 seed], 2", got, dropped)
+	}
+
+	// The top seed survives a floor above every score
+	kept, dropped = dropBelowScore(ranked, 0.9)
+	if got := names(kept); !slices.Equal(got, []string{"seed"}) || dropped != 3 {
+		t.Errorf("dropBelowScore(0.9) = %v, %d dropped; want [seed], 3", got, dropped)
+	}
+
+	if kept, dropped := dropBelowScore(ranked, 0); len(kept) != 4 || dropped != 0 {
+		t.Errorf("a zero floor should keep everything, got %d kept, %d dropped", len(kept), dropped)
+	}
+}
+
+func TestDropBelowScore_SeedReachedByHop(t *testing.T) {
+	// A weak seed that is also a neighbour of a strong one takes the hop's
+	// higher combined score, and must still count as a direct hit
+	seen := map[string]*scoredNode{
+		"weak": {nodeID: "weak", qualifiedName: "weak", similarity: 0.5, weight: 1, seed: true},
+	}
+	addOrUpdate(seen, NodeResult{NodeID: "weak", QualifiedName: "weak"}, 0.9, 0.7)
+	if sn := seen["weak"]; sn.weight != 0.7 || !sn.seed {
+		t.Fatalf("expected the hop's weight with the seed kept, got %+v", sn)
+	}
+	addOrUpdate(seen, NodeResult{NodeID: "hop", QualifiedName: "hop"}, 0.9, 0.4)
+
+	kept, _ := dropBelowScore(rankNodes(seen, resolveAssembleOptions(nil), nil, time.Now()), 0.95)
+	if len(kept) != 1 || kept[0].nodeID != "weak" {
+		t.Errorf("expected only the seed to survive, got %+v", kept)
+	}
+}
+
+func TestSplitQuery(t *testing.T) {
+	cases := map[string][]string{
+		"how does auth work and where are sessions stored":  {"how does auth work", "where are sessions stored"},
+		"How is config loaded? Where are retries handled?":  {"How is config loaded", "Where are retries handled"},
+		"parse the config; also validate incoming requests": {"parse the config", "validate incoming requests"},
+		"read and write files":                              {"read and write files"},
+		"authentication":                                    {"authentication"},
+		"   ":                                               nil,
+	}
+	for query, want := range cases {
+		if got := SplitQuery(query); !slices.Equal(got, want) {
+			t.Errorf("SplitQuery(%q) = %q, want %q", query, got, want)
+		}
+	}
+}
+
+func TestMergeSearchResults(t *testing.T) {
+	merged := mergeSearchResults([][]SearchResult{
+		{{NodeID: "a", QualifiedName: "a", Similarity: 0.9}, {NodeID: "b", QualifiedName: "b", Similarity: 0.5}},
+		{{NodeID: "c", QualifiedName: "c", Similarity: 0.7}, {NodeID: "b", QualifiedName: "b", Similarity: 0.8}},
+	})
+	var got []string
+	for _, r := range merged {
+		got = append(got, fmt.Sprintf("%s=%.1f", r.NodeID, r.Similarity))
+	}
+	want := []string{"a=0.9", "b=0.8", "c=0.7"}
+	if !slices.Equal(got, want) {
+		t.Errorf("merged = %v, want %v", got, want)
+	}
+}
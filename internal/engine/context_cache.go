@@ -0,0 +1,144 @@
+package engine
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ContextCache stores AssembleContext results so identical queries against an
+// unchanged project skip the embedding call and the search/graph/format
+// pipeline. Keys already encode the project's index version, so entries never
+// need explicit invalidation — a re-index simply makes old keys unreachable
+// and implementations only have to evict. NewLRUContextCache is the in-memory
+// implementation; a shared store such as Redis can implement the same two
+// methods, serializing AssembledContext as JSON.
+type ContextCache interface {
+	Get(ctx context.Context, key string) (*AssembledContext, bool)
+	Set(ctx context.Context, key string, value *AssembledContext)
+}
+
+// WithContextCache serves AssembleContext from c when the same query, budget,
+// and options were assembled since the project was last indexed. Off by
+// default. Recency-boosted scores are cached as of the first call.
+func WithContextCache(c ContextCache) AssembleOption {
+	return func(o *assembleOptions) {
+		o.cache = c
+	}
+}
+
+// LRUContextCache is an in-memory ContextCache holding at most size entries,
+// evicting the least recently used. Safe for concurrent use.
+type LRUContextCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type lruEntry struct {
+	key   string
+	value *AssembledContext
+}
+
+var _ ContextCache = (*LRUContextCache)(nil)
+
+// NewLRUContextCache returns an LRU cache of the given size (minimum 1).
+func NewLRUContextCache(size int) *LRUContextCache {
+	return &LRUContextCache{
+		size:    max(size, 1),
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// Get returns a copy of the cached context, so callers may modify it.
+func (c *LRUContextCache) Get(_ context.Context, key string) (*AssembledContext, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return copyAssembledContext(el.Value.(*lruEntry).value), true
+}
+
+func (c *LRUContextCache) Set(_ context.Context, key string, value *AssembledContext) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	value = copyAssembledContext(value)
+	if el, ok := c.entries[key]; ok {
+		el.Value.(*lruEntry).value = value
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&lruEntry{key: key, value: value})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry).key)
+	}
+}
+
+// Len reports the number of cached entries.
+func (c *LRUContextCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func copyAssembledContext(ac *AssembledContext) *AssembledContext {
+	cp := *ac
+	cp.Nodes = slices.Clone(ac.Nodes)
+	return &cp
+}
+
+// projectIndexVersion identifies the current state of a project's graph:
+// projects.graph_version, which every transaction writing the graph bumps
+// (BuildGraph, IndexFile, RebuildEdges, cross-source resolution), whichever
+// process runs it. A project that doesn't exist is version 0.
+func projectIndexVersion(ctx context.Context, pool *pgxpool.Pool, projectID string) (string, error) {
+	var version int64
+	if err := pool.QueryRow(ctx,
+		`SELECT COALESCE((SELECT graph_version FROM projects WHERE id = $1), 0)`, projectID,
+	).Scan(&version); err != nil {
+		return "", fmt.Errorf("querying project graph version: %w", err)
+	}
+	return strconv.FormatInt(version, 10), nil
+}
+
+// contextCacheKey hashes everything that affects an assembled context. The
+// query is normalized by trimming and collapsing whitespace only — case can
+// matter for symbol lookups.
+func contextCacheKey(projectID, query string, maxTokens int, o *assembleOptions, version string) string {
+	parts := []string{
+		projectID,
+		strings.Join(strings.Fields(query), " "),
+		strconv.Itoa(maxTokens),
+		fmt.Sprintf("%T%+v", o.formatter, o.formatter),
+		strconv.FormatFloat(o.alpha, 'g', -1, 64),
+		strings.Join(o.edgeKinds, ","),
+		strings.Join(o.kinds, ","),
+		strconv.FormatBool(o.expandGenerated),
+		o.embedding,
+		o.branch,
+		strconv.FormatFloat(o.recencyBoost, 'g', -1, 64),
+		o.recencyHalfLife.String(),
+		strconv.FormatFloat(o.docstringBoost, 'g', -1, 64),
+		strconv.FormatFloat(o.minScore, 'g', -1, 64),
+		strconv.Itoa(o.annotationLimit),
+		strconv.FormatBool(o.compact),
+		version,
+	}
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
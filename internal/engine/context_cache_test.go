@@ -0,0 +1,94 @@
+package engine
+
+import (
+	"context"
+	"testing"
+)
+
+func TestLRUContextCache_Evicts(t *testing.T) {
+	ctx := context.Background()
+	c := NewLRUContextCache(2)
+	c.Set(ctx, "a", &AssembledContext{Text: "a"})
+	c.Set(ctx, "b", &AssembledContext{Text: "b"})
+
+	// Touch a so b becomes the least recently used
+	if _, ok := c.Get(ctx, "a"); !ok {
+		t.Fatal("expected a to be cached")
+	}
+	c.Set(ctx, "c", &AssembledContext{Text: "c"})
+
+	if _, ok := c.Get(ctx, "b"); ok {
+		t.Error("expected b to be evicted")
+	}
+	for _, k := range []string{"a", "c"} {
+		if got, ok := c.Get(ctx, k); !ok || got.Text != k {
+			t.Errorf("expected %s to be cached, got %+v", k, got)
+		}
+	}
+	if c.Len() != 2 {
+		t.Errorf("expected 2 entries, got %d", c.Len())
+	}
+}
+
+func TestLRUContextCache_ReturnsCopies(t *testing.T) {
+	ctx := context.Background()
+	c := NewLRUContextCache(1)
+	c.Set(ctx, "k", &AssembledContext{Nodes: []ContextNode{{NodeID: "n1"}}, Text: "t"})
+
+	got, _ := c.Get(ctx, "k")
+	got.Text = "changed"
+	got.Nodes[0].NodeID = "changed"
+
+	again, _ := c.Get(ctx, "k")
+	if again.Text != "t" || again.Nodes[0].NodeID != "n1" {
+		t.Errorf("cached entry was mutated through a returned copy: %+v", again)
+	}
+}
+
+func TestContextCacheKey(t *testing.T) {
+	o := defaultAssembleOptions()
+	base := contextCacheKey("p", "how does auth work", 8000, o, "1.0")
+
+	if got := contextCacheKey("p", "  how does\tauth   work ", 8000, o, "1.0"); got != base {
+		t.Error("expected whitespace differences to share a key")
+	}
+
+	alpha := defaultAssembleOptions()
+	WithAlpha(0.2)(alpha)
+	json := defaultAssembleOptions()
+	WithFormatter(JSONFormatter{})(json)
+	kinds := defaultAssembleOptions()
+	WithKinds([]string{"callable"})(kinds)
+	generated := defaultAssembleOptions()
+	WithGeneratedExpansion(true)(generated)
+	signature := defaultAssembleOptions()
+	WithSearchEmbedding(EmbeddingSignature)(signature)
+	compact := defaultAssembleOptions()
+	WithCompactContext()(compact)
+	annotations := defaultAssembleOptions()
+	WithAnnotationLimit(3)(annotations)
+	minScore := defaultAssembleOptions()
+	WithMinIncludedScore(0.3)(minScore)
+	branch := defaultAssembleOptions()
+	WithBranch("release")(branch)
+
+	for name, key := range map[string]string{
+		"query case": contextCacheKey("p", "How does auth work", 8000, o, "1.0"),
+		"project":    contextCacheKey("q", "how does auth work", 8000, o, "1.0"),
+		"maxTokens":  contextCacheKey("p", "how does auth work", 4000, o, "1.0"),
+		"alpha":      contextCacheKey("p", "how does auth work", 8000, alpha, "1.0"),
+		"formatter":  contextCacheKey("p", "how does auth work", 8000, json, "1.0"),
+		"kinds":      contextCacheKey("p", "how does auth work", 8000, kinds, "1.0"),
+		"generated":  contextCacheKey("p", "how does auth work", 8000, generated, "1.0"),
+		"embedding":  contextCacheKey("p", "how does auth work", 8000, signature, "1.0"),
+		"compact":    contextCacheKey("p", "how does auth work", 8000, compact, "1.0"),
+		"annotation": contextCacheKey("p", "how does auth work", 8000, annotations, "1.0"),
+		"minScore":   contextCacheKey("p", "how does auth work", 8000, minScore, "1.0"),
+		"branch":     contextCacheKey("p", "how does auth work", 8000, branch, "1.0"),
+		"version":    contextCacheKey("p", "how does auth work", 8000, o, "1.1"),
+	} {
+		if key == base {
+			t.Errorf("expected a different key when %s changes", name)
+		}
+	}
+}
@@ -0,0 +1,295 @@
+package engine
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ContextFormatter renders assembled context nodes into the text handed to an LLM.
+// FormatNode is also used during assembly to measure each node's token cost,
+// so it must produce the same text FormatContext would emit for that node.
+type ContextFormatter interface {
+	FormatNode(n ContextNode) string
+	FormatContext(nodes []ContextNode) string
+}
+
+var (
+	_ ContextFormatter = MarkdownFormatter{}
+	_ ContextFormatter = XMLFormatter{}
+	_ ContextFormatter = JSONFormatter{}
+)
+
+// FormatterByName returns the formatter for "markdown", "xml", or "json".
+// An empty name selects markdown.
+func FormatterByName(name string) (ContextFormatter, error) {
+	switch strings.ToLower(name) {
+	case "", "markdown", "md":
+		return MarkdownFormatter{}, nil
+	case "xml":
+		return XMLFormatter{}, nil
+	case "json":
+		return JSONFormatter{}, nil
+	default:
+		return nil, fmt.Errorf("unknown context format %q", name)
+	}
+}
+
+// QueryContext is the context assembled for one query of a batch, as its
+// formatter rendered it, or the error assembling it.
+type QueryContext struct {
+	Query string
+	Text  string
+	Err   error
+}
+
+// FormatBatch joins the contexts of queries run in one call into a single
+// document in f's layout: one JSON array of {query, result} objects, one
+// <results> element holding a <query> per query, or markdown sections
+// under a heading each. A failed query carries its error in place of a
+// result.
+func FormatBatch(f ContextFormatter, batch []QueryContext) string {
+	switch f.(type) {
+	case JSONFormatter:
+		type entry struct {
+			Query  string          `json:"query"`
+			Result json.RawMessage `json:"result,omitempty"`
+			Error  string          `json:"error,omitempty"`
+		}
+		entries := make([]entry, 0, len(batch))
+		for _, qc := range batch {
+			e := entry{Query: qc.Query}
+			if qc.Err != nil {
+				e.Error = qc.Err.Error()
+			} else {
+				e.Result = json.RawMessage(qc.Text)
+			}
+			entries = append(entries, e)
+		}
+		out, err := json.Marshal(entries)
+		if err != nil {
+			return "[]"
+		}
+		return string(out)
+	case XMLFormatter:
+		var b strings.Builder
+		b.WriteString("<results>\n")
+		for _, qc := range batch {
+			b.WriteString(fmt.Sprintf("<query text=\"%s\">\n", xmlEscape(qc.Query)))
+			if qc.Err != nil {
+				b.WriteString(fmt.Sprintf("<error>%s</error>\n", xmlEscape(qc.Err.Error())))
+			} else {
+				b.WriteString(strings.TrimSuffix(qc.Text, "\n") + "\n")
+			}
+			b.WriteString("</query>\n")
+		}
+		b.WriteString("</results>\n")
+		return b.String()
+	default:
+		var b strings.Builder
+		for i, qc := range batch {
+			if qc.Err != nil {
+				b.WriteString(fmt.Sprintf("## Query %d: %s\n\nError: %v\n\n", i+1, qc.Query, qc.Err))
+				continue
+			}
+			b.WriteString(fmt.Sprintf("## Query %d: %s\n\n", i+1, qc.Query))
+			b.WriteString(qc.Text)
+			b.WriteString("\n\n---\n\n")
+		}
+		return b.String()
+	}
+}
+
+// groupBySource splits nodes by source alias, keeping each group's nodes in
+// input order. Groups are ordered by their best score, then alias, so the
+// same nodes always render the same text whatever order they arrive in.
+func groupBySource(nodes []ContextNode) (map[string][]ContextNode, []string) {
+	groups := make(map[string][]ContextNode)
+	var order []string
+	for _, n := range nodes {
+		alias := n.SourceAlias
+		if alias == "" {
+			alias = "(unknown)"
+		}
+		if _, exists := groups[alias]; !exists {
+			order = append(order, alias)
+		}
+		groups[alias] = append(groups[alias], n)
+	}
+	best := func(alias string) float64 {
+		top := groups[alias][0].Score
+		for _, n := range groups[alias][1:] {
+			top = max(top, n.Score)
+		}
+		return top
+	}
+	sort.Slice(order, func(i, j int) bool {
+		if bi, bj := best(order[i]), best(order[j]); bi != bj {
+			return bi > bj
+		}
+		return order[i] < order[j]
+	})
+	return groups, order
+}
+
+// --- Markdown (default) ---
+
+// MarkdownFormatter renders headings per node with signature/docstring lines
+// and fenced source code. This is the default layout.
+type MarkdownFormatter struct{}
+
+func (MarkdownFormatter) FormatContext(nodes []ContextNode) string {
+	if len(nodes) == 0 {
+		return "No relevant code found."
+	}
+
+	// Group nodes by source alias for clear repo boundaries
+	groups, order := groupBySource(nodes)
+
+	var b strings.Builder
+	b.WriteString("## Relevant Code\n\n")
+
+	if len(order) == 1 && order[0] == "(unknown)" {
+		// Single source or no aliases — flat list (backwards compatible)
+		for _, n := range nodes {
+			b.WriteString(MarkdownFormatter{}.FormatNode(n))
+			b.WriteString("\n")
+		}
+	} else {
+		for _, alias := range order {
+			b.WriteString(fmt.Sprintf("## Source: %s\n\n", alias))
+			for _, n := range groups[alias] {
+				b.WriteString(MarkdownFormatter{}.FormatNode(n))
+				b.WriteString("\n")
+			}
+		}
+	}
+
+	return b.String()
+}
+
+func (MarkdownFormatter) FormatNode(n ContextNode) string {
+	var b strings.Builder
+
+	if n.SourceAlias != "" {
+		b.WriteString(fmt.Sprintf("### %s — %s [source: %s] (similarity: %.2f)\n", n.FilePath, n.QualifiedName, n.SourceAlias, n.Similarity))
+	} else {
+		b.WriteString(fmt.Sprintf("### %s — %s (similarity: %.2f)\n", n.FilePath, n.QualifiedName, n.Similarity))
+	}
+	b.WriteString(fmt.Sprintf("Signature: %s\n", n.Signature))
+
+	if n.Docstring != "" {
+		b.WriteString(fmt.Sprintf("Docstring: %s\n", n.Docstring))
+	}
+
+	if len(n.ImportedBy) > 0 {
+		b.WriteString(fmt.Sprintf("Imported by: %s\n", strings.Join(n.ImportedBy, ", ")))
+	}
+	if len(n.Imports) > 0 {
+		b.WriteString(fmt.Sprintf("Imports: %s\n", strings.Join(n.Imports, ", ")))
+	}
+	if len(n.CalledBy) > 0 {
+		b.WriteString(fmt.Sprintf("Called by: %s\n", strings.Join(n.CalledBy, ", ")))
+	}
+	if len(n.Calls) > 0 {
+		b.WriteString(fmt.Sprintf("Calls: %s\n", strings.Join(n.Calls, ", ")))
+	}
+
+	if n.FullSource && n.SourceCode != "" {
+		b.WriteString(fmt.Sprintf("\n```\n%s\n```\n", n.SourceCode))
+	}
+
+	return b.String()
+}
+
+// --- XML ---
+
+// XMLFormatter wraps each node in <node> tags grouped under <source> elements.
+// Some models follow tag-delimited context more reliably than markdown.
+type XMLFormatter struct{}
+
+func (XMLFormatter) FormatContext(nodes []ContextNode) string {
+	if len(nodes) == 0 {
+		return "<context />"
+	}
+
+	groups, order := groupBySource(nodes)
+
+	var b strings.Builder
+	b.WriteString("<context>\n")
+	for _, alias := range order {
+		b.WriteString(fmt.Sprintf("<source alias=\"%s\">\n", xmlEscape(alias)))
+		for _, n := range groups[alias] {
+			b.WriteString(XMLFormatter{}.FormatNode(n))
+		}
+		b.WriteString("</source>\n")
+	}
+	b.WriteString("</context>\n")
+	return b.String()
+}
+
+func (XMLFormatter) FormatNode(n ContextNode) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("<node file=\"%s\" name=\"%s\" kind=\"%s\" similarity=\"%.2f\">\n",
+		xmlEscape(n.FilePath), xmlEscape(n.QualifiedName), xmlEscape(n.Kind), n.Similarity))
+	b.WriteString(fmt.Sprintf("<signature>%s</signature>\n", xmlEscape(n.Signature)))
+
+	if n.Docstring != "" {
+		b.WriteString(fmt.Sprintf("<docstring>%s</docstring>\n", xmlEscape(n.Docstring)))
+	}
+
+	writeList := func(tag string, items []string) {
+		if len(items) > 0 {
+			b.WriteString(fmt.Sprintf("<%s>%s</%s>\n", tag, xmlEscape(strings.Join(items, ", ")), tag))
+		}
+	}
+	writeList("imported_by", n.ImportedBy)
+	writeList("imports", n.Imports)
+	writeList("called_by", n.CalledBy)
+	writeList("calls", n.Calls)
+
+	if n.FullSource && n.SourceCode != "" {
+		b.WriteString(fmt.Sprintf("<code>\n%s\n</code>\n", xmlEscape(n.SourceCode)))
+	}
+
+	b.WriteString("</node>\n")
+	return b.String()
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// --- JSON ---
+
+// JSONFormatter emits the context nodes as a JSON document, for agents that
+// post-process the context programmatically rather than reading it.
+type JSONFormatter struct{}
+
+func (JSONFormatter) FormatContext(nodes []ContextNode) string {
+	if nodes == nil {
+		nodes = []ContextNode{}
+	}
+	out, err := json.Marshal(struct {
+		Nodes []ContextNode `json:"nodes"`
+	}{Nodes: nodes})
+	if err != nil {
+		return `{"nodes":[]}`
+	}
+	return string(out)
+}
+
+func (JSONFormatter) FormatNode(n ContextNode) string {
+	if !n.FullSource {
+		n.SourceCode = ""
+	}
+	out, err := json.Marshal(n)
+	if err != nil {
+		return ""
+	}
+	return string(out)
+}
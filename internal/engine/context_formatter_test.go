@@ -0,0 +1,214 @@
+package engine
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func sampleContextNodes() []ContextNode {
+	return []ContextNode{
+		{
+			NodeID:        "n1",
+			QualifiedName: "validateToken",
+			FilePath:      "src/auth.ts",
+			Kind:          "function",
+			Signature:     "function validateToken(t: string): boolean",
+			Docstring:     "Checks a token & its expiry",
+			Similarity:    0.91,
+			Calls:         []string{"decode"},
+			FullSource:    true,
+			SourceCode:    "function validateToken(t: string): boolean { return t.length < 10 }",
+			SourceAlias:   "api",
+		},
+		{
+			NodeID:        "n2",
+			QualifiedName: "decode",
+			FilePath:      "src/jwt.ts",
+			Kind:          "function",
+			Signature:     "function decode(t: string)",
+			Similarity:    0.55,
+			SourceAlias:   "api",
+		},
+	}
+}
+
+func TestMarkdownFormatter(t *testing.T) {
+	text := MarkdownFormatter{}.FormatContext(sampleContextNodes())
+
+	for _, want := range []string{
+		"## Relevant Code",
+		"## Source: api",
+		"### src/auth.ts — validateToken [source: api] (similarity: 0.91)",
+		"Signature: function validateToken(t: string): boolean",
+		"Calls: decode",
+		"```\nfunction validateToken",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("markdown output missing %q:\n%s", want, text)
+		}
+	}
+
+	if got := (MarkdownFormatter{}).FormatContext(nil); got != "No relevant code found." {
+		t.Errorf("empty markdown = %q", got)
+	}
+}
+
+func TestXMLFormatter(t *testing.T) {
+	text := XMLFormatter{}.FormatContext(sampleContextNodes())
+
+	// Output must be well-formed so models (and tests) can rely on the tags
+	var doc struct {
+		Sources []struct {
+			Alias string `xml:"alias,attr"`
+			Nodes []struct {
+				Name      string `xml:"name,attr"`
+				Signature string `xml:"signature"`
+				Docstring string `xml:"docstring"`
+				Code      string `xml:"code"`
+			} `xml:"node"`
+		} `xml:"source"`
+	}
+	if err := xml.Unmarshal([]byte(text), &doc); err != nil {
+		t.Fatalf("xml output not well-formed: %v\n%s", err, text)
+	}
+	if len(doc.Sources) != 1 || doc.Sources[0].Alias != "api" || len(doc.Sources[0].Nodes) != 2 {
+		t.Fatalf("unexpected structure: %+v", doc)
+	}
+	first := doc.Sources[0].Nodes[0]
+	if first.Name != "validateToken" || first.Docstring != "Checks a token & its expiry" {
+		t.Errorf("unexpected first node: %+v", first)
+	}
+	if !strings.Contains(first.Code, "t.length < 10") {
+		t.Errorf("expected escaped source to round-trip, got %q", first.Code)
+	}
+	if doc.Sources[0].Nodes[1].Code != "" {
+		t.Error("expected no code for non-full-source node")
+	}
+}
+
+func TestJSONFormatter(t *testing.T) {
+	text := JSONFormatter{}.FormatContext(sampleContextNodes())
+
+	var doc struct {
+		Nodes []ContextNode `json:"nodes"`
+	}
+	if err := json.Unmarshal([]byte(text), &doc); err != nil {
+		t.Fatalf("json output invalid: %v", err)
+	}
+	if len(doc.Nodes) != 2 || doc.Nodes[0].QualifiedName != "validateToken" {
+		t.Errorf("unexpected nodes: %+v", doc.Nodes)
+	}
+
+	if got := (JSONFormatter{}).FormatContext(nil); got != `{"nodes":[]}` {
+		t.Errorf("empty json = %q", got)
+	}
+}
+
+func TestFormatBatch(t *testing.T) {
+	batch := func(f ContextFormatter) []QueryContext {
+		return []QueryContext{
+			{Query: "auth & tokens", Text: f.FormatContext(sampleContextNodes())},
+			{Query: "sessions", Err: errors.New("no embeddings")},
+		}
+	}
+
+	var entries []struct {
+		Query  string          `json:"query"`
+		Result json.RawMessage `json:"result"`
+		Error  string          `json:"error"`
+	}
+	text := FormatBatch(JSONFormatter{}, batch(JSONFormatter{}))
+	if err := json.Unmarshal([]byte(text), &entries); err != nil {
+		t.Fatalf("json batch invalid: %v\n%s", err, text)
+	}
+	if len(entries) != 2 || entries[0].Query != "auth & tokens" || entries[1].Error != "no embeddings" {
+		t.Fatalf("unexpected json batch: %s", text)
+	}
+	var result struct {
+		Nodes []ContextNode `json:"nodes"`
+	}
+	if err := json.Unmarshal(entries[0].Result, &result); err != nil || len(result.Nodes) != 2 {
+		t.Errorf("expected the first result's two nodes, got %s (%v)", entries[0].Result, err)
+	}
+
+	var doc struct {
+		XMLName xml.Name `xml:"results"`
+		Queries []struct {
+			Text    string `xml:"text,attr"`
+			Error   string `xml:"error"`
+			Context struct {
+				Sources []struct {
+					Nodes []struct{} `xml:"node"`
+				} `xml:"source"`
+			} `xml:"context"`
+		} `xml:"query"`
+	}
+	text = FormatBatch(XMLFormatter{}, batch(XMLFormatter{}))
+	if err := xml.Unmarshal([]byte(text), &doc); err != nil {
+		t.Fatalf("xml batch not well-formed: %v\n%s", err, text)
+	}
+	if len(doc.Queries) != 2 || doc.Queries[0].Text != "auth & tokens" || doc.Queries[1].Error != "no embeddings" {
+		t.Fatalf("unexpected xml batch: %s", text)
+	}
+	if len(doc.Queries[0].Context.Sources) != 1 || len(doc.Queries[0].Context.Sources[0].Nodes) != 2 {
+		t.Errorf("expected the first query's context nested in it, got %s", text)
+	}
+
+	text = FormatBatch(MarkdownFormatter{}, batch(MarkdownFormatter{}))
+	if !strings.HasPrefix(text, "## Query 1: auth & tokens") || !strings.Contains(text, "## Query 2: sessions\n\nError: no embeddings") {
+		t.Errorf("unexpected markdown batch:\n%s", text)
+	}
+}
+
+func TestFormatterByName(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    ContextFormatter
+		wantErr bool
+	}{
+		{"", MarkdownFormatter{}, false},
+		{"markdown", MarkdownFormatter{}, false},
+		{"XML", XMLFormatter{}, false},
+		{"json", JSONFormatter{}, false},
+		{"yaml", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := FormatterByName(tt.name)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("FormatterByName(%q) err = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("FormatterByName(%q) = %T, want %T", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGroupBySource_Order(t *testing.T) {
+	nodes := []ContextNode{
+		{QualifiedName: "a", SourceAlias: "web", Score: 0.4},
+		{QualifiedName: "b", SourceAlias: "api", Score: 0.9},
+		{QualifiedName: "c", SourceAlias: "web", Score: 0.7},
+		{QualifiedName: "d", SourceAlias: "cli", Score: 0.7},
+		{QualifiedName: "e", Score: 0.1},
+	}
+	groups, order := groupBySource(nodes)
+
+	// Best score first, ties on alias
+	if want := []string{"api", "cli", "web", "(unknown)"}; !slices.Equal(order, want) {
+		t.Errorf("order = %v, want %v", order, want)
+	}
+	if g := groups["web"]; len(g) != 2 || g[0].QualifiedName != "a" || g[1].QualifiedName != "c" {
+		t.Errorf("web group should keep input order, got %+v", g)
+	}
+
+	// Which group shows up first doesn't matter
+	reordered := []ContextNode{nodes[4], nodes[0], nodes[3], nodes[2], nodes[1]}
+	if got, want := (MarkdownFormatter{}).FormatContext(reordered), (MarkdownFormatter{}).FormatContext(nodes); got != want {
+		t.Errorf("output changed with group arrival order:\n%s\nwant:\n%s", got, want)
+	}
+}
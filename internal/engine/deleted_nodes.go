@@ -0,0 +1,61 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DeletedNode is a tombstone of a node removed as stale while SOFT_DELETE
+// was on. Restored is set when a node with the same ID exists again, e.g.
+// after a revert.
+type DeletedNode struct {
+	NodeID        string    `json:"nodeId"`
+	QualifiedName string    `json:"qualifiedName"`
+	FilePath      string    `json:"filePath"`
+	Kind          string    `json:"kind"`
+	Signature     string    `json:"signature"`
+	Docstring     string    `json:"docstring,omitempty"`
+	StartLine     int       `json:"startLine"`
+	EndLine       int       `json:"endLine"`
+	SourceAlias   string    `json:"sourceAlias,omitempty"`
+	DeletedAt     time.Time `json:"deletedAt"`
+	Restored      bool      `json:"restored,omitempty"`
+}
+
+// GetDeletedNodes returns the project's nodes deleted at or after since,
+// most recent first, answering "this function used to exist". Only deletions
+// made with soft delete on are recorded, and tombstones older than the
+// retention are purged. A zero since returns every tombstone kept.
+func GetDeletedNodes(ctx context.Context, pool *pgxpool.Pool, projectID string, since time.Time) ([]DeletedNode, error) {
+	rows, err := pool.Query(ctx, `
+		SELECT d.id, COALESCE(d.qualified_name, d.name), d.file_path, d.kind,
+		       COALESCE(d.signature, ''), COALESCE(d.docstring, ''),
+		       COALESCE(d.start_line, 0), COALESCE(d.end_line, 0), COALESCE(ps.alias, ''),
+		       d.deleted_at, EXISTS (SELECT 1 FROM nodes n WHERE n.id = d.id)
+		FROM deleted_nodes d
+		JOIN workspaces ws ON d.workspace_id = ws.id
+		LEFT JOIN project_sources ps ON ws.source_id = ps.id
+		WHERE ws.project_id = $1 AND d.deleted_at >= $2
+		ORDER BY d.deleted_at DESC, d.file_path, d.start_line`, projectID, since.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("querying deleted nodes: %w", err)
+	}
+	defer rows.Close()
+
+	results := []DeletedNode{}
+	for rows.Next() {
+		var d DeletedNode
+		if err := rows.Scan(&d.NodeID, &d.QualifiedName, &d.FilePath, &d.Kind, &d.Signature, &d.Docstring,
+			&d.StartLine, &d.EndLine, &d.SourceAlias, &d.DeletedAt, &d.Restored); err != nil {
+			return nil, fmt.Errorf("scanning deleted node: %w", err)
+		}
+		results = append(results, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating deleted nodes: %w", err)
+	}
+	return results, nil
+}
@@ -0,0 +1,303 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// DefaultDuplicateThreshold is the embedding similarity above which two
+	// functions are considered likely copies.
+	DefaultDuplicateThreshold = 0.95
+
+	// duplicateCandidateCap bounds the pairwise comparison. The self-join is
+	// O(n²) distance computations with no index help, so 2,000 candidates is
+	// ~2M comparisons — a few seconds on a laptop-sized Postgres.
+	duplicateCandidateCap = 2000
+)
+
+// DuplicateCluster is a group of functions whose embeddings are all connected
+// by pairwise similarity >= the threshold. Members are not necessarily all
+// similar to each other: A~B and B~C put A, B, C in one cluster.
+type DuplicateCluster struct {
+	Nodes         []ClusterNode `json:"nodes"`
+	MinSimilarity float64       `json:"minSimilarity"`
+	MaxSimilarity float64       `json:"maxSimilarity"`
+}
+
+// ClusterNode is one member of a DuplicateCluster with its location.
+type ClusterNode struct {
+	NodeID        string `json:"nodeId"`
+	QualifiedName string `json:"qualifiedName"`
+	FilePath      string `json:"filePath"`
+	Kind          string `json:"kind"`
+	StartLine     int    `json:"startLine"`
+	EndLine       int    `json:"endLine"`
+	SourceAlias   string `json:"sourceAlias,omitempty"`
+}
+
+type similarPair struct {
+	a, b       string
+	similarity float64
+}
+
+// FindDuplicateClusters groups a project's functions and methods into clusters
+// of likely copy-paste, as connected components of the graph whose edges are
+// embedding pairs with similarity >= threshold. Clusters smaller than
+// minClusterSize are dropped.
+//
+// This compares every candidate pair in SQL, so unlike SemanticSearch it does
+// not use the vector index. Only the duplicateCandidateCap longest embedded
+// functions are considered, which keeps the cost bounded on large projects at
+// the price of missing duplicates among short helpers.
+//
+// A threshold <= 0 uses DefaultDuplicateThreshold; minClusterSize < 2 uses 2.
+// Clusters are ordered largest first.
+func FindDuplicateClusters(ctx context.Context, pool *pgxpool.Pool, projectID string, threshold float64, minClusterSize int) ([]DuplicateCluster, error) {
+	if threshold <= 0 {
+		threshold = DefaultDuplicateThreshold
+	}
+	if minClusterSize < 2 {
+		minClusterSize = 2
+	}
+
+	similarity := similaritySQL(similarityMetric(), "a.embedding", "b.embedding")
+	rows, err := pool.Query(ctx, `
+		WITH candidates AS (
+			SELECT n.id, n.embedding
+			FROM nodes n
+			JOIN workspaces ws ON n.workspace_id = ws.id
+			WHERE ws.project_id = $1
+			  AND n.embedding IS NOT NULL
+			  AND n.kind_group = 'callable'
+			ORDER BY COALESCE(n.end_line, 0) - COALESCE(n.start_line, 0) DESC, n.id
+			LIMIT $3
+		)
+		SELECT a.id, b.id, `+similarity+`
+		FROM candidates a
+		JOIN candidates b ON a.id < b.id
+		WHERE `+similarity+` >= $2::float8`, projectID, threshold, duplicateCandidateCap)
+	if err != nil {
+		return nil, fmt.Errorf("querying similar pairs: %w", err)
+	}
+	defer rows.Close()
+
+	var pairs []similarPair
+	for rows.Next() {
+		var p similarPair
+		if err := rows.Scan(&p.a, &p.b, &p.similarity); err != nil {
+			return nil, fmt.Errorf("scanning similar pair: %w", err)
+		}
+		pairs = append(pairs, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating similar pairs: %w", err)
+	}
+
+	groups := clusterPairs(pairs, minClusterSize)
+	if len(groups) == 0 {
+		return []DuplicateCluster{}, nil
+	}
+
+	var ids []string
+	for _, g := range groups {
+		ids = append(ids, g.ids...)
+	}
+	nodes, err := loadClusterNodes(ctx, pool, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	clusters := make([]DuplicateCluster, 0, len(groups))
+	for _, g := range groups {
+		c := DuplicateCluster{MinSimilarity: g.min, MaxSimilarity: g.max}
+		for _, id := range g.ids {
+			if n, ok := nodes[id]; ok {
+				c.Nodes = append(c.Nodes, n)
+			}
+		}
+		sortClusterNodes(c.Nodes)
+		clusters = append(clusters, c)
+	}
+	return clusters, nil
+}
+
+// sortClusterNodes orders a cluster's members by file, then line.
+func sortClusterNodes(nodes []ClusterNode) {
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].FilePath != nodes[j].FilePath {
+			return nodes[i].FilePath < nodes[j].FilePath
+		}
+		return nodes[i].StartLine < nodes[j].StartLine
+	})
+}
+
+// structuralDuplicateMinLines is the shortest function FindStructuralDuplicates
+// groups. Below it, one-line getters and empty stubs share a shape by the
+// hundreds.
+const structuralDuplicateMinLines = 3
+
+// StructuralDuplicateGroup is a set of functions with the same structural
+// hash: the same code up to naming, formatting, and comments.
+type StructuralDuplicateGroup struct {
+	StructuralHash string        `json:"structuralHash"`
+	Nodes          []ClusterNode `json:"nodes"`
+}
+
+// FindStructuralDuplicates groups a project's functions and methods by
+// structural hash, across all of its sources, to find exact copies such as
+// vendored forks and duplicated utils, including copies with renamed
+// variables. Unlike FindDuplicateClusters it needs no embeddings and compares
+// every function, not just the longest, since grouping by hash is an index
+// lookup rather than a pairwise scan. A function indexed on several branches
+// counts once. Functions shorter than structuralDuplicateMinLines lines are
+// ignored. Groups are ordered largest first.
+func FindStructuralDuplicates(ctx context.Context, pool *pgxpool.Pool, projectID string) ([]StructuralDuplicateGroup, error) {
+	rows, err := pool.Query(ctx, `
+		WITH located AS (
+			SELECT DISTINCT ON (COALESCE(ws.source_id, ws.id), n.file_path, COALESCE(n.qualified_name, n.name))
+			       n.id, n.structural_hash
+			FROM nodes n
+			JOIN workspaces ws ON n.workspace_id = ws.id
+			WHERE ws.project_id = $1
+			  AND n.structural_hash IS NOT NULL
+			  AND n.kind_group = 'callable'
+			  AND COALESCE(n.end_line, 0) - COALESCE(n.start_line, 0) + 1 >= $2
+			ORDER BY COALESCE(ws.source_id, ws.id), n.file_path, COALESCE(n.qualified_name, n.name), n.id
+		)
+		SELECT structural_hash, array_agg(id ORDER BY id)
+		FROM located
+		GROUP BY structural_hash
+		HAVING COUNT(*) > 1
+		ORDER BY COUNT(*) DESC, structural_hash`, projectID, structuralDuplicateMinLines)
+	if err != nil {
+		return nil, fmt.Errorf("querying structural duplicates: %w", err)
+	}
+	defer rows.Close()
+
+	var groups []StructuralDuplicateGroup
+	var groupIDs [][]string
+	for rows.Next() {
+		var g StructuralDuplicateGroup
+		var ids []string
+		if err := rows.Scan(&g.StructuralHash, &ids); err != nil {
+			return nil, fmt.Errorf("scanning structural duplicate: %w", err)
+		}
+		groups = append(groups, g)
+		groupIDs = append(groupIDs, ids)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating structural duplicates: %w", err)
+	}
+	if len(groups) == 0 {
+		return []StructuralDuplicateGroup{}, nil
+	}
+
+	var ids []string
+	for _, g := range groupIDs {
+		ids = append(ids, g...)
+	}
+	nodes, err := loadClusterNodes(ctx, pool, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range groups {
+		for _, id := range groupIDs[i] {
+			if n, ok := nodes[id]; ok {
+				groups[i].Nodes = append(groups[i].Nodes, n)
+			}
+		}
+		sortClusterNodes(groups[i].Nodes)
+	}
+	return groups, nil
+}
+
+type pairGroup struct {
+	ids      []string
+	min, max float64
+}
+
+// clusterPairs unions similar pairs into connected components with at least
+// minSize members, largest first (ties broken by highest similarity).
+func clusterPairs(pairs []similarPair, minSize int) []pairGroup {
+	parent := make(map[string]string)
+	var find func(string) string
+	find = func(x string) string {
+		if parent[x] != x {
+			parent[x] = find(parent[x])
+		}
+		return parent[x]
+	}
+	for _, p := range pairs {
+		for _, id := range []string{p.a, p.b} {
+			if _, ok := parent[id]; !ok {
+				parent[id] = id
+			}
+		}
+		if ra, rb := find(p.a), find(p.b); ra != rb {
+			parent[ra] = rb
+		}
+	}
+
+	byRoot := make(map[string]*pairGroup)
+	for id := range parent {
+		root := find(id)
+		g, ok := byRoot[root]
+		if !ok {
+			g = &pairGroup{min: 1}
+			byRoot[root] = g
+		}
+		g.ids = append(g.ids, id)
+	}
+	for _, p := range pairs {
+		g := byRoot[find(p.a)]
+		g.min = min(g.min, p.similarity)
+		g.max = max(g.max, p.similarity)
+	}
+
+	var groups []pairGroup
+	for _, g := range byRoot {
+		if len(g.ids) < minSize {
+			continue
+		}
+		sort.Strings(g.ids)
+		groups = append(groups, *g)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if len(groups[i].ids) != len(groups[j].ids) {
+			return len(groups[i].ids) > len(groups[j].ids)
+		}
+		if groups[i].max != groups[j].max {
+			return groups[i].max > groups[j].max
+		}
+		return groups[i].ids[0] < groups[j].ids[0]
+	})
+	return groups
+}
+
+func loadClusterNodes(ctx context.Context, pool *pgxpool.Pool, ids []string) (map[string]ClusterNode, error) {
+	rows, err := pool.Query(ctx, `
+		SELECT n.id, COALESCE(n.qualified_name, n.name), n.file_path, n.kind,
+		       COALESCE(n.start_line, 0), COALESCE(n.end_line, 0), COALESCE(ps.alias, '')
+		FROM nodes n
+		JOIN workspaces ws ON n.workspace_id = ws.id
+		LEFT JOIN project_sources ps ON ws.source_id = ps.id
+		WHERE n.id = ANY($1)`, ids)
+	if err != nil {
+		return nil, fmt.Errorf("querying cluster nodes: %w", err)
+	}
+	defer rows.Close()
+
+	nodes := make(map[string]ClusterNode, len(ids))
+	for rows.Next() {
+		var n ClusterNode
+		if err := rows.Scan(&n.NodeID, &n.QualifiedName, &n.FilePath, &n.Kind, &n.StartLine, &n.EndLine, &n.SourceAlias); err != nil {
+			return nil, fmt.Errorf("scanning cluster node: %w", err)
+		}
+		nodes[n.NodeID] = n
+	}
+	return nodes, rows.Err()
+}
@@ -0,0 +1,39 @@
+package engine
+
+import "testing"
+
+func TestClusterPairs(t *testing.T) {
+	pairs := []similarPair{
+		{a: "a", b: "b", similarity: 0.97},
+		{a: "b", b: "c", similarity: 0.96},
+		{a: "d", b: "e", similarity: 0.99},
+		{a: "f", b: "g", similarity: 0.95},
+		{a: "f", b: "h", similarity: 0.98},
+		{a: "x", b: "y", similarity: 0.95},
+	}
+
+	groups := clusterPairs(pairs, 2)
+	if len(groups) != 4 {
+		t.Fatalf("expected 4 clusters, got %d: %+v", len(groups), groups)
+	}
+	// Two three-member clusters first, higher max similarity breaking the tie.
+	if got := groups[0].ids; len(got) != 3 || got[0] != "f" || got[1] != "g" || got[2] != "h" {
+		t.Errorf("expected first cluster [f g h], got %v", got)
+	}
+	if groups[0].min != 0.95 || groups[0].max != 0.98 {
+		t.Errorf("expected similarity range 0.95-0.98, got %v-%v", groups[0].min, groups[0].max)
+	}
+	if got := groups[1].ids; len(got) != 3 || got[0] != "a" || got[2] != "c" {
+		t.Errorf("expected second cluster [a b c], got %v", got)
+	}
+	if got := groups[2].ids; len(got) != 2 || got[0] != "d" {
+		t.Errorf("expected third cluster [d e], got %v", got)
+	}
+
+	if groups := clusterPairs(pairs, 3); len(groups) != 2 {
+		t.Errorf("expected 2 clusters with minSize 3, got %d", len(groups))
+	}
+	if groups := clusterPairs(nil, 2); len(groups) != 0 {
+		t.Errorf("expected no clusters for no pairs, got %d", len(groups))
+	}
+}
@@ -0,0 +1,164 @@
+package engine
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// nodeCSVHeader are the columns ExportNodesCSV writes.
+var nodeCSVHeader = []string{
+	"id", "name", "qualified_name", "kind", "file", "package",
+	"start_line", "end_line", "lines", "complexity",
+	"in_degree", "out_degree", "centrality", "exported", "is_test",
+}
+
+// complexityPatternSQL matches the decision points counted toward a
+// callable's complexity.
+const complexityPatternSQL = `'\m(if|for|while|case|catch)\M|&&|\|\|'`
+
+// ExportNodesCSV streams every node of a project to w as CSV, one row per
+// node ordered by ID, for analysis outside Postgres. Methods are placed in
+// their class's file, and package is the name of the node's workspace
+// package. Degrees count the node's edges of every kind but contains, so a
+// file holding a symbol doesn't count as using it, and centrality is the
+// node's degree over the project's other nodes (0 to 1, and above 1 only
+// for nodes with parallel edges of several kinds). complexity approximates
+// cyclomatic complexity for callables as 1 plus the if/for/while/case/catch
+// keywords and &&/|| operators in their source; it is empty for other
+// kinds. Rows are written as they're read, so memory stays flat on large
+// projects.
+func ExportNodesCSV(ctx context.Context, pool *pgxpool.Pool, projectID string, w io.Writer) error {
+	var total int
+	if err := pool.QueryRow(ctx, `SELECT COUNT(*) FROM nodes n WHERE `+projectNodeSQL("n", "$1"), projectID).Scan(&total); err != nil {
+		return fmt.Errorf("counting nodes: %w", err)
+	}
+
+	rows, err := pool.Query(ctx, `
+		WITH in_degree AS (
+			SELECT e.target_id AS id, COUNT(*) AS n
+			FROM edges e
+			JOIN nodes t ON e.target_id = t.id
+			WHERE e.kind <> 'contains' AND `+projectNodeSQL("t", "$1")+`
+			GROUP BY e.target_id
+		),
+		out_degree AS (
+			SELECT e.source_id AS id, COUNT(*) AS n
+			FROM edges e
+			JOIN nodes s ON e.source_id = s.id
+			WHERE e.kind <> 'contains' AND `+projectNodeSQL("s", "$1")+`
+			GROUP BY e.source_id
+		)
+		SELECT DISTINCT ON (n.id)
+		       n.id, n.name, COALESCE(n.qualified_name, ''), n.kind,
+		       CASE WHEN n.kind = 'method' THEN COALESCE(parent.file_path, n.file_path) ELSE n.file_path END,
+		       COALESCE(p.name, ''),
+		       COALESCE(n.start_line, 0), COALESCE(n.end_line, 0),
+		       CASE WHEN n.kind_group = 'callable'
+		            THEN 1 + regexp_count(COALESCE(n.source_code, ''), `+complexityPatternSQL+`) END,
+		       COALESCE(i.n, 0), COALESCE(o.n, 0), n.exported
+		FROM nodes n
+		LEFT JOIN packages p ON n.package_id = p.id
+		LEFT JOIN edges c ON n.kind = 'method' AND c.target_id = n.id AND c.kind = 'contains'
+		LEFT JOIN nodes parent ON c.source_id = parent.id
+		LEFT JOIN in_degree i ON i.id = n.id
+		LEFT JOIN out_degree o ON o.id = n.id
+		WHERE `+projectNodeSQL("n", "$1")+`
+		ORDER BY n.id, parent.file_path`, projectID)
+	if err != nil {
+		return fmt.Errorf("export nodes query: %w", err)
+	}
+	defer rows.Close()
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(nodeCSVHeader); err != nil {
+		return fmt.Errorf("writing header: %w", err)
+	}
+	for rows.Next() {
+		var (
+			id, name, qname, kind, file, pkg string
+			startLine, endLine, in, out      int
+			complexity                       *int
+			exported                         bool
+		)
+		if err := rows.Scan(&id, &name, &qname, &kind, &file, &pkg, &startLine, &endLine, &complexity, &in, &out, &exported); err != nil {
+			return fmt.Errorf("scanning node: %w", err)
+		}
+		centrality := 0.0
+		if total > 1 {
+			centrality = float64(in+out) / float64(total-1)
+		}
+		lines := 0
+		if endLine >= startLine && startLine > 0 {
+			lines = endLine - startLine + 1
+		}
+		cc := ""
+		if complexity != nil {
+			cc = strconv.Itoa(*complexity)
+		}
+		err := cw.Write([]string{
+			id, name, qname, kind, file, pkg,
+			strconv.Itoa(startLine), strconv.Itoa(endLine), strconv.Itoa(lines), cc,
+			strconv.Itoa(in), strconv.Itoa(out), strconv.FormatFloat(centrality, 'f', 6, 64),
+			strconv.FormatBool(exported), strconv.FormatBool(isTestFile(file)),
+		})
+		if err != nil {
+			return fmt.Errorf("writing node: %w", err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterating nodes: %w", err)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ExportEdgesCSV streams every edge between a project's nodes to w as CSV,
+// ordered by source, target, and kind. source_id and target_id join to the
+// id column of ExportNodesCSV; call_sites is how many lines the edge was
+// seen on.
+func ExportEdgesCSV(ctx context.Context, pool *pgxpool.Pool, projectID string, w io.Writer) error {
+	rows, err := pool.Query(ctx, `
+		SELECT e.source_id, e.target_id, e.kind, e.weight, COALESCE(e.line_number, 0),
+		       GREATEST(COALESCE(cardinality(e.call_sites), 0), 1)
+		FROM edges e
+		JOIN nodes s ON e.source_id = s.id
+		JOIN nodes t ON e.target_id = t.id
+		WHERE `+projectNodeSQL("s", "$1")+` AND `+projectNodeSQL("t", "$1")+`
+		ORDER BY e.source_id, e.target_id, e.kind`, projectID)
+	if err != nil {
+		return fmt.Errorf("export edges query: %w", err)
+	}
+	defer rows.Close()
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"source_id", "target_id", "kind", "weight", "line", "call_sites"}); err != nil {
+		return fmt.Errorf("writing header: %w", err)
+	}
+	for rows.Next() {
+		var (
+			source, target, kind string
+			weight               float64
+			line, callSites      int
+		)
+		if err := rows.Scan(&source, &target, &kind, &weight, &line, &callSites); err != nil {
+			return fmt.Errorf("scanning edge: %w", err)
+		}
+		err := cw.Write([]string{
+			source, target, kind,
+			strconv.FormatFloat(weight, 'f', -1, 64), strconv.Itoa(line), strconv.Itoa(callSites),
+		})
+		if err != nil {
+			return fmt.Errorf("writing edge: %w", err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterating edges: %w", err)
+	}
+	cw.Flush()
+	return cw.Error()
+}
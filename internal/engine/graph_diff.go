@@ -0,0 +1,276 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// GraphDiff describes how a project's graph changed between two indexed
+// commits, or differs between two indexed branches.
+type GraphDiff struct {
+	FromCommit    string       `json:"fromCommit"`
+	ToCommit      string       `json:"toCommit"`
+	FromBranch    string       `json:"fromBranch,omitempty"`
+	ToBranch      string       `json:"toBranch,omitempty"`
+	AddedNodes    []NodeChange `json:"addedNodes"`
+	RemovedNodes  []NodeChange `json:"removedNodes"`
+	ModifiedNodes []NodeChange `json:"modifiedNodes"`
+	AddedEdges    []EdgeChange `json:"addedEdges"`
+	RemovedEdges  []EdgeChange `json:"removedEdges"`
+}
+
+// NodeChange is one node that differs between the two manifests. OldBodyHash
+// is empty for additions and NewBodyHash is empty for removals.
+type NodeChange struct {
+	QualifiedName string `json:"qualifiedName"`
+	FilePath      string `json:"filePath"`
+	Kind          string `json:"kind"`
+	SourceAlias   string `json:"sourceAlias,omitempty"`
+	OldBodyHash   string `json:"oldBodyHash,omitempty"`
+	NewBodyHash   string `json:"newBodyHash,omitempty"`
+}
+
+// EdgeChange is one edge present in only one of the two manifests.
+type EdgeChange struct {
+	Source      string `json:"source"`
+	Target      string `json:"target"`
+	Kind        string `json:"kind"`
+	SourceAlias string `json:"sourceAlias,omitempty"`
+}
+
+type manifestNode struct {
+	sourceAlias, filePath, qname, kind, bodyHash string
+}
+
+type manifestEdge struct {
+	sourceAlias, source, target, kind string
+}
+
+// DiffIndexRuns compares the graph manifests recorded at two commits of a
+// project's sources. Nodes are matched by source, file, and qualified name, so
+// a moved node shows up as removed + added. Returns nil, nil if either commit
+// has no manifest (never indexed, non-git source, or pruned).
+func DiffIndexRuns(ctx context.Context, pool *pgxpool.Pool, projectID, fromCommit, toCommit string) (*GraphDiff, error) {
+	for _, commit := range []string{fromCommit, toCommit} {
+		var exists bool
+		err := pool.QueryRow(ctx, `
+			SELECT EXISTS (
+				SELECT 1 FROM index_manifests m
+				JOIN project_sources ps ON m.source_id = ps.id
+				WHERE ps.project_id = $1 AND m.commit_sha = $2
+			)`, projectID, commit).Scan(&exists)
+		if err != nil {
+			return nil, fmt.Errorf("checking manifest for %s: %w", commit, err)
+		}
+		if !exists {
+			return nil, nil
+		}
+	}
+
+	diff, err := diffSnapshots(ctx, pool, commitSnapshotSQL, projectID, fromCommit, toCommit)
+	if err != nil {
+		return nil, err
+	}
+	diff.FromCommit = fromCommit
+	diff.ToCommit = toCommit
+	return diff, nil
+}
+
+// DiffBranches compares the graphs of two branches indexed with
+// BRANCH_WORKSPACES, each source at the commit its branch was last indexed
+// at. FromCommit and ToCommit are left empty, since every source has its
+// own; FromBranch and ToBranch are set instead. Returns nil, nil if either branch has no indexed workspace with a
+// manifest.
+func DiffBranches(ctx context.Context, pool *pgxpool.Pool, projectID, fromBranch, toBranch string) (*GraphDiff, error) {
+	for _, branch := range []string{fromBranch, toBranch} {
+		var exists bool
+		err := pool.QueryRow(ctx, `
+			SELECT EXISTS (
+				SELECT 1 FROM index_manifests m
+				WHERE (m.source_id, m.commit_sha) IN (`+branchSnapshotSQL+`)
+			)`, projectID, branch).Scan(&exists)
+		if err != nil {
+			return nil, fmt.Errorf("checking manifest for branch %s: %w", branch, err)
+		}
+		if !exists {
+			return nil, nil
+		}
+	}
+	diff, err := diffSnapshots(ctx, pool, branchSnapshotSQL, projectID, fromBranch, toBranch)
+	if err != nil {
+		return nil, err
+	}
+	diff.FromBranch = fromBranch
+	diff.ToBranch = toBranch
+	return diff, nil
+}
+
+// diffSnapshots diffs the manifests snapshot selects for from and to.
+func diffSnapshots(ctx context.Context, pool *pgxpool.Pool, snapshot, projectID, from, to string) (*GraphDiff, error) {
+	fromNodes, err := loadManifestNodes(ctx, pool, snapshot, projectID, from)
+	if err != nil {
+		return nil, err
+	}
+	toNodes, err := loadManifestNodes(ctx, pool, snapshot, projectID, to)
+	if err != nil {
+		return nil, err
+	}
+	fromEdges, err := loadManifestEdges(ctx, pool, snapshot, projectID, from)
+	if err != nil {
+		return nil, err
+	}
+	toEdges, err := loadManifestEdges(ctx, pool, snapshot, projectID, to)
+	if err != nil {
+		return nil, err
+	}
+	return diffManifests(fromNodes, toNodes, fromEdges, toEdges), nil
+}
+
+// commitSnapshotSQL selects every source of project $1 at commit $2, for
+// loadManifestNodes and loadManifestEdges.
+const commitSnapshotSQL = `SELECT ps.id, $2::text FROM project_sources ps WHERE ps.project_id = $1`
+
+// branchSnapshotSQL selects each source of project $1 at the commit its
+// branch $2 workspace was last indexed at.
+const branchSnapshotSQL = `
+	SELECT w.source_id, w.last_indexed_commit FROM workspaces w
+	WHERE w.project_id = $1 AND w.branch = $2 AND w.last_indexed_commit IS NOT NULL`
+
+// loadManifestNodes loads the manifest nodes of the (source, commit) pairs
+// snapshot selects for key.
+func loadManifestNodes(ctx context.Context, pool *pgxpool.Pool, snapshot, projectID, key string) ([]manifestNode, error) {
+	rows, err := pool.Query(ctx, `
+		SELECT COALESCE(ps.alias, ''), mf.file_path, mn.qualified_name, mn.kind, COALESCE(mn.body_hash, '')
+		FROM manifest_files mf
+		JOIN manifest_nodes mn ON mn.source_id = mf.source_id AND mn.content_hash = mf.content_hash
+		JOIN project_sources ps ON mf.source_id = ps.id
+		WHERE (mf.source_id, mf.commit_sha) IN (`+snapshot+`)`, projectID, key)
+	if err != nil {
+		return nil, fmt.Errorf("querying manifest nodes: %w", err)
+	}
+	defer rows.Close()
+
+	var nodes []manifestNode
+	for rows.Next() {
+		var n manifestNode
+		if err := rows.Scan(&n.sourceAlias, &n.filePath, &n.qname, &n.kind, &n.bodyHash); err != nil {
+			return nil, fmt.Errorf("scanning manifest node: %w", err)
+		}
+		nodes = append(nodes, n)
+	}
+	return nodes, rows.Err()
+}
+
+// loadManifestEdges is loadManifestNodes for edges. Files sharing a content
+// hash share its edges, hence the DISTINCT.
+func loadManifestEdges(ctx context.Context, pool *pgxpool.Pool, snapshot, projectID, key string) ([]manifestEdge, error) {
+	rows, err := pool.Query(ctx, `
+		SELECT DISTINCT COALESCE(ps.alias, ''), me.source_qname, me.target_qname, me.kind
+		FROM manifest_files mf
+		JOIN manifest_edges me ON me.source_id = mf.source_id AND me.content_hash = mf.content_hash
+		JOIN project_sources ps ON mf.source_id = ps.id
+		WHERE (mf.source_id, mf.commit_sha) IN (`+snapshot+`)`, projectID, key)
+	if err != nil {
+		return nil, fmt.Errorf("querying manifest edges: %w", err)
+	}
+	defer rows.Close()
+
+	var edges []manifestEdge
+	for rows.Next() {
+		var e manifestEdge
+		if err := rows.Scan(&e.sourceAlias, &e.source, &e.target, &e.kind); err != nil {
+			return nil, fmt.Errorf("scanning manifest edge: %w", err)
+		}
+		edges = append(edges, e)
+	}
+	return edges, rows.Err()
+}
+
+// diffManifests computes added/removed/modified sets. Output is sorted so the
+// diff is stable enough to paste into a changelog.
+func diffManifests(fromNodes, toNodes []manifestNode, fromEdges, toEdges []manifestEdge) *GraphDiff {
+	type nodeKey struct{ alias, file, qname string }
+
+	diff := &GraphDiff{
+		AddedNodes:    []NodeChange{},
+		RemovedNodes:  []NodeChange{},
+		ModifiedNodes: []NodeChange{},
+		AddedEdges:    []EdgeChange{},
+		RemovedEdges:  []EdgeChange{},
+	}
+
+	before := make(map[nodeKey]manifestNode, len(fromNodes))
+	for _, n := range fromNodes {
+		before[nodeKey{n.sourceAlias, n.filePath, n.qname}] = n
+	}
+	after := make(map[nodeKey]manifestNode, len(toNodes))
+	for _, n := range toNodes {
+		after[nodeKey{n.sourceAlias, n.filePath, n.qname}] = n
+	}
+
+	for k, n := range after {
+		old, ok := before[k]
+		switch {
+		case !ok:
+			diff.AddedNodes = append(diff.AddedNodes, NodeChange{
+				QualifiedName: n.qname, FilePath: n.filePath, Kind: n.kind, SourceAlias: n.sourceAlias,
+				NewBodyHash: n.bodyHash,
+			})
+		case old.bodyHash != n.bodyHash:
+			diff.ModifiedNodes = append(diff.ModifiedNodes, NodeChange{
+				QualifiedName: n.qname, FilePath: n.filePath, Kind: n.kind, SourceAlias: n.sourceAlias,
+				OldBodyHash: old.bodyHash, NewBodyHash: n.bodyHash,
+			})
+		}
+	}
+	for k, n := range before {
+		if _, ok := after[k]; !ok {
+			diff.RemovedNodes = append(diff.RemovedNodes, NodeChange{
+				QualifiedName: n.qname, FilePath: n.filePath, Kind: n.kind, SourceAlias: n.sourceAlias,
+				OldBodyHash: n.bodyHash,
+			})
+		}
+	}
+
+	beforeEdges := make(map[manifestEdge]bool, len(fromEdges))
+	for _, e := range fromEdges {
+		beforeEdges[e] = true
+	}
+	afterEdges := make(map[manifestEdge]bool, len(toEdges))
+	for _, e := range toEdges {
+		afterEdges[e] = true
+		if !beforeEdges[e] {
+			diff.AddedEdges = append(diff.AddedEdges, EdgeChange{Source: e.source, Target: e.target, Kind: e.kind, SourceAlias: e.sourceAlias})
+		}
+	}
+	for _, e := range fromEdges {
+		if !afterEdges[e] {
+			diff.RemovedEdges = append(diff.RemovedEdges, EdgeChange{Source: e.source, Target: e.target, Kind: e.kind, SourceAlias: e.sourceAlias})
+		}
+	}
+
+	for _, list := range [][]NodeChange{diff.AddedNodes, diff.RemovedNodes, diff.ModifiedNodes} {
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].FilePath != list[j].FilePath {
+				return list[i].FilePath < list[j].FilePath
+			}
+			return list[i].QualifiedName < list[j].QualifiedName
+		})
+	}
+	for _, list := range [][]EdgeChange{diff.AddedEdges, diff.RemovedEdges} {
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].Source != list[j].Source {
+				return list[i].Source < list[j].Source
+			}
+			if list[i].Target != list[j].Target {
+				return list[i].Target < list[j].Target
+			}
+			return list[i].Kind < list[j].Kind
+		})
+	}
+
+	return diff
+}
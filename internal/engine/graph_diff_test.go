@@ -0,0 +1,52 @@
+package engine
+
+import "testing"
+
+func TestDiffManifests(t *testing.T) {
+	from := []manifestNode{
+		{filePath: "a.ts", qname: "keep", kind: "function", bodyHash: "h1"},
+		{filePath: "a.ts", qname: "change", kind: "function", bodyHash: "h2"},
+		{filePath: "a.ts", qname: "gone", kind: "function", bodyHash: "h3"},
+	}
+	to := []manifestNode{
+		{filePath: "a.ts", qname: "keep", kind: "function", bodyHash: "h1"},
+		{filePath: "a.ts", qname: "change", kind: "function", bodyHash: "h2b"},
+		{filePath: "b.ts", qname: "fresh", kind: "class", bodyHash: "h4"},
+	}
+	fromEdges := []manifestEdge{
+		{source: "keep", target: "gone", kind: "calls"},
+		{source: "keep", target: "change", kind: "calls"},
+	}
+	toEdges := []manifestEdge{
+		{source: "keep", target: "change", kind: "calls"},
+		{source: "keep", target: "fresh", kind: "uses_type"},
+	}
+
+	diff := diffManifests(from, to, fromEdges, toEdges)
+
+	if len(diff.AddedNodes) != 1 || diff.AddedNodes[0].QualifiedName != "fresh" || diff.AddedNodes[0].NewBodyHash != "h4" {
+		t.Errorf("unexpected added nodes: %+v", diff.AddedNodes)
+	}
+	if len(diff.RemovedNodes) != 1 || diff.RemovedNodes[0].QualifiedName != "gone" {
+		t.Errorf("unexpected removed nodes: %+v", diff.RemovedNodes)
+	}
+	if len(diff.ModifiedNodes) != 1 || diff.ModifiedNodes[0].OldBodyHash != "h2" || diff.ModifiedNodes[0].NewBodyHash != "h2b" {
+		t.Errorf("unexpected modified nodes: %+v", diff.ModifiedNodes)
+	}
+	if len(diff.AddedEdges) != 1 || diff.AddedEdges[0].Target != "fresh" {
+		t.Errorf("unexpected added edges: %+v", diff.AddedEdges)
+	}
+	if len(diff.RemovedEdges) != 1 || diff.RemovedEdges[0].Target != "gone" {
+		t.Errorf("unexpected removed edges: %+v", diff.RemovedEdges)
+	}
+}
+
+func TestDiffManifests_Identical(t *testing.T) {
+	nodes := []manifestNode{{filePath: "a.ts", qname: "x", kind: "function", bodyHash: "h"}}
+	edges := []manifestEdge{{source: "x", target: "y", kind: "calls"}}
+
+	diff := diffManifests(nodes, nodes, edges, edges)
+	if len(diff.AddedNodes)+len(diff.RemovedNodes)+len(diff.ModifiedNodes)+len(diff.AddedEdges)+len(diff.RemovedEdges) != 0 {
+		t.Errorf("expected empty diff, got %+v", diff)
+	}
+}
@@ -4,22 +4,41 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/maximilianfalco/mycelium/internal/config"
+	"github.com/maximilianfalco/mycelium/internal/indexer"
 )
 
 // NodeResult represents a node returned from a structural query.
 type NodeResult struct {
-	NodeID        string `json:"nodeId"`
-	QualifiedName string `json:"qualifiedName"`
-	FilePath      string `json:"filePath"`
-	Kind          string `json:"kind"`
-	Signature     string `json:"signature"`
-	SourceCode    string `json:"sourceCode,omitempty"`
-	Docstring     string `json:"docstring,omitempty"`
-	Depth         int    `json:"depth,omitempty"`
-	SourceAlias   string `json:"sourceAlias,omitempty"`
+	NodeID        string   `json:"nodeId"`
+	QualifiedName string   `json:"qualifiedName"`
+	FilePath      string   `json:"filePath"`
+	Kind          string   `json:"kind"`
+	Signature     string   `json:"signature"`
+	SourceCode    string   `json:"sourceCode,omitempty"`
+	Docstring     string   `json:"docstring,omitempty"`
+	Modifiers     []string `json:"modifiers,omitempty"`
+	ReleaseTag    string   `json:"releaseTag,omitempty"`
+	Deprecated    bool     `json:"deprecated,omitempty"`
+	Owners        []string `json:"owners,omitempty"`
+	Depth         int      `json:"depth,omitempty"`
+	SourceAlias   string   `json:"sourceAlias,omitempty"`
+	// BlastRadius is the number of transitive dependents, filled in only by
+	// AnnotateBlastRadius.
+	BlastRadius *int `json:"blastRadius,omitempty"`
+	// InDegree counts the incoming edges of the walked kinds, filled in by
+	// GetDependencies and GetDependents while the hub guard is on. IsHub
+	// marks nodes above the threshold; a dependents walk returns them but
+	// doesn't continue through them, so their own dependents are missing.
+	InDegree int  `json:"inDegree,omitempty"`
+	IsHub    bool `json:"isHub,omitempty"`
 }
 
 // EdgeResult represents an edge returned from cross-package queries.
@@ -36,19 +55,29 @@ type EdgeResult struct {
 // FindNodeByQualifiedName looks up a node by its qualified name within a project.
 // Returns nil, nil if no matching node is found.
 func FindNodeByQualifiedName(ctx context.Context, pool *pgxpool.Pool, projectID, qualifiedName string) (*NodeResult, error) {
+	return FindNodeByQualifiedNameOnBranch(ctx, pool, projectID, "", qualifiedName)
+}
+
+// FindNodeByQualifiedNameOnBranch is FindNodeByQualifiedName limited to the
+// workspaces indexed from branch with BRANCH_WORKSPACES. An empty branch
+// looks on each source's default branch. When several sources define the
+// name, the lowest node ID wins, so repeated lookups agree.
+func FindNodeByQualifiedNameOnBranch(ctx context.Context, pool *pgxpool.Pool, projectID, branch, qualifiedName string) (*NodeResult, error) {
+	branchSQL, branchArgs := branchScopeSQL(branch, 3)
 	sql := `
 		SELECT n.id, COALESCE(n.qualified_name, n.name), n.file_path, n.kind,
 		       COALESCE(n.signature, ''), COALESCE(n.source_code, ''),
-		       COALESCE(n.docstring, ''), COALESCE(ps.alias, '')
+		       COALESCE(n.docstring, ''), COALESCE(n.modifiers, '{}'), COALESCE(n.release_tag, ''), n.deprecated, COALESCE(n.owners, '{}'), COALESCE(ps.alias, '')
 		FROM nodes n
 		JOIN workspaces ws ON n.workspace_id = ws.id
 		LEFT JOIN project_sources ps ON ws.source_id = ps.id
-		WHERE ws.project_id = $1 AND n.qualified_name = $2
+		WHERE ws.project_id = $1 AND n.qualified_name = $2` + branchSQL + `
+		ORDER BY n.id
 		LIMIT 1`
 
 	var r NodeResult
-	err := pool.QueryRow(ctx, sql, projectID, qualifiedName).Scan(
-		&r.NodeID, &r.QualifiedName, &r.FilePath, &r.Kind, &r.Signature, &r.SourceCode, &r.Docstring, &r.SourceAlias,
+	err := pool.QueryRow(ctx, sql, append([]any{projectID, qualifiedName}, branchArgs...)...).Scan(
+		&r.NodeID, &r.QualifiedName, &r.FilePath, &r.Kind, &r.Signature, &r.SourceCode, &r.Docstring, &r.Modifiers, &r.ReleaseTag, &r.Deprecated, &r.Owners, &r.SourceAlias,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -69,11 +98,51 @@ func GetCallees(ctx context.Context, pool *pgxpool.Pool, nodeID string, limit in
 	return getRelated(ctx, pool, nodeID, "calls", "outgoing", limit)
 }
 
+// GetReferencers returns nodes that use the given node as a value without
+// calling it, such as passing it as a callback (incoming "references" edges).
+func GetReferencers(ctx context.Context, pool *pgxpool.Pool, nodeID string, limit int) ([]NodeResult, error) {
+	return getRelated(ctx, pool, nodeID, "references", "incoming", limit)
+}
+
 // GetImporters returns nodes that import the given node (incoming "imports" edges).
 func GetImporters(ctx context.Context, pool *pgxpool.Pool, nodeID string, limit int) ([]NodeResult, error) {
 	return getRelated(ctx, pool, nodeID, "imports", "incoming", limit)
 }
 
+// implementationEdgeKinds link a type to the interface or class it fulfils.
+var implementationEdgeKinds = []string{"implements", "extends", "satisfies"}
+
+// GetImplementers returns the types that implement or extend the given
+// interface or class (incoming "implements", "extends", or "satisfies" edges).
+func GetImplementers(ctx context.Context, pool *pgxpool.Pool, nodeID string) ([]NodeResult, error) {
+	return getImplementationRelated(ctx, pool, nodeID, "incoming")
+}
+
+// GetInterfacesImplemented returns the interfaces and base classes the given
+// type implements or extends — the inverse of GetImplementers.
+func GetInterfacesImplemented(ctx context.Context, pool *pgxpool.Pool, nodeID string) ([]NodeResult, error) {
+	return getImplementationRelated(ctx, pool, nodeID, "outgoing")
+}
+
+// getImplementationRelated is unlimited, unlike getRelated: type hierarchies
+// are small, and a truncated implementer list is misleading. A pair linked by
+// more than one kind (e.g. extends and implements) is returned once.
+func getImplementationRelated(ctx context.Context, pool *pgxpool.Pool, nodeID, direction string) ([]NodeResult, error) {
+	match := "SELECT e.source_id FROM edges e WHERE e.target_id = $1"
+	if direction == "outgoing" {
+		match = "SELECT e.target_id FROM edges e WHERE e.source_id = $1"
+	}
+	return queryNodes(ctx, pool, `
+		SELECT n.id, COALESCE(n.qualified_name, n.name), n.file_path, n.kind,
+		       COALESCE(n.signature, ''), COALESCE(n.source_code, ''),
+		       COALESCE(n.docstring, ''), COALESCE(n.modifiers, '{}'), COALESCE(n.release_tag, ''), n.deprecated, COALESCE(n.owners, '{}'), COALESCE(ps.alias, '')
+		FROM nodes n
+		JOIN workspaces ws ON n.workspace_id = ws.id
+		LEFT JOIN project_sources ps ON ws.source_id = ps.id
+		WHERE n.id IN (`+match+` AND e.kind = ANY($2))
+		ORDER BY n.file_path, n.start_line`, nodeID, implementationEdgeKinds)
+}
+
 // getRelated is the shared implementation for single-hop traversals.
 func getRelated(ctx context.Context, pool *pgxpool.Pool, nodeID, edgeKind, direction string, limit int) ([]NodeResult, error) {
 	limit = clampLimit(limit)
@@ -84,7 +153,7 @@ func getRelated(ctx context.Context, pool *pgxpool.Pool, nodeID, edgeKind, direc
 		sql = `
 			SELECT n.id, COALESCE(n.qualified_name, n.name), n.file_path, n.kind,
 			       COALESCE(n.signature, ''), COALESCE(n.source_code, ''),
-			       COALESCE(n.docstring, ''), COALESCE(ps.alias, '')
+			       COALESCE(n.docstring, ''), COALESCE(n.modifiers, '{}'), COALESCE(n.release_tag, ''), n.deprecated, COALESCE(n.owners, '{}'), COALESCE(ps.alias, '')
 			FROM nodes n
 			JOIN edges e ON e.source_id = n.id
 			JOIN workspaces ws ON n.workspace_id = ws.id
@@ -97,7 +166,7 @@ func getRelated(ctx context.Context, pool *pgxpool.Pool, nodeID, edgeKind, direc
 		sql = `
 			SELECT n.id, COALESCE(n.qualified_name, n.name), n.file_path, n.kind,
 			       COALESCE(n.signature, ''), COALESCE(n.source_code, ''),
-			       COALESCE(n.docstring, ''), COALESCE(ps.alias, '')
+			       COALESCE(n.docstring, ''), COALESCE(n.modifiers, '{}'), COALESCE(n.release_tag, ''), n.deprecated, COALESCE(n.owners, '{}'), COALESCE(ps.alias, '')
 			FROM nodes n
 			JOIN edges e ON e.target_id = n.id
 			JOIN workspaces ws ON n.workspace_id = ws.id
@@ -110,28 +179,287 @@ func getRelated(ctx context.Context, pool *pgxpool.Pool, nodeID, edgeKind, direc
 	return queryNodes(ctx, pool, sql, nodeID, edgeKind, limit)
 }
 
-// GetDependencies returns all nodes reachable via outgoing calls/imports/uses_type
-// edges up to maxDepth hops. Uses a recursive CTE with UNION for cycle safety.
-func GetDependencies(ctx context.Context, pool *pgxpool.Pool, nodeID string, maxDepth, limit int) ([]NodeResult, error) {
-	return getTransitive(ctx, pool, nodeID, "outgoing", maxDepth, limit)
+// getRelatedBatch is getRelated for many nodes in one query: up to limit
+// neighbors of each node in nodeIDs, keyed by that node's ID, each list in
+// getRelated's order. Nodes without neighbors have no entry. SourceCode is
+// left empty, since callers only label the neighbors.
+func getRelatedBatch(ctx context.Context, pool *pgxpool.Pool, nodeIDs []string, edgeKind, direction string, limit int) (map[string][]NodeResult, error) {
+	limit = clampLimit(limit)
+
+	anchor, neighbor := "e.source_id", "e.target_id"
+	if direction == "incoming" {
+		anchor, neighbor = "e.target_id", "e.source_id"
+	}
+	rows, err := pool.Query(ctx, `
+		SELECT anchor, id, qualified_name, file_path, kind, signature, docstring, modifiers, release_tag, deprecated, owners, alias
+		FROM (
+			SELECT `+anchor+` AS anchor, n.id, COALESCE(n.qualified_name, n.name) AS qualified_name, n.file_path, n.kind,
+			       COALESCE(n.signature, '') AS signature, COALESCE(n.docstring, '') AS docstring,
+			       COALESCE(n.modifiers, '{}') AS modifiers, COALESCE(n.release_tag, '') AS release_tag, n.deprecated,
+			       COALESCE(n.owners, '{}') AS owners, COALESCE(ps.alias, '') AS alias,
+			       ROW_NUMBER() OVER (PARTITION BY `+anchor+` ORDER BY e.weight DESC) AS rank
+			FROM nodes n
+			JOIN edges e ON `+neighbor+` = n.id
+			JOIN workspaces ws ON n.workspace_id = ws.id
+			LEFT JOIN project_sources ps ON ws.source_id = ps.id
+			WHERE `+anchor+` = ANY($1) AND e.kind = $2
+		) ranked
+		WHERE rank <= $3
+		ORDER BY anchor, rank`, nodeIDs, edgeKind, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query related nodes: %w", err)
+	}
+	defer rows.Close()
+
+	related := make(map[string][]NodeResult)
+	for rows.Next() {
+		var id string
+		var r NodeResult
+		if err := rows.Scan(&id, &r.NodeID, &r.QualifiedName, &r.FilePath, &r.Kind, &r.Signature, &r.Docstring, &r.Modifiers, &r.ReleaseTag, &r.Deprecated, &r.Owners, &r.SourceAlias); err != nil {
+			return nil, fmt.Errorf("scanning related node: %w", err)
+		}
+		related[id] = append(related[id], r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating related nodes: %w", err)
+	}
+	return related, nil
+}
+
+// DefaultTraversalEdgeKinds are the edge kinds walked by GetDependencies and
+// GetDependents when no explicit set is given.
+var DefaultTraversalEdgeKinds = []string{"calls", "imports", "uses_type"}
+
+var (
+	hubInDegreeMu sync.RWMutex
+	hubInDegreeV  = config.DefaultHubInDegree
+)
+
+// SetHubInDegree sets the in-degree above which a node is a hub: a ubiquitous
+// logger or helper that GetDependents returns but doesn't walk through, since
+// its thousands of callers would swamp the result and the query. The start
+// node of a walk is always expanded. 0 or less disables the guard.
+func SetHubInDegree(n int) {
+	hubInDegreeMu.Lock()
+	defer hubInDegreeMu.Unlock()
+	hubInDegreeV = max(n, 0)
+}
+
+func hubInDegree() int {
+	hubInDegreeMu.RLock()
+	defer hubInDegreeMu.RUnlock()
+	return hubInDegreeV
+}
+
+// TraversalOption customizes GetDependencies and GetDependents.
+type TraversalOption func(*traversalOptions)
+
+type traversalOptions struct {
+	skipGenerated bool
+	skipTypeOnly  bool
+}
+
+// SkipGenerated stops a traversal at nodes from machine-generated files:
+// they are neither returned nor walked through, so protobuf stubs calling
+// each other don't crowd out hand-written code.
+func SkipGenerated() TraversalOption {
+	return func(o *traversalOptions) {
+		o.skipGenerated = true
+	}
+}
+
+// SkipTypeOnly leaves out edges erased at runtime: TypeScript `import type`
+// imports, and depends_on edges backed only by them (metadata typeOnly), so
+// a walk follows runtime coupling alone. Imports mixing runtime and inline
+// `type` specifiers are still walked.
+func SkipTypeOnly() TraversalOption {
+	return func(o *traversalOptions) {
+		o.skipTypeOnly = true
+	}
+}
+
+// GetDependencies returns all nodes reachable via outgoing edges of the given
+// kinds up to maxDepth hops. Uses a recursive CTE with UNION for cycle safety.
+// A nil or empty edgeKinds walks DefaultTraversalEdgeKinds; pass e.g.
+// []string{"calls"} for a pure call graph.
+func GetDependencies(ctx context.Context, pool *pgxpool.Pool, nodeID string, maxDepth, limit int, edgeKinds []string, opts ...TraversalOption) ([]NodeResult, error) {
+	return getTransitive(ctx, pool, nodeID, "outgoing", maxDepth, limit, edgeKinds, opts)
 }
 
 // GetDependents returns all nodes that transitively depend on the given node
-// (incoming calls/imports/uses_type edges) up to maxDepth hops.
-func GetDependents(ctx context.Context, pool *pgxpool.Pool, nodeID string, maxDepth, limit int) ([]NodeResult, error) {
-	return getTransitive(ctx, pool, nodeID, "incoming", maxDepth, limit)
+// via incoming edges of the given kinds up to maxDepth hops. A nil or empty
+// edgeKinds walks DefaultTraversalEdgeKinds.
+func GetDependents(ctx context.Context, pool *pgxpool.Pool, nodeID string, maxDepth, limit int, edgeKinds []string, opts ...TraversalOption) ([]NodeResult, error) {
+	return getTransitive(ctx, pool, nodeID, "incoming", maxDepth, limit, edgeKinds, opts)
 }
 
-func getTransitive(ctx context.Context, pool *pgxpool.Pool, nodeID, direction string, maxDepth, limit int) ([]NodeResult, error) {
-	limit = clampLimit(limit)
+// BlastRadius counts the distinct nodes that transitively depend on nodeID
+// via DefaultTraversalEdgeKinds, up to maxDepth hops — how many things could
+// break if it changes. It is GetDependents reduced to COUNT(DISTINCT) in SQL,
+// so it's cheap enough to run per changed function in a review. The node
+// itself is not counted, even when it sits on a cycle.
+func BlastRadius(ctx context.Context, pool *pgxpool.Pool, nodeID string, maxDepth int) (int, error) {
+	maxDepth = clampDepth(maxDepth)
+
+	var count int
+	err := pool.QueryRow(ctx, `
+		WITH RECURSIVE traversal AS (
+			SELECT e.source_id AS node_id, 1 AS depth
+			FROM edges e
+			WHERE e.target_id = $1 AND e.kind = ANY($2)
+			UNION
+			SELECT e.source_id, t.depth + 1
+			FROM edges e
+			JOIN traversal t ON e.target_id = t.node_id
+			WHERE e.kind = ANY($2) AND t.depth < $3
+		)
+		SELECT COUNT(DISTINCT node_id) FROM traversal WHERE node_id <> $1`,
+		nodeID, DefaultTraversalEdgeKinds, maxDepth).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("blast radius query: %w", err)
+	}
+	return count, nil
+}
+
+// GetReachableExternals returns the external import specifiers — imports
+// that didn't resolve to indexed code, typically third-party packages —
+// reachable from nodeID: the imports of every file holding the node or one
+// of its transitive callees via "calls" edges, up to maxDepth hops. Imports
+// are recorded per file, so a callee counts its whole file's imports; methods
+// use the file of their class. Node built-ins and the Go standard library
+// count (stored with kind indexer.BuiltinRefKind), so "fs" or "os/exec"
+// show up; relative specifiers that failed to resolve are left out. Sorted
+// and deduplicated.
+func GetReachableExternals(ctx context.Context, pool *pgxpool.Pool, nodeID string, maxDepth int) ([]string, error) {
+	maxDepth = clampDepth(maxDepth)
+
+	rows, err := pool.Query(ctx, `
+		WITH RECURSIVE reach AS (
+			SELECT $1::text AS node_id, 0 AS depth
+			UNION
+			SELECT e.target_id, r.depth + 1
+			FROM edges e
+			JOIN reach r ON e.source_id = r.node_id
+			WHERE e.kind = 'calls' AND r.depth < $2
+		),
+		files AS (
+			SELECT DISTINCT n.workspace_id, COALESCE(owner.file_path, n.file_path) AS file_path
+			FROM reach r
+			JOIN nodes n ON n.id = r.node_id
+			LEFT JOIN edges c ON c.target_id = n.id AND c.kind = 'contains'
+			LEFT JOIN nodes owner ON owner.id = c.source_id
+		)
+		SELECT DISTINCT ur.raw_import
+		FROM unresolved_refs ur
+		JOIN nodes s ON ur.source_node_id = s.id
+		JOIN files f ON f.workspace_id = s.workspace_id AND f.file_path = s.file_path
+		WHERE (`+externalImportSQL("ur")+` OR ur.kind = $3)
+		ORDER BY ur.raw_import`,
+		nodeID, maxDepth, indexer.BuiltinRefKind)
+	if err != nil {
+		return nil, fmt.Errorf("reachable externals query: %w", err)
+	}
+	defer rows.Close()
+
+	externals := []string{}
+	for rows.Next() {
+		var spec string
+		if err := rows.Scan(&spec); err != nil {
+			return nil, fmt.Errorf("scanning external import: %w", err)
+		}
+		externals = append(externals, spec)
+	}
+	return externals, rows.Err()
+}
+
+// LowestCommonCaller returns the closest node from which both nodeA and nodeB
+// are reachable via "calls" edges — the function that coordinates the two.
+// It intersects the bounded reverse call reachability of each node (the nodes
+// themselves included at distance 0, so a node that calls the other wins) and
+// picks the member with the smallest combined distance, breaking ties on the
+// more balanced path, then on qualified name. Depth holds the combined
+// distance. Returns nil when the two share no caller within maxDepth hops.
+func LowestCommonCaller(ctx context.Context, pool *pgxpool.Pool, nodeA, nodeB string, maxDepth int) (*NodeResult, error) {
+	maxDepth = clampDepth(maxDepth)
+
+	var r NodeResult
+	err := pool.QueryRow(ctx, `
+		WITH RECURSIVE callers_a AS (
+			SELECT $1::text AS node_id, 0 AS depth
+			UNION
+			SELECT e.source_id, c.depth + 1
+			FROM edges e
+			JOIN callers_a c ON e.target_id = c.node_id
+			WHERE e.kind = 'calls' AND c.depth < $3
+		),
+		callers_b AS (
+			SELECT $2::text AS node_id, 0 AS depth
+			UNION
+			SELECT e.source_id, c.depth + 1
+			FROM edges e
+			JOIN callers_b c ON e.target_id = c.node_id
+			WHERE e.kind = 'calls' AND c.depth < $3
+		),
+		common AS (
+			SELECT a.node_id, MIN(a.depth) AS depth_a, MIN(b.depth) AS depth_b
+			FROM callers_a a
+			JOIN callers_b b ON a.node_id = b.node_id
+			GROUP BY a.node_id
+		)
+		SELECT n.id, COALESCE(n.qualified_name, n.name), n.file_path, n.kind,
+		       COALESCE(n.signature, ''), COALESCE(n.source_code, ''),
+		       COALESCE(n.docstring, ''), COALESCE(n.modifiers, '{}'), COALESCE(n.release_tag, ''), n.deprecated, COALESCE(n.owners, '{}'),
+		       c.depth_a + c.depth_b,
+		       COALESCE(ps.alias, '')
+		FROM common c
+		JOIN nodes n ON n.id = c.node_id
+		JOIN workspaces ws ON n.workspace_id = ws.id
+		LEFT JOIN project_sources ps ON ws.source_id = ps.id
+		ORDER BY c.depth_a + c.depth_b, GREATEST(c.depth_a, c.depth_b), n.qualified_name
+		LIMIT 1`,
+		nodeA, nodeB, maxDepth,
+	).Scan(&r.NodeID, &r.QualifiedName, &r.FilePath, &r.Kind, &r.Signature, &r.SourceCode, &r.Docstring, &r.Modifiers, &r.ReleaseTag, &r.Deprecated, &r.Owners, &r.Depth, &r.SourceAlias)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("lowest common caller query: %w", err)
+	}
+	return &r, nil
+}
+
+// AnnotateBlastRadius sets BlastRadius on each node, one count query per node.
+func AnnotateBlastRadius(ctx context.Context, pool *pgxpool.Pool, nodes []NodeResult, maxDepth int) error {
+	for i := range nodes {
+		n, err := BlastRadius(ctx, pool, nodes[i].NodeID, maxDepth)
+		if err != nil {
+			return err
+		}
+		nodes[i].BlastRadius = &n
+	}
+	return nil
+}
+
+// clampDepth applies the traversal depth defaults: 5 when unset, at most 10.
+func clampDepth(maxDepth int) int {
 	if maxDepth <= 0 {
-		maxDepth = 5
+		return 5
 	}
-	if maxDepth > 10 {
-		maxDepth = 10
+	return min(maxDepth, 10)
+}
+
+func getTransitive(ctx context.Context, pool *pgxpool.Pool, nodeID, direction string, maxDepth, limit int, edgeKinds []string, opts []TraversalOption) ([]NodeResult, error) {
+	limit = clampLimit(limit)
+	maxDepth = clampDepth(maxDepth)
+
+	var o traversalOptions
+	for _, opt := range opts {
+		opt(&o)
 	}
 
-	edgeKinds := []string{"calls", "imports", "uses_type"}
+	if len(edgeKinds) == 0 {
+		edgeKinds = DefaultTraversalEdgeKinds
+	}
 
 	var sql string
 	if direction == "outgoing" {
@@ -139,23 +467,27 @@ func getTransitive(ctx context.Context, pool *pgxpool.Pool, nodeID, direction st
 			WITH RECURSIVE traversal AS (
 				SELECT e.target_id AS node_id, 1 AS depth
 				FROM edges e
-				WHERE e.source_id = $1 AND e.kind = ANY($2)
+				JOIN nodes g ON g.id = e.target_id
+				WHERE e.source_id = $1 AND e.kind = ANY($2) AND NOT (g.generated AND $5)
+				  AND NOT (COALESCE(e.metadata @> '{"typeOnly": true}', false) AND $6)
 				UNION
 				SELECT e.target_id, t.depth + 1
 				FROM edges e
 				JOIN traversal t ON e.source_id = t.node_id
-				WHERE e.kind = ANY($2) AND t.depth < $3
+				JOIN nodes g ON g.id = e.target_id
+				WHERE e.kind = ANY($2) AND t.depth < $3 AND NOT (g.generated AND $5)
+				  AND NOT (COALESCE(e.metadata @> '{"typeOnly": true}', false) AND $6)
 			)
 			SELECT n.id, COALESCE(n.qualified_name, n.name), n.file_path, n.kind,
 			       COALESCE(n.signature, ''), COALESCE(n.source_code, ''),
-			       COALESCE(n.docstring, ''),
+			       COALESCE(n.docstring, ''), COALESCE(n.modifiers, '{}'), COALESCE(n.release_tag, ''), n.deprecated, COALESCE(n.owners, '{}'),
 			       MIN(t.depth) AS min_depth,
 			       COALESCE(ps.alias, '')
 			FROM nodes n
 			JOIN traversal t ON n.id = t.node_id
 			JOIN workspaces ws ON n.workspace_id = ws.id
 			LEFT JOIN project_sources ps ON ws.source_id = ps.id
-			GROUP BY n.id, n.qualified_name, n.name, n.file_path, n.kind, n.signature, n.source_code, n.docstring, ps.alias
+			GROUP BY n.id, n.qualified_name, n.name, n.file_path, n.kind, n.signature, n.source_code, n.docstring, n.modifiers, n.release_tag, n.deprecated, n.owners, ps.alias
 			ORDER BY min_depth, n.qualified_name
 			LIMIT $4`
 	} else {
@@ -163,28 +495,45 @@ func getTransitive(ctx context.Context, pool *pgxpool.Pool, nodeID, direction st
 			WITH RECURSIVE traversal AS (
 				SELECT e.source_id AS node_id, 1 AS depth
 				FROM edges e
-				WHERE e.target_id = $1 AND e.kind = ANY($2)
+				JOIN nodes g ON g.id = e.source_id
+				WHERE e.target_id = $1 AND e.kind = ANY($2) AND NOT (g.generated AND $5)
+				  AND NOT (COALESCE(e.metadata @> '{"typeOnly": true}', false) AND $6)
 				UNION
 				SELECT e.source_id, t.depth + 1
 				FROM edges e
 				JOIN traversal t ON e.target_id = t.node_id
-				WHERE e.kind = ANY($2) AND t.depth < $3
+				JOIN nodes g ON g.id = e.source_id
+				WHERE e.kind = ANY($2) AND t.depth < $3 AND NOT (g.generated AND $5)
+				  AND NOT (COALESCE(e.metadata @> '{"typeOnly": true}', false) AND $6)
+				  AND ($7 <= 0 OR (
+					SELECT COUNT(*) FROM (
+						SELECT 1 FROM edges h
+						WHERE h.target_id = t.node_id AND h.kind = ANY($2)
+						LIMIT $7 + 1
+					) hub
+				  ) <= $7)
 			)
 			SELECT n.id, COALESCE(n.qualified_name, n.name), n.file_path, n.kind,
 			       COALESCE(n.signature, ''), COALESCE(n.source_code, ''),
-			       COALESCE(n.docstring, ''),
+			       COALESCE(n.docstring, ''), COALESCE(n.modifiers, '{}'), COALESCE(n.release_tag, ''), n.deprecated, COALESCE(n.owners, '{}'),
 			       MIN(t.depth) AS min_depth,
 			       COALESCE(ps.alias, '')
 			FROM nodes n
 			JOIN traversal t ON n.id = t.node_id
 			JOIN workspaces ws ON n.workspace_id = ws.id
 			LEFT JOIN project_sources ps ON ws.source_id = ps.id
-			GROUP BY n.id, n.qualified_name, n.name, n.file_path, n.kind, n.signature, n.source_code, n.docstring, ps.alias
+			GROUP BY n.id, n.qualified_name, n.name, n.file_path, n.kind, n.signature, n.source_code, n.docstring, n.modifiers, n.release_tag, n.deprecated, n.owners, ps.alias
 			ORDER BY min_depth, n.qualified_name
 			LIMIT $4`
 	}
 
-	rows, err := pool.Query(ctx, sql, nodeID, edgeKinds, maxDepth, limit)
+	hubThreshold := hubInDegree()
+	args := []any{nodeID, edgeKinds, maxDepth, limit, o.skipGenerated, o.skipTypeOnly}
+	if direction != "outgoing" {
+		args = append(args, hubThreshold)
+	}
+
+	rows, err := pool.Query(ctx, sql, args...)
 	if err != nil {
 		return nil, fmt.Errorf("transitive query: %w", err)
 	}
@@ -193,7 +542,7 @@ func getTransitive(ctx context.Context, pool *pgxpool.Pool, nodeID, direction st
 	var results []NodeResult
 	for rows.Next() {
 		var r NodeResult
-		if err := rows.Scan(&r.NodeID, &r.QualifiedName, &r.FilePath, &r.Kind, &r.Signature, &r.SourceCode, &r.Docstring, &r.Depth, &r.SourceAlias); err != nil {
+		if err := rows.Scan(&r.NodeID, &r.QualifiedName, &r.FilePath, &r.Kind, &r.Signature, &r.SourceCode, &r.Docstring, &r.Modifiers, &r.ReleaseTag, &r.Deprecated, &r.Owners, &r.Depth, &r.SourceAlias); err != nil {
 			return nil, fmt.Errorf("scanning transitive row: %w", err)
 		}
 		results = append(results, r)
@@ -204,9 +553,54 @@ func getTransitive(ctx context.Context, pool *pgxpool.Pool, nodeID, direction st
 	if results == nil {
 		results = []NodeResult{}
 	}
+	if hubThreshold > 0 {
+		if err := annotateInDegree(ctx, pool, results, edgeKinds, hubThreshold); err != nil {
+			return nil, err
+		}
+	}
 	return results, nil
 }
 
+// annotateInDegree fills in InDegree and IsHub on traversal results.
+func annotateInDegree(ctx context.Context, pool *pgxpool.Pool, nodes []NodeResult, edgeKinds []string, threshold int) error {
+	if len(nodes) == 0 {
+		return nil
+	}
+	ids := make([]string, len(nodes))
+	for i, n := range nodes {
+		ids[i] = n.NodeID
+	}
+
+	rows, err := pool.Query(ctx, `
+		SELECT target_id, COUNT(*)
+		FROM edges
+		WHERE target_id = ANY($1) AND kind = ANY($2)
+		GROUP BY target_id`, ids, edgeKinds)
+	if err != nil {
+		return fmt.Errorf("counting in-degree: %w", err)
+	}
+	defer rows.Close()
+
+	degrees := make(map[string]int, len(nodes))
+	for rows.Next() {
+		var id string
+		var degree int
+		if err := rows.Scan(&id, &degree); err != nil {
+			return fmt.Errorf("scanning in-degree row: %w", err)
+		}
+		degrees[id] = degree
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterating in-degree rows: %w", err)
+	}
+
+	for i := range nodes {
+		nodes[i].InDegree = degrees[nodes[i].NodeID]
+		nodes[i].IsHub = nodes[i].InDegree > threshold
+	}
+	return nil
+}
+
 // GetCrossPackageDeps returns all edges between nodes in two packages.
 func GetCrossPackageDeps(ctx context.Context, pool *pgxpool.Pool, packageA, packageB string, limit int) ([]EdgeResult, error) {
 	limit = clampLimit(limit)
@@ -251,16 +645,326 @@ func GetFileContext(ctx context.Context, pool *pgxpool.Pool, filePath, projectID
 	sql := `
 		SELECT n.id, COALESCE(n.qualified_name, n.name), n.file_path, n.kind,
 		       COALESCE(n.signature, ''), COALESCE(n.source_code, ''),
-		       COALESCE(n.docstring, ''), COALESCE(ps.alias, '')
+		       COALESCE(n.docstring, ''), COALESCE(n.modifiers, '{}'), COALESCE(n.release_tag, ''), n.deprecated, COALESCE(n.owners, '{}'), COALESCE(ps.alias, '')
 		FROM nodes n
 		JOIN workspaces ws ON n.workspace_id = ws.id
 		LEFT JOIN project_sources ps ON ws.source_id = ps.id
-		WHERE ws.project_id = $1 AND n.file_path = $2
+		WHERE ws.project_id = $1 AND n.file_path = $2` + defaultBranchSQL("ws") + `
 		ORDER BY n.start_line`
 
 	return queryNodes(ctx, pool, sql, projectID, filePath)
 }
 
+// GetSiblings returns the node's peers: the other members of its class or
+// receiver for a method, or the other top-level nodes of its file otherwise.
+// Members are found through their parent's contains edges, so same-named
+// classes in different files never mix. Go package nodes span files and
+// don't count as a parent. Ordered by start line.
+func GetSiblings(ctx context.Context, pool *pgxpool.Pool, nodeID string) ([]NodeResult, error) {
+	sql := `
+		WITH parent AS (
+			SELECT e.source_id AS id
+			FROM edges e
+			JOIN nodes p ON e.source_id = p.id
+			WHERE e.target_id = $1 AND e.kind = 'contains' AND p.kind <> 'package'
+		)
+		SELECT n.id, COALESCE(n.qualified_name, n.name), n.file_path, n.kind,
+		       COALESCE(n.signature, ''), COALESCE(n.source_code, ''),
+		       COALESCE(n.docstring, ''), COALESCE(n.modifiers, '{}'), COALESCE(n.release_tag, ''), n.deprecated, COALESCE(n.owners, '{}'), COALESCE(ps.alias, '')
+		FROM nodes self
+		JOIN nodes n ON n.workspace_id = self.workspace_id AND n.id <> self.id
+		JOIN workspaces ws ON n.workspace_id = ws.id
+		LEFT JOIN project_sources ps ON ws.source_id = ps.id
+		WHERE self.id = $1
+		  AND CASE WHEN EXISTS (SELECT 1 FROM parent) THEN EXISTS (
+			SELECT 1 FROM edges e
+			WHERE e.target_id = n.id AND e.kind = 'contains' AND e.source_id IN (SELECT id FROM parent)
+		  ) ELSE n.file_path = self.file_path AND NOT EXISTS (
+			SELECT 1 FROM edges e
+			JOIN nodes p ON e.source_id = p.id
+			WHERE e.target_id = n.id AND e.kind = 'contains' AND p.kind <> 'package'
+		  ) END
+		ORDER BY n.start_line, n.id`
+
+	results, err := queryNodes(ctx, pool, sql, nodeID)
+	if err != nil {
+		return nil, fmt.Errorf("finding siblings: %w", err)
+	}
+	return results, nil
+}
+
+// GetNodeAtPosition returns the innermost node in filePath whose line range
+// contains line, e.g. to map a stack-trace frame to a graph node. Nested
+// matches are ordered by smallest range, then methods before their class.
+// Methods are stored under their class/receiver rather than the file, so they
+// are matched through the parent's contains edge. Returns nil, nil if no node
+// covers the line.
+func GetNodeAtPosition(ctx context.Context, pool *pgxpool.Pool, projectID, filePath string, line int) (*NodeResult, error) {
+	sql := `
+		SELECT n.id, COALESCE(n.qualified_name, n.name), n.file_path, n.kind,
+		       COALESCE(n.signature, ''), COALESCE(n.source_code, ''),
+		       COALESCE(n.docstring, ''), COALESCE(n.modifiers, '{}'), COALESCE(n.release_tag, ''), n.deprecated, COALESCE(n.owners, '{}'), COALESCE(ps.alias, '')
+		FROM nodes n
+		JOIN workspaces ws ON n.workspace_id = ws.id
+		LEFT JOIN project_sources ps ON ws.source_id = ps.id
+		WHERE ws.project_id = $1` + defaultBranchSQL("ws") + `
+		  AND n.start_line <= $3 AND n.end_line >= $3
+		  AND (n.file_path = $2 OR EXISTS (
+			SELECT 1 FROM edges e
+			JOIN nodes p ON e.source_id = p.id
+			WHERE e.target_id = n.id AND e.kind = 'contains' AND p.file_path = $2
+		  ))
+		ORDER BY n.end_line - n.start_line, CASE WHEN n.kind = 'method' THEN 0 ELSE 1 END, n.start_line DESC
+		LIMIT 1`
+
+	results, err := queryNodes(ctx, pool, sql, projectID, filePath, line)
+	if err != nil {
+		return nil, fmt.Errorf("finding node at position: %w", err)
+	}
+	if len(results) == 0 {
+		return nil, nil
+	}
+	return &results[0], nil
+}
+
+// GotoDefinition resolves symbol as used on line of filePath to the node
+// defining it, like an editor's go-to-definition, e.g. "validateToken" or
+// "auth.validateToken" at a call site. The enclosing node (GetNodeAtPosition)
+// is searched first for an outgoing calls edge to a node of that name,
+// preferring an edge recorded on the line. Failing that, the files that
+// filePath imports are searched for a node of that name, which also covers
+// import lines and symbols used outside any function. A trailing "(" is
+// ignored. Returns nil, nil if the symbol isn't resolved in the graph.
+func GotoDefinition(ctx context.Context, pool *pgxpool.Pool, projectID, filePath string, line int, symbol string) (*NodeResult, error) {
+	symbol = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(symbol), "("))
+	if symbol == "" {
+		return nil, nil
+	}
+	name := symbol
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+
+	enclosing, err := GetNodeAtPosition(ctx, pool, projectID, filePath, line)
+	if err != nil {
+		return nil, err
+	}
+	if enclosing != nil {
+		results, err := queryNodes(ctx, pool, `
+			SELECT n.id, COALESCE(n.qualified_name, n.name), n.file_path, n.kind,
+			       COALESCE(n.signature, ''), COALESCE(n.source_code, ''),
+			       COALESCE(n.docstring, ''), COALESCE(n.modifiers, '{}'), COALESCE(n.release_tag, ''), n.deprecated, COALESCE(n.owners, '{}'), COALESCE(ps.alias, '')
+			FROM edges e
+			JOIN nodes n ON e.target_id = n.id
+			JOIN workspaces ws ON n.workspace_id = ws.id
+			LEFT JOIN project_sources ps ON ws.source_id = ps.id
+			WHERE e.source_id = $1 AND e.kind = 'calls'
+			  AND (n.name = $2 OR n.qualified_name = $3)
+			ORDER BY COALESCE(e.line_number = $4 OR $4 = ANY(e.call_sites), false) DESC, e.weight DESC, n.id
+			LIMIT 1`, enclosing.NodeID, name, symbol, line)
+		if err != nil {
+			return nil, fmt.Errorf("finding call target: %w", err)
+		}
+		if len(results) > 0 {
+			return &results[0], nil
+		}
+	}
+
+	// Import edges land on the imported file's first node, so the symbol is
+	// looked up among that file's nodes.
+	results, err := queryNodes(ctx, pool, `
+		SELECT n.id, COALESCE(n.qualified_name, n.name), n.file_path, n.kind,
+		       COALESCE(n.signature, ''), COALESCE(n.source_code, ''),
+		       COALESCE(n.docstring, ''), COALESCE(n.modifiers, '{}'), COALESCE(n.release_tag, ''), n.deprecated, COALESCE(n.owners, '{}'), COALESCE(ps.alias, '')
+		FROM nodes s
+		JOIN workspaces sws ON s.workspace_id = sws.id
+		JOIN edges e ON e.source_id = s.id AND e.kind = 'imports'
+		JOIN nodes f ON e.target_id = f.id
+		JOIN nodes n ON n.workspace_id = f.workspace_id AND n.file_path = f.file_path
+		JOIN workspaces ws ON n.workspace_id = ws.id
+		LEFT JOIN project_sources ps ON ws.source_id = ps.id
+		WHERE sws.project_id = $1 AND s.file_path = $2`+defaultBranchSQL("sws")+`
+		  AND (n.name = $3 OR n.qualified_name = $4)
+		ORDER BY n.exported DESC, n.file_path, n.start_line
+		LIMIT 1`, projectID, filePath, name, symbol)
+	if err != nil {
+		return nil, fmt.Errorf("finding imported definition: %w", err)
+	}
+	if len(results) == 0 {
+		return nil, nil
+	}
+	return &results[0], nil
+}
+
+// GetPublicAPI returns a package's public surface: exported nodes that are not
+// tagged @internal, ordered by file and line. Go packages under internal/
+// count, since the indexed module owns them. Deprecated nodes are kept and
+// flagged.
+func GetPublicAPI(ctx context.Context, pool *pgxpool.Pool, packageID string) ([]NodeResult, error) {
+	sql := `
+		SELECT n.id, COALESCE(n.qualified_name, n.name), n.file_path, n.kind,
+		       COALESCE(n.signature, ''), COALESCE(n.source_code, ''),
+		       COALESCE(n.docstring, ''), COALESCE(n.modifiers, '{}'), COALESCE(n.release_tag, ''), n.deprecated, COALESCE(n.owners, '{}'), COALESCE(ps.alias, '')
+		FROM nodes n
+		JOIN workspaces ws ON n.workspace_id = ws.id
+		LEFT JOIN project_sources ps ON ws.source_id = ps.id
+		WHERE n.package_id = $1
+		  AND n.exported
+		  AND COALESCE(n.release_tag, '') <> 'internal'
+		ORDER BY n.file_path, n.start_line`
+
+	return queryNodes(ctx, pool, sql, packageID)
+}
+
+// GetNodesByOwner returns the nodes of a project whose file the given
+// CODEOWNERS owner owns, ordered by source, file, and line. Owners compare
+// case-insensitively, as on GitHub, and the leading @ of a user or team is
+// optional: "org/team" finds "@org/team".
+func GetNodesByOwner(ctx context.Context, pool *pgxpool.Pool, projectID, owner string) ([]NodeResult, error) {
+	if owner != "" && !strings.Contains(owner, "@") {
+		owner = "@" + owner
+	}
+	sql := `
+		SELECT n.id, COALESCE(n.qualified_name, n.name), n.file_path, n.kind,
+		       COALESCE(n.signature, ''), COALESCE(n.source_code, ''),
+		       COALESCE(n.docstring, ''), COALESCE(n.modifiers, '{}'), COALESCE(n.release_tag, ''), n.deprecated, COALESCE(n.owners, '{}'), COALESCE(ps.alias, '')
+		FROM nodes n
+		JOIN workspaces ws ON n.workspace_id = ws.id
+		LEFT JOIN project_sources ps ON ws.source_id = ps.id
+		WHERE ` + projectNodeSQL("n", "$1") + `
+		  AND EXISTS (SELECT 1 FROM unnest(n.owners) o WHERE lower(o) = lower($2))
+		ORDER BY ps.alias, n.file_path, n.start_line`
+
+	results, err := queryNodes(ctx, pool, sql, projectID, owner)
+	if err != nil {
+		return nil, fmt.Errorf("finding nodes by owner: %w", err)
+	}
+	return results, nil
+}
+
+// GetUnusedExports returns exported top-level nodes in a project that nothing
+// outside their own file uses — candidates to make private or delete. Import
+// edges land on a file's first node rather than on each imported symbol, so a
+// node counts as used when any edge reaches it from another file (calls, type
+// uses, extends, ...), from any source in the project, or an imports edge
+// targets it directly. Package entry points (see indexer.EntryPointFiles) and
+// nodes tagged @public are left out, since their consumers live outside the
+// project, and so are fixtures unless IncludeFixtures is passed. Ordered by
+// source, file, and line.
+func GetUnusedExports(ctx context.Context, pool *pgxpool.Pool, projectID string, opts ...ReportOption) ([]NodeResult, error) {
+	o := resolveReportOptions(opts)
+	sql := `
+		SELECT n.id, COALESCE(n.qualified_name, n.name), n.file_path, n.kind,
+		       COALESCE(n.signature, ''), COALESCE(n.source_code, ''),
+		       COALESCE(n.docstring, ''), COALESCE(n.modifiers, '{}'), COALESCE(n.release_tag, ''), n.deprecated, COALESCE(n.owners, '{}'), COALESCE(ps.alias, '')
+		FROM nodes n
+		JOIN workspaces ws ON n.workspace_id = ws.id
+		LEFT JOIN project_sources ps ON ws.source_id = ps.id
+		LEFT JOIN packages p ON n.package_id = p.id
+		WHERE ` + projectNodeSQL("n", "$1") + `
+		  AND n.exported
+		  AND n.kind NOT IN ('method', 'package')
+		  AND COALESCE(n.release_tag, '') <> 'public'
+		  AND (NOT n.fixture OR $3)
+		  AND NOT n.file_path = ANY(
+			SELECT CASE WHEN COALESCE(p.path, '') IN ('', '.') THEN ep ELSE p.path || '/' || ep END
+			FROM unnest($2::text[]) AS ep
+		  )
+		  AND NOT EXISTS (
+			SELECT 1 FROM edges e
+			JOIN nodes s ON e.source_id = s.id
+			WHERE e.target_id = n.id AND e.kind <> 'contains'
+			  AND (e.kind = 'imports' OR s.file_path <> n.file_path)
+		  )
+		ORDER BY ps.alias, n.file_path, n.start_line`
+
+	results, err := queryNodes(ctx, pool, sql, projectID, indexer.EntryPointFiles, o.includeFixtures)
+	if err != nil {
+		return nil, fmt.Errorf("finding unused exports: %w", err)
+	}
+	return results, nil
+}
+
+// GetOrphanFiles returns the files in a project that no imports or calls
+// edge links to any other file, in either direction — often leftovers or
+// modules that were never wired up. It complements GetUnusedExports in a
+// hygiene report. Methods are stored under their class, so their edges count
+// for the file their class lives in. Package entry points
+// (indexer.EntryPointFiles), generated files, tests, tool configs, assets,
+// and fixtures (unless IncludeFixtures is passed) are left out: nothing in
+// the project needs to import them. Ordered by path.
+func GetOrphanFiles(ctx context.Context, pool *pgxpool.Pool, projectID string, opts ...ReportOption) ([]string, error) {
+	o := resolveReportOptions(opts)
+	rows, err := pool.Query(ctx, `
+		WITH located AS (
+			SELECT n.id, n.generated, n.kind, n.fixture,
+			       CASE WHEN n.kind = 'method' THEN COALESCE(parent.file_path, n.file_path) ELSE n.file_path END AS file_path,
+			       COALESCE(p.path, '') AS package_path
+			FROM nodes n
+			LEFT JOIN packages p ON n.package_id = p.id
+			LEFT JOIN edges c ON n.kind = 'method' AND c.target_id = n.id AND c.kind = 'contains'
+			LEFT JOIN nodes parent ON c.source_id = parent.id
+			WHERE `+projectNodeSQL("n", "$1")+`
+		),
+		linked AS (
+			SELECT unnest(ARRAY[s.file_path, t.file_path]) AS file_path
+			FROM edges e
+			JOIN located s ON e.source_id = s.id
+			JOIN located t ON e.target_id = t.id
+			WHERE e.kind IN ('imports', 'calls') AND s.file_path <> t.file_path
+		)
+		SELECT l.file_path
+		FROM located l
+		WHERE NOT EXISTS (SELECT 1 FROM linked WHERE linked.file_path = l.file_path)
+		  AND NOT l.file_path = ANY(
+			SELECT CASE WHEN l.package_path IN ('', '.') THEN ep ELSE l.package_path || '/' || ep END
+			FROM unnest($2::text[]) AS ep
+		  )
+		GROUP BY l.file_path
+		HAVING NOT bool_or(l.generated) AND NOT bool_or(l.kind = 'asset')
+		   AND (NOT bool_or(l.fixture) OR $3)
+		ORDER BY l.file_path`, projectID, indexer.EntryPointFiles, o.includeFixtures)
+	if err != nil {
+		return nil, fmt.Errorf("finding orphan files: %w", err)
+	}
+	defer rows.Close()
+
+	files := []string{}
+	for rows.Next() {
+		var f string
+		if err := rows.Scan(&f); err != nil {
+			return nil, fmt.Errorf("scanning orphan file: %w", err)
+		}
+		if !isTestFile(f) && !isConfigFile(f) {
+			files = append(files, f)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating orphan files: %w", err)
+	}
+	return files, nil
+}
+
+// isTestFile reports whether path follows a Go or JS/TS test naming
+// convention: foo_test.go, foo.test.ts, foo.spec.tsx, or anything under a
+// __tests__ directory.
+func isTestFile(path string) bool {
+	base := filepath.Base(path)
+	if strings.HasSuffix(base, "_test.go") || strings.Contains("/"+filepath.ToSlash(path), "/__tests__/") {
+		return true
+	}
+	stem := strings.TrimSuffix(base, filepath.Ext(base))
+	return strings.HasSuffix(stem, ".test") || strings.HasSuffix(stem, ".spec")
+}
+
+// isConfigFile reports whether path is a tool config loaded by name, such as
+// vite.config.ts, jest.config.js, or .eslintrc.cjs, rather than imported.
+func isConfigFile(path string) bool {
+	base := filepath.Base(path)
+	stem := strings.TrimSuffix(base, filepath.Ext(base))
+	return strings.HasSuffix(stem, ".config") || (strings.HasPrefix(base, ".") && strings.Contains(base, "rc."))
+}
+
 // queryNodes is a helper that runs a query and scans results into NodeResult slices.
 func queryNodes(ctx context.Context, pool *pgxpool.Pool, sql string, args ...any) ([]NodeResult, error) {
 	rows, err := pool.Query(ctx, sql, args...)
@@ -272,7 +976,7 @@ func queryNodes(ctx context.Context, pool *pgxpool.Pool, sql string, args ...any
 	var results []NodeResult
 	for rows.Next() {
 		var r NodeResult
-		if err := rows.Scan(&r.NodeID, &r.QualifiedName, &r.FilePath, &r.Kind, &r.Signature, &r.SourceCode, &r.Docstring, &r.SourceAlias); err != nil {
+		if err := rows.Scan(&r.NodeID, &r.QualifiedName, &r.FilePath, &r.Kind, &r.Signature, &r.SourceCode, &r.Docstring, &r.Modifiers, &r.ReleaseTag, &r.Deprecated, &r.Owners, &r.SourceAlias); err != nil {
 			return nil, fmt.Errorf("scanning node row: %w", err)
 		}
 		results = append(results, r)
@@ -0,0 +1,37 @@
+package engine
+
+import "testing"
+
+func TestIsTestFile(t *testing.T) {
+	for path, want := range map[string]bool{
+		"internal/db/pool_test.go":      true,
+		"src/store.test.ts":             true,
+		"src/Button.spec.tsx":           true,
+		"src/__tests__/store.ts":        true,
+		"__tests__/setup.js":            true,
+		"src/testing.ts":                false,
+		"internal/db/pool.go":           false,
+		"src/contest.ts":                false,
+		"src/specification/schema.ts":   false,
+		"internal/testutil/fixtures.go": false,
+	} {
+		if got := isTestFile(path); got != want {
+			t.Errorf("isTestFile(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestIsConfigFile(t *testing.T) {
+	for path, want := range map[string]bool{
+		"vite.config.ts":           true,
+		"apps/web/next.config.mjs": true,
+		".eslintrc.cjs":            true,
+		"src/config.ts":            false,
+		"src/appconfig.ts":         false,
+		".env":                     false,
+	} {
+		if got := isConfigFile(path); got != want {
+			t.Errorf("isConfigFile(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
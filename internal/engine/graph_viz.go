@@ -12,6 +12,7 @@ type GraphVizNode struct {
 	Name          string `json:"name"`
 	QualifiedName string `json:"qualifiedName"`
 	Kind          string `json:"kind"`
+	KindGroup     string `json:"kindGroup"`
 	FilePath      string `json:"filePath"`
 	SourceAlias   string `json:"sourceAlias"`
 	Degree        int    `json:"degree"`
@@ -27,6 +28,8 @@ type GraphVizStats struct {
 	NodeCount int            `json:"nodeCount"`
 	EdgeCount int            `json:"edgeCount"`
 	Kinds     map[string]int `json:"kinds"`
+	// KindGroups counts nodes by language-neutral kind group.
+	KindGroups map[string]int `json:"kindGroups"`
 }
 
 type GraphVizData struct {
@@ -37,7 +40,7 @@ type GraphVizData struct {
 
 func GetProjectGraph(ctx context.Context, pool *pgxpool.Pool, projectID string) (*GraphVizData, error) {
 	nodeSQL := `
-		SELECT n.id, n.name, COALESCE(n.qualified_name, n.name), n.kind, n.file_path,
+		SELECT n.id, n.name, COALESCE(n.qualified_name, n.name), n.kind, COALESCE(n.kind_group, ''), n.file_path,
 		       COALESCE(ps.alias, ''),
 		       COALESCE(deg.degree, 0)
 		FROM nodes n
@@ -61,12 +64,16 @@ func GetProjectGraph(ctx context.Context, pool *pgxpool.Pool, projectID string)
 
 	var nodes []GraphVizNode
 	kinds := make(map[string]int)
+	kindGroups := make(map[string]int)
 	for nodeRows.Next() {
 		var n GraphVizNode
-		if err := nodeRows.Scan(&n.ID, &n.Name, &n.QualifiedName, &n.Kind, &n.FilePath, &n.SourceAlias, &n.Degree); err != nil {
+		if err := nodeRows.Scan(&n.ID, &n.Name, &n.QualifiedName, &n.Kind, &n.KindGroup, &n.FilePath, &n.SourceAlias, &n.Degree); err != nil {
 			return nil, fmt.Errorf("scanning graph node: %w", err)
 		}
 		kinds[n.Kind]++
+		if n.KindGroup != "" {
+			kindGroups[n.KindGroup]++
+		}
 		nodes = append(nodes, n)
 	}
 	if err := nodeRows.Err(); err != nil {
@@ -108,9 +115,10 @@ func GetProjectGraph(ctx context.Context, pool *pgxpool.Pool, projectID string)
 		Nodes: nodes,
 		Edges: edges,
 		Stats: GraphVizStats{
-			NodeCount: len(nodes),
-			EdgeCount: len(edges),
-			Kinds:     kinds,
+			NodeCount:  len(nodes),
+			EdgeCount:  len(edges),
+			Kinds:      kinds,
+			KindGroups: kindGroups,
 		},
 	}, nil
 }
@@ -0,0 +1,96 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// nodeCardLimit bounds each list of a NodeCard.
+const nodeCardLimit = 10
+
+// CardEntry is a node named on a NodeCard: enough to label and link it,
+// without its source.
+type CardEntry struct {
+	NodeID        string `json:"nodeId"`
+	QualifiedName string `json:"qualifiedName"`
+	Kind          string `json:"kind"`
+	Signature     string `json:"signature,omitempty"`
+}
+
+// NodeCard is a node with its immediate context, for hovers and tooltips.
+// The node's own SourceCode is left empty. Callers, Callees and Siblings
+// hold at most nodeCardLimit entries each; CallerCount and CalleeCount are
+// the full totals, so a client can show how many were cut.
+type NodeCard struct {
+	NodeResult
+	Callers     []CardEntry `json:"callers"`
+	Callees     []CardEntry `json:"callees"`
+	Siblings    []CardEntry `json:"siblings"`
+	CallerCount int         `json:"callerCount"`
+	CalleeCount int         `json:"calleeCount"`
+}
+
+// GetNodeCard returns a node with its signature, docstring, direct callers
+// and callees, and siblings (see GetSiblings), in one call. It composes the
+// single-purpose queries so editor integrations don't have to. Returns nil,
+// nil if the node doesn't exist.
+func GetNodeCard(ctx context.Context, pool *pgxpool.Pool, nodeID string) (*NodeCard, error) {
+	var card NodeCard
+	r := &card.NodeResult
+	err := pool.QueryRow(ctx, `
+		SELECT n.id, COALESCE(n.qualified_name, n.name), n.file_path, n.kind,
+		       COALESCE(n.signature, ''), COALESCE(n.docstring, ''), COALESCE(n.modifiers, '{}'),
+		       COALESCE(n.release_tag, ''), n.deprecated, COALESCE(n.owners, '{}'), COALESCE(ps.alias, ''),
+		       (SELECT COUNT(*) FROM edges WHERE target_id = n.id AND kind = 'calls'),
+		       (SELECT COUNT(*) FROM edges WHERE source_id = n.id AND kind = 'calls')
+		FROM nodes n
+		JOIN workspaces ws ON n.workspace_id = ws.id
+		LEFT JOIN project_sources ps ON ws.source_id = ps.id
+		WHERE n.id = $1`, nodeID).Scan(
+		&r.NodeID, &r.QualifiedName, &r.FilePath, &r.Kind, &r.Signature, &r.Docstring, &r.Modifiers,
+		&r.ReleaseTag, &r.Deprecated, &r.Owners, &r.SourceAlias, &card.CallerCount, &card.CalleeCount,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("looking up node: %w", err)
+	}
+
+	callers, err := GetCallers(ctx, pool, nodeID, nodeCardLimit)
+	if err != nil {
+		return nil, fmt.Errorf("finding callers: %w", err)
+	}
+	callees, err := GetCallees(ctx, pool, nodeID, nodeCardLimit)
+	if err != nil {
+		return nil, fmt.Errorf("finding callees: %w", err)
+	}
+	siblings, err := GetSiblings(ctx, pool, nodeID)
+	if err != nil {
+		return nil, err
+	}
+
+	card.Callers = cardEntries(callers, nodeCardLimit)
+	card.Callees = cardEntries(callees, nodeCardLimit)
+	card.Siblings = cardEntries(siblings, nodeCardLimit)
+	return &card, nil
+}
+
+// cardEntries reduces the first limit nodes to card entries. It never
+// returns nil, so empty lists encode as [].
+func cardEntries(nodes []NodeResult, limit int) []CardEntry {
+	entries := make([]CardEntry, 0, min(limit, len(nodes)))
+	for _, n := range nodes[:min(limit, len(nodes))] {
+		entries = append(entries, CardEntry{
+			NodeID:        n.NodeID,
+			QualifiedName: n.QualifiedName,
+			Kind:          n.Kind,
+			Signature:     n.Signature,
+		})
+	}
+	return entries
+}
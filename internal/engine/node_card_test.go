@@ -0,0 +1,23 @@
+package engine
+
+import "testing"
+
+func TestCardEntries(t *testing.T) {
+	nodes := []NodeResult{
+		{NodeID: "a", QualifiedName: "a", Kind: "function", Signature: "function a()", SourceCode: "function a() {}"},
+		{NodeID: "b", QualifiedName: "b", Kind: "function"},
+		{NodeID: "c", QualifiedName: "c", Kind: "function"},
+	}
+
+	got := cardEntries(nodes, 2)
+	if len(got) != 2 || got[0].NodeID != "a" || got[1].NodeID != "b" {
+		t.Fatalf("expected the first 2 nodes, got %+v", got)
+	}
+	if got[0].Signature != "function a()" {
+		t.Errorf("expected the signature kept, got %q", got[0].Signature)
+	}
+
+	if empty := cardEntries(nil, 2); empty == nil || len(empty) != 0 {
+		t.Errorf("expected an empty non-nil slice, got %#v", empty)
+	}
+}
@@ -0,0 +1,196 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// TreeNode is one level of the project hierarchy:
+// project → workspace → package → file → symbol. Only symbols carry
+// kind/signature/lines; source bodies are fetched on demand elsewhere.
+type TreeNode struct {
+	Type      string      `json:"type"` // "project", "workspace", "package", "file", "node"
+	ID        string      `json:"id"`
+	Name      string      `json:"name"`
+	Kind      string      `json:"kind,omitempty"`
+	Signature string      `json:"signature,omitempty"`
+	StartLine int         `json:"startLine,omitempty"`
+	EndLine   int         `json:"endLine,omitempty"`
+	Children  []*TreeNode `json:"children,omitempty"`
+}
+
+type treeWorkspaceRow struct {
+	id, name string
+}
+
+type treePackageRow struct {
+	id, workspaceID, name string
+}
+
+type treeNodeRow struct {
+	id, workspaceID, packageID, filePath, name, kind, signature string
+	startLine, endLine                                          int
+}
+
+// GetProjectTree returns the full hierarchy of a project in one call, built
+// from one flat query per level. Symbols without a package sit directly under
+// their workspace. Returns nil, nil if the project does not exist.
+func GetProjectTree(ctx context.Context, pool *pgxpool.Pool, projectID string) (*TreeNode, error) {
+	var projectName string
+	if err := pool.QueryRow(ctx, `SELECT name FROM projects WHERE id = $1`, projectID).Scan(&projectName); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("querying project: %w", err)
+	}
+
+	wsRows, err := pool.Query(ctx, `
+		SELECT ws.id, COALESCE(ps.alias, ws.name)
+		FROM workspaces ws
+		LEFT JOIN project_sources ps ON ws.source_id = ps.id
+		WHERE ws.project_id = $1`, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("querying tree workspaces: %w", err)
+	}
+	var workspaces []treeWorkspaceRow
+	for wsRows.Next() {
+		var w treeWorkspaceRow
+		if err := wsRows.Scan(&w.id, &w.name); err != nil {
+			wsRows.Close()
+			return nil, fmt.Errorf("scanning tree workspace: %w", err)
+		}
+		workspaces = append(workspaces, w)
+	}
+	wsRows.Close()
+	if err := wsRows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating tree workspaces: %w", err)
+	}
+
+	pkgRows, err := pool.Query(ctx, `
+		SELECT p.id, p.workspace_id, p.name
+		FROM packages p
+		JOIN workspaces ws ON p.workspace_id = ws.id
+		WHERE ws.project_id = $1`, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("querying tree packages: %w", err)
+	}
+	var packages []treePackageRow
+	for pkgRows.Next() {
+		var p treePackageRow
+		if err := pkgRows.Scan(&p.id, &p.workspaceID, &p.name); err != nil {
+			pkgRows.Close()
+			return nil, fmt.Errorf("scanning tree package: %w", err)
+		}
+		packages = append(packages, p)
+	}
+	pkgRows.Close()
+	if err := pkgRows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating tree packages: %w", err)
+	}
+
+	nodeRows, err := pool.Query(ctx, `
+		SELECT n.id, n.workspace_id, COALESCE(n.package_id, ''), n.file_path,
+		       COALESCE(n.qualified_name, n.name), n.kind, COALESCE(n.signature, ''),
+		       COALESCE(n.start_line, 0), COALESCE(n.end_line, 0)
+		FROM nodes n
+		JOIN workspaces ws ON n.workspace_id = ws.id
+		WHERE ws.project_id = $1`, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("querying tree nodes: %w", err)
+	}
+	defer nodeRows.Close()
+	var nodes []treeNodeRow
+	for nodeRows.Next() {
+		var n treeNodeRow
+		if err := nodeRows.Scan(&n.id, &n.workspaceID, &n.packageID, &n.filePath, &n.name, &n.kind, &n.signature, &n.startLine, &n.endLine); err != nil {
+			return nil, fmt.Errorf("scanning tree node: %w", err)
+		}
+		nodes = append(nodes, n)
+	}
+	if err := nodeRows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating tree nodes: %w", err)
+	}
+
+	return buildProjectTree(projectID, projectName, workspaces, packages, nodes), nil
+}
+
+// buildProjectTree assembles the flat rows into a nested tree. Every level is
+// sorted by name (symbols by start line) so the output is stable across calls.
+func buildProjectTree(projectID, projectName string, workspaces []treeWorkspaceRow, packages []treePackageRow, nodes []treeNodeRow) *TreeNode {
+	root := &TreeNode{Type: "project", ID: projectID, Name: projectName}
+
+	wsByID := make(map[string]*TreeNode, len(workspaces))
+	for _, w := range workspaces {
+		t := &TreeNode{Type: "workspace", ID: w.id, Name: w.name}
+		wsByID[w.id] = t
+		root.Children = append(root.Children, t)
+	}
+
+	pkgByID := make(map[string]*TreeNode, len(packages))
+	for _, p := range packages {
+		ws, ok := wsByID[p.workspaceID]
+		if !ok {
+			continue
+		}
+		t := &TreeNode{Type: "package", ID: p.id, Name: p.name}
+		pkgByID[p.id] = t
+		ws.Children = append(ws.Children, t)
+	}
+
+	// Files are keyed per parent, since the same relative path can exist in two workspaces
+	type fileKey struct{ parent, path string }
+	files := make(map[fileKey]*TreeNode)
+
+	for _, n := range nodes {
+		parent := pkgByID[n.packageID]
+		if parent == nil {
+			parent = wsByID[n.workspaceID]
+		}
+		if parent == nil {
+			continue
+		}
+
+		key := fileKey{parent.ID, n.filePath}
+		file, ok := files[key]
+		if !ok {
+			file = &TreeNode{Type: "file", ID: parent.ID + "/" + n.filePath, Name: n.filePath}
+			files[key] = file
+			parent.Children = append(parent.Children, file)
+		}
+
+		file.Children = append(file.Children, &TreeNode{
+			Type:      "node",
+			ID:        n.id,
+			Name:      n.name,
+			Kind:      n.kind,
+			Signature: n.signature,
+			StartLine: n.startLine,
+			EndLine:   n.endLine,
+		})
+	}
+
+	sortTree(root)
+	return root
+}
+
+func sortTree(t *TreeNode) {
+	sort.SliceStable(t.Children, func(i, j int) bool {
+		a, b := t.Children[i], t.Children[j]
+		// Packages before loose files within a workspace
+		if a.Type != b.Type {
+			return a.Type > b.Type
+		}
+		if a.Type == "node" && a.StartLine != b.StartLine {
+			return a.StartLine < b.StartLine
+		}
+		return a.Name < b.Name
+	})
+	for _, c := range t.Children {
+		sortTree(c)
+	}
+}
@@ -0,0 +1,59 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DefaultRecencyHalfLife is how long it takes the recency boost of a file to
+// halve after its last commit.
+const DefaultRecencyHalfLife = 14 * 24 * time.Hour
+
+// recencyDecay maps the age of a file's last commit to (0, 1]: 1 for code
+// committed just now, 0.5 after one half-life, 0.25 after two. Future
+// timestamps (clock skew) count as brand new.
+func recencyDecay(age, halfLife time.Duration) float64 {
+	if age <= 0 {
+		return 1
+	}
+	if halfLife <= 0 {
+		halfLife = DefaultRecencyHalfLife
+	}
+	return math.Pow(0.5, float64(age)/float64(halfLife))
+}
+
+// recencyMultiplier is the factor a node's score is scaled by: 1 + boost for
+// code committed just now, tending back to 1 as the commit ages.
+func recencyMultiplier(lastCommit, now time.Time, boost float64, halfLife time.Duration) float64 {
+	if boost <= 0 || lastCommit.IsZero() {
+		return 1
+	}
+	return 1 + boost*recencyDecay(now.Sub(lastCommit), halfLife)
+}
+
+// loadLastCommitTimes returns the stored last-commit time for each node that
+// has one. Nodes indexed outside git are absent from the map.
+func loadLastCommitTimes(ctx context.Context, pool *pgxpool.Pool, nodeIDs []string) (map[string]time.Time, error) {
+	rows, err := pool.Query(ctx, `
+		SELECT id, last_commit_at FROM nodes
+		WHERE id = ANY($1) AND last_commit_at IS NOT NULL`, nodeIDs)
+	if err != nil {
+		return nil, fmt.Errorf("querying last commit times: %w", err)
+	}
+	defer rows.Close()
+
+	times := make(map[string]time.Time, len(nodeIDs))
+	for rows.Next() {
+		var id string
+		var t time.Time
+		if err := rows.Scan(&id, &t); err != nil {
+			return nil, fmt.Errorf("scanning last commit time: %w", err)
+		}
+		times[id] = t
+	}
+	return times, rows.Err()
+}
@@ -0,0 +1,280 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pgvector/pgvector-go"
+	openai "github.com/sashabaranov/go-openai"
+
+	"github.com/maximilianfalco/mycelium/internal/indexer"
+)
+
+// Provenance values of a RelatedSymbol.
+const (
+	ProvenanceGraph    = "graph"
+	ProvenanceSemantic = "semantic"
+)
+
+// relatedEdgeWeights rank a one-hop neighbor by the strongest edge kind
+// linking it to the node, in either direction. Kinds not listed, like
+// contains and depends_on, don't make two symbols related.
+var relatedEdgeWeights = map[string]float64{
+	"calls":      1.0,
+	"renders":    1.0,
+	"handles":    1.0,
+	"extends":    0.9,
+	"implements": 0.9,
+	"satisfies":  0.9,
+	"embeds":     0.9,
+	"references": 0.8,
+	"uses_type":  0.7,
+	"imports":    0.5,
+	"re_exports": 0.5,
+}
+
+// relatedGraphShare is the part of a RelatedSymbol's score that comes from
+// the graph; the rest comes from embedding similarity.
+const relatedGraphShare = 0.5
+
+// RelatedSymbol is one result of GetRelatedSymbols. Score blends GraphScore
+// (the weight of the strongest edge kind, 0 for semantic-only results) and
+// SemanticScore (embedding similarity in [0, 1], 0 for graph-only results).
+// Provenance says which side found it; EdgeKinds lists the kinds of the
+// edges linking it to the node.
+type RelatedSymbol struct {
+	NodeResult
+	Score         float64  `json:"score"`
+	GraphScore    float64  `json:"graphScore"`
+	SemanticScore float64  `json:"semanticScore"`
+	Provenance    []string `json:"provenance"`
+	EdgeKinds     []string `json:"edgeKinds,omitempty"`
+}
+
+// GetRelatedSymbols returns the symbols most related to a node, as the
+// union of its one-hop graph neighbors and its nearest neighbors by
+// embedding, ranked by a blend of both scores so a symbol found both ways
+// ranks above one found either way. The node's stored embedding is used as
+// the probe; when it has none (e.g. it was indexed with embeddings off), its
+// text is embedded via client, and with a nil client only graph neighbors
+// are returned. Returns nil, nil if the node doesn't exist.
+func GetRelatedSymbols(ctx context.Context, pool *pgxpool.Pool, client *openai.Client, nodeID string, limit int) ([]RelatedSymbol, error) {
+	limit = clampLimit(limit)
+
+	var (
+		projectID, signature, docstring, source string
+		embedded                                bool
+	)
+	err := pool.QueryRow(ctx, `
+		SELECT ws.project_id, COALESCE(n.signature, ''), COALESCE(n.docstring, ''), COALESCE(n.source_code, ''),
+		       n.embedding IS NOT NULL
+		FROM nodes n
+		JOIN workspaces ws ON n.workspace_id = ws.id
+		WHERE n.id = $1`, nodeID).Scan(&projectID, &signature, &docstring, &source, &embedded)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("looking up node: %w", err)
+	}
+
+	graph, err := graphNeighbors(ctx, pool, nodeID)
+	if err != nil {
+		return nil, err
+	}
+
+	var semantic []SearchResult
+	switch {
+	case embedded:
+		semantic, err = semanticNeighbors(ctx, pool, projectID, nodeID, limit, `(SELECT embedding FROM nodes WHERE id = $1)`)
+	case client != nil:
+		var input *indexer.ChunkResult
+		if input, err = indexer.PrepareEmbeddingInput(signature, docstring, source); err != nil {
+			return nil, fmt.Errorf("preparing node text: %w", err)
+		}
+		if input.Text == "" {
+			break
+		}
+		var vec []float32
+		if vec, err = indexer.EmbedText(ctx, client, input.Text); err != nil {
+			return nil, fmt.Errorf("embedding node: %w", err)
+		}
+		semantic, err = semanticNeighbors(ctx, pool, projectID, nodeID, limit, `$4`, pgvector.NewVector(vec))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return blendRelated(graph, semantic, limit), nil
+}
+
+// graphNeighbor is a one-hop neighbor with the kinds of the edges linking
+// it to the node.
+type graphNeighbor struct {
+	NodeResult
+	kinds []string
+}
+
+// graphNeighbors returns the nodes linked to nodeID by an edge of a
+// relatedEdgeWeights kind, in either direction, one entry per neighbor.
+func graphNeighbors(ctx context.Context, pool *pgxpool.Pool, nodeID string) ([]graphNeighbor, error) {
+	kinds := make([]string, 0, len(relatedEdgeWeights))
+	for kind := range relatedEdgeWeights {
+		kinds = append(kinds, kind)
+	}
+	rows, err := pool.Query(ctx, `
+		WITH linked AS (
+			SELECT target_id AS id, kind FROM edges WHERE source_id = $1 AND kind = ANY($2)
+			UNION
+			SELECT source_id AS id, kind FROM edges WHERE target_id = $1 AND kind = ANY($2)
+		)
+		SELECT n.id, COALESCE(n.qualified_name, n.name), n.file_path, n.kind,
+		       COALESCE(n.signature, ''), COALESCE(n.source_code, ''),
+		       COALESCE(n.docstring, ''), COALESCE(n.modifiers, '{}'), COALESCE(n.release_tag, ''), n.deprecated, COALESCE(n.owners, '{}'), COALESCE(ps.alias, ''),
+		       array_agg(DISTINCT l.kind ORDER BY l.kind)
+		FROM linked l
+		JOIN nodes n ON n.id = l.id
+		JOIN workspaces ws ON n.workspace_id = ws.id
+		LEFT JOIN project_sources ps ON ws.source_id = ps.id
+		WHERE n.id <> $1
+		GROUP BY n.id, ps.alias`, nodeID, kinds)
+	if err != nil {
+		return nil, fmt.Errorf("querying graph neighbors: %w", err)
+	}
+	defer rows.Close()
+
+	var neighbors []graphNeighbor
+	for rows.Next() {
+		var g graphNeighbor
+		r := &g.NodeResult
+		if err := rows.Scan(&r.NodeID, &r.QualifiedName, &r.FilePath, &r.Kind, &r.Signature, &r.SourceCode, &r.Docstring, &r.Modifiers, &r.ReleaseTag, &r.Deprecated, &r.Owners, &r.SourceAlias, &g.kinds); err != nil {
+			return nil, fmt.Errorf("scanning graph neighbor: %w", err)
+		}
+		neighbors = append(neighbors, g)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating graph neighbors: %w", err)
+	}
+	return neighbors, nil
+}
+
+// semanticNeighbors returns the limit nodes of the project nearest to probe,
+// a vector expression over the query's parameters, excluding nodeID itself.
+// Like SemanticSearch it covers the default search scope. Only nodes of
+// nodeID's branch are compared, so its copies on other indexed branches
+// don't crowd out the results.
+func semanticNeighbors(ctx context.Context, pool *pgxpool.Pool, projectID, nodeID string, limit int, probe string, extra ...any) ([]SearchResult, error) {
+	metric := similarityMetric()
+	sql := `
+		SELECT n.id, COALESCE(n.qualified_name, n.name), n.file_path, n.kind,
+		       ` + similaritySQL(metric, "n.embedding", probe) + `,
+		       COALESCE(n.signature, ''), COALESCE(n.source_code, ''), COALESCE(n.docstring, ''),
+		       COALESCE(n.release_tag, ''), COALESCE(ps.alias, '')
+		FROM nodes n
+		JOIN workspaces ws ON n.workspace_id = ws.id
+		LEFT JOIN project_sources ps ON ws.source_id = ps.id
+		WHERE ws.project_id = $2 AND n.id <> $1 AND n.embedding IS NOT NULL
+		  AND ws.branch IS NOT DISTINCT FROM (
+		      SELECT w.branch FROM nodes s JOIN workspaces w ON s.workspace_id = w.id WHERE s.id = $1)`
+	args := append([]any{nodeID, projectID, limit}, extra...)
+	if kinds := searchKinds(nil); len(kinds) > 0 {
+		sql += kindFilterSQL(len(args) + 1)
+		args = append(args, kinds)
+	}
+	sql += `
+		ORDER BY ` + distanceSQL(metric, "n.embedding", probe) + `
+		LIMIT $3`
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, "SET LOCAL ivfflat.probes = 10"); err != nil {
+		return nil, fmt.Errorf("setting ivfflat.probes: %w", err)
+	}
+
+	rows, err := tx.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("querying semantic neighbors: %w", err)
+	}
+	defer rows.Close()
+
+	var results []SearchResult
+	for rows.Next() {
+		var r SearchResult
+		if err := rows.Scan(&r.NodeID, &r.QualifiedName, &r.FilePath, &r.Kind, &r.SemanticScore, &r.Signature, &r.SourceCode, &r.Docstring, &r.ReleaseTag, &r.SourceAlias); err != nil {
+			return nil, fmt.Errorf("scanning semantic neighbor: %w", err)
+		}
+		results = append(results, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating semantic neighbors: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("committing transaction: %w", err)
+	}
+	return results, nil
+}
+
+// blendRelated merges graph and semantic neighbors into one list, keeping
+// one entry per node, and returns the limit highest by blended score. Ties
+// are broken by qualified name so the order is stable.
+func blendRelated(graph []graphNeighbor, semantic []SearchResult, limit int) []RelatedSymbol {
+	byID := make(map[string]*RelatedSymbol, len(graph)+len(semantic))
+	var order []*RelatedSymbol
+	entry := func(r NodeResult) *RelatedSymbol {
+		if s, ok := byID[r.NodeID]; ok {
+			return s
+		}
+		s := &RelatedSymbol{NodeResult: r}
+		byID[r.NodeID] = s
+		order = append(order, s)
+		return s
+	}
+
+	for _, g := range graph {
+		s := entry(g.NodeResult)
+		for _, kind := range g.kinds {
+			s.GraphScore = max(s.GraphScore, relatedEdgeWeights[kind])
+		}
+		s.EdgeKinds = g.kinds
+		s.Provenance = append(s.Provenance, ProvenanceGraph)
+	}
+	for _, r := range semantic {
+		s := entry(NodeResult{
+			NodeID:        r.NodeID,
+			QualifiedName: r.QualifiedName,
+			FilePath:      r.FilePath,
+			Kind:          r.Kind,
+			Signature:     r.Signature,
+			SourceCode:    r.SourceCode,
+			Docstring:     r.Docstring,
+			ReleaseTag:    r.ReleaseTag,
+			SourceAlias:   r.SourceAlias,
+		})
+		s.SemanticScore = r.SemanticScore
+		s.Provenance = append(s.Provenance, ProvenanceSemantic)
+	}
+
+	for _, s := range order {
+		s.Score = relatedGraphShare*s.GraphScore + (1-relatedGraphShare)*s.SemanticScore
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		if order[i].Score != order[j].Score {
+			return order[i].Score > order[j].Score
+		}
+		return order[i].QualifiedName < order[j].QualifiedName
+	})
+
+	results := make([]RelatedSymbol, 0, min(limit, len(order)))
+	for _, s := range order[:min(limit, len(order))] {
+		results = append(results, *s)
+	}
+	return results
+}
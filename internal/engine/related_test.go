@@ -0,0 +1,47 @@
+package engine
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestBlendRelated(t *testing.T) {
+	graph := []graphNeighbor{
+		{NodeResult: NodeResult{NodeID: "callee", QualifiedName: "callee"}, kinds: []string{"calls", "imports"}},
+		{NodeResult: NodeResult{NodeID: "importer", QualifiedName: "importer"}, kinds: []string{"imports"}},
+	}
+	semantic := []SearchResult{
+		{NodeID: "importer", QualifiedName: "importer", SemanticScore: 0.9},
+		{NodeID: "lookalike", QualifiedName: "lookalike", SemanticScore: 0.8},
+	}
+
+	got := blendRelated(graph, semantic, 10)
+	var names []string
+	for _, r := range got {
+		names = append(names, r.QualifiedName)
+	}
+	if want := []string{"importer", "callee", "lookalike"}; !slices.Equal(names, want) {
+		t.Fatalf("expected order %v, got %v", want, names)
+	}
+
+	importer := got[0]
+	if !slices.Equal(importer.Provenance, []string{ProvenanceGraph, ProvenanceSemantic}) {
+		t.Errorf("expected importer found both ways, got %v", importer.Provenance)
+	}
+	if importer.Score != 0.7 {
+		t.Errorf("expected importer score 0.7, got %v", importer.Score)
+	}
+	if callee := got[1]; callee.GraphScore != 1.0 || !slices.Equal(callee.Provenance, []string{ProvenanceGraph}) {
+		t.Errorf("expected callee ranked by its calls edge, got %+v", callee)
+	}
+	if lookalike := got[2]; lookalike.GraphScore != 0 || len(lookalike.EdgeKinds) != 0 || !slices.Equal(lookalike.Provenance, []string{ProvenanceSemantic}) {
+		t.Errorf("expected lookalike from embeddings only, got %+v", lookalike)
+	}
+
+	if got := blendRelated(graph, semantic, 1); len(got) != 1 || got[0].NodeID != "importer" {
+		t.Errorf("expected limit to keep the top result, got %+v", got)
+	}
+	if got := blendRelated(nil, nil, 5); got == nil || len(got) != 0 {
+		t.Errorf("expected an empty non-nil result, got %#v", got)
+	}
+}
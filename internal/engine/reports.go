@@ -0,0 +1,295 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ReportOption customizes a project-wide report such as GetMostImported.
+type ReportOption func(*reportOptions)
+
+type reportOptions struct {
+	includeExternals bool
+	includeFixtures  bool
+}
+
+// IncludeExternals adds external imports — specifiers that didn't resolve to
+// indexed code, typically third-party packages — to reports that can rank
+// them alongside project nodes. Reports cover in-project code only by
+// default, since externals otherwise outrank everything (every file imports
+// react).
+func IncludeExternals() ReportOption {
+	return func(o *reportOptions) {
+		o.includeExternals = true
+	}
+}
+
+// IncludeFixtures keeps nodes from test fixtures and mocks (FIXTURES=tag)
+// in the hygiene reports, GetUnusedExports and GetOrphanFiles. They're left
+// out by default: nothing imports a fixture, so every one would be listed.
+func IncludeFixtures() ReportOption {
+	return func(o *reportOptions) {
+		o.includeFixtures = true
+	}
+}
+
+func resolveReportOptions(opts []ReportOption) *reportOptions {
+	o := &reportOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// projectNodeSQL is the predicate every project report scopes its nodes
+// with: the node alias belongs to one of the project's sources, whose ID is
+// the query parameter param ("$1"), on the source's default branch. It needs
+// no external filter, since externals never become nodes: vendored and
+// dependency directories are skipped at crawl, and imports of packages are
+// kept in unresolved_refs (see externalImportSQL). Edges always join two
+// indexed nodes, so a report that applies it to both endpoints only sees
+// in-project links.
+func projectNodeSQL(alias, param string) string {
+	return alias + `.workspace_id IN (SELECT ws.id FROM workspaces ws WHERE ws.project_id = ` + param + defaultBranchSQL("ws") + `)`
+}
+
+// externalImportSQL is the predicate for unresolved_refs rows (alias) that
+// name an external package. Relative and absolute specifiers that failed to
+// resolve are broken local imports, not externals. Node built-ins and the Go
+// standard library are stored under their own kind and left out.
+func externalImportSQL(alias string) string {
+	return alias + `.kind = 'imports' AND NOT starts_with(` + alias + `.raw_import, '.') AND NOT starts_with(` + alias + `.raw_import, '/')`
+}
+
+// ImportCount is one entry of GetMostImported: an imported file, or with
+// IncludeExternals an external specifier, and how many files import it.
+type ImportCount struct {
+	Target      string `json:"target"`
+	External    bool   `json:"external,omitempty"`
+	SourceAlias string `json:"sourceAlias,omitempty"`
+	Importers   int    `json:"importers"`
+}
+
+// GetMostImported ranks a project's files by how many other files import
+// them — the modules everything leans on. Import edges land on the target
+// file's first node, so they are counted per target file. With
+// IncludeExternals, external specifiers are ranked in the same list by the
+// files importing them. Ties break on target. At most limit entries
+// (default 10, max 100).
+func GetMostImported(ctx context.Context, pool *pgxpool.Pool, projectID string, limit int, opts ...ReportOption) ([]ImportCount, error) {
+	o := resolveReportOptions(opts)
+	limit = clampLimit(limit)
+
+	sql := `
+		SELECT t.file_path, false, COALESCE(ps.alias, ''), COUNT(DISTINCT (s.workspace_id, s.file_path))
+		FROM edges e
+		JOIN nodes s ON e.source_id = s.id
+		JOIN nodes t ON e.target_id = t.id
+		JOIN workspaces ws ON t.workspace_id = ws.id
+		LEFT JOIN project_sources ps ON ws.source_id = ps.id
+		WHERE e.kind = 'imports'
+		  AND ` + projectNodeSQL("s", "$1") + `
+		  AND ` + projectNodeSQL("t", "$1") + `
+		  AND (s.workspace_id, s.file_path) <> (t.workspace_id, t.file_path)
+		GROUP BY t.file_path, ps.alias`
+	if o.includeExternals {
+		sql += `
+		UNION ALL
+		SELECT ur.raw_import, true, '', COUNT(DISTINCT (s.workspace_id, s.file_path))
+		FROM unresolved_refs ur
+		JOIN nodes s ON ur.source_node_id = s.id
+		WHERE ` + externalImportSQL("ur") + `
+		  AND ` + projectNodeSQL("s", "$1") + `
+		GROUP BY ur.raw_import`
+	}
+	sql = `SELECT * FROM (` + sql + `) counts ORDER BY 4 DESC, 1, 3 LIMIT $2`
+
+	rows, err := pool.Query(ctx, sql, projectID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("most imported query: %w", err)
+	}
+	defer rows.Close()
+
+	results := []ImportCount{}
+	for rows.Next() {
+		var c ImportCount
+		if err := rows.Scan(&c.Target, &c.External, &c.SourceAlias, &c.Importers); err != nil {
+			return nil, fmt.Errorf("scanning import count: %w", err)
+		}
+		results = append(results, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating import counts: %w", err)
+	}
+	return results, nil
+}
+
+// DeepImport is one result of GetDeepImportViolations: a file importing
+// another package's file that isn't one of the entry points listed.
+type DeepImport struct {
+	ImporterFile    string   `json:"importerFile"`
+	ImporterPackage string   `json:"importerPackage,omitempty"`
+	TargetFile      string   `json:"targetFile"`
+	TargetPackage   string   `json:"targetPackage"`
+	Line            int      `json:"line,omitempty"`
+	EntryPoints     []string `json:"entryPoints"`
+	SourceAlias     string   `json:"sourceAlias,omitempty"`
+}
+
+// GetDeepImportViolations finds imports that reach into another package
+// past its entry points, like @company/core/src/internal/secret instead of
+// @company/core. A violation is a resolved imports edge between files of
+// different packages, across sources too, whose target file is none of the
+// target package's entry points: its resolved entry file and package.json
+// exports, with "*" subpath patterns matching any run of characters.
+// Packages without entry points, such as Go library packages, have no
+// boundary and are never reported. Results are ordered by source, importer,
+// and line.
+func GetDeepImportViolations(ctx context.Context, pool *pgxpool.Pool, projectID string) ([]DeepImport, error) {
+	rows, err := pool.Query(ctx, `
+		SELECT s.file_path, COALESCE(sp.name, ''), t.file_path, tp.name,
+		       COALESCE(e.line_number, 0), tp.entry_points, COALESCE(ps.alias, '')
+		FROM edges e
+		JOIN nodes s ON e.source_id = s.id
+		JOIN nodes t ON e.target_id = t.id
+		JOIN packages tp ON t.package_id = tp.id
+		LEFT JOIN packages sp ON s.package_id = sp.id
+		JOIN workspaces ws ON s.workspace_id = ws.id
+		LEFT JOIN project_sources ps ON ws.source_id = ps.id
+		WHERE e.kind = 'imports'
+		  AND `+projectNodeSQL("s", "$1")+`
+		  AND `+projectNodeSQL("t", "$1")+`
+		  AND s.package_id IS DISTINCT FROM t.package_id
+		  AND cardinality(tp.entry_points) > 0
+		ORDER BY ps.alias, s.file_path, e.line_number, t.file_path`, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("deep imports query: %w", err)
+	}
+	defer rows.Close()
+
+	results := []DeepImport{}
+	for rows.Next() {
+		var d DeepImport
+		if err := rows.Scan(&d.ImporterFile, &d.ImporterPackage, &d.TargetFile, &d.TargetPackage, &d.Line, &d.EntryPoints, &d.SourceAlias); err != nil {
+			return nil, fmt.Errorf("scanning deep import: %w", err)
+		}
+		if !isEntryPoint(d.TargetFile, d.EntryPoints) {
+			results = append(results, d)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating deep imports: %w", err)
+	}
+	return results, nil
+}
+
+// isEntryPoint reports whether file is one of entryPoints, where a "*" in an
+// entry (from a package.json subpath pattern) matches any run of characters,
+// slashes included, as Node resolves it.
+func isEntryPoint(file string, entryPoints []string) bool {
+	for _, entry := range entryPoints {
+		prefix, suffix, wildcard := strings.Cut(entry, "*")
+		if !wildcard {
+			if file == entry {
+				return true
+			}
+			continue
+		}
+		if len(file) >= len(prefix)+len(suffix) && strings.HasPrefix(file, prefix) && strings.HasSuffix(file, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// FileSize is one entry of GetLargestFiles: a file, its package, how much it
+// holds, and how many other packages it imports from.
+type FileSize struct {
+	FilePath         string `json:"filePath"`
+	Package          string `json:"package,omitempty"`
+	SourceAlias      string `json:"sourceAlias,omitempty"`
+	Nodes            int    `json:"nodes"`
+	Lines            int    `json:"lines"`
+	ImportedPackages int    `json:"importedPackages"`
+}
+
+// GetLargestFiles ranks a project's files by node count, then by lines, to
+// orient in a codebase and flag god-files. Methods count for the file their
+// class lives in. Lines add up the spans of the file's nodes, leaving out
+// methods nested in their class's span, so it undercounts code outside any
+// node. ImportedPackages is how many distinct packages other than the file's
+// own it imports from, a rough count of its responsibilities; with
+// IncludeExternals, external specifiers are counted too. Ties break on path.
+// At most limit entries (default 10, max 100).
+func GetLargestFiles(ctx context.Context, pool *pgxpool.Pool, projectID string, limit int, opts ...ReportOption) ([]FileSize, error) {
+	o := resolveReportOptions(opts)
+	limit = clampLimit(limit)
+
+	imported := `
+			SELECT s.workspace_id, s.file_path, COUNT(DISTINCT t.package_id) AS n
+			FROM edges e
+			JOIN located s ON e.source_id = s.id
+			JOIN nodes t ON e.target_id = t.id
+			WHERE e.kind = 'imports'
+			  AND ` + projectNodeSQL("t", "$1") + `
+			  AND t.package_id IS NOT NULL AND t.package_id IS DISTINCT FROM s.package_id
+			GROUP BY s.workspace_id, s.file_path`
+	if o.includeExternals {
+		imported += `
+			UNION ALL
+			SELECT s.workspace_id, s.file_path, COUNT(DISTINCT ur.raw_import)
+			FROM unresolved_refs ur
+			JOIN located s ON ur.source_node_id = s.id
+			WHERE ` + externalImportSQL("ur") + `
+			GROUP BY s.workspace_id, s.file_path`
+	}
+
+	rows, err := pool.Query(ctx, `
+		WITH located AS (
+			SELECT DISTINCT ON (n.id) n.id, n.workspace_id, n.package_id,
+			       CASE WHEN n.kind = 'method' THEN COALESCE(parent.file_path, n.file_path) ELSE n.file_path END AS file_path,
+			       CASE WHEN n.kind = 'method' AND n.start_line BETWEEN parent.start_line AND parent.end_line THEN 0
+			            ELSE GREATEST(COALESCE(n.end_line - n.start_line + 1, 0), 0) END AS lines
+			FROM nodes n
+			LEFT JOIN edges c ON n.kind = 'method' AND c.target_id = n.id AND c.kind = 'contains'
+			LEFT JOIN nodes parent ON c.source_id = parent.id
+			WHERE `+projectNodeSQL("n", "$1")+`
+			ORDER BY n.id, parent.file_path
+		),
+		files AS (
+			SELECT workspace_id, file_path, COUNT(*) AS nodes, SUM(lines) AS lines, MIN(package_id) AS package_id
+			FROM located
+			GROUP BY workspace_id, file_path
+		),
+		imported AS (`+imported+`
+		)
+		SELECT f.file_path, COALESCE(p.name, ''), COALESCE(ps.alias, ''), f.nodes, f.lines, COALESCE(SUM(i.n), 0)::int
+		FROM files f
+		JOIN workspaces ws ON f.workspace_id = ws.id
+		LEFT JOIN project_sources ps ON ws.source_id = ps.id
+		LEFT JOIN packages p ON f.package_id = p.id
+		LEFT JOIN imported i ON i.workspace_id = f.workspace_id AND i.file_path = f.file_path
+		GROUP BY f.workspace_id, f.file_path, f.nodes, f.lines, p.name, ps.alias
+		ORDER BY f.nodes DESC, f.lines DESC, f.file_path, ps.alias
+		LIMIT $2`, projectID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("largest files query: %w", err)
+	}
+	defer rows.Close()
+
+	results := []FileSize{}
+	for rows.Next() {
+		var s FileSize
+		if err := rows.Scan(&s.FilePath, &s.Package, &s.SourceAlias, &s.Nodes, &s.Lines, &s.ImportedPackages); err != nil {
+			return nil, fmt.Errorf("scanning file size: %w", err)
+		}
+		results = append(results, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating file sizes: %w", err)
+	}
+	return results, nil
+}
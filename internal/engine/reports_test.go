@@ -0,0 +1,26 @@
+package engine
+
+import "testing"
+
+func TestIsEntryPoint(t *testing.T) {
+	entries := []string{"packages/core/src/index.ts", "packages/core/src/utils/*.ts"}
+	tests := []struct {
+		file string
+		want bool
+	}{
+		{"packages/core/src/index.ts", true},
+		{"packages/core/src/utils/format.ts", true},
+		{"packages/core/src/utils/date/parse.ts", true},
+		{"packages/core/src/utils/format.js", false},
+		{"packages/core/src/internal/secret.ts", false},
+		{"packages/core/src/index.tsx", false},
+	}
+	for _, tt := range tests {
+		if got := isEntryPoint(tt.file, entries); got != tt.want {
+			t.Errorf("isEntryPoint(%q) = %v, want %v", tt.file, got, tt.want)
+		}
+	}
+	if isEntryPoint("a.ts", nil) {
+		t.Error("expected no entry point match without entries")
+	}
+}
@@ -3,40 +3,244 @@ package engine
 import (
 	"context"
 	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/pgvector/pgvector-go"
 	openai "github.com/sashabaranov/go-openai"
 
+	"github.com/maximilianfalco/mycelium/internal/config"
 	"github.com/maximilianfalco/mycelium/internal/indexer"
 )
 
-// SearchResult represents a single semantic search hit.
+// DefaultHybridAlpha weighs semantic and keyword ranks equally.
+const DefaultHybridAlpha = 0.5
+
+// Embedding variants the vector side of a search can compare against.
+// EmbeddingSignature needs nodes indexed with EMBED_SIGNATURES; EmbeddingAuto
+// picks per query with ClassifyQuery.
+const (
+	EmbeddingFull      = "full"
+	EmbeddingSignature = "signature"
+	EmbeddingAuto      = "auto"
+)
+
+// SearchOption customizes SemanticSearch and HybridSearch.
+type SearchOption func(*searchOptions)
+
+type searchOptions struct {
+	embedding string
+	branch    string
+	fixtures  bool
+}
+
+// WithEmbedding selects which stored vector the query is compared against:
+// EmbeddingFull (default), EmbeddingSignature, or EmbeddingAuto. When no node
+// has a signature vector, a signature search falls back to full embeddings
+// rather than come back empty.
+func WithEmbedding(variant string) SearchOption {
+	return func(o *searchOptions) {
+		o.embedding = variant
+	}
+}
+
+// OnBranch limits a search to the workspaces indexed from branch with
+// BRANCH_WORKSPACES. Without it a search covers each source's default
+// branch (see SetBranchWorkspaces).
+func OnBranch(branch string) SearchOption {
+	return func(o *searchOptions) {
+		o.branch = branch
+	}
+}
+
+// SearchFixtures lets nodes from test fixtures and mocks (FIXTURES=tag)
+// match. They're left out by default; since they're never embedded, only
+// keyword matches bring them in.
+func SearchFixtures() SearchOption {
+	return func(o *searchOptions) {
+		o.fixtures = true
+	}
+}
+
+// variant resolves the selected embedding for query. Without the query text
+// there's nothing to classify, so EmbeddingAuto means EmbeddingFull.
+func (o searchOptions) variant(query string) string {
+	switch o.embedding {
+	case EmbeddingSignature:
+		return EmbeddingSignature
+	case EmbeddingAuto:
+		if query != "" {
+			return ClassifyQuery(query)
+		}
+	}
+	return EmbeddingFull
+}
+
+func resolveSearchOptions(opts []SearchOption) searchOptions {
+	var o searchOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// symbolQuery matches a lone identifier, optionally qualified (Store.get,
+// http::Client, Foo#bar) or called (parse(src)).
+var symbolQuery = regexp.MustCompile(`^[A-Za-z_$][\w$]*(?:(?:\.|::|#)[A-Za-z_$][\w$]*)*(?:\(.*\))?$`)
+
+// ClassifyQuery guesses which embedding a query retrieves best from. Code-shaped
+// identifiers (parseConfig, Store.get, max_retries, render()) are exact-symbol
+// lookups, which signature embeddings answer best. Anything else, including a
+// lone plain word like "authentication", reads as a description and goes to
+// the full embeddings.
+func ClassifyQuery(query string) string {
+	q := strings.TrimSpace(query)
+	if !symbolQuery.MatchString(q) {
+		return EmbeddingFull
+	}
+	if strings.ContainsAny(q, "._:#($") || strings.ToLower(q[1:]) != q[1:] {
+		return EmbeddingSignature
+	}
+	return EmbeddingFull
+}
+
+// embeddingColumn is the nodes column holding variant's vectors.
+func embeddingColumn(variant string) string {
+	if variant == EmbeddingSignature {
+		return "n.embedding_sig"
+	}
+	return "n.embedding"
+}
+
+// SearchResult represents a single semantic search hit. Similarity is the
+// final ranking score; SemanticScore (vector similarity in [0, 1], see
+// similaritySQL) and KeywordScore (ts_rank) are the raw sub-scores behind it,
+// zero when that side missed.
 type SearchResult struct {
 	NodeID        string  `json:"nodeId"`
 	QualifiedName string  `json:"qualifiedName"`
 	FilePath      string  `json:"filePath"`
 	Kind          string  `json:"kind"`
+	KindGroup     string  `json:"kindGroup,omitempty"`
 	Similarity    float64 `json:"similarity"`
+	SemanticScore float64 `json:"semanticScore"`
+	KeywordScore  float64 `json:"keywordScore"`
 	Signature     string  `json:"signature"`
 	SourceCode    string  `json:"sourceCode,omitempty"`
 	Docstring     string  `json:"docstring,omitempty"`
+	ReleaseTag    string  `json:"releaseTag,omitempty"`
 	SourceAlias   string  `json:"sourceAlias,omitempty"`
 }
 
-// SemanticSearch embeds the query text via OpenAI, then runs a pgvector cosine
-// similarity search against all indexed nodes in the given project.
-func SemanticSearch(ctx context.Context, pool *pgxpool.Pool, client *openai.Client, query string, projectID string, limit int, kinds []string) ([]SearchResult, error) {
+var (
+	searchKindsMu sync.RWMutex
+	searchKindsV  = config.DefaultSearchKinds
+)
+
+// SetSearchKinds sets the kinds and kind groups SemanticSearch and
+// HybridSearch cover when called without kinds. Structural queries always
+// see every kind. An empty list lifts the default scope.
+func SetSearchKinds(kinds []string) {
+	searchKindsMu.Lock()
+	defer searchKindsMu.Unlock()
+	searchKindsV = slices.Clone(kinds)
+}
+
+// searchKinds returns the kind filter a search applies: kinds when the
+// caller named any, the default search scope otherwise.
+func searchKinds(kinds []string) []string {
+	if len(kinds) > 0 {
+		return kinds
+	}
+	searchKindsMu.RLock()
+	defer searchKindsMu.RUnlock()
+	return searchKindsV
+}
+
+// branchFilterSQL matches nodes of workspaces indexed from the branch in
+// parameter $argIdx.
+func branchFilterSQL(argIdx int) string {
+	return fmt.Sprintf(` AND ws.branch = $%d`, argIdx)
+}
+
+var (
+	branchWorkspacesMu sync.RWMutex
+	branchWorkspacesV  bool
+)
+
+// SetBranchWorkspaces tells queries whether sources are indexed with
+// BRANCH_WORKSPACES. When they are, a query that names no branch covers each
+// source's default branch, the one it had checked out when last indexed,
+// instead of every indexed branch at once.
+func SetBranchWorkspaces(on bool) {
+	branchWorkspacesMu.Lock()
+	defer branchWorkspacesMu.Unlock()
+	branchWorkspacesV = on
+}
+
+// defaultBranchSQL limits the workspace alias ws to its source's default
+// branch when BRANCH_WORKSPACES is on, and is empty otherwise. A source not
+// indexed on any branch yet keeps its plain workspace as the default.
+func defaultBranchSQL(ws string) string {
+	branchWorkspacesMu.RLock()
+	defer branchWorkspacesMu.RUnlock()
+	if !branchWorkspacesV {
+		return ""
+	}
+	return ` AND (` + ws + `.branch IS NOT DISTINCT FROM (SELECT last_indexed_branch FROM project_sources WHERE id = ` + ws + `.source_id)
+		OR (` + ws + `.branch IS NULL AND NOT EXISTS (
+			SELECT 1 FROM workspaces bw WHERE bw.source_id = ` + ws + `.source_id AND bw.branch IS NOT NULL)))`
+}
+
+// branchScopeSQL limits the workspace alias ws to branch, passed as parameter
+// $argIdx, or to the default branch when branch is empty. It returns the
+// predicate and the arguments it adds.
+func branchScopeSQL(branch string, argIdx int) (string, []any) {
+	if branch != "" {
+		return branchFilterSQL(argIdx), []any{branch}
+	}
+	return defaultBranchSQL("ws"), nil
+}
+
+// kindFilterSQL matches nodes whose kind or kind group is in the array
+// parameter $argIdx, so filters can mix precise kinds ("struct") with
+// language-neutral groups ("callable").
+func kindFilterSQL(argIdx int) string {
+	return fmt.Sprintf(` AND (n.kind = ANY($%d) OR n.kind_group = ANY($%d))`, argIdx, argIdx)
+}
+
+// SemanticSearch embeds the query text via OpenAI, then runs a pgvector
+// similarity search against the project's nodes of the given kinds, or of
+// the default search scope (see SetSearchKinds) when kinds is empty.
+func SemanticSearch(ctx context.Context, pool *pgxpool.Pool, client *openai.Client, query string, projectID string, limit int, kinds []string, opts ...SearchOption) ([]SearchResult, error) {
 	queryVec, err := indexer.EmbedText(ctx, client, query)
 	if err != nil {
 		return nil, fmt.Errorf("embedding query: %w", err)
 	}
-	return SemanticSearchWithVector(ctx, pool, queryVec, projectID, limit, kinds)
+	o := resolveSearchOptions(opts)
+	return SemanticSearchWithVector(ctx, pool, queryVec, projectID, limit, kinds, WithEmbedding(o.variant(query)), OnBranch(o.branch))
 }
 
 // SemanticSearchWithVector runs the pgvector similarity search using a
 // pre-computed query vector. Useful for testing without an OpenAI client.
-func SemanticSearchWithVector(ctx context.Context, pool *pgxpool.Pool, queryVec []float32, projectID string, limit int, kinds []string) ([]SearchResult, error) {
+func SemanticSearchWithVector(ctx context.Context, pool *pgxpool.Pool, queryVec []float32, projectID string, limit int, kinds []string, opts ...SearchOption) ([]SearchResult, error) {
+	o := resolveSearchOptions(opts)
+	variant := o.variant("")
+	results, err := semanticSearch(ctx, pool, queryVec, projectID, limit, kinds, o.branch, embeddingColumn(variant))
+	if err == nil && len(results) == 0 && variant == EmbeddingSignature {
+		return semanticSearch(ctx, pool, queryVec, projectID, limit, kinds, o.branch, embeddingColumn(EmbeddingFull))
+	}
+	return results, err
+}
+
+// semanticSearch ranks nodes by the similarity of their column vector to
+// queryVec, on branch's workspaces when branch is set and on the default
+// branch otherwise.
+func semanticSearch(ctx context.Context, pool *pgxpool.Pool, queryVec []float32, projectID string, limit int, kinds []string, branch, column string) ([]SearchResult, error) {
+	kinds = searchKinds(kinds)
 	if limit <= 0 {
 		limit = 10
 	}
@@ -45,38 +249,43 @@ func SemanticSearchWithVector(ctx context.Context, pool *pgxpool.Pool, queryVec
 	}
 
 	vec := pgvector.NewVector(queryVec)
+	metric := similarityMetric()
 
-	// cosine distance operator <=> returns distance (0 = identical),
-	// so similarity = 1 - distance
 	sql := `
 		SELECT
 			n.id,
 			COALESCE(n.qualified_name, n.name),
 			n.file_path,
 			n.kind,
-			1 - (n.embedding <=> $1) AS similarity,
+			COALESCE(n.kind_group, ''),
+			` + similaritySQL(metric, column, "$1") + ` AS similarity,
 			COALESCE(n.signature, ''),
 			COALESCE(n.source_code, ''),
 			COALESCE(n.docstring, ''),
+			COALESCE(n.release_tag, ''),
 			COALESCE(ps.alias, '')
 		FROM nodes n
 		JOIN workspaces ws ON n.workspace_id = ws.id
 		LEFT JOIN project_sources ps ON ws.source_id = ps.id
 		WHERE ws.project_id = $2
-		  AND n.embedding IS NOT NULL`
+		  AND ` + column + ` IS NOT NULL`
 
 	args := []any{vec, projectID}
 	argIdx := 3
 
 	if len(kinds) > 0 {
-		sql += fmt.Sprintf(` AND n.kind = ANY($%d)`, argIdx)
+		sql += kindFilterSQL(argIdx)
 		args = append(args, kinds)
 		argIdx++
 	}
+	branchSQL, branchArgs := branchScopeSQL(branch, argIdx)
+	sql += branchSQL
+	args = append(args, branchArgs...)
+	argIdx += len(branchArgs)
 
 	sql += fmt.Sprintf(`
-		ORDER BY n.embedding <=> $1
-		LIMIT $%d`, argIdx)
+		ORDER BY %s
+		LIMIT $%d`, distanceSQL(metric, column, "$1"), argIdx)
 	args = append(args, limit)
 
 	tx, err := pool.Begin(ctx)
@@ -98,9 +307,10 @@ func SemanticSearchWithVector(ctx context.Context, pool *pgxpool.Pool, queryVec
 	var results []SearchResult
 	for rows.Next() {
 		var r SearchResult
-		if err := rows.Scan(&r.NodeID, &r.QualifiedName, &r.FilePath, &r.Kind, &r.Similarity, &r.Signature, &r.SourceCode, &r.Docstring, &r.SourceAlias); err != nil {
+		if err := rows.Scan(&r.NodeID, &r.QualifiedName, &r.FilePath, &r.Kind, &r.KindGroup, &r.Similarity, &r.Signature, &r.SourceCode, &r.Docstring, &r.ReleaseTag, &r.SourceAlias); err != nil {
 			return nil, fmt.Errorf("scanning row: %w", err)
 		}
+		r.SemanticScore = r.Similarity
 		results = append(results, r)
 	}
 	if err := rows.Err(); err != nil {
@@ -120,27 +330,65 @@ func SemanticSearchWithVector(ctx context.Context, pool *pgxpool.Pool, queryVec
 
 // HybridSearch combines vector similarity with Postgres full-text search using
 // Reciprocal Rank Fusion (RRF). Keyword matches boost exact symbol name hits
-// while semantic search preserves conceptual relevance.
-func HybridSearch(ctx context.Context, pool *pgxpool.Pool, client *openai.Client, query string, projectID string, limit int, kinds []string) ([]SearchResult, error) {
+// while semantic search preserves conceptual relevance. alpha weighs the two
+// sides: 0 is pure keyword, 1 is pure semantic, DefaultHybridAlpha is even.
+func HybridSearch(ctx context.Context, pool *pgxpool.Pool, client *openai.Client, query string, projectID string, limit int, kinds []string, alpha float64, opts ...SearchOption) ([]SearchResult, error) {
 	queryVec, err := indexer.EmbedText(ctx, client, query)
 	if err != nil {
 		return nil, fmt.Errorf("embedding query: %w", err)
 	}
-	return HybridSearchWithVector(ctx, pool, queryVec, query, projectID, limit, kinds)
+	return HybridSearchWithVector(ctx, pool, queryVec, query, projectID, limit, kinds, alpha, opts...)
 }
 
-// HybridSearchWithVector runs both vector cosine similarity and full-text keyword
-// search, then merges results via RRF scoring. The query string is used for
-// keyword matching while the vector is used for semantic similarity.
-func HybridSearchWithVector(ctx context.Context, pool *pgxpool.Pool, queryVec []float32, query string, projectID string, limit int, kinds []string) ([]SearchResult, error) {
+// HybridSearchWithVector runs both vector similarity and full-text keyword
+// search, then merges results via alpha-weighted RRF scoring. The query string
+// is used for keyword matching while the vector is used for semantic similarity.
+func HybridSearchWithVector(ctx context.Context, pool *pgxpool.Pool, queryVec []float32, query string, projectID string, limit int, kinds []string, alpha float64, opts ...SearchOption) ([]SearchResult, error) {
+	o := resolveSearchOptions(opts)
+	variant := o.variant(query)
+	if variant == EmbeddingSignature {
+		hasSignatures, err := projectHasSignatureEmbeddings(ctx, pool, projectID)
+		if err != nil {
+			return nil, err
+		}
+		if !hasSignatures {
+			variant = EmbeddingFull
+		}
+	}
+	return hybridSearch(ctx, pool, queryVec, query, projectID, limit, kinds, alpha, o, embeddingColumn(variant))
+}
+
+// projectHasSignatureEmbeddings reports whether any of the project's nodes
+// were indexed with a signature-only vector.
+func projectHasSignatureEmbeddings(ctx context.Context, pool *pgxpool.Pool, projectID string) (bool, error) {
+	var exists bool
+	err := pool.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM nodes n
+			JOIN workspaces ws ON n.workspace_id = ws.id
+			WHERE ws.project_id = $1 AND n.embedding_sig IS NOT NULL
+		)`, projectID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("checking for signature embeddings: %w", err)
+	}
+	return exists, nil
+}
+
+// hybridSearch fuses keyword ranks with the similarity of each node's column
+// vector to queryVec, on branch's workspaces when branch is set and on the
+// default branch otherwise.
+func hybridSearch(ctx context.Context, pool *pgxpool.Pool, queryVec []float32, query string, projectID string, limit int, kinds []string, alpha float64, o searchOptions, column string) ([]SearchResult, error) {
+	kinds = searchKinds(kinds)
 	if limit <= 0 {
 		limit = 10
 	}
 	if limit > 100 {
 		limit = 100
 	}
+	alpha = clampAlpha(alpha)
 
 	vec := pgvector.NewVector(queryVec)
+	metric := similarityMetric()
 
 	// Fetch more candidates from each source than the final limit so RRF
 	// fusion has enough to work with.
@@ -150,42 +398,57 @@ func HybridSearchWithVector(ctx context.Context, pool *pgxpool.Pool, queryVec []
 	}
 
 	// Build the hybrid query with two CTEs (vector + keyword) merged via RRF.
-	// RRF formula: score = 1/(k + rank_vector) + 1/(k + rank_keyword), k=60.
+	// Weighted RRF: score = alpha/(k + rank_vector) + (1-alpha)/(k + rank_keyword), k=60.
+	// Zero-score rows are dropped so alpha=0 or 1 returns only that side's hits.
 	sql := `
 		WITH vector_results AS (
-			SELECT n.id, ROW_NUMBER() OVER (ORDER BY n.embedding <=> $1) AS rank_v
+			SELECT n.id,
+				   ROW_NUMBER() OVER (ORDER BY ` + distanceSQL(metric, column, "$1") + `) AS rank_v,
+				   ` + similaritySQL(metric, column, "$1") + ` AS score_v
 			FROM nodes n
 			JOIN workspaces ws ON n.workspace_id = ws.id
 			WHERE ws.project_id = $2
-			  AND n.embedding IS NOT NULL`
+			  AND ` + column + ` IS NOT NULL`
 
-	args := []any{vec, projectID, query}
-	argIdx := 4
+	args := []any{vec, projectID, query, alpha}
+	argIdx := 5
 
 	if len(kinds) > 0 {
-		sql += fmt.Sprintf(` AND n.kind = ANY($%d)`, argIdx)
+		sql += kindFilterSQL(argIdx)
 		args = append(args, kinds)
 		argIdx++
 	}
+	// The keyword side reuses the branch parameter, if any
+	branchSQL, branchArgs := branchScopeSQL(o.branch, argIdx)
+	sql += branchSQL
+	args = append(args, branchArgs...)
+	argIdx += len(branchArgs)
 
 	sql += fmt.Sprintf(`
-			ORDER BY n.embedding <=> $1
+			ORDER BY %s
 			LIMIT $%d
 		),
 		keyword_results AS (
-			SELECT n.id, ROW_NUMBER() OVER (ORDER BY ts_rank(n.search_vector, query) DESC) AS rank_k
+			SELECT n.id,
+				   ROW_NUMBER() OVER (ORDER BY ts_rank(n.search_vector, query) DESC) AS rank_k,
+				   ts_rank(n.search_vector, query) AS score_k
 			FROM nodes n
 			JOIN workspaces ws ON n.workspace_id = ws.id,
 				 plainto_tsquery('english', $3) query
 			WHERE ws.project_id = $2
-			  AND n.search_vector @@ plainto_tsquery('english', $3)`, argIdx)
+			  AND n.search_vector @@ plainto_tsquery('english', $3)`, distanceSQL(metric, column, "$1"), argIdx)
 	args = append(args, candidateLimit)
 	argIdx++
 
 	if len(kinds) > 0 {
 		// kinds was already appended; reuse the same parameter index
-		kindsArgIdx := 4 // always $4 when kinds are present
-		sql += fmt.Sprintf(` AND n.kind = ANY($%d)`, kindsArgIdx)
+		kindsArgIdx := 5 // always $5 when kinds are present
+		sql += kindFilterSQL(kindsArgIdx)
+	}
+	sql += branchSQL
+	if !o.fixtures {
+		sql += `
+			  AND NOT n.fixture`
 	}
 
 	sql += fmt.Sprintf(`
@@ -195,9 +458,16 @@ func HybridSearchWithVector(ctx context.Context, pool *pgxpool.Pool, queryVec []
 		fused AS (
 			SELECT
 				COALESCE(v.id, k.id) AS id,
-				COALESCE(1.0 / (60 + v.rank_v), 0) + COALESCE(1.0 / (60 + k.rank_k), 0) AS rrf_score
+				$4::float8 * COALESCE(1.0 / (60 + v.rank_v), 0)
+					+ (1 - $4::float8) * COALESCE(1.0 / (60 + k.rank_k), 0) AS rrf_score,
+				COALESCE(v.score_v, 0) AS score_v,
+				COALESCE(k.score_k, 0) AS score_k
 			FROM vector_results v
 			FULL OUTER JOIN keyword_results k ON v.id = k.id
+		),
+		ranked AS (
+			SELECT * FROM fused
+			WHERE rrf_score > 0
 			ORDER BY rrf_score DESC
 			LIMIT $%d
 		)
@@ -206,12 +476,16 @@ func HybridSearchWithVector(ctx context.Context, pool *pgxpool.Pool, queryVec []
 			COALESCE(n.qualified_name, n.name),
 			n.file_path,
 			n.kind,
+			COALESCE(n.kind_group, ''),
 			f.rrf_score,
+			f.score_v,
+			f.score_k,
 			COALESCE(n.signature, ''),
 			COALESCE(n.source_code, ''),
 			COALESCE(n.docstring, ''),
+			COALESCE(n.release_tag, ''),
 			COALESCE(ps.alias, '')
-		FROM fused f
+		FROM ranked f
 		JOIN nodes n ON f.id = n.id
 		JOIN workspaces ws ON n.workspace_id = ws.id
 		LEFT JOIN project_sources ps ON ws.source_id = ps.id
@@ -237,7 +511,7 @@ func HybridSearchWithVector(ctx context.Context, pool *pgxpool.Pool, queryVec []
 	var results []SearchResult
 	for rows.Next() {
 		var r SearchResult
-		if err := rows.Scan(&r.NodeID, &r.QualifiedName, &r.FilePath, &r.Kind, &r.Similarity, &r.Signature, &r.SourceCode, &r.Docstring, &r.SourceAlias); err != nil {
+		if err := rows.Scan(&r.NodeID, &r.QualifiedName, &r.FilePath, &r.Kind, &r.KindGroup, &r.Similarity, &r.SemanticScore, &r.KeywordScore, &r.Signature, &r.SourceCode, &r.Docstring, &r.ReleaseTag, &r.SourceAlias); err != nil {
 			return nil, fmt.Errorf("scanning row: %w", err)
 		}
 		results = append(results, r)
@@ -256,3 +530,14 @@ func HybridSearchWithVector(ctx context.Context, pool *pgxpool.Pool, queryVec []
 
 	return results, nil
 }
+
+// clampAlpha keeps the hybrid weight within [0, 1].
+func clampAlpha(alpha float64) float64 {
+	if alpha < 0 {
+		return 0
+	}
+	if alpha > 1 {
+		return 1
+	}
+	return alpha
+}
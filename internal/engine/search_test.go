@@ -0,0 +1,66 @@
+package engine
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/maximilianfalco/mycelium/internal/config"
+)
+
+func TestClassifyQuery(t *testing.T) {
+	cases := map[string]string{
+		"parseConfig":                EmbeddingSignature,
+		"Store.get":                  EmbeddingSignature,
+		"http::Client":               EmbeddingSignature,
+		"User#save":                  EmbeddingSignature,
+		"max_retries":                EmbeddingSignature,
+		"render()":                   EmbeddingSignature,
+		"  NewServer  ":              EmbeddingSignature,
+		"authentication":             EmbeddingFull,
+		"how does auth work":         EmbeddingFull,
+		"where do we retry uploads?": EmbeddingFull,
+		"parse config file":          EmbeddingFull,
+		"":                           EmbeddingFull,
+	}
+	for query, want := range cases {
+		if got := ClassifyQuery(query); got != want {
+			t.Errorf("ClassifyQuery(%q) = %q, want %q", query, got, want)
+		}
+	}
+}
+
+func TestSearchOptionsVariant(t *testing.T) {
+	auto := resolveSearchOptions([]SearchOption{WithEmbedding(EmbeddingAuto)})
+	if got := auto.variant("Store.get"); got != EmbeddingSignature {
+		t.Errorf("expected auto to pick signature for a symbol, got %q", got)
+	}
+	if got := auto.variant(""); got != EmbeddingFull {
+		t.Errorf("expected auto without query text to mean full, got %q", got)
+	}
+	if got := resolveSearchOptions(nil).variant("Store.get"); got != EmbeddingFull {
+		t.Errorf("expected full by default, got %q", got)
+	}
+	if got := resolveSearchOptions([]SearchOption{WithEmbedding("bogus")}).variant("x"); got != EmbeddingFull {
+		t.Errorf("expected an unknown variant to mean full, got %q", got)
+	}
+}
+
+func TestSearchKinds(t *testing.T) {
+	defer SetSearchKinds(config.DefaultSearchKinds)
+
+	if got := searchKinds(nil); !slices.Equal(got, config.DefaultSearchKinds) {
+		t.Errorf("expected the default scope without kinds, got %v", got)
+	}
+	if got := searchKinds([]string{"type_alias"}); !slices.Equal(got, []string{"type_alias"}) {
+		t.Errorf("expected explicit kinds to replace the default, got %v", got)
+	}
+
+	SetSearchKinds([]string{"function"})
+	if got := searchKinds(nil); !slices.Equal(got, []string{"function"}) {
+		t.Errorf("expected SetSearchKinds to change the default, got %v", got)
+	}
+	SetSearchKinds(nil)
+	if got := searchKinds(nil); len(got) != 0 {
+		t.Errorf("expected an empty default to search every kind, got %v", got)
+	}
+}
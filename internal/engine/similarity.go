@@ -0,0 +1,158 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/maximilianfalco/mycelium/internal/config"
+)
+
+// Similarity metrics accepted by SetSimilarityMetric.
+const (
+	MetricCosine = "cosine"
+	MetricDot    = "dot"
+	MetricL2     = "l2"
+)
+
+var (
+	similarityMetricMu sync.RWMutex
+	similarityMetricV  = MetricCosine
+)
+
+// SetSimilarityMetric selects the pgvector distance semantic search orders
+// by. An empty name restores cosine. The vector index must be built for the
+// same metric to be used; EnsureVectorIndex takes care of that.
+func SetSimilarityMetric(metric string) error {
+	if metric == "" {
+		metric = MetricCosine
+	}
+	if err := config.ValidateSimilarityMetric(metric); err != nil {
+		return err
+	}
+	similarityMetricMu.Lock()
+	defer similarityMetricMu.Unlock()
+	similarityMetricV = metric
+	return nil
+}
+
+func similarityMetric() string {
+	similarityMetricMu.RLock()
+	defer similarityMetricMu.RUnlock()
+	return similarityMetricV
+}
+
+// distanceSQL is the pgvector distance between a and b under metric, smallest
+// for the closest match. Ordering by it is what lets the index be used.
+func distanceSQL(metric, a, b string) string {
+	switch metric {
+	case MetricDot:
+		// <#> is the negated inner product
+		return fmt.Sprintf("(%s <#> %s)", a, b)
+	case MetricL2:
+		return fmt.Sprintf("(%s <-> %s)", a, b)
+	default:
+		return fmt.Sprintf("(%s <=> %s)", a, b)
+	}
+}
+
+// similaritySQL maps the distance between a and b onto [0, 1], higher for
+// closer vectors, so a threshold never falls outside the scale whichever
+// metric is set:
+//
+//   - cosine: 1 - distance, with opposite vectors floored at 0
+//   - dot: the logistic of the inner product, 1 / (1 + exp(distance)),
+//     which is bounded whatever the vectors' length and keeps their order.
+//     The exponent is held within ±700, past which float8 exp overflows or
+//     underflows and the score is 0 or 1 anyway.
+//   - l2: 1 / (1 + distance)
+func similaritySQL(metric, a, b string) string {
+	d := distanceSQL(metric, a, b)
+	switch metric {
+	case MetricDot:
+		return fmt.Sprintf("(1 / (1 + exp(LEAST(700, GREATEST(-700, %s)))))", d)
+	case MetricL2:
+		return fmt.Sprintf("(1 / (1 + %s))", d)
+	default:
+		return fmt.Sprintf("GREATEST(0, 1 - %s)", d)
+	}
+}
+
+// vectorOpsClass is the pgvector operator class an index needs for metric.
+func vectorOpsClass(metric string) string {
+	switch metric {
+	case MetricDot:
+		return "vector_ip_ops"
+	case MetricL2:
+		return "vector_l2_ops"
+	default:
+		return "vector_cosine_ops"
+	}
+}
+
+// vectorIndexes maps each ivfflat index to the embedding column it covers.
+var vectorIndexes = []struct{ name, column string }{
+	{"idx_nodes_embedding", "embedding"},
+	{"idx_nodes_embedding_sig", "embedding_sig"},
+}
+
+// EnsureVectorIndex rebuilds the embedding indexes that were built for a
+// different metric than the one selected, since pgvector only uses an index
+// whose operator class matches the query's distance operator.
+func EnsureVectorIndex(ctx context.Context, pool *pgxpool.Pool) error {
+	for _, idx := range vectorIndexes {
+		if err := ensureVectorIndex(ctx, pool, idx.name, idx.column); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func ensureVectorIndex(ctx context.Context, pool *pgxpool.Pool, name, column string) error {
+	opsClass := vectorOpsClass(similarityMetric())
+
+	// The operator class is read from the catalog: pg_indexes.indexdef
+	// leaves out an index's default class (vector_l2_ops for ivfflat), so
+	// matching on it rebuilt l2 indexes at every start.
+	var current string
+	err := pool.QueryRow(ctx, `
+		SELECT COALESCE((
+			SELECT opc.opcname
+			FROM pg_index i
+			JOIN pg_class c ON c.oid = i.indexrelid
+			JOIN pg_opclass opc ON opc.oid = i.indclass[0]
+			WHERE c.relnamespace = current_schema()::regnamespace AND c.relname = $1
+		), '')`, name,
+	).Scan(&current)
+	if err != nil {
+		return fmt.Errorf("reading vector index %s: %w", name, err)
+	}
+	if current == opsClass {
+		return nil
+	}
+
+	slog.Info("rebuilding vector index", "index", name, "metric", similarityMetric(), "opsClass", opsClass, "was", current)
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DROP INDEX IF EXISTS `+name); err != nil {
+		return fmt.Errorf("dropping vector index %s: %w", name, err)
+	}
+	if _, err := tx.Exec(ctx, fmt.Sprintf(
+		`CREATE INDEX %s ON nodes USING ivfflat (%s %s) WITH (lists = 100)`, name, column, opsClass,
+	)); err != nil {
+		return fmt.Errorf("creating vector index %s: %w", name, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("committing transaction: %w", err)
+	}
+	return nil
+}
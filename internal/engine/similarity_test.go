@@ -0,0 +1,44 @@
+package engine
+
+import "testing"
+
+func TestSetSimilarityMetric(t *testing.T) {
+	defer SetSimilarityMetric("")
+
+	if err := SetSimilarityMetric("l2"); err != nil {
+		t.Fatalf("SetSimilarityMetric(l2): %v", err)
+	}
+	if got := similarityMetric(); got != MetricL2 {
+		t.Errorf("expected l2, got %q", got)
+	}
+	if err := SetSimilarityMetric("manhattan"); err == nil {
+		t.Error("expected an unknown metric to be rejected")
+	}
+	if got := similarityMetric(); got != MetricL2 {
+		t.Errorf("a rejected metric must leave the current one, got %q", got)
+	}
+	if err := SetSimilarityMetric(""); err != nil || similarityMetric() != MetricCosine {
+		t.Errorf("expected empty to restore cosine, got %q (%v)", similarityMetric(), err)
+	}
+}
+
+func TestSimilaritySQL(t *testing.T) {
+	cases := []struct {
+		metric, distance, similarity, opsClass string
+	}{
+		{MetricCosine, "(e <=> $1)", "GREATEST(0, 1 - (e <=> $1))", "vector_cosine_ops"},
+		{MetricDot, "(e <#> $1)", "(1 / (1 + exp(LEAST(700, GREATEST(-700, (e <#> $1))))))", "vector_ip_ops"},
+		{MetricL2, "(e <-> $1)", "(1 / (1 + (e <-> $1)))", "vector_l2_ops"},
+	}
+	for _, c := range cases {
+		if got := distanceSQL(c.metric, "e", "$1"); got != c.distance {
+			t.Errorf("%s: distance %q, want %q", c.metric, got, c.distance)
+		}
+		if got := similaritySQL(c.metric, "e", "$1"); got != c.similarity {
+			t.Errorf("%s: similarity %q, want %q", c.metric, got, c.similarity)
+		}
+		if got := vectorOpsClass(c.metric); got != c.opsClass {
+			t.Errorf("%s: ops class %q, want %q", c.metric, got, c.opsClass)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package indexer
+
+import (
+	"github.com/maximilianfalco/mycelium/internal/config"
+	"github.com/maximilianfalco/mycelium/internal/indexer/parsers"
+)
+
+// boilerplateFilter decides which nodes are not worth an embedding. Skipped
+// nodes are still stored and traversable; they just never show up as
+// semantic hits or spend embedding budget.
+type boilerplateFilter struct {
+	minTokens    int
+	trivialNames map[string]bool
+	countTokens  func(string) (int, error)
+}
+
+// newBoilerplateFilter returns nil when filtering is disabled in cfg. A nil
+// filter skips nothing.
+func newBoilerplateFilter(cfg *config.Config) *boilerplateFilter {
+	if cfg == nil || !cfg.SkipBoilerplateEmbeddings {
+		return nil
+	}
+	f := &boilerplateFilter{
+		minTokens:    cfg.BoilerplateMinTokens,
+		trivialNames: make(map[string]bool, len(cfg.TrivialMethodNames)),
+		countTokens:  CountTokens,
+	}
+	for _, name := range cfg.TrivialMethodNames {
+		f.trivialNames[name] = true
+	}
+	return f
+}
+
+// skip reports whether node is boilerplate and why: it comes from a generated
+// file, is a function/method with a trivial name, or its source is under
+// minTokens tokens (getters, setters, empty constructors).
+func (f *boilerplateFilter) skip(node parsers.NodeInfo) (bool, string) {
+	if f == nil {
+		return false, ""
+	}
+	if node.Generated {
+		return true, "generated"
+	}
+	isFunc := node.Kind == "function" || node.Kind == "method"
+	if isFunc && f.trivialNames[node.Name] {
+		return true, "trivial_name"
+	}
+	if isFunc && f.minTokens > 0 {
+		if n, err := f.countTokens(node.SourceCode); err == nil && n < f.minTokens {
+			return true, "small_body"
+		}
+	}
+	return false, ""
+}
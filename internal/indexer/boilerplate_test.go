@@ -0,0 +1,52 @@
+package indexer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/maximilianfalco/mycelium/internal/config"
+	"github.com/maximilianfalco/mycelium/internal/indexer/parsers"
+)
+
+func TestBoilerplateFilter(t *testing.T) {
+	f := newBoilerplateFilter(&config.Config{
+		SkipBoilerplateEmbeddings: true,
+		BoilerplateMinTokens:      6,
+		TrivialMethodNames:        []string{"String", "toString"},
+	})
+	// One token per word keeps the test off the network-backed tokenizer.
+	f.countTokens = func(s string) (int, error) { return len(strings.Fields(s)), nil }
+
+	long := "func (u User) Validate() error { if u.Name == empty { return errMissing } return nil }"
+	tests := []struct {
+		name   string
+		node   parsers.NodeInfo
+		want   bool
+		reason string
+	}{
+		{"generated file", parsers.NodeInfo{Name: "Validate", Kind: "method", SourceCode: long, Generated: true}, true, "generated"},
+		{"trivial name", parsers.NodeInfo{Name: "String", Kind: "method", SourceCode: long}, true, "trivial_name"},
+		{"small getter", parsers.NodeInfo{Name: "getName", Kind: "method", SourceCode: "getName() { return this.name }"}, true, "small_body"},
+		{"real function", parsers.NodeInfo{Name: "Validate", Kind: "method", SourceCode: long}, false, ""},
+		{"small type kept", parsers.NodeInfo{Name: "ID", Kind: "type_alias", SourceCode: "type ID string"}, false, ""},
+		{"trivial name on class kept", parsers.NodeInfo{Name: "String", Kind: "class", SourceCode: long}, false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, reason := f.skip(tt.node)
+			if got != tt.want || reason != tt.reason {
+				t.Errorf("skip = (%v, %q), want (%v, %q)", got, reason, tt.want, tt.reason)
+			}
+		})
+	}
+}
+
+func TestBoilerplateFilter_Disabled(t *testing.T) {
+	f := newBoilerplateFilter(&config.Config{TrivialMethodNames: []string{"String"}})
+	if f != nil {
+		t.Fatal("expected nil filter when SkipBoilerplateEmbeddings is off")
+	}
+	if skip, _ := f.skip(parsers.NodeInfo{Name: "String", Kind: "method", Generated: true}); skip {
+		t.Error("nil filter should skip nothing")
+	}
+}
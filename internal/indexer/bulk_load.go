@@ -0,0 +1,85 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// The bulk-load path is for a workspace's first index, where every node and
+// edge is new. Rows are streamed into a temporary staging table with COPY and
+// merged into the real table with one INSERT ... SELECT, instead of one
+// round-tripped upsert per row. The merge keeps the same ON CONFLICT clause
+// as the batched path, so a concurrent writer can't make it fail.
+
+// workspaceIsEmpty reports whether no nodes are stored for the workspace yet.
+func workspaceIsEmpty(ctx context.Context, tx pgx.Tx, workspaceID string) (bool, error) {
+	var exists bool
+	if err := tx.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM nodes WHERE workspace_id = $1)`, workspaceID,
+	).Scan(&exists); err != nil {
+		return false, fmt.Errorf("checking for stored nodes: %w", err)
+	}
+	return !exists, nil
+}
+
+// copyNodes bulk-loads input's nodes. When the same node ID appears more than
+// once, the last row wins, as it would with sequential upserts.
+func copyNodes(ctx context.Context, tx pgx.Tx, workspaceID string, packageIDs map[string]string, input *BuildInput, language string) (int, error) {
+	rows := nodeRows(workspaceID, packageIDs, input, language)
+
+	last := make(map[string]int, len(rows))
+	for i, row := range rows {
+		last[row[0].(string)] = i
+	}
+	unique := make([][]any, 0, len(last))
+	for i, row := range rows {
+		if last[row[0].(string)] == i {
+			unique = append(unique, row)
+		}
+	}
+
+	return copyAndMerge(ctx, tx, "nodes", nodeColumns, unique, nodeConflictUpdate)
+}
+
+// copyEdges bulk-loads edge rows, deduplicated the same way as writeEdges.
+func copyEdges(ctx context.Context, tx pgx.Tx, rows []edgeRow) (int, error) {
+	edges := dedupeEdges(rows)
+	values := make([][]any, len(edges))
+	for i, r := range edges {
+		values[i] = []any{r.sourceID, r.targetID, r.kind, r.weight, r.line, r.lines, r.metadata}
+	}
+
+	columns := []string{"source_id", "target_id", "kind", "weight", "line_number", "call_sites", "metadata"}
+	return copyAndMerge(ctx, tx, "edges", columns, values, edgeConflictUpdate)
+}
+
+// copyAndMerge streams rows into a staging copy of table, dropped at commit,
+// then merges them in with conflictClause. Returns the number of rows merged.
+func copyAndMerge(ctx context.Context, tx pgx.Tx, table string, columns []string, rows [][]any, conflictClause string) (int, error) {
+	if len(rows) == 0 {
+		return 0, nil
+	}
+	staging := table + "_staging"
+
+	if _, err := tx.Exec(ctx, fmt.Sprintf(
+		`CREATE TEMP TABLE %s (LIKE %s INCLUDING DEFAULTS) ON COMMIT DROP`, staging, table,
+	)); err != nil {
+		return 0, fmt.Errorf("creating %s: %w", staging, err)
+	}
+
+	if _, err := tx.CopyFrom(ctx, pgx.Identifier{staging}, columns, pgx.CopyFromRows(rows)); err != nil {
+		return 0, fmt.Errorf("copying into %s: %w", staging, err)
+	}
+
+	cols := strings.Join(columns, ", ")
+	tag, err := tx.Exec(ctx, fmt.Sprintf(
+		`INSERT INTO %s (%s) SELECT %s FROM %s`, table, cols, cols, staging,
+	)+conflictClause)
+	if err != nil {
+		return 0, fmt.Errorf("merging %s: %w", staging, err)
+	}
+	return int(tag.RowsAffected()), nil
+}
@@ -0,0 +1,180 @@
+package indexer
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/maximilianfalco/mycelium/internal/indexer/parsers"
+)
+
+// CallHeuristics tells call resolution which calls in one language go to its
+// runtime or standard library rather than to indexed code. Each language
+// brings its own, so Go's String() methods don't hide a TypeScript function
+// named String and JS's map() doesn't hide a Go one.
+type CallHeuristics struct {
+	// Globals are callees never resolved, as written: "console.log", "len".
+	Globals []string
+	// GlobalPrefixes mark whole namespaces of globals: "Math.".
+	GlobalPrefixes []string
+	// BuiltinMethods are method names common on the language's built-in
+	// types. A member call with one of these names (obj.split()) skips the
+	// project-wide name search, so it doesn't land on a user function split.
+	BuiltinMethods []string
+}
+
+// callHeuristics is CallHeuristics with its name lists turned into sets.
+type callHeuristics struct {
+	globals        map[string]bool
+	globalPrefixes []string
+	builtinMethods map[string]bool
+}
+
+func compileCallHeuristics(h CallHeuristics) *callHeuristics {
+	c := &callHeuristics{
+		globals:        make(map[string]bool, len(h.Globals)),
+		globalPrefixes: h.GlobalPrefixes,
+		builtinMethods: make(map[string]bool, len(h.BuiltinMethods)),
+	}
+	for _, g := range h.Globals {
+		c.globals[g] = true
+	}
+	for _, m := range h.BuiltinMethods {
+		c.builtinMethods[m] = true
+	}
+	return c
+}
+
+// isGlobalCall reports whether callee is a runtime global that no indexed
+// node can define.
+func (c *callHeuristics) isGlobalCall(callee string) bool {
+	if c.globals[callee] {
+		return true
+	}
+	for _, prefix := range c.globalPrefixes {
+		if strings.HasPrefix(callee, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isBuiltinMethodName reports whether name is a method of the language's
+// built-in types.
+func (c *callHeuristics) isBuiltinMethodName(name string) bool {
+	return c.builtinMethods[name]
+}
+
+var jsCallHeuristics = CallHeuristics{
+	Globals: []string{
+		"console.log", "console.error", "console.warn", "console.info",
+		"JSON.stringify", "JSON.parse",
+		"Promise.resolve", "Promise.reject", "Promise.all",
+		"Math.round", "Math.floor", "Math.ceil", "Math.random",
+		"parseInt", "parseFloat", "setTimeout", "setInterval",
+		"clearTimeout", "clearInterval",
+		"require", "super",
+	},
+	GlobalPrefixes: []string{"console.", "Math.", "Object.", "Array.", "String.", "Number.", "Promise."},
+	BuiltinMethods: []string{
+		// String
+		"split", "replace", "replaceAll", "match",
+		"trim", "trimStart", "trimEnd", "toLowerCase",
+		"toUpperCase", "startsWith", "endsWith", "includes",
+		"indexOf", "lastIndexOf", "slice", "substring",
+		"charAt", "charCodeAt", "padStart", "padEnd",
+		"repeat", "normalize", "search", "at",
+		// Array
+		"push", "pop", "shift", "unshift",
+		"map", "filter", "reduce", "reduceRight",
+		"find", "findIndex", "some", "every",
+		"forEach", "flat", "flatMap", "sort",
+		"reverse", "concat", "join", "fill",
+		"splice", "keys", "values", "entries",
+		// Object
+		"hasOwnProperty", "toString", "valueOf",
+		"toJSON", "toLocaleString",
+		// Promise
+		"then", "catch", "finally",
+		// Map/Set
+		"get", "set", "has", "clear", "add",
+		// Date
+		"getTime", "toISOString", "toDateString",
+		// DOM/Node
+		"addEventListener", "removeEventListener",
+		"querySelector", "querySelectorAll",
+		"getAttribute", "setAttribute",
+		"createElement", "appendChild", "removeChild",
+	},
+}
+
+var goCallHeuristics = CallHeuristics{
+	Globals: []string{
+		"make", "len", "cap", "append", "copy", "delete", "close",
+		"panic", "recover", "new", "print", "println",
+		"fmt.Sprintf", "fmt.Printf", "fmt.Println", "fmt.Fprintf",
+		"fmt.Errorf",
+	},
+	// Methods of common interfaces that shadow user functions
+	BuiltinMethods: []string{"Error", "String", "Close", "Read", "Write", "Scan", "Next", "Err", "Rows"},
+}
+
+var (
+	callHeuristicsMu sync.RWMutex
+	callHeuristicsBy = make(map[string]*callHeuristics)
+	// anyCallHeuristics merges every registered language, for callers whose
+	// file has no registered extension.
+	anyCallHeuristics = &callHeuristics{}
+)
+
+func init() {
+	RegisterCallHeuristics([]string{".ts", ".tsx", ".js", ".jsx", ".vue", ".svelte"}, jsCallHeuristics)
+	RegisterCallHeuristics([]string{".go"}, goCallHeuristics)
+}
+
+// RegisterCallHeuristics sets the call heuristics for files with the given
+// extensions (with leading dot), replacing any registered before. A parser
+// added with parsers.RegisterParser can bring its language's globals this
+// way; extensions without heuristics are checked against every language's.
+func RegisterCallHeuristics(exts []string, h CallHeuristics) {
+	callHeuristicsMu.Lock()
+	defer callHeuristicsMu.Unlock()
+	c := compileCallHeuristics(h)
+	for _, ext := range exts {
+		callHeuristicsBy[ext] = c
+	}
+
+	merged := &callHeuristics{globals: make(map[string]bool), builtinMethods: make(map[string]bool)}
+	for _, c := range callHeuristicsBy {
+		for g := range c.globals {
+			merged.globals[g] = true
+		}
+		for m := range c.builtinMethods {
+			merged.builtinMethods[m] = true
+		}
+		for _, p := range c.globalPrefixes {
+			if !slices.Contains(merged.globalPrefixes, p) {
+				merged.globalPrefixes = append(merged.globalPrefixes, p)
+			}
+		}
+	}
+	anyCallHeuristics = merged
+}
+
+// callHeuristicsFor picks the heuristics for calls made from file. Methods
+// are filed under their class rather than a path, so a name without a
+// registered extension is traced up its contains edges first.
+func callHeuristicsFor(file string, nodesByFile map[string][]parsers.NodeInfo) *callHeuristics {
+	callHeuristicsMu.RLock()
+	defer callHeuristicsMu.RUnlock()
+	for range 3 {
+		if c, ok := callHeuristicsBy[filepath.Ext(file)]; ok {
+			return c
+		}
+		if file = findFileForNode(file, nodesByFile); file == "" {
+			break
+		}
+	}
+	return anyCallHeuristics
+}
@@ -0,0 +1,73 @@
+package indexer
+
+import (
+	"testing"
+
+	"github.com/maximilianfalco/mycelium/internal/indexer/parsers"
+)
+
+func TestCallHeuristicsPerLanguage(t *testing.T) {
+	nodesByFile := map[string][]parsers.NodeInfo{
+		"src/format.ts": {{Name: "String", QualifiedName: "String"}, {Name: "Store", QualifiedName: "Store"}},
+		"Store":         {{Name: "save", QualifiedName: "Store.save"}},
+		"lib/split.go":  {{Name: "split", QualifiedName: "split"}},
+	}
+	nodesByName := map[string][]parsers.NodeInfo{
+		"String": {{Name: "String", QualifiedName: "String"}},
+		"split":  {{Name: "split", QualifiedName: "split"}},
+	}
+	resolve := func(source, target string) string {
+		r := resolveCallEdge(parsers.EdgeInfo{Source: source, Target: target, Kind: "calls"}, nodesByFile, nil, nodesByName)
+		if r == nil {
+			return ""
+		}
+		return r.Target
+	}
+
+	// A Go-only builtin method name doesn't block a TS member call, and a
+	// JS one doesn't block a Go member call
+	if got := resolve("Store", "value.String"); got != "String" {
+		t.Errorf("TS value.String() should resolve to String, got %q", got)
+	}
+	if got := resolve("split", "parts.split"); got != "split" {
+		t.Errorf("Go parts.split() should resolve to split, got %q", got)
+	}
+
+	// A method's language comes from the file of its class
+	if got := resolve("Store.save", "text.split"); got != "" {
+		t.Errorf("TS text.split() is a builtin, got %q", got)
+	}
+	if ts, goH := callHeuristicsFor("src/a.ts", nil), callHeuristicsFor("a.go", nil); ts.isGlobalCall("len") || !goH.isGlobalCall("len") {
+		t.Error("len should only be global in Go")
+	}
+}
+
+func TestRegisterCallHeuristics(t *testing.T) {
+	RegisterCallHeuristics([]string{".py"}, CallHeuristics{
+		Globals:        []string{"print"},
+		GlobalPrefixes: []string{"os."},
+		BuiltinMethods: []string{"append"},
+	})
+	t.Cleanup(func() {
+		callHeuristicsMu.Lock()
+		delete(callHeuristicsBy, ".py")
+		callHeuristicsMu.Unlock()
+		RegisterCallHeuristics(nil, CallHeuristics{})
+	})
+
+	py := callHeuristicsFor("app/main.py", nil)
+	if !py.isGlobalCall("print") || !py.isGlobalCall("os.path.join") || !py.isBuiltinMethodName("append") {
+		t.Error("registered Python heuristics not applied")
+	}
+	if py.isGlobalCall("console.log") || py.isGlobalCall("len") {
+		t.Error("Python should not inherit other languages' globals")
+	}
+
+	// Unregistered extensions check against every language
+	other := callHeuristicsFor("schema.graphql", nil)
+	for _, name := range []string{"console.log", "len", "print"} {
+		if !other.isGlobalCall(name) {
+			t.Errorf("expected %s global for an unregistered extension", name)
+		}
+	}
+}
@@ -1,12 +1,14 @@
 package indexer
 
 import (
+	"bufio"
 	"context"
 	"fmt"
 	"log/slog"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -23,26 +25,49 @@ type ChangeSet struct {
 	ModifiedFiles     []string `json:"modifiedFiles"`
 	DeletedFiles      []string `json:"deletedFiles"`
 	ThresholdExceeded bool     `json:"thresholdExceeded"`
+	// ConfigChanged is set when a workspace config file (package.json, tsconfig.json,
+	// go.mod, ...) changed, meaning cached workspace detection is stale.
+	ConfigChanged bool `json:"configChanged"`
+	// CodeownersChanged is set when the CODEOWNERS file changed, so node
+	// owners need refreshing even if no code did.
+	CodeownersChanged bool `json:"codeownersChanged,omitempty"`
+	// DependentFiles are unchanged files that referenced a deleted file. They
+	// are re-parsed so imports of the deleted file become unresolved refs.
+	// Filled in by the pipeline, not by DetectChanges.
+	DependentFiles []string `json:"dependentFiles,omitempty"`
+	// FileCommitTimes maps added and modified files to the committer time of
+	// the last commit that touched them. Git repos only.
+	FileCommitTimes map[string]time.Time `json:"-"`
 }
 
 // DetectChanges compares the current state of sourcePath against its last indexed state.
 // For git repos, uses git diff. For plain directories, uses file mtime.
 // When force is true, always performs a full index regardless of threshold or previous state.
-func DetectChanges(ctx context.Context, sourcePath string, lastIndexedCommit *string, lastIndexedAt *time.Time, maxAutoReindexFiles int, force bool) (*ChangeSet, error) {
-	if force {
-		return detectForceFullIndex(ctx, sourcePath)
+// submodules decides whether files inside git submodules are part of the change set.
+func DetectChanges(ctx context.Context, sourcePath string, lastIndexedCommit *string, lastIndexedAt *time.Time, maxAutoReindexFiles int, force bool, submodules SubmoduleMode) (*ChangeSet, error) {
+	var cs *ChangeSet
+	var err error
+	switch {
+	case force:
+		cs, err = detectForceFullIndex(ctx, sourcePath, submodules)
+	case isGitRepo(ctx, sourcePath):
+		cs, err = detectGitChanges(ctx, sourcePath, lastIndexedCommit, maxAutoReindexFiles, submodules)
+	default:
+		return detectMtimeChanges(sourcePath, lastIndexedAt, maxAutoReindexFiles, submodules)
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	isGit := isGitRepo(ctx, sourcePath)
-
-	if isGit {
-		return detectGitChanges(ctx, sourcePath, lastIndexedCommit, maxAutoReindexFiles)
+	if cs.IsGitRepo && cs.CurrentCommit != "" {
+		touched := append(append([]string{}, cs.AddedFiles...), cs.ModifiedFiles...)
+		cs.FileCommitTimes = fileCommitTimes(ctx, sourcePath, touched, submodules)
 	}
-	return detectMtimeChanges(sourcePath, lastIndexedAt, maxAutoReindexFiles)
+	return cs, nil
 }
 
 // detectForceFullIndex builds a change set that forces a complete re-index of all files.
-func detectForceFullIndex(ctx context.Context, sourcePath string) (*ChangeSet, error) {
+func detectForceFullIndex(ctx context.Context, sourcePath string, submodules SubmoduleMode) (*ChangeSet, error) {
 	cs := &ChangeSet{
 		IsGitRepo:   isGitRepo(ctx, sourcePath),
 		IsFullIndex: true,
@@ -56,7 +81,7 @@ func detectForceFullIndex(ctx context.Context, sourcePath string) (*ChangeSet, e
 		cs.CurrentBranch = gitCurrentBranch(ctx, sourcePath)
 	}
 
-	return populateFullIndex(cs, sourcePath)
+	return populateFullIndex(cs, sourcePath, submodules)
 }
 
 func isGitRepo(ctx context.Context, path string) bool {
@@ -87,7 +112,7 @@ func gitCurrentBranch(ctx context.Context, dir string) string {
 	return strings.TrimSpace(string(out))
 }
 
-func detectGitChanges(ctx context.Context, sourcePath string, lastIndexedCommit *string, maxAutoReindexFiles int) (*ChangeSet, error) {
+func detectGitChanges(ctx context.Context, sourcePath string, lastIndexedCommit *string, maxAutoReindexFiles int, submodules SubmoduleMode) (*ChangeSet, error) {
 	cs := &ChangeSet{
 		IsGitRepo: true,
 	}
@@ -107,7 +132,7 @@ func detectGitChanges(ctx context.Context, sourcePath string, lastIndexedCommit
 	if lastIndexedCommit == nil || *lastIndexedCommit == "" {
 		cs.IsFullIndex = true
 		cs.LastIndexedCommit = ""
-		return populateFullIndex(cs, sourcePath)
+		return populateFullIndex(cs, sourcePath, submodules)
 	}
 
 	cs.LastIndexedCommit = *lastIndexedCommit
@@ -128,12 +153,30 @@ func detectGitChanges(ctx context.Context, sourcePath string, lastIndexedCommit
 			"error", err,
 		)
 		cs.IsFullIndex = true
-		return populateFullIndex(cs, sourcePath)
+		return populateFullIndex(cs, sourcePath, submodules)
 	}
 
-	cs.AddedFiles = filterCodeFiles(added)
-	cs.ModifiedFiles = filterCodeFiles(modified)
-	cs.DeletedFiles = filterCodeFiles(deleted)
+	// git diff reports a moved submodule as one gitlink path; look inside.
+	if subs := readSubmodulePaths(sourcePath); submodules == SubmodulesInclude && len(subs) > 0 {
+		subAdded, subModified, subDeleted, err := diffSubmodules(ctx, sourcePath, *lastIndexedCommit, currentCommit, subs)
+		if err != nil {
+			slog.Warn("submodule diff failed, falling back to full index",
+				"path", sourcePath,
+				"error", err,
+			)
+			cs.IsFullIndex = true
+			return populateFullIndex(cs, sourcePath, submodules)
+		}
+		added = append(added, subAdded...)
+		modified = append(modified, subModified...)
+		deleted = append(deleted, subDeleted...)
+	}
+
+	cs.ConfigChanged = hasWorkspaceConfigFile(added) || hasWorkspaceConfigFile(modified) || hasWorkspaceConfigFile(deleted)
+	cs.CodeownersChanged = hasCodeownersFile(added) || hasCodeownersFile(modified) || hasCodeownersFile(deleted)
+	cs.AddedFiles = filterCodeFiles(sourcePath, added)
+	cs.ModifiedFiles = filterCodeFiles(sourcePath, modified)
+	cs.DeletedFiles = filterCodeFiles(sourcePath, deleted)
 
 	totalChanged := len(cs.AddedFiles) + len(cs.ModifiedFiles) + len(cs.DeletedFiles)
 	if maxAutoReindexFiles > 0 && totalChanged > maxAutoReindexFiles {
@@ -148,6 +191,97 @@ func detectGitChanges(ctx context.Context, sourcePath string, lastIndexedCommit
 	return cs, nil
 }
 
+// fileCommitTimes runs gitFileCommitTimes for the parent repo and, in
+// SubmodulesInclude mode, inside each submodule for the files it holds.
+func fileCommitTimes(ctx context.Context, sourcePath string, files []string, submodules SubmoduleMode) map[string]time.Time {
+	var subs []string
+	if submodules == SubmodulesInclude {
+		subs = readSubmodulePaths(sourcePath)
+	}
+	if len(subs) == 0 {
+		return gitFileCommitTimes(ctx, sourcePath, files)
+	}
+
+	bySub := make(map[string][]string)
+	for _, f := range files {
+		sub := submoduleFor(f, subs)
+		if sub != "" {
+			f = strings.TrimPrefix(filepath.ToSlash(f), sub+"/")
+		}
+		bySub[sub] = append(bySub[sub], f)
+	}
+
+	times := gitFileCommitTimes(ctx, sourcePath, bySub[""])
+	if times == nil {
+		times = make(map[string]time.Time)
+	}
+	for _, sub := range subs {
+		for f, t := range gitFileCommitTimes(ctx, filepath.Join(sourcePath, filepath.FromSlash(sub)), bySub[sub]) {
+			times[sub+"/"+f] = t
+		}
+	}
+	return times
+}
+
+// gitFileCommitTimes walks git log newest-first and records, for each of
+// files, the committer time of the most recent commit that touched it. The
+// walk stops as soon as every file has been seen, so recently edited files
+// are cheap even in long histories. Failures are logged and yield whatever
+// was collected so far — commit times only feed an optional ranking signal.
+func gitFileCommitTimes(ctx context.Context, dir string, files []string) map[string]time.Time {
+	if len(files) == 0 {
+		return nil
+	}
+	want := make(map[string]bool, len(files))
+	for _, f := range files {
+		want[f] = true
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// %x00 marks commit header lines so they can't be mistaken for paths.
+	cmd := exec.CommandContext(ctx, "git", "-c", "core.quotePath=false", "log",
+		"--format=%x00%ct", "--name-only", "--no-renames", "--relative")
+	cmd.Dir = dir
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		slog.Warn("git log for commit times failed", "path", dir, "error", err)
+		return nil
+	}
+	if err := cmd.Start(); err != nil {
+		slog.Warn("git log for commit times failed", "path", dir, "error", err)
+		return nil
+	}
+
+	times := make(map[string]time.Time, len(want))
+	var current time.Time
+	scanner := bufio.NewScanner(stdout)
+	for len(times) < len(want) && scanner.Scan() {
+		line := scanner.Text()
+		if ts, ok := strings.CutPrefix(line, "\x00"); ok {
+			sec, err := strconv.ParseInt(ts, 10, 64)
+			if err != nil {
+				current = time.Time{}
+				continue
+			}
+			current = time.Unix(sec, 0).UTC()
+			continue
+		}
+		if line == "" || current.IsZero() || !want[line] {
+			continue
+		}
+		if _, seen := times[line]; !seen {
+			times[line] = current
+		}
+	}
+
+	// Stop git early if we have everything; Wait then reports the kill.
+	cancel()
+	_ = cmd.Wait()
+	return times
+}
+
 // gitDiff runs git diff and categorizes files by change type.
 func gitDiff(ctx context.Context, dir, fromCommit, toCommit string) (added, modified, deleted []string, err error) {
 	cmd := exec.CommandContext(ctx, "git", "diff", "--name-status", "--diff-filter=ACDMR", fromCommit+".."+toCommit)
@@ -192,8 +326,8 @@ func gitDiff(ctx context.Context, dir, fromCommit, toCommit string) (added, modi
 }
 
 // populateFullIndex crawls the source path and marks all files as added.
-func populateFullIndex(cs *ChangeSet, sourcePath string) (*ChangeSet, error) {
-	result, err := CrawlDirectory(sourcePath, true)
+func populateFullIndex(cs *ChangeSet, sourcePath string, submodules SubmoduleMode) (*ChangeSet, error) {
+	result, err := crawlSource(sourcePath, true, submodules)
 	if err != nil {
 		return nil, fmt.Errorf("crawling for full index: %w", err)
 	}
@@ -206,14 +340,15 @@ func populateFullIndex(cs *ChangeSet, sourcePath string) (*ChangeSet, error) {
 	return cs, nil
 }
 
-// filterCodeFiles keeps only files with code extensions, excluding lockfiles and other junk.
-func filterCodeFiles(files []string) []string {
+// filterCodeFiles keeps only parseable files, excluding lockfiles and other junk.
+// Extensionless files are sniffed under sourcePath; deleted ones can't be and are dropped.
+func filterCodeFiles(sourcePath string, files []string) []string {
 	var filtered []string
 	for _, f := range files {
 		ext := filepath.Ext(f)
 		name := filepath.Base(f)
 
-		if !codeExtensions[ext] {
+		if !isParseableFile(filepath.Join(sourcePath, f), ext) {
 			continue
 		}
 		if skipFiles[name] {
@@ -221,15 +356,7 @@ func filterCodeFiles(files []string) []string {
 		}
 
 		// Skip files in known skip directories
-		skip := false
-		parts := strings.Split(f, "/")
-		for _, p := range parts[:len(parts)-1] {
-			if skipDirs[p] || strings.HasPrefix(p, ".") {
-				skip = true
-				break
-			}
-		}
-		if skip {
+		if inSkippedDir(f) || skipsFixtureFile(f) {
 			continue
 		}
 
@@ -239,7 +366,7 @@ func filterCodeFiles(files []string) []string {
 }
 
 // detectMtimeChanges uses file modification times for non-git directories.
-func detectMtimeChanges(sourcePath string, lastIndexedAt *time.Time, maxAutoReindexFiles int) (*ChangeSet, error) {
+func detectMtimeChanges(sourcePath string, lastIndexedAt *time.Time, maxAutoReindexFiles int, submodules SubmoduleMode) (*ChangeSet, error) {
 	cs := &ChangeSet{
 		IsGitRepo: false,
 	}
@@ -247,7 +374,7 @@ func detectMtimeChanges(sourcePath string, lastIndexedAt *time.Time, maxAutoRein
 	// First index — no threshold, always allowed
 	if lastIndexedAt == nil {
 		cs.IsFullIndex = true
-		result, err := CrawlDirectory(sourcePath, true)
+		result, err := crawlSource(sourcePath, true, submodules)
 		if err != nil {
 			return nil, fmt.Errorf("crawling for mtime detection: %w", err)
 		}
@@ -258,7 +385,7 @@ func detectMtimeChanges(sourcePath string, lastIndexedAt *time.Time, maxAutoRein
 	}
 
 	// Walk and compare mtimes
-	result, err := CrawlDirectory(sourcePath, true)
+	result, err := crawlSource(sourcePath, true, submodules)
 	if err != nil {
 		return nil, fmt.Errorf("crawling for mtime detection: %w", err)
 	}
@@ -278,5 +405,9 @@ func detectMtimeChanges(sourcePath string, lastIndexedAt *time.Time, maxAutoRein
 		cs.ThresholdExceeded = true
 	}
 
+	// Deletions are invisible to mtimes; the root config hash in the workspace cache covers the common case
+	cs.ConfigChanged = workspaceConfigModifiedSince(sourcePath, *lastIndexedAt)
+	cs.CodeownersChanged = codeownersModifiedSince(sourcePath, *lastIndexedAt)
+
 	return cs, nil
 }
@@ -8,6 +8,8 @@ import (
 	"path/filepath"
 	"testing"
 	"time"
+
+	"github.com/maximilianfalco/mycelium/internal/config"
 )
 
 func initGitRepo(t *testing.T, dir string) {
@@ -61,7 +63,7 @@ func TestDetectChanges_FirstIndex(t *testing.T) {
 	gitCommit(t, dir, "initial")
 
 	ctx := context.Background()
-	cs, err := DetectChanges(ctx, dir, nil, nil, 100, false)
+	cs, err := DetectChanges(ctx, dir, nil, nil, 100, false, SubmodulesSkip)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -86,7 +88,7 @@ func TestDetectChanges_NoChanges(t *testing.T) {
 	commit := gitCommit(t, dir, "initial")
 
 	ctx := context.Background()
-	cs, err := DetectChanges(ctx, dir, &commit, nil, 100, false)
+	cs, err := DetectChanges(ctx, dir, &commit, nil, 100, false, SubmodulesSkip)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -112,7 +114,7 @@ func TestDetectChanges_AddedFile(t *testing.T) {
 	gitCommit(t, dir, "add new.ts")
 
 	ctx := context.Background()
-	cs, err := DetectChanges(ctx, dir, &commit1, nil, 100, false)
+	cs, err := DetectChanges(ctx, dir, &commit1, nil, 100, false, SubmodulesSkip)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -138,7 +140,7 @@ func TestDetectChanges_ModifiedFile(t *testing.T) {
 	gitCommit(t, dir, "modify main.go")
 
 	ctx := context.Background()
-	cs, err := DetectChanges(ctx, dir, &commit1, nil, 100, false)
+	cs, err := DetectChanges(ctx, dir, &commit1, nil, 100, false, SubmodulesSkip)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -162,7 +164,7 @@ func TestDetectChanges_DeletedFile(t *testing.T) {
 	gitCommit(t, dir, "delete old.ts")
 
 	ctx := context.Background()
-	cs, err := DetectChanges(ctx, dir, &commit1, nil, 100, false)
+	cs, err := DetectChanges(ctx, dir, &commit1, nil, 100, false, SubmodulesSkip)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -184,7 +186,7 @@ func TestDetectChanges_RenamedFile(t *testing.T) {
 	gitCommit(t, dir, "rename old.ts -> new.ts")
 
 	ctx := context.Background()
-	cs, err := DetectChanges(ctx, dir, &commit1, nil, 100, false)
+	cs, err := DetectChanges(ctx, dir, &commit1, nil, 100, false, SubmodulesSkip)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -212,7 +214,7 @@ func TestDetectChanges_FiltersNonCodeFiles(t *testing.T) {
 	gitCommit(t, dir, "add various files")
 
 	ctx := context.Background()
-	cs, err := DetectChanges(ctx, dir, &commit1, nil, 100, false)
+	cs, err := DetectChanges(ctx, dir, &commit1, nil, 100, false, SubmodulesSkip)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -236,7 +238,7 @@ func TestDetectChanges_FiltersSkipDirs(t *testing.T) {
 	gitCommit(t, dir, "add files in skip dirs")
 
 	ctx := context.Background()
-	cs, err := DetectChanges(ctx, dir, &commit1, nil, 100, false)
+	cs, err := DetectChanges(ctx, dir, &commit1, nil, 100, false, SubmodulesSkip)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -266,7 +268,7 @@ func TestDetectChanges_ThresholdExceeded(t *testing.T) {
 	gitCommit(t, dir, "add many files")
 
 	ctx := context.Background()
-	cs, err := DetectChanges(ctx, dir, &commit1, nil, 3, false)
+	cs, err := DetectChanges(ctx, dir, &commit1, nil, 3, false, SubmodulesSkip)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -289,7 +291,7 @@ func TestDetectChanges_InvalidLastCommit(t *testing.T) {
 
 	badCommit := "0000000000000000000000000000000000000000"
 	ctx := context.Background()
-	cs, err := DetectChanges(ctx, dir, &badCommit, nil, 100, false)
+	cs, err := DetectChanges(ctx, dir, &badCommit, nil, 100, false, SubmodulesSkip)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -306,7 +308,7 @@ func TestDetectChanges_NonGitDirectory(t *testing.T) {
 	writeFile(t, filepath.Join(dir, "app.ts"), 200)
 
 	ctx := context.Background()
-	cs, err := DetectChanges(ctx, dir, nil, nil, 100, false)
+	cs, err := DetectChanges(ctx, dir, nil, nil, 100, false, SubmodulesSkip)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -336,7 +338,7 @@ func TestDetectChanges_MtimeModified(t *testing.T) {
 	indexTime := time.Now().Add(-30 * time.Minute)
 
 	ctx := context.Background()
-	cs, err := DetectChanges(ctx, dir, nil, &indexTime, 100, false)
+	cs, err := DetectChanges(ctx, dir, nil, &indexTime, 100, false, SubmodulesSkip)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -357,7 +359,7 @@ func TestDetectChanges_EmptyGitRepo(t *testing.T) {
 	// No commits at all
 
 	ctx := context.Background()
-	cs, err := DetectChanges(ctx, dir, nil, nil, 100, false)
+	cs, err := DetectChanges(ctx, dir, nil, nil, 100, false, SubmodulesSkip)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -386,7 +388,7 @@ func TestDetectChanges_BranchInfo(t *testing.T) {
 	commit := gitCommit(t, dir, "add on branch")
 
 	ctx := context.Background()
-	cs, err := DetectChanges(ctx, dir, &commit, nil, 100, false)
+	cs, err := DetectChanges(ctx, dir, &commit, nil, 100, false, SubmodulesSkip)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -413,7 +415,7 @@ func TestDetectChanges_MixedChanges(t *testing.T) {
 	gitCommit(t, dir, "mixed changes")
 
 	ctx := context.Background()
-	cs, err := DetectChanges(ctx, dir, &commit1, nil, 100, false)
+	cs, err := DetectChanges(ctx, dir, &commit1, nil, 100, false, SubmodulesSkip)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -429,11 +431,43 @@ func TestDetectChanges_MixedChanges(t *testing.T) {
 	}
 }
 
+func TestDetectChanges_FileCommitTimes(t *testing.T) {
+	dir := t.TempDir()
+	initGitRepo(t, dir)
+
+	writeFile(t, filepath.Join(dir, "old.go"), 100)
+	writeFile(t, filepath.Join(dir, "new.go"), 100)
+	gitAdd(t, dir, ".")
+	gitCommit(t, dir, "initial") // 2025-01-01, from run's env
+
+	writeFile(t, filepath.Join(dir, "new.go"), 200)
+	gitAdd(t, dir, ".")
+	cmd := exec.Command("git", "commit", "-m", "touch new.go")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_AUTHOR_DATE=2025-03-01T00:00:00Z", "GIT_COMMITTER_DATE=2025-03-01T00:00:00Z")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git commit: %v\n%s", err, out)
+	}
+
+	cs, err := DetectChanges(context.Background(), dir, nil, nil, 100, false, SubmodulesSkip)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	if got := cs.FileCommitTimes["new.go"]; !got.Equal(want) {
+		t.Errorf("new.go commit time = %v, want %v", got, want)
+	}
+	if got, ok := cs.FileCommitTimes["old.go"]; !ok || !got.Before(want) {
+		t.Errorf("old.go commit time = %v (ok=%v), want before %v", got, ok, want)
+	}
+}
+
 // --- filterCodeFiles unit tests ---
 
 func TestFilterCodeFiles_Basic(t *testing.T) {
 	input := []string{"main.go", "app.ts", "readme.md", "config.json"}
-	got := filterCodeFiles(input)
+	got := filterCodeFiles("", input)
 	if len(got) != 2 {
 		t.Errorf("expected 2 code files, got %d: %v", len(got), got)
 	}
@@ -441,14 +475,14 @@ func TestFilterCodeFiles_Basic(t *testing.T) {
 
 func TestFilterCodeFiles_SkipDirs(t *testing.T) {
 	input := []string{"node_modules/pkg/index.js", "src/app.ts", ".hidden/secret.go"}
-	got := filterCodeFiles(input)
+	got := filterCodeFiles("", input)
 	if len(got) != 1 || got[0] != "src/app.ts" {
 		t.Errorf("expected [src/app.ts], got %v", got)
 	}
 }
 
 func TestFilterCodeFiles_Empty(t *testing.T) {
-	got := filterCodeFiles(nil)
+	got := filterCodeFiles("", nil)
 	if len(got) != 0 {
 		t.Errorf("expected empty, got %v", got)
 	}
@@ -456,8 +490,38 @@ func TestFilterCodeFiles_Empty(t *testing.T) {
 
 func TestFilterCodeFiles_AllExtensions(t *testing.T) {
 	input := []string{"a.ts", "b.tsx", "c.js", "d.jsx", "e.go"}
-	got := filterCodeFiles(input)
+	got := filterCodeFiles("", input)
 	if len(got) != 5 {
 		t.Errorf("expected 5, got %d: %v", len(got), got)
 	}
 }
+
+func TestIndexBranch(t *testing.T) {
+	dir := t.TempDir()
+	initGitRepo(t, dir)
+	run(t, dir, "git", "checkout", "-b", "release/1.x")
+	gitCommit(t, dir, "initial")
+
+	ctx := context.Background()
+	if got := indexBranch(ctx, &config.Config{}, dir); got != "" {
+		t.Errorf("expected no branch with BranchWorkspaces off, got %q", got)
+	}
+	on := &config.Config{BranchWorkspaces: true}
+	if got := indexBranch(ctx, on, dir); got != "release/1.x" {
+		t.Errorf("expected release/1.x, got %q", got)
+	}
+	if got := indexBranch(ctx, on, t.TempDir()); got != "" {
+		t.Errorf("expected no branch outside git, got %q", got)
+	}
+
+	run(t, dir, "git", "checkout", "--detach")
+	if got := indexBranch(ctx, on, dir); got != "" {
+		t.Errorf("expected no branch on a detached HEAD, got %q", got)
+	}
+	if got := BranchWorkspaceID("p", "s", "release/1.x"); got != "p/s@release/1.x" {
+		t.Errorf("unexpected branch workspace ID %q", got)
+	}
+	if got := BranchWorkspaceID("p", "s", ""); got != WorkspaceID("p", "s") {
+		t.Errorf("expected the source workspace without a branch, got %q", got)
+	}
+}
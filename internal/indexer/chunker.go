@@ -64,6 +64,19 @@ func PrepareEmbeddingInput(signature, docstring, sourceCode string) (*ChunkResul
 	}, nil
 }
 
+// signatureEmbeddingText is what the signature-only embedding is computed
+// from: the signature, led by the qualified name when it doesn't already
+// say it (a method signature omits its class).
+func signatureEmbeddingText(qualifiedName, signature string) string {
+	if signature == "" {
+		return qualifiedName
+	}
+	if strings.Contains(signature, qualifiedName) {
+		return signature
+	}
+	return qualifiedName + "\n" + signature
+}
+
 // CountTokens returns the token count for a given text using the embedding model's encoding.
 func CountTokens(text string) (int, error) {
 	tke, err := getEncoding()
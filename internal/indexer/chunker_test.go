@@ -118,3 +118,19 @@ func TestBuildText(t *testing.T) {
 		}
 	}
 }
+
+func TestSignatureEmbeddingText(t *testing.T) {
+	tests := []struct {
+		qname, sig string
+		want       string
+	}{
+		{"greet", "function greet(name: string): string", "function greet(name: string): string"},
+		{"Store.get", "get(id: string): User", "Store.get\nget(id: string): User"},
+		{"Config", "", "Config"},
+	}
+	for _, tt := range tests {
+		if got := signatureEmbeddingText(tt.qname, tt.sig); got != tt.want {
+			t.Errorf("signatureEmbeddingText(%q, %q) = %q, want %q", tt.qname, tt.sig, got, tt.want)
+		}
+	}
+}
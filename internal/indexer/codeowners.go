@@ -0,0 +1,249 @@
+package indexer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// codeownersLocations are where GitHub looks for a CODEOWNERS file, in the
+// order it checks them; the first one found is used.
+var codeownersLocations = []string{
+	".github/CODEOWNERS",
+	"CODEOWNERS",
+	"docs/CODEOWNERS",
+}
+
+// codeownersRule is one pattern line of a CODEOWNERS file.
+type codeownersRule struct {
+	pattern string
+	owners  []string
+	re      *regexp.Regexp
+}
+
+// codeowners is a parsed CODEOWNERS file.
+type codeowners struct {
+	rules []codeownersRule
+}
+
+// loadCodeowners reads the source's CODEOWNERS file. Returns nil, nil when
+// the source has none.
+func loadCodeowners(sourcePath string) (*codeowners, error) {
+	for _, loc := range codeownersLocations {
+		data, err := os.ReadFile(filepath.Join(sourcePath, loc))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", loc, err)
+		}
+		return parseCodeowners(data), nil
+	}
+	return nil, nil
+}
+
+// parseCodeowners parses CODEOWNERS content. Each line is a path pattern
+// followed by owners (@user, @org/team, or an email); # starts a comment,
+// and a pattern without owners leaves its paths unowned. Like GitHub, lines
+// using gitignore features CODEOWNERS doesn't support — ! negation and [ ]
+// ranges — are skipped as invalid.
+func parseCodeowners(data []byte) *codeowners {
+	c := &codeowners{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		for i, f := range fields {
+			if strings.HasPrefix(f, "#") {
+				fields = fields[:i]
+				break
+			}
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		pattern := fields[0]
+		if strings.HasPrefix(pattern, "!") || strings.ContainsAny(pattern, "[]") {
+			slog.Debug("skipping unsupported CODEOWNERS pattern", "pattern", pattern)
+			continue
+		}
+		c.rules = append(c.rules, codeownersRule{
+			pattern: pattern,
+			owners:  fields[1:],
+			re:      compileCodeownersPattern(pattern),
+		})
+	}
+	return c
+}
+
+// ownersOf returns the owners of a file path relative to the source root.
+// The last matching rule wins, as on GitHub, so a later line without owners
+// clears what an earlier one assigned. Returns nil for unowned files.
+func (c *codeowners) ownersOf(path string) []string {
+	path = filepath.ToSlash(path)
+	for i := len(c.rules) - 1; i >= 0; i-- {
+		if c.rules[i].re.MatchString(path) {
+			if len(c.rules[i].owners) == 0 {
+				return nil
+			}
+			return c.rules[i].owners
+		}
+	}
+	return nil
+}
+
+// compileCodeownersPattern turns a CODEOWNERS pattern into a regexp over
+// slash-separated file paths, following gitignore rules as GitHub applies
+// them:
+//   - a pattern with a slash at the start or in the middle is anchored to
+//     the root; one without matches at any depth (*.js, apps/)
+//   - * and ? match within one path segment; ** matches across segments
+//   - a pattern matches a file or a directory, and a directory match covers
+//     everything below it; a trailing slash matches directories only
+//   - a trailing /* matches the directory's direct children only (docs/*
+//     owns docs/a.md but not docs/guides/b.md)
+func compileCodeownersPattern(pattern string) *regexp.Regexp {
+	dirOnly := strings.HasSuffix(pattern, "/")
+	p := strings.TrimSuffix(pattern, "/")
+	anchored := strings.Contains(p, "/")
+	p = strings.TrimPrefix(p, "/")
+
+	var b strings.Builder
+	b.WriteString(`\A`)
+	if !anchored {
+		b.WriteString(`(?:.*/)?`)
+	}
+	segments := strings.Split(p, "/")
+	for i, seg := range segments {
+		last := i == len(segments)-1
+		switch {
+		case seg == "**" && last:
+			b.WriteString(`.*`)
+		case seg == "**":
+			b.WriteString(`(?:.*/)?`)
+		default:
+			b.WriteString(globSegment(seg))
+			if !last {
+				b.WriteString("/")
+			}
+		}
+	}
+
+	last := segments[len(segments)-1]
+	switch {
+	case dirOnly:
+		b.WriteString(`/.*`)
+	case last == "**", last == "*" && len(segments) > 1:
+	default:
+		b.WriteString(`(?:/.*)?`)
+	}
+	b.WriteString(`\z`)
+	return regexp.MustCompile(b.String())
+}
+
+// globSegment converts one path segment of a glob to a regexp.
+func globSegment(seg string) string {
+	var b strings.Builder
+	for _, r := range seg {
+		switch r {
+		case '*':
+			b.WriteString(`[^/]*`)
+		case '?':
+			b.WriteString(`[^/]`)
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	return b.String()
+}
+
+// applyCodeowners stores the owners from the source's CODEOWNERS file on
+// every node of the workspace. It covers unchanged files too, so editing
+// CODEOWNERS takes effect on the next run without a full reindex. Methods
+// are stored under their class, so they take the owners of their parent's
+// file. A source without a CODEOWNERS file ends up with no owners.
+func applyCodeowners(ctx context.Context, pool *pgxpool.Pool, workspaceID, sourcePath string) error {
+	co, err := loadCodeowners(sourcePath)
+	if err != nil {
+		return err
+	}
+
+	rows, err := pool.Query(ctx, `SELECT DISTINCT file_path FROM nodes WHERE workspace_id = $1 AND kind <> 'method'`, workspaceID)
+	if err != nil {
+		return fmt.Errorf("listing indexed files: %w", err)
+	}
+	var files []string
+	for rows.Next() {
+		var f string
+		if err := rows.Scan(&f); err != nil {
+			rows.Close()
+			return fmt.Errorf("scanning file path: %w", err)
+		}
+		files = append(files, f)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterating file paths: %w", err)
+	}
+
+	// Owners never contain whitespace, so each file's list travels as one
+	// space-joined string and is split back into an array in SQL.
+	owners := make([]string, len(files))
+	if co != nil {
+		for i, f := range files {
+			owners[i] = strings.Join(co.ownersOf(f), " ")
+		}
+	}
+
+	if _, err := pool.Exec(ctx, `
+		UPDATE nodes n SET owners = o.owners
+		FROM (
+			SELECT f, NULLIF(string_to_array(joined, ' '), '{}') AS owners
+			FROM unnest($2::text[], $3::text[]) AS u(f, joined)
+		) o
+		WHERE n.workspace_id = $1 AND n.kind <> 'method' AND n.file_path = o.f
+		  AND n.owners IS DISTINCT FROM o.owners`, workspaceID, files, owners); err != nil {
+		return fmt.Errorf("updating file owners: %w", err)
+	}
+	if _, err := pool.Exec(ctx, `
+		UPDATE nodes n SET owners = parent.owners
+		FROM edges c
+		JOIN nodes parent ON c.source_id = parent.id
+		WHERE n.workspace_id = $1 AND n.kind = 'method'
+		  AND c.target_id = n.id AND c.kind = 'contains'
+		  AND n.owners IS DISTINCT FROM parent.owners`, workspaceID); err != nil {
+		return fmt.Errorf("updating method owners: %w", err)
+	}
+	return nil
+}
+
+// hasCodeownersFile reports whether any of files is a CODEOWNERS location.
+func hasCodeownersFile(files []string) bool {
+	for _, f := range files {
+		for _, loc := range codeownersLocations {
+			if filepath.ToSlash(f) == loc {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// codeownersModifiedSince reports whether a CODEOWNERS file under rootPath
+// was modified after since, for sources tracked by mtime.
+func codeownersModifiedSince(rootPath string, since time.Time) bool {
+	for _, loc := range codeownersLocations {
+		if info, err := os.Stat(filepath.Join(rootPath, loc)); err == nil && info.ModTime().After(since) {
+			return true
+		}
+	}
+	return false
+}
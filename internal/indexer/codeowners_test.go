@@ -0,0 +1,126 @@
+package indexer
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestCompileCodeownersPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		// Unanchored patterns match at any depth
+		{"*", "main.go", true},
+		{"*", "a/b/c.ts", true},
+		{"*.js", "index.js", true},
+		{"*.js", "src/lib/index.js", true},
+		{"*.js", "src/index.ts", false},
+		{"apps/", "apps/web/page.tsx", true},
+		{"apps/", "packages/apps/x.ts", true},
+		{"apps/", "apps.ts", false},
+		{"build", "build/out.js", true},
+		{"build", "src/build/out.js", true},
+		// A leading or inner slash anchors to the root
+		{"/docs/", "docs/guide.md", true},
+		{"/docs/", "src/docs/guide.md", false},
+		{"/build/logs/", "build/logs/today.log", true},
+		{"/build/logs/", "x/build/logs/today.log", false},
+		{"src/api", "src/api/handler.go", true},
+		{"src/api", "web/src/api/handler.go", false},
+		{"/Makefile", "Makefile", true},
+		{"/Makefile", "sub/Makefile", false},
+		// A trailing /* owns direct children only
+		{"docs/*", "docs/getting-started.md", true},
+		{"docs/*", "docs/build-app/troubleshooting.md", false},
+		// ** crosses directories
+		{"**/logs", "logs/a.log", true},
+		{"**/logs", "deep/down/logs/a.log", true},
+		{"src/**/test", "src/test/a.go", true},
+		{"src/**/test", "src/a/b/test/a.go", true},
+		{"src/**", "src/a/b.go", true},
+		{"src/**", "lib/a.go", false},
+		// * and ? stay within one segment
+		{"/src/*.go", "src/main.go", true},
+		{"/src/*.go", "src/sub/main.go", false},
+		{"file?.ts", "file1.ts", true},
+		{"file?.ts", "file10.ts", false},
+		// Metacharacters are literal and matching is case-sensitive
+		{"a+b.ts", "a+b.ts", true},
+		{"a+b.ts", "aab.ts", false},
+		{"/Docs/", "docs/x.md", false},
+	}
+	for _, tt := range tests {
+		if got := compileCodeownersPattern(tt.pattern).MatchString(tt.path); got != tt.want {
+			t.Errorf("pattern %q on %q = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestCodeownersOwnersOf(t *testing.T) {
+	co := parseCodeowners([]byte(`# Default owners for everything
+*       @org/core
+
+# Later matches take precedence
+*.js    @org/frontend @alice # inline comment
+/docs/  docs@example.com
+/docs/generated/
+
+!/src/keep.js @nobody
+/src/[ab].js @nobody
+`))
+
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"main.go", []string{"@org/core"}},
+		{"web/app.js", []string{"@org/frontend", "@alice"}},
+		{"docs/intro.md", []string{"docs@example.com"}},
+		{"docs/generated/api.md", nil},
+		// Negation and character ranges are unsupported and skipped
+		{"src/keep.js", []string{"@org/frontend", "@alice"}},
+		{"src/a.js", []string{"@org/frontend", "@alice"}},
+	}
+	for _, tt := range tests {
+		if got := co.ownersOf(tt.path); !slices.Equal(got, tt.want) {
+			t.Errorf("ownersOf(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+	if n := len(co.rules); n != 4 {
+		t.Errorf("expected 4 rules, got %d", n)
+	}
+}
+
+func TestLoadCodeowners(t *testing.T) {
+	dir := t.TempDir()
+	co, err := loadCodeowners(dir)
+	if err != nil || co != nil {
+		t.Fatalf("expected nil, nil without a CODEOWNERS file, got %v, %v", co, err)
+	}
+
+	// .github/CODEOWNERS takes precedence over the root file
+	os.WriteFile(filepath.Join(dir, "CODEOWNERS"), []byte("* @root\n"), 0644)
+	os.MkdirAll(filepath.Join(dir, ".github"), 0755)
+	os.WriteFile(filepath.Join(dir, ".github", "CODEOWNERS"), []byte("* @github\n"), 0644)
+
+	co, err = loadCodeowners(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := co.ownersOf("a.go"); !slices.Equal(got, []string{"@github"}) {
+		t.Errorf("expected .github/CODEOWNERS to win, got %v", got)
+	}
+}
+
+func TestHasCodeownersFile(t *testing.T) {
+	if !hasCodeownersFile([]string{"src/a.ts", ".github/CODEOWNERS"}) {
+		t.Error("expected .github/CODEOWNERS to be detected")
+	}
+	if hasCodeownersFile([]string{"src/CODEOWNERS", "CODEOWNERS.md"}) {
+		t.Error("only the locations GitHub reads count")
+	}
+}
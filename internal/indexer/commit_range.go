@@ -0,0 +1,163 @@
+package indexer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// lineRange is a run of changed lines on the new side of a diff. A hunk that
+// only deletes lines has no new lines; it is recorded as the gap after line
+// start, with end == start and gap set.
+type lineRange struct {
+	start, end int
+	gap        bool
+}
+
+// touches reports whether a node spanning [start, end] contains the change.
+// A deletion gap counts when the node spans both sides of it.
+func (r lineRange) touches(start, end int) bool {
+	if r.gap {
+		return start <= r.start && end > r.start
+	}
+	return start <= r.end && end >= r.start
+}
+
+var hunkHeaderRe = regexp.MustCompile(`^@@ -\d+(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// GetNodesModifiedInCommitRange maps the commits between fromCommit and
+// toCommit to the nodes they changed: the IDs of the workspace's nodes whose
+// line range overlaps a line the range added or changed, or spans lines it
+// deleted. Line numbers are taken from toCommit, so the workspace should be
+// indexed at it (typically HEAD); files it deleted have no nodes left to
+// report. Methods are matched against the file of their class. repoDir is
+// the source's local checkout, and diff paths are taken relative to it.
+// The result is sorted and feeds GetDependents for impact analysis.
+func GetNodesModifiedInCommitRange(ctx context.Context, pool *pgxpool.Pool, workspaceID, repoDir, fromCommit, toCommit string) ([]string, error) {
+	changed, err := gitChangedLines(ctx, repoDir, fromCommit, toCommit)
+	if err != nil {
+		return nil, err
+	}
+	if len(changed) == 0 {
+		return []string{}, nil
+	}
+	files := make([]string, 0, len(changed))
+	for f := range changed {
+		files = append(files, f)
+	}
+
+	rows, err := pool.Query(ctx, `
+		SELECT n.id,
+		       CASE WHEN n.kind = 'method' THEN COALESCE(parent.file_path, n.file_path) ELSE n.file_path END AS file,
+		       COALESCE(n.start_line, 0), COALESCE(n.end_line, 0)
+		FROM nodes n
+		LEFT JOIN edges c ON n.kind = 'method' AND c.target_id = n.id AND c.kind = 'contains'
+		LEFT JOIN nodes parent ON c.source_id = parent.id
+		WHERE n.workspace_id = $1
+		  AND (n.file_path = ANY($2) OR parent.file_path = ANY($2))`, workspaceID, files)
+	if err != nil {
+		return nil, fmt.Errorf("querying nodes in changed files: %w", err)
+	}
+	defer rows.Close()
+
+	seen := make(map[string]bool)
+	ids := []string{}
+	for rows.Next() {
+		var id, file string
+		var start, end int
+		if err := rows.Scan(&id, &file, &start, &end); err != nil {
+			return nil, fmt.Errorf("scanning node row: %w", err)
+		}
+		if seen[id] {
+			continue
+		}
+		for _, r := range changed[file] {
+			if r.touches(start, end) {
+				seen[id] = true
+				ids = append(ids, id)
+				break
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating node rows: %w", err)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// gitChangedLines runs a zero-context git diff between two commits and
+// returns the changed line ranges per file, on the toCommit side.
+func gitChangedLines(ctx context.Context, dir, fromCommit, toCommit string) (map[string][]lineRange, error) {
+	cmd := exec.CommandContext(ctx, "git", "-c", "core.quotePath=false", "diff",
+		"--unified=0", "--no-color", "--no-ext-diff", "--relative", fromCommit+".."+toCommit)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git diff %s..%s: %w", fromCommit, toCommit, err)
+	}
+	return parseDiffHunks(out), nil
+}
+
+// parseDiffHunks reads the +++ file headers and @@ hunk headers of a
+// unified diff. Hunk bodies are skipped by their line counts, so a changed
+// line that happens to start with "++ " or "@@" is never taken for a header.
+// Deleted files (+++ /dev/null) are skipped.
+func parseDiffHunks(diff []byte) map[string][]lineRange {
+	changed := make(map[string][]lineRange)
+	file := ""
+	body := 0 // lines left in the current hunk
+	scanner := bufio.NewScanner(bytes.NewReader(diff))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if body > 0 {
+			// "\ No newline at end of file" isn't counted in the hunk
+			if !strings.HasPrefix(line, "\\") {
+				body--
+			}
+			continue
+		}
+		if name, ok := strings.CutPrefix(line, "+++ "); ok {
+			file = ""
+			if name != "/dev/null" {
+				file = strings.TrimPrefix(name, "b/")
+			}
+			continue
+		}
+		m := hunkHeaderRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		newStart, _ := strconv.Atoi(m[2])
+		oldCount, newCount := hunkCount(m[1]), hunkCount(m[3])
+		body = oldCount + newCount
+		if file == "" {
+			continue
+		}
+		if newCount == 0 {
+			changed[file] = append(changed[file], lineRange{start: newStart, end: newStart, gap: true})
+			continue
+		}
+		changed[file] = append(changed[file], lineRange{start: newStart, end: newStart + newCount - 1})
+	}
+	return changed
+}
+
+// hunkCount parses the optional line count of a hunk header side, which
+// git omits when it is 1.
+func hunkCount(s string) int {
+	if s == "" {
+		return 1
+	}
+	n, _ := strconv.Atoi(s)
+	return n
+}
@@ -0,0 +1,108 @@
+package indexer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestParseDiffHunks(t *testing.T) {
+	diff := []byte(`diff --git a/src/a.ts b/src/a.ts
+index 1111111..2222222 100644
+--- a/src/a.ts
++++ b/src/a.ts
+@@ -3 +3 @@ export function a() {
+-  return 1
++  return 2
+@@ -10,2 +9,0 @@ export function b() {
+--- not a header
+-++ neither
+@@ -20,0 +18,3 @@
++++ added line, not a header
++x
++y
+diff --git a/src/gone.ts b/src/gone.ts
+deleted file mode 100644
+--- a/src/gone.ts
++++ /dev/null
+@@ -1,2 +0,0 @@
+-export const gone = 1
+-export const alsoGone = 2
+diff --git a/src/new.ts b/src/new.ts
+--- /dev/null
++++ b/src/new.ts
+@@ -0,0 +1,2 @@
++export const x = 1
++export const y = 2
+\ No newline at end of file
+`)
+	got := parseDiffHunks(diff)
+	want := map[string][]lineRange{
+		"src/a.ts":   {{start: 3, end: 3}, {start: 9, end: 9, gap: true}, {start: 18, end: 20}},
+		"src/new.ts": {{start: 1, end: 2}},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("files = %v, want %v", got, want)
+	}
+	for file, ranges := range want {
+		if !slices.Equal(got[file], ranges) {
+			t.Errorf("%s: ranges = %v, want %v", file, got[file], ranges)
+		}
+	}
+}
+
+func TestLineRangeTouches(t *testing.T) {
+	changed := lineRange{start: 5, end: 7}
+	for _, tc := range []struct {
+		start, end int
+		want       bool
+	}{
+		{1, 4, false}, {1, 5, true}, {6, 6, true}, {7, 12, true}, {8, 12, false},
+	} {
+		if got := changed.touches(tc.start, tc.end); got != tc.want {
+			t.Errorf("[5,7] touches [%d,%d] = %v, want %v", tc.start, tc.end, got, tc.want)
+		}
+	}
+
+	// Lines deleted between 9 and 10 change a node spanning both
+	gap := lineRange{start: 9, end: 9, gap: true}
+	for _, tc := range []struct {
+		start, end int
+		want       bool
+	}{
+		{5, 9, false}, {10, 12, false}, {8, 10, true},
+	} {
+		if got := gap.touches(tc.start, tc.end); got != tc.want {
+			t.Errorf("gap after 9 touches [%d,%d] = %v, want %v", tc.start, tc.end, got, tc.want)
+		}
+	}
+}
+
+func TestGitChangedLines(t *testing.T) {
+	dir := t.TempDir()
+	initGitRepo(t, dir)
+	src := filepath.Join(dir, "src")
+	os.MkdirAll(src, 0o755)
+	os.WriteFile(filepath.Join(src, "a.ts"), []byte("export function a() {\n  return 1\n}\n\nexport function b() {\n  return 2\n}\n"), 0o644)
+	gitAdd(t, dir, ".")
+	from := gitCommit(t, dir, "initial")
+
+	os.WriteFile(filepath.Join(src, "a.ts"), []byte("export function a() {\n  return 1\n}\n\nexport function b() {\n  return 3\n}\n"), 0o644)
+	gitAdd(t, dir, ".")
+	to := gitCommit(t, dir, "change b")
+
+	// Paths are relative to the directory git runs in
+	changed, err := gitChangedLines(context.Background(), src, from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []lineRange{{start: 6, end: 6}}; len(changed) != 1 || !slices.Equal(changed["a.ts"], want) {
+		t.Errorf("changed = %v, want a.ts: %v", changed, want)
+	}
+
+	if _, err := gitChangedLines(context.Background(), dir, "nosuchcommit", to); err == nil {
+		t.Error("expected an error for an unknown commit")
+	}
+}
@@ -2,12 +2,15 @@ package indexer
 
 import (
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
 
 	ignore "github.com/sabhiram/go-gitignore"
+
+	"github.com/maximilianfalco/mycelium/internal/indexer/parsers"
 )
 
 const defaultMaxFileSizeKB = 100
@@ -34,6 +37,9 @@ type FileInfo struct {
 	RelPath   string `json:"relPath"`
 	Extension string `json:"extension"`
 	SizeBytes int64  `json:"sizeBytes"`
+	// Fixture marks a file under a fixture directory crawled in FixturesTag
+	// mode.
+	Fixture bool `json:"fixture,omitempty"`
 }
 
 type CrawlStats struct {
@@ -50,7 +56,9 @@ type CrawlResult struct {
 // CrawlDirectory walks rootPath and returns a list of files to process.
 // If isCode is true, only files with code extensions are included.
 // Respects .gitignore at all directory levels, skips common junk directories,
-// lockfiles, and files exceeding the size limit.
+// fixture directories (see SetFixtures), lockfiles, and files exceeding the
+// size limit. Submodules declared in
+// rootPath's .gitmodules are never entered; see crawlSource for including them.
 func CrawlDirectory(rootPath string, isCode bool, maxFileSizeKB ...int) (*CrawlResult, error) {
 	maxBytes := int64(defaultMaxFileSizeKB) * 1024
 	if len(maxFileSizeKB) > 0 && maxFileSizeKB[0] > 0 {
@@ -70,6 +78,11 @@ func CrawlDirectory(rootPath string, isCode bool, maxFileSizeKB ...int) (*CrawlR
 		},
 	}
 
+	submodules := make(map[string]bool)
+	for _, p := range readSubmodulePaths(rootPath) {
+		submodules[p] = true
+	}
+
 	var ignoreStack []ignoreEntry
 
 	// Load root .gitignore if present
@@ -112,6 +125,18 @@ func CrawlDirectory(rootPath string, isCode bool, maxFileSizeKB ...int) (*CrawlR
 				return filepath.SkipDir
 			}
 
+			// Fixture directories, unless they're indexed and tagged
+			if skipsFixtureDir(relPath) {
+				result.Stats.Skipped++
+				return filepath.SkipDir
+			}
+
+			// Submodules are separate repositories with their own history
+			if submodules[filepath.ToSlash(relPath)] {
+				result.Stats.Skipped++
+				return filepath.SkipDir
+			}
+
 			// Skip hidden directories
 			if strings.HasPrefix(name, ".") {
 				result.Stats.Skipped++
@@ -166,7 +191,7 @@ func CrawlDirectory(rootPath string, isCode bool, maxFileSizeKB ...int) (*CrawlR
 		}
 
 		// Code extension filter
-		if isCode && !codeExtensions[ext] {
+		if isCode && !isParseableFile(path, ext) {
 			result.Stats.Skipped++
 			return nil
 		}
@@ -176,6 +201,7 @@ func CrawlDirectory(rootPath string, isCode bool, maxFileSizeKB ...int) (*CrawlR
 			RelPath:   relPath,
 			Extension: ext,
 			SizeBytes: fileInfo.Size(),
+			Fixture:   inFixtureDir(relPath),
 		})
 		result.Stats.Total++
 		result.Stats.ByExtension[ext]++
@@ -190,6 +216,33 @@ func CrawlDirectory(rootPath string, isCode bool, maxFileSizeKB ...int) (*CrawlR
 	return result, nil
 }
 
+// isParseableFile reports whether a file should be handed to the parsers: a
+// known code extension, an extension with a registered parser, or an
+// extensionless script whose shebang names a supported interpreter.
+func isParseableFile(absPath, ext string) bool {
+	if codeExtensions[ext] || parsers.HasParser(ext) {
+		return true
+	}
+	if ext != "" {
+		return false
+	}
+	return sniffShebang(absPath) != ""
+}
+
+// sniffShebang reads just the first line of a file and returns the
+// extension of the parser its shebang maps to, or "".
+func sniffShebang(absPath string) string {
+	f, err := os.Open(absPath)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	buf := make([]byte, 256)
+	n, _ := io.ReadFull(f, buf)
+	return parsers.ShebangExtension(buf[:n])
+}
+
 type ignoreEntry struct {
 	depth   int
 	matcher *ignore.GitIgnore
@@ -319,3 +319,21 @@ func TestCrawlDirectory_RelPaths(t *testing.T) {
 		}
 	}
 }
+
+func TestCrawlDirectory_ShebangScripts(t *testing.T) {
+	dir := t.TempDir()
+
+	os.MkdirAll(filepath.Join(dir, "bin"), 0o755)
+	os.WriteFile(filepath.Join(dir, "bin", "cli"), []byte("#!/usr/bin/env node\nmain()\n"), 0o755)
+	os.WriteFile(filepath.Join(dir, "bin", "setup"), []byte("#!/bin/bash\necho hi\n"), 0o755)
+	os.WriteFile(filepath.Join(dir, "LICENSE"), []byte("MIT License\n"), 0o644)
+
+	result, err := CrawlDirectory(dir, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(result.Files) != 1 || result.Files[0].RelPath != filepath.Join("bin", "cli") {
+		t.Errorf("expected only the node script, got %+v", result.Files)
+	}
+}
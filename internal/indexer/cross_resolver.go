@@ -2,8 +2,11 @@ package indexer
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"path"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -24,12 +27,17 @@ type unresolvedEntry struct {
 	RawImport    string
 	Kind         string
 	Line         int
+	FilePath     string
 	WorkspaceID  string
+	SourceID     string
+	Branch       string
 }
 
 type packageEntry struct {
 	ID          string
 	WorkspaceID string
+	SourceID    string
+	Branch      string
 	Name        string
 	Path        string
 }
@@ -76,6 +84,31 @@ func ResolveCrossSources(ctx context.Context, pool *pgxpool.Pool, projectID stri
 			continue
 		}
 
+		// Go import paths name a package directory, usually below a module
+		// other than the importer's, so they're matched on the whole path
+		if strings.HasSuffix(ref.FilePath, ".go") {
+			for _, pkg := range crossCandidates(ref, goImportCandidates(ref.RawImport, packages)) {
+				targetNodeID, err := findGoPackageNode(ctx, pool, pkg, ref.RawImport)
+				if err != nil {
+					slog.Warn("cross-resolve: Go package lookup failed",
+						"import", ref.RawImport, "package", pkg.Name, "error", err)
+					continue
+				}
+				if targetNodeID == "" {
+					continue
+				}
+				edges = append(edges, crossEdge{
+					sourceNodeID: ref.SourceNodeID,
+					targetNodeID: targetNodeID,
+					kind:         "imports",
+					line:         ref.Line,
+				})
+				resolvedIDs = append(resolvedIDs, ref.ID)
+				break
+			}
+			continue
+		}
+
 		pkgName, subpath := splitSpecifier(ref.RawImport)
 
 		candidates, ok := pkgByName[pkgName]
@@ -83,11 +116,7 @@ func ResolveCrossSources(ctx context.Context, pool *pgxpool.Pool, projectID stri
 			continue
 		}
 
-		for _, pkg := range candidates {
-			if pkg.WorkspaceID == ref.WorkspaceID {
-				continue
-			}
-
+		for _, pkg := range crossCandidates(ref, candidates) {
 			targetNodeID, err := findTargetNode(ctx, pool, pkg, subpath)
 			if err != nil {
 				slog.Warn("cross-resolve: target lookup failed",
@@ -129,6 +158,9 @@ func ResolveCrossSources(ctx context.Context, pool *pgxpool.Pool, projectID stri
 	if err := deleteResolvedRefs(ctx, tx, resolvedIDs); err != nil {
 		return nil, fmt.Errorf("deleting resolved refs: %w", err)
 	}
+	if err := bumpGraphVersion(ctx, tx, projectID); err != nil {
+		return nil, err
+	}
 
 	if err := tx.Commit(ctx); err != nil {
 		return nil, fmt.Errorf("committing transaction: %w", err)
@@ -149,15 +181,17 @@ func ResolveCrossSources(ctx context.Context, pool *pgxpool.Pool, projectID stri
 	return result, nil
 }
 
-// loadProjectUnresolvedRefs returns all unresolved_refs for nodes in workspaces
-// belonging to the given project.
+// loadProjectUnresolvedRefs returns the unresolved imports for nodes in
+// workspaces belonging to the given project. Unresolved external calls are
+// kept for FindCallersMatching and never resolve across sources.
 func loadProjectUnresolvedRefs(ctx context.Context, pool *pgxpool.Pool, projectID string) ([]unresolvedEntry, error) {
 	rows, err := pool.Query(ctx, `
-		SELECT ur.id, ur.source_node_id, ur.raw_import, ur.kind, ur.line_number, n.workspace_id
+		SELECT ur.id, ur.source_node_id, ur.raw_import, ur.kind, ur.line_number, n.file_path, n.workspace_id,
+		       COALESCE(w.source_id, ''), COALESCE(w.branch, '')
 		FROM unresolved_refs ur
 		JOIN nodes n ON ur.source_node_id = n.id
 		JOIN workspaces w ON n.workspace_id = w.id
-		WHERE w.project_id = $1`,
+		WHERE w.project_id = $1 AND ur.kind = 'imports'`,
 		projectID,
 	)
 	if err != nil {
@@ -168,7 +202,7 @@ func loadProjectUnresolvedRefs(ctx context.Context, pool *pgxpool.Pool, projectI
 	var refs []unresolvedEntry
 	for rows.Next() {
 		var r unresolvedEntry
-		if err := rows.Scan(&r.ID, &r.SourceNodeID, &r.RawImport, &r.Kind, &r.Line, &r.WorkspaceID); err != nil {
+		if err := rows.Scan(&r.ID, &r.SourceNodeID, &r.RawImport, &r.Kind, &r.Line, &r.FilePath, &r.WorkspaceID, &r.SourceID, &r.Branch); err != nil {
 			return nil, fmt.Errorf("scanning unresolved ref: %w", err)
 		}
 		refs = append(refs, r)
@@ -179,7 +213,7 @@ func loadProjectUnresolvedRefs(ctx context.Context, pool *pgxpool.Pool, projectI
 // loadProjectPackages returns all packages across all workspaces in the project.
 func loadProjectPackages(ctx context.Context, pool *pgxpool.Pool, projectID string) ([]packageEntry, error) {
 	rows, err := pool.Query(ctx, `
-		SELECT p.id, p.workspace_id, p.name, p.path
+		SELECT p.id, p.workspace_id, COALESCE(w.source_id, ''), COALESCE(w.branch, ''), p.name, p.path
 		FROM packages p
 		JOIN workspaces w ON p.workspace_id = w.id
 		WHERE w.project_id = $1`,
@@ -193,7 +227,7 @@ func loadProjectPackages(ctx context.Context, pool *pgxpool.Pool, projectID stri
 	var packages []packageEntry
 	for rows.Next() {
 		var p packageEntry
-		if err := rows.Scan(&p.ID, &p.WorkspaceID, &p.Name, &p.Path); err != nil {
+		if err := rows.Scan(&p.ID, &p.WorkspaceID, &p.SourceID, &p.Branch, &p.Name, &p.Path); err != nil {
 			return nil, fmt.Errorf("scanning package: %w", err)
 		}
 		packages = append(packages, p)
@@ -201,6 +235,81 @@ func loadProjectPackages(ctx context.Context, pool *pgxpool.Pool, projectID stri
 	return packages, rows.Err()
 }
 
+// crossCandidates narrows the packages named by ref's import to those it may
+// resolve into: ones in other sources, since a source's own packages were
+// resolved in-source and its other branches are separate snapshots of it.
+// Packages on ref's branch come first, so a main branch imports main.
+func crossCandidates(ref unresolvedEntry, packages []packageEntry) []packageEntry {
+	var same, other []packageEntry
+	for _, pkg := range packages {
+		switch {
+		case pkg.WorkspaceID == ref.WorkspaceID:
+		case ref.SourceID != "" && pkg.SourceID == ref.SourceID:
+		case pkg.Branch == ref.Branch:
+			same = append(same, pkg)
+		default:
+			other = append(other, pkg)
+		}
+	}
+	return append(same, other...)
+}
+
+// goImportCandidates returns the packages a Go import path may resolve into,
+// one per workspace, with Path moved to the imported directory. A package
+// named by the whole path matches; otherwise the longest package whose import
+// path is a prefix does, typically the module root, and the rest of the path
+// is taken as a directory below it. That covers a directory the module's
+// detector didn't list as a package.
+func goImportCandidates(importPath string, packages []packageEntry) []packageEntry {
+	best := make(map[string]packageEntry)
+	var order []string
+	for _, pkg := range packages {
+		rest, ok := strings.CutPrefix(importPath, pkg.Name)
+		if !ok || (rest != "" && !strings.HasPrefix(rest, "/")) {
+			continue
+		}
+		prev, seen := best[pkg.WorkspaceID]
+		if !seen {
+			order = append(order, pkg.WorkspaceID)
+		} else if len(prev.Name) >= len(pkg.Name) {
+			continue
+		}
+		pkg.Path = path.Join(filepath.ToSlash(pkg.Path), strings.TrimPrefix(rest, "/"))
+		best[pkg.WorkspaceID] = pkg
+	}
+
+	candidates := make([]packageEntry, 0, len(order))
+	for _, ws := range order {
+		candidates = append(candidates, best[ws])
+	}
+	return candidates
+}
+
+// findGoPackageNode returns the node an import of importPath lands on in
+// pkg's workspace: the Go package node named by the import path, or failing
+// that the first node of the first file directly in pkg.Path.
+func findGoPackageNode(ctx context.Context, pool *pgxpool.Pool, pkg packageEntry, importPath string) (string, error) {
+	var nodeID string
+	err := pool.QueryRow(ctx, `
+		SELECT id FROM nodes
+		WHERE workspace_id = $1
+		  AND ((kind = 'package' AND qualified_name = $2)
+		       OR (file_path LIKE '%.go'
+		           AND CASE WHEN strpos(file_path, '/') = 0 THEN '.'
+		                    ELSE regexp_replace(file_path, '/[^/]*$', '') END = $3))
+		ORDER BY kind = 'package' DESC, file_path ASC, start_line ASC
+		LIMIT 1`,
+		pkg.WorkspaceID, importPath, pkg.Path,
+	).Scan(&nodeID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("querying Go package node: %w", err)
+	}
+	return nodeID, nil
+}
+
 // splitSpecifier separates a package name from an optional subpath.
 // "@company/auth/validators" -> ("@company/auth", "validators")
 // "@company/auth"            -> ("@company/auth", "")
@@ -229,8 +338,9 @@ func splitSpecifier(specifier string) (string, string) {
 	return specifier[:slash], specifier[slash+1:]
 }
 
-// entryPointFiles are the common entry point file patterns, ordered by priority.
-var entryPointFiles = []string{
+// EntryPointFiles are the common entry point file patterns relative to a
+// package root, ordered by priority.
+var EntryPointFiles = []string{
 	"src/index.ts",
 	"src/index.tsx",
 	"src/index.js",
@@ -255,7 +365,7 @@ func findTargetNode(ctx context.Context, pool *pgxpool.Pool, pkg packageEntry, s
 // findEntryPointNode finds a node in a common entry point file within the package.
 func findEntryPointNode(ctx context.Context, pool *pgxpool.Pool, packageID, packagePath string) (string, error) {
 	// Try each entry point pattern
-	for _, ep := range entryPointFiles {
+	for _, ep := range EntryPointFiles {
 		var filePath string
 		if packagePath == "." || packagePath == "" {
 			filePath = ep
@@ -31,3 +31,42 @@ func TestSplitSpecifier(t *testing.T) {
 		})
 	}
 }
+
+func TestCrossCandidates(t *testing.T) {
+	ref := unresolvedEntry{WorkspaceID: "p/app@main", SourceID: "app", Branch: "main"}
+	packages := []packageEntry{
+		{ID: "own", WorkspaceID: "p/app@main", SourceID: "app", Branch: "main"},
+		{ID: "own-release", WorkspaceID: "p/app@release", SourceID: "app", Branch: "release"},
+		{ID: "lib-release", WorkspaceID: "p/lib@release", SourceID: "lib", Branch: "release"},
+		{ID: "lib-main", WorkspaceID: "p/lib@main", SourceID: "lib", Branch: "main"},
+	}
+
+	got := crossCandidates(ref, packages)
+	if len(got) != 2 || got[0].ID != "lib-main" || got[1].ID != "lib-release" {
+		t.Errorf("expected lib's main package before its release one and none of app's, got %+v", got)
+	}
+}
+
+func TestGoImportCandidates(t *testing.T) {
+	packages := []packageEntry{
+		{ID: "other-root", WorkspaceID: "p/other", Name: "github.com/acme/other-module", Path: "."},
+		{ID: "other-pkg", WorkspaceID: "p/other", Name: "github.com/acme/other-module/pkg", Path: "pkg"},
+		{ID: "mono", WorkspaceID: "p/mono", Name: "github.com/acme/other-module", Path: "modules/other"},
+		{ID: "lookalike", WorkspaceID: "p/look", Name: "github.com/acme/other", Path: "."},
+		{ID: "core", WorkspaceID: "p/web", Name: "@acme/core", Path: "packages/core"},
+	}
+
+	got := goImportCandidates("github.com/acme/other-module/pkg", packages)
+	if len(got) != 2 || got[0].ID != "other-pkg" || got[0].Path != "pkg" || got[1].ID != "mono" || got[1].Path != "modules/other/pkg" {
+		t.Errorf("expected the exact package in one workspace and a module-relative directory in the other, got %+v", got)
+	}
+
+	got = goImportCandidates("github.com/acme/other-module/internal/auth", packages)
+	if len(got) != 2 || got[0].ID != "other-root" || got[0].Path != "internal/auth" {
+		t.Errorf("expected the module root to place an unlisted directory, got %+v", got)
+	}
+
+	if got := goImportCandidates("github.com/spf13/cobra", packages); len(got) != 0 {
+		t.Errorf("expected no candidates for a third-party import, got %+v", got)
+	}
+}
@@ -12,6 +12,11 @@ type WorkspaceInfo struct {
 	Packages       []PackageInfo     `json:"packages"`
 	AliasMap       map[string]string `json:"aliasMap"`
 	TSConfigPaths  map[string]string `json:"tsconfigPaths"`
+	// TSBaseURLs maps the directory of each tsconfig (or jsconfig) that sets
+	// compilerOptions.baseUrl, itself or through extends, to the directory
+	// the baseUrl names, both relative to the source root. Non-relative
+	// imports from files under the config resolve against that directory.
+	TSBaseURLs map[string]string `json:"tsBaseUrls,omitempty"`
 }
 
 type PackageInfo struct {
@@ -19,6 +24,13 @@ type PackageInfo struct {
 	Path       string `json:"path"`
 	Version    string `json:"version"`
 	EntryPoint string `json:"entryPoint"`
+	// EntryPoints maps import subpaths ("." for the bare name, "./server",
+	// "./utils/*") to files relative to Path, from package.json "exports"
+	// plus EntryPoint as ".". Nil for packages without subpath entries.
+	EntryPoints map[string]string `json:"entryPoints,omitempty"`
+	// Synthesized is set when the manifest had no name and Name was derived
+	// from the package directory. Such packages are not importable by name.
+	Synthesized bool `json:"synthesized,omitempty"`
 }
 
 // LanguageDetector detects workspace structure for a specific language ecosystem.
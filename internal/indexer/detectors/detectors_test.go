@@ -1,9 +1,11 @@
 package detectors
 
 import (
+	"maps"
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"sort"
 	"strings"
 	"testing"
@@ -200,6 +202,166 @@ func TestDetectWorkspace_TSConfigExtends(t *testing.T) {
 	}
 }
 
+func TestDetectWorkspace_JSConfigPaths(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tmpDir, "lib"), 0o755)
+	os.WriteFile(filepath.Join(tmpDir, "package.json"), []byte(`{"name": "js-app"}`), 0o644)
+	os.WriteFile(filepath.Join(tmpDir, "jsconfig.base.json"), []byte(`{
+  // shared aliases
+  "compilerOptions": { "paths": { "@lib/*": ["lib/*"] } }
+}`), 0o644)
+	os.WriteFile(filepath.Join(tmpDir, "jsconfig.json"), []byte(`{
+  "extends": "./jsconfig.base.json",
+  "compilerOptions": { "baseUrl": ".", "paths": { "~/*": ["src/*"] } }
+}`), 0o644)
+
+	info, err := DetectWorkspace(tmpDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := info.TSConfigPaths["~/*"]; got != "src/*" {
+		t.Errorf("expected ~/* → src/*, got %q", got)
+	}
+	if got := info.TSConfigPaths["@lib/*"]; got != "lib/*" {
+		t.Errorf("expected extended @lib/* → lib/*, got %q", got)
+	}
+}
+
+func TestDetectWorkspace_TSBaseURLs(t *testing.T) {
+	tmpDir := t.TempDir()
+	write := func(rel, content string) {
+		t.Helper()
+		abs := filepath.Join(tmpDir, rel)
+		os.MkdirAll(filepath.Dir(abs), 0o755)
+		if err := os.WriteFile(abs, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("package.json", `{"name": "root", "workspaces": ["packages/*"]}`)
+	write("tsconfig.base.json", `{"compilerOptions": {"baseUrl": "."}}`)
+	write("tsconfig.json", `{"extends": "./tsconfig.base.json"}`)
+	write("packages/web/package.json", `{"name": "web"}`)
+	write("packages/web/tsconfig.json", `{"compilerOptions": {"baseUrl": "src"}}`)
+	// Only an explicit baseUrl counts, not the "." paths resolve against
+	write("packages/api/package.json", `{"name": "api"}`)
+	write("packages/api/tsconfig.json", `{"compilerOptions": {"paths": {"~/*": ["src/*"]}}}`)
+
+	info, err := DetectWorkspace(tmpDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]string{
+		".":                              ".",
+		filepath.Join("packages", "web"): filepath.Join("packages", "web", "src"),
+	}
+	if !maps.Equal(info.TSBaseURLs, want) {
+		t.Errorf("expected baseUrls %v, got %v", want, info.TSBaseURLs)
+	}
+}
+
+func TestDetectWorkspace_TSConfigExtendsPackage(t *testing.T) {
+	tmpDir := t.TempDir()
+	write := func(rel, content string) {
+		t.Helper()
+		abs := filepath.Join(tmpDir, rel)
+		os.MkdirAll(filepath.Dir(abs), 0o755)
+		if err := os.WriteFile(abs, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("package.json", `{"name": "root", "workspaces": ["packages/*"]}`)
+	// A shared config referenced by file, pointing back at the extending project
+	write("node_modules/@company/tsconfig/base.json", `{
+  "compilerOptions": { "paths": { "@shared/*": ["${configDir}/src/shared/*"] } }
+}`)
+	// A shared config referenced by package name, resolved through main
+	write("node_modules/@tsconfig/node20/package.json", `{"name": "@tsconfig/node20", "main": "tsconfig.json"}`)
+	write("node_modules/@tsconfig/node20/tsconfig.json", `{
+  "compilerOptions": { "baseUrl": "${configDir}", "paths": { "~/*": ["src/*"] } }
+}`)
+	write("tsconfig.json", `{"extends": "@company/tsconfig/base.json"}`)
+	write("packages/web/package.json", `{"name": "web"}`)
+	write("packages/web/tsconfig.json", `{"extends": "@tsconfig/node20"}`)
+
+	info, err := DetectWorkspace(tmpDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := info.TSConfigPaths["@shared/*"]; got != "src/shared/*" {
+		t.Errorf("expected @shared/* → src/shared/*, got %q", got)
+	}
+	// Found in the root node_modules, two levels above the package
+	if got := info.TSConfigPaths["~/*"]; got != "packages/web/src/*" {
+		t.Errorf("expected ~/* → packages/web/src/*, got %q", got)
+	}
+}
+
+func TestResolveExtendsPath(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "tsconfig.base.json"), []byte(`{}`), 0o644)
+	tsconfig := filepath.Join(tmpDir, "tsconfig.json")
+
+	tests := map[string]string{
+		"./tsconfig.base.json": filepath.Join(tmpDir, "tsconfig.base.json"),
+		"./tsconfig.base":      filepath.Join(tmpDir, "tsconfig.base.json"),
+		// Not an installed package, so it's taken as a sibling file
+		"tsconfig.base.json": filepath.Join(tmpDir, "tsconfig.base.json"),
+	}
+	for extends, want := range tests {
+		if got := resolveExtendsPath(tsconfig, extends); got != want {
+			t.Errorf("resolveExtendsPath(%q) = %q, want %q", extends, got, want)
+		}
+	}
+}
+
+func TestTSConfigExtends(t *testing.T) {
+	tmpDir := t.TempDir()
+	write := func(rel, content string) {
+		t.Helper()
+		abs := filepath.Join(tmpDir, rel)
+		os.MkdirAll(filepath.Dir(abs), 0o755)
+		if err := os.WriteFile(abs, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("node_modules/@tsconfig/node20/tsconfig.json", `{}`)
+	write("tsconfig.base.json", `{
+  // shared settings
+  "extends": "@tsconfig/node20/tsconfig.json"
+}`)
+	write("packages/web/tsconfig.json", `{"extends": "../../tsconfig.base.json"}`)
+
+	got := TSConfigExtends(filepath.Join(tmpDir, "packages/web"))
+	want := []string{
+		filepath.Join(tmpDir, "tsconfig.base.json"),
+		filepath.Join(tmpDir, "node_modules/@tsconfig/node20/tsconfig.json"),
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("TSConfigExtends = %v, want %v", got, want)
+	}
+	if got := TSConfigExtends(tmpDir); got != nil {
+		t.Errorf("expected no chain without a tsconfig, got %v", got)
+	}
+}
+
+func TestDetectWorkspace_TSConfigPreferredOverJSConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "package.json"), []byte(`{"name": "both"}`), 0o644)
+	os.WriteFile(filepath.Join(tmpDir, "tsconfig.json"), []byte(`{"compilerOptions": {"paths": {"@ts/*": ["ts/*"]}}}`), 0o644)
+	os.WriteFile(filepath.Join(tmpDir, "jsconfig.json"), []byte(`{"compilerOptions": {"paths": {"@js/*": ["js/*"]}}}`), 0o644)
+
+	info, err := DetectWorkspace(tmpDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := info.TSConfigPaths["@ts/*"]; !ok {
+		t.Error("expected tsconfig paths")
+	}
+	if _, ok := info.TSConfigPaths["@js/*"]; ok {
+		t.Error("jsconfig should be ignored when tsconfig exists")
+	}
+}
+
 func TestDetectWorkspace_AliasMapEntryPoints(t *testing.T) {
 	dir := filepath.Join(fixturesDir(), "monorepo-pnpm")
 	info, err := DetectWorkspace(dir)
@@ -290,6 +452,76 @@ func TestParsePnpmWorkspace(t *testing.T) {
 	}
 }
 
+func TestParsePnpmWorkspace_Variations(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			name:    "flow sequence",
+			content: "packages: ['packages/*', \"apps/*\", tools]\n",
+			want:    []string{"packages/*", "apps/*", "tools"},
+		},
+		{
+			name:    "multi-line flow sequence",
+			content: "packages: [\n  'packages/*', # libraries\n  'apps/*',\n]\n",
+			want:    []string{"packages/*", "apps/*"},
+		},
+		{
+			name: "inline comments and quoted hashes",
+			content: `packages:
+  - packages/*  # shared libraries
+  - 'apps/#legacy' # kept for now
+  - "!**/test/**"
+`,
+			want: []string{"packages/*", "apps/#legacy", "!**/test/**"},
+		},
+		{
+			name: "catalogs around packages",
+			content: `catalog:
+  react: ^18.2.0
+  "@types/node": ^20.0.0
+
+packages:
+- packages/*
+- apps/*
+
+catalogs:
+  react17:
+    react: ^17.0.2
+    - not-a-package
+`,
+			want: []string{"packages/*", "apps/*"},
+		},
+		{
+			name:    "multiple documents",
+			content: "# workspace\n---\npackages:\n  - packages/*\n---\nonlyBuiltDependencies:\n  - esbuild\n",
+			want:    []string{"packages/*"},
+		},
+		{
+			name:    "windows line endings",
+			content: "packages:\r\n  - 'packages/*'\r\n",
+			want:    []string{"packages/*"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "pnpm-workspace.yaml")
+			os.WriteFile(path, []byte(tt.content), 0o644)
+
+			globs, err := parsePnpmWorkspace(path)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if strings.Join(globs, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("expected %v, got %v", tt.want, globs)
+			}
+		})
+	}
+}
+
 func TestParsePackageJSONWorkspaces(t *testing.T) {
 	dir := filepath.Join(fixturesDir(), "monorepo-yarn")
 	globs, err := parsePackageJSONWorkspaces(filepath.Join(dir, "package.json"))
@@ -395,6 +627,68 @@ func TestNegationPatterns(t *testing.T) {
 	}
 }
 
+func TestDetectWorkspace_NamelessPackages(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	os.MkdirAll(filepath.Join(tmpDir, "apps", "web", "src"), 0o755)
+	os.MkdirAll(filepath.Join(tmpDir, "packages", "ui", "src"), 0o755)
+
+	os.WriteFile(filepath.Join(tmpDir, "pnpm-workspace.yaml"), []byte("packages:\n  - 'apps/*'\n  - 'packages/*'\n"), 0o644)
+	os.WriteFile(filepath.Join(tmpDir, "package.json"), []byte(`{"private": true}`), 0o644)
+
+	os.WriteFile(filepath.Join(tmpDir, "apps", "web", "package.json"), []byte(`{"private": true}`), 0o644)
+	os.WriteFile(filepath.Join(tmpDir, "apps", "web", "src", "index.ts"), []byte("export {}\n"), 0o644)
+	os.WriteFile(filepath.Join(tmpDir, "packages", "ui", "package.json"), []byte(`{"name": "@test/ui"}`), 0o644)
+	os.WriteFile(filepath.Join(tmpDir, "packages", "ui", "src", "index.ts"), []byte("export {}\n"), 0o644)
+
+	info, err := DetectWorkspace(tmpDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	byName := make(map[string]PackageInfo)
+	for _, pkg := range info.Packages {
+		byName[pkg.Name] = pkg
+	}
+
+	web, ok := byName["apps/web"]
+	if !ok {
+		t.Fatalf("expected nameless app as 'apps/web', got %v", packageNames(info.Packages))
+	}
+	if !web.Synthesized {
+		t.Error("expected apps/web to be marked synthesized")
+	}
+	if web.EntryPoint != "src/index.ts" {
+		t.Errorf("expected entry point src/index.ts, got %q", web.EntryPoint)
+	}
+	if _, ok := info.AliasMap["apps/web"]; ok {
+		t.Error("synthesized package should not be an import alias")
+	}
+
+	if byName["@test/ui"].Synthesized {
+		t.Error("named package should not be synthesized")
+	}
+	if _, ok := info.AliasMap["@test/ui"]; !ok {
+		t.Error("alias map missing @test/ui")
+	}
+}
+
+func TestDetectWorkspace_NamelessStandalone(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "package.json"), []byte(`{"private": true}`), 0o644)
+
+	info, err := DetectWorkspace(tmpDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(info.Packages) != 1 {
+		t.Fatalf("expected 1 package, got %d", len(info.Packages))
+	}
+	if got := info.Packages[0].Name; got != filepath.Base(tmpDir) {
+		t.Errorf("expected name %q, got %q", filepath.Base(tmpDir), got)
+	}
+}
+
 func TestYarnWorkspacesObjectForm(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -657,3 +951,67 @@ func packageNames(packages []PackageInfo) []string {
 	}
 	return names
 }
+
+func TestDetectWorkspace_PackageExports(t *testing.T) {
+	tmpDir := t.TempDir()
+	pkgDir := filepath.Join(tmpDir, "packages", "auth")
+	os.MkdirAll(filepath.Join(pkgDir, "src", "utils"), 0o755)
+
+	os.WriteFile(filepath.Join(tmpDir, "pnpm-workspace.yaml"), []byte("packages:\n  - 'packages/*'\n"), 0o644)
+	os.WriteFile(filepath.Join(tmpDir, "package.json"), []byte(`{"private": true}`), 0o644)
+	os.WriteFile(filepath.Join(pkgDir, "package.json"), []byte(`{
+  "name": "@test/auth",
+  "exports": {
+    ".": "./dist/index.js",
+    "./server": {"types": "./dist/server.d.ts", "import": "./dist/server.mjs"},
+    "./utils/*": "./dist/utils/*.js",
+    "./internal/*": null
+  }
+}`), 0o644)
+	os.WriteFile(filepath.Join(pkgDir, "src", "index.ts"), []byte("export {}\n"), 0o644)
+	os.WriteFile(filepath.Join(pkgDir, "src", "server.ts"), []byte("export {}\n"), 0o644)
+	os.WriteFile(filepath.Join(pkgDir, "src", "utils", "hash.ts"), []byte("export {}\n"), 0o644)
+
+	info, err := DetectWorkspace(tmpDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(info.Packages) != 1 {
+		t.Fatalf("expected 1 package, got %d", len(info.Packages))
+	}
+
+	want := map[string]string{
+		".":         "src/index.ts",
+		"./server":  "src/server.ts",
+		"./utils/*": "src/utils/*.js",
+	}
+	got := info.Packages[0].EntryPoints
+	if len(got) != len(want) {
+		t.Fatalf("expected entry points %v, got %v", want, got)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("entry point %q = %q, want %q", k, got[k], v)
+		}
+	}
+
+	if info.AliasMap["@test/auth"] != filepath.Join("packages", "auth", "src", "index.ts") {
+		t.Errorf("unexpected bare alias %q", info.AliasMap["@test/auth"])
+	}
+	if info.AliasMap["@test/auth/server"] != filepath.Join("packages", "auth", "src", "server.ts") {
+		t.Errorf("unexpected server alias %q", info.AliasMap["@test/auth/server"])
+	}
+	if _, ok := info.AliasMap["@test/auth/utils/*"]; !ok {
+		t.Error("alias map missing @test/auth/utils/* pattern")
+	}
+}
+
+func TestReadPackageExports_Shorthand(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "package.json"), []byte(`{"exports": {"require": "./cjs/index.js", "import": "./esm/index.js"}}`), 0o644)
+
+	exports := readPackageExports(tmpDir)
+	if len(exports) != 1 || exports["."] != "./esm/index.js" {
+		t.Errorf("expected conditions object to map \".\" to the import target, got %v", exports)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"maps"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 )
 
@@ -49,35 +50,46 @@ func (d *NodeDetector) Detect(sourcePath string) (*WorkspaceInfo, error) {
 		info.Packages = packages
 	}
 
-	// Build alias map from discovered packages
+	// Build alias map from discovered packages: the bare name plus one alias
+	// per exported subpath (@co/auth/server → packages/auth/src/server.ts)
 	for i := range info.Packages {
 		pkg := &info.Packages[i]
-		if pkg.Name == "" {
+		pkgDir := filepath.Join(sourcePath, pkg.Path)
+		pkg.EntryPoint = findEntryPoint(pkgDir)
+		pkg.EntryPoints = findEntryPoints(pkgDir, pkg.EntryPoint)
+		if pkg.Synthesized {
 			continue
 		}
-		entryPoint := findEntryPoint(filepath.Join(sourcePath, pkg.Path))
-		pkg.EntryPoint = entryPoint
-		if entryPoint != "" {
-			info.AliasMap[pkg.Name] = filepath.Join(pkg.Path, entryPoint)
+		for subpath, file := range pkg.EntryPoints {
+			info.AliasMap[pkg.Name+strings.TrimPrefix(subpath, ".")] = filepath.Join(pkg.Path, file)
 		}
 	}
 
 	// Read tsconfig paths from workspace root
-	tsconfigPaths, err := readTSConfigPaths(sourcePath, sourcePath)
+	tsconfigPaths, baseURL, err := readTSConfigPaths(sourcePath, sourcePath)
 	if err == nil {
 		maps.Copy(info.TSConfigPaths, tsconfigPaths)
+		if baseURL != "" {
+			info.TSBaseURLs = map[string]string{".": baseURL}
+		}
 	}
 
 	// Also read tsconfig paths from each package
 	for _, pkg := range info.Packages {
 		pkgPath := filepath.Join(sourcePath, pkg.Path)
-		paths, err := readTSConfigPaths(pkgPath, sourcePath)
+		paths, baseURL, err := readTSConfigPaths(pkgPath, sourcePath)
 		if err == nil {
 			for k, v := range paths {
 				if _, exists := info.TSConfigPaths[k]; !exists {
 					info.TSConfigPaths[k] = v
 				}
 			}
+			if baseURL != "" {
+				if info.TSBaseURLs == nil {
+					info.TSBaseURLs = make(map[string]string)
+				}
+				info.TSBaseURLs[filepath.Clean(pkg.Path)] = baseURL
+			}
 		}
 	}
 
@@ -127,7 +139,9 @@ func detectWorkspaceGlobs(sourcePath string) ([]string, error) {
 }
 
 // parsePnpmWorkspace reads pnpm-workspace.yaml and extracts package globs.
-// Uses simple line parsing to avoid a YAML dependency.
+// To avoid a YAML dependency it handles just the subset these files use:
+// block and flow sequences, quoted scalars, comments, and multiple documents.
+// Other top-level keys, such as catalog and catalogs, are skipped.
 func parsePnpmWorkspace(path string) ([]string, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -136,26 +150,46 @@ func parsePnpmWorkspace(path string) ([]string, error) {
 
 	var globs []string
 	inPackages := false
-	for line := range strings.SplitSeq(string(data), "\n") {
+	flow := "" // a flow sequence spanning several lines, up to the current one
+	for rawLine := range strings.SplitSeq(string(data), "\n") {
+		line := stripYAMLComment(strings.TrimRight(rawLine, "\r"))
 		trimmed := strings.TrimSpace(line)
-		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+
+		if flow != "" {
+			flow += " " + trimmed
+			if items, closed := parseYAMLFlowSequence(flow); closed {
+				globs = append(globs, items...)
+				flow = ""
+			}
+			continue
+		}
+		if trimmed == "" {
 			continue
 		}
 
-		if trimmed == "packages:" {
-			inPackages = true
+		// Document markers reset to the top level
+		if trimmed == "---" || trimmed == "..." || strings.HasPrefix(trimmed, "--- ") {
+			inPackages = false
 			continue
 		}
 
-		// A new top-level key ends the packages block
-		if inPackages && !strings.HasPrefix(trimmed, "-") && !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") {
-			break
+		// A top-level key starts or ends the packages block. Sequence items
+		// may sit at column 0 under their key, so they don't count.
+		if !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") && !isYAMLSequenceItem(trimmed) {
+			key, value, ok := splitYAMLKey(trimmed)
+			inPackages = ok && key == "packages" && value == ""
+			if ok && key == "packages" && strings.HasPrefix(value, "[") {
+				if items, closed := parseYAMLFlowSequence(value); closed {
+					globs = append(globs, items...)
+				} else {
+					flow = value
+				}
+			}
+			continue
 		}
 
-		if rest, ok := strings.CutPrefix(trimmed, "-"); inPackages && ok {
-			glob := strings.TrimSpace(rest)
-			glob = strings.Trim(glob, "'\"")
-			if glob != "" {
+		if inPackages && isYAMLSequenceItem(trimmed) {
+			if glob := yamlScalar(strings.TrimSpace(trimmed[1:])); glob != "" {
 				globs = append(globs, glob)
 			}
 		}
@@ -164,6 +198,100 @@ func parsePnpmWorkspace(path string) ([]string, error) {
 	return globs, nil
 }
 
+// isYAMLSequenceItem reports whether a trimmed line is a block sequence
+// entry ("- item"), as opposed to a plain scalar that starts with a dash.
+func isYAMLSequenceItem(trimmed string) bool {
+	return trimmed == "-" || strings.HasPrefix(trimmed, "- ") || strings.HasPrefix(trimmed, "-\t")
+}
+
+// stripYAMLComment removes a trailing # comment. A # only starts a comment
+// at the beginning of the line or after whitespace, and never inside quotes.
+func stripYAMLComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++ // skip the escaped character
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// splitYAMLKey splits a "key: value" mapping entry. The colon must be
+// followed by whitespace or end the line, and the key may be quoted.
+func splitYAMLKey(s string) (key, value string, ok bool) {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == ':' && (i+1 == len(s) || s[i+1] == ' ' || s[i+1] == '\t'):
+			return yamlScalar(strings.TrimSpace(s[:i])), strings.TrimSpace(s[i+1:]), true
+		}
+	}
+	return "", "", false
+}
+
+// parseYAMLFlowSequence parses a flow sequence like ['a', "b", c]. closed is
+// false when the closing bracket hasn't been reached yet, so the caller can
+// append the next line and try again.
+func parseYAMLFlowSequence(s string) (items []string, closed bool) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "[")
+	var quote byte
+	start := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == ',' || c == ']':
+			if item := yamlScalar(strings.TrimSpace(s[start:i])); item != "" {
+				items = append(items, item)
+			}
+			if c == ']' {
+				return items, true
+			}
+			start = i + 1
+		}
+	}
+	return nil, false
+}
+
+// yamlScalar unquotes a single- or double-quoted scalar; plain scalars are
+// returned as is.
+func yamlScalar(s string) string {
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'")
+	}
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		if unquoted, err := strconv.Unquote(s); err == nil {
+			return unquoted
+		}
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
 // parsePackageJSONWorkspaces reads the workspaces field from package.json.
 // Handles both array form and object form (yarn).
 func parsePackageJSONWorkspaces(path string) ([]string, error) {
@@ -321,6 +449,8 @@ func isNegated(relPath string, negations []string) bool {
 }
 
 // readPackageInfo reads package.json from a directory and extracts name/version.
+// Private apps often omit "name"; those get the package's relative directory
+// (or the directory name for the root) so they still own their files.
 func readPackageInfo(pkgDir, rootPath string) (PackageInfo, error) {
 	pkgJSONPath := filepath.Join(pkgDir, "package.json")
 	data, err := os.ReadFile(pkgJSONPath)
@@ -341,11 +471,19 @@ func readPackageInfo(pkgDir, rootPath string) (PackageInfo, error) {
 		relPath = pkgDir
 	}
 
-	return PackageInfo{
+	info := PackageInfo{
 		Name:    pkg.Name,
 		Path:    relPath,
 		Version: pkg.Version,
-	}, nil
+	}
+	if info.Name == "" {
+		info.Name = filepath.ToSlash(relPath)
+		if relPath == "." {
+			info.Name = filepath.Base(pkgDir)
+		}
+		info.Synthesized = true
+	}
+	return info, nil
 }
 
 // findEntryPoint looks for the source entry point of a JS/TS package.
@@ -393,27 +531,197 @@ func findEntryPoint(pkgDir string) string {
 	return pkg.Main
 }
 
+// findEntryPoints collects a package's subpath entry points from the
+// package.json "exports" field. The heuristic entryPoint, when found, wins for
+// "." since it points at source rather than build output.
+func findEntryPoints(pkgDir, entryPoint string) map[string]string {
+	entries := make(map[string]string)
+	for subpath, target := range readPackageExports(pkgDir) {
+		entries[subpath] = exportSourceFile(pkgDir, target)
+	}
+	if entryPoint != "" {
+		entries["."] = entryPoint
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	return entries
+}
+
+// exportConditions is the order conditional exports are tried in. "types"
+// comes last because it points at declaration files, not code.
+var exportConditions = []string{"source", "import", "module", "default", "require", "node", "types"}
+
+// readPackageExports flattens package.json "exports" into subpath → target.
+// Handles the string shorthand, a bare conditions object (applies to "."),
+// subpath maps, and nested conditions. Null targets (blocked subpaths) are
+// dropped.
+func readPackageExports(pkgDir string) map[string]string {
+	data, err := os.ReadFile(filepath.Join(pkgDir, "package.json"))
+	if err != nil {
+		return nil
+	}
+	var pkg struct {
+		Exports json.RawMessage `json:"exports"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil || len(pkg.Exports) == 0 {
+		return nil
+	}
+
+	var exports any
+	if err := json.Unmarshal(pkg.Exports, &exports); err != nil {
+		return nil
+	}
+
+	out := make(map[string]string)
+	switch v := exports.(type) {
+	case string:
+		out["."] = v
+	case map[string]any:
+		isSubpathMap := false
+		for k := range v {
+			if strings.HasPrefix(k, ".") {
+				isSubpathMap = true
+				break
+			}
+		}
+		if !isSubpathMap {
+			if target := exportTarget(v); target != "" {
+				out["."] = target
+			}
+			break
+		}
+		for subpath, t := range v {
+			if target := exportTarget(t); target != "" && strings.HasPrefix(subpath, ".") {
+				out[subpath] = target
+			}
+		}
+	}
+	return out
+}
+
+// exportTarget picks the file an exports value points to, walking nested
+// condition objects in exportConditions order.
+func exportTarget(v any) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case map[string]any:
+		for _, cond := range exportConditions {
+			if next, ok := t[cond]; ok {
+				if target := exportTarget(next); target != "" {
+					return target
+				}
+			}
+		}
+	case []any:
+		for _, alt := range t {
+			if target := exportTarget(alt); target != "" {
+				return target
+			}
+		}
+	}
+	return ""
+}
+
+// exportSourceFile maps an exports target to a file relative to pkgDir,
+// preferring the source file when the target is build output that doesn't
+// exist yet: ./dist/server.js → src/server.ts. Wildcard targets keep their
+// "*" and only get the dist → src rewrite.
+func exportSourceFile(pkgDir, target string) string {
+	rel := filepath.ToSlash(filepath.Clean(target))
+	if strings.Contains(rel, "*") {
+		return buildToSource(pkgDir, rel)
+	}
+	if fileExists(filepath.Join(pkgDir, rel)) {
+		return rel
+	}
+	src := buildToSource(pkgDir, rel)
+	base := strings.TrimSuffix(src, filepath.Ext(src))
+	for _, ext := range []string{".ts", ".tsx", ".js", ".jsx"} {
+		if fileExists(filepath.Join(pkgDir, base+ext)) {
+			return base + ext
+		}
+	}
+	return rel
+}
+
+// buildToSource swaps a leading dist/, lib/, or build/ for src/ when the
+// package has a src directory.
+func buildToSource(pkgDir, rel string) string {
+	for _, dir := range []string{"dist/", "lib/", "build/"} {
+		if rest, ok := strings.CutPrefix(rel, dir); ok && dirExists(filepath.Join(pkgDir, "src")) {
+			return "src/" + rest
+		}
+	}
+	return rel
+}
+
 // readTSConfigPaths reads tsconfig.json and extracts compilerOptions.paths,
-// following extends chains.
-func readTSConfigPaths(dir, rootPath string) (map[string]string, error) {
-	tsconfigPath := filepath.Join(dir, "tsconfig.json")
-	if !fileExists(tsconfigPath) {
-		return nil, fmt.Errorf("tsconfig.json not found in %s", dir)
+// following extends chains. Plain-JS projects use jsconfig.json with the same
+// structure; it is read only when the directory has no tsconfig.json, matching
+// how the TypeScript tooling picks between the two. It also returns the
+// directory compilerOptions.baseUrl names, relative to rootPath, or "" when
+// no config in the chain sets one.
+func readTSConfigPaths(dir, rootPath string) (map[string]string, string, error) {
+	for _, name := range []string{"tsconfig.json", "jsconfig.json"} {
+		configPath := filepath.Join(dir, name)
+		if fileExists(configPath) {
+			return parseTSConfig(configPath, rootPath, dir, 0)
+		}
 	}
+	return nil, "", fmt.Errorf("tsconfig.json or jsconfig.json not found in %s", dir)
+}
 
-	return parseTSConfig(tsconfigPath, rootPath, 0)
+// TSConfigExtends returns the configs the tsconfig.json (or jsconfig.json)
+// of dir extends, nearest first, resolved the way readTSConfigPaths follows
+// them: sibling files like tsconfig.base.json and shared configs in
+// node_modules alike. The chain stops at a config that is missing or doesn't
+// parse. Callers watching for config changes use it, since these files have
+// names no workspace config check looks for.
+func TSConfigExtends(dir string) []string {
+	var configPath string
+	for _, name := range []string{"tsconfig.json", "jsconfig.json"} {
+		if p := filepath.Join(dir, name); fileExists(p) {
+			configPath = p
+			break
+		}
+	}
+	var chain []string
+	for configPath != "" && len(chain) <= 10 {
+		data, err := os.ReadFile(configPath)
+		if err != nil {
+			break
+		}
+		var tsconfig struct {
+			Extends string `json:"extends"`
+		}
+		if json.Unmarshal(stripJSONComments(data), &tsconfig) != nil || tsconfig.Extends == "" {
+			break
+		}
+		configPath = resolveExtendsPath(configPath, tsconfig.Extends)
+		if !fileExists(configPath) {
+			break
+		}
+		chain = append(chain, configPath)
+	}
+	return chain
 }
 
-// parseTSConfig reads a tsconfig.json, follows extends, and merges paths.
+// parseTSConfig reads a tsconfig.json (or jsconfig.json), follows extends, and merges paths.
+// configDir is the directory of the config the chain started from, which
+// ${configDir} in baseUrl and paths refers to — the way shared configs in
+// node_modules point back at the project extending them.
+// A baseUrl set in the config overrides one inherited through extends.
 // maxDepth prevents infinite loops from circular extends.
-func parseTSConfig(tsconfigPath, rootPath string, depth int) (map[string]string, error) {
+func parseTSConfig(tsconfigPath, rootPath, configDir string, depth int) (map[string]string, string, error) {
 	if depth > 10 {
-		return nil, fmt.Errorf("tsconfig extends chain too deep")
+		return nil, "", fmt.Errorf("tsconfig extends chain too deep")
 	}
 
 	data, err := os.ReadFile(tsconfigPath)
 	if err != nil {
-		return nil, fmt.Errorf("reading tsconfig: %w", err)
+		return nil, "", fmt.Errorf("reading tsconfig: %w", err)
 	}
 
 	// Strip single-line comments (tsconfig allows them)
@@ -427,35 +735,49 @@ func parseTSConfig(tsconfigPath, rootPath string, depth int) (map[string]string,
 		} `json:"compilerOptions"`
 	}
 	if err := json.Unmarshal(cleaned, &tsconfig); err != nil {
-		return nil, fmt.Errorf("parsing tsconfig: %w", err)
+		return nil, "", fmt.Errorf("parsing tsconfig: %w", err)
 	}
 
 	paths := make(map[string]string)
 
 	// Follow extends chain first (parent paths are overridden by child)
+	var baseURLDir string
 	if tsconfig.Extends != "" {
 		parentPath := resolveExtendsPath(tsconfigPath, tsconfig.Extends)
-		parentPaths, err := parseTSConfig(parentPath, rootPath, depth+1)
+		parentPaths, parentBaseURL, err := parseTSConfig(parentPath, rootPath, configDir, depth+1)
 		if err == nil {
 			maps.Copy(paths, parentPaths)
+			baseURLDir = parentBaseURL
 		}
 	}
 
 	// Apply this tsconfig's paths (override parent)
 	tsconfigDir := filepath.Dir(tsconfigPath)
-	baseURL := tsconfig.CompilerOptions.BaseURL
+	explicitBaseURL := tsconfig.CompilerOptions.BaseURL != ""
+	baseURL := strings.ReplaceAll(tsconfig.CompilerOptions.BaseURL, "${configDir}", configDir)
 	if baseURL == "" {
 		baseURL = "."
 	}
+	if !filepath.IsAbs(baseURL) {
+		baseURL = filepath.Join(tsconfigDir, baseURL)
+	}
+	if explicitBaseURL {
+		if rel, err := filepath.Rel(rootPath, baseURL); err == nil && rel != ".." && !strings.HasPrefix(rel, "../") {
+			baseURLDir = rel
+		}
+	}
 
 	for alias, targets := range tsconfig.CompilerOptions.Paths {
 		if len(targets) == 0 {
 			continue
 		}
 		// Use the first target path
-		target := targets[0]
+		target := strings.ReplaceAll(targets[0], "${configDir}", configDir)
 		// Resolve relative to baseUrl and tsconfig directory
-		absTarget := filepath.Join(tsconfigDir, baseURL, target)
+		absTarget := target
+		if !filepath.IsAbs(target) {
+			absTarget = filepath.Join(baseURL, target)
+		}
 		relTarget, err := filepath.Rel(rootPath, absTarget)
 		if err != nil {
 			relTarget = target
@@ -463,20 +785,84 @@ func parseTSConfig(tsconfigPath, rootPath string, depth int) (map[string]string,
 		paths[alias] = relTarget
 	}
 
-	return paths, nil
+	return paths, baseURLDir, nil
 }
 
-// resolveExtendsPath resolves the extends field relative to the tsconfig location.
+// resolveExtendsPath resolves the extends field of the tsconfig at
+// tsconfigPath. Relative and absolute paths resolve against the tsconfig's
+// directory; bare specifiers like "@tsconfig/node20" or
+// "@company/tsconfig/base.json" name a shared config published as a package,
+// looked up in node_modules the way Node does. A bare specifier that isn't an
+// installed package falls back to the relative lookup, which is what
+// "extends": "tsconfig.base.json" means in practice.
 func resolveExtendsPath(tsconfigPath, extends string) string {
 	dir := filepath.Dir(tsconfigPath)
-	resolved := filepath.Join(dir, extends)
+	if !strings.HasPrefix(extends, ".") && !filepath.IsAbs(extends) {
+		if resolved := resolvePackageExtends(dir, extends); resolved != "" {
+			return resolved
+		}
+	}
 
+	resolved := filepath.Join(dir, extends)
 	if filepath.Ext(resolved) != ".json" {
 		resolved += ".json"
 	}
 	return resolved
 }
 
+// resolvePackageExtends looks for a package-style extends target in the
+// node_modules of dir and each of its parents, so a workspace package finds
+// configs hoisted to the repo root. Returns "" when it isn't installed.
+func resolvePackageExtends(dir, spec string) string {
+	for {
+		if resolved := packageConfigFile(filepath.Join(dir, "node_modules", filepath.FromSlash(spec))); resolved != "" {
+			return resolved
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// packageConfigFile finds the config a specifier inside node_modules points
+// at: the file itself, the file with .json appended, or, for a package
+// directory, the file named by its package.json "tsconfig" or "main" field,
+// falling back to tsconfig.json.
+func packageConfigFile(base string) string {
+	if fileExists(base) {
+		return base
+	}
+	if filepath.Ext(base) != ".json" && fileExists(base+".json") {
+		return base + ".json"
+	}
+	if !dirExists(base) {
+		return ""
+	}
+
+	if data, err := os.ReadFile(filepath.Join(base, "package.json")); err == nil {
+		var pkg struct {
+			TSConfig string `json:"tsconfig"`
+			Main     string `json:"main"`
+		}
+		if json.Unmarshal(data, &pkg) == nil {
+			for _, field := range []string{pkg.TSConfig, pkg.Main} {
+				if field == "" {
+					continue
+				}
+				if resolved := filepath.Join(base, field); fileExists(resolved) {
+					return resolved
+				}
+			}
+		}
+	}
+	if resolved := filepath.Join(base, "tsconfig.json"); fileExists(resolved) {
+		return resolved
+	}
+	return ""
+}
+
 // stripJSONComments removes single-line (//) and multi-line (/* */) comments
 // from JSON with comments (as used by tsconfig.json).
 func stripJSONComments(data []byte) []byte {
@@ -0,0 +1,200 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/maximilianfalco/mycelium/internal/config"
+	"github.com/maximilianfalco/mycelium/internal/projects"
+)
+
+// EdgeRebuildResult summarizes a RebuildEdges run.
+type EdgeRebuildResult struct {
+	Kinds            []string      `json:"kinds,omitempty"`
+	SourcesProcessed int           `json:"sourcesProcessed"`
+	EdgesDeleted     int           `json:"edgesDeleted"`
+	EdgesUpserted    int           `json:"edgesUpserted"`
+	Duration         time.Duration `json:"duration"`
+}
+
+// RebuildEdges recomputes a project's edges from its source trees without
+// reindexing: every file is re-parsed and resolved as a full run would, but
+// nodes, embeddings, and unresolved refs are left as stored. With edgeKinds,
+// only edges of those kinds are deleted and rewritten and every other kind is
+// untouched, which is how a new or improved extractor (renders, references)
+// is rolled out to existing indexes. An empty edgeKinds rebuilds every kind.
+//
+// Edges are only written between nodes that are already stored, so a file
+// that changed since the last index gets its new edges on the next run.
+// Cross-source imports are kept: their refs were consumed when they resolved,
+// so they can't be recomputed from a single source.
+func RebuildEdges(ctx context.Context, pool *pgxpool.Pool, cfg *config.Config, projectID string, edgeKinds []string) (*EdgeRebuildResult, error) {
+	start := time.Now()
+	applyIndexConfig(cfg)
+
+	if _, loaded := activeJobs.LoadOrStore(projectID, true); loaded {
+		return nil, ErrIndexingInProgress
+	}
+	defer activeJobs.Delete(projectID)
+
+	sources, err := projects.ListSources(ctx, pool, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("listing sources: %w", err)
+	}
+
+	result := &EdgeRebuildResult{Kinds: edgeKinds}
+	for _, source := range sources {
+		if !source.IsCode {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		deleted, upserted, err := rebuildSourceEdges(ctx, pool, cfg, projectID, &source, edgeKinds)
+		if err != nil {
+			return nil, fmt.Errorf("source %s: %w", source.Alias, err)
+		}
+		result.SourcesProcessed++
+		result.EdgesDeleted += deleted
+		result.EdgesUpserted += upserted
+	}
+
+	result.Duration = time.Since(start)
+	slog.Info("edges rebuilt",
+		"project", projectID,
+		"kinds", edgeKinds,
+		"sources", result.SourcesProcessed,
+		"deleted", result.EdgesDeleted,
+		"upserted", result.EdgesUpserted,
+		"duration", result.Duration,
+	)
+	return result, nil
+}
+
+// rebuildSourceEdges re-parses and resolves one source, then swaps its
+// intra-source edges of edgeKinds (all kinds when empty) for the fresh ones
+// in a single transaction.
+func rebuildSourceEdges(ctx context.Context, pool *pgxpool.Pool, cfg *config.Config, projectID string, source *projects.ProjectSource, edgeKinds []string) (int, int, error) {
+	sourcePath, err := PrepareSource(ctx, cfg, source)
+	if err != nil {
+		return 0, 0, fmt.Errorf("preparing source: %w", err)
+	}
+	branch := indexBranch(ctx, cfg, sourcePath)
+	workspaceID := BranchWorkspaceID(projectID, source.ID, branch)
+	wsInfo, _, err := detectWorkspaceCached(workspaceID, sourcePath, &ChangeSet{IsFullIndex: true})
+	if err != nil {
+		return 0, 0, fmt.Errorf("workspace detection: %w", err)
+	}
+	crawlResult, err := crawlSource(sourcePath, source.IsCode, ParseSubmoduleMode(cfg.Submodules))
+	if err != nil {
+		return 0, 0, fmt.Errorf("crawling: %w", err)
+	}
+	allRelPaths := make([]string, 0, len(crawlResult.Files))
+	for _, f := range crawlResult.Files {
+		allRelPaths = append(allRelPaths, f.RelPath)
+	}
+
+	allNodes, allEdges, parseErrors, _ := parseFiles(ctx, crawlResult.Files, sourcePath, newParseCache(cfg.ParseCacheDir))
+	allNodes, allEdges, _ = applyNodeFilter(newNodeFilter(cfg), allNodes, allEdges)
+	if len(parseErrors) > 0 {
+		slog.Warn("parse errors", "count", len(parseErrors), "source", source.Alias)
+	}
+
+	resolveResult := ResolveImports(
+		allEdges,
+		wsInfo.AliasMap,
+		wsInfo.TSConfigPaths,
+		allNodes,
+		allRelPaths,
+		sourcePath,
+		WithPackages(wsInfo.Packages),
+		WithTSBaseURLs(wsInfo.TSBaseURLs),
+		WithPackageMarkerDirs(cfg.PackageMarkerDirs),
+	)
+	resolved, dependsOn := selectResolved(cfg, resolveResult)
+	pkgNodes, pkgEdges := goPackageNodes(crawlResult.Files, crawlResult.Files, wsInfo, allEdges)
+	allNodes = append(allNodes, pkgNodes...)
+	allEdges = append(allEdges, pkgEdges...)
+
+	input := &BuildInput{
+		ProjectID:  projectID,
+		SourceID:   source.ID,
+		SourcePath: sourcePath,
+		Branch:     branch,
+		Workspace:  wsInfo,
+		Nodes:      allNodes,
+		Edges:      allEdges,
+		Resolved:   resolved,
+		DependsOn:  dependsOn,
+		FilePaths:  allRelPaths,
+	}
+
+	stored, err := loadStoredNodeIDs(ctx, pool, workspaceID)
+	if err != nil {
+		return 0, 0, err
+	}
+	var rows []edgeRow
+	for _, r := range collectEdgeRows(input, nodeLookup(workspaceID, input)) {
+		if !stored[r.sourceID] || !stored[r.targetID] {
+			continue
+		}
+		if len(edgeKinds) > 0 && !slices.Contains(edgeKinds, r.kind) {
+			continue
+		}
+		rows = append(rows, r)
+	}
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return 0, 0, fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		DELETE FROM edges e
+		USING nodes s, nodes t
+		WHERE e.source_id = s.id AND e.target_id = t.id
+		  AND s.workspace_id = $1 AND t.workspace_id = $1
+		  AND (COALESCE(cardinality($2::text[]), 0) = 0 OR e.kind = ANY($2))`, workspaceID, edgeKinds)
+	if err != nil {
+		return 0, 0, fmt.Errorf("deleting edges: %w", err)
+	}
+	upserted, err := writeEdges(ctx, tx, rows)
+	if err != nil {
+		return 0, 0, err
+	}
+	if err := bumpGraphVersion(ctx, tx, projectID); err != nil {
+		return 0, 0, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, 0, fmt.Errorf("committing transaction: %w", err)
+	}
+	return int(tag.RowsAffected()), upserted, nil
+}
+
+// loadStoredNodeIDs returns the IDs of a workspace's stored nodes.
+func loadStoredNodeIDs(ctx context.Context, pool *pgxpool.Pool, workspaceID string) (map[string]bool, error) {
+	rows, err := pool.Query(ctx, `SELECT id FROM nodes WHERE workspace_id = $1`, workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("loading node IDs: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scanning node ID: %w", err)
+		}
+		ids[id] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating node IDs: %w", err)
+	}
+	return ids, nil
+}
@@ -7,33 +7,142 @@ import (
 	"log/slog"
 	"math"
 	"math/rand"
+	"sync"
 	"time"
 
 	openai "github.com/sashabaranov/go-openai"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/maximilianfalco/mycelium/internal/config"
 )
 
 const (
-	maxRetries     = 10
-	baseBackoff    = 1 * time.Second
-	maxBackoff     = 60 * time.Second
-	embeddingModel = openai.SmallEmbedding3
+	maxRetries  = 10
+	baseBackoff = 1 * time.Second
+	maxBackoff  = 60 * time.Second
+)
+
+var (
+	embeddingModelMu sync.RWMutex
+	embeddingModelV  = string(openai.SmallEmbedding3)
+)
+
+// SetEmbeddingModel selects the model EmbedTexts requests. An empty name
+// restores text-embedding-3-small. NewEmbeddingClient calls it with
+// cfg.EmbeddingModel.
+func SetEmbeddingModel(model string) {
+	if model == "" {
+		model = string(openai.SmallEmbedding3)
+	}
+	embeddingModelMu.Lock()
+	defer embeddingModelMu.Unlock()
+	embeddingModelV = model
+}
+
+func embeddingModel() string {
+	embeddingModelMu.RLock()
+	defer embeddingModelMu.RUnlock()
+	return embeddingModelV
+}
+
+// EmbeddingLimits bound how hard embedding calls hit the provider. The
+// request and token budgets are shared by every caller in the process, so
+// concurrent index jobs and query embeddings stay under one quota.
+type EmbeddingLimits struct {
+	// Concurrency is how many batches EmbedBatched has in flight; values
+	// below 1 mean one at a time.
+	Concurrency int
+	// RPM and TPM cap requests and input tokens per minute; 0 is unlimited.
+	RPM int
+	TPM int
+}
+
+var (
+	embeddingLimitsMu sync.RWMutex
+	embeddingLimitsV  = EmbeddingLimits{Concurrency: 1}
+	requestLimiter    *tokenBucket
+	tokenLimiter      *tokenBucket
 )
 
+// SetEmbeddingLimits replaces the embedding concurrency and rate limits.
+// NewEmbeddingClient calls it with cfg's EmbeddingConcurrency, EmbeddingRPM,
+// and EmbeddingTPM.
+func SetEmbeddingLimits(l EmbeddingLimits) {
+	if l.Concurrency < 1 {
+		l.Concurrency = 1
+	}
+	embeddingLimitsMu.Lock()
+	defer embeddingLimitsMu.Unlock()
+	embeddingLimitsV = l
+	requestLimiter = newTokenBucket(l.RPM)
+	tokenLimiter = newTokenBucket(l.TPM)
+}
+
+func embeddingLimits() (EmbeddingLimits, *tokenBucket, *tokenBucket) {
+	embeddingLimitsMu.RLock()
+	defer embeddingLimitsMu.RUnlock()
+	return embeddingLimitsV, requestLimiter, tokenLimiter
+}
+
+// estimateTokens counts text's tokens, falling back to ~3 characters per
+// token when the tokenizer isn't available.
+func estimateTokens(text string) int {
+	tc, err := CountTokens(text)
+	if err != nil {
+		return len(text) / 3
+	}
+	return tc
+}
+
 // EmbedTexts calls the OpenAI embeddings API for a batch of texts.
 // Handles rate limiting (429) and server errors (5xx) with exponential backoff.
+// Every attempt waits its turn under the limits set by SetEmbeddingLimits.
 func EmbedTexts(ctx context.Context, client *openai.Client, texts []string) ([][]float32, error) {
 	if len(texts) == 0 {
 		return nil, nil
 	}
+	tokens := -1
+	if _, _, tpm := embeddingLimits(); tpm != nil {
+		tokens = 0
+		for _, t := range texts {
+			tokens += estimateTokens(t)
+		}
+	}
+	return embedTexts(ctx, client, texts, tokens)
+}
+
+// embedTexts is EmbedTexts for callers that have already counted the batch's
+// tokens; tokens < 0 means uncounted, which only works without a TPM limit.
+func embedTexts(ctx context.Context, client *openai.Client, texts []string, tokens int) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+
+	req := openai.EmbeddingRequest{
+		Input: texts,
+		Model: openai.EmbeddingModel(embeddingModel()),
+	}
+	if config.EmbeddingModelSupportsDimensions(string(req.Model)) {
+		req.Dimensions = config.EmbeddingDimensions
+	}
+
+	start := time.Now()
+	defer func() { metrics().EmbeddingLatency(time.Since(start)) }()
 
 	var resp openai.EmbeddingResponse
 	var err error
 
+	_, rpm, tpm := embeddingLimits()
 	for attempt := range maxRetries {
-		resp, err = client.CreateEmbeddings(ctx, openai.EmbeddingRequest{
-			Input: texts,
-			Model: embeddingModel,
-		})
+		if err := rpm.wait(ctx, 1); err != nil {
+			return nil, err
+		}
+		if tokens > 0 {
+			if err := tpm.wait(ctx, tokens); err != nil {
+				return nil, err
+			}
+		}
+		resp, err = client.CreateEmbeddings(ctx, req)
 		if err == nil {
 			break
 		}
@@ -78,6 +187,8 @@ const maxTokensPerBatch = 250_000
 // EmbedBatched splits texts into token-aware batches and embeds them all.
 // Each batch stays under 250K tokens (OpenAI limit is 300K, this leaves headroom).
 // batchSize caps the max number of items per batch as a secondary limit.
+// Up to EmbeddingLimits.Concurrency batches are in flight at once, paced by
+// the RPM and TPM limits; vectors come back in the order of texts.
 // If onProgress is non-nil, it's called after each batch with the percentage complete (0–100).
 func EmbedBatched(ctx context.Context, client *openai.Client, texts []string, batchSize int, onProgress func(pct int)) ([][]float32, error) {
 	if batchSize <= 0 {
@@ -87,16 +198,12 @@ func EmbedBatched(ctx context.Context, client *openai.Client, texts []string, ba
 	// Pre-count tokens for each text to build token-aware batches
 	tokenCounts := make([]int, len(texts))
 	for i, t := range texts {
-		tc, err := CountTokens(t)
-		if err != nil {
-			tc = len(t) / 3 // rough fallback: ~3 chars per token
-		}
-		tokenCounts[i] = tc
+		tokenCounts[i] = estimateTokens(t)
 	}
 
 	// Build batches respecting both token and item limits
 	type batch struct {
-		start, end int
+		start, end, tokens int
 	}
 	var batches []batch
 	i := 0
@@ -110,37 +217,42 @@ func EmbedBatched(ctx context.Context, client *openai.Client, texts []string, ba
 			batchTokens += tokenCounts[j]
 			j++
 		}
-		batches = append(batches, batch{i, j})
+		batches = append(batches, batch{i, j, batchTokens})
 		i = j
 	}
 
 	allVectors := make([][]float32, len(texts))
 	totalBatches := len(batches)
+	limits, _, _ := embeddingLimits()
 
-	for batchNum, b := range batches {
-		chunk := texts[b.start:b.end]
-
-		slog.Info("embedding batch", "batch", batchNum+1, "total", totalBatches, "nodes", len(chunk))
+	var progressMu sync.Mutex
+	done := 0
 
-		vectors, err := EmbedTexts(ctx, client, chunk)
-		if err != nil {
-			return nil, fmt.Errorf("batch %d/%d: %w", batchNum+1, totalBatches, err)
-		}
-
-		copy(allVectors[b.start:b.end], vectors)
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(limits.Concurrency)
+	for batchNum, b := range batches {
+		g.Go(func() error {
+			chunk := texts[b.start:b.end]
+			slog.Info("embedding batch", "batch", batchNum+1, "total", totalBatches, "nodes", len(chunk))
 
-		if onProgress != nil {
-			onProgress((batchNum + 1) * 100 / totalBatches)
-		}
+			vectors, err := embedTexts(gctx, client, chunk, b.tokens)
+			if err != nil {
+				return fmt.Errorf("batch %d/%d: %w", batchNum+1, totalBatches, err)
+			}
+			// Batches cover disjoint ranges, so they fill allVectors unlocked
+			copy(allVectors[b.start:b.end], vectors)
 
-		// Pace batches to avoid exceeding TPM rate limits
-		if batchNum < totalBatches-1 {
-			select {
-			case <-ctx.Done():
-				return nil, ctx.Err()
-			case <-time.After(500 * time.Millisecond):
+			if onProgress != nil {
+				progressMu.Lock()
+				done++
+				onProgress(done * 100 / totalBatches)
+				progressMu.Unlock()
 			}
-		}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
 	}
 
 	return allVectors, nil
@@ -6,9 +6,15 @@ import (
 	"math"
 	"net/http"
 	"net/http/httptest"
+	"slices"
+	"strconv"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	openai "github.com/sashabaranov/go-openai"
+
+	"github.com/maximilianfalco/mycelium/internal/config"
 )
 
 func TestCosineSimilarity_Identical(t *testing.T) {
@@ -146,6 +152,66 @@ func TestEmbedTexts_MockAPI_Batch(t *testing.T) {
 	}
 }
 
+func TestEmbedBatched_ConcurrentKeepsOrder(t *testing.T) {
+	SetEmbeddingLimits(EmbeddingLimits{Concurrency: 3})
+	t.Cleanup(func() { SetEmbeddingLimits(EmbeddingLimits{}) })
+
+	var inFlight, maxInFlight atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			m := maxInFlight.Load()
+			if n <= m || maxInFlight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+
+		var req openai.EmbeddingRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		inputs := req.Input.([]any)
+		// Later batches answer first, so completion order differs from input order
+		first, _ := strconv.Atoi(inputs[0].(string))
+		time.Sleep(time.Duration(20-first) * time.Millisecond)
+
+		data := make([]openai.Embedding, len(inputs))
+		for i, in := range inputs {
+			v, _ := strconv.Atoi(in.(string))
+			data[i] = openai.Embedding{Object: "embedding", Embedding: []float32{float32(v)}, Index: i}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(openai.EmbeddingResponse{Data: data, Model: openai.SmallEmbedding3})
+	}))
+	defer server.Close()
+
+	cfg := openai.DefaultConfig("test-key")
+	cfg.BaseURL = server.URL + "/v1"
+	client := openai.NewClientWithConfig(cfg)
+
+	texts := make([]string, 20)
+	for i := range texts {
+		texts[i] = strconv.Itoa(i)
+	}
+	var progress []int
+	vectors, err := EmbedBatched(context.Background(), client, texts, 2, func(pct int) {
+		progress = append(progress, pct)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i, v := range vectors {
+		if len(v) != 1 || v[0] != float32(i) {
+			t.Errorf("vector %d: expected [%d], got %v", i, i, v)
+		}
+	}
+	if got := maxInFlight.Load(); got > 3 || got < 2 {
+		t.Errorf("expected 2-3 batches in flight, saw %d", got)
+	}
+	if len(progress) != 10 || progress[len(progress)-1] != 100 || !slices.IsSorted(progress) {
+		t.Errorf("expected 10 increasing progress reports ending at 100, got %v", progress)
+	}
+}
+
 func TestEmbedTexts_Empty(t *testing.T) {
 	vectors, err := EmbedTexts(context.Background(), nil, nil)
 	if err != nil {
@@ -214,3 +280,87 @@ func TestCalcBackoff(t *testing.T) {
 		}
 	}
 }
+
+func TestNewEmbeddingClient_BaseURLAndHeaders(t *testing.T) {
+	defer SetEmbeddingModel("")
+
+	var gotPath, gotHeader, gotAuth string
+	var gotReq openai.EmbeddingRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotHeader = r.Header.Get("X-Team")
+		gotAuth = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&gotReq)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(openai.EmbeddingResponse{
+			Data: []openai.Embedding{{Object: "embedding", Embedding: []float32{1}, Index: 0}},
+		})
+	}))
+	defer server.Close()
+
+	client := NewEmbeddingClient(&config.Config{
+		EmbeddingBaseURL:      server.URL + "/v1",
+		EmbeddingModel:        "nomic-embed-text",
+		EmbeddingExtraHeaders: map[string]string{"X-Team": "search"},
+	})
+	if client == nil {
+		t.Fatal("expected a client for a base URL without an API key")
+	}
+	if _, err := EmbedText(context.Background(), client, "hello"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if gotPath != "/v1/embeddings" {
+		t.Errorf("expected request to the custom base URL, got path %q", gotPath)
+	}
+	if gotHeader != "search" {
+		t.Errorf("expected extra header X-Team=search, got %q", gotHeader)
+	}
+	if gotAuth != "" {
+		t.Errorf("expected no Authorization header without an API key, got %q", gotAuth)
+	}
+	if gotReq.Model != "nomic-embed-text" || gotReq.Dimensions != 0 {
+		t.Errorf("expected configured model without dimensions, got model=%q dimensions=%d", gotReq.Model, gotReq.Dimensions)
+	}
+}
+
+func TestNewEmbeddingClient_Azure(t *testing.T) {
+	defer SetEmbeddingModel("")
+
+	var gotPath, gotQuery, gotKey string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotQuery = r.URL.Path, r.URL.RawQuery
+		gotKey = r.Header.Get("api-key")
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(openai.EmbeddingResponse{
+			Data: []openai.Embedding{{Object: "embedding", Embedding: []float32{1}, Index: 0}},
+		})
+	}))
+	defer server.Close()
+
+	client := NewEmbeddingClient(&config.Config{
+		OpenAIAPIKey:        "azure-key",
+		EmbeddingBaseURL:    server.URL,
+		EmbeddingAPIVersion: "2024-02-01",
+		EmbeddingModel:      "text-embedding-3-large",
+	})
+	if _, err := EmbedText(context.Background(), client, "hello"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if gotPath != "/openai/deployments/text-embedding-3-large/embeddings" {
+		t.Errorf("unexpected Azure path %q", gotPath)
+	}
+	if gotQuery != "api-version=2024-02-01" {
+		t.Errorf("unexpected Azure query %q", gotQuery)
+	}
+	if gotKey != "azure-key" {
+		t.Errorf("expected api-key header, got %q", gotKey)
+	}
+}
+
+func TestNewEmbeddingClient_DisabledWithoutKeyOrURL(t *testing.T) {
+	if c := NewEmbeddingClient(&config.Config{}); c != nil {
+		t.Error("expected nil client without an API key or base URL")
+	}
+}
@@ -0,0 +1,63 @@
+package indexer
+
+import (
+	"net/http"
+
+	openai "github.com/sashabaranov/go-openai"
+
+	"github.com/maximilianfalco/mycelium/internal/config"
+)
+
+// NewEmbeddingClient builds the OpenAI-compatible client used for embeddings
+// (and chat) from cfg: plain OpenAI by default, any OpenAI-compatible server
+// when EmbeddingBaseURL is set, Azure OpenAI when EmbeddingAPIVersion is also
+// set. EmbeddingExtraHeaders are added to every request.
+//
+// It returns nil when there is neither an API key nor a base URL, which
+// callers treat as "embeddings disabled". It also selects cfg.EmbeddingModel
+// for EmbedTexts and applies the embedding concurrency and rate limits.
+func NewEmbeddingClient(cfg *config.Config) *openai.Client {
+	if cfg.OpenAIAPIKey == "" && cfg.EmbeddingBaseURL == "" {
+		return nil
+	}
+	SetEmbeddingModel(cfg.EmbeddingModel)
+	SetEmbeddingLimits(EmbeddingLimits{
+		Concurrency: cfg.EmbeddingConcurrency,
+		RPM:         cfg.EmbeddingRPM,
+		TPM:         cfg.EmbeddingTPM,
+	})
+
+	var cc openai.ClientConfig
+	switch {
+	case cfg.EmbeddingAPIVersion != "":
+		cc = openai.DefaultAzureConfig(cfg.OpenAIAPIKey, cfg.EmbeddingBaseURL)
+		cc.APIVersion = cfg.EmbeddingAPIVersion
+	case cfg.EmbeddingBaseURL != "":
+		cc = openai.DefaultConfig(cfg.OpenAIAPIKey)
+		cc.BaseURL = cfg.EmbeddingBaseURL
+	default:
+		cc = openai.DefaultConfig(cfg.OpenAIAPIKey)
+	}
+
+	if len(cfg.EmbeddingExtraHeaders) > 0 {
+		cc.HTTPClient = &http.Client{Transport: &headerTransport{
+			base:    http.DefaultTransport,
+			headers: cfg.EmbeddingExtraHeaders,
+		}}
+	}
+	return openai.NewClientWithConfig(cc)
+}
+
+// headerTransport sets fixed headers on every outgoing request.
+type headerTransport struct {
+	base    http.RoundTripper
+	headers map[string]string
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for name, value := range t.headers {
+		req.Header.Set(name, value)
+	}
+	return t.base.RoundTrip(req)
+}
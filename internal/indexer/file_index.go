@@ -0,0 +1,428 @@
+package indexer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	openai "github.com/sashabaranov/go-openai"
+
+	"github.com/maximilianfalco/mycelium/internal/config"
+	"github.com/maximilianfalco/mycelium/internal/indexer/parsers"
+	"github.com/maximilianfalco/mycelium/internal/projects"
+)
+
+// FileIndexResult summarizes a single-file reindex.
+type FileIndexResult struct {
+	FilePath       string `json:"filePath"`
+	Deleted        bool   `json:"deleted"` // the file no longer exists; its nodes were removed
+	NodesUpserted  int    `json:"nodesUpserted"`
+	EdgesUpserted  int    `json:"edgesUpserted"`
+	UnresolvedRefs int    `json:"unresolvedRefs"`
+	NodesEmbedded  int    `json:"nodesEmbedded"`
+	NodesDeleted   int    `json:"nodesDeleted"`
+	// SyntaxErrors are the lines of the file that failed to parse; the
+	// declarations around them were still indexed.
+	SyntaxErrors []parsers.LineRange `json:"syntaxErrors,omitempty"`
+	Duration     time.Duration       `json:"duration"`
+}
+
+// ErrIndexingInProgress is returned by IndexFile while a full run holds the project.
+var ErrIndexingInProgress = errors.New("indexing already in progress for this project")
+
+// IndexFile re-indexes one file of an already indexed source without running
+// the whole pipeline — the fast path for editor save hooks. The file is parsed
+// and its imports and calls are resolved against the rest of the workspace as
+// stored in the database, so edges into unchanged files survive. Only the
+// file's own nodes, their outgoing edges, and their unresolved refs are
+// rewritten; nodes that disappeared from it are deleted. A file that no longer
+// exists has all of its nodes removed.
+//
+// Edges from other files into this one are left alone, so a reference to a
+// symbol that was removed dangles until the next IndexProject run, as do
+// depends_on edges, which are only recomputed package-wide.
+func IndexFile(ctx context.Context, pool *pgxpool.Pool, cfg *config.Config, oaiClient *openai.Client, sourceID, relPath string) (*FileIndexResult, error) {
+	start := time.Now()
+	applyIndexConfig(cfg)
+
+	relPath = filepath.ToSlash(filepath.Clean(relPath))
+	if relPath == "." || filepath.IsAbs(relPath) || relPath == ".." || strings.HasPrefix(relPath, "../") {
+		return nil, fmt.Errorf("invalid file path %q: must be relative to the source root", relPath)
+	}
+
+	source, err := projects.GetSource(ctx, pool, sourceID)
+	if err != nil {
+		return nil, err
+	}
+	if source == nil {
+		return nil, fmt.Errorf("source %s not found", sourceID)
+	}
+	if !source.IsCode {
+		return nil, fmt.Errorf("source %s is not a code source", source.Alias)
+	}
+
+	if _, loaded := activeJobs.LoadOrStore(source.ProjectID, true); loaded {
+		return nil, ErrIndexingInProgress
+	}
+	defer activeJobs.Delete(source.ProjectID)
+
+	sourcePath, err := PrepareSource(ctx, cfg, source)
+	if err != nil {
+		return nil, fmt.Errorf("preparing source: %w", err)
+	}
+	branch := indexBranch(ctx, cfg, sourcePath)
+	workspaceID := BranchWorkspaceID(source.ProjectID, source.ID, branch)
+	result := &FileIndexResult{FilePath: relPath}
+
+	stored, err := loadWorkspaceNodes(ctx, pool, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	absPath := filepath.Join(sourcePath, filepath.FromSlash(relPath))
+	if _, err := os.Stat(absPath); errors.Is(err, os.ErrNotExist) {
+		deleted, err := deleteFileNodes(ctx, pool, workspaceID, stored.fileNodeIDs(relPath))
+		if err != nil {
+			return nil, err
+		}
+		result.Deleted = true
+		result.NodesDeleted = deleted
+		result.Duration = time.Since(start)
+		slog.Info("file removed from index", "source", source.Alias, "file", relPath, "deleted", deleted)
+		return result, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("checking file: %w", err)
+	}
+	if !isParseableFile(absPath, filepath.Ext(relPath)) {
+		return nil, fmt.Errorf("%s is not a supported code file", relPath)
+	}
+
+	changeSet := &ChangeSet{ModifiedFiles: []string{relPath}, ConfigChanged: hasWorkspaceConfigFile([]string{relPath})}
+	wsInfo, _, err := detectWorkspaceCached(workspaceID, sourcePath, changeSet)
+	if err != nil {
+		return nil, fmt.Errorf("workspace detection: %w", err)
+	}
+
+	if skipsFixtureFile(relPath) {
+		return nil, fmt.Errorf("%s is in a fixture directory; set FIXTURES=tag to index it", relPath)
+	}
+
+	file := FileInfo{AbsPath: absPath, RelPath: relPath, Extension: filepath.Ext(relPath), Fixture: inFixtureDir(relPath)}
+	nodes, edges, parseErrors, syntaxErrors := parseFiles(ctx, []FileInfo{file}, sourcePath, newParseCache(cfg.ParseCacheDir))
+	if len(parseErrors) > 0 {
+		return nil, fmt.Errorf("parsing %s", parseErrors[0])
+	}
+	result.SyntaxErrors = syntaxErrors[relPath]
+	nodes, edges, _ = applyNodeFilter(newNodeFilter(cfg), nodes, edges)
+
+	// The rest of the workspace stands in for the files that weren't parsed,
+	// so calls into them resolve the same way a full run would
+	ctxNodes, ctxEdges := stored.resolutionContext(relPath)
+	resolveResult := ResolveImports(
+		slices.Concat(edges, ctxEdges),
+		wsInfo.AliasMap,
+		wsInfo.TSConfigPaths,
+		slices.Concat(nodes, ctxNodes),
+		append(stored.filePaths(), relPath),
+		sourcePath,
+		WithTSBaseURLs(wsInfo.TSBaseURLs),
+	)
+
+	resolved, _ := selectResolved(cfg, resolveResult)
+
+	pkgNodes, pkgEdges := goPackageNodes(siblingGoFiles(sourcePath, relPath), []FileInfo{file}, wsInfo, edges)
+	parsedCount := len(nodes)
+	nodes = append(nodes, pkgNodes...)
+	edges = append(edges, pkgEdges...)
+
+	var renames map[string]Rename
+	existing, err := loadExistingNodes(ctx, pool, workspaceID, []string{relPath})
+	if err != nil {
+		slog.Warn("could not load existing nodes, skipping rename detection", "error", err)
+	} else {
+		renames = detectRenames(existing, nodes, edges)
+	}
+
+	embeddings, signatureEmbeddings, embedded, err := embedChangedNodes(ctx, pool, oaiClient, cfg, workspaceID, source.ID, nodes, renames, func(string, string) {})
+	if err != nil {
+		return nil, fmt.Errorf("embedding: %w", err)
+	}
+	result.NodesEmbedded = embedded
+
+	input := &BuildInput{
+		ProjectID:  source.ProjectID,
+		SourceID:   source.ID,
+		SourcePath: sourcePath,
+		Branch:     branch,
+		Workspace:  wsInfo,
+		Nodes:      nodes,
+		Edges:      edges,
+		Resolved:   resolved,
+		Unresolved: resolveResult.Unresolved,
+		Embeddings: embeddings,
+		FilePaths:  append(stored.filePaths(), relPath),
+		Renames:    renames,
+
+		SignatureEmbeddings: signatureEmbeddings,
+	}
+	if err := buildFileGraph(ctx, pool, input, relPath, parsedCount, stored, result); err != nil {
+		return nil, err
+	}
+	refreshOwners(ctx, pool, source, workspaceID, sourcePath)
+
+	result.Duration = time.Since(start)
+	slog.Info("file reindexed",
+		"source", source.Alias,
+		"file", relPath,
+		"nodes", result.NodesUpserted,
+		"edges", result.EdgesUpserted,
+		"embedded", result.NodesEmbedded,
+		"deleted", result.NodesDeleted,
+		"duration", result.Duration,
+	)
+	return result, nil
+}
+
+// buildFileGraph is BuildGraph narrowed to one file: the first parsedCount
+// nodes of input came from the file, the rest are its Go package node, whose
+// contains edges into sibling files are kept.
+func buildFileGraph(ctx context.Context, pool *pgxpool.Pool, input *BuildInput, relPath string, parsedCount int, stored *workspaceNodes, result *FileIndexResult) error {
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	workspaceID := BranchWorkspaceID(input.ProjectID, input.SourceID, input.Branch)
+	if err := upsertWorkspace(ctx, tx, workspaceID, input); err != nil {
+		return err
+	}
+	packageIDs, err := upsertPackages(ctx, tx, workspaceID, input.Workspace)
+	if err != nil {
+		return err
+	}
+	if result.NodesUpserted, err = upsertNodes(ctx, tx, workspaceID, packageIDs, input, detectLanguage(input.FilePaths)); err != nil {
+		return err
+	}
+
+	newIDs := make([]string, 0, len(input.Nodes))
+	for _, node := range input.Nodes {
+		filePath := nodeFilePath(node, input.Edges)
+		newIDs = append(newIDs, NodeID(workspaceID, filePath, node.QualifiedName))
+	}
+	parsedIDs := newIDs[:parsedCount]
+
+	if _, err := tx.Exec(ctx, `DELETE FROM edges WHERE source_id = ANY($1)`, parsedIDs); err != nil {
+		return fmt.Errorf("cleaning up stale edges: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM unresolved_refs WHERE source_node_id = ANY($1)`, parsedIDs); err != nil {
+		return fmt.Errorf("clearing old unresolved refs: %w", err)
+	}
+
+	// Endpoints outside the file resolve to the stored graph
+	fileLookup := nodeLookup(workspaceID, input)
+	lookupID := func(key string) (string, bool) {
+		if id, ok := fileLookup(key); ok {
+			return id, true
+		}
+		return stored.lookup(key)
+	}
+	if result.EdgesUpserted, err = writeEdges(ctx, tx, collectEdgeRows(input, lookupID)); err != nil {
+		return err
+	}
+	if result.UnresolvedRefs, err = writeUnresolvedRefs(ctx, tx, input.Unresolved, lookupID); err != nil {
+		return err
+	}
+
+	vanished := slices.DeleteFunc(stored.fileNodeIDs(relPath), func(id string) bool { return slices.Contains(newIDs, id) })
+	if err := tombstoneNodes(ctx, tx, workspaceID, vanished); err != nil {
+		return err
+	}
+	tag, err := tx.Exec(ctx,
+		`DELETE FROM nodes WHERE workspace_id = $1 AND id = ANY($2)`,
+		workspaceID, vanished,
+	)
+	if err != nil {
+		return fmt.Errorf("deleting vanished nodes: %w", err)
+	}
+	result.NodesDeleted = int(tag.RowsAffected())
+
+	if err := bumpGraphVersion(ctx, tx, input.ProjectID); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("committing transaction: %w", err)
+	}
+	return nil
+}
+
+func deleteFileNodes(ctx context.Context, pool *pgxpool.Pool, workspaceID string, ids []string) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := tombstoneNodes(ctx, tx, workspaceID, ids); err != nil {
+		return 0, err
+	}
+	tag, err := tx.Exec(ctx, `DELETE FROM nodes WHERE workspace_id = $1 AND id = ANY($2)`, workspaceID, ids)
+	if err != nil {
+		return 0, fmt.Errorf("deleting file nodes: %w", err)
+	}
+	if err := bumpWorkspaceGraphVersion(ctx, tx, workspaceID); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("committing transaction: %w", err)
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+// storedNode is the slice of a stored node IndexFile needs to resolve against.
+type storedNode struct {
+	ID            string
+	Name          string
+	QualifiedName string
+	Kind          string
+	FilePath      string
+	Fixture       bool
+}
+
+// workspaceNodes is a workspace's stored graph, in file then line order.
+type workspaceNodes struct {
+	nodes  []storedNode
+	byName map[string]string // qualifiedName -> nodeID
+	byFile map[string]string // filePath -> first nodeID
+}
+
+func loadWorkspaceNodes(ctx context.Context, pool *pgxpool.Pool, workspaceID string) (*workspaceNodes, error) {
+	rows, err := pool.Query(ctx, `
+		SELECT id, name, COALESCE(qualified_name, name), kind, file_path, fixture
+		FROM nodes WHERE workspace_id = $1
+		ORDER BY file_path, start_line`, workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("querying workspace nodes: %w", err)
+	}
+	nodes, err := pgx.CollectRows(rows, pgx.RowToStructByPos[storedNode])
+	if err != nil {
+		return nil, fmt.Errorf("scanning workspace node: %w", err)
+	}
+	return newWorkspaceNodes(nodes), nil
+}
+
+func newWorkspaceNodes(nodes []storedNode) *workspaceNodes {
+	w := &workspaceNodes{
+		nodes:  nodes,
+		byName: make(map[string]string, len(nodes)),
+		byFile: make(map[string]string),
+	}
+	for _, n := range nodes {
+		if _, ok := w.byName[n.QualifiedName]; !ok {
+			w.byName[n.QualifiedName] = n.ID
+		}
+		w.byName[placedKey(n.FilePath, n.QualifiedName)] = n.ID
+		if _, ok := w.byFile[n.FilePath]; !ok {
+			w.byFile[n.FilePath] = n.ID
+		}
+	}
+	return w
+}
+
+// inFile returns a predicate for the stored nodes of relPath. Methods are
+// stored with their class or receiver as file path, so those owned by the
+// file's types count too.
+func (w *workspaceNodes) inFile(relPath string) func(storedNode) bool {
+	owners := make(map[string]bool)
+	for _, n := range w.nodes {
+		if n.FilePath == relPath {
+			owners[n.QualifiedName] = true
+		}
+	}
+	return func(n storedNode) bool {
+		return n.FilePath == relPath || owners[n.FilePath]
+	}
+}
+
+// fileNodeIDs returns the IDs of relPath's stored nodes.
+func (w *workspaceNodes) fileNodeIDs(relPath string) []string {
+	inFile := w.inFile(relPath)
+	var ids []string
+	for _, n := range w.nodes {
+		if inFile(n) {
+			ids = append(ids, n.ID)
+		}
+	}
+	return ids
+}
+
+// resolutionContext returns every node outside relPath, with contains edges
+// from its file, in the shape ResolveImports expects from parsing. Package
+// nodes are left out, as in the pipeline, so calls never resolve to them.
+func (w *workspaceNodes) resolutionContext(relPath string) ([]parsers.NodeInfo, []parsers.EdgeInfo) {
+	inFile := w.inFile(relPath)
+	var nodes []parsers.NodeInfo
+	var edges []parsers.EdgeInfo
+	for _, n := range w.nodes {
+		if inFile(n) || n.Kind == "package" {
+			continue
+		}
+		nodes = append(nodes, parsers.NodeInfo{Name: n.Name, QualifiedName: n.QualifiedName, Kind: n.Kind, Fixture: n.Fixture})
+		edges = append(edges, parsers.EdgeInfo{Source: n.FilePath, Target: n.QualifiedName, Kind: "contains"})
+	}
+	return nodes, edges
+}
+
+// filePaths returns the distinct file paths of the stored nodes.
+func (w *workspaceNodes) filePaths() []string {
+	var paths []string
+	for _, n := range w.nodes {
+		if len(paths) == 0 || paths[len(paths)-1] != n.FilePath {
+			paths = append(paths, n.FilePath)
+		}
+	}
+	return paths
+}
+
+// lookup resolves a qualified name, or a file path to its first node, the
+// same way nodeLookup does for freshly parsed nodes.
+func (w *workspaceNodes) lookup(key string) (string, bool) {
+	if id, ok := w.byName[key]; ok {
+		return id, true
+	}
+	id, ok := w.byFile[key]
+	return id, ok
+}
+
+// siblingGoFiles lists the Go files next to relPath, which goPackageNodes
+// reads for the package doc comment.
+func siblingGoFiles(sourcePath, relPath string) []FileInfo {
+	if filepath.Ext(relPath) != ".go" {
+		return nil
+	}
+	dir := filepath.Dir(filepath.FromSlash(relPath))
+	entries, err := os.ReadDir(filepath.Join(sourcePath, dir))
+	if err != nil {
+		return nil
+	}
+	var files []FileInfo
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".go" {
+			continue
+		}
+		rel := filepath.ToSlash(filepath.Join(dir, e.Name()))
+		files = append(files, FileInfo{AbsPath: filepath.Join(sourcePath, dir, e.Name()), RelPath: rel, Extension: ".go"})
+	}
+	return files
+}
@@ -0,0 +1,62 @@
+package indexer
+
+import (
+	"slices"
+	"testing"
+)
+
+func testWorkspaceNodes() *workspaceNodes {
+	return newWorkspaceNodes([]storedNode{
+		{ID: "ws/src/a.ts::Store", Name: "Store", QualifiedName: "Store", Kind: "class", FilePath: "src/a.ts"},
+		{ID: "ws/src/a.ts::load", Name: "load", QualifiedName: "load", Kind: "function", FilePath: "src/a.ts"},
+		{ID: "ws/Store::Store.get", Name: "get", QualifiedName: "Store.get", Kind: "method", FilePath: "Store"},
+		{ID: "ws/src/b.ts::save", Name: "save", QualifiedName: "save", Kind: "function", FilePath: "src/b.ts"},
+		{ID: "ws/src/b.ts::app", Name: "app", QualifiedName: "example.com/app", Kind: "package", FilePath: "src/b.ts"},
+	})
+}
+
+func TestWorkspaceNodes_FileNodeIDs(t *testing.T) {
+	w := testWorkspaceNodes()
+
+	// Methods are stored under their class, so they go with the class's file
+	want := []string{"ws/src/a.ts::Store", "ws/src/a.ts::load", "ws/Store::Store.get"}
+	if got := w.fileNodeIDs("src/a.ts"); !slices.Equal(got, want) {
+		t.Errorf("fileNodeIDs(a.ts) = %v, want %v", got, want)
+	}
+	if got := w.fileNodeIDs("src/missing.ts"); got != nil {
+		t.Errorf("expected no nodes for an unknown file, got %v", got)
+	}
+}
+
+func TestWorkspaceNodes_ResolutionContext(t *testing.T) {
+	w := testWorkspaceNodes()
+	nodes, edges := w.resolutionContext("src/a.ts")
+
+	// Only save: a.ts is being re-parsed and package nodes never resolve calls
+	if len(nodes) != 1 || nodes[0].QualifiedName != "save" {
+		t.Fatalf("expected just save as context, got %+v", nodes)
+	}
+	if len(edges) != 1 || edges[0].Source != "src/b.ts" || edges[0].Target != "save" || edges[0].Kind != "contains" {
+		t.Errorf("unexpected context edges %+v", edges)
+	}
+}
+
+func TestWorkspaceNodes_Lookup(t *testing.T) {
+	w := testWorkspaceNodes()
+	cases := map[string]string{
+		"Store.get": "ws/Store::Store.get",
+		"src/b.ts":  "ws/src/b.ts::save", // first node of the file
+	}
+	for key, want := range cases {
+		if got, ok := w.lookup(key); !ok || got != want {
+			t.Errorf("lookup(%q) = %q, %v; want %q", key, got, ok, want)
+		}
+	}
+	if _, ok := w.lookup("nope"); ok {
+		t.Error("expected unknown keys to miss")
+	}
+
+	if got := w.filePaths(); !slices.Equal(got, []string{"src/a.ts", "Store", "src/b.ts"}) {
+		t.Errorf("unexpected file paths %v", got)
+	}
+}
@@ -0,0 +1,93 @@
+package indexer
+
+import (
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/maximilianfalco/mycelium/internal/config"
+)
+
+// FixtureMode controls how the crawler treats fixture directories: test
+// fixtures and mocks that parse like real code but would put fake
+// implementations into search and call resolution.
+type FixtureMode string
+
+const (
+	// FixturesSkip leaves fixture directories out of crawling and change
+	// detection, like node_modules or vendor. This is the default.
+	FixturesSkip FixtureMode = "skip"
+	// FixturesTag indexes fixture directories, with their nodes marked
+	// Fixture so they're never embedded or picked by project-wide call
+	// resolution.
+	FixturesTag FixtureMode = "tag"
+)
+
+// ParseFixtureMode maps a config value to a mode. Anything other than "tag"
+// skips fixtures.
+func ParseFixtureMode(s string) FixtureMode {
+	if strings.EqualFold(strings.TrimSpace(s), string(FixturesTag)) {
+		return FixturesTag
+	}
+	return FixturesSkip
+}
+
+var (
+	fixtureMu   sync.RWMutex
+	fixtureDirs = config.DefaultFixtureDirs
+	fixtureMode = FixturesSkip
+)
+
+// SetFixtures sets the fixture directories and how they're handled. An entry
+// is a directory name ("__mocks__") or a trailing run of directories
+// ("tests/fixtures"), matched at any depth. FIXTURE_DIRS and FIXTURES set
+// them.
+func SetFixtures(dirs []string, mode FixtureMode) {
+	fixtureMu.Lock()
+	defer fixtureMu.Unlock()
+	fixtureDirs = dirs
+	fixtureMode = mode
+}
+
+// isFixtureDir reports whether the directory at relDir is a fixture
+// directory itself, not merely inside one.
+func isFixtureDir(relDir string) bool {
+	fixtureMu.RLock()
+	defer fixtureMu.RUnlock()
+	relDir = "/" + filepath.ToSlash(relDir)
+	for _, d := range fixtureDirs {
+		if d = strings.Trim(d, "/"); d != "" && strings.HasSuffix(relDir, "/"+d) {
+			return true
+		}
+	}
+	return false
+}
+
+// inFixtureDir reports whether the file at relPath lies under a fixture
+// directory.
+func inFixtureDir(relPath string) bool {
+	for dir := filepath.Dir(relPath); dir != "." && dir != "/" && dir != ""; dir = filepath.Dir(dir) {
+		if isFixtureDir(dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// skipsFixtureDir reports whether the fixture mode leaves relDir out of the
+// crawl.
+func skipsFixtureDir(relDir string) bool {
+	fixtureMu.RLock()
+	skip := fixtureMode == FixturesSkip
+	fixtureMu.RUnlock()
+	return skip && isFixtureDir(relDir)
+}
+
+// skipsFixtureFile reports whether the fixture mode leaves the file at
+// relPath out of the crawl.
+func skipsFixtureFile(relPath string) bool {
+	fixtureMu.RLock()
+	skip := fixtureMode == FixturesSkip
+	fixtureMu.RUnlock()
+	return skip && inFixtureDir(relPath)
+}
@@ -0,0 +1,83 @@
+package indexer
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/maximilianfalco/mycelium/internal/config"
+)
+
+func TestParseFixtureMode(t *testing.T) {
+	for in, want := range map[string]FixtureMode{
+		"tag":   FixturesTag,
+		"Tag":   FixturesTag,
+		"skip":  FixturesSkip,
+		"":      FixturesSkip,
+		"bogus": FixturesSkip,
+	} {
+		if got := ParseFixtureMode(in); got != want {
+			t.Errorf("ParseFixtureMode(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestInFixtureDir(t *testing.T) {
+	SetFixtures(config.DefaultFixtureDirs, FixturesSkip)
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"src/__mocks__/api.ts", true},
+		{"src/api/__mocks__/deep/client.ts", true},
+		{"tests/fixtures/sample.go", true},
+		{"packages/core/test/fixtures/app/index.ts", true},
+		{"fixtures/sample.go", false},
+		{"src/tests/fixtures.ts", false},
+		{"src/mocks/api.ts", false},
+	}
+	for _, tt := range tests {
+		if got := inFixtureDir(tt.path); got != tt.want {
+			t.Errorf("inFixtureDir(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestCrawlDirectory_Fixtures(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "src", "api.ts"), 100)
+	writeFile(t, filepath.Join(dir, "src", "__mocks__", "api.ts"), 100)
+	writeFile(t, filepath.Join(dir, "tests", "fixtures", "app", "main.go"), 100)
+	writeFile(t, filepath.Join(dir, "pkg", "testdata", "input.go"), 100)
+	t.Cleanup(func() { SetFixtures(config.DefaultFixtureDirs, FixturesSkip) })
+
+	SetFixtures(config.DefaultFixtureDirs, FixturesSkip)
+	result, err := CrawlDirectory(dir, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Files) != 1 || result.Files[0].RelPath != filepath.Join("src", "api.ts") {
+		t.Errorf("expected fixtures skipped, got %+v", result.Files)
+	}
+	if got := filterCodeFiles(dir, []string{"src/api.ts", "src/__mocks__/api.ts"}); len(got) != 1 {
+		t.Errorf("expected changed fixtures filtered out, got %v", got)
+	}
+
+	SetFixtures(config.DefaultFixtureDirs, FixturesTag)
+	result, err = CrawlDirectory(dir, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fixtures := make(map[string]bool)
+	for _, f := range result.Files {
+		fixtures[filepath.ToSlash(f.RelPath)] = f.Fixture
+	}
+	want := map[string]bool{"src/api.ts": false, "src/__mocks__/api.ts": true, "tests/fixtures/app/main.go": true}
+	if len(fixtures) != len(want) {
+		t.Fatalf("expected testdata still skipped and fixtures tagged, got %v", fixtures)
+	}
+	for path, fixture := range want {
+		if got, ok := fixtures[path]; !ok || got != fixture {
+			t.Errorf("%s: fixture = %v (crawled %v), want %v", path, got, ok, fixture)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package indexer
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/maximilianfalco/mycelium/internal/indexer/detectors"
+	"github.com/maximilianfalco/mycelium/internal/indexer/parsers"
+)
+
+// goPackageNodes synthesizes a "package" node for every Go package directory
+// with a file in parsed, plus contains edges from it to the top-level nodes
+// parsed from that directory. Parsers only see one file at a time, so this
+// runs after parsing and reads just the package clause and doc comment of
+// every file in the package — including unchanged ones on incremental runs,
+// so the doc survives when only a sibling file changed.
+//
+// The node is qualified by the package's import path when the workspace knows
+// it (the directory otherwise), carries the `// Package x ...` comment as
+// docstring and source, and lives in the file holding that comment.
+func goPackageNodes(allFiles, parsed []FileInfo, ws *detectors.WorkspaceInfo, edges []parsers.EdgeInfo) ([]parsers.NodeInfo, []parsers.EdgeInfo) {
+	dirs := make(map[string]bool)
+	for _, f := range parsed {
+		if isGoPackageFile(f.RelPath) {
+			dirs[filepath.Dir(f.RelPath)] = true
+		}
+	}
+	if len(dirs) == 0 {
+		return nil, nil
+	}
+
+	filesByDir := make(map[string][]FileInfo)
+	for _, f := range allFiles {
+		dir := filepath.Dir(f.RelPath)
+		if dirs[dir] && isGoPackageFile(f.RelPath) {
+			filesByDir[dir] = append(filesByDir[dir], f)
+		}
+	}
+
+	importPaths := make(map[string]string)
+	if ws != nil {
+		for _, pkg := range ws.Packages {
+			importPaths[pkg.Path] = pkg.Name
+		}
+	}
+
+	var nodes []parsers.NodeInfo
+	var pkgEdges []parsers.EdgeInfo
+	for _, dir := range sortedKeys(dirs) {
+		node, ok := goPackageNode(filesByDir[dir])
+		if !ok {
+			continue
+		}
+		node.QualifiedName = importPaths[dir]
+		if node.QualifiedName == "" {
+			node.QualifiedName = filepath.ToSlash(dir)
+			if dir == "." {
+				node.QualifiedName = node.Name
+			}
+		}
+		nodes = append(nodes, node)
+
+		for _, e := range edges {
+			if e.Kind == "contains" && isGoPackageFile(e.Source) && filepath.Dir(e.Source) == dir {
+				pkgEdges = append(pkgEdges, parsers.EdgeInfo{
+					Source: node.QualifiedName,
+					Target: e.Target,
+					Kind:   "contains",
+					Line:   e.Line,
+				})
+			}
+		}
+	}
+	return nodes, pkgEdges
+}
+
+// goPackageNode builds the package node from the first file (by path) that
+// has a package doc comment, or the first file when none does. QualifiedName
+// is left for the caller.
+func goPackageNode(files []FileInfo) (parsers.NodeInfo, bool) {
+	sort.Slice(files, func(i, j int) bool { return files[i].RelPath < files[j].RelPath })
+
+	var fallback *parsers.NodeInfo
+	for _, f := range files {
+		src, err := os.ReadFile(f.AbsPath)
+		if err != nil {
+			continue
+		}
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, f.AbsPath, src, parser.PackageClauseOnly|parser.ParseComments)
+		if err != nil || file.Name == nil {
+			continue
+		}
+
+		start := file.Package
+		if file.Doc != nil {
+			start = file.Doc.Pos()
+		}
+		code := string(src[fset.Position(start).Offset:fset.Position(file.Name.End()).Offset])
+		node := parsers.NodeInfo{
+			Name:       file.Name.Name,
+			Kind:       "package",
+			Signature:  "package " + file.Name.Name,
+			StartLine:  fset.Position(start).Line,
+			EndLine:    fset.Position(file.Name.End()).Line,
+			SourceCode: code,
+			BodyHash:   fmt.Sprintf("%x", sha256.Sum256([]byte(code))),
+			Exported:   true,
+			FilePath:   f.RelPath,
+		}
+		if file.Doc != nil {
+			node.Docstring = strings.TrimSpace(file.Doc.Text())
+			return node, true
+		}
+		if fallback == nil {
+			fallback = &node
+		}
+	}
+	if fallback == nil {
+		return parsers.NodeInfo{}, false
+	}
+	return *fallback, true
+}
+
+// isGoPackageFile reports whether a file belongs to its directory's package.
+// Tests are left out: external _test packages have a different name, and a
+// package doc comment never lives in a test file.
+func isGoPackageFile(relPath string) bool {
+	return strings.HasSuffix(relPath, ".go") && !strings.HasSuffix(relPath, "_test.go")
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
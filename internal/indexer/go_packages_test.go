@@ -0,0 +1,102 @@
+package indexer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/maximilianfalco/mycelium/internal/indexer/detectors"
+	"github.com/maximilianfalco/mycelium/internal/indexer/parsers"
+)
+
+func writeGoFiles(t *testing.T, dir string, files map[string]string) []FileInfo {
+	t.Helper()
+	var infos []FileInfo
+	for rel, content := range files {
+		abs := filepath.Join(dir, rel)
+		os.MkdirAll(filepath.Dir(abs), 0o755)
+		if err := os.WriteFile(abs, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		infos = append(infos, FileInfo{AbsPath: abs, RelPath: rel, Extension: ".go"})
+	}
+	return infos
+}
+
+func TestGoPackageNodes(t *testing.T) {
+	dir := t.TempDir()
+	files := writeGoFiles(t, dir, map[string]string{
+		"auth/auth.go": "package auth\n\nfunc Login() {}\n",
+		"auth/doc.go": `// Package auth provides login and token checks.
+//
+// It talks to the session store.
+package auth
+`,
+		"auth/auth_test.go": "package auth_test\n",
+		"util/util.go":      "package util\n\nfunc Clamp() {}\n",
+	})
+
+	var authGo, utilGo FileInfo
+	for _, f := range files {
+		switch f.RelPath {
+		case "auth/auth.go":
+			authGo = f
+		case "util/util.go":
+			utilGo = f
+		}
+	}
+
+	ws := &detectors.WorkspaceInfo{Packages: []detectors.PackageInfo{
+		{Name: "example.com/app/auth", Path: "auth"},
+	}}
+	edges := []parsers.EdgeInfo{
+		{Source: "auth/auth.go", Target: "Login", Kind: "contains", Line: 3},
+		{Source: "auth/auth_test.go", Target: "TestLogin", Kind: "contains", Line: 1},
+		{Source: "util/util.go", Target: "Clamp", Kind: "contains", Line: 3},
+		{Source: "auth/auth.go", Target: "fmt", Kind: "imports", Line: 1},
+	}
+
+	// Only auth.go changed, yet the doc comment comes from the unchanged doc.go
+	nodes, pkgEdges := goPackageNodes(files, []FileInfo{authGo, utilGo}, ws, edges)
+	if len(nodes) != 2 {
+		t.Fatalf("expected 2 package nodes, got %d: %+v", len(nodes), nodes)
+	}
+
+	auth := nodes[0]
+	if auth.Name != "auth" || auth.QualifiedName != "example.com/app/auth" || auth.Kind != "package" {
+		t.Errorf("unexpected auth node: %+v", auth)
+	}
+	if auth.Docstring != "Package auth provides login and token checks.\n\nIt talks to the session store." {
+		t.Errorf("unexpected docstring %q", auth.Docstring)
+	}
+	if auth.FilePath != "auth/doc.go" || auth.StartLine != 1 || auth.EndLine != 4 {
+		t.Errorf("expected auth node at auth/doc.go:1-4, got %s:%d-%d", auth.FilePath, auth.StartLine, auth.EndLine)
+	}
+	if auth.SourceCode == "" || auth.BodyHash == "" {
+		t.Error("expected source code and body hash on package node")
+	}
+
+	// Packages the workspace doesn't know are qualified by directory
+	util := nodes[1]
+	if util.QualifiedName != "util" || util.Docstring != "" || util.FilePath != "util/util.go" {
+		t.Errorf("unexpected util node: %+v", util)
+	}
+
+	want := map[string]string{"Login": "example.com/app/auth", "Clamp": "util"}
+	if len(pkgEdges) != len(want) {
+		t.Fatalf("expected %d contains edges, got %+v", len(want), pkgEdges)
+	}
+	for _, e := range pkgEdges {
+		if e.Kind != "contains" || want[e.Target] != e.Source {
+			t.Errorf("unexpected package edge %+v", e)
+		}
+	}
+}
+
+func TestGoPackageNodes_NoGoFiles(t *testing.T) {
+	files := []FileInfo{{RelPath: "src/index.ts"}}
+	nodes, edges := goPackageNodes(files, files, nil, nil)
+	if nodes != nil || edges != nil {
+		t.Errorf("expected nothing for a non-Go source, got %v %v", nodes, edges)
+	}
+}
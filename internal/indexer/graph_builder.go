@@ -4,7 +4,11 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"maps"
+	"path"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 
@@ -22,6 +26,9 @@ type BuildInput struct {
 	ProjectID  string
 	SourceID   string
 	SourcePath string
+	// Branch is the git branch being indexed with BRANCH_WORKSPACES, which
+	// selects the workspace; empty writes the source's single workspace.
+	Branch     string
 	Workspace  *detectors.WorkspaceInfo
 	Nodes      []parsers.NodeInfo
 	Edges      []parsers.EdgeInfo
@@ -30,6 +37,20 @@ type BuildInput struct {
 	DependsOn  []ResolvedEdge
 	Embeddings map[string][]float32 // qualifiedName -> vector
 	FilePaths  []string             // relative paths of all current files
+	Renames    map[string]Rename    // new qualifiedName -> vanished node it replaces
+	// SignatureEmbeddings are the signature-only vectors, qualifiedName ->
+	// vector, when EMBED_SIGNATURES is on. Nodes missing from it store none.
+	SignatureEmbeddings map[string][]float32
+	// CommitTimes maps re-parsed files to their last commit time. Nodes in
+	// files missing from the map keep whatever was stored before.
+	CommitTimes map[string]time.Time
+	// Sharded marks the input as one shard of a sharded full index (see
+	// indexShards): stale edges and unresolved refs are replaced only for
+	// nodes in the shard's files, leaving the other shards' in place.
+	// shardIndex places the endpoints of edges that cross into other
+	// shards; its nodes aren't written.
+	Sharded    bool
+	shardIndex *shardIndex
 }
 
 // BuildResult summarizes what was written to the database.
@@ -45,6 +66,7 @@ type BuildResult struct {
 // BuildGraph writes all indexing pipeline output to Postgres in a single transaction.
 func BuildGraph(ctx context.Context, pool *pgxpool.Pool, input *BuildInput) (*BuildResult, error) {
 	start := time.Now()
+	metrics().EdgesResolved(len(input.Resolved), len(input.Unresolved))
 
 	tx, err := pool.Begin(ctx)
 	if err != nil {
@@ -52,7 +74,7 @@ func BuildGraph(ctx context.Context, pool *pgxpool.Pool, input *BuildInput) (*Bu
 	}
 	defer tx.Rollback(ctx)
 
-	workspaceID := makeWorkspaceID(input.ProjectID, input.SourceID)
+	workspaceID := BranchWorkspaceID(input.ProjectID, input.SourceID, input.Branch)
 	language := detectLanguage(input.FilePaths)
 
 	// 1. Upsert workspace
@@ -66,30 +88,53 @@ func BuildGraph(ctx context.Context, pool *pgxpool.Pool, input *BuildInput) (*Bu
 		return nil, err
 	}
 
-	// 3. Upsert nodes
-	nodesUpserted, err := upsertNodes(ctx, tx, workspaceID, packageIDs, input, language)
+	// 3. Upsert nodes. A workspace's first index writes everything fresh, so
+	// it takes the COPY path instead of batched upserts.
+	bulk, err := workspaceIsEmpty(ctx, tx, workspaceID)
 	if err != nil {
 		return nil, err
 	}
+	var nodesUpserted int
+	if bulk {
+		nodesUpserted, err = copyNodes(ctx, tx, workspaceID, packageIDs, input, language)
+	} else {
+		nodesUpserted, err = upsertNodes(ctx, tx, workspaceID, packageIDs, input, language)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Tombstone the nodes step 6 removes while their edges are still there
+	if err := tombstoneStale(ctx, tx, workspaceID, input.FilePaths, renamedNodeIDs(input.Renames)...); err != nil {
+		return nil, err
+	}
 
 	// 4. Upsert edges (resolved imports, calls, structural, depends_on)
-	edgesUpserted, err := upsertEdges(ctx, tx, workspaceID, packageIDs, input)
+	edgesUpserted, err := upsertEdges(ctx, tx, workspaceID, input, bulk)
 	if err != nil {
 		return nil, err
 	}
 
 	// 5. Insert unresolved refs
-	unresolvedCount, err := insertUnresolvedRefs(ctx, tx, workspaceID, packageIDs, input)
+	unresolvedCount, err := insertUnresolvedRefs(ctx, tx, workspaceID, input)
 	if err != nil {
 		return nil, err
 	}
 
-	// 6. Cleanup stale nodes from deleted files
+	// 6. Cleanup stale nodes from deleted files, plus the old side of any renames
 	deleted, err := cleanupStale(ctx, tx, workspaceID, input.FilePaths)
 	if err != nil {
 		return nil, err
 	}
+	renamedAway, err := deleteRenamedNodes(ctx, tx, workspaceID, input.Renames)
+	if err != nil {
+		return nil, err
+	}
+	deleted += renamedAway
 
+	if err := bumpGraphVersion(ctx, tx, input.ProjectID); err != nil {
+		return nil, err
+	}
 	if err := tx.Commit(ctx); err != nil {
 		return nil, fmt.Errorf("committing transaction: %w", err)
 	}
@@ -109,13 +154,38 @@ func BuildGraph(ctx context.Context, pool *pgxpool.Pool, input *BuildInput) (*Bu
 		"edges", edgesUpserted,
 		"unresolved", unresolvedCount,
 		"deleted", deleted,
+		"bulk", bulk,
 		"duration", result.Duration,
 	)
 
 	return result, nil
 }
 
-// CleanupStale removes nodes from files that no longer exist in the workspace.
+// bumpGraphVersion increments projects.graph_version inside a transaction
+// that writes the project's graph. Caches of query results in any process
+// key on it, so the write invalidates them once it commits. Call it last,
+// just before committing: it holds the project row's lock until then.
+func bumpGraphVersion(ctx context.Context, tx pgx.Tx, projectID string) error {
+	if _, err := tx.Exec(ctx, `UPDATE projects SET graph_version = graph_version + 1 WHERE id = $1`, projectID); err != nil {
+		return fmt.Errorf("bumping graph version: %w", err)
+	}
+	return nil
+}
+
+// bumpWorkspaceGraphVersion is bumpGraphVersion for the project owning
+// workspaceID.
+func bumpWorkspaceGraphVersion(ctx context.Context, tx pgx.Tx, workspaceID string) error {
+	if _, err := tx.Exec(ctx, `
+		UPDATE projects SET graph_version = graph_version + 1
+		WHERE id = (SELECT project_id FROM workspaces WHERE id = $1)`, workspaceID,
+	); err != nil {
+		return fmt.Errorf("bumping graph version: %w", err)
+	}
+	return nil
+}
+
+// CleanupStale removes nodes from files that no longer exist in the workspace,
+// tombstoning them first when soft delete is on.
 // Exported for use by the pipeline orchestrator.
 func CleanupStale(ctx context.Context, pool *pgxpool.Pool, workspaceID string, currentFilePaths []string) (int, error) {
 	tx, err := pool.Begin(ctx)
@@ -124,10 +194,16 @@ func CleanupStale(ctx context.Context, pool *pgxpool.Pool, workspaceID string, c
 	}
 	defer tx.Rollback(ctx)
 
+	if err := tombstoneStale(ctx, tx, workspaceID, currentFilePaths); err != nil {
+		return 0, err
+	}
 	deleted, err := cleanupStale(ctx, tx, workspaceID, currentFilePaths)
 	if err != nil {
 		return 0, err
 	}
+	if err := bumpWorkspaceGraphVersion(ctx, tx, workspaceID); err != nil {
+		return 0, err
+	}
 
 	if err := tx.Commit(ctx); err != nil {
 		return 0, fmt.Errorf("committing transaction: %w", err)
@@ -138,8 +214,8 @@ func CleanupStale(ctx context.Context, pool *pgxpool.Pool, workspaceID string, c
 func upsertWorkspace(ctx context.Context, tx pgx.Tx, workspaceID string, input *BuildInput) error {
 	now := time.Now()
 	_, err := tx.Exec(ctx, `
-		INSERT INTO workspaces (id, project_id, source_id, name, path, workspace_type, package_manager, indexed_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO workspaces (id, project_id, source_id, name, path, workspace_type, package_manager, branch, indexed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), $9)
 		ON CONFLICT (id) DO UPDATE SET
 			workspace_type = EXCLUDED.workspace_type,
 			package_manager = EXCLUDED.package_manager,
@@ -151,6 +227,7 @@ func upsertWorkspace(ctx context.Context, tx pgx.Tx, workspaceID string, input *
 		input.SourcePath,
 		input.Workspace.WorkspaceType,
 		input.Workspace.PackageManager,
+		input.Branch,
 		now,
 	)
 	if err != nil {
@@ -169,13 +246,14 @@ func upsertPackages(ctx context.Context, tx pgx.Tx, workspaceID string, ws *dete
 		packageIDs[pkg.Name] = pkgID
 
 		_, err := tx.Exec(ctx, `
-			INSERT INTO packages (id, workspace_id, name, path, version, indexed_at)
-			VALUES ($1, $2, $3, $4, $5, $6)
+			INSERT INTO packages (id, workspace_id, name, path, version, entry_points, indexed_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
 			ON CONFLICT (id) DO UPDATE SET
 				path = EXCLUDED.path,
 				version = EXCLUDED.version,
+				entry_points = EXCLUDED.entry_points,
 				indexed_at = EXCLUDED.indexed_at`,
-			pkgID, workspaceID, pkg.Name, pkg.Path, pkg.Version, now,
+			pkgID, workspaceID, pkg.Name, pkg.Path, pkg.Version, packageEntryFiles(pkg), now,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("upserting package %s: %w", pkg.Name, err)
@@ -185,50 +263,121 @@ func upsertPackages(ctx context.Context, tx pgx.Tx, workspaceID string, ws *dete
 	return packageIDs, nil
 }
 
-func upsertNodes(ctx context.Context, tx pgx.Tx, workspaceID string, packageIDs map[string]string, input *BuildInput, language string) (int, error) {
+// packageEntryFiles returns the files, relative to the source root, that a
+// package declares as importable: its entry point and its package.json
+// exports targets, which keep their "*" wildcards. Nil when it declares none,
+// as with Go library packages.
+func packageEntryFiles(pkg detectors.PackageInfo) []string {
+	var files []string
+	for _, file := range slices.Concat([]string{pkg.EntryPoint}, slices.Collect(maps.Values(pkg.EntryPoints))) {
+		if file == "" {
+			continue
+		}
+		files = append(files, path.Join(filepath.ToSlash(pkg.Path), filepath.ToSlash(file)))
+	}
+	if len(files) == 0 {
+		return nil
+	}
+	slices.Sort(files)
+	return slices.Compact(files)
+}
+
+// nodeColumns are the nodes columns written by the graph builder, in the
+// order nodeRows produces values.
+var nodeColumns = []string{
+	"id", "workspace_id", "package_id", "file_path", "name", "qualified_name", "kind", "language",
+	"signature", "start_line", "end_line", "source_code", "docstring", "body_hash", "modifiers",
+	"exported", "release_tag", "deprecated", "embedding", "renamed_from", "updated_at", "last_commit_at",
+	"kind_group", "generated", "embedding_sig", "structural_hash", "fixture",
+}
+
+// nodeConflictUpdate is how an incoming node row merges into a stored one,
+// shared by the batched and bulk-load paths.
+const nodeConflictUpdate = `
+	ON CONFLICT (id) DO UPDATE SET
+		file_path = EXCLUDED.file_path,
+		name = EXCLUDED.name,
+		qualified_name = EXCLUDED.qualified_name,
+		kind = EXCLUDED.kind,
+		kind_group = EXCLUDED.kind_group,
+		language = EXCLUDED.language,
+		signature = EXCLUDED.signature,
+		start_line = EXCLUDED.start_line,
+		end_line = EXCLUDED.end_line,
+		source_code = EXCLUDED.source_code,
+		docstring = EXCLUDED.docstring,
+		body_hash = EXCLUDED.body_hash,
+		structural_hash = EXCLUDED.structural_hash,
+		modifiers = EXCLUDED.modifiers,
+		exported = EXCLUDED.exported,
+		release_tag = EXCLUDED.release_tag,
+		deprecated = EXCLUDED.deprecated,
+		generated = EXCLUDED.generated,
+		fixture = EXCLUDED.fixture,
+		embedding = EXCLUDED.embedding,
+		embedding_sig = EXCLUDED.embedding_sig,
+		renamed_from = COALESCE(EXCLUDED.renamed_from, nodes.renamed_from),
+		updated_at = EXCLUDED.updated_at,
+		last_commit_at = COALESCE(EXCLUDED.last_commit_at, nodes.last_commit_at)`
+
+// nodeRows returns the column values of each of input's nodes, in
+// nodeColumns order.
+func nodeRows(workspaceID string, packageIDs map[string]string, input *BuildInput, language string) [][]any {
 	now := time.Now()
-	count := 0
+	rows := make([][]any, 0, len(input.Nodes))
+	parentOf := buildNodeFileMap(input.Edges)
+	for _, node := range input.Nodes {
+		filePath := node.FilePath
+		if filePath == "" {
+			filePath = parentOf(node.QualifiedName)
+		}
+		pkgID := findPackageID(filePath, input.Workspace, packageIDs)
+		nodeID := NodeID(workspaceID, filePath, node.QualifiedName)
 
-	for i := 0; i < len(input.Nodes); i += batchSize {
+		emb := nodeVector(input.Embeddings, node.QualifiedName)
+		sigEmb := nodeVector(input.SignatureEmbeddings, node.QualifiedName)
+
+		var renamedFrom *string
+		if r, ok := input.Renames[node.QualifiedName]; ok {
+			renamedFrom = &r.OldNodeID
+		}
+
+		var lastCommitAt *time.Time
+		if t, ok := nodeCommitTime(filePath, parentOf, input.CommitTimes); ok {
+			lastCommitAt = &t
+		}
+
+		rows = append(rows, []any{
+			nodeID, workspaceID, nilIfEmpty(pkgID), filePath, node.Name, node.QualifiedName,
+			node.Kind, language, node.Signature, node.StartLine, node.EndLine,
+			node.SourceCode, node.Docstring, node.BodyHash, node.Modifiers,
+			node.Exported, nilIfEmpty(releaseTag(node, filePath)), node.Deprecated,
+			emb, renamedFrom, now, lastCommitAt, nilIfEmpty(parsers.KindGroup(node.Kind)), node.Generated,
+			sigEmb, nilIfEmpty(node.StructuralHash), node.Fixture,
+		})
+	}
+	return rows
+}
+
+func upsertNodes(ctx context.Context, tx pgx.Tx, workspaceID string, packageIDs map[string]string, input *BuildInput, language string) (int, error) {
+	placeholders := make([]string, len(nodeColumns))
+	for i := range placeholders {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+	}
+	sql := "INSERT INTO nodes (" + strings.Join(nodeColumns, ", ") + ")\n\tVALUES (" + strings.Join(placeholders, ", ") + ")" + nodeConflictUpdate
+
+	rows := nodeRows(workspaceID, packageIDs, input, language)
+	count := 0
+	for i := 0; i < len(rows); i += batchSize {
 		end := i + batchSize
-		if end > len(input.Nodes) {
-			end = len(input.Nodes)
+		if end > len(rows) {
+			end = len(rows)
 		}
-		chunk := input.Nodes[i:end]
+		chunk := rows[i:end]
 
 		batch := &pgx.Batch{}
-		for _, node := range chunk {
-			filePath := nodeFilePath(node, input.Edges)
-			pkgID := findPackageID(filePath, input.Workspace, packageIDs)
-			nodeID := makeNodeID(workspaceID, pkgID, filePath, node.QualifiedName)
-
-			var emb *pgvector.Vector
-			if vec, ok := input.Embeddings[node.QualifiedName]; ok && len(vec) > 0 {
-				v := pgvector.NewVector(vec)
-				emb = &v
-			}
-
-			batch.Queue(`
-				INSERT INTO nodes (id, workspace_id, package_id, file_path, name, qualified_name, kind, language, signature, start_line, end_line, source_code, docstring, body_hash, embedding, updated_at)
-				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
-				ON CONFLICT (id) DO UPDATE SET
-					file_path = EXCLUDED.file_path,
-					name = EXCLUDED.name,
-					qualified_name = EXCLUDED.qualified_name,
-					kind = EXCLUDED.kind,
-					language = EXCLUDED.language,
-					signature = EXCLUDED.signature,
-					start_line = EXCLUDED.start_line,
-					end_line = EXCLUDED.end_line,
-					source_code = EXCLUDED.source_code,
-					docstring = EXCLUDED.docstring,
-					body_hash = EXCLUDED.body_hash,
-					embedding = EXCLUDED.embedding,
-					updated_at = EXCLUDED.updated_at`,
-				nodeID, workspaceID, nilIfEmpty(pkgID), filePath, node.Name, node.QualifiedName,
-				node.Kind, language, node.Signature, node.StartLine, node.EndLine,
-				node.SourceCode, node.Docstring, node.BodyHash, emb, now,
-			)
+		for _, row := range chunk {
+			batch.Queue(sql, row...)
 		}
 
 		br := tx.SendBatch(ctx, batch)
@@ -247,67 +396,173 @@ func upsertNodes(ctx context.Context, tx pgx.Tx, workspaceID string, packageIDs
 	return count, nil
 }
 
-func upsertEdges(ctx context.Context, tx pgx.Tx, workspaceID string, packageIDs map[string]string, input *BuildInput) (int, error) {
+// GoInternalReleaseTag marks Go nodes under an internal/ directory. Go's
+// internal rule hides them from other modules only; the module indexed here
+// owns its internal/ tree, so they are its own API and, unlike a doc-tag
+// "internal", are neither left out of GetPublicAPI nor ranked down.
+const GoInternalReleaseTag = "go_internal"
+
+// releaseTag returns the node's doc-tag release tag, falling back to
+// GoInternalReleaseTag for Go files under an internal/ directory.
+func releaseTag(node parsers.NodeInfo, filePath string) string {
+	if node.ReleaseTag != "" {
+		return node.ReleaseTag
+	}
+	if filepath.Ext(filePath) == ".go" && isGoInternalPath(filePath) {
+		return GoInternalReleaseTag
+	}
+	return ""
+}
+
+func isGoInternalPath(filePath string) bool {
+	for _, part := range strings.Split(filepath.ToSlash(filePath), "/") {
+		if part == "internal" {
+			return true
+		}
+	}
+	return false
+}
+
+func upsertEdges(ctx context.Context, tx pgx.Tx, workspaceID string, input *BuildInput, bulk bool) (int, error) {
 	// Delete stale edges from previous runs. Without this, edges that the
 	// resolver no longer produces (e.g. after fixing false positives) would
 	// persist forever because upsert only inserts/updates, never deletes.
-	if _, err := tx.Exec(ctx, `
-		DELETE FROM edges WHERE source_id IN (
-			SELECT id FROM nodes WHERE workspace_id = $1
-		)`, workspaceID); err != nil {
+	replaced, args := replacedNodes(workspaceID, input)
+	if _, err := tx.Exec(ctx, `DELETE FROM edges WHERE source_id IN (`+replaced+`)`, args...); err != nil {
 		return 0, fmt.Errorf("cleaning up stale edges: %w", err)
 	}
 
-	// Collect all edges: resolved imports/calls + structural contains edges + depends_on
-	type edgeRow struct {
-		sourceID string
-		targetID string
-		kind     string
-		weight   float64
-		line     int
+	rows := collectEdgeRows(input, nodeLookup(workspaceID, input))
+	if bulk {
+		return copyEdges(ctx, tx, rows)
 	}
+	return writeEdges(ctx, tx, rows)
+}
 
-	var rows []edgeRow
+// replacedNodes returns a query selecting the stored nodes whose edges and
+// unresolved refs input replaces, with its arguments: every node of the
+// workspace, or for a shard those stored under the shard's files and
+// classes.
+func replacedNodes(workspaceID string, input *BuildInput) (string, []any) {
+	query := `SELECT id FROM nodes WHERE workspace_id = $1`
+	if !input.Sharded {
+		return query, []any{workspaceID}
+	}
+	fileOf := buildNodeFileMap(input.Edges)
+	seen := make(map[string]bool)
+	var paths []string
+	for _, node := range input.Nodes {
+		filePath := node.FilePath
+		if filePath == "" {
+			filePath = fileOf(node.QualifiedName)
+		}
+		if !seen[filePath] {
+			seen[filePath] = true
+			paths = append(paths, filePath)
+		}
+	}
+	return query + ` AND file_path = ANY($2)`, []any{workspaceID, paths}
+}
 
+// edgeRow is one edge ready to write, with endpoints resolved to node IDs.
+type edgeRow struct {
+	sourceID string
+	targetID string
+	kind     string
+	weight   float64
+	line     int
+	lines    []int          // every call site, for edges seen more than once
+	metadata map[string]any // nil writes NULL
+}
+
+// nodeLookup returns a resolver from qualified names — or file paths, for
+// edges that use a file as their source — to the IDs of input's nodes. A
+// shard's own nodes win over same-named ones in its shard index.
+func nodeLookup(workspaceID string, input *BuildInput) func(string) (string, bool) {
 	// Build a node lookup: qualifiedName -> nodeID
 	nodeIDLookup := make(map[string]string)
 	// Also build filePath -> first nodeID for edges that use file paths as sources
 	fileNodeLookup := make(map[string]string)
-	for _, node := range input.Nodes {
-		filePath := nodeFilePath(node, input.Edges)
-		pkgID := findPackageID(filePath, input.Workspace, packageIDs)
-		nodeID := makeNodeID(workspaceID, pkgID, filePath, node.QualifiedName)
+	add := func(node parsers.NodeInfo, filePath string) {
+		nodeID := NodeID(workspaceID, filePath, node.QualifiedName)
 		nodeIDLookup[node.QualifiedName] = nodeID
+		nodeIDLookup[placedKey(filePath, node.QualifiedName)] = nodeID
 		if _, exists := fileNodeLookup[filePath]; !exists {
 			fileNodeLookup[filePath] = nodeID
 		}
 	}
+	for _, node := range input.Nodes {
+		add(node, nodeFilePath(node, input.Edges))
+	}
 
-	// lookupID resolves both qualified names and file paths to node IDs
-	lookupID := func(key string) (string, bool) {
+	var indexIDs, indexFileIDs map[string]string
+	if input.shardIndex != nil {
+		indexIDs, indexFileIDs = input.shardIndex.ids, input.shardIndex.fileIDs
+	}
+	return func(key string) (string, bool) {
 		if id, ok := nodeIDLookup[key]; ok {
 			return id, true
 		}
+		if id, ok := indexIDs[key]; ok {
+			return id, true
+		}
 		if id, ok := fileNodeLookup[key]; ok {
 			return id, true
 		}
+		if id, ok := indexFileIDs[key]; ok {
+			return id, true
+		}
 		return "", false
 	}
+}
+
+// placedKey is the lookup key of the node named qualifiedName in filePath,
+// which tells apart same-named nodes of different files where the plain
+// qualified name is last-wins.
+func placedKey(filePath, qualifiedName string) string {
+	return filePath + "\x00" + qualifiedName
+}
 
-	// Resolved edges (imports, calls, extends, implements, uses_type, embeds)
+// collectEdgeRows gathers resolved imports/calls, structural contains edges,
+// and depends_on edges, dropping any whose endpoints lookupID can't place.
+func collectEdgeRows(input *BuildInput, lookupID func(string) (string, bool)) []edgeRow {
+	var rows []edgeRow
+
+	// Resolved edges (imports, calls, recurses, references, extends, implements, uses_type, embeds)
 	for _, e := range input.Resolved {
 		srcID, srcOK := lookupID(e.Source)
-		tgtID, tgtOK := lookupID(e.Target)
+		// The resolver's file picks the target among same-named nodes
+		tgtID, tgtOK := lookupID(placedKey(e.ResolvedPath, e.Target))
+		if !tgtOK {
+			tgtID, tgtOK = lookupID(e.Target)
+		}
+		// An import's target is its specifier; it lands on the resolved
+		// file's first node.
+		if !tgtOK && (e.Kind == "imports" || e.Kind == "re_exports") && e.ResolvedPath != "" {
+			tgtID, tgtOK = lookupID(e.ResolvedPath)
+		}
 		if !srcOK || !tgtOK {
 			continue
 		}
-		rows = append(rows, edgeRow{
+		row := edgeRow{
 			sourceID: srcID,
 			targetID: tgtID,
 			kind:     e.Kind,
 			weight:   edgeWeight(e.Kind),
 			line:     e.Line,
-		})
+		}
+		if e.Confidence != "" {
+			// Guessed edges rank below any real one and say so in metadata
+			row.weight = lowConfidenceWeight
+			row.metadata = map[string]any{"confidence": e.Confidence}
+		}
+		if meta := typeOnlyMetadata(e); meta != nil {
+			if row.metadata != nil {
+				meta["confidence"] = e.Confidence
+			}
+			row.metadata = meta
+		}
+		rows = append(rows, row)
 	}
 
 	// Structural "contains" edges from raw edges
@@ -317,7 +572,8 @@ func upsertEdges(ctx context.Context, tx pgx.Tx, workspaceID string, packageIDs
 		}
 		srcID, srcOK := lookupID(e.Source)
 		tgtID, tgtOK := lookupID(e.Target)
-		if !srcOK || !tgtOK {
+		// A file whose only node is the one it contains resolves to itself
+		if !srcOK || !tgtOK || srcID == tgtID {
 			continue
 		}
 		rows = append(rows, edgeRow{
@@ -342,26 +598,66 @@ func upsertEdges(ctx context.Context, tx pgx.Tx, workspaceID string, packageIDs
 			kind:     "depends_on",
 			weight:   1.0,
 			line:     e.Line,
+			metadata: typeOnlyMetadata(e),
 		})
 	}
+	return rows
+}
+
+// typeOnlyMetadata is the metadata recording which parts of an import, or
+// the whole of a package dependency, are erased at runtime, so traversals
+// can skip them with engine.SkipTypeOnly. nil when nothing is type-only.
+func typeOnlyMetadata(e ResolvedEdge) map[string]any {
+	switch {
+	case e.TypeOnly:
+		meta := map[string]any{"typeOnly": true}
+		if len(e.TypeOnlySymbols) > 0 {
+			meta["typeOnlySymbols"] = e.TypeOnlySymbols
+		}
+		return meta
+	case len(e.TypeOnlySymbols) > 0:
+		return map[string]any{"typeOnlySymbols": e.TypeOnlySymbols}
+	}
+	return nil
+}
 
-	// Deduplicate: same (source, target, kind) should pick highest weight
+// edgeConflictUpdate is how an incoming edge row merges into a stored one,
+// shared by the batched and bulk-load paths.
+const edgeConflictUpdate = `
+	ON CONFLICT (source_id, target_id, kind) DO UPDATE SET
+		weight = EXCLUDED.weight,
+		line_number = EXCLUDED.line_number,
+		call_sites = EXCLUDED.call_sites,
+		metadata = EXCLUDED.metadata`
+
+// dedupeEdges merges rows with the same (source, target, kind), keeping the
+// highest weight and every distinct line as a call site.
+func dedupeEdges(rows []edgeRow) []edgeRow {
 	type edgeKey struct{ src, tgt, kind string }
 	deduped := make(map[edgeKey]edgeRow)
 	for _, r := range rows {
 		key := edgeKey{r.sourceID, r.targetID, r.kind}
-		if existing, ok := deduped[key]; !ok || r.weight > existing.weight {
+		existing, ok := deduped[key]
+		lines := appendLine(existing.lines, r.line)
+		if !ok || r.weight > existing.weight {
 			deduped[key] = r
 		}
+		kept := deduped[key]
+		kept.lines = lines
+		deduped[key] = kept
 	}
 
-	// Batch upsert
-	count := 0
 	edgeSlice := make([]edgeRow, 0, len(deduped))
 	for _, r := range deduped {
 		edgeSlice = append(edgeSlice, r)
 	}
+	return edgeSlice
+}
 
+// writeEdges deduplicates rows and upserts them in batches.
+func writeEdges(ctx context.Context, tx pgx.Tx, rows []edgeRow) (int, error) {
+	edgeSlice := dedupeEdges(rows)
+	count := 0
 	for i := 0; i < len(edgeSlice); i += batchSize {
 		end := i + batchSize
 		if end > len(edgeSlice) {
@@ -372,12 +668,9 @@ func upsertEdges(ctx context.Context, tx pgx.Tx, workspaceID string, packageIDs
 		batch := &pgx.Batch{}
 		for _, r := range chunk {
 			batch.Queue(`
-				INSERT INTO edges (source_id, target_id, kind, weight, line_number)
-				VALUES ($1, $2, $3, $4, $5)
-				ON CONFLICT (source_id, target_id, kind) DO UPDATE SET
-					weight = EXCLUDED.weight,
-					line_number = EXCLUDED.line_number`,
-				r.sourceID, r.targetID, r.kind, r.weight, r.line,
+				INSERT INTO edges (source_id, target_id, kind, weight, line_number, call_sites, metadata)
+				VALUES ($1, $2, $3, $4, $5, $6, $7)`+edgeConflictUpdate,
+				r.sourceID, r.targetID, r.kind, r.weight, r.line, r.lines, r.metadata,
 			)
 		}
 
@@ -397,52 +690,54 @@ func upsertEdges(ctx context.Context, tx pgx.Tx, workspaceID string, packageIDs
 	return count, nil
 }
 
-func insertUnresolvedRefs(ctx context.Context, tx pgx.Tx, workspaceID string, packageIDs map[string]string, input *BuildInput) (int, error) {
-	if len(input.Unresolved) == 0 {
-		return 0, nil
+// appendLine adds line to a sorted set of call-site lines. Zero means the
+// parser didn't record a position and is skipped.
+func appendLine(lines []int, line int) []int {
+	if line <= 0 {
+		return lines
 	}
+	i := sort.SearchInts(lines, line)
+	if i < len(lines) && lines[i] == line {
+		return lines
+	}
+	lines = append(lines, 0)
+	copy(lines[i+1:], lines[i:])
+	lines[i] = line
+	return lines
+}
 
-	// Build node ID lookup (qualifiedName -> nodeID) and file path lookup (filePath -> first nodeID)
-	nodeIDLookup := make(map[string]string)
-	fileNodeLookup := make(map[string]string)
-	for _, node := range input.Nodes {
-		filePath := nodeFilePath(node, input.Edges)
-		pkgID := findPackageID(filePath, input.Workspace, packageIDs)
-		nodeID := makeNodeID(workspaceID, pkgID, filePath, node.QualifiedName)
-		nodeIDLookup[node.QualifiedName] = nodeID
-		if _, exists := fileNodeLookup[filePath]; !exists {
-			fileNodeLookup[filePath] = nodeID
-		}
+func insertUnresolvedRefs(ctx context.Context, tx pgx.Tx, workspaceID string, input *BuildInput) (int, error) {
+	if len(input.Unresolved) == 0 {
+		return 0, nil
 	}
 
 	// Clear old unresolved refs for this workspace before inserting new ones
-	_, err := tx.Exec(ctx, `
-		DELETE FROM unresolved_refs
-		WHERE source_node_id IN (SELECT id FROM nodes WHERE workspace_id = $1)`,
-		workspaceID,
-	)
+	replaced, args := replacedNodes(workspaceID, input)
+	_, err := tx.Exec(ctx, `DELETE FROM unresolved_refs WHERE source_node_id IN (`+replaced+`)`, args...)
 	if err != nil {
 		return 0, fmt.Errorf("clearing old unresolved refs: %w", err)
 	}
 
+	return writeUnresolvedRefs(ctx, tx, input.Unresolved, nodeLookup(workspaceID, input))
+}
+
+// writeUnresolvedRefs inserts refs whose source lookupID can place.
+func writeUnresolvedRefs(ctx context.Context, tx pgx.Tx, refs []UnresolvedRef, lookupID func(string) (string, bool)) (int, error) {
 	count := 0
 	now := time.Now()
 
-	for i := 0; i < len(input.Unresolved); i += batchSize {
+	for i := 0; i < len(refs); i += batchSize {
 		end := i + batchSize
-		if end > len(input.Unresolved) {
-			end = len(input.Unresolved)
+		if end > len(refs) {
+			end = len(refs)
 		}
-		chunk := input.Unresolved[i:end]
+		chunk := refs[i:end]
 
 		batch := &pgx.Batch{}
 		batchCount := 0
 		for _, ref := range chunk {
-			srcID, ok := nodeIDLookup[ref.Source]
-			if !ok {
-				// ref.Source is often a file path for import edges
-				srcID, ok = fileNodeLookup[ref.Source]
-			}
+			// ref.Source is often a file path for import edges
+			srcID, ok := lookupID(ref.Source)
 			if !ok {
 				continue
 			}
@@ -495,29 +790,71 @@ func cleanupStale(ctx context.Context, tx pgx.Tx, workspaceID string, currentFil
 	return int(tag.RowsAffected()), nil
 }
 
+// deleteRenamedNodes removes the vanished half of detected renames. Renames
+// within a file that still exists would otherwise survive cleanupStale.
+// BuildGraph tombstones them with the stale nodes beforehand.
+func deleteRenamedNodes(ctx context.Context, tx pgx.Tx, workspaceID string, renames map[string]Rename) (int, error) {
+	if len(renames) == 0 {
+		return 0, nil
+	}
+	ids := renamedNodeIDs(renames)
+	// Old IDs never collide with upserted ones: a vanished node by definition
+	// has a (file, qualifiedName) pair that no parsed node produced this run
+	tag, err := tx.Exec(ctx,
+		`DELETE FROM nodes WHERE workspace_id = $1 AND id = ANY($2)`,
+		workspaceID, ids,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("deleting renamed nodes: %w", err)
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+// renamedNodeIDs returns the IDs of the vanished nodes of renames.
+func renamedNodeIDs(renames map[string]Rename) []string {
+	ids := make([]string, 0, len(renames))
+	for _, r := range renames {
+		ids = append(ids, r.OldNodeID)
+	}
+	return ids
+}
+
 // --- ID generation ---
 
-func makeWorkspaceID(projectID, sourceID string) string {
+// WorkspaceID returns the ID of the workspace a source is indexed into.
+func WorkspaceID(projectID, sourceID string) string {
 	return fmt.Sprintf("%s/%s", projectID, sourceID)
 }
 
+// BranchWorkspaceID returns the ID of the workspace a source's branch is
+// indexed into with BRANCH_WORKSPACES: the source's workspace ID with
+// "@branch" appended. An empty branch gives WorkspaceID.
+func BranchWorkspaceID(projectID, sourceID, branch string) string {
+	if branch == "" {
+		return WorkspaceID(projectID, sourceID)
+	}
+	return WorkspaceID(projectID, sourceID) + "@" + branch
+}
+
 func makePackageID(workspaceID, packageName string) string {
 	return fmt.Sprintf("%s/%s", workspaceID, packageName)
 }
 
-func makeNodeID(workspaceID, packageID, filePath, qualifiedName string) string {
-	prefix := workspaceID
-	if packageID != "" {
-		prefix = packageID
-	}
-	return fmt.Sprintf("%s/%s::%s", prefix, filePath, qualifiedName)
+// NodeID returns the canonical ID {workspaceID}::{filePath}::{qualifiedName}.
+// The package is left out so callers can build an ID without knowing it, and
+// "::" keeps the parts apart since workspace IDs and paths both contain "/".
+func NodeID(workspaceID, filePath, qualifiedName string) string {
+	return workspaceID + "::" + filePath + "::" + qualifiedName
 }
 
 // --- Helpers ---
 
-// nodeFilePath finds the file path for a node by looking at contains edges.
-// Falls back to the qualified name prefix if no contains edge exists.
+// nodeFilePath finds the file path for a node by looking at contains edges,
+// unless the node sets one explicitly. Falls back to the qualified name prefix if no contains edge exists.
 func nodeFilePath(node parsers.NodeInfo, edges []parsers.EdgeInfo) string {
+	if node.FilePath != "" {
+		return node.FilePath
+	}
 	for _, e := range edges {
 		if e.Kind == "contains" && e.Target == node.QualifiedName {
 			return e.Source
@@ -528,6 +865,27 @@ func nodeFilePath(node parsers.NodeInfo, edges []parsers.EdgeInfo) string {
 	return node.QualifiedName
 }
 
+// nodeCommitTime looks up the commit time of the file a node lives in.
+// Methods carry their class as file path, so this climbs contains edges
+// through parentOf (see buildNodeFileMap) until it reaches a path present in
+// times.
+func nodeCommitTime(filePath string, parentOf func(string) string, times map[string]time.Time) (time.Time, bool) {
+	if len(times) == 0 {
+		return time.Time{}, false
+	}
+	for range 3 {
+		if t, ok := times[filePath]; ok {
+			return t, true
+		}
+		parent := parentOf(filePath)
+		if parent == filePath {
+			break
+		}
+		filePath = parent
+	}
+	return time.Time{}, false
+}
+
 // findPackageID maps a file path to its containing package.
 func findPackageID(filePath string, ws *detectors.WorkspaceInfo, packageIDs map[string]string) string {
 	for _, pkg := range ws.Packages {
@@ -540,15 +898,29 @@ func findPackageID(filePath string, ws *detectors.WorkspaceInfo, packageIDs map[
 	return ""
 }
 
+// lowConfidenceWeight is the weight of guessed edges, such as calls matched
+// only by normalized name.
+const lowConfidenceWeight = 0.1
+
 func edgeWeight(kind string) float64 {
 	switch kind {
-	case "contains", "extends", "implements", "embeds":
+	case "contains", "extends", "implements", "embeds", "handles":
 		return 1.0
 	default:
 		return 0.5
 	}
 }
 
+// nodeVector returns the node's vector from embeddings, or nil for NULL.
+func nodeVector(embeddings map[string][]float32, qualifiedName string) *pgvector.Vector {
+	vec, ok := embeddings[qualifiedName]
+	if !ok || len(vec) == 0 {
+		return nil
+	}
+	v := pgvector.NewVector(vec)
+	return &v
+}
+
 func nilIfEmpty(s string) *string {
 	if s == "" {
 		return nil
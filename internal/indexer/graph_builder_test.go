@@ -2,8 +2,10 @@ package indexer
 
 import (
 	"testing"
+	"time"
 
 	"github.com/maximilianfalco/mycelium/internal/indexer/detectors"
+	"github.com/maximilianfalco/mycelium/internal/indexer/parsers"
 )
 
 func TestFindPackageID(t *testing.T) {
@@ -91,3 +93,96 @@ func TestFindPackageID(t *testing.T) {
 		})
 	}
 }
+
+func TestReleaseTag(t *testing.T) {
+	tests := []struct {
+		node     parsers.NodeInfo
+		filePath string
+		want     string
+	}{
+		{parsers.NodeInfo{ReleaseTag: "beta"}, "internal/db/db.go", "beta"},
+		{parsers.NodeInfo{}, "internal/db/db.go", GoInternalReleaseTag},
+		{parsers.NodeInfo{}, "pkg/internalize/x.go", ""},
+		{parsers.NodeInfo{}, "src/internal/x.ts", ""},
+	}
+	for _, tt := range tests {
+		if got := releaseTag(tt.node, tt.filePath); got != tt.want {
+			t.Errorf("releaseTag(%+v, %q) = %q, want %q", tt.node, tt.filePath, got, tt.want)
+		}
+	}
+}
+
+func TestNodeFilePath(t *testing.T) {
+	edges := []parsers.EdgeInfo{
+		{Source: "auth/auth.go", Target: "Login", Kind: "contains"},
+		{Source: "example.com/app/auth", Target: "Login", Kind: "contains"},
+	}
+	// The file edge comes first, so a package's contains edge doesn't move it
+	if got := nodeFilePath(parsers.NodeInfo{QualifiedName: "Login"}, edges); got != "auth/auth.go" {
+		t.Errorf("nodeFilePath(Login) = %q, want auth/auth.go", got)
+	}
+	pkg := parsers.NodeInfo{QualifiedName: "example.com/app/auth", FilePath: "auth/doc.go"}
+	if got := nodeFilePath(pkg, edges); got != "auth/doc.go" {
+		t.Errorf("nodeFilePath(package) = %q, want auth/doc.go", got)
+	}
+}
+
+func TestPackageEntryFiles(t *testing.T) {
+	pkg := detectors.PackageInfo{
+		Name:       "@company/core",
+		Path:       "packages/core",
+		EntryPoint: "src/index.ts",
+		EntryPoints: map[string]string{
+			".":         "src/index.ts",
+			"./utils/*": "src/utils/*.ts",
+		},
+	}
+	got := packageEntryFiles(pkg)
+	want := []string{"packages/core/src/index.ts", "packages/core/src/utils/*.ts"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("packageEntryFiles = %v, want %v", got, want)
+	}
+	if got := packageEntryFiles(detectors.PackageInfo{Name: "auth", Path: "auth"}); got != nil {
+		t.Errorf("expected nil for a package without entry points, got %v", got)
+	}
+}
+
+func TestAppendLine(t *testing.T) {
+	var lines []int
+	for _, l := range []int{7, 3, 0, 5, 3, 7} {
+		lines = appendLine(lines, l)
+	}
+	want := []int{3, 5, 7}
+	if len(lines) != len(want) {
+		t.Fatalf("appendLine = %v, want %v", lines, want)
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Fatalf("appendLine = %v, want %v", lines, want)
+		}
+	}
+}
+
+func TestNodeCommitTime(t *testing.T) {
+	ts := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	times := map[string]time.Time{"src/user.ts": ts}
+	edges := []parsers.EdgeInfo{
+		{Source: "src/user.ts", Target: "User", Kind: "contains"},
+		{Source: "User", Target: "User.save", Kind: "contains"},
+	}
+	parentOf := buildNodeFileMap(edges)
+
+	if got, ok := nodeCommitTime("src/user.ts", parentOf, times); !ok || !got.Equal(ts) {
+		t.Errorf("file node: got %v, %v", got, ok)
+	}
+	// Methods carry their class as file path; the lookup climbs to the file.
+	if got, ok := nodeCommitTime("User", parentOf, times); !ok || !got.Equal(ts) {
+		t.Errorf("method node: got %v, %v", got, ok)
+	}
+	if _, ok := nodeCommitTime("src/other.ts", parentOf, times); ok {
+		t.Error("expected no commit time for a file missing from the map")
+	}
+	if _, ok := nodeCommitTime("src/user.ts", parentOf, nil); ok {
+		t.Error("expected no commit time without a map")
+	}
+}
@@ -1,9 +1,17 @@
 package indexer
 
 import (
+	"maps"
+	"path"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
 	"strings"
+	"unicode"
 
+	"github.com/maximilianfalco/mycelium/internal/config"
+	"github.com/maximilianfalco/mycelium/internal/indexer/detectors"
 	"github.com/maximilianfalco/mycelium/internal/indexer/parsers"
 )
 
@@ -15,9 +23,19 @@ type ResolvedEdge struct {
 	Kind         string   `json:"kind"`
 	Line         int      `json:"line"`
 	Symbols      []string `json:"symbols,omitempty"`
+	// TypeOnly is carried over from the parsed import. On depends_on edges it
+	// means every import behind the package dependency is type-only.
+	TypeOnly        bool     `json:"typeOnly,omitempty"`
+	TypeOnlySymbols []string `json:"typeOnlySymbols,omitempty"`
+	// Confidence is "low" on guesses from the normalized-name tier (see
+	// ResolveResult.NormalizedCalls) and empty for every other edge.
+	Confidence string `json:"confidence,omitempty"`
 }
 
-// UnresolvedRef is an import or call that couldn't be resolved.
+// UnresolvedRef is an import or call that couldn't be resolved. Imports of
+// Node built-ins and the Go standard library are kept too, with kind
+// BuiltinRefKind, so reachability queries can report them; nothing ever
+// resolves them.
 type UnresolvedRef struct {
 	Source    string `json:"source"`
 	RawImport string `json:"rawImport"`
@@ -30,8 +48,23 @@ type ResolveResult struct {
 	Resolved   []ResolvedEdge  `json:"resolved"`
 	Unresolved []UnresolvedRef `json:"unresolved"`
 	DependsOn  []ResolvedEdge  `json:"dependsOn"`
+	// DependsOnThroughBarrels is DependsOn with imports through barrel files
+	// attributed to the package that defines each imported symbol, found by
+	// following re_exports edges, instead of to the barrel's package.
+	DependsOnThroughBarrels []ResolvedEdge `json:"dependsOnThroughBarrels"`
+	// NormalizedCalls are low-confidence calls edges for calls nothing else
+	// resolved, matched to the one node whose name is the same ignoring case
+	// and underscores (Go's Authenticate for a TS authenticate, or
+	// authenticate_user for authenticateUser), as across generated bindings.
+	// They're kept apart from Resolved since the match is a guess; the
+	// pipeline adds them when NORMALIZED_CALL_MATCHING is on.
+	NormalizedCalls []ResolvedEdge `json:"normalizedCalls"`
 }
 
+// BuiltinRefKind is the unresolved_refs kind of an import of a Node
+// built-in ("fs", "node:child_process") or a Go standard library package.
+const BuiltinRefKind = "builtin"
+
 // nodeBuiltins is the set of Node.js built-in modules that should be skipped.
 var nodeBuiltins = map[string]bool{
 	"assert": true, "buffer": true, "child_process": true, "cluster": true,
@@ -57,6 +90,62 @@ const (
 	statusSkipped
 )
 
+// ResolveOption configures ResolveImports.
+type ResolveOption func(*resolveConfig)
+
+// resolveConfig holds the workspace details ResolveImports takes as options.
+type resolveConfig struct {
+	packages packageGrouping
+	baseURLs map[string]string
+	index    *nodeIndex
+}
+
+// withNodeIndex resolves against a prebuilt index instead of one built from
+// the nodes passed in, which are then ignored. Sharded runs build one index
+// of every shard up front and share it across their resolves.
+func withNodeIndex(index *nodeIndex) ResolveOption {
+	return func(c *resolveConfig) {
+		c.index = index
+	}
+}
+
+// WithPackages attributes files to the detected workspace packages for
+// depends_on: a file belongs to the deepest package directory containing
+// it. The root package is ignored, since it would claim every file. Files
+// in no package fall back to the marker directories.
+func WithPackages(packages []detectors.PackageInfo) ResolveOption {
+	return func(c *resolveConfig) {
+		g := &c.packages
+		g.paths = nil
+		for _, pkg := range packages {
+			if p := filepath.ToSlash(filepath.Clean(pkg.Path)); p != "." && p != "" {
+				g.paths = append(g.paths, p)
+			}
+		}
+		// Deepest first, so a nested package wins over its parent
+		slices.SortFunc(g.paths, func(a, b string) int { return len(b) - len(a) })
+	}
+}
+
+// WithPackageMarkerDirs replaces config.DefaultPackageMarkerDirs as the
+// directories whose children are packages. None disables the heuristic.
+func WithPackageMarkerDirs(dirs []string) ResolveOption {
+	return func(c *resolveConfig) {
+		c.packages.markers = dirs
+	}
+}
+
+// WithTSBaseURLs resolves non-relative specifiers against tsconfig baseUrl
+// directories (see detectors.WorkspaceInfo.TSBaseURLs) when neither the
+// alias map nor tsconfig paths match, so `utils/format` under `baseUrl: "."`
+// finds `utils/format.ts`. A specifier that names no file is left to the
+// tiers after it, so external packages stay unresolved.
+func WithTSBaseURLs(baseURLs map[string]string) ResolveOption {
+	return func(c *resolveConfig) {
+		c.baseURLs = baseURLs
+	}
+}
+
 // ResolveImports takes raw edges from parsing, workspace alias maps, tsconfig
 // paths, and a set of all parsed files, then resolves import specifiers to
 // concrete file paths and traces call edges through imports.
@@ -67,8 +156,14 @@ func ResolveImports(
 	allNodes []parsers.NodeInfo,
 	allFiles []string,
 	rootPath string,
+	opts ...ResolveOption,
 ) *ResolveResult {
 	result := &ResolveResult{}
+	cfg := resolveConfig{packages: packageGrouping{markers: config.DefaultPackageMarkerDirs}}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	packages := cfg.packages
 
 	if aliasMap == nil {
 		aliasMap = make(map[string]string)
@@ -79,52 +174,260 @@ func ResolveImports(
 
 	// Build lookup structures
 	fileSet := buildFileSet(allFiles)
-	nodesByFile := buildNodesByFile(rawEdges, allNodes)
-	importedSymbols := buildImportedSymbolMap(rawEdges)
-	nodesByName := buildNodesByName(allNodes)
+	index := cfg.index
+	if index == nil {
+		index = newNodeIndex()
+		index.add(allNodes, rawEdges)
+	}
+	nodesByFile := index.byFile
+	nodesByName := index.byName
+	nodesByNormalizedName := index.byNormalizedName
 
-	// Track package-level dependencies for depends_on edges
+	// Track package-level dependencies for depends_on edges. The value is
+	// true once any runtime (non-type-only) import backs the dependency.
 	packageDeps := make(map[string]map[string]bool)
 
+	// Pass 1: imports and re-exports. Resolved paths feed call tracing in
+	// pass 2; re-exports also map out barrel files for depends_on below.
+	resolvedImports := make(map[importKey]string)
+	reExports := make(map[string][]reExport)
+	var fileDeps []ResolvedEdge
 	for _, edge := range rawEdges {
-		switch edge.Kind {
-		case "imports":
-			resolved, status := resolveImportEdge(edge, aliasMap, tsconfigPaths, fileSet, rootPath)
-			switch status {
-			case statusResolved:
-				result.Resolved = append(result.Resolved, *resolved)
-				trackPackageDep(packageDeps, edge.Source, resolved.ResolvedPath, rootPath)
-			case statusSkipped:
-				// Builtin or stdlib — don't track
-			case statusUnresolved:
+		if edge.Kind != "imports" && edge.Kind != "re_exports" {
+			continue
+		}
+		resolved, status := resolveImportEdge(edge, aliasMap, tsconfigPaths, cfg.baseURLs, fileSet, rootPath)
+		switch status {
+		case statusResolved:
+			resolved.Kind = edge.Kind
+			result.Resolved = append(result.Resolved, *resolved)
+			if edge.Kind == "re_exports" {
+				reExports[edge.Source] = append(reExports[edge.Source], reExport{file: resolved.ResolvedPath, symbols: edge.Symbols})
+			} else {
+				resolvedImports[importKey{edge.Source, edge.Target}] = resolved.ResolvedPath
+			}
+			fileDeps = append(fileDeps, *resolved)
+			trackPackageDep(packageDeps, packages, edge.Source, resolved.ResolvedPath, !edge.TypeOnly)
+		case statusSkipped:
+			// Builtin or stdlib: no edge or depends_on, only a record of
+			// the import for GetReachableExternals
+			if edge.Kind == "imports" {
 				result.Unresolved = append(result.Unresolved, UnresolvedRef{
 					Source:    edge.Source,
 					RawImport: edge.Target,
-					Kind:      "imports",
+					Kind:      BuiltinRefKind,
 					Line:      edge.Line,
 				})
 			}
+		case statusUnresolved:
+			result.Unresolved = append(result.Unresolved, UnresolvedRef{
+				Source:    edge.Source,
+				RawImport: edge.Target,
+				Kind:      edge.Kind,
+				Line:      edge.Line,
+			})
+		}
+	}
 
-		case "calls":
-			resolved := resolveCallEdge(edge, nodesByFile, importedSymbols, nodesByName)
-			if resolved != nil {
-				result.Resolved = append(result.Resolved, *resolved)
+	// Pass 2: calls, traced through the imports resolved above. A call that
+	// resolves back to its caller is tagged "recurses" rather than "calls", so
+	// recursion doesn't show up as a self-loop in callers/callees, traversal,
+	// or caller counts unless a query asks for it.
+	importedSymbols := buildImportedSymbolMap(rawEdges, resolvedImports)
+	for _, edge := range rawEdges {
+		if edge.Kind != "calls" {
+			continue
+		}
+		if resolved := resolveCallEdge(edge, nodesByFile, importedSymbols, nodesByName); resolved != nil {
+			if resolved.Source == resolved.Target {
+				resolved.Kind = "recurses"
 			}
+			result.Resolved = append(result.Resolved, *resolved)
+		} else if guess := resolveNormalizedCall(edge, nodesByNormalizedName, nodesByFile); guess != nil {
+			result.NormalizedCalls = append(result.NormalizedCalls, *guess)
+		} else if isExternalCall(edge, nodesByFile, importedSymbols) {
+			result.Unresolved = append(result.Unresolved, UnresolvedRef{
+				Source:    edge.Source,
+				RawImport: edge.Target,
+				Kind:      "calls",
+				Line:      edge.Line,
+			})
+		}
+	}
+
+	// Pass 2a: component renders edges from SFC markup, resolved like calls
+	for _, edge := range rawEdges {
+		if edge.Kind != "renders" {
+			continue
+		}
+		if resolved := resolveCallEdge(edge, nodesByFile, importedSymbols, nodesByName); resolved != nil && resolved.Source != resolved.Target {
+			resolved.Kind = "renders"
+			result.Resolved = append(result.Resolved, *resolved)
+		}
+	}
+
+	// Pass 2a': route handles edges from endpoint nodes, resolved like calls
+	for _, edge := range rawEdges {
+		if edge.Kind != "handles" {
+			continue
+		}
+		if resolved := resolveCallEdge(edge, nodesByFile, importedSymbols, nodesByName); resolved != nil {
+			resolved.Kind = "handles"
+			result.Resolved = append(result.Resolved, *resolved)
+		}
+	}
+
+	// Pass 2b: value references, traced like calls but without a
+	// project-wide name search
+	goPackages := buildGoPackageDirs(rawEdges, resolvedImports)
+	goFilesByDir := index.goFiles()
+	for _, edge := range rawEdges {
+		if edge.Kind != "references" {
+			continue
+		}
+		if resolved := resolveReferenceEdge(edge, nodesByFile, importedSymbols, goPackages, goFilesByDir); resolved != nil {
+			result.Resolved = append(result.Resolved, *resolved)
+		}
+	}
+
+	// Pass 2c: type uses, resolved like references. Go stores types
+	// unqualified, so store.Order is looked up as Order in the directory the
+	// file's import of store resolved to; stdlib and third-party types have
+	// no such directory and are dropped.
+	for _, edge := range rawEdges {
+		if edge.Kind != "uses_type" {
+			continue
+		}
+		if resolved := resolveReferenceEdge(edge, nodesByFile, importedSymbols, goPackages, goFilesByDir); resolved != nil {
+			resolved.Kind = "uses_type"
+			result.Resolved = append(result.Resolved, *resolved)
+		}
+	}
+
+	// Pass 3: SQL table references, matched by name to table and view nodes
+	for _, edge := range rawEdges {
+		if edge.Kind != "uses_table" {
+			continue
+		}
+		if resolved := resolveTableEdge(edge, nodesByName, nodesByFile); resolved != nil {
+			result.Resolved = append(result.Resolved, *resolved)
+		}
+	}
+
+	// Build depends_on edges from aggregated package-level imports, once as
+	// written and once with barrel imports traced to their defining files
+	barrelDeps := make(map[string]map[string]bool)
+	for _, dep := range fileDeps {
+		for _, target := range barrelTargets(dep, reExports, nodesByFile) {
+			trackPackageDep(barrelDeps, packages, dep.Source, target, !dep.TypeOnly)
 		}
 	}
+	result.DependsOn = dependsOnEdges(packageDeps)
+	result.DependsOnThroughBarrels = dependsOnEdges(barrelDeps)
+
+	return result
+}
 
-	// Build depends_on edges from aggregated package-level imports
+// dependsOnEdges turns aggregated package dependencies into depends_on edges.
+func dependsOnEdges(packageDeps map[string]map[string]bool) []ResolvedEdge {
+	var edges []ResolvedEdge
 	for srcPkg, targets := range packageDeps {
-		for tgtPkg := range targets {
-			result.DependsOn = append(result.DependsOn, ResolvedEdge{
-				Source: srcPkg,
-				Target: tgtPkg,
-				Kind:   "depends_on",
+		for tgtPkg, runtime := range targets {
+			edges = append(edges, ResolvedEdge{
+				Source:   srcPkg,
+				Target:   tgtPkg,
+				Kind:     "depends_on",
+				TypeOnly: !runtime,
 			})
 		}
 	}
+	return edges
+}
 
-	return result
+// reExport is one `export ... from` in a barrel file: the resolved module and
+// the names re-exported from it, none meaning `export *`.
+type reExport struct {
+	file    string
+	symbols []string
+}
+
+// barrelTargets returns the files an import or re-export really depends on.
+// For one that lands on a barrel (a file with re_exports), each named symbol
+// is followed through the re-export chain to the file defining it. Namespace
+// and default imports, and symbols the chain doesn't account for, stay on the
+// file as written.
+func barrelTargets(dep ResolvedEdge, reExports map[string][]reExport, nodesByFile map[string][]parsers.NodeInfo) []string {
+	if _, isBarrel := reExports[dep.ResolvedPath]; !isBarrel || len(dep.Symbols) == 0 {
+		return []string{dep.ResolvedPath}
+	}
+
+	var targets []string
+	seen := make(map[string]bool)
+	for _, sym := range dep.Symbols {
+		target := dep.ResolvedPath
+		if dep.Kind == "re_exports" {
+			sym, _, _ = strings.Cut(sym, " as ")
+		}
+		if !strings.HasPrefix(sym, "*") {
+			if file, ok := followReExport(dep.ResolvedPath, sym, reExports, nodesByFile, make(map[string]bool)); ok {
+				target = file
+			}
+		}
+		if !seen[target] {
+			seen[target] = true
+			targets = append(targets, target)
+		}
+	}
+	return targets
+}
+
+// followReExport finds the file that defines the symbol file exports as name,
+// walking named re-exports before `export *` ones, as TypeScript does.
+// visited guards against re-export cycles.
+func followReExport(file, name string, reExports map[string][]reExport, nodesByFile map[string][]parsers.NodeInfo, visited map[string]bool) (string, bool) {
+	key := file + "\x00" + name
+	if visited[key] {
+		return "", false
+	}
+	visited[key] = true
+
+	for _, n := range nodesByFile[file] {
+		if n.Name == name && n.QualifiedName == name {
+			return file, true
+		}
+	}
+
+	for _, re := range reExports[file] {
+		for _, sym := range re.symbols {
+			orig, alias, renamed := strings.Cut(sym, " as ")
+			if !renamed {
+				alias = orig
+			}
+			if alias != name {
+				continue
+			}
+			if orig == "*" {
+				// export * as ns: the namespace object is the whole module
+				return re.file, true
+			}
+			if target, ok := followReExport(re.file, orig, reExports, nodesByFile, visited); ok {
+				return target, true
+			}
+			// Named explicitly, so it comes from there even if that file's
+			// nodes don't show it (e.g. a default or a value we don't index)
+			return re.file, true
+		}
+	}
+
+	for _, re := range reExports[file] {
+		if len(re.symbols) > 0 {
+			continue
+		}
+		if target, ok := followReExport(re.file, name, reExports, nodesByFile, visited); ok {
+			return target, true
+		}
+	}
+	return "", false
 }
 
 // resolveImportEdge attempts to resolve a single import edge to a file path.
@@ -132,6 +435,7 @@ func resolveImportEdge(
 	edge parsers.EdgeInfo,
 	aliasMap map[string]string,
 	tsconfigPaths map[string]string,
+	baseURLs map[string]string,
 	fileSet map[string]bool,
 	rootPath string,
 ) (*ResolvedEdge, resolveStatus) {
@@ -159,6 +463,9 @@ func resolveImportEdge(
 			Kind:         "imports",
 			Line:         edge.Line,
 			Symbols:      edge.Symbols,
+
+			TypeOnly:        edge.TypeOnly,
+			TypeOnlySymbols: edge.TypeOnlySymbols,
 		}
 	}
 
@@ -180,7 +487,14 @@ func resolveImportEdge(
 		}
 	}
 
-	// 6. Go module import path check (non-relative, non-builtin)
+	// 6. baseUrl-rooted imports (utils/format with baseUrl ".")
+	if !isGoSource && !strings.HasPrefix(specifier, ".") {
+		if resolved := resolveViaBaseURL(specifier, sourceFile, baseURLs, fileSet); resolved != "" {
+			return makeResolved(resolved), statusResolved
+		}
+	}
+
+	// 7. Go module import path check (non-relative, non-builtin)
 	if resolved := resolveGoModuleImport(specifier, aliasMap, fileSet); resolved != "" {
 		return makeResolved(resolved), statusResolved
 	}
@@ -189,7 +503,8 @@ func resolveImportEdge(
 	return nil, statusUnresolved
 }
 
-// resolveViaAliasMap checks if the specifier matches a monorepo package name.
+// resolveViaAliasMap checks if the specifier matches a monorepo package name,
+// one of its exported subpaths, or a subpath pattern.
 func resolveViaAliasMap(specifier string, aliasMap map[string]string, fileSet map[string]bool) string {
 	// Exact match: @company/auth → packages/auth/src/index.ts
 	if entryPoint, ok := aliasMap[specifier]; ok {
@@ -202,11 +517,36 @@ func resolveViaAliasMap(specifier string, aliasMap map[string]string, fileSet ma
 		}
 	}
 
-	// Subpath match: @company/auth/validators → look relative to package root
+	// Exported subpath patterns: @company/auth/utils/* → packages/auth/src/utils/*.ts
 	for alias, entryPoint := range aliasMap {
+		prefix, ok := strings.CutSuffix(alias, "*")
+		if !ok || !strings.HasPrefix(specifier, prefix) {
+			continue
+		}
+		candidate := strings.Replace(entryPoint, "*", strings.TrimPrefix(specifier, prefix), 1)
+		if resolved := tryExtensions(candidate, fileSet); resolved != "" {
+			return resolved
+		}
+		if resolved := tryExtensions(strings.TrimSuffix(candidate, filepath.Ext(candidate)), fileSet); resolved != "" {
+			return resolved
+		}
+	}
+
+	// Subpath match: @company/auth/validators → look relative to package root.
+	// Longest alias first, so an exported subpath like @company/auth/server
+	// wins over the bare package for @company/auth/server/x.
+	aliases := make([]string, 0, len(aliasMap))
+	for alias := range aliasMap {
+		if !strings.HasSuffix(alias, "*") {
+			aliases = append(aliases, alias)
+		}
+	}
+	sort.Slice(aliases, func(i, j int) bool { return len(aliases[i]) > len(aliases[j]) })
+	for _, alias := range aliases {
 		if !strings.HasPrefix(specifier, alias+"/") {
 			continue
 		}
+		entryPoint := aliasMap[alias]
 		rest := strings.TrimPrefix(specifier, alias+"/")
 
 		// Derive package root from entry point.
@@ -269,6 +609,28 @@ func resolveViaTSConfigPaths(specifier string, tsconfigPaths map[string]string,
 	return ""
 }
 
+// resolveViaBaseURL resolves a bare specifier against the baseUrl of the
+// tsconfig governing sourceFile: the deepest config directory containing it.
+func resolveViaBaseURL(specifier, sourceFile string, baseURLs map[string]string, fileSet map[string]bool) string {
+	depth, baseDir := -1, ""
+	for dir, base := range baseURLs {
+		d := 0
+		if dir != "." {
+			if !strings.HasPrefix(sourceFile, dir+"/") {
+				continue
+			}
+			d = len(dir)
+		}
+		if d > depth {
+			depth, baseDir = d, base
+		}
+	}
+	if depth < 0 {
+		return ""
+	}
+	return tryExtensions(filepath.Join(baseDir, specifier), fileSet)
+}
+
 // resolveRelativeImport resolves a relative import like ./utils or ../shared.
 func resolveRelativeImport(specifier, sourceDir string, fileSet map[string]bool) string {
 	candidate := filepath.Join(sourceDir, specifier)
@@ -306,18 +668,19 @@ func resolveGoModuleImport(specifier string, aliasMap map[string]string, fileSet
 func resolveCallEdge(
 	edge parsers.EdgeInfo,
 	nodesByFile map[string][]parsers.NodeInfo,
-	importedSymbols map[string]map[string]string,
+	importedSymbols map[string]map[string]importedSymbol,
 	nodesByName map[string][]parsers.NodeInfo,
 ) *ResolvedEdge {
 	callerName := edge.Source
 	calleeName := edge.Target
 
-	if isGlobalCall(calleeName) {
+	callerFile := findFileForNode(callerName, nodesByFile)
+	heuristics := callHeuristicsFor(callerFile, nodesByFile)
+	if heuristics.isGlobalCall(calleeName) {
 		return nil
 	}
 
 	// 1. Check if the callee is defined in the same file
-	callerFile := findFileForNode(callerName, nodesByFile)
 	if callerFile != "" {
 		for _, node := range nodesByFile[callerFile] {
 			if node.QualifiedName == calleeName || node.Name == calleeName {
@@ -340,13 +703,15 @@ func resolveCallEdge(
 
 	if callerFile != "" {
 		if imports, ok := importedSymbols[callerFile]; ok {
-			if sourceFile, found := imports[simpleName]; found {
-				for _, node := range nodesByFile[sourceFile] {
-					if node.Name == simpleName || node.QualifiedName == simpleName {
+			if imp, found := imports[simpleName]; found {
+				// A default import binds whatever the module default-exports,
+				// regardless of the local name it was given.
+				for _, node := range nodesByFile[imp.file] {
+					if (imp.isDefault && node.DefaultExport) || (!imp.isDefault && (node.Name == simpleName || node.QualifiedName == simpleName)) {
 						return &ResolvedEdge{
 							Source:       edge.Source,
 							Target:       node.QualifiedName,
-							ResolvedPath: sourceFile,
+							ResolvedPath: imp.file,
 							Kind:         "calls",
 							Line:         edge.Line,
 						}
@@ -360,10 +725,12 @@ func resolveCallEdge(
 	// Skip if the callee is a member expression with a common prototype method name,
 	// e.g. "user.email.split" → "split" would falsely match a user-defined split().
 	isMemberCall := strings.Contains(calleeName, ".")
-	if isMemberCall && isBuiltinMethodName(simpleName) {
+	if isMemberCall && heuristics.isBuiltinMethodName(simpleName) {
 		return nil
 	}
-	if matches, ok := nodesByName[simpleName]; ok && len(matches) == 1 {
+	// Fixtures stand in for real code, so they neither match nor make a real
+	// match ambiguous
+	if matches := withoutFixtures(nodesByName[simpleName]); len(matches) == 1 {
 		return &ResolvedEdge{
 			Source:       edge.Source,
 			Target:       matches[0].QualifiedName,
@@ -376,6 +743,211 @@ func resolveCallEdge(
 	return nil
 }
 
+// resolveReferenceEdge points a references edge at the node it names. Like
+// a call it checks the caller's file, then its imports (named, default, or
+// namespace.name). Bare names are too common for the project-wide search
+// calls fall back to, so instead a Go reference only matches within its own
+// package, the caller's directory, or for pkg.Name, the imported package's.
+// References to anything not indexed, or back to the caller, are dropped.
+func resolveReferenceEdge(
+	edge parsers.EdgeInfo,
+	nodesByFile map[string][]parsers.NodeInfo,
+	importedSymbols map[string]map[string]importedSymbol,
+	goPackages map[string]map[string]string,
+	goFilesByDir map[string][]string,
+) *ResolvedEdge {
+	callerFile := findFileForNode(edge.Source, nodesByFile)
+	if callerFile == "" {
+		return nil
+	}
+	qualifier, name, qualified := strings.Cut(edge.Target, ".")
+	if !qualified {
+		name, qualifier = qualifier, ""
+	}
+
+	resolved := func(target parsers.NodeInfo, file string) *ResolvedEdge {
+		if target.QualifiedName == edge.Source {
+			return nil
+		}
+		return &ResolvedEdge{
+			Source:       edge.Source,
+			Target:       target.QualifiedName,
+			ResolvedPath: file,
+			Kind:         "references",
+			Line:         edge.Line,
+		}
+	}
+
+	if qualifier == "" {
+		for _, node := range nodesByFile[callerFile] {
+			if node.QualifiedName == name {
+				return resolved(node, callerFile)
+			}
+		}
+	}
+
+	// Named and default imports bind the name itself; namespace imports
+	// bind the qualifier
+	importName := name
+	if qualifier != "" {
+		importName = qualifier
+	}
+	if imp, ok := importedSymbols[callerFile][importName]; ok {
+		for _, node := range nodesByFile[imp.file] {
+			if qualifier == "" && imp.isDefault && node.DefaultExport {
+				return resolved(node, imp.file)
+			}
+			if (qualifier != "" || !imp.isDefault) && node.QualifiedName == name {
+				return resolved(node, imp.file)
+			}
+		}
+		return nil
+	}
+
+	if filepath.Ext(callerFile) != ".go" {
+		return nil
+	}
+	dir := filepath.Dir(callerFile)
+	if qualifier != "" {
+		pkgDir, ok := goPackages[callerFile][qualifier]
+		if !ok {
+			return nil
+		}
+		dir = pkgDir
+	}
+	// Searched by file rather than by name, since packages often share
+	// type names (two Order structs)
+	var match *parsers.NodeInfo
+	var matchFile string
+	for _, file := range goFilesByDir[dir] {
+		for i, node := range nodesByFile[file] {
+			if node.QualifiedName != name {
+				continue // methods and other members
+			}
+			if match != nil {
+				return nil
+			}
+			match, matchFile = &nodesByFile[file][i], file
+		}
+	}
+	if match == nil {
+		return nil
+	}
+	return resolved(*match, matchFile)
+}
+
+// buildGoPackageDirs maps each Go file to the directory of every project
+// package it imports, keyed by the name the file refers to it by: the alias,
+// or the last path element minus a major version suffix.
+func buildGoPackageDirs(edges []parsers.EdgeInfo, resolvedImports map[importKey]string) map[string]map[string]string {
+	result := make(map[string]map[string]string)
+	for _, e := range edges {
+		if e.Kind != "imports" || filepath.Ext(e.Source) != ".go" {
+			continue
+		}
+		dir, ok := resolvedImports[importKey{e.Source, e.Target}]
+		if !ok {
+			continue
+		}
+		name := path.Base(e.Target)
+		if goMajorVersion.MatchString(name) {
+			name = path.Base(path.Dir(e.Target))
+		}
+		name, _, _ = strings.Cut(name, ".")
+		if len(e.Symbols) > 0 {
+			if !strings.HasSuffix(e.Symbols[0], " (alias)") {
+				continue
+			}
+			name = strings.TrimSuffix(e.Symbols[0], " (alias)")
+		}
+		if result[e.Source] == nil {
+			result[e.Source] = make(map[string]string)
+		}
+		result[e.Source][name] = dir
+	}
+	return result
+}
+
+var goMajorVersion = regexp.MustCompile(`^v[0-9]+$`)
+
+// resolveNormalizedCall is the last-resort tier for a call resolveCallEdge
+// gave up on: it matches the callee's simple name to the only node whose
+// normalizeIdentifier form is the same. Calls that could be builtins, and
+// names too short to be distinctive, are skipped.
+func resolveNormalizedCall(edge parsers.EdgeInfo, nodesByNormalizedName map[string][]parsers.NodeInfo, nodesByFile map[string][]parsers.NodeInfo) *ResolvedEdge {
+	heuristics := callHeuristicsFor(findFileForNode(edge.Source, nodesByFile), nodesByFile)
+	if heuristics.isGlobalCall(edge.Target) {
+		return nil
+	}
+	simpleName := edge.Target[strings.LastIndex(edge.Target, ".")+1:]
+	if strings.Contains(edge.Target, ".") && heuristics.isBuiltinMethodName(simpleName) {
+		return nil
+	}
+	key := normalizeIdentifier(simpleName)
+	if len(key) < minNormalizedNameLen {
+		return nil
+	}
+	matches := nodesByNormalizedName[key]
+	if len(matches) != 1 || matches[0].QualifiedName == edge.Source {
+		return nil
+	}
+	return &ResolvedEdge{
+		Source:       edge.Source,
+		Target:       matches[0].QualifiedName,
+		ResolvedPath: findFileForNode(matches[0].QualifiedName, nodesByFile),
+		Kind:         "calls",
+		Line:         edge.Line,
+		Confidence:   "low",
+	}
+}
+
+// minNormalizedNameLen keeps short names like "get" or "run", which match
+// something in every language, out of normalized matching.
+const minNormalizedNameLen = 5
+
+// normalizeIdentifier folds case and drops underscores and dashes, so
+// GetUser, getUser, get_user, and get-user all become "getuser".
+func normalizeIdentifier(name string) string {
+	var b strings.Builder
+	b.Grow(len(name))
+	for _, r := range name {
+		if r == '_' || r == '-' {
+			continue
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// resolveTableEdge points a uses_table edge at the table or view it names.
+// "users" and "public.users" refer to the same table unless two schemas both
+// define one, in which case only an exact match resolves. References to
+// tables defined outside the indexed SQL stay unresolved and are dropped.
+func resolveTableEdge(edge parsers.EdgeInfo, nodesByName map[string][]parsers.NodeInfo, nodesByFile map[string][]parsers.NodeInfo) *ResolvedEdge {
+	name := edge.Target[strings.LastIndex(edge.Target, ".")+1:]
+	var tables []parsers.NodeInfo
+	for _, node := range nodesByName[name] {
+		if node.Kind != "table" && node.Kind != "view" {
+			continue
+		}
+		if node.QualifiedName == edge.Target {
+			tables = []parsers.NodeInfo{node}
+			break
+		}
+		tables = append(tables, node)
+	}
+	if len(tables) != 1 {
+		return nil
+	}
+	return &ResolvedEdge{
+		Source:       edge.Source,
+		Target:       tables[0].QualifiedName,
+		ResolvedPath: findFileForNode(tables[0].QualifiedName, nodesByFile),
+		Kind:         "uses_table",
+		Line:         edge.Line,
+	}
+}
+
 // --- Helper functions ---
 
 func buildFileSet(allFiles []string) map[string]bool {
@@ -386,48 +958,131 @@ func buildFileSet(allFiles []string) map[string]bool {
 	return set
 }
 
-// buildNodesByFile maps file path → nodes in that file using contains edges.
-func buildNodesByFile(edges []parsers.EdgeInfo, nodes []parsers.NodeInfo) map[string][]parsers.NodeInfo {
-	nodeMap := make(map[string]parsers.NodeInfo)
+// nodeIndex holds the node lookups ResolveImports resolves against.
+type nodeIndex struct {
+	// byFile maps file path → nodes in that file, using contains edges
+	byFile map[string][]parsers.NodeInfo
+	byName map[string][]parsers.NodeInfo
+	// byNormalizedName indexes non-fixture callable nodes by
+	// normalizeIdentifier of their name, for resolveNormalizedCall
+	byNormalizedName map[string][]parsers.NodeInfo
+	// goFilesByDir is built by goFiles on first use after an add
+	goFilesByDir map[string][]string
+}
+
+func newNodeIndex() *nodeIndex {
+	return &nodeIndex{
+		byFile:           make(map[string][]parsers.NodeInfo),
+		byName:           make(map[string][]parsers.NodeInfo),
+		byNormalizedName: make(map[string][]parsers.NodeInfo),
+	}
+}
+
+// add indexes nodes, filing each under the file whose contains edge among
+// edges targets it. Calls can be spread over batches — one per shard — as
+// long as a node and its contains edge come in the same one.
+func (x *nodeIndex) add(nodes []parsers.NodeInfo, edges []parsers.EdgeInfo) {
+	nodeMap := make(map[string]parsers.NodeInfo, len(nodes))
 	for _, n := range nodes {
 		nodeMap[n.QualifiedName] = n
+		x.byName[n.Name] = append(x.byName[n.Name], n)
+		if parsers.KindGroup(n.Kind) == "callable" && !n.Fixture {
+			key := normalizeIdentifier(n.Name)
+			x.byNormalizedName[key] = append(x.byNormalizedName[key], n)
+		}
 	}
-
-	byFile := make(map[string][]parsers.NodeInfo)
 	for _, e := range edges {
 		if e.Kind == "contains" {
 			if n, ok := nodeMap[e.Target]; ok {
-				byFile[e.Source] = append(byFile[e.Source], n)
+				x.byFile[e.Source] = append(x.byFile[e.Source], n)
 			}
 		}
 	}
-	return byFile
+	x.goFilesByDir = nil
 }
 
-// buildImportedSymbolMap maps: file → (symbol name → import specifier).
-func buildImportedSymbolMap(edges []parsers.EdgeInfo) map[string]map[string]string {
-	result := make(map[string]map[string]string)
+// goFiles groups the Go files holding nodes by directory, that is by
+// package, in path order.
+func (x *nodeIndex) goFiles() map[string][]string {
+	if x.goFilesByDir != nil {
+		return x.goFilesByDir
+	}
+	x.goFilesByDir = make(map[string][]string)
+	for _, file := range slices.Sorted(maps.Keys(x.byFile)) {
+		if filepath.Ext(file) == ".go" {
+			dir := filepath.Dir(file)
+			x.goFilesByDir[dir] = append(x.goFilesByDir[dir], file)
+		}
+	}
+	return x.goFilesByDir
+}
+
+// importKey identifies one import specifier as written in one file.
+type importKey struct{ file, specifier string }
+
+// importedSymbol is where a locally bound import name comes from: the resolved
+// file (or the raw specifier when unresolved) and whether it is the default.
+type importedSymbol struct {
+	file      string
+	isDefault bool
+}
+
+// isExternalCall reports whether an unresolved call goes through a name the
+// caller's file imports from outside the index, such as axios.get() or
+// format() from date-fns. Those are kept as unresolved refs so usages of a
+// third-party API can be found; other unresolved calls (methods on local
+// values, missing functions) are dropped. Methods are filed under their
+// class, so the caller is traced up its contains edges to its file.
+func isExternalCall(edge parsers.EdgeInfo, nodesByFile map[string][]parsers.NodeInfo, importedSymbols map[string]map[string]importedSymbol) bool {
+	receiver, _, _ := strings.Cut(edge.Target, ".")
+	file := edge.Source
+	for range 3 {
+		if file = findFileForNode(file, nodesByFile); file == "" {
+			return false
+		}
+		if imports, ok := importedSymbols[file]; ok {
+			imp, found := imports[receiver]
+			return found && len(nodesByFile[imp.file]) == 0
+		}
+	}
+	return false
+}
+
+// buildImportedSymbolMap maps: file → (symbol name → imported symbol).
+func buildImportedSymbolMap(edges []parsers.EdgeInfo, resolvedImports map[importKey]string) map[string]map[string]importedSymbol {
+	result := make(map[string]map[string]importedSymbol)
 	for _, e := range edges {
 		if e.Kind != "imports" {
 			continue
 		}
 		if result[e.Source] == nil {
-			result[e.Source] = make(map[string]string)
+			result[e.Source] = make(map[string]importedSymbol)
+		}
+		file := e.Target
+		if path, ok := resolvedImports[importKey{e.Source, e.Target}]; ok {
+			file = path
 		}
 		for _, sym := range e.Symbols {
 			sym = strings.TrimPrefix(sym, "* as ")
-			result[e.Source][sym] = e.Target
+			result[e.Source][sym] = importedSymbol{file: file, isDefault: sym == e.DefaultImport}
 		}
 	}
 	return result
 }
 
-func buildNodesByName(nodes []parsers.NodeInfo) map[string][]parsers.NodeInfo {
-	byName := make(map[string][]parsers.NodeInfo)
+// withoutFixtures returns nodes minus the Fixture ones, reusing nodes when
+// there are none.
+func withoutFixtures(nodes []parsers.NodeInfo) []parsers.NodeInfo {
+	if !slices.ContainsFunc(nodes, func(n parsers.NodeInfo) bool { return n.Fixture }) {
+		return nodes
+	}
+	var real []parsers.NodeInfo
 	for _, n := range nodes {
-		byName[n.Name] = append(byName[n.Name], n)
+		if !n.Fixture {
+			real = append(real, n)
+		}
 	}
-	return byName
+	return real
 }
 
 func findFileForNode(qualifiedName string, nodesByFile map[string][]parsers.NodeInfo) string {
@@ -506,100 +1161,58 @@ func isGoStdlib(specifier string) bool {
 	return !strings.Contains(firstSegment, ".")
 }
 
-func isGlobalCall(name string) bool {
-	switch name {
-	case "console.log", "console.error", "console.warn", "console.info",
-		"JSON.stringify", "JSON.parse",
-		"Promise.resolve", "Promise.reject", "Promise.all",
-		"Math.round", "Math.floor", "Math.ceil", "Math.random",
-		"parseInt", "parseFloat", "setTimeout", "setInterval",
-		"clearTimeout", "clearInterval",
-		"require", "super",
-		"make", "len", "cap", "append", "copy", "delete", "close",
-		"panic", "recover", "new", "print", "println",
-		"fmt.Sprintf", "fmt.Printf", "fmt.Println", "fmt.Fprintf",
-		"fmt.Errorf":
-		return true
-	}
-	for _, prefix := range []string{"console.", "Math.", "Object.", "Array.", "String.", "Number.", "Promise."} {
-		if strings.HasPrefix(name, prefix) {
-			return true
-		}
-	}
-	return false
-}
-
-// isBuiltinMethodName returns true for method names that are common on JS/Go
-// built-in types (String, Array, Map, etc.). When a call like "obj.split()"
-// is extracted, we don't want tier-3 global resolution to match it to a
-// user-defined function named "split".
-var builtinMethodNames = map[string]bool{
-	// JS String methods
-	"split": true, "replace": true, "replaceAll": true, "match": true,
-	"trim": true, "trimStart": true, "trimEnd": true, "toLowerCase": true,
-	"toUpperCase": true, "startsWith": true, "endsWith": true, "includes": true,
-	"indexOf": true, "lastIndexOf": true, "slice": true, "substring": true,
-	"charAt": true, "charCodeAt": true, "padStart": true, "padEnd": true,
-	"repeat": true, "normalize": true, "search": true, "at": true,
-	// JS Array methods
-	"push": true, "pop": true, "shift": true, "unshift": true,
-	"map": true, "filter": true, "reduce": true, "reduceRight": true,
-	"find": true, "findIndex": true, "some": true, "every": true,
-	"forEach": true, "flat": true, "flatMap": true, "sort": true,
-	"reverse": true, "concat": true, "join": true, "fill": true,
-	"splice": true, "keys": true, "values": true, "entries": true,
-	// JS Object methods
-	"hasOwnProperty": true, "toString": true, "valueOf": true,
-	"toJSON": true, "toLocaleString": true,
-	// JS Promise methods
-	"then": true, "catch": true, "finally": true,
-	// JS Map/Set methods
-	"get": true, "set": true, "has": true, "clear": true, "add": true,
-	// JS Date methods
-	"getTime": true, "toISOString": true, "toDateString": true,
-	// JS common DOM/Node
-	"addEventListener": true, "removeEventListener": true,
-	"querySelector": true, "querySelectorAll": true,
-	"getAttribute": true, "setAttribute": true,
-	"createElement": true, "appendChild": true, "removeChild": true,
-	// Go common methods that shadow user functions
-	"Error": true, "String": true, "Close": true, "Read": true, "Write": true,
-	"Scan": true, "Next": true, "Err": true, "Rows": true,
-}
-
-func isBuiltinMethodName(name string) bool {
-	return builtinMethodNames[name]
-}
-
 // trackPackageDep records a package-level dependency based on file-level imports.
-func trackPackageDep(deps map[string]map[string]bool, sourceFile, targetFile, rootPath string) {
-	srcPkg := packageForFile(sourceFile)
-	tgtPkg := packageForFile(targetFile)
+func trackPackageDep(deps map[string]map[string]bool, packages packageGrouping, sourceFile, targetFile string, runtime bool) {
+	srcPkg := packages.packageFor(sourceFile)
+	tgtPkg := packages.packageFor(targetFile)
 	if srcPkg == tgtPkg || srcPkg == "" || tgtPkg == "" {
 		return
 	}
 	if deps[srcPkg] == nil {
 		deps[srcPkg] = make(map[string]bool)
 	}
-	deps[srcPkg][tgtPkg] = true
+	deps[srcPkg][tgtPkg] = deps[srcPkg][tgtPkg] || runtime
+}
+
+// RuntimeDependsOn drops depends_on edges backed only by type-only imports,
+// leaving the dependencies that exist after TypeScript erases types.
+func RuntimeDependsOn(edges []ResolvedEdge) []ResolvedEdge {
+	var out []ResolvedEdge
+	for _, e := range edges {
+		if !e.TypeOnly {
+			out = append(out, e)
+		}
+	}
+	return out
 }
 
-// packageForFile extracts the package directory from a file path.
+// packageGrouping decides which package a file belongs to for depends_on.
+type packageGrouping struct {
+	paths   []string // detected package directories, deepest first
+	markers []string
+}
+
+// packageFor returns the package directory of a file: the deepest detected
+// package containing it, or else a child of the first marker directory on
+// its path. With the default markers:
 // "packages/auth/src/validators.ts" → "packages/auth"
 // "apps/web/src/index.tsx" → "apps/web"
-func packageForFile(filePath string) string {
-	parts := strings.Split(filepath.ToSlash(filePath), "/")
+func (g packageGrouping) packageFor(filePath string) string {
+	filePath = filepath.ToSlash(filePath)
+	for _, p := range g.paths {
+		if strings.HasPrefix(filePath, p+"/") {
+			return p
+		}
+	}
+	parts := strings.Split(filePath, "/")
 	if len(parts) < 2 {
 		return ""
 	}
-	for i, part := range parts {
-		if (part == "packages" || part == "apps" || part == "libs" || part == "services") && i+1 < len(parts) {
-			return parts[i] + "/" + parts[i+1]
-		}
-	}
-	for i, part := range parts {
-		if (part == "internal" || part == "cmd" || part == "pkg") && i+1 < len(parts) {
-			return parts[i] + "/" + parts[i+1]
+	for _, marker := range g.markers {
+		for i, part := range parts[:len(parts)-1] {
+			if part == marker {
+				return parts[i] + "/" + parts[i+1]
+			}
 		}
 	}
 	return ""
@@ -1,8 +1,12 @@
 package indexer
 
 import (
+	"maps"
+	"slices"
 	"testing"
 
+	"github.com/maximilianfalco/mycelium/internal/config"
+	"github.com/maximilianfalco/mycelium/internal/indexer/detectors"
 	"github.com/maximilianfalco/mycelium/internal/indexer/parsers"
 )
 
@@ -113,6 +117,51 @@ func TestResolveImports_TSConfigPaths(t *testing.T) {
 	assertResolved(t, result.Resolved[1], "@components/Button", "src/components/Button.tsx")
 }
 
+func TestResolveImports_TSBaseURL(t *testing.T) {
+	allFiles := []string{
+		"utils/format.ts",
+		"src/index.ts",
+		"src/lib/http.ts",
+		"packages/web/src/app.ts",
+		"packages/web/src/lib/http.ts",
+		"components/button.ts",
+		"packages/web/src/components/button.ts",
+	}
+	baseURLs := map[string]string{
+		".":            ".",
+		"packages/web": "packages/web/src",
+	}
+	tsconfigPaths := map[string]string{"lib/*": "src/lib/*"}
+	rawEdges := []parsers.EdgeInfo{
+		{Source: "src/index.ts", Target: "utils/format", Kind: "imports", Line: 1},
+		{Source: "src/index.ts", Target: "react", Kind: "imports", Line: 2},
+		// tsconfig paths come before baseUrl
+		{Source: "packages/web/src/app.ts", Target: "lib/http", Kind: "imports", Line: 1},
+		// The deepest config's baseUrl applies
+		{Source: "packages/web/src/app.ts", Target: "components/button", Kind: "imports", Line: 2},
+	}
+
+	result := ResolveImports(rawEdges, nil, tsconfigPaths, nil, allFiles, "/root", WithTSBaseURLs(baseURLs))
+
+	got := make(map[string]string)
+	for _, r := range result.Resolved {
+		got[r.Source+" "+r.Target] = r.ResolvedPath
+	}
+	want := map[string]string{
+		"src/index.ts utils/format":                 "utils/format.ts",
+		"packages/web/src/app.ts lib/http":          "src/lib/http.ts",
+		"packages/web/src/app.ts components/button": "packages/web/src/components/button.ts",
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s: expected %q, got %q", k, v, got[k])
+		}
+	}
+	if len(result.Unresolved) != 1 || result.Unresolved[0].RawImport != "react" {
+		t.Errorf("expected only react unresolved, got %+v", result.Unresolved)
+	}
+}
+
 func TestResolveImports_NodeBuiltins(t *testing.T) {
 	rawEdges := []parsers.EdgeInfo{
 		{Source: "src/index.ts", Target: "fs", Kind: "imports", Line: 1},
@@ -126,8 +175,9 @@ func TestResolveImports_NodeBuiltins(t *testing.T) {
 	if len(result.Resolved) != 0 {
 		t.Errorf("expected 0 resolved (all builtins), got %d", len(result.Resolved))
 	}
-	if len(result.Unresolved) != 0 {
-		t.Errorf("expected 0 unresolved (builtins are skipped, not unresolved), got %d", len(result.Unresolved))
+	// Kept as builtin refs, so reachability queries can report them
+	if got := refKinds(result.Unresolved); !slices.Equal(got, []string{"builtin fs", "builtin node:path", "builtin crypto", "builtin fs/promises"}) {
+		t.Errorf("expected every builtin kept as a builtin ref, got %v", got)
 	}
 }
 
@@ -163,9 +213,18 @@ func TestResolveImports_GoStdlib(t *testing.T) {
 	if len(result.Resolved) != 0 {
 		t.Errorf("expected 0 resolved (Go stdlib), got %d", len(result.Resolved))
 	}
-	if len(result.Unresolved) != 0 {
-		t.Errorf("expected 0 unresolved (Go stdlib is skipped), got %d", len(result.Unresolved))
+	if got := refKinds(result.Unresolved); !slices.Equal(got, []string{"builtin fmt", "builtin net/http", "builtin context", "builtin encoding/json"}) {
+		t.Errorf("expected every stdlib import kept as a builtin ref, got %v", got)
+	}
+}
+
+// refKinds lists unresolved refs as "kind rawImport", in order.
+func refKinds(refs []UnresolvedRef) []string {
+	var out []string
+	for _, r := range refs {
+		out = append(out, r.Kind+" "+r.RawImport)
 	}
+	return out
 }
 
 func TestResolveImports_GoModuleImport(t *testing.T) {
@@ -285,6 +344,33 @@ func TestResolveImports_CallResolution_GlobalsSkipped(t *testing.T) {
 	}
 }
 
+func TestResolveImports_CallResolution_FixturesSkipped(t *testing.T) {
+	rawEdges := []parsers.EdgeInfo{
+		{Source: "src/handler.ts", Target: "handle", Kind: "contains", Line: 1},
+		{Source: "src/db.ts", Target: "queryUsers", Kind: "contains", Line: 1},
+		{Source: "src/__mocks__/db.ts", Target: "fetchOrders", Kind: "contains", Line: 1},
+		{Source: "handle", Target: "queryUsers", Kind: "calls", Line: 2},
+		{Source: "handle", Target: "fetchOrders", Kind: "calls", Line: 3},
+	}
+	nodes := []parsers.NodeInfo{
+		{Name: "handle", QualifiedName: "handle", Kind: "function"},
+		{Name: "queryUsers", QualifiedName: "queryUsers", Kind: "function"},
+		{Name: "fetchOrders", QualifiedName: "fetchOrders", Kind: "function", Fixture: true},
+	}
+
+	result := ResolveImports(rawEdges, nil, nil, nodes, []string{"src/handler.ts", "src/db.ts", "src/__mocks__/db.ts"}, "/root")
+
+	var targets []string
+	for _, r := range result.Resolved {
+		if r.Kind == "calls" {
+			targets = append(targets, r.Target)
+		}
+	}
+	if len(targets) != 1 || targets[0] != "queryUsers" {
+		t.Errorf("expected only the real function resolved by name, got %v", targets)
+	}
+}
+
 func TestResolveImports_MixedEdgeKinds(t *testing.T) {
 	aliasMap := map[string]string{
 		"@test/utils": "packages/utils/src/index.ts",
@@ -317,6 +403,41 @@ func TestResolveImports_MixedEdgeKinds(t *testing.T) {
 	}
 }
 
+func TestResolveImports_CallResolution_DefaultImport(t *testing.T) {
+	allFiles := []string{
+		"src/app.ts",
+		"src/components/Button.tsx",
+		"src/legacy/Button.ts",
+	}
+	nodes := []parsers.NodeInfo{
+		{Name: "App", QualifiedName: "App", Kind: "function"},
+		// export default function() {} in components/Button.tsx
+		{Name: "Button", QualifiedName: "Button.default", Kind: "function", DefaultExport: true},
+		// export function Button() {} elsewhere — same simple name, not the default
+		{Name: "Button", QualifiedName: "Button", Kind: "function"},
+	}
+	rawEdges := []parsers.EdgeInfo{
+		{Source: "src/app.ts", Target: "App", Kind: "contains", Line: 1},
+		{Source: "src/components/Button.tsx", Target: "Button.default", Kind: "contains", Line: 1},
+		{Source: "src/legacy/Button.ts", Target: "Button", Kind: "contains", Line: 1},
+		{Source: "src/app.ts", Target: "./components/Button", Kind: "imports", Line: 1, Symbols: []string{"Btn"}, DefaultImport: "Btn"},
+		{Source: "App", Target: "Btn", Kind: "calls", Line: 3},
+	}
+
+	result := ResolveImports(rawEdges, nil, nil, nodes, allFiles, "/root")
+
+	var calls []ResolvedEdge
+	for _, r := range result.Resolved {
+		if r.Kind == "calls" {
+			calls = append(calls, r)
+		}
+	}
+	if len(calls) != 1 {
+		t.Fatalf("expected 1 resolved call, got %+v", calls)
+	}
+	assertResolved(t, calls[0], "Button.default", "src/components/Button.tsx")
+}
+
 func TestIsNodeBuiltin(t *testing.T) {
 	tests := []struct {
 		input string
@@ -374,16 +495,70 @@ func TestPackageForFile(t *testing.T) {
 		{"pkg/utils/format.go", "pkg/utils"},
 		{"src/index.ts", ""},
 		{"index.ts", ""},
+		// Monorepo roots win over Go layout dirs
+		{"internal/tools/packages/lint/index.ts", "packages/lint"},
 	}
 
+	packages := packageGrouping{markers: config.DefaultPackageMarkerDirs}
 	for _, tt := range tests {
-		got := packageForFile(tt.input)
+		got := packages.packageFor(tt.input)
 		if got != tt.want {
-			t.Errorf("packageForFile(%q) = %q, want %q", tt.input, got, tt.want)
+			t.Errorf("packageFor(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestPackageForFile_Configured(t *testing.T) {
+	var cfg resolveConfig
+	WithPackageMarkerDirs([]string{"modules", "features"})(&cfg)
+	WithPackages([]detectors.PackageInfo{
+		{Name: "root", Path: "."},
+		{Name: "@acme/web", Path: "web"},
+		{Name: "@acme/web-admin", Path: "web/admin"},
+	})(&cfg)
+	packages := cfg.packages
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		// The deepest detected package wins, then the marker dirs
+		{"web/admin/src/app.ts", "web/admin"},
+		{"web/src/app.ts", "web"},
+		{"webapp/src/app.ts", ""},
+		{"modules/billing/invoice.ts", "modules/billing"},
+		{"src/features/cart/cart.ts", "features/cart"},
+		// Only the configured markers count
+		{"packages/auth/src/validators.ts", ""},
+		{"index.ts", ""},
+	}
+	for _, tt := range tests {
+		if got := packages.packageFor(tt.input); got != tt.want {
+			t.Errorf("packageFor(%q) = %q, want %q", tt.input, got, tt.want)
 		}
 	}
 }
 
+func TestResolveImports_DependsOnDetectedPackages(t *testing.T) {
+	rawEdges := []parsers.EdgeInfo{
+		{Source: "domains/orders/checkout.ts", Target: "../payments/charge", Kind: "imports", Line: 1, Symbols: []string{"charge"}},
+	}
+	files := []string{"domains/orders/checkout.ts", "domains/payments/charge.ts"}
+
+	// Neither the default markers nor anything else claims domains/
+	if result := ResolveImports(rawEdges, nil, nil, nil, files, "/root"); len(result.DependsOn) != 0 {
+		t.Fatalf("expected no depends_on without packages, got %+v", result.DependsOn)
+	}
+
+	result := ResolveImports(rawEdges, nil, nil, nil, files, "/root", WithPackages([]detectors.PackageInfo{
+		{Name: "orders", Path: "domains/orders"},
+		{Name: "payments", Path: "domains/payments"},
+	}))
+	if len(result.DependsOn) != 1 || result.DependsOn[0].Source != "domains/orders" || result.DependsOn[0].Target != "domains/payments" {
+		t.Errorf("expected domains/orders depends_on domains/payments, got %+v", result.DependsOn)
+	}
+}
+
 func TestResolveImports_SubpathImport(t *testing.T) {
 	aliasMap := map[string]string{
 		"@test/core": "packages/core/src/index.ts",
@@ -451,6 +626,165 @@ func TestResolveImports_CallResolution_DirectCallStillResolves(t *testing.T) {
 	}
 }
 
+func TestResolveImports_NormalizedCalls(t *testing.T) {
+	rawEdges := []parsers.EdgeInfo{
+		{Source: "web/client.ts", Target: "loadOrders", Kind: "contains", Line: 1},
+		{Source: "server/handlers.go", Target: "Authenticate", Kind: "contains", Line: 1},
+		{Source: "server/handlers.go", Target: "list_orders", Kind: "contains", Line: 10},
+		{Source: "server/handlers.go", Target: "GetUser", Kind: "contains", Line: 20},
+		{Source: "server/users.go", Target: "get_user", Kind: "contains", Line: 1},
+		{Source: "loadOrders", Target: "api.authenticate", Kind: "calls", Line: 2},
+		{Source: "loadOrders", Target: "api.listOrders", Kind: "calls", Line: 3},
+		// Ambiguous: GetUser and get_user both normalize to getuser
+		{Source: "loadOrders", Target: "api.getUser", Kind: "calls", Line: 4},
+		// Builtin method names never match
+		{Source: "loadOrders", Target: "orders.filter", Kind: "calls", Line: 5},
+	}
+	nodes := []parsers.NodeInfo{
+		{Name: "loadOrders", QualifiedName: "loadOrders", Kind: "function"},
+		{Name: "Authenticate", QualifiedName: "Authenticate", Kind: "function"},
+		{Name: "list_orders", QualifiedName: "list_orders", Kind: "function"},
+		{Name: "GetUser", QualifiedName: "GetUser", Kind: "function"},
+		{Name: "get_user", QualifiedName: "get_user", Kind: "function"},
+		{Name: "Filter", QualifiedName: "Filter", Kind: "function"},
+	}
+	files := []string{"web/client.ts", "server/handlers.go", "server/users.go"}
+
+	result := ResolveImports(rawEdges, nil, nil, nodes, files, "/root")
+
+	for _, r := range result.Resolved {
+		if r.Kind == "calls" {
+			t.Errorf("expected no exact call matches, got %s -> %s", r.Source, r.Target)
+		}
+	}
+	got := make(map[string]string)
+	for _, r := range result.NormalizedCalls {
+		if r.Confidence != "low" || r.Kind != "calls" {
+			t.Errorf("expected a low-confidence calls edge, got %+v", r)
+		}
+		got[r.Target] = r.ResolvedPath
+	}
+	want := map[string]string{"Authenticate": "server/handlers.go", "list_orders": "server/handlers.go"}
+	if !maps.Equal(got, want) {
+		t.Errorf("normalized calls = %v, want %v", got, want)
+	}
+}
+
+func TestResolveImports_References(t *testing.T) {
+	aliasMap := map[string]string{
+		"github.com/test/app":         ".",
+		"github.com/test/app/service": "service",
+	}
+	rawEdges := []parsers.EdgeInfo{
+		{Source: "src/app.ts", Target: "./transform", Kind: "imports", Line: 1, Symbols: []string{"transform"}},
+		{Source: "src/app.ts", Target: "./utils", Kind: "imports", Line: 2, Symbols: []string{"* as utils"}},
+		{Source: "src/app.ts", Target: "run", Kind: "contains", Line: 4},
+		{Source: "src/app.ts", Target: "Store", Kind: "contains", Line: 10},
+		{Source: "src/transform.ts", Target: "transform", Kind: "contains", Line: 1},
+		{Source: "src/utils.ts", Target: "format", Kind: "contains", Line: 1},
+		{Source: "run", Target: "transform", Kind: "references", Line: 5},
+		{Source: "run", Target: "utils.format", Kind: "references", Line: 6},
+		{Source: "run", Target: "Store", Kind: "references", Line: 7},
+
+		{Source: "cmd/routes.go", Target: "github.com/test/app/service", Kind: "imports", Line: 3, Symbols: []string{"svc (alias)"}},
+		{Source: "cmd/routes.go", Target: "routes", Kind: "contains", Line: 5},
+		{Source: "cmd/handlers.go", Target: "handleIndex", Kind: "contains", Line: 1},
+		{Source: "service/users.go", Target: "ListUsers", Kind: "contains", Line: 1},
+		{Source: "other/index.go", Target: "handleOther", Kind: "contains", Line: 1},
+		{Source: "routes", Target: "handleIndex", Kind: "references", Line: 6},
+		{Source: "routes", Target: "svc.ListUsers", Kind: "references", Line: 7},
+		// Same name, different package: never matched by a bare reference
+		{Source: "routes", Target: "handleOther", Kind: "references", Line: 8},
+	}
+	nodes := []parsers.NodeInfo{
+		{Name: "run", QualifiedName: "run", Kind: "function"},
+		{Name: "Store", QualifiedName: "Store", Kind: "class"},
+		{Name: "transform", QualifiedName: "transform", Kind: "function"},
+		{Name: "format", QualifiedName: "format", Kind: "function"},
+		{Name: "routes", QualifiedName: "routes", Kind: "function"},
+		{Name: "handleIndex", QualifiedName: "handleIndex", Kind: "function"},
+		{Name: "ListUsers", QualifiedName: "ListUsers", Kind: "function"},
+		{Name: "handleOther", QualifiedName: "handleOther", Kind: "function"},
+	}
+	files := []string{"src/app.ts", "src/transform.ts", "src/utils.ts", "cmd/routes.go", "cmd/handlers.go", "service/users.go", "other/index.go"}
+
+	result := ResolveImports(rawEdges, aliasMap, nil, nodes, files, "/root")
+
+	got := make(map[string]string)
+	for _, r := range result.Resolved {
+		if r.Kind == "references" {
+			got[r.Source+" -> "+r.Target] = r.ResolvedPath
+		}
+	}
+	want := map[string]string{
+		"run -> transform":      "src/transform.ts",
+		"run -> format":         "src/utils.ts",
+		"run -> Store":          "src/app.ts",
+		"routes -> handleIndex": "cmd/handlers.go",
+		"routes -> ListUsers":   "service/users.go",
+	}
+	if !maps.Equal(got, want) {
+		t.Errorf("references = %v, want %v", got, want)
+	}
+}
+
+func TestResolveImports_TypeUses(t *testing.T) {
+	aliasMap := map[string]string{
+		"github.com/test/shop":       ".",
+		"github.com/test/shop/store": "store",
+	}
+	rawEdges := []parsers.EdgeInfo{
+		{Source: "api/handler.go", Target: "github.com/test/shop/store", Kind: "imports", Line: 3},
+		{Source: "api/handler.go", Target: "context", Kind: "imports", Line: 4},
+		{Source: "api/handler.go", Target: "GetOrder", Kind: "contains", Line: 6},
+		{Source: "api/types.go", Target: "Response", Kind: "contains", Line: 1},
+		{Source: "store/order.go", Target: "Order", Kind: "contains", Line: 1},
+		{Source: "billing/order.go", Target: "Order", Kind: "contains", Line: 1},
+		{Source: "GetOrder", Target: "store.Order", Kind: "uses_type", Line: 6},
+		{Source: "GetOrder", Target: "Response", Kind: "uses_type", Line: 6},
+		{Source: "GetOrder", Target: "context.Context", Kind: "uses_type", Line: 6},
+
+		{Source: "src/api.ts", Target: "./models", Kind: "imports", Line: 1, Symbols: []string{"User"}, TypeOnly: true},
+		{Source: "src/api.ts", Target: "fetchUser", Kind: "contains", Line: 3},
+		{Source: "src/models.ts", Target: "User", Kind: "contains", Line: 1},
+		{Source: "fetchUser", Target: "User", Kind: "uses_type", Line: 3},
+	}
+	nodes := []parsers.NodeInfo{
+		{Name: "GetOrder", QualifiedName: "GetOrder", Kind: "function"},
+		{Name: "Response", QualifiedName: "Response", Kind: "struct"},
+		{Name: "Order", QualifiedName: "Order", Kind: "struct"},
+		{Name: "Order", QualifiedName: "Order", Kind: "struct"},
+		{Name: "fetchUser", QualifiedName: "fetchUser", Kind: "function"},
+		{Name: "User", QualifiedName: "User", Kind: "interface"},
+	}
+	files := []string{"api/handler.go", "api/types.go", "store/order.go", "billing/order.go", "src/api.ts", "src/models.ts"}
+
+	result := ResolveImports(rawEdges, aliasMap, nil, nodes, files, "/root")
+
+	got := make(map[string]string)
+	for _, r := range result.Resolved {
+		if r.Kind == "uses_type" {
+			got[r.Source+" -> "+r.Target] = r.ResolvedPath
+		}
+	}
+	want := map[string]string{
+		"GetOrder -> Order":    "store/order.go",
+		"GetOrder -> Response": "api/types.go",
+		"fetchUser -> User":    "src/models.ts",
+	}
+	if !maps.Equal(got, want) {
+		t.Errorf("uses_type = %v, want %v", got, want)
+	}
+}
+
+func TestNormalizeIdentifier(t *testing.T) {
+	for _, name := range []string{"GetUser", "getUser", "get_user", "get-user", "GET_USER"} {
+		if got := normalizeIdentifier(name); got != "getuser" {
+			t.Errorf("normalizeIdentifier(%q) = %q, want getuser", name, got)
+		}
+	}
+}
+
 // --- Helpers ---
 
 func assertResolved(t *testing.T, edge ResolvedEdge, expectedTarget, expectedResolvedPath string) {
@@ -462,3 +796,391 @@ func assertResolved(t *testing.T, edge ResolvedEdge, expectedTarget, expectedRes
 		t.Errorf("expected resolvedPath %q, got %q (target: %q)", expectedResolvedPath, edge.ResolvedPath, edge.Target)
 	}
 }
+
+func TestResolveImports_AliasMapSubpathEntries(t *testing.T) {
+	aliasMap := map[string]string{
+		"@test/auth":         "packages/auth/src/index.ts",
+		"@test/auth/server":  "packages/auth/src/server.ts",
+		"@test/auth/utils/*": "packages/auth/src/utils/*.js",
+	}
+	allFiles := []string{
+		"packages/auth/src/index.ts",
+		"packages/auth/src/server.ts",
+		"packages/auth/src/server/routes.ts",
+		"packages/auth/src/utils/hash.ts",
+		"apps/web/src/index.ts",
+	}
+	rawEdges := []parsers.EdgeInfo{
+		{Source: "apps/web/src/index.ts", Target: "@test/auth", Kind: "imports", Line: 1},
+		{Source: "apps/web/src/index.ts", Target: "@test/auth/server", Kind: "imports", Line: 2},
+		{Source: "apps/web/src/index.ts", Target: "@test/auth/utils/hash", Kind: "imports", Line: 3},
+		{Source: "apps/web/src/index.ts", Target: "@test/auth/server/routes", Kind: "imports", Line: 4},
+	}
+
+	result := ResolveImports(rawEdges, aliasMap, nil, nil, allFiles, "/root")
+
+	if len(result.Resolved) != 4 {
+		t.Fatalf("expected 4 resolved edges, got %d (unresolved: %+v)", len(result.Resolved), result.Unresolved)
+	}
+	assertResolved(t, result.Resolved[0], "@test/auth", "packages/auth/src/index.ts")
+	assertResolved(t, result.Resolved[1], "@test/auth/server", "packages/auth/src/server.ts")
+	assertResolved(t, result.Resolved[2], "@test/auth/utils/hash", "packages/auth/src/utils/hash.ts")
+	assertResolved(t, result.Resolved[3], "@test/auth/server/routes", "packages/auth/src/server/routes.ts")
+}
+
+func TestResolveImports_TypeOnlyDependsOn(t *testing.T) {
+	aliasMap := map[string]string{
+		"@test/utils": "packages/utils/src/index.ts",
+		"@test/core":  "packages/core/src/index.ts",
+	}
+	allFiles := []string{
+		"packages/utils/src/index.ts",
+		"packages/core/src/index.ts",
+		"apps/web/src/index.tsx",
+		"apps/web/src/page.tsx",
+	}
+	rawEdges := []parsers.EdgeInfo{
+		{Source: "apps/web/src/index.tsx", Target: "@test/core", Kind: "imports", Line: 1, TypeOnly: true},
+		{Source: "apps/web/src/index.tsx", Target: "@test/utils", Kind: "imports", Line: 2, TypeOnly: true},
+		{Source: "apps/web/src/page.tsx", Target: "@test/utils", Kind: "imports", Line: 1},
+	}
+
+	result := ResolveImports(rawEdges, aliasMap, nil, nil, allFiles, "/root")
+
+	if !result.Resolved[0].TypeOnly || result.Resolved[2].TypeOnly {
+		t.Errorf("expected TypeOnly to carry over to resolved edges, got %+v", result.Resolved)
+	}
+
+	typeOnly := map[string]bool{}
+	for _, dep := range result.DependsOn {
+		typeOnly[dep.Target] = dep.TypeOnly
+	}
+	if !typeOnly["packages/core"] {
+		t.Error("expected apps/web → packages/core to be type-only")
+	}
+	if typeOnly["packages/utils"] {
+		t.Error("expected apps/web → packages/utils to be runtime (page.tsx imports it at runtime)")
+	}
+
+	runtime := RuntimeDependsOn(result.DependsOn)
+	if len(runtime) != 1 || runtime[0].Target != "packages/utils" {
+		t.Errorf("expected only packages/utils after dropping type-only deps, got %+v", runtime)
+	}
+}
+
+func TestResolveImports_DependsOnThroughBarrels(t *testing.T) {
+	aliasMap := map[string]string{
+		"@test/kit":     "packages/kit/src/index.ts",
+		"@test/buttons": "packages/buttons/src/index.ts",
+		"@test/icons":   "packages/icons/src/index.ts",
+	}
+	allFiles := []string{
+		"packages/kit/src/index.ts",
+		"packages/buttons/src/index.ts",
+		"packages/buttons/src/button.ts",
+		"packages/icons/src/index.ts",
+		"packages/icons/src/icon.ts",
+		"apps/web/src/page.tsx",
+		"apps/admin/src/page.tsx",
+		"apps/docs/src/page.tsx",
+	}
+	nodes := []parsers.NodeInfo{
+		{Name: "Button", QualifiedName: "Button", Kind: "function"},
+		{Name: "Icon", QualifiedName: "Icon", Kind: "function"},
+	}
+	rawEdges := []parsers.EdgeInfo{
+		{Source: "packages/buttons/src/button.ts", Target: "Button", Kind: "contains"},
+		{Source: "packages/icons/src/icon.ts", Target: "Icon", Kind: "contains"},
+		// kit is a pure barrel over the other two packages
+		{Source: "packages/kit/src/index.ts", Target: "@test/buttons", Kind: "re_exports", Line: 1, Symbols: []string{"Button"}},
+		{Source: "packages/kit/src/index.ts", Target: "@test/icons", Kind: "re_exports", Line: 2},
+		{Source: "packages/buttons/src/index.ts", Target: "./button", Kind: "re_exports", Line: 1},
+		{Source: "packages/icons/src/index.ts", Target: "./icon", Kind: "re_exports", Line: 1, Symbols: []string{"Icon as Glyph"}},
+		{Source: "apps/web/src/page.tsx", Target: "@test/kit", Kind: "imports", Line: 1, Symbols: []string{"Button"}},
+		{Source: "apps/admin/src/page.tsx", Target: "@test/kit", Kind: "imports", Line: 1, Symbols: []string{"Glyph"}},
+		{Source: "apps/docs/src/page.tsx", Target: "@test/kit", Kind: "imports", Line: 1, Symbols: []string{"Missing"}},
+	}
+
+	result := ResolveImports(rawEdges, aliasMap, nil, nodes, allFiles, "/root")
+
+	reExports := 0
+	for _, e := range result.Resolved {
+		if e.Kind == "re_exports" {
+			reExports++
+		}
+	}
+	if reExports != 4 {
+		t.Errorf("expected 4 resolved re_exports edges, got %d", reExports)
+	}
+
+	deps := func(edges []ResolvedEdge) []string {
+		var out []string
+		for _, e := range edges {
+			out = append(out, e.Source+" -> "+e.Target)
+		}
+		slices.Sort(out)
+		return out
+	}
+
+	barrel := []string{
+		"apps/admin -> packages/kit",
+		"apps/docs -> packages/kit",
+		"apps/web -> packages/kit",
+		"packages/kit -> packages/buttons",
+		"packages/kit -> packages/icons",
+	}
+	if got := deps(result.DependsOn); !slices.Equal(got, barrel) {
+		t.Errorf("DependsOn = %v, want %v", got, barrel)
+	}
+
+	// Each import lands on the package defining the symbol; one the barrels
+	// don't account for stays on the barrel
+	through := []string{
+		"apps/admin -> packages/icons",
+		"apps/docs -> packages/kit",
+		"apps/web -> packages/buttons",
+		"packages/kit -> packages/buttons",
+		"packages/kit -> packages/icons",
+	}
+	if got := deps(result.DependsOnThroughBarrels); !slices.Equal(got, through) {
+		t.Errorf("DependsOnThroughBarrels = %v, want %v", got, through)
+	}
+}
+
+func TestFollowReExport_Cycle(t *testing.T) {
+	reExports := map[string][]reExport{
+		"a/index.ts": {{file: "b/index.ts"}},
+		"b/index.ts": {{file: "a/index.ts"}},
+	}
+	if file, ok := followReExport("a/index.ts", "X", reExports, nil, make(map[string]bool)); ok {
+		t.Errorf("expected a re-export cycle to resolve nowhere, got %q", file)
+	}
+}
+
+func TestResolveImports_Recursion(t *testing.T) {
+	sources := map[string]string{
+		"src/tree.ts": `function fact(n: number): number {
+  return n <= 1 ? 1 : n * fact(n - 1);
+}
+
+class Tree {
+  walk(node: Node): void {
+    this.walk(node.left);
+    fact(3);
+  }
+}`,
+		"pkg/tree.go": `package tree
+
+func Fib(n int) int {
+	if n < 2 {
+		return n
+	}
+	return Fib(n-1) + Fib(n-2)
+}
+
+type Walker struct{}
+
+func (w *Walker) Visit(n int) {
+	w.Visit(n - 1)
+	Fib(n)
+}`,
+	}
+
+	var nodes []parsers.NodeInfo
+	var edges []parsers.EdgeInfo
+	var files []string
+	for path, src := range sources {
+		pr, err := parsers.ParseFile(path, []byte(src))
+		if err != nil {
+			t.Fatalf("parsing %s: %v", path, err)
+		}
+		nodes = append(nodes, pr.Nodes...)
+		edges = append(edges, pr.Edges...)
+		files = append(files, path)
+	}
+
+	result := ResolveImports(edges, nil, nil, nodes, files, "/root")
+
+	kinds := make(map[string]string)
+	for _, r := range result.Resolved {
+		if r.Kind == "calls" || r.Kind == "recurses" {
+			kinds[r.Source+"->"+r.Target] = r.Kind
+		}
+	}
+
+	for _, self := range []string{"fact", "Tree.walk", "Fib", "Walker.Visit"} {
+		if got := kinds[self+"->"+self]; got != "recurses" {
+			t.Errorf("expected %s self-call tagged recurses, got %q", self, got)
+		}
+	}
+	for _, call := range []string{"Tree.walk->fact", "Walker.Visit->Fib"} {
+		if got := kinds[call]; got != "calls" {
+			t.Errorf("expected %s to stay a calls edge, got %q", call, got)
+		}
+	}
+}
+
+func TestResolveImports_TableReferences(t *testing.T) {
+	parsers.SetSQLEnabled(true)
+	defer parsers.SetSQLEnabled(false)
+
+	sources := map[string]string{
+		"db/schema.sql": "CREATE TABLE users (id INT);\nCREATE TABLE billing.invoices (id INT);\nCREATE TABLE audit.invoices (id INT);\n",
+		"db/queries.sql": `-- name: GetUser :one
+SELECT * FROM public.users WHERE id = $1;
+
+-- name: ListInvoices :many
+SELECT * FROM billing.invoices JOIN invoices ON true JOIN stripe_events ON true;
+`,
+	}
+
+	var nodes []parsers.NodeInfo
+	var edges []parsers.EdgeInfo
+	var files []string
+	for path, src := range sources {
+		pr, err := parsers.ParseFile(path, []byte(src))
+		if err != nil {
+			t.Fatalf("parsing %s: %v", path, err)
+		}
+		nodes = append(nodes, pr.Nodes...)
+		edges = append(edges, pr.Edges...)
+		files = append(files, path)
+	}
+
+	result := ResolveImports(edges, nil, nil, nodes, files, "/root")
+
+	var got []string
+	for _, r := range result.Resolved {
+		if r.Kind == "uses_table" {
+			got = append(got, r.Source+"->"+r.Target)
+			if r.ResolvedPath != "db/schema.sql" {
+				t.Errorf("%s->%s: expected schema.sql, got %q", r.Source, r.Target, r.ResolvedPath)
+			}
+		}
+	}
+	// Unqualified invoices is ambiguous between schemas; stripe_events isn't indexed
+	want := []string{"GetUser->users", "ListInvoices->billing.invoices"}
+	slices.Sort(got)
+	if !slices.Equal(got, want) {
+		t.Errorf("resolved uses_table %v, want %v", got, want)
+	}
+}
+
+func TestResolveImports_Renders(t *testing.T) {
+	rawEdges := []parsers.EdgeInfo{
+		{Source: "src/UserCard.vue", Target: "./UserAvatar.vue", Kind: "imports", Line: 13, Symbols: []string{"Avatar"}, DefaultImport: "Avatar"},
+		{Source: "src/UserCard.vue", Target: "UserCard", Kind: "contains", Line: 1},
+		{Source: "src/UserAvatar.vue", Target: "UserAvatar", Kind: "contains", Line: 1},
+		{Source: "src/Badge.vue", Target: "Badge", Kind: "contains", Line: 1},
+		// Bound through the default import, whatever its local name
+		{Source: "UserCard", Target: "Avatar", Kind: "renders", Line: 4},
+		// Globally registered: found by the unambiguous name search
+		{Source: "UserCard", Target: "Badge", Kind: "renders", Line: 5},
+		{Source: "UserCard", Target: "RouterLink", Kind: "renders", Line: 6},
+	}
+	nodes := []parsers.NodeInfo{
+		{Name: "UserCard", QualifiedName: "UserCard", Kind: "component", DefaultExport: true},
+		{Name: "UserAvatar", QualifiedName: "UserAvatar", Kind: "component", DefaultExport: true},
+		{Name: "Badge", QualifiedName: "Badge", Kind: "component", DefaultExport: true},
+	}
+	files := []string{"src/UserCard.vue", "src/UserAvatar.vue", "src/Badge.vue"}
+
+	result := ResolveImports(rawEdges, nil, nil, nodes, files, "/root")
+
+	got := make(map[string]string)
+	for _, r := range result.Resolved {
+		if r.Kind == "renders" {
+			got[r.Source+" -> "+r.Target] = r.ResolvedPath
+		}
+	}
+	want := map[string]string{
+		"UserCard -> UserAvatar": "src/UserAvatar.vue",
+		"UserCard -> Badge":      "src/Badge.vue",
+	}
+	if !maps.Equal(got, want) {
+		t.Errorf("renders = %v, want %v", got, want)
+	}
+}
+
+func TestResolveImports_Handles(t *testing.T) {
+	rawEdges := []parsers.EdgeInfo{
+		{Source: "src/server.ts", Target: "./handlers", Kind: "imports", Line: 1, Symbols: []string{"listUsers"}},
+		{Source: "src/server.ts", Target: "src/server.ts:GET /users", Kind: "contains", Line: 4},
+		{Source: "src/server.ts", Target: "src/server.ts:GET /health", Kind: "contains", Line: 5},
+		{Source: "src/server.ts", Target: "health", Kind: "contains", Line: 7},
+		{Source: "src/handlers.ts", Target: "listUsers", Kind: "contains", Line: 1},
+		{Source: "src/server.ts:GET /users", Target: "listUsers", Kind: "handles", Line: 4},
+		{Source: "src/server.ts:GET /health", Target: "health", Kind: "handles", Line: 5},
+		{Source: "src/server.ts:GET /missing", Target: "missing", Kind: "handles", Line: 6},
+		// A second router registering the same route
+		{Source: "src/admin.ts", Target: "src/admin.ts:GET /health", Kind: "contains", Line: 2},
+		{Source: "src/admin.ts", Target: "adminHealth", Kind: "contains", Line: 4},
+		{Source: "src/admin.ts:GET /health", Target: "adminHealth", Kind: "handles", Line: 2},
+	}
+	nodes := []parsers.NodeInfo{
+		{Name: "GET /users", QualifiedName: "src/server.ts:GET /users", Kind: "endpoint"},
+		{Name: "GET /health", QualifiedName: "src/server.ts:GET /health", Kind: "endpoint"},
+		{Name: "health", QualifiedName: "health", Kind: "function"},
+		{Name: "listUsers", QualifiedName: "listUsers", Kind: "function", Exported: true},
+		{Name: "GET /health", QualifiedName: "src/admin.ts:GET /health", Kind: "endpoint"},
+		{Name: "adminHealth", QualifiedName: "adminHealth", Kind: "function"},
+	}
+	files := []string{"src/server.ts", "src/handlers.ts", "src/admin.ts"}
+
+	result := ResolveImports(rawEdges, nil, nil, nodes, files, "/root")
+
+	got := make(map[string]string)
+	for _, r := range result.Resolved {
+		if r.Kind == "handles" {
+			got[r.Source+" -> "+r.Target] = r.ResolvedPath
+		}
+	}
+	want := map[string]string{
+		"src/server.ts:GET /users -> listUsers":   "src/handlers.ts",
+		"src/server.ts:GET /health -> health":     "src/server.ts",
+		"src/admin.ts:GET /health -> adminHealth": "src/admin.ts",
+	}
+	if !maps.Equal(got, want) {
+		t.Errorf("handles = %v, want %v", got, want)
+	}
+}
+
+func TestResolveImports_ExternalCalls(t *testing.T) {
+	rawEdges := []parsers.EdgeInfo{
+		{Source: "src/api.ts", Target: "axios", Kind: "imports", Line: 1, Symbols: []string{"axios"}, DefaultImport: "axios"},
+		{Source: "src/api.ts", Target: "date-fns", Kind: "imports", Line: 2, Symbols: []string{"format"}},
+		{Source: "src/api.ts", Target: "./util", Kind: "imports", Line: 3, Symbols: []string{"helper"}},
+		{Source: "src/api.ts", Target: "fetchUser", Kind: "contains", Line: 5},
+		{Source: "src/api.ts", Target: "Client", Kind: "contains", Line: 12},
+		{Source: "Client", Target: "Client.load", Kind: "contains", Line: 13},
+		{Source: "src/util.ts", Target: "helper", Kind: "contains", Line: 1},
+		{Source: "fetchUser", Target: "axios.get", Kind: "calls", Line: 6},
+		{Source: "fetchUser", Target: "format", Kind: "calls", Line: 7},
+		{Source: "fetchUser", Target: "helper", Kind: "calls", Line: 8},
+		// Not imported: dropped as before
+		{Source: "fetchUser", Target: "user.save", Kind: "calls", Line: 9},
+		{Source: "fetchUser", Target: "console.log", Kind: "calls", Line: 10},
+		// Methods find their file through their class
+		{Source: "Client.load", Target: "axios.post", Kind: "calls", Line: 14},
+	}
+	nodes := []parsers.NodeInfo{
+		{Name: "fetchUser", QualifiedName: "fetchUser", Kind: "function"},
+		{Name: "Client", QualifiedName: "Client", Kind: "class"},
+		{Name: "load", QualifiedName: "Client.load", Kind: "method"},
+		{Name: "helper", QualifiedName: "helper", Kind: "function"},
+	}
+	files := []string{"src/api.ts", "src/util.ts"}
+
+	result := ResolveImports(rawEdges, nil, nil, nodes, files, "/root")
+
+	var got []string
+	for _, u := range result.Unresolved {
+		if u.Kind == "calls" {
+			got = append(got, u.Source+" -> "+u.RawImport)
+		}
+	}
+	want := []string{"fetchUser -> axios.get", "fetchUser -> format", "Client.load -> axios.post"}
+	if !slices.Equal(got, want) {
+		t.Errorf("unresolved calls = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,122 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// manifestNodeEntrySQL and manifestEdgeEntrySQL render one node or edge of a
+// file for its content hash. Migration 021 hashes converted manifests the
+// same way, so unchanged files keep sharing rows across the upgrade.
+const (
+	manifestNodeEntrySQL = `COALESCE(n.qualified_name, n.name) || E'\t' || n.kind || E'\t' || COALESCE(n.body_hash, '')`
+	manifestEdgeEntrySQL = `COALESCE(s.qualified_name, s.name) || E'\t' || COALESCE(t.qualified_name, t.name) || E'\t' || e.kind`
+)
+
+// DefaultManifestRetention keeps the manifests of the last 20 indexed
+// commits per source: enough to diff recent runs without keeping a graph
+// snapshot of every commit forever.
+const DefaultManifestRetention = 20
+
+// RecordManifest snapshots the current graph of a source's workspace as the
+// manifest for commit: for each file, a hash of its nodes' qualified names,
+// kinds, and body hashes plus its nodes' outgoing edges by qualified name.
+// The nodes and edges themselves are stored once per distinct hash and
+// shared by every manifest holding that file content, so a commit costs one
+// row per file plus the rows of the files it changed. Re-recording a commit
+// replaces it. Then only the newest retention manifests of the source are
+// kept: 0 means DefaultManifestRetention, and less than 0 keeps them all.
+func RecordManifest(ctx context.Context, pool *pgxpool.Pool, sourceID, workspaceID, commit string, retention int) error {
+	if retention == 0 {
+		retention = DefaultManifestRetention
+	}
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("beginning manifest transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM index_manifests WHERE source_id = $1 AND commit_sha = $2`, sourceID, commit); err != nil {
+		return fmt.Errorf("clearing manifest: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `INSERT INTO index_manifests (source_id, commit_sha) VALUES ($1, $2)`, sourceID, commit); err != nil {
+		return fmt.Errorf("inserting manifest: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO manifest_files (source_id, commit_sha, file_path, content_hash)
+		SELECT $1, $2, fn.file_path, md5(fn.entries || E'\n\n' || COALESCE(fe.entries, ''))
+		FROM (
+			SELECT n.file_path, string_agg(DISTINCT `+manifestNodeEntrySQL+`, E'\n' ORDER BY `+manifestNodeEntrySQL+`) AS entries
+			FROM nodes n
+			WHERE n.workspace_id = $3
+			GROUP BY n.file_path
+		) fn
+		LEFT JOIN (
+			SELECT s.file_path, string_agg(DISTINCT `+manifestEdgeEntrySQL+`, E'\n' ORDER BY `+manifestEdgeEntrySQL+`) AS entries
+			FROM edges e
+			JOIN nodes s ON e.source_id = s.id
+			JOIN nodes t ON e.target_id = t.id
+			WHERE s.workspace_id = $3
+			GROUP BY s.file_path
+		) fe ON fe.file_path = fn.file_path`, sourceID, commit, workspaceID); err != nil {
+		return fmt.Errorf("hashing manifest files: %w", err)
+	}
+
+	// Only content no earlier manifest stored is copied. Every stored content
+	// has at least one node, so manifest_nodes tells; edges go first, before
+	// this run's nodes make their content look stored.
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO manifest_edges (source_id, content_hash, source_qname, target_qname, kind)
+		SELECT $1, mf.content_hash, COALESCE(s.qualified_name, s.name), COALESCE(t.qualified_name, t.name), e.kind
+		FROM edges e
+		JOIN nodes s ON e.source_id = s.id
+		JOIN nodes t ON e.target_id = t.id
+		JOIN manifest_files mf ON mf.source_id = $1 AND mf.commit_sha = $2 AND mf.file_path = s.file_path
+		WHERE s.workspace_id = $3
+		  AND NOT EXISTS (SELECT 1 FROM manifest_nodes mn WHERE mn.source_id = $1 AND mn.content_hash = mf.content_hash)
+		ON CONFLICT DO NOTHING`, sourceID, commit, workspaceID); err != nil {
+		return fmt.Errorf("snapshotting manifest edges: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO manifest_nodes (source_id, content_hash, qualified_name, kind, body_hash)
+		SELECT $1, mf.content_hash, COALESCE(n.qualified_name, n.name), n.kind, n.body_hash
+		FROM nodes n
+		JOIN manifest_files mf ON mf.source_id = $1 AND mf.commit_sha = $2 AND mf.file_path = n.file_path
+		WHERE n.workspace_id = $3
+		  AND NOT EXISTS (SELECT 1 FROM manifest_nodes mn WHERE mn.source_id = $1 AND mn.content_hash = mf.content_hash)
+		ON CONFLICT DO NOTHING`, sourceID, commit, workspaceID); err != nil {
+		return fmt.Errorf("snapshotting manifest nodes: %w", err)
+	}
+
+	if retention > 0 {
+		if _, err := tx.Exec(ctx, `
+			DELETE FROM index_manifests
+			WHERE source_id = $1 AND commit_sha NOT IN (
+				SELECT commit_sha FROM index_manifests
+				WHERE source_id = $1
+				ORDER BY recorded_at DESC
+				LIMIT $2
+			)`, sourceID, retention); err != nil {
+			return fmt.Errorf("pruning old manifests: %w", err)
+		}
+	}
+	// Drop content no remaining manifest has a file with
+	for _, table := range []string{"manifest_nodes", "manifest_edges"} {
+		if _, err := tx.Exec(ctx, `
+			DELETE FROM `+table+` c
+			WHERE c.source_id = $1 AND NOT EXISTS (
+				SELECT 1 FROM manifest_files mf WHERE mf.source_id = $1 AND mf.content_hash = c.content_hash
+			)`, sourceID); err != nil {
+			return fmt.Errorf("pruning unreferenced %s: %w", table, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("committing manifest: %w", err)
+	}
+	return nil
+}
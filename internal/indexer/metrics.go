@@ -0,0 +1,66 @@
+package indexer
+
+import (
+	"sync"
+	"time"
+)
+
+// Metrics receives time-series measurements from the indexing pipeline.
+// Implementations adapt them to a backend — e.g. Prometheus counters and
+// histograms — so the indexer itself never imports a metrics library.
+// Methods may be called concurrently and must not block.
+type Metrics interface {
+	// FilesParsed counts files parsed successfully.
+	FilesParsed(n int)
+	// ParseErrors counts files that could not be read or parsed.
+	ParseErrors(n int)
+	// NodesEmbedded counts nodes sent to the embedding API.
+	NodesEmbedded(n int)
+	// TokensEmbedded counts input tokens sent to the embedding API.
+	TokensEmbedded(n int)
+	// EmbeddingLatency observes one embeddings API call, retries included.
+	EmbeddingLatency(d time.Duration)
+	// EdgesResolved counts resolved vs. unresolved edges after import resolution.
+	EdgesResolved(resolved, unresolved int)
+	// StageDuration observes how long one pipeline stage took for one source.
+	StageDuration(stage string, d time.Duration)
+}
+
+// NopMetrics discards everything. It is the default until SetMetrics is called.
+type NopMetrics struct{}
+
+func (NopMetrics) FilesParsed(int)                     {}
+func (NopMetrics) ParseErrors(int)                     {}
+func (NopMetrics) NodesEmbedded(int)                   {}
+func (NopMetrics) TokensEmbedded(int)                  {}
+func (NopMetrics) EmbeddingLatency(time.Duration)      {}
+func (NopMetrics) EdgesResolved(int, int)              {}
+func (NopMetrics) StageDuration(string, time.Duration) {}
+
+var (
+	metricsMu sync.RWMutex
+	metricsV  Metrics = NopMetrics{}
+)
+
+// SetMetrics installs the sink that pipeline measurements are reported to.
+// Passing nil restores NopMetrics.
+func SetMetrics(m Metrics) {
+	if m == nil {
+		m = NopMetrics{}
+	}
+	metricsMu.Lock()
+	defer metricsMu.Unlock()
+	metricsV = m
+}
+
+func metrics() Metrics {
+	metricsMu.RLock()
+	defer metricsMu.RUnlock()
+	return metricsV
+}
+
+// timeStage starts timing a pipeline stage; call the returned func when it ends.
+func timeStage(stage string) func() {
+	start := time.Now()
+	return func() { metrics().StageDuration(stage, time.Since(start)) }
+}
@@ -0,0 +1,79 @@
+package indexer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordingMetrics struct {
+	NopMetrics
+	mu          sync.Mutex
+	filesParsed int
+	parseErrors int
+	stages      []string
+}
+
+func (m *recordingMetrics) FilesParsed(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.filesParsed += n
+}
+
+func (m *recordingMetrics) ParseErrors(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.parseErrors += n
+}
+
+func (m *recordingMetrics) StageDuration(stage string, _ time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stages = append(m.stages, stage)
+}
+
+func TestParseFiles_EmitsMetrics(t *testing.T) {
+	rec := &recordingMetrics{}
+	SetMetrics(rec)
+	t.Cleanup(func() { SetMetrics(nil) })
+
+	dir := t.TempDir()
+	good := filepath.Join(dir, "a.ts")
+	os.WriteFile(good, []byte("export function a() { return 1 }\n"), 0o644)
+
+	files := []FileInfo{
+		{AbsPath: good, RelPath: "a.ts"},
+		{AbsPath: filepath.Join(dir, "missing.ts"), RelPath: "missing.ts"},
+	}
+	parseFiles(context.Background(), files, dir, nil)
+
+	if rec.filesParsed != 1 {
+		t.Errorf("expected 1 file parsed, got %d", rec.filesParsed)
+	}
+	if rec.parseErrors != 1 {
+		t.Errorf("expected 1 parse error, got %d", rec.parseErrors)
+	}
+}
+
+func TestTimeStage(t *testing.T) {
+	rec := &recordingMetrics{}
+	SetMetrics(rec)
+	t.Cleanup(func() { SetMetrics(nil) })
+
+	done := timeStage("parsing")
+	done()
+
+	if len(rec.stages) != 1 || rec.stages[0] != "parsing" {
+		t.Errorf("expected one parsing stage observation, got %v", rec.stages)
+	}
+}
+
+func TestSetMetrics_NilRestoresNop(t *testing.T) {
+	SetMetrics(nil)
+	if _, ok := metrics().(NopMetrics); !ok {
+		t.Errorf("expected NopMetrics after SetMetrics(nil), got %T", metrics())
+	}
+}
@@ -0,0 +1,146 @@
+package indexer
+
+import (
+	"path"
+	"sync"
+
+	"github.com/maximilianfalco/mycelium/internal/config"
+	"github.com/maximilianfalco/mycelium/internal/indexer/parsers"
+)
+
+// NodeFilter reports whether a parsed node is kept. Nodes it rejects are
+// dropped right after parsing, before resolution, embedding, and storage,
+// together with the edges that name them.
+type NodeFilter func(parsers.NodeInfo) bool
+
+var (
+	nodeFilterMu     sync.RWMutex
+	customNodeFilter NodeFilter
+)
+
+// SetNodeFilter installs a filter that runs alongside the NODE_FILTER_*
+// built-ins on every indexing run, for granularity rules they can't
+// express. nil removes it.
+func SetNodeFilter(f NodeFilter) {
+	nodeFilterMu.Lock()
+	defer nodeFilterMu.Unlock()
+	customNodeFilter = f
+}
+
+// MinLinesFilter keeps nodes spanning at least n lines, dropping one-line
+// getters and the like. Nodes without a line span are kept.
+func MinLinesFilter(n int) NodeFilter {
+	return func(node parsers.NodeInfo) bool {
+		if node.StartLine <= 0 || node.EndLine < node.StartLine {
+			return true
+		}
+		return node.EndLine-node.StartLine+1 >= n
+	}
+}
+
+// ExcludeKindsFilter drops nodes whose kind or kind group (see
+// parsers.KindGroup) is listed, e.g. "variable" or "value".
+func ExcludeKindsFilter(kinds ...string) NodeFilter {
+	excluded := make(map[string]bool, len(kinds))
+	for _, k := range kinds {
+		excluded[k] = true
+	}
+	return func(node parsers.NodeInfo) bool {
+		return !excluded[node.Kind] && !excluded[parsers.KindGroup(node.Kind)]
+	}
+}
+
+// ExcludeNamesFilter drops nodes whose name matches one of patterns, in
+// path.Match syntax ("_*", "get?*"). A malformed pattern matches nothing.
+func ExcludeNamesFilter(patterns ...string) NodeFilter {
+	return func(node parsers.NodeInfo) bool {
+		for _, p := range patterns {
+			if ok, _ := path.Match(p, node.Name); ok {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// AllFilters keeps a node only if every non-nil filter does. It returns nil,
+// keeping everything, when there are none.
+func AllFilters(filters ...NodeFilter) NodeFilter {
+	var active []NodeFilter
+	for _, f := range filters {
+		if f != nil {
+			active = append(active, f)
+		}
+	}
+	switch len(active) {
+	case 0:
+		return nil
+	case 1:
+		return active[0]
+	}
+	return func(node parsers.NodeInfo) bool {
+		for _, f := range active {
+			if !f(node) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// newNodeFilter combines the built-in filters cfg enables with the one set
+// by SetNodeFilter. nil means nothing is filtered.
+func newNodeFilter(cfg *config.Config) NodeFilter {
+	var filters []NodeFilter
+	if cfg != nil {
+		if cfg.NodeFilterMinLines > 1 {
+			filters = append(filters, MinLinesFilter(cfg.NodeFilterMinLines))
+		}
+		if len(cfg.NodeFilterExcludeKinds) > 0 {
+			filters = append(filters, ExcludeKindsFilter(cfg.NodeFilterExcludeKinds...))
+		}
+		if len(cfg.NodeFilterExcludeNames) > 0 {
+			filters = append(filters, ExcludeNamesFilter(cfg.NodeFilterExcludeNames...))
+		}
+	}
+	nodeFilterMu.RLock()
+	custom := customNodeFilter
+	nodeFilterMu.RUnlock()
+	return AllFilters(append(filters, custom)...)
+}
+
+// applyNodeFilter drops the nodes filter rejects and every edge whose source
+// or target names one of them, so no edge is left pointing at a node that
+// won't be stored. A name another kept node still goes by keeps its edges.
+// It returns the kept nodes and edges and how many nodes were dropped.
+func applyNodeFilter(filter NodeFilter, nodes []parsers.NodeInfo, edges []parsers.EdgeInfo) ([]parsers.NodeInfo, []parsers.EdgeInfo, int) {
+	if filter == nil {
+		return nodes, edges, 0
+	}
+	kept := make([]parsers.NodeInfo, 0, len(nodes))
+	keptNames := make(map[string]bool, len(nodes))
+	dropped := make(map[string]bool)
+	for _, n := range nodes {
+		if filter(n) {
+			kept = append(kept, n)
+			keptNames[n.QualifiedName] = true
+		} else {
+			dropped[n.QualifiedName] = true
+		}
+	}
+	if len(dropped) == 0 {
+		return nodes, edges, 0
+	}
+	for name := range keptNames {
+		delete(dropped, name)
+	}
+
+	keptEdges := make([]parsers.EdgeInfo, 0, len(edges))
+	for _, e := range edges {
+		if dropped[e.Source] || dropped[e.Target] {
+			continue
+		}
+		keptEdges = append(keptEdges, e)
+	}
+	return kept, keptEdges, len(nodes) - len(kept)
+}
@@ -0,0 +1,84 @@
+package indexer
+
+import (
+	"testing"
+
+	"github.com/maximilianfalco/mycelium/internal/config"
+	"github.com/maximilianfalco/mycelium/internal/indexer/parsers"
+)
+
+func TestBuiltinNodeFilters(t *testing.T) {
+	getter := parsers.NodeInfo{Name: "getName", QualifiedName: "User.getName", Kind: "method", StartLine: 4, EndLine: 4}
+	login := parsers.NodeInfo{Name: "login", QualifiedName: "login", Kind: "function", StartLine: 10, EndLine: 20}
+	limit := parsers.NodeInfo{Name: "limit", QualifiedName: "limit", Kind: "variable", StartLine: 1, EndLine: 1}
+
+	tests := []struct {
+		name   string
+		filter NodeFilter
+		node   parsers.NodeInfo
+		want   bool
+	}{
+		{"min lines drops one-liner", MinLinesFilter(2), getter, false},
+		{"min lines keeps long body", MinLinesFilter(2), login, true},
+		{"min lines keeps spanless node", MinLinesFilter(2), parsers.NodeInfo{Name: "x"}, true},
+		{"exclude kind", ExcludeKindsFilter("method"), getter, false},
+		{"exclude kind group", ExcludeKindsFilter("value"), limit, false},
+		{"exclude kind keeps others", ExcludeKindsFilter("value"), login, true},
+		{"exclude name pattern", ExcludeNamesFilter("get*"), getter, false},
+		{"exclude name keeps others", ExcludeNamesFilter("get*"), login, true},
+		{"malformed pattern matches nothing", ExcludeNamesFilter("["), getter, true},
+		{"all filters", AllFilters(nil, MinLinesFilter(2), ExcludeKindsFilter("function")), login, false},
+	}
+	for _, tt := range tests {
+		if got := tt.filter(tt.node); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if AllFilters(nil, nil) != nil {
+		t.Error("expected AllFilters of no filters to be nil")
+	}
+}
+
+func TestApplyNodeFilter(t *testing.T) {
+	nodes := []parsers.NodeInfo{
+		{Name: "login", QualifiedName: "login", Kind: "function"},
+		{Name: "getName", QualifiedName: "User.getName", Kind: "method"},
+		{Name: "helper", QualifiedName: "helper", Kind: "function"},
+		{Name: "helper", QualifiedName: "helper", Kind: "variable"},
+	}
+	edges := []parsers.EdgeInfo{
+		{Source: "src/a.ts", Target: "login", Kind: "contains"},
+		{Source: "User", Target: "User.getName", Kind: "contains"},
+		{Source: "User.getName", Target: "helper", Kind: "calls"},
+		{Source: "login", Target: "helper", Kind: "calls"},
+		{Source: "src/a.ts", Target: "./user", Kind: "imports"},
+	}
+	kept, keptEdges, dropped := applyNodeFilter(ExcludeKindsFilter("method", "variable"), nodes, edges)
+	if dropped != 2 || len(kept) != 2 {
+		t.Fatalf("expected 2 nodes dropped and 2 kept, got %d dropped: %+v", dropped, kept)
+	}
+	// helper's variable is dropped, but the function of that name keeps its edges
+	if len(keptEdges) != 3 || keptEdges[0].Target != "login" || keptEdges[1].Source != "login" || keptEdges[2].Kind != "imports" {
+		t.Errorf("unexpected edges after filtering: %+v", keptEdges)
+	}
+
+	if _, _, dropped := applyNodeFilter(nil, nodes, edges); dropped != 0 {
+		t.Errorf("expected a nil filter to keep everything, dropped %d", dropped)
+	}
+}
+
+func TestNewNodeFilter(t *testing.T) {
+	if newNodeFilter(&config.Config{NodeFilterMinLines: 1}) != nil {
+		t.Error("expected no filter when nothing is enabled")
+	}
+
+	SetNodeFilter(func(n parsers.NodeInfo) bool { return n.Name != "secret" })
+	defer SetNodeFilter(nil)
+	filter := newNodeFilter(&config.Config{NodeFilterExcludeKinds: []string{"variable"}})
+	if filter(parsers.NodeInfo{Name: "secret", Kind: "function"}) || filter(parsers.NodeInfo{Name: "limit", Kind: "variable"}) {
+		t.Error("expected both the custom and the built-in filter to apply")
+	}
+	if !filter(parsers.NodeInfo{Name: "login", Kind: "function"}) {
+		t.Error("expected login to be kept")
+	}
+}
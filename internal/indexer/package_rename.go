@@ -0,0 +1,156 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/maximilianfalco/mycelium/internal/indexer/detectors"
+	"github.com/maximilianfalco/mycelium/internal/indexer/parsers"
+)
+
+// PackageRename records a package whose directory moved between runs: the
+// same package name was detected at a new path.
+type PackageRename struct {
+	Name    string
+	OldPath string
+	NewPath string
+}
+
+// loadStoredPackagePaths returns the stored path of each package in the
+// workspace, keyed by package name.
+func loadStoredPackagePaths(ctx context.Context, pool *pgxpool.Pool, workspaceID string) (map[string]string, error) {
+	rows, err := pool.Query(ctx, `SELECT name, path FROM packages WHERE workspace_id = $1`, workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("querying stored packages: %w", err)
+	}
+	defer rows.Close()
+
+	paths := make(map[string]string)
+	for rows.Next() {
+		var name, path string
+		if err := rows.Scan(&name, &path); err != nil {
+			return nil, fmt.Errorf("scanning stored package: %w", err)
+		}
+		paths[name] = path
+	}
+	return paths, rows.Err()
+}
+
+// detectPackageRenames compares the stored package paths with the freshly
+// detected workspace. A package whose name is unchanged but whose path moved
+// counts as renamed, unless its old path is still claimed by a detected
+// package or either path is the workspace root, where a prefix match would
+// take in every file.
+func detectPackageRenames(stored map[string]string, ws *detectors.WorkspaceInfo) []PackageRename {
+	if len(stored) == 0 || ws == nil {
+		return nil
+	}
+	detected := make(map[string]bool, len(ws.Packages))
+	for _, pkg := range ws.Packages {
+		detected[pkg.Path] = true
+	}
+
+	var renames []PackageRename
+	for _, pkg := range ws.Packages {
+		oldPath, ok := stored[pkg.Name]
+		if !ok || oldPath == pkg.Path || isRootPackagePath(oldPath) || isRootPackagePath(pkg.Path) || detected[oldPath] {
+			continue
+		}
+		renames = append(renames, PackageRename{Name: pkg.Name, OldPath: oldPath, NewPath: pkg.Path})
+	}
+	return renames
+}
+
+func isRootPackagePath(path string) bool {
+	return path == "" || path == "."
+}
+
+// remapRenamedPackages moves the stored nodes of renamed packages to their
+// new paths in place, so they keep their embeddings and the edges pointing
+// at them (edges follow node IDs via ON UPDATE CASCADE). A node is moved only
+// when the re-parsed file at the new path has a node with the same qualified
+// name and body hash; anything else is left to rename detection and stale
+// cleanup as usual. Members stored under their class rather than a file path
+// don't carry the package path in their ID and need no remapping. Returns the
+// number of nodes moved.
+func remapRenamedPackages(ctx context.Context, pool *pgxpool.Pool, workspaceID string, renames []PackageRename, parsed []parsers.NodeInfo, edges []parsers.EdgeInfo) (int, error) {
+	if len(renames) == 0 {
+		return 0, nil
+	}
+
+	type nodeKey struct{ file, qname string }
+	fileOf := buildNodeFileMap(edges)
+	parsedHashes := make(map[nodeKey]string, len(parsed))
+	for _, n := range parsed {
+		file := n.FilePath
+		if file == "" {
+			file = fileOf(n.QualifiedName)
+		}
+		parsedHashes[nodeKey{file, n.QualifiedName}] = n.BodyHash
+	}
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	moved := 0
+	for _, r := range renames {
+		oldPrefix := strings.TrimSuffix(r.OldPath, "/")
+		newPrefix := strings.TrimSuffix(r.NewPath, "/")
+		rows, err := tx.Query(ctx, `
+			SELECT id, COALESCE(qualified_name, name), file_path, COALESCE(body_hash, '')
+			FROM nodes
+			WHERE workspace_id = $1 AND starts_with(file_path, $2)`,
+			workspaceID, oldPrefix+"/",
+		)
+		if err != nil {
+			return 0, fmt.Errorf("querying nodes of package %s: %w", r.Name, err)
+		}
+		type remap struct{ oldID, newID, newFile string }
+		var remaps []remap
+		for rows.Next() {
+			var id, qname, file, hash string
+			if err := rows.Scan(&id, &qname, &file, &hash); err != nil {
+				rows.Close()
+				return 0, fmt.Errorf("scanning node of package %s: %w", r.Name, err)
+			}
+			newFile := newPrefix + strings.TrimPrefix(file, oldPrefix)
+			if parsedHash, ok := parsedHashes[nodeKey{newFile, qname}]; ok && hash != "" && parsedHash == hash {
+				remaps = append(remaps, remap{oldID: id, newID: NodeID(workspaceID, newFile, qname), newFile: newFile})
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return 0, fmt.Errorf("iterating nodes of package %s: %w", r.Name, err)
+		}
+
+		pkgMoved := 0
+		for _, m := range remaps {
+			tag, err := tx.Exec(ctx, `
+				UPDATE nodes SET id = $2, file_path = $3, renamed_from = $1
+				WHERE id = $1 AND NOT EXISTS (SELECT 1 FROM nodes WHERE id = $2)`,
+				m.oldID, m.newID, m.newFile,
+			)
+			if err != nil {
+				return 0, fmt.Errorf("remapping node %s: %w", m.oldID, err)
+			}
+			pkgMoved += int(tag.RowsAffected())
+		}
+		moved += pkgMoved
+		slog.Info("remapped renamed package", "package", r.Name, "from", r.OldPath, "to", r.NewPath, "nodes", pkgMoved)
+	}
+
+	if err := bumpWorkspaceGraphVersion(ctx, tx, workspaceID); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("committing transaction: %w", err)
+	}
+	return moved, nil
+}
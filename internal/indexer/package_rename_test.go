@@ -0,0 +1,46 @@
+package indexer
+
+import (
+	"testing"
+
+	"github.com/maximilianfalco/mycelium/internal/indexer/detectors"
+)
+
+func TestDetectPackageRenames(t *testing.T) {
+	stored := map[string]string{
+		"@acme/ui":    "packages/old-ui",
+		"@acme/api":   "packages/api",
+		"@acme/root":  ".",
+		"@acme/swap":  "packages/a",
+		"@acme/taken": "packages/b",
+		"@acme/gone":  "packages/gone",
+	}
+	ws := &detectors.WorkspaceInfo{Packages: []detectors.PackageInfo{
+		{Name: "@acme/ui", Path: "packages/ui"},
+		{Name: "@acme/api", Path: "packages/api"},
+		{Name: "@acme/root", Path: "apps/root"},
+		// swap moved into the directory taken used to have. Taken's old path
+		// now belongs to swap, so its files can't be told apart by prefix
+		{Name: "@acme/swap", Path: "packages/b"},
+		{Name: "@acme/taken", Path: "packages/c"},
+		{Name: "@acme/new", Path: "packages/new"},
+	}}
+
+	renames := detectPackageRenames(stored, ws)
+	got := make(map[string]PackageRename)
+	for _, r := range renames {
+		got[r.Name] = r
+	}
+
+	if r := got["@acme/ui"]; r.OldPath != "packages/old-ui" || r.NewPath != "packages/ui" {
+		t.Errorf("expected @acme/ui moved from packages/old-ui to packages/ui, got %+v", r)
+	}
+	if r := got["@acme/swap"]; r.OldPath != "packages/a" || r.NewPath != "packages/b" {
+		t.Errorf("expected @acme/swap moved from packages/a to packages/b, got %+v", r)
+	}
+	for _, name := range []string{"@acme/api", "@acme/root", "@acme/taken", "@acme/new", "@acme/gone"} {
+		if r, ok := got[name]; ok {
+			t.Errorf("expected no rename for %s, got %+v", name, r)
+		}
+	}
+}
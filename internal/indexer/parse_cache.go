@@ -0,0 +1,111 @@
+package indexer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strconv"
+	"strings"
+
+	"github.com/maximilianfalco/mycelium/internal/indexer/parsers"
+)
+
+// parseCacheVersion is mixed into every cache key. Bump it whenever parser
+// output changes for the same input, so entries written by older code miss.
+const parseCacheVersion = "9"
+
+// parseCache stores ParseResults on disk keyed by a hash of the file's path
+// and content, so re-parsing an unchanged file — typically during a full
+// reindex forced by a config change — skips tree-sitter. Entries are never
+// updated in place: a content change produces a new key. The directory can be
+// deleted at any time.
+type parseCache struct {
+	dir  string
+	salt string
+}
+
+// newParseCache returns nil when dir is empty, which disables caching.
+func newParseCache(dir string) *parseCache {
+	if dir == "" {
+		return nil
+	}
+	// The source cap and route detectors change parser output, so entries
+	// are per setting
+	salt := parseCacheVersion + "\x00" + buildRevision() + "\x00" + strconv.Itoa(parsers.MaxNodeSourceBytes()) +
+		"\x00" + strings.Join(parsers.RouteDetectors(), ",")
+	return &parseCache{dir: dir, salt: salt}
+}
+
+// buildRevision is the VCS revision the binary was built from, so entries
+// from a different build of the parsers miss even if nobody bumped
+// parseCacheVersion. Empty for builds without VCS stamping.
+func buildRevision() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	for _, s := range info.Settings {
+		if s.Key == "vcs.revision" {
+			return s.Value
+		}
+	}
+	return ""
+}
+
+// key hashes the absolute path along with the content: parse results embed
+// the path in contains/imports edges, so identical files elsewhere differ.
+func (c *parseCache) key(absPath string, source []byte) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00", c.salt, absPath)
+	h.Write(source)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (c *parseCache) path(key string) string {
+	return filepath.Join(c.dir, key[:2], key+".json")
+}
+
+func (c *parseCache) get(key string) (*parsers.ParseResult, bool) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+	var pr parsers.ParseResult
+	if err := json.Unmarshal(data, &pr); err != nil {
+		return nil, false
+	}
+	return &pr, true
+}
+
+// put writes an entry via a temp file and rename, so concurrent runs never
+// see a partial file. Failures only cost a future cache miss.
+func (c *parseCache) put(key string, pr *parsers.ParseResult) {
+	data, err := json.Marshal(pr)
+	if err != nil {
+		return
+	}
+	path := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		slog.Debug("parse cache write failed", "error", err)
+		return
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), key+".*.tmp")
+	if err != nil {
+		slog.Debug("parse cache write failed", "error", err)
+		return
+	}
+	_, writeErr := tmp.Write(data)
+	closeErr := tmp.Close()
+	if writeErr != nil || closeErr != nil {
+		os.Remove(tmp.Name())
+		return
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+	}
+}
@@ -0,0 +1,86 @@
+package indexer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/maximilianfalco/mycelium/internal/indexer/parsers"
+)
+
+func TestParseCache_RoundTrip(t *testing.T) {
+	c := newParseCache(t.TempDir())
+	key := c.key("/src/a.go", []byte("package a"))
+
+	if _, ok := c.get(key); ok {
+		t.Fatal("expected miss on empty cache")
+	}
+
+	want := &parsers.ParseResult{
+		Nodes: []parsers.NodeInfo{{Name: "A", QualifiedName: "A", Kind: "function", Exported: true, Generated: true}},
+		Edges: []parsers.EdgeInfo{{Source: "/src/a.go", Target: "A", Kind: "contains", Line: 3}},
+	}
+	c.put(key, want)
+
+	got, ok := c.get(key)
+	if !ok {
+		t.Fatal("expected hit after put")
+	}
+	if len(got.Nodes) != 1 || !reflect.DeepEqual(got.Nodes[0], want.Nodes[0]) {
+		t.Errorf("nodes = %+v, want %+v", got.Nodes, want.Nodes)
+	}
+	if len(got.Edges) != 1 || got.Edges[0].Source != "/src/a.go" || got.Edges[0].Line != 3 {
+		t.Errorf("edges = %+v, want %+v", got.Edges, want.Edges)
+	}
+}
+
+func TestParseCache_KeyCoversPathAndContent(t *testing.T) {
+	c := newParseCache(t.TempDir())
+	base := c.key("/src/a.go", []byte("package a"))
+
+	if c.key("/src/a.go", []byte("package a")) != base {
+		t.Error("expected a stable key for the same path and content")
+	}
+	if c.key("/src/b.go", []byte("package a")) == base {
+		t.Error("expected a different key for the same content at another path")
+	}
+	if c.key("/src/a.go", []byte("package b")) == base {
+		t.Error("expected a different key after the content changed")
+	}
+}
+
+func TestNewParseCache_DisabledWithoutDir(t *testing.T) {
+	if c := newParseCache(""); c != nil {
+		t.Errorf("expected nil cache for empty dir, got %+v", c)
+	}
+}
+
+func TestParseFiles_UsesCache(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.go")
+	source := []byte("package a\n\nfunc Real() {}\n")
+	os.WriteFile(path, source, 0o644)
+	files := []FileInfo{{AbsPath: path, RelPath: "a.go", Extension: ".go"}}
+
+	c := newParseCache(t.TempDir())
+	nodes, _, errs, _ := parseFiles(context.Background(), files, dir, c)
+	if len(errs) != 0 || len(nodes) != 1 || nodes[0].Name != "Real" {
+		t.Fatalf("first parse: nodes=%+v errs=%v", nodes, errs)
+	}
+
+	// Swap in a sentinel entry: a hit must return it rather than re-parse.
+	c.put(c.key(path, source), &parsers.ParseResult{Nodes: []parsers.NodeInfo{{Name: "Cached"}}})
+	nodes, _, _, _ = parseFiles(context.Background(), files, dir, c)
+	if len(nodes) != 1 || nodes[0].Name != "Cached" {
+		t.Errorf("expected cached result, got %+v", nodes)
+	}
+
+	// Changing the content misses and parses the new source.
+	os.WriteFile(path, []byte("package a\n\nfunc Changed() {}\n"), 0o644)
+	nodes, _, _, _ = parseFiles(context.Background(), files, dir, c)
+	if len(nodes) != 1 || nodes[0].Name != "Changed" {
+		t.Errorf("expected re-parse after content change, got %+v", nodes)
+	}
+}
@@ -0,0 +1,66 @@
+package parsers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"path/filepath"
+	"unicode/utf8"
+)
+
+var _ Parser = (*AssetParser)(nil)
+
+// AssetParser handles files that code imports but that hold no code: JSON
+// read through `resolveJsonModule`, and images or stylesheets a bundler
+// loads. Each file becomes one "asset" node spanning it, so an import of
+// the file resolves to a node instead of staying an unresolved ref.
+type AssetParser struct{}
+
+func NewAssetParser() *AssetParser {
+	return &AssetParser{}
+}
+
+// SetAssetExtensions registers an AssetParser for exts and unregisters it
+// from any extension it held before, leaving other parsers alone: an
+// extension that already has a code parser keeps it. ASSET_EXTENSIONS sets
+// the list.
+func SetAssetExtensions(exts []string) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	for ext, p := range registry {
+		if _, ok := p.(*AssetParser); ok {
+			delete(registry, ext)
+		}
+	}
+	asset := NewAssetParser()
+	for _, ext := range exts {
+		if _, ok := registry[ext]; !ok {
+			registry[ext] = asset
+		}
+	}
+}
+
+// Parse emits the file's asset node, named after the file and placed by
+// FilePath, since names like package.json repeat across directories. Text
+// files keep their content as source; binary files have none.
+func (p *AssetParser) Parse(filePath string, source []byte) (*ParseResult, error) {
+	name := filepath.Base(filePath)
+	lines := bytes.Count(source, []byte("\n"))
+	if len(source) > 0 && source[len(source)-1] != '\n' {
+		lines++
+	}
+	node := NodeInfo{
+		Name:          name,
+		QualifiedName: name,
+		Kind:          "asset",
+		Signature:     name,
+		FilePath:      filePath,
+		StartLine:     1,
+		EndLine:       max(1, lines),
+		BodyHash:      fmt.Sprintf("%x", sha256.Sum256(source)),
+	}
+	if utf8.Valid(source) && bytes.IndexByte(source, 0) < 0 {
+		node.SourceCode = string(source)
+	}
+	return &ParseResult{Nodes: []NodeInfo{node}}, nil
+}
@@ -0,0 +1,49 @@
+package parsers
+
+import "testing"
+
+func TestSetAssetExtensions(t *testing.T) {
+	SetAssetExtensions([]string{".json", ".svg", ".ts"})
+	t.Cleanup(func() { SetAssetExtensions(nil) })
+
+	if !HasParser(".json") || !HasParser(".svg") {
+		t.Fatal("expected .json and .svg to be registered")
+	}
+	if p, _ := lookupParser(".ts"); p == nil {
+		t.Fatal("expected .ts to have a parser")
+	} else if _, ok := p.(*TypeScriptParser); !ok {
+		t.Error("expected .ts to keep the TypeScript parser")
+	}
+
+	// A new list drops the extensions left out of it
+	SetAssetExtensions([]string{".json"})
+	if HasParser(".svg") {
+		t.Error("expected .svg to be unregistered")
+	}
+	if !HasParser(".ts") {
+		t.Error("expected .ts to stay registered")
+	}
+}
+
+func TestAssetParser(t *testing.T) {
+	result, err := NewAssetParser().Parse("/repo/src/config.json", []byte("{\n  \"port\": 8080\n}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Nodes) != 1 || len(result.Edges) != 0 {
+		t.Fatalf("expected one node and no edges, got %+v", result)
+	}
+	n := result.Nodes[0]
+	if n.Name != "config.json" || n.Kind != "asset" || n.FilePath != "/repo/src/config.json" || n.EndLine != 3 || n.SourceCode == "" || n.BodyHash == "" {
+		t.Errorf("unexpected asset node %+v", n)
+	}
+
+	// Binary content isn't stored as source
+	result, err = NewAssetParser().Parse("/repo/logo.png", []byte("\x89PNG\r\n\x1a\n\x00\x00"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := result.Nodes[0]; n.SourceCode != "" || n.Name != "logo.png" {
+		t.Errorf("expected a binary asset without source, got %+v", n)
+	}
+}
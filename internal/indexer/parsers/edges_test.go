@@ -280,6 +280,92 @@ func TestCallEdgesSkipsNamedFunctionDeclarations(t *testing.T) {
 	}
 }
 
+func TestClassFieldArrowMethods(t *testing.T) {
+	src := []byte(`class Toolbar extends React.Component<Props> {
+  state: ToolbarState = { open: false };
+  private handleClick = async (e: MouseEvent): Promise<void> => {
+    this.setState({ open: true });
+    track(e);
+  };
+  onHover = (item: Item) => highlight(item);
+  onLeave = function () { reset(); };
+  static count = 0;
+
+  render() {
+    return <Button onClick={this.handleClick} />;
+  }
+}`)
+	result, err := ParseFile("toolbar.tsx", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"handleClick", "onHover", "onLeave", "render"} {
+		n := findNode(result.Nodes, name)
+		if n == nil || n.Kind != "method" || n.QualifiedName != "Toolbar."+name {
+			t.Errorf("expected method Toolbar.%s, got %+v", name, n)
+		}
+		if findEdge(result.Edges, "contains", "Toolbar", "Toolbar."+name) == nil {
+			t.Errorf("expected Toolbar contains Toolbar.%s", name)
+		}
+	}
+	if findNode(result.Nodes, "state") != nil || findNode(result.Nodes, "count") != nil {
+		t.Error("data fields should not become nodes")
+	}
+
+	handleClick := findNode(result.Nodes, "handleClick")
+	if handleClick != nil {
+		if handleClick.StartLine != 3 || handleClick.EndLine != 6 {
+			t.Errorf("expected handleClick on lines 3-6, got %d-%d", handleClick.StartLine, handleClick.EndLine)
+		}
+		if !strings.Contains(strings.Join(handleClick.Modifiers, " "), "private") || !strings.Contains(strings.Join(handleClick.Modifiers, " "), "async") {
+			t.Errorf("expected private async modifiers, got %v", handleClick.Modifiers)
+		}
+	}
+
+	if findEdge(result.Edges, "calls", "Toolbar.handleClick", "this.setState") == nil {
+		t.Error("expected Toolbar.handleClick calls this.setState")
+	}
+	if findEdge(result.Edges, "calls", "Toolbar.handleClick", "track") == nil {
+		t.Error("expected Toolbar.handleClick calls track")
+	}
+	if findEdge(result.Edges, "calls", "Toolbar.onHover", "highlight") == nil {
+		t.Error("expected Toolbar.onHover calls highlight (concise arrow body)")
+	}
+	if findEdge(result.Edges, "calls", "Toolbar.onLeave", "reset") == nil {
+		t.Error("expected Toolbar.onLeave calls reset")
+	}
+
+	if findEdge(result.Edges, "uses_type", "Toolbar.handleClick", "MouseEvent") == nil {
+		t.Error("expected Toolbar.handleClick uses_type MouseEvent")
+	}
+	if findEdge(result.Edges, "uses_type", "Toolbar.onHover", "Item") == nil {
+		t.Error("expected Toolbar.onHover uses_type Item")
+	}
+	if findEdge(result.Edges, "uses_type", "Toolbar", "ToolbarState") == nil {
+		t.Error("expected Toolbar uses_type ToolbarState from its state field")
+	}
+}
+
+func TestClassFieldArrowMethodsJavaScript(t *testing.T) {
+	src := []byte(`class Form {
+  submit = () => {
+    send(this.data);
+  };
+}`)
+	result, err := ParseFile("form.js", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := findNode(result.Nodes, "submit"); n == nil || n.QualifiedName != "Form.submit" || n.Kind != "method" {
+		t.Fatalf("expected method Form.submit, got %+v", n)
+	}
+	if findEdge(result.Edges, "calls", "Form.submit", "send") == nil {
+		t.Error("expected Form.submit calls send")
+	}
+}
+
 func TestUsesTypeEdges(t *testing.T) {
 	path, src := readFixture(t, "typescript", "edges.ts")
 	result, err := ParseFile(path, src)
@@ -579,3 +665,59 @@ func TestJSXNamespacedComponent(t *testing.T) {
 		t.Error("expected Form calls ui.Button (namespaced JSX)")
 	}
 }
+
+func TestReferenceEdges(t *testing.T) {
+	src := []byte(`import { transform, type Options } from './transform';
+import * as utils from './utils';
+
+enum Color { Red }
+class Store {}
+function helper(x: number): number { return x; }
+
+function run(items: number[], opts: Options): void {
+  const mapped = items.map(transform);
+  const store = new Store();
+  register({ cb: helper, fmt: utils.format });
+  const c = Color.Red;
+  helper(1);
+  items.forEach(transform);
+}
+
+function shadowed(helper: number): number {
+  return helper;
+}`)
+	result, err := ParseFile("test.ts", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, target := range []string{"transform", "Store", "helper", "utils.format", "Color"} {
+		if findEdge(result.Edges, "references", "run", target) == nil {
+			t.Errorf("expected run references %s", target)
+		}
+	}
+	// Calls stay calls, and each target is referenced once per function
+	for _, target := range []string{"items.map", "register"} {
+		if findEdge(result.Edges, "references", "run", target) != nil {
+			t.Errorf("callee %s should not be a reference", target)
+		}
+	}
+	count := 0
+	for _, e := range findEdges(result.Edges, "references") {
+		if e.Source == "run" && e.Target == "transform" {
+			count++
+			if e.Line != 9 {
+				t.Errorf("expected first use on line 9, got %d", e.Line)
+			}
+		}
+	}
+	if count != 1 {
+		t.Errorf("expected one run -> transform reference, got %d", count)
+	}
+	if findEdge(result.Edges, "references", "run", "Options") != nil {
+		t.Error("type-only imports are not values")
+	}
+	if findEdge(result.Edges, "references", "shadowed", "helper") != nil {
+		t.Error("a parameter should shadow the top-level helper")
+	}
+}
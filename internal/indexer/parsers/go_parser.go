@@ -2,14 +2,15 @@ package parsers
 
 import (
 	"context"
-	"fmt"
+	"regexp"
 	"strings"
+	"unicode"
 
 	sitter "github.com/smacker/go-tree-sitter"
 	"github.com/smacker/go-tree-sitter/golang"
 )
 
-var _ Parser = (*GoParser)(nil)
+var _ ContextParser = (*GoParser)(nil)
 
 type GoParser struct{}
 
@@ -18,22 +19,62 @@ func NewGoParser() *GoParser {
 }
 
 func (p *GoParser) Parse(filePath string, source []byte) (*ParseResult, error) {
+	return p.ParseCtx(context.Background(), filePath, source)
+}
+
+// ParseCtx is Parse, stopping with ctx's error once ctx is done.
+func (p *GoParser) ParseCtx(ctx context.Context, filePath string, source []byte) (*ParseResult, error) {
 	parser := sitter.NewParser()
 	parser.SetLanguage(golang.GetLanguage())
 
-	tree, err := parser.ParseCtx(context.Background(), nil, source)
+	tree, syntaxErrors, err := parseRecovering(ctx, parser, source)
 	if err != nil {
-		return nil, fmt.Errorf("tree-sitter parse: %w", err)
+		return nil, err
 	}
 	defer tree.Close()
 
-	result := &ParseResult{}
+	result := &ParseResult{HasSyntaxErrors: len(syntaxErrors) > 0, SyntaxErrors: syntaxErrors}
 	root := tree.RootNode()
 	p.extractNodes(source, root, result)
 	p.extractEdges(source, root, filePath, result)
+	generated := isGeneratedGo(source)
+	for i := range result.Nodes {
+		result.Nodes[i].Exported = goExported(result.Nodes[i].QualifiedName)
+		result.Nodes[i].Generated = generated
+	}
+	applyDocTags(result.Nodes)
 	return result, nil
 }
 
+// goGeneratedRe is the standard marker from https://go.dev/s/generatedcode.
+var goGeneratedRe = regexp.MustCompile(`^// Code generated .* DO NOT EDIT\.$`)
+
+// isGeneratedGo reports whether the file carries the generated-code marker on
+// a line before the package clause.
+func isGeneratedGo(source []byte) bool {
+	for _, line := range strings.Split(string(source), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.HasPrefix(line, "package ") {
+			return false
+		}
+		if goGeneratedRe.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// goExported reports whether every segment of a qualified name is a
+// capitalized identifier — so a method only counts if its receiver does too.
+func goExported(qname string) bool {
+	for _, part := range strings.Split(qname, ".") {
+		if part == "" || !unicode.IsUpper([]rune(part)[0]) {
+			return false
+		}
+	}
+	return true
+}
+
 // --- Node extraction ---
 
 func (p *GoParser) extractNodes(source []byte, root *sitter.Node, result *ParseResult) {
@@ -58,15 +99,16 @@ func (p *GoParser) extractFunction(source []byte, node *sitter.Node, result *Par
 	name := nodeContent(source, nameNode)
 
 	result.Nodes = append(result.Nodes, NodeInfo{
-		Name:          name,
-		QualifiedName: name,
-		Kind:          "function",
-		Signature:     goSignature(source, node),
-		StartLine:     int(node.StartPoint().Row) + 1,
-		EndLine:       int(node.EndPoint().Row) + 1,
-		SourceCode:    nodeContent(source, node),
-		Docstring:     goDocstring(source, node),
-		BodyHash:      computeBodyHash(source, node),
+		Name:           name,
+		QualifiedName:  name,
+		Kind:           "function",
+		Signature:      goSignature(source, node),
+		StartLine:      int(node.StartPoint().Row) + 1,
+		EndLine:        int(node.EndPoint().Row) + 1,
+		SourceCode:     nodeContent(source, node),
+		Docstring:      goDocstring(source, node),
+		BodyHash:       computeBodyHashWithoutComments(source, node),
+		StructuralHash: computeStructuralHash(source, node),
 	})
 }
 
@@ -83,15 +125,17 @@ func (p *GoParser) extractMethod(source []byte, node *sitter.Node, result *Parse
 	}
 
 	result.Nodes = append(result.Nodes, NodeInfo{
-		Name:          name,
-		QualifiedName: qname,
-		Kind:          "method",
-		Signature:     goSignature(source, node),
-		StartLine:     int(node.StartPoint().Row) + 1,
-		EndLine:       int(node.EndPoint().Row) + 1,
-		SourceCode:    nodeContent(source, node),
-		Docstring:     goDocstring(source, node),
-		BodyHash:      computeBodyHash(source, node),
+		Name:           name,
+		QualifiedName:  qname,
+		Kind:           "method",
+		Signature:      goSignature(source, node),
+		StartLine:      int(node.StartPoint().Row) + 1,
+		EndLine:        int(node.EndPoint().Row) + 1,
+		SourceCode:     nodeContent(source, node),
+		Docstring:      goDocstring(source, node),
+		BodyHash:       computeBodyHashWithoutComments(source, node),
+		StructuralHash: computeStructuralHash(source, node),
+		Modifiers:      goReceiverModifiers(node),
 	})
 }
 
@@ -124,15 +168,16 @@ func (p *GoParser) extractTypeSpec(source []byte, spec *sitter.Node, declNode *s
 	}
 
 	result.Nodes = append(result.Nodes, NodeInfo{
-		Name:          name,
-		QualifiedName: name,
-		Kind:          kind,
-		Signature:     goTypeSignature(source, spec, kind),
-		StartLine:     int(declNode.StartPoint().Row) + 1,
-		EndLine:       int(declNode.EndPoint().Row) + 1,
-		SourceCode:    nodeContent(source, declNode),
-		Docstring:     goDocstring(source, declNode),
-		BodyHash:      computeBodyHash(source, declNode),
+		Name:           name,
+		QualifiedName:  name,
+		Kind:           kind,
+		Signature:      goTypeSignature(source, spec, kind),
+		StartLine:      int(declNode.StartPoint().Row) + 1,
+		EndLine:        int(declNode.EndPoint().Row) + 1,
+		SourceCode:     nodeContent(source, declNode),
+		Docstring:      goDocstring(source, declNode),
+		BodyHash:       computeBodyHashWithoutComments(source, declNode),
+		StructuralHash: computeStructuralHash(source, declNode),
 	})
 }
 
@@ -143,6 +188,7 @@ func (p *GoParser) extractEdges(source []byte, root *sitter.Node, filePath strin
 	p.extractContainsEdges(filePath, result)
 	p.extractHeritageEdges(source, root, result)
 	p.extractCallEdgesGo(source, root, result)
+	p.extractReferenceEdgesGo(source, root, result)
 	p.extractTypeEdgesGo(source, root, result)
 }
 
@@ -238,6 +284,7 @@ func (p *GoParser) extractHeritageEdges(source []byte, root *sitter.Node, result
 				continue
 			}
 			p.extractEmbedEdges(source, spec, result)
+			p.extractFieldTypeEdges(source, spec, result)
 		}
 	}
 }
@@ -279,6 +326,46 @@ func (p *GoParser) extractEmbedEdges(source []byte, spec *sitter.Node, result *P
 	}
 }
 
+// extractFieldTypeEdges adds uses_type edges from a struct to the named types
+// of its fields, however deeply they're nested in composite types or
+// anonymous structs. Embedded fields get embeds edges instead.
+func (p *GoParser) extractFieldTypeEdges(source []byte, spec *sitter.Node, result *ParseResult) {
+	nameNode := spec.ChildByFieldName("name")
+	typeNode := spec.ChildByFieldName("type")
+	if nameNode == nil || typeNode == nil || typeNode.Type() != "struct_type" {
+		return
+	}
+	structName := nodeContent(source, nameNode)
+
+	fieldList := findChildByType(typeNode, "field_declaration_list")
+	if fieldList == nil {
+		return
+	}
+	seen := goTypeParamNames(source, spec)
+	for i := 0; i < int(fieldList.NamedChildCount()); i++ {
+		field := fieldList.NamedChild(i)
+		if field.Type() != "field_declaration" || isEmbeddedField(field) {
+			continue
+		}
+		fieldType := field.ChildByFieldName("type")
+		if fieldType == nil {
+			continue
+		}
+		for _, t := range goFindTypeRefs(source, fieldType) {
+			if seen[t.name] || isGoBuiltinType(t.name) || t.name == structName {
+				continue
+			}
+			seen[t.name] = true
+			result.Edges = append(result.Edges, EdgeInfo{
+				Source: structName,
+				Target: t.name,
+				Kind:   "uses_type",
+				Line:   t.line,
+			})
+		}
+	}
+}
+
 func isEmbeddedField(field *sitter.Node) bool {
 	// An embedded field has no field_identifier child — only a type
 	for i := 0; i < int(field.NamedChildCount()); i++ {
@@ -341,18 +428,25 @@ func (p *GoParser) collectCallsGo(source []byte, node *sitter.Node, callerName s
 			continue
 		}
 
-		if child.Type() == "call_expression" {
-			fn := child.ChildByFieldName("function")
-			if fn != nil {
-				callee := goCalleeName(source, fn)
-				if callee != "" {
-					result.Edges = append(result.Edges, EdgeInfo{
-						Source: callerName,
-						Target: callee,
-						Kind:   "calls",
-						Line:   int(child.StartPoint().Row) + 1,
-					})
-				}
+		var fn *sitter.Node
+		switch child.Type() {
+		case "call_expression":
+			fn = child.ChildByFieldName("function")
+		case "type_conversion_expression":
+			// Explicit multi-argument instantiations like Reduce[int, string](xs)
+			// parse as conversions to a generic type
+			if t := child.ChildByFieldName("type"); t != nil && t.Type() == "generic_type" {
+				fn = t
+			}
+		}
+		if fn != nil {
+			if callee := goCalleeName(source, fn); callee != "" {
+				result.Edges = append(result.Edges, EdgeInfo{
+					Source: callerName,
+					Target: callee,
+					Kind:   "calls",
+					Line:   int(child.StartPoint().Row) + 1,
+				})
 			}
 		}
 
@@ -360,17 +454,189 @@ func (p *GoParser) collectCallsGo(source []byte, node *sitter.Node, callerName s
 	}
 }
 
+// goCalleeName returns the called function's name, unwrapping generic
+// instantiations (Map[int], lo.Map[User]) to the underlying identifier.
 func goCalleeName(source []byte, node *sitter.Node) string {
 	switch node.Type() {
 	case "identifier":
 		return nodeContent(source, node)
 	case "selector_expression":
 		return nodeContent(source, node)
+	case "index_expression":
+		if operand := node.ChildByFieldName("operand"); operand != nil {
+			return goCalleeName(source, operand)
+		}
+		return ""
+	case "generic_type":
+		if t := node.ChildByFieldName("type"); t != nil {
+			return nodeContent(source, t)
+		}
+		return ""
 	default:
 		return ""
 	}
 }
 
+// extractReferenceEdgesGo adds a references edge for each package-level name
+// a function uses without calling it, such as a handler passed to
+// http.HandleFunc or a comparator passed to sort.Slice. Any identifier the
+// function doesn't bind itself is package-level, but it may be declared in
+// another file, so targets are left for the resolver to match within the
+// package. Qualified uses (pkg.Handler) are kept for non-stdlib imports.
+// Each target gets one edge per function, at its first use.
+func (p *GoParser) extractReferenceEdgesGo(source []byte, root *sitter.Node, result *ParseResult) {
+	imports := goImportNames(result)
+	for _, node := range result.Nodes {
+		if node.Kind != "function" && node.Kind != "method" {
+			continue
+		}
+		astNode := goFindDeclAtLine(root, node.StartLine-1)
+		if astNode == nil {
+			continue
+		}
+		body := astNode.ChildByFieldName("body")
+		if body == nil {
+			continue
+		}
+		locals := make(map[string]bool)
+		goCollectBindings(source, astNode, locals)
+		seen := map[string]bool{node.QualifiedName: true}
+		goCollectReferences(source, body, locals, imports, func(name string, line int) {
+			if seen[name] {
+				return
+			}
+			seen[name] = true
+			result.Edges = append(result.Edges, EdgeInfo{
+				Source: node.QualifiedName,
+				Target: name,
+				Kind:   "references",
+				Line:   line,
+			})
+		})
+	}
+}
+
+// goImportNames maps the name each import is referred to by in the file to
+// whether it is a standard library package. Unaliased imports go by the last
+// path element, minus a major version suffix (yaml.v3, /v2).
+func goImportNames(result *ParseResult) map[string]bool {
+	names := make(map[string]bool)
+	for _, e := range result.Edges {
+		if e.Kind != "imports" {
+			continue
+		}
+		stdlib := !strings.Contains(strings.SplitN(e.Target, "/", 2)[0], ".")
+		name := ""
+		if len(e.Symbols) > 0 {
+			if !strings.HasSuffix(e.Symbols[0], " (alias)") {
+				continue // dot and blank imports bind no name
+			}
+			name = strings.TrimSuffix(e.Symbols[0], " (alias)")
+		} else {
+			parts := strings.Split(e.Target, "/")
+			name = parts[len(parts)-1]
+			if goMajorVersionRe.MatchString(name) && len(parts) > 1 {
+				name = parts[len(parts)-2]
+			}
+			name, _, _ = strings.Cut(name, ".")
+		}
+		names[name] = stdlib
+	}
+	return names
+}
+
+var goMajorVersionRe = regexp.MustCompile(`^v[0-9]+$`)
+
+// goCollectBindings records every name node declares: receiver, parameters
+// and results (including those of func literals), and local variables and
+// constants.
+func goCollectBindings(source []byte, node *sitter.Node, names map[string]bool) {
+	switch node.Type() {
+	case "parameter_declaration", "variadic_parameter_declaration", "var_spec", "const_spec":
+		for i := 0; i < int(node.NamedChildCount()); i++ {
+			if c := node.NamedChild(i); c.Type() == "identifier" {
+				names[nodeContent(source, c)] = true
+			}
+		}
+	case "short_var_declaration", "range_clause", "receive_statement":
+		if left := node.ChildByFieldName("left"); left != nil {
+			goCollectIdentifiers(source, left, names)
+		}
+	case "type_switch_statement":
+		if alias := node.ChildByFieldName("alias"); alias != nil {
+			goCollectIdentifiers(source, alias, names)
+		}
+	}
+	for i := 0; i < int(node.NamedChildCount()); i++ {
+		goCollectBindings(source, node.NamedChild(i), names)
+	}
+}
+
+func goCollectIdentifiers(source []byte, node *sitter.Node, names map[string]bool) {
+	if node.Type() == "identifier" {
+		names[nodeContent(source, node)] = true
+		return
+	}
+	for i := 0; i < int(node.NamedChildCount()); i++ {
+		goCollectIdentifiers(source, node.NamedChild(i), names)
+	}
+}
+
+// goCollectReferences reports the free identifiers used as values under
+// node. Callees are skipped, since they already produce calls edges.
+func goCollectReferences(source []byte, node *sitter.Node, locals, imports map[string]bool, report func(name string, line int)) {
+	line := int(node.StartPoint().Row) + 1
+	switch node.Type() {
+	case "identifier":
+		name := nodeContent(source, node)
+		if _, isImport := imports[name]; !isImport && !locals[name] && !isGoPredeclaredValue(name) {
+			report(name, line)
+		}
+		return
+	case "call_expression":
+		if fn := node.ChildByFieldName("function"); fn != nil && goCalleeName(source, fn) != "" {
+			if args := node.ChildByFieldName("arguments"); args != nil {
+				goCollectReferences(source, args, locals, imports, report)
+			}
+			return
+		}
+	case "selector_expression":
+		operand := node.ChildByFieldName("operand")
+		field := node.ChildByFieldName("field")
+		if operand != nil && field != nil && operand.Type() == "identifier" {
+			if stdlib, isImport := imports[nodeContent(source, operand)]; isImport && !locals[nodeContent(source, operand)] {
+				if !stdlib {
+					report(nodeContent(source, node), line)
+				}
+				return
+			}
+		}
+		if operand != nil {
+			goCollectReferences(source, operand, locals, imports, report)
+		}
+		return
+	case "keyed_element":
+		// The key of Config{Name: x} is a field, not a value
+		for i := 1; i < int(node.NamedChildCount()); i++ {
+			goCollectReferences(source, node.NamedChild(i), locals, imports, report)
+		}
+		return
+	}
+	for i := 0; i < int(node.NamedChildCount()); i++ {
+		goCollectReferences(source, node.NamedChild(i), locals, imports, report)
+	}
+}
+
+// isGoPredeclaredValue reports whether name is one of Go's predeclared
+// constants or the blank identifier.
+func isGoPredeclaredValue(name string) bool {
+	switch name {
+	case "nil", "true", "false", "iota", "_":
+		return true
+	}
+	return false
+}
+
 func (p *GoParser) extractTypeEdgesGo(source []byte, root *sitter.Node, result *ParseResult) {
 	for _, node := range result.Nodes {
 		if node.Kind != "function" && node.Kind != "method" {
@@ -381,7 +647,10 @@ func (p *GoParser) extractTypeEdgesGo(source []byte, root *sitter.Node, result *
 			continue
 		}
 		types := goCollectParamTypes(source, astNode)
-		seen := make(map[string]bool)
+		if body := astNode.ChildByFieldName("body"); body != nil {
+			types = append(types, goCollectTypeArgRefs(source, body)...)
+		}
+		seen := goTypeParamNames(source, astNode)
 		for _, t := range types {
 			if seen[t.name] || isGoBuiltinType(t.name) {
 				continue
@@ -409,7 +678,8 @@ func goCollectParamTypes(source []byte, node *sitter.Node) []typeRef {
 		case "type_identifier":
 			name := nodeContent(source, child)
 			refs = append(refs, typeRef{name: name, line: int(child.StartPoint().Row) + 1})
-		case "pointer_type", "slice_type", "array_type", "map_type", "channel_type":
+		case "pointer_type", "slice_type", "array_type", "map_type", "channel_type",
+			"qualified_type", "generic_type", "function_type", "struct_type", "interface_type":
 			refs = append(refs, goFindTypeRefs(source, child)...)
 		}
 	}
@@ -423,11 +693,51 @@ func goCollectParamTypes(source []byte, node *sitter.Node) []typeRef {
 	return refs
 }
 
+// goCollectTypeArgRefs finds named types used as type arguments inside a
+// function body, e.g. User in Map[User](xs) or var l List[User].
+func goCollectTypeArgRefs(source []byte, node *sitter.Node) []typeRef {
+	if node.Type() == "type_arguments" {
+		return goFindTypeRefs(source, node)
+	}
+	var refs []typeRef
+	for i := 0; i < int(node.NamedChildCount()); i++ {
+		refs = append(refs, goCollectTypeArgRefs(source, node.NamedChild(i))...)
+	}
+	return refs
+}
+
+// goTypeParamNames returns a declaration's own type parameters (T in
+// func Map[T any]), which are not references to named types.
+func goTypeParamNames(source []byte, decl *sitter.Node) map[string]bool {
+	names := make(map[string]bool)
+	params := decl.ChildByFieldName("type_parameters")
+	if params == nil {
+		return names
+	}
+	for i := 0; i < int(params.NamedChildCount()); i++ {
+		param := params.NamedChild(i)
+		for j := 0; j < int(param.ChildCount()); j++ {
+			if param.FieldNameForChild(j) == "name" {
+				names[nodeContent(source, param.Child(j))] = true
+			}
+		}
+	}
+	return names
+}
+
+// goFindTypeRefs finds the named types anywhere in a type expression,
+// descending through pointers, slices, maps, channels, function signatures,
+// anonymous structs and interfaces, and type arguments. A package-qualified
+// type is kept whole (store.Order), so it isn't mistaken for a local type.
 func goFindTypeRefs(source []byte, node *sitter.Node) []typeRef {
 	var refs []typeRef
-	if node.Type() == "type_identifier" {
+	switch node.Type() {
+	case "type_identifier":
 		name := nodeContent(source, node)
 		refs = append(refs, typeRef{name: name, line: int(node.StartPoint().Row) + 1})
+	case "qualified_type":
+		name := nodeContent(source, node)
+		return append(refs, typeRef{name: name, line: int(node.StartPoint().Row) + 1})
 	}
 	for i := 0; i < int(node.ChildCount()); i++ {
 		refs = append(refs, goFindTypeRefs(source, node.Child(i))...)
@@ -468,17 +778,44 @@ func goReceiverType(source []byte, method *sitter.Node) string {
 	return ""
 }
 
+// goReceiverModifiers reports whether a method has a pointer or value receiver.
+func goReceiverModifiers(method *sitter.Node) []string {
+	recv := method.ChildByFieldName("receiver")
+	if recv == nil {
+		return nil
+	}
+	for i := 0; i < int(recv.NamedChildCount()); i++ {
+		param := recv.NamedChild(i)
+		if param.Type() != "parameter_declaration" {
+			continue
+		}
+		typeNode := param.ChildByFieldName("type")
+		if typeNode == nil {
+			continue
+		}
+		if typeNode.Type() == "pointer_type" {
+			return []string{"pointer_receiver"}
+		}
+		return []string{"value_receiver"}
+	}
+	return nil
+}
+
 func goExtractBaseType(source []byte, node *sitter.Node) string {
 	switch node.Type() {
 	case "type_identifier":
 		return nodeContent(source, node)
 	case "pointer_type":
 		for i := 0; i < int(node.NamedChildCount()); i++ {
-			child := node.NamedChild(i)
-			if child.Type() == "type_identifier" {
-				return nodeContent(source, child)
+			if base := goExtractBaseType(source, node.NamedChild(i)); base != "" {
+				return base
 			}
 		}
+	case "generic_type":
+		// Stack[T] → Stack: methods of a generic type share its name
+		if typeNode := node.ChildByFieldName("type"); typeNode != nil {
+			return goExtractBaseType(source, typeNode)
+		}
 	}
 	return ""
 }
@@ -252,6 +252,96 @@ func foo(a string, b int) bool {
 	}
 }
 
+func TestGoGenericCalls(t *testing.T) {
+	src := []byte(`package main
+
+func run() {
+	Map[int](xs, f)
+	Reduce[int, string](xs)
+	lo.Filter[User](xs)
+	handlers[i](x)
+}`)
+	result, err := ParseFile("test.go", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, callee := range []string{"Map", "Reduce", "lo.Filter", "handlers"} {
+		if findEdge(result.Edges, "calls", "run", callee) == nil {
+			t.Errorf("expected run calls %s", callee)
+		}
+	}
+}
+
+func TestGoGenericTypeArgs(t *testing.T) {
+	src := []byte(`package main
+
+func Load[T any](l List[User], m store.Map[Key, *Order]) Result[T] {
+	Map[Account](xs)
+	return nil
+}`)
+	result, err := ParseFile("test.go", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, typ := range []string{"List", "User", "store.Map", "Key", "Order", "Result", "Account"} {
+		if findEdge(result.Edges, "uses_type", "Load", typ) == nil {
+			t.Errorf("expected Load uses_type %s", typ)
+		}
+	}
+	if findEdge(result.Edges, "uses_type", "Load", "T") != nil {
+		t.Error("type parameter T should not be a uses_type edge")
+	}
+}
+
+func TestGoQualifiedAndCompositeTypes(t *testing.T) {
+	src := []byte(`package main
+
+type Registry[K comparable] struct {
+	Base
+	*store.Cache
+	byKey    map[K][]*Widget
+	handlers map[string]func(ctx context.Context, ev *events.Event) (Result, error)
+	ch       <-chan [4]store.Order
+	opts     struct {
+		Retry  *Policy
+		Logger interface{ Log(Entry) }
+	}
+	next *Registry[K]
+	name string
+}
+
+func Lookup(r *http.Request) (map[string][]*models.User, error) {
+	return nil, nil
+}`)
+	result, err := ParseFile("test.go", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, typ := range []string{"Widget", "context.Context", "events.Event", "Result", "store.Order", "Policy", "Entry"} {
+		if findEdge(result.Edges, "uses_type", "Registry", typ) == nil {
+			t.Errorf("expected Registry uses_type %s", typ)
+		}
+	}
+	for _, typ := range []string{"K", "Registry", "Base", "store.Cache", "string", "Context", "Order"} {
+		if findEdge(result.Edges, "uses_type", "Registry", typ) != nil {
+			t.Errorf("unexpected Registry uses_type %s", typ)
+		}
+	}
+	if findEdge(result.Edges, "embeds", "Registry", "store.Cache") == nil {
+		t.Error("expected Registry embeds store.Cache")
+	}
+
+	for _, typ := range []string{"http.Request", "models.User"} {
+		if findEdge(result.Edges, "uses_type", "Lookup", typ) == nil {
+			t.Errorf("expected Lookup uses_type %s", typ)
+		}
+	}
+	if findEdge(result.Edges, "uses_type", "Lookup", "Request") != nil {
+		t.Error("a qualified type should keep its package")
+	}
+}
+
 func TestGoEmptyFile(t *testing.T) {
 	src := []byte(`package main`)
 	result, err := ParseFile("test.go", src)
@@ -299,6 +389,77 @@ func (s Svc) Stop() {}`)
 	}
 }
 
+func TestGoReceiverModifiers(t *testing.T) {
+	src := []byte(`package main
+
+type Svc struct{}
+
+func (s *Svc) Run() {}
+func (s Svc) Stop() {}
+func helper() {}`)
+	result, err := ParseFile("test.go", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	run := findNode(result.Nodes, "Run")
+	if run == nil || len(run.Modifiers) != 1 || run.Modifiers[0] != "pointer_receiver" {
+		t.Errorf("expected Run to have pointer_receiver, got %v", run)
+	}
+	stop := findNode(result.Nodes, "Stop")
+	if stop == nil || len(stop.Modifiers) != 1 || stop.Modifiers[0] != "value_receiver" {
+		t.Errorf("expected Stop to have value_receiver, got %v", stop)
+	}
+	helper := findNode(result.Nodes, "helper")
+	if helper == nil || len(helper.Modifiers) != 0 {
+		t.Errorf("expected helper to have no modifiers, got %v", helper)
+	}
+}
+
+func TestGoGenericReceivers(t *testing.T) {
+	src := []byte(`package main
+
+type Stack[T any] struct{ items []T }
+type Pair[K comparable, V any] struct{ k K; v V }
+
+func (s *Stack[T]) Push(x T) { s.items = append(s.items, x) }
+func (s Stack[T]) Len() int { return len(s.items) }
+func (*Stack[_]) Reset() {}
+func (p Pair[K, V]) Key() K { return p.k }`)
+	result, err := ParseFile("test.go", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]struct{ qname, modifier string }{
+		"Push":  {"Stack.Push", "pointer_receiver"},
+		"Len":   {"Stack.Len", "value_receiver"},
+		"Reset": {"Stack.Reset", "pointer_receiver"},
+		"Key":   {"Pair.Key", "value_receiver"},
+	} {
+		n := findNode(result.Nodes, name)
+		if n == nil {
+			t.Errorf("expected %s method", name)
+			continue
+		}
+		if n.QualifiedName != want.qname {
+			t.Errorf("%s.QualifiedName = %q, want %q", name, n.QualifiedName, want.qname)
+		}
+		if len(n.Modifiers) != 1 || n.Modifiers[0] != want.modifier {
+			t.Errorf("%s.Modifiers = %v, want [%s]", name, n.Modifiers, want.modifier)
+		}
+	}
+
+	// Methods hang off the generic type like any other
+	contains := make(map[string]string)
+	for _, e := range findEdges(result.Edges, "contains") {
+		contains[e.Target] = e.Source
+	}
+	if contains["Stack.Push"] != "Stack" || contains["Pair.Key"] != "Pair" {
+		t.Errorf("expected contains edges from Stack and Pair, got %v", contains)
+	}
+}
+
 func TestGoPointerEmbed(t *testing.T) {
 	src := []byte(`package main
 
@@ -500,3 +661,169 @@ import _ "net/http/pprof"`)
 		t.Errorf("expected side-effect symbol, got %v", imp.Symbols)
 	}
 }
+
+func TestGoExportedAndDeprecated(t *testing.T) {
+	src := []byte(`package main
+
+type Svc struct{}
+
+type conn struct{}
+
+// Run starts the service.
+//
+// Deprecated: use Start.
+func (s *Svc) Run() {}
+
+func (c *conn) Close() {}
+
+func helper() {}`)
+	result, err := ParseFile("test.go", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		qname      string
+		exported   bool
+		deprecated bool
+	}{
+		{"Svc", true, false},
+		{"conn", false, false},
+		{"Svc.Run", true, true},
+		// Exported method on an unexported type isn't reachable from outside
+		{"conn.Close", false, false},
+		{"helper", false, false},
+	}
+	for _, tt := range tests {
+		var n *NodeInfo
+		for i := range result.Nodes {
+			if result.Nodes[i].QualifiedName == tt.qname {
+				n = &result.Nodes[i]
+			}
+		}
+		if n == nil {
+			t.Errorf("expected node %q", tt.qname)
+			continue
+		}
+		if n.Exported != tt.exported || n.Deprecated != tt.deprecated {
+			t.Errorf("%s: got exported=%v deprecated=%v, want %v %v", tt.qname, n.Exported, n.Deprecated, tt.exported, tt.deprecated)
+		}
+	}
+}
+
+func TestGoBodyHashIgnoresComments(t *testing.T) {
+	base := []byte(`package main
+
+// Sum adds numbers.
+func Sum(xs []int) int {
+	total := 0
+	for _, x := range xs {
+		total += x
+	}
+	return total
+}`)
+	commented := []byte(`package main
+
+// Sum adds all the numbers in xs.
+func Sum(xs []int) int {
+	total := 0 // running total
+	// walk every element
+	for _, x := range xs {
+		/* accumulate */ total += x
+	}
+	return total
+}`)
+	changed := []byte(`package main
+
+// Sum adds numbers.
+func Sum(xs []int) int {
+	total := 1
+	for _, x := range xs {
+		total += x
+	}
+	return total
+}`)
+
+	hash := func(src []byte) string {
+		t.Helper()
+		r, err := ParseFile("sum.go", src)
+		if err != nil || len(r.Nodes) == 0 {
+			t.Fatalf("parse: err=%v nodes=%d", err, len(r.Nodes))
+		}
+		return r.Nodes[0].BodyHash
+	}
+
+	if hash(base) != hash(commented) {
+		t.Error("comment-only edits should not change the body hash")
+	}
+	if hash(base) == hash(changed) {
+		t.Error("code edits should change the body hash")
+	}
+}
+
+func TestGoGeneratedFile(t *testing.T) {
+	generated := []byte(`// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: user.proto
+
+package userpb
+
+func (x *User) GetName() string { return x.Name }`)
+	r, err := ParseFile("user.pb.go", generated)
+	if err != nil || len(r.Nodes) == 0 {
+		t.Fatalf("parse: err=%v", err)
+	}
+	for _, n := range r.Nodes {
+		if !n.Generated {
+			t.Errorf("expected %s to be marked generated", n.QualifiedName)
+		}
+	}
+
+	// The marker only counts before the package clause.
+	handwritten := []byte(`package main
+
+// Code generated by hand. DO NOT EDIT.
+func main() {}`)
+	r, err = ParseFile("main.go", handwritten)
+	if err != nil || len(r.Nodes) == 0 {
+		t.Fatalf("parse: err=%v", err)
+	}
+	if r.Nodes[0].Generated {
+		t.Error("marker after the package clause should not mark the file generated")
+	}
+}
+
+func TestGoReferenceEdges(t *testing.T) {
+	src := []byte(`package main
+
+import (
+	"net/http"
+	"sort"
+
+	svc "github.com/acme/app/service"
+)
+
+func routes(mux *http.ServeMux, extra http.HandlerFunc) {
+	mux.HandleFunc("/", handleIndex)
+	mux.HandleFunc("/users", svc.ListUsers)
+	mux.Handle("/extra", extra)
+	sort.Slice(names, func(i, j int) bool { return less(names[i], names[j]) })
+	cfg := Config{Name: defaultName, Method: http.MethodGet}
+	serve(cfg)
+}`)
+	result, err := ParseFile("test.go", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, target := range []string{"handleIndex", "svc.ListUsers", "names", "defaultName"} {
+		if findEdge(result.Edges, "references", "routes", target) == nil {
+			t.Errorf("expected routes references %s", target)
+		}
+	}
+	// Parameters, locals, callees, struct keys, and stdlib members are not
+	for _, target := range []string{"mux", "extra", "cfg", "i", "less", "serve", "Name", "http.MethodGet", "svc"} {
+		if findEdge(result.Edges, "references", "routes", target) != nil {
+			t.Errorf("unexpected reference to %s", target)
+		}
+	}
+}
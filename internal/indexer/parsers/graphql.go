@@ -0,0 +1,584 @@
+package parsers
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var _ Parser = (*GraphQLParser)(nil)
+
+// GraphQLParser reads GraphQL SDL (.graphql/.gql). There is no tree-sitter
+// grammar for it in our bindings, so this is a small hand-written lexer and
+// recursive-descent parser that understands type system definitions and
+// extensions. Executable documents (queries, fragments) are skipped.
+//
+// Object types, interfaces, inputs, enums, unions, and scalars become nodes
+// of that kind; their fields become "field" nodes qualified by the type
+// (Order.total). Fields get uses_type edges to their named type and argument
+// types, unions to their members, and types to the interfaces they implement.
+// `extend type Query { ... }` becomes an "extend Query" node with an extends
+// edge to Query, so every file adding to a shared root type stays visible.
+type GraphQLParser struct{}
+
+func NewGraphQLParser() *GraphQLParser {
+	return &GraphQLParser{}
+}
+
+func (p *GraphQLParser) Parse(filePath string, source []byte) (*ParseResult, error) {
+	toks, err := lexGraphQL(source)
+	if err != nil {
+		return nil, err
+	}
+	g := &gqlParser{src: source, toks: toks, filePath: filePath, result: &ParseResult{}}
+	g.parseDocument()
+	for i := range g.result.Nodes {
+		g.result.Nodes[i].Exported = true
+	}
+	return g.result, nil
+}
+
+// graphqlBuiltinScalars are never defined in a schema, so no edges to them.
+var graphqlBuiltinScalars = map[string]bool{
+	"Int": true, "Float": true, "String": true, "Boolean": true, "ID": true,
+}
+
+// graphqlDefinitionKinds maps a definition keyword to the node kind it makes.
+var graphqlDefinitionKinds = map[string]string{
+	"type":      "type",
+	"interface": "interface",
+	"input":     "input",
+	"enum":      "enum",
+	"union":     "union",
+	"scalar":    "scalar",
+}
+
+// --- Lexer ---
+
+type gqlTokenKind int
+
+const (
+	gqlEOF gqlTokenKind = iota
+	gqlName
+	gqlPunct
+	gqlString
+	gqlNumber
+)
+
+type gqlToken struct {
+	kind       gqlTokenKind
+	value      string // names and punctuators as written, strings unescaped
+	start, end int    // byte offsets into the source
+	line       int    // 1-based line of start
+	endLine    int
+	// comment holds the `#` lines directly above the token, for
+	// definitions documented without a description string
+	comment string
+}
+
+func lexGraphQL(src []byte) ([]gqlToken, error) {
+	var toks []gqlToken
+	line := 1
+	var comment []string
+	lastCommentLine, lastTokenLine := 0, 0
+	i := 0
+	if strings.HasPrefix(string(src), "\uFEFF") {
+		i = len("\uFEFF")
+	}
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+			continue
+		case c == ' ' || c == '\t' || c == '\r' || c == ',':
+			i++
+			continue
+		case c == '#':
+			j := i
+			for j < len(src) && src[j] != '\n' {
+				j++
+			}
+			if lastTokenLine == line {
+				i = j // trailing comment, documents nothing
+				continue
+			}
+			if lastCommentLine != line-1 {
+				comment = nil
+			}
+			comment = append(comment, strings.TrimSpace(strings.TrimPrefix(string(src[i:j]), "#")))
+			lastCommentLine = line
+			i = j
+			continue
+		}
+
+		tok := gqlToken{start: i, line: line}
+		if lastCommentLine > 0 && lastCommentLine == line-1 {
+			tok.comment = strings.Join(comment, "\n")
+		}
+		comment, lastCommentLine = nil, 0
+
+		switch {
+		case c == '"':
+			value, end, lines, err := lexGraphQLString(src, i)
+			if err != nil {
+				return nil, fmt.Errorf("graphql: %w at line %d", err, line)
+			}
+			tok.kind, tok.value = gqlString, value
+			i = end
+			line += lines
+		case c == '.' && i+2 < len(src) && src[i+1] == '.' && src[i+2] == '.':
+			tok.kind, tok.value = gqlPunct, "..."
+			i += 3
+		case strings.IndexByte("!$&():=@[]{}|", c) >= 0:
+			tok.kind, tok.value = gqlPunct, string(c)
+			i++
+		case c == '-' || (c >= '0' && c <= '9'):
+			j := i + 1
+			for j < len(src) && (isGraphQLNameByte(src[j]) || src[j] == '.' || src[j] == '+' || src[j] == '-') {
+				j++
+			}
+			tok.kind, tok.value = gqlNumber, string(src[i:j])
+			i = j
+		case isGraphQLNameByte(c):
+			j := i + 1
+			for j < len(src) && isGraphQLNameByte(src[j]) {
+				j++
+			}
+			tok.kind, tok.value = gqlName, string(src[i:j])
+			i = j
+		default:
+			// Not valid GraphQL; skip the byte rather than fail the file
+			i++
+			continue
+		}
+		tok.end = i
+		tok.endLine = line
+		lastTokenLine = line
+		toks = append(toks, tok)
+	}
+	toks = append(toks, gqlToken{kind: gqlEOF, start: len(src), end: len(src), line: line, endLine: line})
+	return toks, nil
+}
+
+func isGraphQLNameByte(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// lexGraphQLString reads a "string" or """block string""" starting at i and
+// returns its value, the offset after it, and how many newlines it spans.
+func lexGraphQLString(src []byte, i int) (string, int, int, error) {
+	if strings.HasPrefix(string(src[i:]), `"""`) {
+		body := string(src[i+3:])
+		end := 0
+		for {
+			k := strings.Index(body[end:], `"""`)
+			if k < 0 {
+				return "", 0, 0, fmt.Errorf("unterminated block string")
+			}
+			end += k
+			if end > 0 && body[end-1] == '\\' {
+				end += 3
+				continue
+			}
+			break
+		}
+		raw := body[:end]
+		return blockStringValue(strings.ReplaceAll(raw, `\"""`, `"""`)), i + 3 + end + 3, strings.Count(raw, "\n"), nil
+	}
+	for j := i + 1; j < len(src); j++ {
+		switch src[j] {
+		case '\\':
+			j++
+		case '\n':
+			return "", 0, 0, fmt.Errorf("unterminated string")
+		case '"':
+			raw := string(src[i : j+1])
+			if value, err := strconv.Unquote(raw); err == nil {
+				return value, j + 1, 0, nil
+			}
+			return raw[1 : len(raw)-1], j + 1, 0, nil
+		}
+	}
+	return "", 0, 0, fmt.Errorf("unterminated string")
+}
+
+// blockStringValue strips the common indentation and surrounding blank lines
+// from a block string, per the GraphQL spec.
+func blockStringValue(raw string) string {
+	lines := strings.Split(strings.ReplaceAll(raw, "\r\n", "\n"), "\n")
+	indent := -1
+	for _, l := range lines[1:] {
+		trimmed := strings.TrimLeft(l, " \t")
+		if trimmed == "" {
+			continue
+		}
+		if n := len(l) - len(trimmed); indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	if indent > 0 {
+		for i := 1; i < len(lines); i++ {
+			if len(lines[i]) >= indent {
+				lines[i] = lines[i][indent:]
+			} else {
+				lines[i] = strings.TrimLeft(lines[i], " \t")
+			}
+		}
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// --- Parser ---
+
+type gqlParser struct {
+	src      []byte
+	toks     []gqlToken
+	pos      int
+	filePath string
+	result   *ParseResult
+}
+
+func (g *gqlParser) peek() gqlToken { return g.toks[g.pos] }
+
+func (g *gqlParser) next() gqlToken {
+	t := g.toks[g.pos]
+	if t.kind != gqlEOF {
+		g.pos++
+	}
+	return t
+}
+
+func (g *gqlParser) is(value string) bool {
+	t := g.peek()
+	return (t.kind == gqlPunct || t.kind == gqlName) && t.value == value
+}
+
+func (g *gqlParser) accept(value string) bool {
+	if g.is(value) {
+		g.pos++
+		return true
+	}
+	return false
+}
+
+func (g *gqlParser) parseDocument() {
+	for g.peek().kind != gqlEOF {
+		start := g.pos
+		g.parseDefinition()
+		if g.pos == start {
+			g.next() // never stall on something unexpected
+		}
+	}
+}
+
+func (g *gqlParser) parseDefinition() {
+	first := g.peek()
+	doc := first.comment
+	if first.kind == gqlString {
+		doc = g.next().value
+	}
+
+	t := g.peek()
+	if t.kind == gqlPunct && t.value == "{" {
+		g.skipBalanced("{", "}") // anonymous query
+		return
+	}
+	if t.kind != gqlName {
+		return
+	}
+	switch t.value {
+	case "extend":
+		g.next()
+		if kw := g.peek(); kw.kind == gqlName && graphqlDefinitionKinds[kw.value] != "" {
+			g.parseTypeDefinition(first, "", true)
+		} else {
+			g.skipDefinition()
+		}
+	case "type", "interface", "input", "enum", "union", "scalar":
+		g.parseTypeDefinition(first, doc, false)
+	case "directive":
+		g.skipDirectiveDefinition()
+	default:
+		// schema { ... }, operations, and fragments declare no types
+		g.skipDefinition()
+	}
+}
+
+// parseTypeDefinition parses from the definition keyword on. first is the
+// token the definition starts at (its description or "extend").
+func (g *gqlParser) parseTypeDefinition(first gqlToken, doc string, extension bool) {
+	kw := g.next()
+	kind := graphqlDefinitionKinds[kw.value]
+	// The signature starts at the keyword, after any description
+	sigStart := kw.start
+	if extension {
+		sigStart = first.start
+	}
+	nameTok := g.next()
+	if nameTok.kind != gqlName {
+		return
+	}
+	name := nameTok.value
+	qname := name
+	if extension {
+		qname = "extend " + name
+	}
+
+	var edges []EdgeInfo
+	if extension {
+		edges = append(edges, EdgeInfo{Source: qname, Target: name, Kind: "extends", Line: nameTok.line})
+	}
+	if g.accept("implements") {
+		g.accept("&")
+		for t := g.peek(); t.kind == gqlName && graphqlDefinitionKinds[t.value] == "" && t.value != "extend"; t = g.peek() {
+			g.next()
+			edges = append(edges, EdgeInfo{Source: qname, Target: t.value, Kind: "implements", Line: t.line})
+			if !g.accept("&") && g.peek().line != t.line {
+				break
+			}
+		}
+	}
+	deprecated := g.skipDirectives()
+	headerEnd := g.toks[g.pos-1].end
+
+	var fields []NodeInfo
+	var fieldEdges []EdgeInfo
+	switch {
+	case kind == "union" && g.accept("="):
+		g.accept("|")
+		for t := g.peek(); t.kind == gqlName; t = g.peek() {
+			g.next()
+			edges = append(edges, EdgeInfo{Source: qname, Target: t.value, Kind: "uses_type", Line: t.line})
+			if !g.accept("|") {
+				break
+			}
+		}
+	case g.is("{"):
+		g.next()
+		fields, fieldEdges = g.parseFields(kind, name, qname)
+		g.accept("}")
+	}
+
+	last := g.toks[g.pos-1]
+	code := string(g.src[first.start:last.end])
+	node := NodeInfo{
+		Name:          name,
+		QualifiedName: qname,
+		Kind:          kind,
+		Signature:     strings.Join(strings.Fields(string(g.src[sigStart:headerEnd])), " "),
+		StartLine:     first.line,
+		EndLine:       last.endLine,
+		SourceCode:    code,
+		Docstring:     doc,
+		BodyHash:      fmt.Sprintf("%x", sha256.Sum256([]byte(code))),
+		Deprecated:    deprecated,
+	}
+	node.ReleaseTag, _ = parseDocTags(doc)
+
+	g.result.Nodes = append(g.result.Nodes, node)
+	g.result.Nodes = append(g.result.Nodes, fields...)
+	g.result.Edges = append(g.result.Edges, EdgeInfo{Source: g.filePath, Target: qname, Kind: "contains", Line: node.StartLine})
+	g.result.Edges = append(g.result.Edges, edges...)
+	g.result.Edges = append(g.result.Edges, fieldEdges...)
+}
+
+// parseFields parses the body of a type up to its closing brace. Enum values
+// aren't nodes: they're plain names, and the enum's source already lists them.
+func (g *gqlParser) parseFields(kind, typeName, parent string) ([]NodeInfo, []EdgeInfo) {
+	var nodes []NodeInfo
+	var edges []EdgeInfo
+	for !g.is("}") && g.peek().kind != gqlEOF {
+		first := g.peek()
+		doc := first.comment
+		if first.kind == gqlString {
+			doc = g.next().value
+		}
+		if g.is("}") {
+			break
+		}
+		nameTok := g.next()
+		if nameTok.kind != gqlName {
+			continue
+		}
+		if kind == "enum" {
+			g.skipDirectives()
+			continue
+		}
+
+		qname := typeName + "." + nameTok.value
+		var refs []gqlToken
+		if g.is("(") {
+			refs = append(refs, g.parseArguments()...)
+		}
+		if !g.accept(":") {
+			continue
+		}
+		if ref, ok := g.parseTypeRef(); ok {
+			refs = append([]gqlToken{ref}, refs...)
+		}
+		if g.accept("=") {
+			g.skipValue()
+		}
+		sigEnd := g.toks[g.pos-1].end
+		deprecated := g.skipDirectives()
+
+		last := g.toks[g.pos-1]
+		code := string(g.src[first.start:last.end])
+		node := NodeInfo{
+			Name:          nameTok.value,
+			QualifiedName: qname,
+			Kind:          "field",
+			Signature:     strings.Join(strings.Fields(string(g.src[nameTok.start:sigEnd])), " "),
+			StartLine:     first.line,
+			EndLine:       last.endLine,
+			SourceCode:    code,
+			Docstring:     doc,
+			BodyHash:      fmt.Sprintf("%x", sha256.Sum256([]byte(code))),
+			Deprecated:    deprecated,
+		}
+		node.ReleaseTag, _ = parseDocTags(doc)
+		nodes = append(nodes, node)
+		edges = append(edges, EdgeInfo{Source: parent, Target: qname, Kind: "contains", Line: node.StartLine})
+
+		seen := make(map[string]bool)
+		for _, ref := range refs {
+			if seen[ref.value] || graphqlBuiltinScalars[ref.value] {
+				continue
+			}
+			seen[ref.value] = true
+			edges = append(edges, EdgeInfo{Source: qname, Target: ref.value, Kind: "uses_type", Line: ref.line})
+		}
+	}
+	return nodes, edges
+}
+
+// parseArguments parses `(name: Type = default @dir, ...)` and returns the
+// named type token of each argument.
+func (g *gqlParser) parseArguments() []gqlToken {
+	g.next() // (
+	var refs []gqlToken
+	for !g.is(")") && g.peek().kind != gqlEOF {
+		if g.peek().kind == gqlString {
+			g.next()
+		}
+		if g.next().kind != gqlName || !g.accept(":") {
+			continue
+		}
+		if ref, ok := g.parseTypeRef(); ok {
+			refs = append(refs, ref)
+		}
+		if g.accept("=") {
+			g.skipValue()
+		}
+		g.skipDirectives()
+	}
+	g.accept(")")
+	return refs
+}
+
+// parseTypeRef parses `Name`, `[Type]`, and their `!` forms, returning the
+// innermost named type.
+func (g *gqlParser) parseTypeRef() (gqlToken, bool) {
+	var ref gqlToken
+	ok := false
+	if g.accept("[") {
+		ref, ok = g.parseTypeRef()
+		g.accept("]")
+	} else if t := g.peek(); t.kind == gqlName {
+		ref, ok = g.next(), true
+	}
+	g.accept("!")
+	return ref, ok
+}
+
+// skipDirectives skips `@name(args)` annotations and reports whether one of
+// them was @deprecated.
+func (g *gqlParser) skipDirectives() bool {
+	deprecated := false
+	for g.accept("@") {
+		if t := g.next(); t.value == "deprecated" {
+			deprecated = true
+		}
+		if g.is("(") {
+			g.skipBalanced("(", ")")
+		}
+	}
+	return deprecated
+}
+
+func (g *gqlParser) skipValue() {
+	switch {
+	case g.is("["):
+		g.skipBalanced("[", "]")
+	case g.is("{"):
+		g.skipBalanced("{", "}")
+	case g.accept("$"):
+		g.next()
+	default:
+		g.next()
+	}
+}
+
+func (g *gqlParser) skipBalanced(open, close string) {
+	depth := 0
+	for t := g.peek(); t.kind != gqlEOF; t = g.peek() {
+		g.next()
+		if t.kind != gqlPunct {
+			continue
+		}
+		switch t.value {
+		case open:
+			depth++
+		case close:
+			depth--
+			if depth == 0 {
+				return
+			}
+		}
+	}
+}
+
+// skipDirectiveDefinition skips `directive @name(args) repeatable on A | B`.
+func (g *gqlParser) skipDirectiveDefinition() {
+	g.next() // directive
+	g.accept("@")
+	g.next()
+	if g.is("(") {
+		g.skipBalanced("(", ")")
+	}
+	g.accept("repeatable")
+	if g.accept("on") {
+		g.accept("|")
+		for g.peek().kind == gqlName {
+			g.next()
+			if !g.accept("|") {
+				break
+			}
+		}
+	}
+}
+
+// skipDefinition skips a definition without types in it: everything up to
+// and including its first top-level brace block.
+func (g *gqlParser) skipDefinition() {
+	for t := g.peek(); t.kind != gqlEOF; t = g.peek() {
+		switch {
+		case g.is("{"):
+			g.skipBalanced("{", "}")
+			return
+		case g.is("("):
+			g.skipBalanced("(", ")") // variable defaults may hold braces
+		default:
+			g.next()
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package parsers
+
+import (
+	"testing"
+)
+
+func findNodeByQName(nodes []NodeInfo, qname string) *NodeInfo {
+	for i := range nodes {
+		if nodes[i].QualifiedName == qname {
+			return &nodes[i]
+		}
+	}
+	return nil
+}
+
+func TestGraphQLParseNodes(t *testing.T) {
+	path, src := readFixture(t, "graphql", "schema.graphql")
+	result, err := ParseFile(path, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kinds := map[string]string{
+		"Node":              "interface",
+		"Node.id":           "field",
+		"Money":             "scalar",
+		"Role":              "enum",
+		"Order":             "type",
+		"Order.items":       "field",
+		"Order.total":       "field",
+		"ItemFilter":        "input",
+		"ItemFilter.sku":    "field",
+		"SearchResult":      "union",
+		"Query":             "type",
+		"Query.search":      "field",
+		"extend Query":      "type",
+		"Query.orders":      "field",
+		"Order.legacyTotal": "field",
+	}
+	for qname, kind := range kinds {
+		n := findNodeByQName(result.Nodes, qname)
+		if n == nil {
+			t.Errorf("expected node %s", qname)
+			continue
+		}
+		if n.Kind != kind {
+			t.Errorf("%s: expected kind %s, got %s", qname, kind, n.Kind)
+		}
+		if !n.Exported {
+			t.Errorf("%s: expected schema nodes to be exported", qname)
+		}
+	}
+
+	// Enum values, the operation, and the directive definition aren't nodes
+	for _, name := range []string{"ADMIN", "GetOrder", "auth", "Role.ADMIN"} {
+		if findNodeByQName(result.Nodes, name) != nil {
+			t.Errorf("unexpected node %s", name)
+		}
+	}
+	if len(result.Nodes) != len(kinds)+3 { // + Order.id, ItemFilter.minPrice, Query.order
+		t.Errorf("expected %d nodes, got %v", len(kinds)+3, nodeNames(result.Nodes))
+	}
+}
+
+func TestGraphQLDocsAndSignatures(t *testing.T) {
+	path, src := readFixture(t, "graphql", "schema.graphql")
+	result, err := ParseFile(path, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	order := findNodeByQName(result.Nodes, "Order")
+	if order.Docstring != "An order placed by a customer." {
+		t.Errorf("unexpected Order docstring %q", order.Docstring)
+	}
+	if order.Signature != "type Order implements Node & Timestamped @auth(requires: CUSTOMER)" {
+		t.Errorf("unexpected Order signature %q", order.Signature)
+	}
+	if order.StartLine != 23 || order.EndLine != 30 {
+		t.Errorf("expected Order at 23-30, got %d-%d", order.StartLine, order.EndLine)
+	}
+
+	if node := findNodeByQName(result.Nodes, "Node"); node.Docstring != "Anything with a global ID." {
+		t.Errorf("expected block string description, got %q", node.Docstring)
+	}
+	if money := findNodeByQName(result.Nodes, "Money"); money.Docstring != "Money is stored in cents." {
+		t.Errorf("expected comment docstring, got %q", money.Docstring)
+	}
+
+	items := findNodeByQName(result.Nodes, "Order.items")
+	if items.Signature != "items(first: Int = 10, filter: ItemFilter): [LineItem!]!" {
+		t.Errorf("unexpected items signature %q", items.Signature)
+	}
+	if items.Docstring != "Line items in display order." || items.StartLine != 26 {
+		t.Errorf("unexpected items doc/line: %q line %d", items.Docstring, items.StartLine)
+	}
+
+	// A trailing comment documents nothing
+	if total := findNodeByQName(result.Nodes, "Order.total"); total.Docstring != "" {
+		t.Errorf("expected no docstring on total, got %q", total.Docstring)
+	}
+	if legacy := findNodeByQName(result.Nodes, "Order.legacyTotal"); !legacy.Deprecated || legacy.Docstring != "" {
+		t.Errorf("expected @deprecated to mark legacyTotal: %+v", legacy)
+	}
+
+	if ext := findNodeByQName(result.Nodes, "extend Query"); ext.Name != "Query" || ext.Signature != "extend type Query" {
+		t.Errorf("unexpected extension node %+v", ext)
+	}
+}
+
+func TestGraphQLEdges(t *testing.T) {
+	path, src := readFixture(t, "graphql", "schema.graphql")
+	result, err := ParseFile(path, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct{ kind, source, target string }{
+		{"contains", path, "Order"},
+		{"contains", path, "extend Query"},
+		{"contains", "Order", "Order.items"},
+		{"contains", "extend Query", "Query.orders"},
+		{"implements", "Order", "Node"},
+		{"implements", "Order", "Timestamped"},
+		{"extends", "extend Query", "Query"},
+		{"uses_type", "Order.items", "LineItem"},
+		{"uses_type", "Order.items", "ItemFilter"},
+		{"uses_type", "Order.total", "Money"},
+		{"uses_type", "ItemFilter.minPrice", "Money"},
+		{"uses_type", "SearchResult", "Order"},
+		{"uses_type", "SearchResult", "LineItem"},
+		{"uses_type", "Query.orders", "Role"},
+		{"uses_type", "Query.orders", "Order"},
+	}
+	for _, w := range want {
+		if findEdge(result.Edges, w.kind, w.source, w.target) == nil {
+			t.Errorf("expected %s edge %s -> %s", w.kind, w.source, w.target)
+		}
+	}
+
+	// Built-in scalars have no definition to point at
+	for _, e := range findEdges(result.Edges, "uses_type") {
+		if graphqlBuiltinScalars[e.Target] {
+			t.Errorf("unexpected edge to built-in scalar %+v", e)
+		}
+	}
+}
+
+func TestGraphQLUnterminatedString(t *testing.T) {
+	if _, err := ParseFile("bad.gql", []byte("type A {\n  \"oops\n  id: ID\n}\n")); err == nil {
+		t.Error("expected an error for an unterminated string")
+	}
+}
@@ -17,6 +17,91 @@ func computeBodyHash(source []byte, node *sitter.Node) string {
 	return fmt.Sprintf("%x", h)
 }
 
+// computeBodyHashWithoutComments hashes node with every comment descendant
+// cut out, so comment-only edits don't count as a change. Whitespace runs
+// are collapsed (to a newline if they contain one, else a space) so the gap a
+// removed comment leaves behind doesn't count either. Parsers opt in per
+// language: only use it where comments can't change behavior.
+func computeBodyHashWithoutComments(source []byte, node *sitter.Node) string {
+	var b []byte
+	pos := node.StartByte()
+	var walk func(n *sitter.Node)
+	walk = func(n *sitter.Node) {
+		for i := 0; i < int(n.NamedChildCount()); i++ {
+			c := n.NamedChild(i)
+			if c.Type() == "comment" {
+				b = append(b, source[pos:c.StartByte()]...)
+				pos = c.EndByte()
+				continue
+			}
+			walk(c)
+		}
+	}
+	walk(node)
+	b = append(b, source[pos:node.EndByte()]...)
+
+	h := sha256.Sum256(collapseWhitespace(b))
+	return fmt.Sprintf("%x", h)
+}
+
+// computeStructuralHash hashes the shape of node's syntax tree: every node's
+// type, plus the text of literals. Anonymous tokens (keywords, operators,
+// punctuation) are their own type, while identifiers of every flavor
+// (identifier, type_identifier, property_identifier, ...) contribute only
+// their type, and comments and statement terminators (";", Go's newlines)
+// are skipped. Only tree-sitter's grammar is consulted, so it works for any
+// language: two functions that differ only in naming, formatting, or
+// comments hash the same.
+func computeStructuralHash(source []byte, node *sitter.Node) string {
+	h := sha256.New()
+	var walk func(n *sitter.Node)
+	walk = func(n *sitter.Node) {
+		typ := n.Type()
+		if typ == "comment" || (!n.IsNamed() && (typ == ";" || typ == "\n")) {
+			return
+		}
+		h.Write([]byte(typ))
+		count := int(n.ChildCount())
+		if count == 0 && n.IsNamed() && !strings.HasSuffix(typ, "identifier") {
+			h.Write([]byte{'='})
+			h.Write(source[n.StartByte():n.EndByte()])
+		}
+		if count > 0 {
+			h.Write([]byte{'('})
+			for i := 0; i < count; i++ {
+				walk(n.Child(i))
+			}
+			h.Write([]byte{')'})
+		}
+		h.Write([]byte{0})
+	}
+	walk(node)
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+func collapseWhitespace(b []byte) []byte {
+	out := make([]byte, 0, len(b))
+	for i := 0; i < len(b); {
+		if !isSpace(b[i]) {
+			out = append(out, b[i])
+			i++
+			continue
+		}
+		sep := byte(' ')
+		for ; i < len(b) && isSpace(b[i]); i++ {
+			if b[i] == '\n' {
+				sep = '\n'
+			}
+		}
+		out = append(out, sep)
+	}
+	return out
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
 // extractDocstring looks for JSDoc (/** ... */) or consecutive // comments
 // immediately preceding the given node.
 func extractDocstring(source []byte, node *sitter.Node) string {
@@ -76,3 +161,46 @@ func cleanDocstring(s string) string {
 	}
 	return strings.Join(cleaned, "\n")
 }
+
+// releaseTagAliases maps TSDoc/JSDoc tags to the release tag they set.
+var releaseTagAliases = map[string]string{
+	"public":   "public",
+	"beta":     "beta",
+	"alpha":    "alpha",
+	"internal": "internal",
+	"private":  "internal",
+}
+
+// parseDocTags reads the release tag and deprecation marker from a cleaned
+// docstring. Understands JSDoc `@internal`/`@public`/`@alpha`/`@beta`/
+// `@deprecated` and Go's `Deprecated:` paragraph convention. The first
+// release tag found wins.
+func parseDocTags(doc string) (releaseTag string, deprecated bool) {
+	for _, line := range strings.Split(doc, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "Deprecated:") {
+			deprecated = true
+			continue
+		}
+		for _, field := range strings.Fields(line) {
+			tag, ok := strings.CutPrefix(field, "@")
+			if !ok {
+				continue
+			}
+			tag = strings.ToLower(tag)
+			if tag == "deprecated" {
+				deprecated = true
+			} else if rt, ok := releaseTagAliases[tag]; ok && releaseTag == "" {
+				releaseTag = rt
+			}
+		}
+	}
+	return releaseTag, deprecated
+}
+
+// applyDocTags fills ReleaseTag and Deprecated on every node from its docstring.
+func applyDocTags(nodes []NodeInfo) {
+	for i := range nodes {
+		nodes[i].ReleaseTag, nodes[i].Deprecated = parseDocTags(nodes[i].Docstring)
+	}
+}
@@ -1,26 +1,57 @@
 package parsers
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
 )
 
 /**
  * This is the main parser file and acts as the entry point
  * to extend the parser to support another language, create a parser and
- * simply extend the `init()` function in L62
+ * register it with `RegisterParser()` (the built-ins are registered in `init()`)
  */
 
 type NodeInfo struct {
-	Name          string `json:"name"`
-	QualifiedName string `json:"qualifiedName"`
-	Kind          string `json:"kind"`
-	Signature     string `json:"signature"`
-	StartLine     int    `json:"startLine"`
-	EndLine       int    `json:"endLine"`
-	SourceCode    string `json:"sourceCode"`
-	Docstring     string `json:"docstring"`
-	BodyHash      string `json:"bodyHash"`
+	Name          string   `json:"name"`
+	QualifiedName string   `json:"qualifiedName"`
+	Kind          string   `json:"kind"`
+	Signature     string   `json:"signature"`
+	StartLine     int      `json:"startLine"`
+	EndLine       int      `json:"endLine"`
+	SourceCode    string   `json:"sourceCode"`
+	Docstring     string   `json:"docstring"`
+	BodyHash      string   `json:"bodyHash"`
+	Modifiers     []string `json:"modifiers,omitempty"`
+	// StructuralHash fingerprints the node's syntax tree with identifiers,
+	// comments, and whitespace left out, so copies that only rename
+	// variables share it. Empty for nodes not parsed by tree-sitter.
+	StructuralHash string `json:"structuralHash,omitempty"`
+	// Exported is true when the symbol is visible outside its module:
+	// a TS/JS export, or a capitalized Go identifier.
+	Exported bool `json:"exported"`
+	// ReleaseTag is the API maturity from doc tags: "public", "beta", "alpha",
+	// "internal", or "" when untagged.
+	ReleaseTag string `json:"releaseTag,omitempty"`
+	Deprecated bool   `json:"deprecated,omitempty"`
+	// DefaultExport marks the node bound to the module's `export default`.
+	DefaultExport bool `json:"defaultExport,omitempty"`
+	// Generated is set on every node of a file marked as machine-generated
+	// (`// Code generated ... DO NOT EDIT.` in Go, `@generated` in TS/JS), or
+	// named like codegen output (see IsGeneratedPath).
+	Generated bool `json:"generated,omitempty"`
+	// Fixture is set on every node of a file under a fixture directory
+	// (FIXTURE_DIRS) indexed in tag mode. The pipeline sets it, not parsers.
+	Fixture bool `json:"fixture,omitempty"`
+	// FilePath places a node that no contains edge ties to a file, such as a
+	// synthesized Go package node. Parsers leave it empty.
+	FilePath string `json:"filePath,omitempty"`
 }
 
 type EdgeInfo struct {
@@ -29,11 +60,66 @@ type EdgeInfo struct {
 	Kind    string   `json:"kind"`
 	Line    int      `json:"line"`
 	Symbols []string `json:"symbols,omitempty"`
+	// DefaultImport is the local name bound to the imported module's default
+	// export (`import Button from './Button'` → "Button"), if any.
+	DefaultImport string `json:"defaultImport,omitempty"`
+	// TypeOnly marks an import erased at runtime: `import type { X }`, or one
+	// whose every specifier is inline `type`. TypeOnlySymbols lists which of
+	// Symbols are type-only, including inline ones on mixed imports.
+	TypeOnly        bool     `json:"typeOnly,omitempty"`
+	TypeOnlySymbols []string `json:"typeOnlySymbols,omitempty"`
+}
+
+// Kind groups normalize the language-specific node kinds, so cross-language
+// queries can ask for "all types" or "all callables" without listing every
+// parser's vocabulary.
+const (
+	KindGroupType     = "type"
+	KindGroupCallable = "callable"
+	KindGroupValue    = "value"
+	KindGroupModule   = "module"
+)
+
+var kindGroups = map[string]string{
+	"class":      KindGroupType,
+	"struct":     KindGroupType,
+	"interface":  KindGroupType,
+	"type_alias": KindGroupType,
+	"enum":       KindGroupType,
+	"type":       KindGroupType,
+	"input":      KindGroupType,
+	"union":      KindGroupType,
+	"scalar":     KindGroupType,
+	"table":      KindGroupType,
+	"view":       KindGroupType,
+	"message":    KindGroupType,
+	"service":    KindGroupType,
+	"function":   KindGroupCallable,
+	"method":     KindGroupCallable,
+	"component":  KindGroupCallable,
+	"query":      KindGroupCallable,
+	"rpc":        KindGroupCallable,
+	"field":      KindGroupValue,
+	"variable":   KindGroupValue,
+	"const":      KindGroupValue,
+	"package":    KindGroupModule,
+	"module":     KindGroupModule,
+}
+
+// KindGroup returns the group a node kind belongs to, or "" for an
+// ungrouped kind such as "endpoint" or a kind no parser emits.
+func KindGroup(kind string) string {
+	return kindGroups[kind]
 }
 
 type ParseResult struct {
 	Nodes []NodeInfo `json:"nodes"`
 	Edges []EdgeInfo `json:"edges"`
+	// HasSyntaxErrors is set when the file didn't parse cleanly. The nodes
+	// and edges are what could be recovered around SyntaxErrors, the line
+	// ranges tree-sitter couldn't make sense of.
+	HasSyntaxErrors bool        `json:"hasSyntaxErrors,omitempty"`
+	SyntaxErrors    []LineRange `json:"syntaxErrors,omitempty"`
 }
 
 func (r *ParseResult) Stats() map[string]any {
@@ -57,25 +143,291 @@ type Parser interface {
 	Parse(filePath string, source []byte) (*ParseResult, error)
 }
 
-var registry map[string]Parser
+// ContextParser is a Parser that stops early when ctx is done, as the
+// tree-sitter parsers do. ParseFileCtx prefers ParseCtx when a parser has
+// it; other parsers are abandoned at the deadline and finish in the
+// background.
+type ContextParser interface {
+	Parser
+	ParseCtx(ctx context.Context, filePath string, source []byte) (*ParseResult, error)
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Parser)
+)
 
 func init() {
 	ts := NewTypeScriptParser()
 	gp := NewGoParser()
-	registry = map[string]Parser{
-		".ts":  ts,
-		".tsx": ts,
-		".js":  ts,
-		".jsx": ts,
-		".go":  gp,
+	RegisterParser([]string{".ts", ".tsx", ".js", ".jsx"}, ts)
+	RegisterParser([]string{".go"}, gp)
+	RegisterParser([]string{".graphql", ".gql"}, NewGraphQLParser())
+	RegisterParser([]string{".vue", ".svelte"}, NewSFCParser())
+}
+
+// RegisterParser maps file extensions (with leading dot) to a parser.
+// Registering an extension that already has a parser replaces it, so
+// third-party parsers can override the built-ins.
+func RegisterParser(exts []string, p Parser) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	for _, ext := range exts {
+		registry[ext] = p
 	}
 }
 
+// HasParser reports whether a parser is registered for the extension.
+func HasParser(ext string) bool {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	_, ok := registry[ext]
+	return ok
+}
+
+func lookupParser(ext string) (Parser, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	p, ok := registry[ext]
+	return p, ok
+}
+
+// interpreterExts maps shebang interpreters to the extension whose parser
+// handles their scripts. Interpreters without a registered parser are ignored.
+var interpreterExts = map[string]string{
+	"node":    ".js",
+	"nodejs":  ".js",
+	"bun":     ".ts",
+	"deno":    ".ts",
+	"ts-node": ".ts",
+	"tsx":     ".ts",
+	"python":  ".py",
+	"python3": ".py",
+	"bash":    ".sh",
+	"sh":      ".sh",
+	"zsh":     ".sh",
+}
+
+// ShebangExtension reads the first line of source and, if it is a shebang
+// (`#!/usr/bin/node`, `#!/usr/bin/env -S deno run`), returns the extension of
+// the registered parser for that interpreter. Returns "" otherwise.
+func ShebangExtension(source []byte) string {
+	if !bytes.HasPrefix(source, []byte("#!")) {
+		return ""
+	}
+	line := source[2:]
+	if i := bytes.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+	fields := strings.Fields(string(line))
+	if len(fields) == 0 {
+		return ""
+	}
+
+	interpreter := filepath.Base(fields[0])
+	if interpreter == "env" {
+		// Skip env flags like -S to reach the interpreter name
+		interpreter = ""
+		for _, f := range fields[1:] {
+			if !strings.HasPrefix(f, "-") {
+				interpreter = filepath.Base(f)
+				break
+			}
+		}
+	}
+
+	ext, ok := interpreterExts[interpreter]
+	if !ok || !HasParser(ext) {
+		return ""
+	}
+	return ext
+}
+
+// ParseFile dispatches to the parser registered for the file's extension.
+// Files with an unknown or missing extension fall back to shebang sniffing.
 func ParseFile(filePath string, source []byte) (*ParseResult, error) {
+	return ParseFileCtx(context.Background(), filePath, source)
+}
+
+// ParseFileCtx is ParseFile bounded by ctx and by MaxParseDuration, so a
+// pathological file fails with an error wrapping
+// context.DeadlineExceeded instead of stalling its caller.
+func ParseFileCtx(ctx context.Context, filePath string, source []byte) (*ParseResult, error) {
+	timeout := MaxParseDuration()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	var result *ParseResult
+	var err error
+	if ctx.Done() == nil {
+		result, err = parseFile(ctx, filePath, source)
+	} else {
+		result, err = parseFileBounded(ctx, filePath, source)
+	}
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) && timeout > 0 {
+			return nil, fmt.Errorf("parse exceeded %s: %w", timeout, err)
+		}
+		return nil, err
+	}
+	generated := IsGeneratedPath(filePath)
+	limit := MaxNodeSourceBytes()
+	for i := range result.Nodes {
+		if generated {
+			result.Nodes[i].Generated = true
+		}
+		result.Nodes[i].SourceCode = truncateSource(result.Nodes[i].SourceCode, limit)
+	}
+	return result, nil
+}
+
+// parseFileBounded runs parseFile in the background and returns ctx's error
+// as soon as ctx is done, whether or not the parser has noticed.
+func parseFileBounded(ctx context.Context, filePath string, source []byte) (*ParseResult, error) {
+	type parsed struct {
+		result *ParseResult
+		err    error
+	}
+	done := make(chan parsed, 1)
+	go func() {
+		result, err := parseFile(ctx, filePath, source)
+		done <- parsed{result, err}
+	}()
+	select {
+	case p := <-done:
+		return p.result, p.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// DefaultMaxParseDuration bounds a single file's parse at 30 seconds,
+// orders of magnitude above what a real source file needs.
+const DefaultMaxParseDuration = 30 * time.Second
+
+var (
+	maxParseMu       sync.RWMutex
+	maxParseDuration = DefaultMaxParseDuration
+)
+
+// SetMaxParseDuration sets how long ParseFileCtx lets one file's parse run.
+// 0 or less disables the limit; MAX_PARSE_DURATION sets it.
+func SetMaxParseDuration(d time.Duration) {
+	maxParseMu.Lock()
+	defer maxParseMu.Unlock()
+	maxParseDuration = d
+}
+
+// MaxParseDuration returns the current per-file parse limit, 0 or less
+// meaning none.
+func MaxParseDuration() time.Duration {
+	maxParseMu.RLock()
+	defer maxParseMu.RUnlock()
+	return maxParseDuration
+}
+
+// DefaultMaxNodeSourceBytes caps a node's stored source at 64 KiB, a couple
+// of thousand lines: far above normal functions, but enough to keep a
+// generated table or a giant legacy function from bloating storage and
+// every context that includes it.
+const DefaultMaxNodeSourceBytes = 64 * 1024
+
+var (
+	maxSourceMu        sync.RWMutex
+	maxNodeSourceBytes = DefaultMaxNodeSourceBytes
+)
+
+// SetMaxNodeSourceBytes sets the size above which ParseFile truncates a
+// node's SourceCode. StartLine, EndLine, and BodyHash still describe the
+// whole node. 0 or less disables the cap; MAX_NODE_SOURCE_BYTES sets it.
+func SetMaxNodeSourceBytes(n int) {
+	maxSourceMu.Lock()
+	defer maxSourceMu.Unlock()
+	maxNodeSourceBytes = n
+}
+
+// MaxNodeSourceBytes returns the current source cap, 0 or less meaning none.
+func MaxNodeSourceBytes() int {
+	maxSourceMu.RLock()
+	defer maxSourceMu.RUnlock()
+	return maxNodeSourceBytes
+}
+
+// truncateSource cuts code to at most limit bytes, at the last line break
+// that fits (or the last whole rune when the first line alone is too long),
+// and appends a marker saying how much was dropped.
+func truncateSource(code string, limit int) string {
+	if limit <= 0 || len(code) <= limit {
+		return code
+	}
+	cut := limit
+	if nl := strings.LastIndexByte(code[:limit], '\n'); nl > 0 {
+		cut = nl
+	} else {
+		for cut > 0 && !utf8.RuneStart(code[cut]) {
+			cut--
+		}
+	}
+	return fmt.Sprintf("%s\n... [truncated: %d of %d bytes omitted]", code[:cut], len(code)-cut, len(code))
+}
+
+// generatedPathSuffixes name the output of common code generators:
+// protobuf/gRPC stubs, go:generate output, and GraphQL/OpenAPI clients.
+var generatedPathSuffixes = []string{
+	".pb.go", ".pb.gw.go", "_gen.go", ".gen.go",
+	".gen.ts", ".gen.tsx", ".generated.ts", ".generated.tsx",
+	"_pb.js", "_pb.d.ts",
+}
+
+// IsGeneratedPath reports whether a file's name marks it as generated, for
+// generators that don't write a marker comment.
+func IsGeneratedPath(filePath string) bool {
+	base := filepath.Base(filePath)
+	if strings.HasPrefix(base, "zz_generated") {
+		return true
+	}
+	for _, suffix := range generatedPathSuffixes {
+		if strings.HasSuffix(base, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseFile(ctx context.Context, filePath string, source []byte) (*ParseResult, error) {
 	ext := filepath.Ext(filePath)
-	p, ok := registry[ext]
-	if !ok {
+	if p, ok := lookupParser(ext); ok {
+		return parseWith(ctx, p, filePath, source)
+	}
+
+	sniffed := ShebangExtension(source)
+	if sniffed == "" {
 		return nil, fmt.Errorf("no parser registered for extension %q", ext)
 	}
+	p, _ := lookupParser(sniffed)
+
+	// Parsers pick their grammar from the extension, so hand them one and
+	// map edges back to the real path afterwards
+	parsePath := filePath + sniffed
+	result, err := parseWith(ctx, p, parsePath, source)
+	if err != nil {
+		return nil, err
+	}
+	for i := range result.Edges {
+		if result.Edges[i].Source == parsePath {
+			result.Edges[i].Source = filePath
+		}
+	}
+	return result, nil
+}
+
+// parseWith hands ctx to p if it is a ContextParser.
+func parseWith(ctx context.Context, p Parser, filePath string, source []byte) (*ParseResult, error) {
+	if cp, ok := p.(ContextParser); ok {
+		return cp.ParseCtx(ctx, filePath, source)
+	}
 	return p.Parse(filePath, source)
 }
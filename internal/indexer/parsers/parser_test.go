@@ -0,0 +1,315 @@
+package parsers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type stubParser struct{ called string }
+
+func (s *stubParser) Parse(filePath string, source []byte) (*ParseResult, error) {
+	s.called = filePath
+	return &ParseResult{Nodes: []NodeInfo{{Name: "stub", Kind: "function"}}}, nil
+}
+
+func TestRegisterParser(t *testing.T) {
+	stub := &stubParser{}
+	RegisterParser([]string{".stub"}, stub)
+	t.Cleanup(func() {
+		registryMu.Lock()
+		delete(registry, ".stub")
+		registryMu.Unlock()
+	})
+
+	if !HasParser(".stub") {
+		t.Fatal("expected .stub to be registered")
+	}
+	result, err := ParseFile("lib/thing.stub", []byte("anything"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stub.called != "lib/thing.stub" || len(result.Nodes) != 1 {
+		t.Errorf("expected stub parser to handle file, called=%q nodes=%d", stub.called, len(result.Nodes))
+	}
+}
+
+// blockingParser never returns on its own, like a parser stuck on a
+// pathological file.
+type blockingParser struct{ release chan struct{} }
+
+func (b *blockingParser) Parse(filePath string, source []byte) (*ParseResult, error) {
+	<-b.release
+	return &ParseResult{}, nil
+}
+
+func TestParseFileMaxParseDuration(t *testing.T) {
+	blocking := &blockingParser{release: make(chan struct{})}
+	RegisterParser([]string{".slow"}, blocking)
+	SetMaxParseDuration(20 * time.Millisecond)
+	t.Cleanup(func() {
+		close(blocking.release)
+		SetMaxParseDuration(DefaultMaxParseDuration)
+		registryMu.Lock()
+		delete(registry, ".slow")
+		registryMu.Unlock()
+	})
+
+	start := time.Now()
+	_, err := ParseFile("huge.slow", []byte("anything"))
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "parse exceeded 20ms") {
+		t.Errorf("expected a parse timeout error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected ParseFile to give up at the deadline, took %s", elapsed)
+	}
+
+	// Parsers that take a context are stopped, not just abandoned
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := NewTypeScriptParser().ParseCtx(ctx, "a.ts", []byte("export function a() {}\n")); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected a cancelled TypeScript parse to fail, got %v", err)
+	}
+	if _, err := ParseFileCtx(ctx, "a.go", []byte("package a\n")); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected a cancelled ParseFileCtx to fail, got %v", err)
+	}
+	if _, err := ParseFile("a.go", []byte("package a\n")); err != nil {
+		t.Errorf("expected a quick parse to fit the limit, got %v", err)
+	}
+}
+
+func TestShebangExtension(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"#!/usr/bin/env node\nconsole.log(1)", ".js"},
+		{"#!/usr/local/bin/node\n", ".js"},
+		{"#!/usr/bin/env -S deno run --allow-net\n", ".ts"},
+		{"#!/usr/bin/env bun", ".ts"},
+		// No parser registered for these interpreters
+		{"#!/usr/bin/env python3\n", ""},
+		{"#!/bin/bash\n", ""},
+		{"console.log(1)", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := ShebangExtension([]byte(tt.source)); got != tt.want {
+			t.Errorf("ShebangExtension(%q) = %q, want %q", tt.source, got, tt.want)
+		}
+	}
+}
+
+func TestParseFileShebangFallback(t *testing.T) {
+	src := []byte("#!/usr/bin/env node\nfunction main() {\n  run()\n}\n")
+	result, err := ParseFile("bin/cli", src)
+	if err != nil {
+		t.Fatalf("expected shebang script to parse, got %v", err)
+	}
+	if findNode(result.Nodes, "main") == nil {
+		t.Fatalf("expected main function, got %+v", result.Nodes)
+	}
+	for _, e := range result.Edges {
+		if e.Kind == "contains" && e.Source != "bin/cli" {
+			t.Errorf("contains edge should use the real path, got source %q", e.Source)
+		}
+	}
+}
+
+func TestParseFileUnknownExtension(t *testing.T) {
+	if _, err := ParseFile("notes.txt", []byte("just text")); err == nil {
+		t.Error("expected error for unknown extension without shebang")
+	}
+}
+
+func TestKindGroup(t *testing.T) {
+	SetSQLEnabled(true)
+	defer SetSQLEnabled(false)
+
+	want := map[string]string{
+		"struct":     KindGroupType,
+		"type_alias": KindGroupType,
+		"union":      KindGroupType,
+		"method":     KindGroupCallable,
+		"query":      KindGroupCallable,
+		"table":      KindGroupType,
+		"field":      KindGroupValue,
+		"package":    KindGroupModule,
+		"mystery":    "",
+	}
+	for kind, group := range want {
+		if got := KindGroup(kind); got != group {
+			t.Errorf("KindGroup(%q) = %q, want %q", kind, got, group)
+		}
+	}
+
+	// Every node the parsers produce lands in a group
+	for _, fixture := range [][2]string{{"typescript", "classes.ts"}, {"typescript", "types.ts"}, {"typescript", "interfaces.ts"}, {"go", "sample.go"}, {"graphql", "schema.graphql"}, {"sql", "schema.sql"}} {
+		path, src := readFixture(t, fixture[0], fixture[1])
+		result, err := ParseFile(path, src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, n := range result.Nodes {
+			if KindGroup(n.Kind) == "" {
+				t.Errorf("%s: kind %q of %s has no group", path, n.Kind, n.QualifiedName)
+			}
+		}
+	}
+}
+
+func TestIsGeneratedPath(t *testing.T) {
+	for path, want := range map[string]bool{
+		"api/v1/user.pb.go":            true,
+		"api/v1/user.pb.gw.go":         true,
+		"internal/mock_gen.go":         true,
+		"src/graphql/schema.gen.ts":    true,
+		"src/api/client.generated.tsx": true,
+		"proto/user_pb.d.ts":           true,
+		"pkg/zz_generated.deepcopy.go": true,
+		"src/generator.ts":             false,
+		"internal/pb/user.go":          false,
+	} {
+		if got := IsGeneratedPath(path); got != want {
+			t.Errorf("IsGeneratedPath(%q) = %v, want %v", path, got, want)
+		}
+	}
+
+	result, err := ParseFile("api/user.pb.go", []byte("package api\n\nfunc Marshal() {}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Nodes) == 0 || !result.Nodes[0].Generated {
+		t.Errorf("expected nodes of a .pb.go file to be marked generated, got %+v", result.Nodes)
+	}
+}
+
+func TestTruncateSource(t *testing.T) {
+	code := "line one\nline two\nline three\n"
+	if got := truncateSource(code, 0); got != code {
+		t.Errorf("expected no cap to keep the code, got %q", got)
+	}
+	if got := truncateSource(code, len(code)); got != code {
+		t.Errorf("expected code at the cap to be kept, got %q", got)
+	}
+	want := "line one\nline two\n... [truncated: 12 of 29 bytes omitted]"
+	if got := truncateSource(code, 20); got != want {
+		t.Errorf("expected a cut at the last line break, got %q", got)
+	}
+	// No line break before the cap: cut on a rune boundary instead
+	if got := truncateSource("héllo", 2); !strings.HasPrefix(got, "h\n...") {
+		t.Errorf("expected the cut to back off to a rune boundary, got %q", got)
+	}
+}
+
+func TestParseFileMaxNodeSourceBytes(t *testing.T) {
+	SetMaxNodeSourceBytes(40)
+	defer SetMaxNodeSourceBytes(DefaultMaxNodeSourceBytes)
+
+	var body strings.Builder
+	body.WriteString("function big() {\n")
+	for i := 0; i < 20; i++ {
+		body.WriteString("  step();\n")
+	}
+	body.WriteString("}\n")
+	full, err := NewTypeScriptParser().Parse("big.ts", []byte(body.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	capped, err := ParseFile("big.ts", []byte(body.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, got := findNode(full.Nodes, "big"), findNode(capped.Nodes, "big")
+	if want == nil || got == nil {
+		t.Fatalf("expected big in both results")
+	}
+	if !strings.Contains(got.SourceCode, "[truncated:") || len(got.SourceCode) >= len(want.SourceCode) {
+		t.Errorf("expected truncated source, got %q", got.SourceCode)
+	}
+	if got.StartLine != want.StartLine || got.EndLine != want.EndLine || got.BodyHash != want.BodyHash {
+		t.Errorf("expected span and hash of the full node, got lines %d-%d hash %s", got.StartLine, got.EndLine, got.BodyHash)
+	}
+}
+
+func TestStructuralHash(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		src  string
+	}{
+		{"go", "sum.go", `package util
+
+func Sum(xs []int) int {
+	total := 0
+	for _, x := range xs {
+		total += x
+	}
+	return total
+}
+
+// Add totals its values.
+func Add(values []int) int {
+	acc := 0
+	for _, v := range values { acc += v }
+	return acc
+}
+
+func Product(xs []int) int {
+	total := 1
+	for _, x := range xs {
+		total *= x
+	}
+	return total
+}
+`},
+		{"typescript", "sum.ts", `export function sum(xs: number[]): number {
+  let total = 0;
+  for (const x of xs) {
+    total += x;
+  }
+  return total;
+}
+
+export function add(values: number[]): number {
+  let acc = 0; // running total
+  for (const v of values) { acc += v; }
+  return acc;
+}
+
+export function product(xs: number[]): number {
+  let total = 1;
+  for (const x of xs) {
+    total *= x;
+  }
+  return total;
+}
+`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ParseFile(tt.file, []byte(tt.src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(result.Nodes) != 3 {
+				t.Fatalf("expected 3 nodes, got %v", nodeNames(result.Nodes))
+			}
+			sum, add, product := result.Nodes[0], result.Nodes[1], result.Nodes[2]
+			if sum.StructuralHash == "" {
+				t.Fatal("expected a structural hash")
+			}
+			if add.StructuralHash != sum.StructuralHash {
+				t.Errorf("expected %s, renamed and reformatted, to hash like %s", add.Name, sum.Name)
+			}
+			if add.BodyHash == sum.BodyHash {
+				t.Errorf("expected %s and %s to keep distinct body hashes", add.Name, sum.Name)
+			}
+			if product.StructuralHash == sum.StructuralHash {
+				t.Errorf("expected %s's different operator and literal to change its hash", product.Name)
+			}
+		})
+	}
+}
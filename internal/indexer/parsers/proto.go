@@ -0,0 +1,472 @@
+package parsers
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+)
+
+var _ Parser = (*ProtoParser)(nil)
+
+// ProtoParser reads Protocol Buffers definitions (.proto), the schema a
+// generated gRPC client is built from. Like GraphQL and SQL it's a small
+// hand-written lexer and parser, not a tree-sitter grammar.
+//
+// Services become "service" nodes and their methods "rpc" nodes qualified by
+// the service (OrderService.GetOrder), with uses_type edges to their request
+// and response messages. Messages and enums become "message" and "enum"
+// nodes, nested ones qualified by their parent (Order.Item); messages get
+// uses_type edges to the message and enum types of their fields. Scalar
+// types are skipped, and package-qualified type names are kept as written.
+// Options, imports, reserved ranges, and extensions aren't nodes.
+//
+// Proto parsing is opt-in: see SetProtoEnabled.
+type ProtoParser struct{}
+
+func NewProtoParser() *ProtoParser {
+	return &ProtoParser{}
+}
+
+// SetProtoEnabled registers a ProtoParser for .proto files, or removes it
+// again. It's off by default since most repos have no use for schema nodes
+// next to their code; PARSE_PROTO turns it on.
+func SetProtoEnabled(enabled bool) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if enabled {
+		if _, ok := registry[".proto"]; !ok {
+			registry[".proto"] = NewProtoParser()
+		}
+		return
+	}
+	if _, ok := registry[".proto"].(*ProtoParser); ok {
+		delete(registry, ".proto")
+	}
+}
+
+func (p *ProtoParser) Parse(filePath string, source []byte) (*ParseResult, error) {
+	toks, err := lexProto(source)
+	if err != nil {
+		return nil, err
+	}
+	pp := &protoParser{src: source, toks: toks, filePath: filePath, result: &ParseResult{}}
+	pp.parseFile()
+	for i := range pp.result.Nodes {
+		pp.result.Nodes[i].Exported = true
+	}
+	return pp.result, nil
+}
+
+// protoScalarTypes are built in, so no edges to them.
+var protoScalarTypes = map[string]bool{
+	"double": true, "float": true, "int32": true, "int64": true, "uint32": true, "uint64": true,
+	"sint32": true, "sint64": true, "fixed32": true, "fixed64": true, "sfixed32": true, "sfixed64": true,
+	"bool": true, "string": true, "bytes": true,
+}
+
+// --- Lexer ---
+
+type protoTokenKind int
+
+const (
+	protoEOF protoTokenKind = iota
+	protoIdent
+	protoString
+	protoNumber
+	protoPunct
+)
+
+type protoToken struct {
+	kind       protoTokenKind
+	value      string // identifiers with their dots (google.protobuf.Empty), strings unquoted
+	start, end int    // byte offsets into the source
+	line       int    // 1-based line of start
+	endLine    int
+	// comment holds the `//` lines directly above the token
+	comment string
+}
+
+func lexProto(src []byte) ([]protoToken, error) {
+	var toks []protoToken
+	line := 1
+	var comment []string
+	lastCommentLine, lastTokenLine := 0, 0
+	i := 0
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+			continue
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f':
+			i++
+			continue
+		case c == '/' && i+1 < len(src) && src[i+1] == '/':
+			j := i
+			for j < len(src) && src[j] != '\n' {
+				j++
+			}
+			if lastTokenLine == line {
+				i = j // trailing comment, documents nothing
+				continue
+			}
+			if lastCommentLine != line-1 {
+				comment = nil
+			}
+			comment = append(comment, strings.TrimSpace(string(src[i+2:j])))
+			lastCommentLine = line
+			i = j
+			continue
+		case c == '/' && i+1 < len(src) && src[i+1] == '*':
+			end := strings.Index(string(src[i+2:]), "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("proto: unterminated comment at line %d", line)
+			}
+			line += strings.Count(string(src[i:i+2+end]), "\n")
+			i += 2 + end + 2
+			continue
+		}
+
+		tok := protoToken{start: i, line: line}
+		if lastCommentLine > 0 && lastCommentLine == line-1 {
+			tok.comment = strings.Join(comment, "\n")
+		}
+		comment, lastCommentLine = nil, 0
+
+		switch {
+		case c == '"' || c == '\'':
+			j := i + 1
+			for j < len(src) && src[j] != c && src[j] != '\n' {
+				if src[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(src) || src[j] != c {
+				return nil, fmt.Errorf("proto: unterminated string at line %d", line)
+			}
+			tok.kind, tok.value = protoString, string(src[i+1:j])
+			i = j + 1
+		case isProtoIdentByte(c) && !(c >= '0' && c <= '9') || c == '.' && i+1 < len(src) && isProtoIdentByte(src[i+1]):
+			j := i + 1
+			for j < len(src) && (isProtoIdentByte(src[j]) || src[j] == '.') {
+				j++
+			}
+			tok.kind, tok.value = protoIdent, strings.TrimPrefix(string(src[i:j]), ".")
+			i = j
+		case c == '-' || (c >= '0' && c <= '9'):
+			j := i + 1
+			for j < len(src) && (isProtoIdentByte(src[j]) || src[j] == '.') {
+				j++
+			}
+			tok.kind, tok.value = protoNumber, string(src[i:j])
+			i = j
+		default:
+			tok.kind, tok.value = protoPunct, string(c)
+			i++
+		}
+		tok.end = i
+		tok.endLine = line
+		lastTokenLine = line
+		toks = append(toks, tok)
+	}
+	toks = append(toks, protoToken{kind: protoEOF, start: len(src), end: len(src), line: line, endLine: line})
+	return toks, nil
+}
+
+func isProtoIdentByte(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// --- Parser ---
+
+type protoParser struct {
+	src      []byte
+	toks     []protoToken
+	pos      int
+	filePath string
+	result   *ParseResult
+}
+
+func (p *protoParser) peek() protoToken { return p.toks[p.pos] }
+
+func (p *protoParser) next() protoToken {
+	t := p.toks[p.pos]
+	if t.kind != protoEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *protoParser) is(value string) bool {
+	t := p.peek()
+	return (t.kind == protoPunct || t.kind == protoIdent) && t.value == value
+}
+
+func (p *protoParser) accept(value string) bool {
+	if p.is(value) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *protoParser) parseFile() {
+	for p.peek().kind != protoEOF {
+		start := p.pos
+		switch {
+		case p.is("message"):
+			p.parseMessage(p.filePath, "")
+		case p.is("enum"):
+			p.parseEnum(p.filePath, "")
+		case p.is("service"):
+			p.parseService()
+		case p.is("extend"):
+			p.skipBlock()
+		default:
+			// syntax, package, import, and option statements declare nothing
+			p.skipStatement()
+		}
+		if p.pos == start {
+			p.next() // never stall on something unexpected
+		}
+	}
+}
+
+// parseMessage parses `message Name { ... }` and the messages and enums
+// nested in it. parent contains the node; prefix qualifies its name.
+func (p *protoParser) parseMessage(parent, prefix string) {
+	kw := p.next()
+	nameTok := p.next()
+	if nameTok.kind != protoIdent || !p.accept("{") {
+		return
+	}
+	qname := prefix + nameTok.value
+
+	var refs []protoToken
+	mark := len(p.result.Nodes)
+	for !p.is("}") && p.peek().kind != protoEOF {
+		start := p.pos
+		switch {
+		case p.is("message"):
+			p.parseMessage(qname, qname+".")
+		case p.is("enum"):
+			p.parseEnum(qname, qname+".")
+		case p.is("extend"):
+			p.skipBlock()
+		case p.is("oneof"):
+			p.next()
+			p.next()
+			if p.accept("{") {
+				for !p.is("}") && p.peek().kind != protoEOF {
+					refs = append(refs, p.parseField()...)
+				}
+				p.accept("}")
+			}
+		case p.is("option"), p.is("reserved"), p.is("extensions"), p.is(";"):
+			p.skipStatement()
+		default:
+			refs = append(refs, p.parseField()...)
+		}
+		if p.pos == start {
+			p.next()
+		}
+	}
+	p.accept("}")
+
+	node := p.newNode(kw, nameTok.value, qname, "message", "message "+nameTok.value)
+	// The message goes ahead of the types nested in it
+	p.result.Nodes = append(p.result.Nodes[:mark], append([]NodeInfo{node}, p.result.Nodes[mark:]...)...)
+	p.result.Edges = append(p.result.Edges, EdgeInfo{Source: parent, Target: qname, Kind: "contains", Line: node.StartLine})
+	p.result.Edges = append(p.result.Edges, protoTypeEdges(qname, refs)...)
+}
+
+// parseField parses one field statement, `repeated Item items = 1 [...];` or
+// `map<string, Item> by_id = 2;`, and returns the tokens of the types it
+// names.
+func (p *protoParser) parseField() []protoToken {
+	var refs []protoToken
+	if p.is("option") {
+		p.skipStatement()
+		return nil
+	}
+	if p.accept("map") && p.accept("<") {
+		for !p.is(">") && p.peek().kind != protoEOF {
+			if t := p.next(); t.kind == protoIdent {
+				refs = append(refs, t)
+			}
+		}
+	} else {
+		p.accept("repeated")
+		p.accept("optional")
+		p.accept("required")
+		if t := p.peek(); t.kind == protoIdent {
+			refs = append(refs, p.next())
+		}
+	}
+	p.skipStatement()
+	return refs
+}
+
+// parseEnum parses `enum Name { ... }`. Its values aren't nodes; the enum's
+// source already lists them.
+func (p *protoParser) parseEnum(parent, prefix string) {
+	kw := p.next()
+	nameTok := p.next()
+	if nameTok.kind != protoIdent || !p.is("{") {
+		return
+	}
+	p.skipBalanced("{", "}")
+
+	qname := prefix + nameTok.value
+	node := p.newNode(kw, nameTok.value, qname, "enum", "enum "+nameTok.value)
+	p.result.Nodes = append(p.result.Nodes, node)
+	p.result.Edges = append(p.result.Edges, EdgeInfo{Source: parent, Target: qname, Kind: "contains", Line: node.StartLine})
+}
+
+// parseService parses `service Name { rpc ...; }`, one rpc node per method.
+func (p *protoParser) parseService() {
+	kw := p.next()
+	nameTok := p.next()
+	if nameTok.kind != protoIdent || !p.accept("{") {
+		return
+	}
+	service := nameTok.value
+
+	var rpcs []NodeInfo
+	var edges []EdgeInfo
+	for !p.is("}") && p.peek().kind != protoEOF {
+		if !p.is("rpc") {
+			p.skipStatement()
+			continue
+		}
+		rpcTok := p.next()
+		methodTok := p.next()
+		if methodTok.kind != protoIdent {
+			continue
+		}
+		var refs []protoToken
+		if p.accept("(") {
+			refs = append(refs, p.parseRPCType()...)
+		}
+		if p.accept("returns") && p.accept("(") {
+			refs = append(refs, p.parseRPCType()...)
+		}
+		sigEnd := p.toks[p.pos-1].end
+		if p.is("{") {
+			p.skipBalanced("{", "}") // method options
+		}
+		p.accept(";")
+
+		qname := service + "." + methodTok.value
+		node := p.newNode(rpcTok, methodTok.value, qname, "rpc", strings.Join(strings.Fields(string(p.src[rpcTok.start:sigEnd])), " "))
+		rpcs = append(rpcs, node)
+		edges = append(edges, EdgeInfo{Source: service, Target: qname, Kind: "contains", Line: node.StartLine})
+		edges = append(edges, protoTypeEdges(qname, refs)...)
+	}
+	p.accept("}")
+
+	node := p.newNode(kw, service, service, "service", "service "+service)
+	p.result.Nodes = append(p.result.Nodes, node)
+	p.result.Nodes = append(p.result.Nodes, rpcs...)
+	p.result.Edges = append(p.result.Edges, EdgeInfo{Source: p.filePath, Target: service, Kind: "contains", Line: node.StartLine})
+	p.result.Edges = append(p.result.Edges, edges...)
+}
+
+// parseRPCType parses the `[stream] Type)` after an rpc's opening
+// parenthesis and returns the type's token.
+func (p *protoParser) parseRPCType() []protoToken {
+	var refs []protoToken
+	p.accept("stream")
+	if t := p.peek(); t.kind == protoIdent {
+		refs = append(refs, p.next())
+	}
+	p.accept(")")
+	return refs
+}
+
+// newNode builds a node spanning from first to the last token consumed.
+func (p *protoParser) newNode(first protoToken, name, qname, kind, signature string) NodeInfo {
+	last := p.toks[p.pos-1]
+	code := string(p.src[first.start:last.end])
+	node := NodeInfo{
+		Name:          name,
+		QualifiedName: qname,
+		Kind:          kind,
+		Signature:     signature,
+		StartLine:     first.line,
+		EndLine:       last.endLine,
+		SourceCode:    code,
+		Docstring:     first.comment,
+		BodyHash:      fmt.Sprintf("%x", sha256.Sum256([]byte(code))),
+	}
+	node.ReleaseTag, node.Deprecated = parseDocTags(first.comment)
+	return node
+}
+
+// protoTypeEdges returns one uses_type edge per distinct non-scalar type in
+// refs, leaving out source itself.
+func protoTypeEdges(source string, refs []protoToken) []EdgeInfo {
+	var edges []EdgeInfo
+	seen := map[string]bool{source: true}
+	for _, ref := range refs {
+		if seen[ref.value] || protoScalarTypes[ref.value] {
+			continue
+		}
+		seen[ref.value] = true
+		edges = append(edges, EdgeInfo{Source: source, Target: ref.value, Kind: "uses_type", Line: ref.line})
+	}
+	return edges
+}
+
+// skipStatement skips up to and including the next top-level `;`, along
+// with any bracketed option list before it.
+func (p *protoParser) skipStatement() {
+	for t := p.peek(); t.kind != protoEOF; t = p.peek() {
+		switch {
+		case p.is(";"):
+			p.next()
+			return
+		case p.is("}"):
+			return // the enclosing block ends; a missing `;` isn't ours to eat
+		case p.is("{"):
+			p.skipBalanced("{", "}") // option values may be message literals
+			return
+		case p.is("["):
+			p.skipBalanced("[", "]")
+		default:
+			p.next()
+		}
+	}
+}
+
+// skipBlock skips a definition up to and including its brace block.
+func (p *protoParser) skipBlock() {
+	for t := p.peek(); t.kind != protoEOF; t = p.peek() {
+		if p.is("{") {
+			p.skipBalanced("{", "}")
+			return
+		}
+		p.next()
+	}
+}
+
+func (p *protoParser) skipBalanced(open, close string) {
+	depth := 0
+	for t := p.peek(); t.kind != protoEOF; t = p.peek() {
+		p.next()
+		if t.kind != protoPunct {
+			continue
+		}
+		switch t.value {
+		case open:
+			depth++
+		case close:
+			depth--
+			if depth == 0 {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package parsers
+
+import (
+	"testing"
+)
+
+func TestProtoParseNodes(t *testing.T) {
+	SetProtoEnabled(true)
+	defer SetProtoEnabled(false)
+
+	path, src := readFixture(t, "proto", "orders.proto")
+	result, err := ParseFile(path, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kinds := map[string]string{
+		"Order":                    "message",
+		"Order.Item":               "message",
+		"Status":                   "enum",
+		"Money":                    "message",
+		"Card":                     "message",
+		"GetOrderRequest":          "message",
+		"OrderService":             "service",
+		"OrderService.GetOrder":    "rpc",
+		"OrderService.ListOrders":  "rpc",
+		"OrderService.WatchOrders": "rpc",
+	}
+	for qname, kind := range kinds {
+		n := findNodeByQName(result.Nodes, qname)
+		if n == nil {
+			t.Errorf("expected node %s", qname)
+			continue
+		}
+		if n.Kind != kind {
+			t.Errorf("%s: expected kind %s, got %s", qname, kind, n.Kind)
+		}
+		if !n.Exported {
+			t.Errorf("%s: expected schema nodes to be exported", qname)
+		}
+	}
+	// Fields, enum values, and options aren't nodes
+	if len(result.Nodes) != len(kinds) {
+		t.Errorf("expected %d nodes, got %v", len(kinds), nodeNames(result.Nodes))
+	}
+
+	order := findNodeByQName(result.Nodes, "Order")
+	if order.Docstring != "An order placed by a customer." || order.Signature != "message Order" {
+		t.Errorf("unexpected Order doc/signature: %q / %q", order.Docstring, order.Signature)
+	}
+	if order.StartLine != 12 || order.EndLine != 31 {
+		t.Errorf("expected Order at 12-31, got %d-%d", order.StartLine, order.EndLine)
+	}
+
+	get := findNodeByQName(result.Nodes, "OrderService.GetOrder")
+	if get.Signature != "rpc GetOrder(GetOrderRequest) returns (Order)" || get.Docstring != "Returns one order." {
+		t.Errorf("unexpected GetOrder signature/doc: %q / %q", get.Signature, get.Docstring)
+	}
+	list := findNodeByQName(result.Nodes, "OrderService.ListOrders")
+	if list.Signature != "rpc ListOrders(GetOrderRequest) returns (stream Order)" || !list.Deprecated {
+		t.Errorf("unexpected ListOrders: %q, deprecated %v", list.Signature, list.Deprecated)
+	}
+	if list.EndLine != 59 {
+		t.Errorf("expected ListOrders to end after its options, got line %d", list.EndLine)
+	}
+}
+
+func TestProtoEdges(t *testing.T) {
+	SetProtoEnabled(true)
+	defer SetProtoEnabled(false)
+
+	path, src := readFixture(t, "proto", "orders.proto")
+	result, err := ParseFile(path, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, e := range [][2]string{
+		{path, "Order"},
+		{"Order", "Order.Item"},
+		{path, "OrderService"},
+		{"OrderService", "OrderService.GetOrder"},
+	} {
+		if findEdge(result.Edges, "contains", e[0], e[1]) == nil {
+			t.Errorf("expected %s contains %s", e[0], e[1])
+		}
+	}
+
+	for _, e := range [][2]string{
+		{"Order", "Item"},
+		{"Order", "Money"},
+		{"Order", "google.protobuf.Timestamp"},
+		{"Order", "Status"},
+		{"Order", "Card"},
+		{"Order.Item", "Money"},
+		{"OrderService.GetOrder", "GetOrderRequest"},
+		{"OrderService.GetOrder", "Order"},
+		{"OrderService.ListOrders", "Order"},
+		{"OrderService.WatchOrders", "shop.v1.Order"},
+	} {
+		if findEdge(result.Edges, "uses_type", e[0], e[1]) == nil {
+			t.Errorf("expected %s uses_type %s", e[0], e[1])
+		}
+	}
+	for _, scalar := range []string{"string", "int64", "option"} {
+		for _, e := range findEdges(result.Edges, "uses_type") {
+			if e.Target == scalar {
+				t.Errorf("unexpected uses_type edge to %s from %s", scalar, e.Source)
+			}
+		}
+	}
+}
+
+func TestSetProtoEnabled(t *testing.T) {
+	if HasParser(".proto") {
+		t.Fatal("proto parsing should be off by default")
+	}
+	SetProtoEnabled(true)
+	if !HasParser(".proto") {
+		t.Error("expected .proto to have a parser once enabled")
+	}
+	SetProtoEnabled(false)
+	if HasParser(".proto") {
+		t.Error("expected disabling to remove the parser")
+	}
+}
+
+func TestProtoUnterminated(t *testing.T) {
+	for _, src := range []string{`option x = "oops`, "/* never closed"} {
+		if _, err := NewProtoParser().Parse("bad.proto", []byte(src)); err == nil {
+			t.Errorf("expected an error for %q", src)
+		}
+	}
+}
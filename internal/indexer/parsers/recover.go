@@ -0,0 +1,207 @@
+package parsers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+
+	sitter "github.com/smacker/go-tree-sitter"
+)
+
+// LineRange is a span of 1-based lines, inclusive.
+type LineRange struct {
+	StartLine int `json:"startLine"`
+	EndLine   int `json:"endLine"`
+}
+
+// String formats the range as "5-11", or "5" for a single line.
+func (r LineRange) String() string {
+	if r.StartLine == r.EndLine {
+		return strconv.Itoa(r.StartLine)
+	}
+	return fmt.Sprintf("%d-%d", r.StartLine, r.EndLine)
+}
+
+// maxRecoveryPasses bounds the re-parses parseRecovering makes for a file.
+const maxRecoveryPasses = 8
+
+// parseRecovering parses source and returns its tree plus the line ranges of
+// the syntax errors tree-sitter found (ERROR and MISSING nodes), nil when
+// the file is clean. It fails with ctx's error once ctx is done.
+//
+// tree-sitter recovers from a missing token by inserting it, but an ERROR
+// node can swallow the declarations after it: a half-typed function turns
+// the next one into an expression inside the ERROR, or a dangling
+// `const x = ` binds the following function to x. So while the tree has
+// ERROR nodes, the top-level declaration each one starts in is blanked up
+// to the next line that begins a declaration at column 0, and the file is
+// parsed again, until it is clean or maxRecoveryPasses is reached. An ERROR
+// inside a declaration that swallowed nothing only blanks its own lines.
+// Blanking keeps every byte offset, so the returned tree can be read
+// against the original source.
+func parseRecovering(ctx context.Context, parser *sitter.Parser, source []byte) (*sitter.Tree, []LineRange, error) {
+	// tree-sitter only polls for cancellation now and then, so a context
+	// that is already done is checked up front
+	if err := ctx.Err(); err != nil {
+		return nil, nil, fmt.Errorf("tree-sitter parse: %w", err)
+	}
+	tree, err := parser.ParseCtx(ctx, nil, source)
+	if err != nil {
+		return nil, nil, fmt.Errorf("tree-sitter parse: %w", err)
+	}
+	if !tree.RootNode().HasError() {
+		return tree, nil, nil
+	}
+	syntaxErrors := syntaxErrorRanges(tree.RootNode())
+
+	masked := slices.Clone(source)
+	starts := declarationStartRows(source)
+	for range maxRecoveryPasses {
+		rows := erroringRows(tree.RootNode(), starts)
+		if len(rows) == 0 || !blankLines(masked, rows) {
+			break
+		}
+		next, err := parser.ParseCtx(ctx, nil, masked)
+		if err != nil {
+			if ctx.Err() != nil {
+				tree.Close()
+				return nil, nil, fmt.Errorf("tree-sitter parse: %w", err)
+			}
+			break
+		}
+		tree.Close()
+		tree = next
+	}
+	return tree, syntaxErrors, nil
+}
+
+// erroringRows returns the 0-based rows to blank for the ERROR nodes under
+// root. starts are the rows that begin a declaration at column 0.
+func erroringRows(root *sitter.Node, starts []uint32) map[uint32]bool {
+	rows := make(map[uint32]bool)
+	blank := func(from, to uint32) {
+		for r := from; r <= to; r++ {
+			rows[r] = true
+		}
+	}
+	for i := 0; i < int(root.NamedChildCount()); i++ {
+		decl := root.NamedChild(i)
+		errorRows := errorStartRows(decl)
+		if len(errorRows) == 0 {
+			continue
+		}
+		from, to := decl.StartPoint().Row, decl.EndPoint().Row
+		// The first declaration start after this one's marks where the
+		// erroring code ends and a swallowed sibling begins
+		j, _ := slices.BinarySearch(starts, from+1)
+		switch {
+		case j < len(starts) && starts[j] <= to:
+			blank(from, starts[j]-1)
+		case decl.IsError():
+			blank(from, to)
+		default:
+			for _, r := range errorRows {
+				rows[r] = true
+			}
+		}
+	}
+	return rows
+}
+
+// syntaxErrorRanges returns the merged line ranges of the outermost ERROR
+// nodes and of every MISSING node under root.
+func syntaxErrorRanges(root *sitter.Node) []LineRange {
+	var ranges []LineRange
+	var walk func(n *sitter.Node)
+	walk = func(n *sitter.Node) {
+		switch {
+		case n.IsError():
+			ranges = append(ranges, LineRange{int(n.StartPoint().Row) + 1, int(n.EndPoint().Row) + 1})
+			return
+		case n.IsMissing():
+			line := int(n.StartPoint().Row) + 1
+			ranges = append(ranges, LineRange{line, line})
+			return
+		}
+		for i := 0; i < int(n.ChildCount()); i++ {
+			if c := n.Child(i); c.HasError() || c.IsMissing() {
+				walk(c)
+			}
+		}
+	}
+	walk(root)
+
+	slices.SortFunc(ranges, func(a, b LineRange) int { return a.StartLine - b.StartLine })
+	var merged []LineRange
+	for _, r := range ranges {
+		if last := len(merged) - 1; last >= 0 && r.StartLine <= merged[last].EndLine+1 {
+			merged[last].EndLine = max(merged[last].EndLine, r.EndLine)
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// errorStartRows returns the 0-based rows every ERROR node at or under n
+// starts on.
+func errorStartRows(n *sitter.Node) []uint32 {
+	var rows []uint32
+	var walk func(n *sitter.Node)
+	walk = func(n *sitter.Node) {
+		if n.IsError() {
+			rows = append(rows, n.StartPoint().Row)
+		}
+		for i := 0; i < int(n.ChildCount()); i++ {
+			if c := n.Child(i); c.HasError() {
+				walk(c)
+			}
+		}
+	}
+	if n.HasError() {
+		walk(n)
+	}
+	return rows
+}
+
+// declarationStartRows returns the 0-based rows of source that begin at
+// column 0 with something other than whitespace or closing punctuation: in
+// formatted code, the first line of a top-level declaration or its comment.
+func declarationStartRows(source []byte) []uint32 {
+	var rows []uint32
+	var row uint32
+	lineStart := true
+	for _, b := range source {
+		if lineStart {
+			switch b {
+			case ' ', '\t', '\r', '\n', '}', ')', ']', ',', ';':
+			default:
+				rows = append(rows, row)
+			}
+		}
+		lineStart = b == '\n'
+		if lineStart {
+			row++
+		}
+	}
+	return rows
+}
+
+// blankLines replaces everything but newlines on the given 0-based rows of
+// source with spaces, in place. It reports whether anything changed.
+func blankLines(source []byte, rows map[uint32]bool) bool {
+	changed := false
+	var row uint32
+	for i, b := range source {
+		if b == '\n' {
+			row++
+			continue
+		}
+		if rows[row] && b != ' ' && b != '\t' && b != '\r' {
+			source[i] = ' '
+			changed = true
+		}
+	}
+	return changed
+}
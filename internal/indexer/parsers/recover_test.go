@@ -0,0 +1,102 @@
+package parsers
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseRecovering(t *testing.T) {
+	tests := []struct {
+		name      string
+		path, src string
+		nodes     []string
+		errors    []LineRange
+	}{
+		{
+			name:   "broken function swallowing the next",
+			path:   "a.ts",
+			src:    "export function a() {\n  return 1;\n}\n\nfunction broken( {\n  foo(\n}\n\nexport function c() {\n  return helper();\n}\n",
+			nodes:  []string{"a", "c"},
+			errors: []LineRange{{5, 11}},
+		},
+		{
+			name:   "dangling initializer",
+			path:   "b.ts",
+			src:    "const x = ;\nfunction ok() { call(); }\n",
+			nodes:  []string{"ok"},
+			errors: []LineRange{{1, 1}},
+		},
+		{
+			name:   "missing token inside a body",
+			path:   "c.ts",
+			src:    "function a() {\n  if (x {\n    y();\n  }\n}\nfunction b() { z(); }\n",
+			nodes:  []string{"a", "b"},
+			errors: []LineRange{{2, 2}},
+		},
+		{
+			name:   "broken JSX",
+			path:   "d.tsx",
+			src:    "export function A() {\n  return <div>{x</div>;\n}\nexport function B() { return <span/>; }\n",
+			nodes:  []string{"B"},
+			errors: []LineRange{{1, 4}},
+		},
+		{
+			name:   "unclosed Go block",
+			path:   "e.go",
+			src:    "package x\n\nfunc a() {\n\tif x {\n}\n\nfunc c() { helper() }\n",
+			nodes:  []string{"c"},
+			errors: []LineRange{{7, 8}},
+		},
+		{
+			name:  "clean file",
+			path:  "f.ts",
+			src:   "export function a() {}\n",
+			nodes: []string{"a"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ParseFile(tt.path, []byte(tt.src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := nodeNames(result.Nodes); !slices.Equal(got, tt.nodes) {
+				t.Errorf("expected nodes %v, got %v", tt.nodes, got)
+			}
+			if result.HasSyntaxErrors != (len(tt.errors) > 0) || !slices.Equal(result.SyntaxErrors, tt.errors) {
+				t.Errorf("expected syntax errors %v, got %v (%v)", tt.errors, result.SyntaxErrors, result.HasSyntaxErrors)
+			}
+		})
+	}
+}
+
+func TestParseRecovering_KeepsOriginalSource(t *testing.T) {
+	src := "export function a() {\n  return 1;\n}\n\nfunction broken( {\n  foo(\n}\n\n/** Runs c. */\nexport function c() {\n  return helper();\n}\n"
+	result, err := ParseFile("a.ts", []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := findNode(result.Nodes, "c")
+	if c == nil {
+		t.Fatal("expected c to be recovered")
+	}
+	if !c.Exported || c.StartLine != 10 || c.Docstring != "Runs c." || c.SourceCode != "function c() {\n  return helper();\n}" {
+		t.Errorf("unexpected c: %+v", c)
+	}
+	if findEdge(result.Edges, "calls", "c", "helper") == nil {
+		t.Error("expected c's calls to be extracted")
+	}
+	// Nothing comes out of the erroring lines
+	if findEdge(result.Edges, "calls", "c", "foo") != nil || findNode(result.Nodes, "broken") != nil {
+		t.Error("expected the broken declaration to be skipped")
+	}
+}
+
+func TestLineRangeString(t *testing.T) {
+	if got := (LineRange{5, 11}).String(); got != "5-11" {
+		t.Errorf("expected 5-11, got %s", got)
+	}
+	if got := (LineRange{3, 3}).String(); got != "3" {
+		t.Errorf("expected 3, got %s", got)
+	}
+}
@@ -0,0 +1,471 @@
+package parsers
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	sitter "github.com/smacker/go-tree-sitter"
+)
+
+// Route is an HTTP endpoint found by a RouteDetector. Method is upper-case,
+// or "ANY" when every method reaches the handler. Handler is the name of the
+// function handling it as the file refers to it (resolved like a call), or
+// empty for an inline handler. The lines and source describe the
+// registration, which becomes the endpoint node's span.
+type Route struct {
+	Method     string
+	Path       string
+	Handler    string
+	StartLine  int
+	EndLine    int
+	SourceCode string
+}
+
+// RouteDetector recognizes the route registrations of one web framework in
+// a parsed TypeScript or JavaScript file. nodes are the file's parsed nodes.
+type RouteDetector interface {
+	DetectRoutes(filePath string, source []byte, root *sitter.Node, nodes []NodeInfo) []Route
+}
+
+var (
+	routeMu        sync.RWMutex
+	routeDetectors = make(map[string]RouteDetector)
+	enabledRoutes  []string
+)
+
+func init() {
+	RegisterRouteDetector("express", ExpressRoutes{})
+	RegisterRouteDetector("nestjs", NestRoutes{})
+	RegisterRouteDetector("next", NextRoutes{})
+}
+
+// RegisterRouteDetector makes a detector available under name, replacing any
+// registered before. Registered detectors only run once enabled with
+// SetRouteDetectors.
+func RegisterRouteDetector(name string, d RouteDetector) {
+	routeMu.Lock()
+	defer routeMu.Unlock()
+	routeDetectors[name] = d
+}
+
+// SetRouteDetectors enables the named route detectors and disables the rest.
+// Route detection is opt-in, since the framework heuristics can misfire on
+// code that isn't a server; ROUTE_DETECTORS sets it. Unknown names are
+// reported in the error and the known ones are enabled anyway.
+func SetRouteDetectors(names []string) error {
+	routeMu.Lock()
+	defer routeMu.Unlock()
+	enabledRoutes = nil
+	var unknown []string
+	for _, name := range names {
+		if _, ok := routeDetectors[name]; !ok {
+			unknown = append(unknown, name)
+			continue
+		}
+		if !slices.Contains(enabledRoutes, name) {
+			enabledRoutes = append(enabledRoutes, name)
+		}
+	}
+	sort.Strings(enabledRoutes)
+	if len(unknown) > 0 {
+		return fmt.Errorf("unknown route detectors: %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// RouteDetectors returns the names of the enabled route detectors, sorted.
+func RouteDetectors() []string {
+	routeMu.RLock()
+	defer routeMu.RUnlock()
+	return slices.Clone(enabledRoutes)
+}
+
+func enabledRouteDetectors() []RouteDetector {
+	routeMu.RLock()
+	defer routeMu.RUnlock()
+	detectors := make([]RouteDetector, 0, len(enabledRoutes))
+	for _, name := range enabledRoutes {
+		detectors = append(detectors, routeDetectors[name])
+	}
+	return detectors
+}
+
+// extractRoutes runs the enabled route detectors over a parsed file. Each
+// route becomes an "endpoint" node named "METHOD /path", contained by the
+// file, with a "handles" edge to its handler when it has a named one. A
+// route registered twice in a file is kept once.
+func extractRoutes(filePath string, source []byte, root *sitter.Node, result *ParseResult) {
+	detectors := enabledRouteDetectors()
+	if len(detectors) == 0 {
+		return
+	}
+	seen := make(map[string]bool)
+	for _, d := range detectors {
+		for _, r := range d.DetectRoutes(filePath, source, root, result.Nodes) {
+			name := r.Method + " " + r.Path
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			// The same route can be registered by several files, such as
+			// two routers mounted at different prefixes, so the qualified
+			// name carries the file
+			qname := endpointQualifiedName(filePath, name)
+			result.Nodes = append(result.Nodes, NodeInfo{
+				Name:          name,
+				QualifiedName: qname,
+				Kind:          "endpoint",
+				Signature:     name,
+				StartLine:     r.StartLine,
+				EndLine:       r.EndLine,
+				SourceCode:    r.SourceCode,
+				BodyHash:      fmt.Sprintf("%x", sha256.Sum256([]byte(name+"\x00"+r.Handler+"\x00"+r.SourceCode))),
+			})
+			result.Edges = append(result.Edges, EdgeInfo{Source: filePath, Target: qname, Kind: "contains", Line: r.StartLine})
+			if r.Handler != "" {
+				result.Edges = append(result.Edges, EdgeInfo{Source: qname, Target: r.Handler, Kind: "handles", Line: r.StartLine})
+			}
+		}
+	}
+}
+
+// endpointQualifiedName qualifies an endpoint's "METHOD /path" name by the
+// file registering it, e.g. "src/server.ts:GET /users".
+func endpointQualifiedName(filePath, name string) string {
+	return filePath + ":" + name
+}
+
+// httpMethods are the route methods frameworks name after HTTP verbs.
+var httpMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS", "HEAD"}
+
+// joinRoutePath joins route path segments into a clean path with a leading
+// slash, e.g. ("users", ":id") → "/users/:id".
+func joinRoutePath(parts ...string) string {
+	var kept []string
+	for _, p := range parts {
+		if p = strings.Trim(p, "/"); p != "" {
+			kept = append(kept, p)
+		}
+	}
+	return "/" + strings.Join(kept, "/")
+}
+
+// stringLiteral returns the value of a string or substitution-free template
+// literal node.
+func stringLiteral(source []byte, node *sitter.Node) (string, bool) {
+	switch node.Type() {
+	case "string":
+		return stripQuotes(nodeContent(source, node)), true
+	case "template_string":
+		if findChildByType(node, "template_substitution") != nil {
+			return "", false
+		}
+		return strings.Trim(nodeContent(source, node), "`"), true
+	}
+	return "", false
+}
+
+// ExpressRoutes detects Express-style registrations, `app.get('/users',
+// listUsers)`, on any receiver: a call to get, post, put, patch, delete,
+// options, head, or all whose first argument is a path literal starting with
+// "/" and whose last argument is the handler, after any middleware. Koa
+// routers and Fastify's shorthand methods share the shape. Receivers named
+// like HTTP clients (axios.get('/users', config)) are skipped.
+type ExpressRoutes struct{}
+
+// httpClientReceivers are receivers whose get/post calls send requests
+// rather than register routes.
+var httpClientReceivers = map[string]bool{
+	"axios": true, "http": true, "https": true, "client": true, "api": true,
+	"request": true, "superagent": true, "got": true, "ky": true, "$http": true,
+}
+
+func (ExpressRoutes) DetectRoutes(filePath string, source []byte, root *sitter.Node, nodes []NodeInfo) []Route {
+	var routes []Route
+	var walk func(n *sitter.Node)
+	walk = func(n *sitter.Node) {
+		if n.Type() == "call_expression" {
+			if r, ok := expressRoute(source, n); ok {
+				routes = append(routes, r)
+			}
+		}
+		for i := 0; i < int(n.NamedChildCount()); i++ {
+			walk(n.NamedChild(i))
+		}
+	}
+	walk(root)
+	return routes
+}
+
+func expressRoute(source []byte, call *sitter.Node) (Route, bool) {
+	fn := call.ChildByFieldName("function")
+	args := call.ChildByFieldName("arguments")
+	if fn == nil || args == nil || fn.Type() != "member_expression" || args.NamedChildCount() < 2 {
+		return Route{}, false
+	}
+	object, property := fn.ChildByFieldName("object"), fn.ChildByFieldName("property")
+	if object == nil || property == nil {
+		return Route{}, false
+	}
+	if t := object.Type(); t != "identifier" && t != "member_expression" && t != "this" {
+		return Route{}, false
+	}
+	receiver := nodeContent(source, object)
+	if i := strings.LastIndex(receiver, "."); i >= 0 {
+		receiver = receiver[i+1:]
+	}
+	if httpClientReceivers[receiver] {
+		return Route{}, false
+	}
+
+	method := strings.ToUpper(nodeContent(source, property))
+	if method == "ALL" {
+		method = "ANY"
+	} else if !slices.Contains(httpMethods, method) {
+		return Route{}, false
+	}
+
+	routePath, ok := stringLiteral(source, args.NamedChild(0))
+	if !ok || !strings.HasPrefix(routePath, "/") {
+		return Route{}, false
+	}
+
+	handler := ""
+	switch last := args.NamedChild(int(args.NamedChildCount()) - 1); last.Type() {
+	case "identifier", "member_expression":
+		handler = nodeContent(source, last)
+	case "arrow_function", "function_expression", "function", "call_expression":
+		// Inline handlers have no node of their own to point at
+	default:
+		return Route{}, false
+	}
+
+	return Route{
+		Method:     method,
+		Path:       routePath,
+		Handler:    handler,
+		StartLine:  int(call.StartPoint().Row) + 1,
+		EndLine:    int(call.EndPoint().Row) + 1,
+		SourceCode: nodeContent(source, call),
+	}, true
+}
+
+// NestRoutes detects NestJS controllers: methods decorated with @Get(),
+// @Post(':id') and the other verb decorators, under the path prefix of
+// their class's @Controller('users'). The handler is the method.
+type NestRoutes struct{}
+
+// nestMethodDecorators maps NestJS route decorators to their HTTP method.
+var nestMethodDecorators = map[string]string{
+	"Get": "GET", "Post": "POST", "Put": "PUT", "Patch": "PATCH",
+	"Delete": "DELETE", "Options": "OPTIONS", "Head": "HEAD", "All": "ANY",
+}
+
+func (NestRoutes) DetectRoutes(filePath string, source []byte, root *sitter.Node, nodes []NodeInfo) []Route {
+	var routes []Route
+	var walk func(n *sitter.Node)
+	walk = func(n *sitter.Node) {
+		switch n.Type() {
+		case "class_declaration", "abstract_class_declaration":
+			routes = append(routes, nestControllerRoutes(source, n)...)
+			return
+		}
+		for i := 0; i < int(n.NamedChildCount()); i++ {
+			walk(n.NamedChild(i))
+		}
+	}
+	walk(root)
+	return routes
+}
+
+func nestControllerRoutes(source []byte, class *sitter.Node) []Route {
+	nameNode, body := class.ChildByFieldName("name"), class.ChildByFieldName("body")
+	if nameNode == nil || body == nil {
+		return nil
+	}
+
+	// An exported class carries its decorators on the export statement
+	decorators := childrenByType(class, "decorator")
+	if parent := class.Parent(); parent != nil && parent.Type() == "export_statement" {
+		decorators = append(decorators, childrenByType(parent, "decorator")...)
+	}
+	prefix, isController := "", false
+	for _, d := range decorators {
+		if name, arg := decoratorCall(source, d); name == "Controller" {
+			prefix, isController = nestControllerPath(source, arg), true
+		}
+	}
+	if !isController {
+		return nil
+	}
+
+	className := nodeContent(source, nameNode)
+	var routes []Route
+	var pending []*sitter.Node
+	for i := 0; i < int(body.NamedChildCount()); i++ {
+		child := body.NamedChild(i)
+		switch child.Type() {
+		case "decorator":
+			pending = append(pending, child)
+			continue
+		case "method_definition":
+			methodName := child.ChildByFieldName("name")
+			for _, d := range pending {
+				name, arg := decoratorCall(source, d)
+				method, ok := nestMethodDecorators[name]
+				if !ok || methodName == nil {
+					continue
+				}
+				sub := ""
+				if arg != nil {
+					sub, _ = stringLiteral(source, arg)
+				}
+				routes = append(routes, Route{
+					Method:     method,
+					Path:       joinRoutePath(prefix, sub),
+					Handler:    className + "." + nodeContent(source, methodName),
+					StartLine:  int(d.StartPoint().Row) + 1,
+					EndLine:    int(d.EndPoint().Row) + 1,
+					SourceCode: nodeContent(source, d),
+				})
+			}
+		}
+		pending = nil
+	}
+	return routes
+}
+
+// decoratorCall returns a decorator's name and first argument: "Get" and
+// ':id' for @Get(':id'), "Injectable" and nil for @Injectable.
+func decoratorCall(source []byte, decorator *sitter.Node) (string, *sitter.Node) {
+	if decorator.NamedChildCount() == 0 {
+		return "", nil
+	}
+	expr := decorator.NamedChild(0)
+	switch expr.Type() {
+	case "identifier":
+		return nodeContent(source, expr), nil
+	case "call_expression":
+		fn, args := expr.ChildByFieldName("function"), expr.ChildByFieldName("arguments")
+		if fn == nil || fn.Type() != "identifier" {
+			return "", nil
+		}
+		var first *sitter.Node
+		if args != nil && args.NamedChildCount() > 0 {
+			first = args.NamedChild(0)
+		}
+		return nodeContent(source, fn), first
+	}
+	return "", nil
+}
+
+// nestControllerPath reads the prefix from @Controller('users') or
+// @Controller({ path: 'users' }).
+func nestControllerPath(source []byte, arg *sitter.Node) string {
+	if arg == nil {
+		return ""
+	}
+	if s, ok := stringLiteral(source, arg); ok {
+		return s
+	}
+	if arg.Type() != "object" {
+		return ""
+	}
+	for i := 0; i < int(arg.NamedChildCount()); i++ {
+		pair := arg.NamedChild(i)
+		key, value := pair.ChildByFieldName("key"), pair.ChildByFieldName("value")
+		if pair.Type() != "pair" || key == nil || value == nil || stripQuotes(nodeContent(source, key)) != "path" {
+			continue
+		}
+		s, _ := stringLiteral(source, value)
+		return s
+	}
+	return ""
+}
+
+func childrenByType(node *sitter.Node, nodeType string) []*sitter.Node {
+	var children []*sitter.Node
+	for i := 0; i < int(node.NamedChildCount()); i++ {
+		if c := node.NamedChild(i); c.Type() == nodeType {
+			children = append(children, c)
+		}
+	}
+	return children
+}
+
+// NextRoutes detects Next.js file-based API routes. In the App Router,
+// app/**/route.ts exports one function per method (export function GET);
+// route groups like (admin) and parallel @slots don't add to the path. In
+// the Pages Router, pages/api/** default-exports a handler for every method.
+// Folders starting with _ are private and never routed. Dynamic segments
+// keep their Next.js spelling: /api/users/[id].
+type NextRoutes struct{}
+
+func (NextRoutes) DetectRoutes(filePath string, source []byte, root *sitter.Node, nodes []NodeInfo) []Route {
+	// filePath is absolute, so the innermost app/ or pages/ is the router's
+	segments := strings.Split(strings.TrimSuffix(filePath, path.Ext(filePath)), "/")
+	for i := len(segments) - 2; i >= 0; i-- {
+		switch seg := segments[i]; {
+		case seg == "app" && segments[len(segments)-1] == "route":
+			routePath, ok := nextRoutePath(segments[i+1 : len(segments)-1])
+			if !ok {
+				return nil
+			}
+			var routes []Route
+			for _, n := range nodes {
+				if n.Kind == "function" && n.Exported && n.QualifiedName == n.Name && slices.Contains(httpMethods, n.Name) {
+					routes = append(routes, nextRoute(n.Name, routePath, n))
+				}
+			}
+			return routes
+		case seg == "pages" && i+1 < len(segments) && segments[i+1] == "api":
+			rest := segments[i+1:]
+			if rest[len(rest)-1] == "index" {
+				rest = rest[:len(rest)-1]
+			}
+			routePath, ok := nextRoutePath(rest)
+			if !ok {
+				return nil
+			}
+			for _, n := range nodes {
+				if n.Kind == "function" && n.DefaultExport {
+					return []Route{nextRoute("ANY", routePath, n)}
+				}
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+// nextRoutePath builds the URL path from the directories under app/ or
+// pages/. ok is false inside a private _folder.
+func nextRoutePath(dirs []string) (string, bool) {
+	var parts []string
+	for _, d := range dirs {
+		switch {
+		case strings.HasPrefix(d, "_"):
+			return "", false
+		case strings.HasPrefix(d, "(") && strings.HasSuffix(d, ")"), strings.HasPrefix(d, "@"):
+			continue
+		}
+		parts = append(parts, d)
+	}
+	return joinRoutePath(parts...), true
+}
+
+func nextRoute(method, routePath string, handler NodeInfo) Route {
+	return Route{
+		Method:     method,
+		Path:       routePath,
+		Handler:    handler.QualifiedName,
+		StartLine:  handler.StartLine,
+		EndLine:    handler.StartLine,
+		SourceCode: handler.Signature,
+	}
+}
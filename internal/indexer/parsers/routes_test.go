@@ -0,0 +1,213 @@
+package parsers
+
+import (
+	"slices"
+	"testing"
+)
+
+func parseRoutes(t *testing.T, detectors []string, path, src string) *ParseResult {
+	t.Helper()
+	if err := SetRouteDetectors(detectors); err != nil {
+		t.Fatal(err)
+	}
+	defer SetRouteDetectors(nil)
+	result, err := ParseFile(path, []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func endpointNames(nodes []NodeInfo) []string {
+	var names []string
+	for _, n := range nodes {
+		if n.Kind == "endpoint" {
+			names = append(names, n.Name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+func TestExpressRoutes(t *testing.T) {
+	src := `import express from 'express';
+import axios from 'axios';
+import { listUsers } from './handlers';
+
+const app = express();
+const router = express.Router();
+
+app.get('/users', auth, listUsers);
+router.post(` + "`/users/:id`" + `, handlers.updateUser);
+app.all('/health', (req, res) => res.send('ok'));
+app.get('setting');
+axios.get('/users', config);
+map.get(key);
+`
+	result := parseRoutes(t, []string{"express"}, "src/server.ts", src)
+
+	want := []string{"ANY /health", "GET /users", "POST /users/:id"}
+	if got := endpointNames(result.Nodes); !slices.Equal(got, want) {
+		t.Fatalf("expected endpoints %v, got %v", want, got)
+	}
+
+	get := findNodeByQName(result.Nodes, "src/server.ts:GET /users")
+	if get.StartLine != 8 || get.SourceCode != "app.get('/users', auth, listUsers)" {
+		t.Errorf("unexpected GET /users span: line %d, %q", get.StartLine, get.SourceCode)
+	}
+	if findEdge(result.Edges, "contains", "src/server.ts", "src/server.ts:GET /users") == nil {
+		t.Error("expected the file to contain GET /users")
+	}
+	if findEdge(result.Edges, "handles", "src/server.ts:GET /users", "listUsers") == nil {
+		t.Error("expected GET /users to be handled by the last argument, not the middleware")
+	}
+	if findEdge(result.Edges, "handles", "src/server.ts:POST /users/:id", "handlers.updateUser") == nil {
+		t.Error("expected a member expression handler")
+	}
+	// Inline handlers have nothing to point at
+	for _, e := range findEdges(result.Edges, "handles") {
+		if e.Source == "src/server.ts:ANY /health" {
+			t.Errorf("expected no handles edge for an inline handler, got %+v", e)
+		}
+	}
+}
+
+func TestExpressRoutes_SameRouteInTwoRouters(t *testing.T) {
+	src := `const router = express.Router();
+router.get('/', list);
+`
+	users := parseRoutes(t, []string{"express"}, "src/routes/users.ts", src)
+	admins := parseRoutes(t, []string{"express"}, "src/routes/admins.ts", src)
+
+	for _, tc := range []struct {
+		result *ParseResult
+		qname  string
+	}{
+		{users, "src/routes/users.ts:GET /"},
+		{admins, "src/routes/admins.ts:GET /"},
+	} {
+		n := findNodeByQName(tc.result.Nodes, tc.qname)
+		if n == nil || n.Name != "GET /" {
+			t.Fatalf("expected endpoint %s named GET /, got %+v", tc.qname, n)
+		}
+		if findEdge(tc.result.Edges, "handles", tc.qname, "list") == nil {
+			t.Errorf("expected %s to be handled by list", tc.qname)
+		}
+	}
+}
+
+func TestNestRoutes(t *testing.T) {
+	src := `import { Controller, Get, Post, Injectable } from '@nestjs/common';
+
+@Controller('users')
+export class UsersController {
+  @Get()
+  findAll() {
+    return [];
+  }
+
+  @Get(':id')
+  findOne() {
+    return null;
+  }
+
+  @Post()
+  @HttpCode(204)
+  create() {}
+
+  helper() {}
+}
+
+@Controller({ path: 'admin' })
+class AdminController {
+  @Get('stats')
+  stats() {}
+}
+
+@Injectable()
+export class UsersService {
+  @Get('nope')
+  find() {}
+}
+`
+	result := parseRoutes(t, []string{"nestjs"}, "src/users.controller.ts", src)
+
+	want := []string{"GET /admin/stats", "GET /users", "GET /users/:id", "POST /users"}
+	if got := endpointNames(result.Nodes); !slices.Equal(got, want) {
+		t.Fatalf("expected endpoints %v, got %v", want, got)
+	}
+	if findEdge(result.Edges, "handles", "src/users.controller.ts:GET /users/:id", "UsersController.findOne") == nil {
+		t.Error("expected GET /users/:id to be handled by UsersController.findOne")
+	}
+	if findEdge(result.Edges, "handles", "src/users.controller.ts:POST /users", "UsersController.create") == nil {
+		t.Error("expected other decorators not to hide the route decorator")
+	}
+	if n := findNodeByQName(result.Nodes, "src/users.controller.ts:GET /users/:id"); n.StartLine != 10 || n.SourceCode != "@Get(':id')" {
+		t.Errorf("unexpected GET /users/:id span: line %d, %q", n.StartLine, n.SourceCode)
+	}
+}
+
+func TestNextRoutes(t *testing.T) {
+	appSrc := `export async function GET(request: Request) {
+  return Response.json([]);
+}
+
+export async function POST(request: Request) {
+  return new Response(null, { status: 201 });
+}
+
+function DELETE() {}
+`
+	result := parseRoutes(t, []string{"next"}, "app/(admin)/api/users/[id]/route.ts", appSrc)
+	want := []string{"GET /api/users/[id]", "POST /api/users/[id]"}
+	if got := endpointNames(result.Nodes); !slices.Equal(got, want) {
+		t.Fatalf("expected endpoints %v, got %v", want, got)
+	}
+	if findEdge(result.Edges, "handles", "app/(admin)/api/users/[id]/route.ts:GET /api/users/[id]", "GET") == nil {
+		t.Error("expected GET /api/users/[id] to be handled by GET")
+	}
+
+	result = parseRoutes(t, []string{"next"}, "/home/app/web/app/api/route.ts", appSrc)
+	if got := endpointNames(result.Nodes); !slices.Equal(got, []string{"GET /api", "POST /api"}) {
+		t.Errorf("expected the innermost app folder to be the router's, got %v", got)
+	}
+
+	result = parseRoutes(t, []string{"next"}, "app/_lib/route.ts", appSrc)
+	if got := endpointNames(result.Nodes); len(got) != 0 {
+		t.Errorf("expected private folders to have no routes, got %v", got)
+	}
+
+	pagesSrc := `export default function handler(req, res) {
+  res.status(200).json({});
+}
+`
+	result = parseRoutes(t, []string{"next"}, "src/pages/api/users/index.js", pagesSrc)
+	if got := endpointNames(result.Nodes); !slices.Equal(got, []string{"ANY /api/users"}) {
+		t.Fatalf("expected ANY /api/users, got %v", got)
+	}
+	if findEdge(result.Edges, "handles", "src/pages/api/users/index.js:ANY /api/users", "handler") == nil {
+		t.Error("expected the default export to handle the route")
+	}
+
+	result = parseRoutes(t, []string{"next"}, "src/pages/users.js", pagesSrc)
+	if got := endpointNames(result.Nodes); len(got) != 0 {
+		t.Errorf("expected pages outside api to have no routes, got %v", got)
+	}
+}
+
+func TestSetRouteDetectors(t *testing.T) {
+	defer SetRouteDetectors(nil)
+
+	src := "app.get('/users', listUsers);\n"
+	if result := parseRoutes(t, nil, "server.js", src); len(endpointNames(result.Nodes)) != 0 {
+		t.Error("expected no routes with detection off")
+	}
+
+	err := SetRouteDetectors([]string{"next", "rails", "express", "express"})
+	if err == nil || err.Error() != "unknown route detectors: rails" {
+		t.Errorf("expected an error naming rails, got %v", err)
+	}
+	if got := RouteDetectors(); !slices.Equal(got, []string{"express", "next"}) {
+		t.Errorf("expected the known detectors to be enabled anyway, got %v", got)
+	}
+}
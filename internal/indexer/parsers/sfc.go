@@ -0,0 +1,176 @@
+package parsers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var _ ContextParser = (*SFCParser)(nil)
+
+// SFCParser reads Vue and Svelte single-file components. Their <script>
+// blocks (a Vue file may have both <script> and <script setup>) go to the
+// TypeScript parser in the grammar their lang attribute names, with the rest
+// of the file blanked out to spaces, so every line number and byte offset in
+// the result matches the original file.
+//
+// The file itself becomes a "component" node named after it (user-card.vue
+// → UserCard), which is the module's default export unless the script
+// declares one. Components used in the markup — PascalCase tags, or
+// kebab-case ones in Vue — become renders edges from it.
+type SFCParser struct {
+	ts *TypeScriptParser
+}
+
+func NewSFCParser() *SFCParser {
+	return &SFCParser{ts: NewTypeScriptParser()}
+}
+
+var (
+	sfcScriptRe  = regexp.MustCompile(`(?is)<script\b([^>]*)>(.*?)</script\s*>`)
+	sfcStyleRe   = regexp.MustCompile(`(?is)<style\b[^>]*>.*?</style\s*>`)
+	sfcCommentRe = regexp.MustCompile(`(?s)<!--.*?-->`)
+	sfcLangRe    = regexp.MustCompile(`(?i)\blang\s*=\s*["']?([a-z]+)`)
+	sfcTagRe     = regexp.MustCompile(`<([A-Za-z][\w.:-]*)`)
+)
+
+// sfcScriptExts maps a script block's lang attribute to the extension whose
+// grammar parses it. Blocks without one are JavaScript.
+var sfcScriptExts = map[string]string{
+	"ts":         ".ts",
+	"typescript": ".ts",
+	"tsx":        ".tsx",
+	"jsx":        ".jsx",
+	"js":         ".js",
+	"javascript": ".js",
+}
+
+// vueBuiltinTags are Vue's built-in components, which no file defines.
+var vueBuiltinTags = map[string]bool{
+	"component": true, "transition": true, "transition-group": true,
+	"keep-alive": true, "slot": true, "teleport": true, "suspense": true,
+	"template": true,
+}
+
+func (p *SFCParser) Parse(filePath string, source []byte) (*ParseResult, error) {
+	return p.ParseCtx(context.Background(), filePath, source)
+}
+
+// ParseCtx is Parse, stopping with ctx's error once ctx is done.
+func (p *SFCParser) ParseCtx(ctx context.Context, filePath string, source []byte) (*ParseResult, error) {
+	script := blankOut(source, 0, len(source))
+	markup := bytes.Clone(source)
+	ext := ".js"
+	for _, m := range sfcScriptRe.FindAllSubmatchIndex(source, -1) {
+		copy(script[m[4]:m[5]], source[m[4]:m[5]])
+		copy(markup[m[0]:m[1]], blankOut(source, m[0], m[1]))
+		if lang := sfcLangRe.FindSubmatch(source[m[2]:m[3]]); lang != nil {
+			// When blocks disagree, TypeScript grammars parse JavaScript too
+			if e, ok := sfcScriptExts[strings.ToLower(string(lang[1]))]; ok && (ext == ".js" || e == ".tsx") {
+				ext = e
+			}
+		}
+	}
+	for _, re := range []*regexp.Regexp{sfcStyleRe, sfcCommentRe} {
+		for _, m := range re.FindAllIndex(markup, -1) {
+			copy(markup[m[0]:m[1]], blankOut(markup, m[0], m[1]))
+		}
+	}
+
+	result, err := p.ts.parseAs(ctx, filePath, ext, script)
+	if err != nil {
+		return nil, err
+	}
+
+	component := sfcComponentNode(filePath, source, result.Nodes)
+	result.Nodes = append(result.Nodes, component)
+	result.Edges = append(result.Edges, EdgeInfo{Source: filePath, Target: component.QualifiedName, Kind: "contains", Line: 1})
+	result.Edges = append(result.Edges, sfcRendersEdges(component.QualifiedName, markup, filepath.Ext(filePath) == ".vue")...)
+	return result, nil
+}
+
+// blankOut returns source[start:end] with every byte but newlines replaced
+// by a space.
+func blankOut(source []byte, start, end int) []byte {
+	out := make([]byte, end-start)
+	for i, b := range source[start:end] {
+		if b == '\n' {
+			out[i] = '\n'
+		} else {
+			out[i] = ' '
+		}
+	}
+	return out
+}
+
+// sfcComponentNode builds the node standing for the whole component file.
+func sfcComponentNode(filePath string, source []byte, scriptNodes []NodeInfo) NodeInfo {
+	name := pascalCase(defaultExportBaseName(filePath))
+	hasDefault := false
+	for _, n := range scriptNodes {
+		hasDefault = hasDefault || n.DefaultExport
+	}
+	code := string(source)
+	return NodeInfo{
+		Name:          name,
+		QualifiedName: name,
+		Kind:          "component",
+		Signature:     "component " + name,
+		StartLine:     1,
+		EndLine:       strings.Count(strings.TrimRight(code, "\n"), "\n") + 1,
+		SourceCode:    code,
+		BodyHash:      fmt.Sprintf("%x", sha256.Sum256(source)),
+		Exported:      true,
+		DefaultExport: !hasDefault,
+	}
+}
+
+// sfcRendersEdges finds the components used in markup (the file with script,
+// style, and comments blanked out), one edge per component at its first use.
+// Lowercase tags are HTML elements, except hyphenated ones in Vue, which name
+// components in kebab-case. Vue built-ins and svelte:* elements are skipped.
+func sfcRendersEdges(component string, markup []byte, vue bool) []EdgeInfo {
+	var edges []EdgeInfo
+	seen := make(map[string]bool)
+	line, pos := 1, 0
+	for _, m := range sfcTagRe.FindAllSubmatchIndex(markup, -1) {
+		tag := string(markup[m[2]:m[3]])
+		line += bytes.Count(markup[pos:m[0]], []byte("\n"))
+		pos = m[0]
+
+		if strings.Contains(tag, ":") || (vue && vueBuiltinTags[strings.ToLower(tag)]) {
+			continue
+		}
+		target := tag
+		if tag[0] < 'A' || tag[0] > 'Z' {
+			if !vue || !strings.Contains(tag, "-") {
+				continue
+			}
+			target = pascalCase(tag)
+		}
+		if seen[target] || target == component {
+			continue
+		}
+		seen[target] = true
+		edges = append(edges, EdgeInfo{Source: component, Target: target, Kind: "renders", Line: line})
+	}
+	return edges
+}
+
+// pascalCase turns a kebab- or snake-case name into PascalCase: user-card →
+// UserCard. Names without separators only get their first letter raised.
+func pascalCase(name string) string {
+	var b strings.Builder
+	for _, part := range strings.FieldsFunc(name, func(r rune) bool { return r == '-' || r == '_' }) {
+		r, size := utf8.DecodeRuneInString(part)
+		b.WriteRune(unicode.ToUpper(r))
+		b.WriteString(part[size:])
+	}
+	return b.String()
+}
@@ -0,0 +1,85 @@
+package parsers
+
+import (
+	"testing"
+)
+
+func TestSFCParseVue(t *testing.T) {
+	path, src := readFixture(t, "sfc", "user-card.vue")
+	result, err := ParseFile(path, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Line numbers come from the original file, not the script block
+	fn := findNode(result.Nodes, "formatName")
+	if fn == nil {
+		t.Fatal("expected formatName node")
+	}
+	if fn.StartLine != 18 || fn.EndLine != 20 {
+		t.Errorf("formatName: expected lines 18-20, got %d-%d", fn.StartLine, fn.EndLine)
+	}
+	if fn.Signature != "function formatName(user: User): string" {
+		t.Errorf("formatName: expected the lang=ts grammar, got signature %q", fn.Signature)
+	}
+
+	comp := findNode(result.Nodes, "UserCard")
+	if comp == nil {
+		t.Fatal("expected UserCard component node")
+	}
+	if comp.Kind != "component" || !comp.DefaultExport || comp.StartLine != 1 || comp.EndLine != 25 {
+		t.Errorf("UserCard: got kind=%s default=%v lines %d-%d", comp.Kind, comp.DefaultExport, comp.StartLine, comp.EndLine)
+	}
+
+	renders := findEdges(result.Edges, "renders")
+	want := map[string]int{"UserAvatar": 4, "UserStats": 5}
+	if len(renders) != len(want) {
+		t.Fatalf("expected %d renders edges, got %+v", len(want), renders)
+	}
+	for _, e := range renders {
+		if e.Source != "UserCard" || want[e.Target] != e.Line {
+			t.Errorf("unexpected renders edge %+v", e)
+		}
+	}
+
+	imports := findEdges(result.Edges, "imports")
+	if len(imports) != 2 || imports[0].Target != "./UserAvatar.vue" || imports[0].Line != 13 {
+		t.Errorf("expected script imports at their file lines, got %+v", imports)
+	}
+}
+
+func TestSFCParseSvelte(t *testing.T) {
+	path, src := readFixture(t, "sfc", "Counter.svelte")
+	result, err := ParseFile(path, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fn := findNode(result.Nodes, "increment")
+	if fn == nil || fn.StartLine != 7 {
+		t.Fatalf("expected increment at line 7, got %+v", fn)
+	}
+	if findNode(result.Nodes, "Counter") == nil {
+		t.Error("expected Counter component node")
+	}
+
+	// svelte:head is an element, and kebab-case tags are plain HTML in Svelte
+	renders := findEdges(result.Edges, "renders")
+	if len(renders) != 1 || renders[0].Target != "Button" || renders[0].Line != 14 {
+		t.Errorf("expected one renders edge to Button on line 14, got %+v", renders)
+	}
+}
+
+func TestPascalCase(t *testing.T) {
+	for in, want := range map[string]string{
+		"user-card":  "UserCard",
+		"user_card":  "UserCard",
+		"Counter":    "Counter",
+		"app":        "App",
+		"émoji-list": "ÉmojiList",
+	} {
+		if got := pascalCase(in); got != want {
+			t.Errorf("pascalCase(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,621 @@
+package parsers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var _ Parser = (*SQLParser)(nil)
+
+// SQLParser reads standalone .sql files: schema files, migrations, and sqlc
+// query files. Like GraphQL there's no tree-sitter grammar in our bindings,
+// so it's a small lexer that splits statements on top-level semicolons and
+// recognizes a few statement shapes, Postgres flavored.
+//
+// Statements annotated with a `-- name: GetUser :one` comment (the sqlc
+// convention) become "query" nodes named by the annotation, with the command
+// (:one, :many, :exec) as a modifier. Unannotated CREATE TABLE, CREATE VIEW,
+// and CREATE FUNCTION/PROCEDURE statements become "table", "view", and
+// "function" nodes. Every node gets a uses_table edge to each table it reads
+// or writes (FROM, JOIN, INTO, UPDATE, USING, REFERENCES, TRUNCATE), looking
+// inside dollar-quoted function bodies too. Other statements, like ALTER
+// TABLE or CREATE INDEX, aren't nodes.
+//
+// SQL parsing is opt-in: see SetSQLEnabled.
+type SQLParser struct{}
+
+func NewSQLParser() *SQLParser {
+	return &SQLParser{}
+}
+
+// SetSQLEnabled registers a SQLParser for .sql files, or removes it again.
+// It's off by default because most repos' .sql files are migrations whose
+// nodes would crowd search results; PARSE_SQL turns it on.
+func SetSQLEnabled(enabled bool) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if enabled {
+		if _, ok := registry[".sql"]; !ok {
+			registry[".sql"] = NewSQLParser()
+		}
+		return
+	}
+	if _, ok := registry[".sql"].(*SQLParser); ok {
+		delete(registry, ".sql")
+	}
+}
+
+func (p *SQLParser) Parse(filePath string, source []byte) (*ParseResult, error) {
+	toks, err := lexSQL(source, 1)
+	if err != nil {
+		return nil, err
+	}
+	result := &ParseResult{}
+	for _, stmt := range splitSQLStatements(toks) {
+		node, ok := sqlStatementNode(source, stmt)
+		if !ok {
+			continue
+		}
+		result.Nodes = append(result.Nodes, node)
+		result.Edges = append(result.Edges, EdgeInfo{Source: filePath, Target: node.QualifiedName, Kind: "contains", Line: node.StartLine})
+		result.Edges = append(result.Edges, sqlTableEdges(node, stmt.toks)...)
+	}
+	return result, nil
+}
+
+// --- Lexer ---
+
+type sqlTokenKind int
+
+const (
+	sqlWord sqlTokenKind = iota
+	sqlQuotedIdent
+	sqlString
+	sqlDollarString
+	sqlNumber
+	sqlPunct
+)
+
+type sqlToken struct {
+	kind       sqlTokenKind
+	value      string // words lowercased, quoted identifiers unquoted, dollar strings without their tags
+	start, end int    // byte offsets into the source
+	line       int
+	// comment holds the `--` lines directly above the token
+	comment     []string
+	commentLine int // line of the first comment line
+}
+
+// sqlAnnotation matches sqlc's `name: GetUser :one` query annotation.
+var sqlAnnotation = regexp.MustCompile(`^name:\s*([A-Za-z_][A-Za-z0-9_]*)(?:\s+:([a-z]+))?`)
+
+// lexSQL tokenizes src, numbering lines from firstLine so function bodies can
+// be lexed on their own and still report lines in the enclosing file.
+func lexSQL(src []byte, firstLine int) ([]sqlToken, error) {
+	var toks []sqlToken
+	line := firstLine
+	var comment []string
+	commentLine, lastCommentLine, lastTokenLine := 0, 0, 0
+	i := 0
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+			continue
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f':
+			i++
+			continue
+		case c == '-' && i+1 < len(src) && src[i+1] == '-':
+			j := i
+			for j < len(src) && src[j] != '\n' {
+				j++
+			}
+			if lastTokenLine == line {
+				i = j // trailing comment, documents nothing
+				continue
+			}
+			if lastCommentLine == 0 || lastCommentLine != line-1 {
+				comment = nil
+				commentLine = line
+			}
+			comment = append(comment, strings.TrimSpace(string(src[i+2:j])))
+			lastCommentLine = line
+			i = j
+			continue
+		case c == '/' && i+1 < len(src) && src[i+1] == '*':
+			// Block comments nest in Postgres; they don't document anything
+			depth, j := 0, i
+			for j < len(src) {
+				if src[j] == '/' && j+1 < len(src) && src[j+1] == '*' {
+					depth++
+					j += 2
+					continue
+				}
+				if src[j] == '*' && j+1 < len(src) && src[j+1] == '/' {
+					depth--
+					j += 2
+					if depth == 0 {
+						break
+					}
+					continue
+				}
+				if src[j] == '\n' {
+					line++
+				}
+				j++
+			}
+			if depth > 0 {
+				return nil, fmt.Errorf("unterminated block comment")
+			}
+			comment, lastCommentLine = nil, 0
+			i = j
+			continue
+		}
+
+		tok := sqlToken{start: i, line: line}
+		if lastCommentLine > 0 && lastCommentLine == line-1 {
+			tok.comment = comment
+			tok.commentLine = commentLine
+		}
+		comment = nil
+		lastCommentLine = 0
+
+		switch {
+		case c == '\'' || ((c == 'E' || c == 'e') && i+1 < len(src) && src[i+1] == '\''):
+			j := i + 1
+			if c != '\'' {
+				j++
+			}
+			for {
+				if j >= len(src) {
+					return nil, fmt.Errorf("line %d: unterminated string", tok.line)
+				}
+				if src[j] == '\\' && c != '\'' {
+					j += 2
+					continue
+				}
+				if src[j] == '\'' {
+					if j+1 < len(src) && src[j+1] == '\'' {
+						j += 2
+						continue
+					}
+					break
+				}
+				if src[j] == '\n' {
+					line++
+				}
+				j++
+			}
+			tok.kind, tok.end = sqlString, j+1
+			tok.value = string(src[i:tok.end])
+		case c == '"':
+			j := i + 1
+			for j < len(src) && (src[j] != '"' || (j+1 < len(src) && src[j+1] == '"')) {
+				if src[j] == '"' {
+					j++
+				}
+				j++
+			}
+			if j >= len(src) {
+				return nil, fmt.Errorf("line %d: unterminated quoted identifier", tok.line)
+			}
+			tok.kind, tok.end = sqlQuotedIdent, j+1
+			tok.value = strings.ReplaceAll(string(src[i+1:j]), `""`, `"`)
+		case c == '$' && sqlDollarTag(src[i:]) != "":
+			tag := sqlDollarTag(src[i:])
+			body := i + len(tag)
+			k := bytes.Index(src[body:], []byte(tag))
+			if k < 0 {
+				return nil, fmt.Errorf("line %d: unterminated %s string", tok.line, tag)
+			}
+			tok.kind, tok.end = sqlDollarString, body+k+len(tag)
+			tok.value = string(src[body : body+k])
+			line += bytes.Count(src[i:tok.end], []byte("\n"))
+		case isSQLWordStart(c):
+			j := i
+			for j < len(src) && isSQLWordByte(src[j]) {
+				j++
+			}
+			tok.kind, tok.end = sqlWord, j
+			tok.value = strings.ToLower(string(src[i:j]))
+		case c >= '0' && c <= '9':
+			j := i
+			for j < len(src) && (isSQLWordByte(src[j]) || src[j] == '.') {
+				j++
+			}
+			tok.kind, tok.end = sqlNumber, j
+			tok.value = string(src[i:j])
+		default:
+			j := i + 1
+			if c == '$' { // positional parameter
+				for j < len(src) && src[j] >= '0' && src[j] <= '9' {
+					j++
+				}
+			}
+			tok.kind, tok.end = sqlPunct, j
+			tok.value = string(src[i:j])
+		}
+		lastTokenLine = line
+		toks = append(toks, tok)
+		i = tok.end
+	}
+	return toks, nil
+}
+
+// sqlDollarTag returns the opening `$tag$` (or `$$`) at the start of b, or "".
+func sqlDollarTag(b []byte) string {
+	for j := 1; j < len(b); j++ {
+		if b[j] == '$' {
+			return string(b[:j+1])
+		}
+		if !isSQLWordByte(b[j]) || (j == 1 && b[j] >= '0' && b[j] <= '9') {
+			return ""
+		}
+	}
+	return ""
+}
+
+func isSQLWordStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
+
+func isSQLWordByte(c byte) bool {
+	return isSQLWordStart(c) || (c >= '0' && c <= '9') || c == '$'
+}
+
+// --- Statements ---
+
+type sqlStatement struct {
+	toks []sqlToken
+	end  int // byte offset just past the statement, including its semicolon
+}
+
+func splitSQLStatements(toks []sqlToken) []sqlStatement {
+	var stmts []sqlStatement
+	start := 0
+	for i, t := range toks {
+		if t.kind == sqlPunct && t.value == ";" {
+			if i > start {
+				stmts = append(stmts, sqlStatement{toks: toks[start:i], end: t.end})
+			}
+			start = i + 1
+		}
+	}
+	if start < len(toks) {
+		stmts = append(stmts, sqlStatement{toks: toks[start:], end: toks[len(toks)-1].end})
+	}
+	return stmts
+}
+
+// sqlStatementNode makes the node for an annotated query or a CREATE TABLE,
+// VIEW, or FUNCTION statement. ok is false for any other statement.
+func sqlStatementNode(src []byte, stmt sqlStatement) (NodeInfo, bool) {
+	first := stmt.toks[0]
+	var doc []string
+	var name, command string
+	for _, line := range first.comment {
+		if m := sqlAnnotation.FindStringSubmatch(line); m != nil && name == "" {
+			name, command = m[1], m[2]
+			continue
+		}
+		doc = append(doc, line)
+	}
+
+	node := NodeInfo{
+		StartLine: first.line,
+		EndLine:   lineAt(src, stmt.end-1),
+		Docstring: strings.TrimSpace(strings.Join(doc, "\n")),
+		Exported:  true,
+	}
+	codeStart := first.start
+	if name != "" {
+		// The annotation is part of a sqlc query, so the node starts there
+		node.Kind = "query"
+		node.Name, node.QualifiedName = name, name
+		if command != "" {
+			node.Modifiers = []string{command}
+		}
+		node.StartLine = first.commentLine
+		codeStart = lineStartAt(src, first.start, first.line-first.commentLine)
+	} else {
+		kind, qname, ok := sqlCreatedObject(stmt.toks)
+		if !ok {
+			return NodeInfo{}, false
+		}
+		node.Kind, node.QualifiedName = kind, qname
+		node.Name = qname[strings.LastIndex(qname, ".")+1:]
+	}
+
+	code := string(src[codeStart:stmt.end])
+	node.SourceCode = code
+	node.Signature = sqlSignature(src[first.start:stmt.end], node.Kind)
+	node.BodyHash = fmt.Sprintf("%x", sha256.Sum256([]byte(code)))
+	node.ReleaseTag, node.Deprecated = parseDocTags(node.Docstring)
+	return node, true
+}
+
+// sqlCreatedObject reads `CREATE [OR REPLACE] [TEMP|UNLOGGED|MATERIALIZED]
+// TABLE|VIEW|FUNCTION|PROCEDURE [IF NOT EXISTS] name`.
+func sqlCreatedObject(toks []sqlToken) (kind, qname string, ok bool) {
+	if len(toks) < 3 || toks[0].kind != sqlWord || toks[0].value != "create" {
+		return "", "", false
+	}
+	i := 1
+	if i+1 < len(toks) && toks[i].value == "or" && toks[i+1].value == "replace" {
+		i += 2
+	}
+	for i < len(toks) && toks[i].kind == sqlWord {
+		switch toks[i].value {
+		case "temp", "temporary", "unlogged", "materialized", "recursive":
+			i++
+			continue
+		}
+		break
+	}
+	if i >= len(toks) {
+		return "", "", false
+	}
+	switch toks[i].value {
+	case "table":
+		kind = "table"
+	case "view":
+		kind = "view"
+	case "function", "procedure":
+		kind = "function"
+	default:
+		return "", "", false
+	}
+	i++
+	if i+2 < len(toks) && toks[i].value == "if" && toks[i+1].value == "not" && toks[i+2].value == "exists" {
+		i += 3
+	}
+	qname, _ = sqlQualifiedName(toks, i)
+	if qname == "" {
+		return "", "", false
+	}
+	return kind, qname, true
+}
+
+// sqlQualifiedName reads a possibly schema-qualified name starting at toks[i]
+// and returns it along with the index just past it. Unquoted parts are
+// lowercased, as Postgres folds them.
+func sqlQualifiedName(toks []sqlToken, i int) (string, int) {
+	var parts []string
+	for i < len(toks) && (toks[i].kind == sqlWord || toks[i].kind == sqlQuotedIdent) {
+		parts = append(parts, toks[i].value)
+		i++
+		if i+1 < len(toks) && toks[i].kind == sqlPunct && toks[i].value == "." {
+			i++
+			continue
+		}
+		break
+	}
+	return strings.Join(parts, "."), i
+}
+
+// sqlSignature is a statement's text with whitespace collapsed, cut before
+// a table's columns, a view's query, or a function's body. Long queries are
+// truncated.
+func sqlSignature(stmt []byte, kind string) string {
+	s := strings.TrimSuffix(strings.Join(strings.Fields(string(stmt)), " "), ";")
+	lower := strings.ToLower(s)
+	cut := -1
+	switch kind {
+	case "table":
+		cut = strings.IndexAny(s, "(")
+		if i := strings.Index(lower, " as "); i > 0 && (cut < 0 || i < cut) {
+			cut = i
+		}
+	case "view":
+		cut = strings.Index(lower, " as ")
+	case "function":
+		cut = strings.IndexByte(s, '$')
+		if i := strings.LastIndex(lower[:max(cut, 0)], " as "); cut > 0 && i > 0 {
+			cut = i
+		}
+	}
+	if cut > 0 {
+		s = strings.TrimSpace(s[:cut])
+	}
+
+	const maxSignature = 200
+	if len(s) > maxSignature {
+		s = strings.TrimSpace(s[:maxSignature]) + "..."
+	}
+	return s
+}
+
+// --- Table references ---
+
+// sqlTableKeywords are followed by a table name.
+var sqlTableKeywords = map[string]bool{
+	"from": true, "join": true, "into": true, "update": true,
+	"using": true, "references": true, "truncate": true,
+}
+
+// sqlFromFunctions use FROM inside their argument list: extract(epoch FROM t).
+var sqlFromFunctions = map[string]bool{
+	"extract": true, "substring": true, "trim": true, "overlay": true,
+}
+
+// sqlClauseWords end a FROM item, so they're never read as a table alias.
+var sqlClauseWords = map[string]bool{
+	"where": true, "join": true, "inner": true, "left": true, "right": true,
+	"full": true, "cross": true, "natural": true, "on": true, "group": true,
+	"order": true, "limit": true, "offset": true, "union": true, "except": true,
+	"intersect": true, "having": true, "window": true, "for": true,
+	"returning": true, "set": true, "values": true, "select": true,
+	"using": true, "fetch": true, "into": true, "do": true, "when": true,
+	"then": true, "loop": true, "end": true, "and": true, "or": true,
+}
+
+// sqlTableEdges returns a uses_table edge per distinct table node's
+// statement touches. CTE names and the statement's own object are skipped.
+func sqlTableEdges(node NodeInfo, toks []sqlToken) []EdgeInfo {
+	skip := sqlCTENames(toks)
+	if node.Kind == "table" || node.Kind == "view" {
+		skip[node.QualifiedName] = true
+	}
+
+	var edges []EdgeInfo
+	seen := make(map[string]bool)
+	add := func(name string, line int) {
+		if name == "" || skip[name] || seen[name] {
+			return
+		}
+		seen[name] = true
+		edges = append(edges, EdgeInfo{Source: node.QualifiedName, Target: name, Kind: "uses_table", Line: line})
+	}
+
+	var scan func(toks []sqlToken)
+	scan = func(toks []sqlToken) {
+		var parens []bool // true for an argument list that takes FROM
+		deleteOrMerge := false
+		for i := 0; i < len(toks); i++ {
+			t := toks[i]
+			switch {
+			case t.kind == sqlDollarString && node.Kind == "function":
+				// A function body: lex it on its own and look inside
+				body, err := lexSQL([]byte(t.value), t.line)
+				if err == nil {
+					for name := range sqlCTENames(body) {
+						skip[name] = true
+					}
+					scan(body)
+				}
+			case t.kind == sqlPunct && t.value == "(":
+				parens = append(parens, i > 0 && toks[i-1].kind == sqlWord && sqlFromFunctions[toks[i-1].value])
+			case t.kind == sqlPunct && t.value == ")":
+				if len(parens) > 0 {
+					parens = parens[:len(parens)-1]
+				}
+			case t.kind == sqlWord && (t.value == "delete" || t.value == "merge"):
+				deleteOrMerge = true
+			case t.kind == sqlWord && sqlTableKeywords[t.value]:
+				if len(parens) > 0 && parens[len(parens)-1] {
+					continue
+				}
+				prev := ""
+				if i > 0 {
+					prev = toks[i-1].value
+				}
+				switch {
+				case t.value == "from" && prev == "distinct": // IS DISTINCT FROM
+					continue
+				case t.value == "into" && prev != "insert" && prev != "merge": // plpgsql SELECT ... INTO var
+					continue
+				case t.value == "update" && (prev == "for" || prev == "do" || prev == "key"): // row locks, upserts
+					continue
+				case t.value == "using" && !deleteOrMerge: // JOIN ... USING, EXECUTE ... USING
+					continue
+				}
+				i = sqlTableRefs(toks, i, add)
+			}
+		}
+	}
+	scan(toks)
+	return edges
+}
+
+// sqlTableRefs reads the table names after the keyword at toks[i], and
+// returns the index of the last token consumed.
+func sqlTableRefs(toks []sqlToken, i int, add func(string, int)) int {
+	keyword := toks[i].value
+	j := i + 1
+	if keyword == "truncate" && j < len(toks) && toks[j].value == "table" {
+		j++
+	}
+	for {
+		for j < len(toks) && toks[j].kind == sqlWord && (toks[j].value == "only" || toks[j].value == "lateral") {
+			j++
+		}
+		if j >= len(toks) || (toks[j].kind != sqlWord && toks[j].kind != sqlQuotedIdent) || (toks[j].kind == sqlWord && sqlClauseWords[toks[j].value]) {
+			return j - 1
+		}
+		line := toks[j].line
+		name, next := sqlQualifiedName(toks, j)
+		// FROM generate_series(...) is a function call; INTO t (cols) and
+		// REFERENCES t (col) are tables with a column list
+		isCall := next < len(toks) && toks[next].value == "(" && (keyword == "from" || keyword == "join" || keyword == "using")
+		if !isCall {
+			add(name, line)
+		}
+		j = next
+		if keyword != "from" && keyword != "truncate" {
+			return j - 1
+		}
+
+		// Skip an alias, then carry on through a comma-separated list
+		if j < len(toks) && toks[j].value == "as" {
+			j++
+		}
+		if j < len(toks) && (toks[j].kind == sqlQuotedIdent || (toks[j].kind == sqlWord && !sqlClauseWords[toks[j].value])) {
+			j++
+		}
+		if isCall || j >= len(toks) || toks[j].value != "," {
+			return j - 1
+		}
+		j++
+	}
+}
+
+// sqlCTENames collects the names bound by WITH: `name AS (` or
+// `name (cols) AS (`, optionally [NOT] MATERIALIZED.
+func sqlCTENames(toks []sqlToken) map[string]bool {
+	names := make(map[string]bool)
+	for i, t := range toks {
+		if t.kind != sqlWord && t.kind != sqlQuotedIdent {
+			continue
+		}
+		j := i + 1
+		if j < len(toks) && toks[j].value == "(" && i > 0 && (toks[i-1].value == "with" || toks[i-1].value == "recursive" || toks[i-1].value == ",") {
+			depth := 0
+			for ; j < len(toks); j++ {
+				if toks[j].value == "(" {
+					depth++
+				} else if toks[j].value == ")" {
+					depth--
+					if depth == 0 {
+						j++
+						break
+					}
+				}
+			}
+		}
+		if j >= len(toks) || toks[j].value != "as" {
+			continue
+		}
+		j++
+		if j < len(toks) && toks[j].value == "not" {
+			j++
+		}
+		if j < len(toks) && toks[j].value == "materialized" {
+			j++
+		}
+		if j < len(toks) && toks[j].value == "(" {
+			names[t.value] = true
+		}
+	}
+	return names
+}
+
+// lineAt returns the 1-based line of byte offset off.
+func lineAt(src []byte, off int) int {
+	return bytes.Count(src[:off], []byte("\n")) + 1
+}
+
+// lineStartAt walks back from off to the start of the line n lines above it.
+func lineStartAt(src []byte, off, n int) int {
+	for off > 0 && (src[off-1] != '\n' || n > 0) {
+		if src[off-1] == '\n' {
+			n--
+		}
+		off--
+	}
+	return off
+}
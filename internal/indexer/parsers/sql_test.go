@@ -0,0 +1,125 @@
+package parsers
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSQLParseNodes(t *testing.T) {
+	SetSQLEnabled(true)
+	defer SetSQLEnabled(false)
+
+	path, src := readFixture(t, "sql", "schema.sql")
+	result, err := ParseFile(path, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kinds := map[string]string{
+		"users":            "table",
+		"public.Orders":    "table",
+		"active_users":     "view",
+		"archive_orders":   "function",
+		"GetUser":          "query",
+		"ListRecentOrders": "query",
+		"UpsertUser":       "query",
+	}
+	for qname, kind := range kinds {
+		n := findNodeByQName(result.Nodes, qname)
+		if n == nil {
+			t.Errorf("expected node %s", qname)
+			continue
+		}
+		if n.Kind != kind {
+			t.Errorf("%s: expected kind %s, got %s", qname, kind, n.Kind)
+		}
+	}
+	// The index isn't a node
+	if len(result.Nodes) != len(kinds) {
+		t.Errorf("expected %d nodes, got %v", len(kinds), nodeNames(result.Nodes))
+	}
+
+	users := findNodeByQName(result.Nodes, "users")
+	if users.Docstring != "Everyone who can sign in." || users.Signature != "CREATE TABLE IF NOT EXISTS users" {
+		t.Errorf("unexpected users doc/signature: %q / %q", users.Docstring, users.Signature)
+	}
+	if users.StartLine != 5 || users.EndLine != 8 {
+		t.Errorf("expected users at 5-8, got %d-%d", users.StartLine, users.EndLine)
+	}
+	if orders := findNodeByQName(result.Nodes, "public.Orders"); orders.Name != "Orders" {
+		t.Errorf("expected quoted name to keep its case, got %q", orders.Name)
+	}
+
+	fn := findNodeByQName(result.Nodes, "archive_orders")
+	if fn.Signature != "CREATE FUNCTION archive_orders(uid BIGINT) RETURNS void" {
+		t.Errorf("unexpected function signature %q", fn.Signature)
+	}
+	if !fn.Deprecated {
+		t.Error("expected @deprecated to mark archive_orders")
+	}
+
+	get := findNodeByQName(result.Nodes, "GetUser")
+	if get.StartLine != 34 || get.Docstring != "Looks a user up by email." || !slices.Equal(get.Modifiers, []string{"one"}) {
+		t.Errorf("unexpected GetUser line/doc/modifiers: %d %q %v", get.StartLine, get.Docstring, get.Modifiers)
+	}
+	if get.Signature != "SELECT id, email FROM users WHERE email = $1 AND extract(epoch FROM now()) > 0" {
+		t.Errorf("unexpected GetUser signature %q", get.Signature)
+	}
+}
+
+func TestSQLTableEdges(t *testing.T) {
+	SetSQLEnabled(true)
+	defer SetSQLEnabled(false)
+
+	path, src := readFixture(t, "sql", "schema.sql")
+	result, err := ParseFile(path, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string][]string{
+		"users":            nil,
+		"public.Orders":    {"users"},
+		"active_users":     {"users", "public.Orders"},
+		"archive_orders":   {"public.Orders", "order_archive"},
+		"GetUser":          {"users"},
+		"ListRecentOrders": {"public.Orders", "users"},
+		"UpsertUser":       {"users"},
+	}
+	for source, targets := range want {
+		var got []string
+		for _, e := range result.Edges {
+			if e.Kind == "uses_table" && e.Source == source {
+				got = append(got, e.Target)
+			}
+		}
+		if !slices.Equal(got, targets) {
+			t.Errorf("%s: uses_table %v, want %v", source, got, targets)
+		}
+	}
+	if findEdge(result.Edges, "contains", path, "GetUser") == nil {
+		t.Error("expected the file to contain GetUser")
+	}
+}
+
+func TestSetSQLEnabled(t *testing.T) {
+	if HasParser(".sql") {
+		t.Fatal("SQL parsing should be off by default")
+	}
+	SetSQLEnabled(true)
+	if !HasParser(".sql") {
+		t.Error("expected .sql to have a parser once enabled")
+	}
+	SetSQLEnabled(false)
+	if HasParser(".sql") {
+		t.Error("expected disabling to remove the parser")
+	}
+}
+
+func TestSQLUnterminated(t *testing.T) {
+	for _, src := range []string{"SELECT 'oops", "CREATE FUNCTION f() AS $$ BEGIN", "/* never closed"} {
+		if _, err := NewSQLParser().Parse("bad.sql", []byte(src)); err == nil {
+			t.Errorf("expected an error for %q", src)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	sitter "github.com/smacker/go-tree-sitter"
@@ -12,7 +13,7 @@ import (
 	"github.com/smacker/go-tree-sitter/typescript/typescript"
 )
 
-var _ Parser = (*TypeScriptParser)(nil)
+var _ ContextParser = (*TypeScriptParser)(nil)
 
 type TypeScriptParser struct{}
 
@@ -21,7 +22,19 @@ func NewTypeScriptParser() *TypeScriptParser {
 }
 
 func (p *TypeScriptParser) Parse(filePath string, source []byte) (*ParseResult, error) {
-	lang, err := p.languageForExt(filepath.Ext(filePath))
+	return p.ParseCtx(context.Background(), filePath, source)
+}
+
+// ParseCtx is Parse, stopping with ctx's error once ctx is done.
+func (p *TypeScriptParser) ParseCtx(ctx context.Context, filePath string, source []byte) (*ParseResult, error) {
+	return p.parseAs(ctx, filePath, filepath.Ext(filePath), source)
+}
+
+// parseAs parses source with the grammar for ext while attributing nodes and
+// edges to filePath, for script embedded in another file type (see
+// SFCParser).
+func (p *TypeScriptParser) parseAs(ctx context.Context, filePath, ext string, source []byte) (*ParseResult, error) {
+	lang, err := p.languageForExt(ext)
 	if err != nil {
 		return nil, err
 	}
@@ -29,16 +42,25 @@ func (p *TypeScriptParser) Parse(filePath string, source []byte) (*ParseResult,
 	parser := sitter.NewParser()
 	parser.SetLanguage(lang)
 
-	tree, err := parser.ParseCtx(context.Background(), nil, source)
+	tree, syntaxErrors, err := parseRecovering(ctx, parser, source)
 	if err != nil {
-		return nil, fmt.Errorf("tree-sitter parse: %w", err)
+		return nil, err
 	}
 	defer tree.Close()
 
-	result := &ParseResult{}
+	result := &ParseResult{HasSyntaxErrors: len(syntaxErrors) > 0, SyntaxErrors: syntaxErrors}
 	root := tree.RootNode()
 	p.walkTopLevel(source, root, "", result)
+	markExportClauses(source, root, result)
+	qualifyAnonymousDefault(filePath, result.Nodes)
 	p.extractEdges(source, root, filePath, result)
+	extractRoutes(filePath, source, root, result)
+	applyDocTags(result.Nodes)
+	if isGeneratedTS(source, root) {
+		for i := range result.Nodes {
+			result.Nodes[i].Generated = true
+		}
+	}
 	return result, nil
 }
 
@@ -102,15 +124,17 @@ func (p *TypeScriptParser) extractFunction(source []byte, node *sitter.Node, par
 	}
 
 	info := NodeInfo{
-		Name:          name,
-		QualifiedName: qname,
-		Kind:          "function",
-		Signature:     extractSignature(source, node),
-		StartLine:     int(node.StartPoint().Row) + 1,
-		EndLine:       int(node.EndPoint().Row) + 1,
-		SourceCode:    nodeContent(source, node),
-		Docstring:     extractDocstring(source, node),
-		BodyHash:      computeBodyHash(source, node),
+		Name:           name,
+		QualifiedName:  qname,
+		Kind:           "function",
+		Signature:      extractSignature(source, node),
+		StartLine:      int(node.StartPoint().Row) + 1,
+		EndLine:        int(node.EndPoint().Row) + 1,
+		SourceCode:     nodeContent(source, node),
+		Docstring:      extractDocstring(source, node),
+		BodyHash:       computeBodyHash(source, node),
+		StructuralHash: computeStructuralHash(source, node),
+		Modifiers:      tsModifiers(node),
 	}
 	result.Nodes = append(result.Nodes, info)
 }
@@ -123,19 +147,20 @@ func (p *TypeScriptParser) extractClass(source []byte, node *sitter.Node, result
 	name := nodeContent(source, nameNode)
 
 	info := NodeInfo{
-		Name:          name,
-		QualifiedName: name,
-		Kind:          "class",
-		Signature:     extractSignature(source, node),
-		StartLine:     int(node.StartPoint().Row) + 1,
-		EndLine:       int(node.EndPoint().Row) + 1,
-		SourceCode:    nodeContent(source, node),
-		Docstring:     extractDocstring(source, node),
-		BodyHash:      computeBodyHash(source, node),
+		Name:           name,
+		QualifiedName:  name,
+		Kind:           "class",
+		Signature:      extractSignature(source, node),
+		StartLine:      int(node.StartPoint().Row) + 1,
+		EndLine:        int(node.EndPoint().Row) + 1,
+		SourceCode:     nodeContent(source, node),
+		Docstring:      extractDocstring(source, node),
+		BodyHash:       computeBodyHash(source, node),
+		StructuralHash: computeStructuralHash(source, node),
 	}
 	result.Nodes = append(result.Nodes, info)
 
-	// Recurse into class body for methods
+	// Recurse into class body for methods, including arrow-function fields
 	body := node.ChildByFieldName("body")
 	if body == nil {
 		return
@@ -144,10 +169,68 @@ func (p *TypeScriptParser) extractClass(source []byte, node *sitter.Node, result
 		child := body.NamedChild(i)
 		if child.Type() == "method_definition" {
 			p.extractMethod(source, child, name, result)
+		} else if fn := classFieldFunction(child); fn != nil {
+			p.extractFieldMethod(source, child, fn, name, result)
 		}
 	}
 }
 
+// extractFieldMethod emits a method node for a class field initialized with a
+// function, e.g. `handleClick = () => {...}` in a React class component.
+func (p *TypeScriptParser) extractFieldMethod(source []byte, field, fn *sitter.Node, className string, result *ParseResult) {
+	nameNode := classFieldName(field)
+	if nameNode == nil {
+		return
+	}
+	name := nodeContent(source, nameNode)
+
+	signature := extractSignature(source, field)
+	if fn.Type() == "arrow_function" {
+		signature = extractArrowSignature(source, field)
+	}
+
+	info := NodeInfo{
+		Name:           name,
+		QualifiedName:  className + "." + name,
+		Kind:           "method",
+		Signature:      signature,
+		StartLine:      int(field.StartPoint().Row) + 1,
+		EndLine:        int(field.EndPoint().Row) + 1,
+		SourceCode:     nodeContent(source, field),
+		Docstring:      extractDocstring(source, field),
+		BodyHash:       computeBodyHash(source, field),
+		StructuralHash: computeStructuralHash(source, field),
+		Modifiers:      append(tsModifiers(field), tsModifiers(fn)...),
+	}
+	result.Nodes = append(result.Nodes, info)
+}
+
+// classFieldFunction returns the arrow or function expression a class field
+// is initialized with, or nil if the node is not such a field.
+func classFieldFunction(node *sitter.Node) *sitter.Node {
+	if node.Type() != "public_field_definition" && node.Type() != "field_definition" {
+		return nil
+	}
+	value := node.ChildByFieldName("value")
+	if value == nil {
+		return nil
+	}
+	switch value.Type() {
+	case "arrow_function", "function_expression", "function":
+		return value
+	}
+	return nil
+}
+
+// classFieldName returns a class field's name node. The TypeScript grammar
+// calls it "name", the JavaScript grammar "property".
+func classFieldName(field *sitter.Node) *sitter.Node {
+	if n := field.ChildByFieldName("name"); n != nil {
+		return n
+	}
+	return field.ChildByFieldName("property")
+}
+
 func (p *TypeScriptParser) extractMethod(source []byte, node *sitter.Node, className string, result *ParseResult) {
 	nameNode := node.ChildByFieldName("name")
 	if nameNode == nil {
@@ -156,15 +239,17 @@ func (p *TypeScriptParser) extractMethod(source []byte, node *sitter.Node, class
 	name := nodeContent(source, nameNode)
 
 	info := NodeInfo{
-		Name:          name,
-		QualifiedName: className + "." + name,
-		Kind:          "method",
-		Signature:     extractSignature(source, node),
-		StartLine:     int(node.StartPoint().Row) + 1,
-		EndLine:       int(node.EndPoint().Row) + 1,
-		SourceCode:    nodeContent(source, node),
-		Docstring:     extractDocstring(source, node),
-		BodyHash:      computeBodyHash(source, node),
+		Name:           name,
+		QualifiedName:  className + "." + name,
+		Kind:           "method",
+		Signature:      extractSignature(source, node),
+		StartLine:      int(node.StartPoint().Row) + 1,
+		EndLine:        int(node.EndPoint().Row) + 1,
+		SourceCode:     nodeContent(source, node),
+		Docstring:      extractDocstring(source, node),
+		BodyHash:       computeBodyHash(source, node),
+		StructuralHash: computeStructuralHash(source, node),
+		Modifiers:      tsModifiers(node),
 	}
 	result.Nodes = append(result.Nodes, info)
 }
@@ -178,15 +263,16 @@ func (p *TypeScriptParser) extractSimpleDecl(source []byte, node *sitter.Node, k
 	qname := qualifiedName(parentName, name)
 
 	info := NodeInfo{
-		Name:          name,
-		QualifiedName: qname,
-		Kind:          kind,
-		Signature:     extractSignature(source, node),
-		StartLine:     int(node.StartPoint().Row) + 1,
-		EndLine:       int(node.EndPoint().Row) + 1,
-		SourceCode:    nodeContent(source, node),
-		Docstring:     extractDocstring(source, node),
-		BodyHash:      computeBodyHash(source, node),
+		Name:           name,
+		QualifiedName:  qname,
+		Kind:           kind,
+		Signature:      extractSignature(source, node),
+		StartLine:      int(node.StartPoint().Row) + 1,
+		EndLine:        int(node.EndPoint().Row) + 1,
+		SourceCode:     nodeContent(source, node),
+		Docstring:      extractDocstring(source, node),
+		BodyHash:       computeBodyHash(source, node),
+		StructuralHash: computeStructuralHash(source, node),
 	}
 	result.Nodes = append(result.Nodes, info)
 }
@@ -214,15 +300,17 @@ func (p *TypeScriptParser) extractLexicalDecl(source []byte, node *sitter.Node,
 
 		// Use the entire lexical_declaration as the node span for docstrings
 		info := NodeInfo{
-			Name:          name,
-			QualifiedName: qname,
-			Kind:          "function",
-			Signature:     extractArrowSignature(source, decl),
-			StartLine:     int(node.StartPoint().Row) + 1,
-			EndLine:       int(node.EndPoint().Row) + 1,
-			SourceCode:    nodeContent(source, node),
-			Docstring:     extractDocstring(source, node),
-			BodyHash:      computeBodyHash(source, node),
+			Name:           name,
+			QualifiedName:  qname,
+			Kind:           "function",
+			Signature:      extractArrowSignature(source, decl),
+			StartLine:      int(node.StartPoint().Row) + 1,
+			EndLine:        int(node.EndPoint().Row) + 1,
+			SourceCode:     nodeContent(source, node),
+			Docstring:      extractDocstring(source, node),
+			BodyHash:       computeBodyHash(source, node),
+			StructuralHash: computeStructuralHash(source, node),
+			Modifiers:      tsModifiers(value),
 		}
 		result.Nodes = append(result.Nodes, info)
 	}
@@ -233,39 +321,56 @@ func (p *TypeScriptParser) extractExport(source []byte, node *sitter.Node, paren
 	// Capture the docstring from the export node so we can attach it to the inner declaration.
 	exportDocstring := extractDocstring(source, node)
 
-	// export default function() → unwrap the declaration
+	// Everything extracted from inside the export statement is exported; for
+	// `export default <declaration>` the first node is the default export.
+	first := len(result.Nodes)
+	isDefault := hasDefaultKeyword(node)
+	defer func() {
+		markExported(result.Nodes[first:])
+		if isDefault && len(result.Nodes) > first {
+			result.Nodes[first].DefaultExport = true
+		}
+	}()
+
 	for i := 0; i < int(node.NamedChildCount()); i++ {
 		child := node.NamedChild(i)
 		switch child.Type() {
+		case "function_expression", "function", "arrow_function":
+			// export default function() {} / export default () => {} — anonymous.
+			// Named "default" here; qualifyAnonymousDefault renames it per file.
+			if !isDefault || child.ChildByFieldName("body") == nil {
+				continue
+			}
+			signature := extractSignature(source, child)
+			if child.Type() == "arrow_function" {
+				signature = extractArrowSignature(source, child)
+			}
+			result.Nodes = append(result.Nodes, NodeInfo{
+				Name:           "default",
+				QualifiedName:  qualifiedName(parentName, "default"),
+				Kind:           "function",
+				Signature:      signature,
+				StartLine:      int(node.StartPoint().Row) + 1,
+				EndLine:        int(node.EndPoint().Row) + 1,
+				SourceCode:     nodeContent(source, node),
+				Docstring:      exportDocstring,
+				BodyHash:       computeBodyHash(source, node),
+				StructuralHash: computeStructuralHash(source, node),
+				Modifiers:      tsModifiers(child),
+			})
+
 		case "function_declaration":
 			nameNode := child.ChildByFieldName("name")
 			if nameNode == nil {
-				// export default function() — anonymous
-				body := child.ChildByFieldName("body")
-				if body == nil {
-					continue
-				}
-				info := NodeInfo{
-					Name:          "default",
-					QualifiedName: qualifiedName(parentName, "default"),
-					Kind:          "function",
-					Signature:     extractSignature(source, child),
-					StartLine:     int(node.StartPoint().Row) + 1,
-					EndLine:       int(node.EndPoint().Row) + 1,
-					SourceCode:    nodeContent(source, node),
-					Docstring:     exportDocstring,
-					BodyHash:      computeBodyHash(source, node),
-				}
-				result.Nodes = append(result.Nodes, info)
-			} else {
-				p.extractFunction(source, child, parentName, result)
-				// Backfill export docstring if the inner node had none
-				if exportDocstring != "" {
-					for j := range result.Nodes {
-						n := &result.Nodes[j]
-						if n.Name == nodeContent(source, nameNode) && n.Docstring == "" {
-							n.Docstring = exportDocstring
-						}
+				continue
+			}
+			p.extractFunction(source, child, parentName, result)
+			// Backfill export docstring if the inner node had none
+			if exportDocstring != "" {
+				for j := range result.Nodes {
+					n := &result.Nodes[j]
+					if n.Name == nodeContent(source, nameNode) && n.Docstring == "" {
+						n.Docstring = exportDocstring
 					}
 				}
 			}
@@ -311,6 +416,201 @@ func (p *TypeScriptParser) extractExport(source []byte, node *sitter.Node, paren
 	}
 }
 
+// markExported flags nodes as exported, except class members hidden by
+// `private`/`protected` or a `#private` name.
+func markExported(nodes []NodeInfo) {
+	for i := range nodes {
+		n := &nodes[i]
+		if n.Kind == "method" && (slices.Contains(n.Modifiers, "private") || slices.Contains(n.Modifiers, "protected") || strings.HasPrefix(n.Name, "#")) {
+			continue
+		}
+		n.Exported = true
+	}
+}
+
+// markExportClauses handles `export { a, b as c }` and `export default a`
+// referring to local declarations: the named top-level nodes (and their
+// members) are exported, and `export default a` marks a as the default export.
+// CommonJS assignments to module.exports/exports count the same way, as does
+// TypeScript's `export = a`, its typed spelling of `module.exports = a`.
+// Re-exports with a `from` source declare nothing locally and are skipped.
+func markExportClauses(source []byte, root *sitter.Node, result *ParseResult) {
+	names := make(map[string]bool)
+	defaultName := ""
+	for i := 0; i < int(root.NamedChildCount()); i++ {
+		stmt := root.NamedChild(i)
+		if stmt.Type() == "expression_statement" {
+			exported, def := commonJSExports(source, stmt)
+			for _, name := range exported {
+				names[name] = true
+			}
+			if def != "" {
+				defaultName = def
+			}
+			continue
+		}
+		if stmt.Type() != "export_statement" || stmt.ChildByFieldName("source") != nil {
+			continue
+		}
+		if hasKeyword(stmt, "=") && stmt.NamedChildCount() > 0 {
+			exported, def := moduleExportsValue(source, stmt.NamedChild(0))
+			for _, name := range exported {
+				names[name] = true
+			}
+			if def != "" {
+				defaultName = def
+			}
+			continue
+		}
+		if value := stmt.ChildByFieldName("value"); value != nil && value.Type() == "identifier" && hasDefaultKeyword(stmt) {
+			defaultName = nodeContent(source, value)
+			names[defaultName] = true
+		}
+		for j := 0; j < int(stmt.NamedChildCount()); j++ {
+			clause := stmt.NamedChild(j)
+			if clause.Type() != "export_clause" {
+				continue
+			}
+			for k := 0; k < int(clause.NamedChildCount()); k++ {
+				if nameNode := clause.NamedChild(k).ChildByFieldName("name"); nameNode != nil {
+					names[nodeContent(source, nameNode)] = true
+				}
+			}
+		}
+	}
+	if len(names) == 0 {
+		return
+	}
+
+	for i := range result.Nodes {
+		owner, _, _ := strings.Cut(result.Nodes[i].QualifiedName, ".")
+		if names[owner] {
+			markExported(result.Nodes[i : i+1])
+		}
+		if defaultName != "" && result.Nodes[i].QualifiedName == defaultName {
+			result.Nodes[i].DefaultExport = true
+		}
+	}
+}
+
+// commonJSExports recognizes a top-level CommonJS export assignment and
+// returns the local names it exports: `module.exports = foo` (foo becomes the
+// default export), `module.exports = { a, b: c }`, `exports.foo = ...`, and
+// `module.exports.foo = ...`. For property assignments the right-hand
+// identifier is used when there is one, else the property name.
+func commonJSExports(source []byte, stmt *sitter.Node) (names []string, defaultName string) {
+	if stmt.NamedChildCount() == 0 {
+		return nil, ""
+	}
+	assign := stmt.NamedChild(0)
+	if assign.Type() != "assignment_expression" {
+		return nil, ""
+	}
+	left := assign.ChildByFieldName("left")
+	right := assign.ChildByFieldName("right")
+	if left == nil || right == nil || left.Type() != "member_expression" {
+		return nil, ""
+	}
+
+	target := nodeContent(source, left)
+	switch {
+	case target == "module.exports":
+		return moduleExportsValue(source, right)
+	case strings.HasPrefix(target, "exports.") || strings.HasPrefix(target, "module.exports."):
+		if right.Type() == "identifier" {
+			return []string{nodeContent(source, right)}, ""
+		}
+		if prop := left.ChildByFieldName("property"); prop != nil {
+			return []string{nodeContent(source, prop)}, ""
+		}
+	}
+	return nil, ""
+}
+
+// moduleExportsValue returns the local names exported by the value assigned
+// to the whole module, in `module.exports = value` or `export = value`: an
+// identifier becomes the default export, and an object literal exports the
+// identifiers its properties name.
+func moduleExportsValue(source []byte, value *sitter.Node) (names []string, defaultName string) {
+	switch value.Type() {
+	case "identifier":
+		name := nodeContent(source, value)
+		return []string{name}, name
+	case "object":
+		for i := 0; i < int(value.NamedChildCount()); i++ {
+			prop := value.NamedChild(i)
+			switch prop.Type() {
+			case "shorthand_property_identifier":
+				names = append(names, nodeContent(source, prop))
+			case "pair":
+				if v := prop.ChildByFieldName("value"); v != nil && v.Type() == "identifier" {
+					names = append(names, nodeContent(source, v))
+				}
+			}
+		}
+	}
+	return names, ""
+}
+
+// hasDefaultKeyword reports whether an export_statement is `export default ...`.
+func hasDefaultKeyword(stmt *sitter.Node) bool {
+	return hasKeyword(stmt, "default")
+}
+
+// hasKeyword reports whether node has the anonymous keyword token kw as a
+// direct child, e.g. the `type` in `import type { X }`.
+func hasKeyword(node *sitter.Node, kw string) bool {
+	for i := 0; i < int(node.ChildCount()); i++ {
+		if c := node.Child(i); !c.IsNamed() && c.Type() == kw {
+			return true
+		}
+	}
+	return false
+}
+
+// qualifyAnonymousDefault renames an anonymous default export after its file
+// so defaults from different modules don't all collide on "default":
+// components/Button.tsx → Button.default, components/Button/index.tsx →
+// Button.default. Name is set to the bare file name, which is what callers
+// usually bind it to on import.
+func qualifyAnonymousDefault(filePath string, nodes []NodeInfo) {
+	for i := range nodes {
+		n := &nodes[i]
+		if !n.DefaultExport || n.QualifiedName != "default" {
+			continue
+		}
+		name := defaultExportBaseName(filePath)
+		n.Name = name
+		n.QualifiedName = name + ".default"
+	}
+}
+
+// isGeneratedTS reports whether a comment above the first statement carries
+// an `@generated` tag, the convention used by codegen tools like GraphQL
+// Code Generator and protobuf-ts.
+func isGeneratedTS(source []byte, root *sitter.Node) bool {
+	for i := 0; i < int(root.NamedChildCount()); i++ {
+		child := root.NamedChild(i)
+		if child.Type() != "comment" && child.Type() != "hash_bang_line" {
+			return false
+		}
+		if strings.Contains(nodeContent(source, child), "@generated") {
+			return true
+		}
+	}
+	return false
+}
+
+func defaultExportBaseName(filePath string) string {
+	base := strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
+	if base == "index" {
+		if dir := filepath.Base(filepath.Dir(filePath)); dir != "." && dir != string(filepath.Separator) {
+			return dir
+		}
+	}
+	return base
+}
+
 // backfillDocstring sets the export-level docstring on the last-added node if it has none.
 func (p *TypeScriptParser) backfillDocstring(source []byte, child *sitter.Node, result *ParseResult, docstring string) {
 	nameNode := child.ChildByFieldName("name")
@@ -380,15 +680,24 @@ func (p *TypeScriptParser) extractEdges(source []byte, root *sitter.Node, filePa
 	p.extractContainsEdges(filePath, result)
 	p.extractClassEdges(source, root, result)
 	p.extractCallEdges(source, root, result)
+	p.extractReferenceEdges(source, root, result)
 	p.extractTypeEdges(source, root, result)
 }
 
 func (p *TypeScriptParser) extractImportEdges(source []byte, root *sitter.Node, filePath string, result *ParseResult) {
 	for i := 0; i < int(root.NamedChildCount()); i++ {
 		child := root.NamedChild(i)
+		if edge, ok := reExportEdge(source, child, filePath); ok {
+			result.Edges = append(result.Edges, edge)
+			continue
+		}
 		if child.Type() != "import_statement" {
 			continue
 		}
+		if edge, ok := importRequireEdge(source, child, filePath); ok {
+			result.Edges = append(result.Edges, edge)
+			continue
+		}
 
 		moduleNode := findChildByType(child, "string")
 		if moduleNode == nil {
@@ -396,24 +705,190 @@ func (p *TypeScriptParser) extractImportEdges(source []byte, root *sitter.Node,
 		}
 		module := stripQuotes(nodeContent(source, moduleNode))
 
-		var symbols []string
+		var symbols, typeOnlySymbols []string
+		defaultImport := ""
 		clause := findChildByType(child, "import_clause")
 		if clause != nil {
-			symbols = extractImportSymbols(source, clause)
+			symbols, typeOnlySymbols = extractImportSymbols(source, clause)
+			if id := findChildByType(clause, "identifier"); id != nil {
+				defaultImport = nodeContent(source, id)
+			}
+		}
+		// `import type ...` erases every binding; so does a clause made up
+		// only of inline `type` specifiers.
+		typeOnly := hasKeyword(child, "type")
+		if typeOnly {
+			typeOnlySymbols = symbols
+		} else if len(symbols) > 0 && len(typeOnlySymbols) == len(symbols) {
+			typeOnly = true
 		}
 
 		result.Edges = append(result.Edges, EdgeInfo{
-			Source:  filePath,
-			Target:  module,
-			Kind:    "imports",
-			Line:    int(child.StartPoint().Row) + 1,
-			Symbols: symbols,
+			Source:          filePath,
+			Target:          module,
+			Kind:            "imports",
+			Line:            int(child.StartPoint().Row) + 1,
+			Symbols:         symbols,
+			DefaultImport:   defaultImport,
+			TypeOnly:        typeOnly,
+			TypeOnlySymbols: typeOnlySymbols,
 		})
 	}
+	collectRequires(source, root, filePath, result)
+}
+
+// importRequireEdge turns TypeScript's `import m = require('./mod')` into
+// an imports edge. Like `const m = require(...)`, m binds module.exports, so
+// it is recorded as a default import; `import type m = require(...)` is
+// type-only. Internal aliases (`import x = NS.y`) aren't import statements
+// and never get here.
+func importRequireEdge(source []byte, stmt *sitter.Node, filePath string) (EdgeInfo, bool) {
+	clause := findChildByType(stmt, "import_require_clause")
+	if clause == nil {
+		return EdgeInfo{}, false
+	}
+	moduleNode := clause.ChildByFieldName("source")
+	nameNode := findChildByType(clause, "identifier")
+	if moduleNode == nil || nameNode == nil {
+		return EdgeInfo{}, false
+	}
+
+	name := nodeContent(source, nameNode)
+	edge := EdgeInfo{
+		Source:        filePath,
+		Target:        stripQuotes(nodeContent(source, moduleNode)),
+		Kind:          "imports",
+		Line:          int(stmt.StartPoint().Row) + 1,
+		Symbols:       []string{name},
+		DefaultImport: name,
+		TypeOnly:      hasKeyword(stmt, "type"),
+	}
+	if edge.TypeOnly {
+		edge.TypeOnlySymbols = edge.Symbols
+	}
+	return edge, true
+}
+
+// reExportEdge turns `export ... from './mod'` into a re_exports edge.
+// Symbols lists the names the file exports, written "orig as name" when
+// renamed and "* as ns" for a namespace re-export; `export * from` has no
+// Symbols, meaning everything the module exports.
+func reExportEdge(source []byte, stmt *sitter.Node, filePath string) (EdgeInfo, bool) {
+	if stmt.Type() != "export_statement" {
+		return EdgeInfo{}, false
+	}
+	moduleNode := stmt.ChildByFieldName("source")
+	if moduleNode == nil {
+		return EdgeInfo{}, false
+	}
+
+	edge := EdgeInfo{
+		Source:   filePath,
+		Target:   stripQuotes(nodeContent(source, moduleNode)),
+		Kind:     "re_exports",
+		Line:     int(stmt.StartPoint().Row) + 1,
+		TypeOnly: hasKeyword(stmt, "type"),
+	}
+	for i := 0; i < int(stmt.NamedChildCount()); i++ {
+		child := stmt.NamedChild(i)
+		switch child.Type() {
+		case "namespace_export":
+			if id := findChildByType(child, "identifier"); id != nil {
+				edge.Symbols = append(edge.Symbols, "* as "+nodeContent(source, id))
+			}
+		case "export_clause":
+			for j := 0; j < int(child.NamedChildCount()); j++ {
+				spec := child.NamedChild(j)
+				name := spec.ChildByFieldName("name")
+				if spec.Type() != "export_specifier" || name == nil {
+					continue
+				}
+				sym := nodeContent(source, name)
+				if alias := spec.ChildByFieldName("alias"); alias != nil {
+					sym += " as " + nodeContent(source, alias)
+				}
+				edge.Symbols = append(edge.Symbols, sym)
+			}
+		}
+	}
+	if edge.TypeOnly {
+		edge.TypeOnlySymbols = edge.Symbols
+	}
+	return edge, true
+}
+
+// collectRequires emits imports edges for CommonJS `require('mod')` calls
+// anywhere in the file. Bindings come from the enclosing declarator:
+// `const { a, b: c } = require('x')` imports a and b, and `const m =
+// require('x')` binds m to module.exports, which is treated like a default
+// import. Dynamic arguments (`require(name)`) are skipped.
+func collectRequires(source []byte, node *sitter.Node, filePath string, result *ParseResult) {
+	for i := 0; i < int(node.NamedChildCount()); i++ {
+		child := node.NamedChild(i)
+		if module, ok := requiredModule(source, child); ok {
+			edge := EdgeInfo{
+				Source: filePath,
+				Target: module,
+				Kind:   "imports",
+				Line:   int(child.StartPoint().Row) + 1,
+			}
+			if value := node.ChildByFieldName("value"); node.Type() == "variable_declarator" && value != nil && value.StartByte() == child.StartByte() {
+				edge.Symbols, edge.DefaultImport = requireBindings(source, node.ChildByFieldName("name"))
+			}
+			result.Edges = append(result.Edges, edge)
+			continue
+		}
+		collectRequires(source, child, filePath, result)
+	}
 }
 
-func extractImportSymbols(source []byte, clause *sitter.Node) []string {
-	var symbols []string
+// requiredModule returns the module of a `require('...')` call expression.
+func requiredModule(source []byte, node *sitter.Node) (string, bool) {
+	if node.Type() != "call_expression" {
+		return "", false
+	}
+	fn := node.ChildByFieldName("function")
+	if fn == nil || fn.Type() != "identifier" || nodeContent(source, fn) != "require" {
+		return "", false
+	}
+	args := node.ChildByFieldName("arguments")
+	if args == nil || args.NamedChildCount() != 1 || args.NamedChild(0).Type() != "string" {
+		return "", false
+	}
+	return stripQuotes(nodeContent(source, args.NamedChild(0))), true
+}
+
+// requireBindings returns the symbols bound by a require declarator's name,
+// plus the default-import name when the whole module is assigned.
+func requireBindings(source []byte, name *sitter.Node) ([]string, string) {
+	if name == nil {
+		return nil, ""
+	}
+	switch name.Type() {
+	case "identifier":
+		id := nodeContent(source, name)
+		return []string{id}, id
+	case "object_pattern":
+		var symbols []string
+		for i := 0; i < int(name.NamedChildCount()); i++ {
+			prop := name.NamedChild(i)
+			switch prop.Type() {
+			case "shorthand_property_identifier_pattern":
+				symbols = append(symbols, nodeContent(source, prop))
+			case "pair_pattern":
+				if key := prop.ChildByFieldName("key"); key != nil {
+					symbols = append(symbols, nodeContent(source, key))
+				}
+			}
+		}
+		return symbols, ""
+	}
+	return nil, ""
+}
+
+// extractImportSymbols returns the names an import clause binds, plus the
+// subset marked with an inline `type` modifier (`import { type A, B }`).
+func extractImportSymbols(source []byte, clause *sitter.Node) (symbols, typeOnly []string) {
 	for i := 0; i < int(clause.ChildCount()); i++ {
 		child := clause.Child(i)
 		switch child.Type() {
@@ -426,6 +901,9 @@ func extractImportSymbols(source []byte, clause *sitter.Node) []string {
 					name := spec.ChildByFieldName("name")
 					if name != nil {
 						symbols = append(symbols, nodeContent(source, name))
+						if hasKeyword(spec, "type") {
+							typeOnly = append(typeOnly, nodeContent(source, name))
+						}
 					}
 				}
 			}
@@ -439,7 +917,7 @@ func extractImportSymbols(source []byte, clause *sitter.Node) []string {
 			}
 		}
 	}
-	return symbols
+	return symbols, typeOnly
 }
 
 func (p *TypeScriptParser) extractContainsEdges(filePath string, result *ParseResult) {
@@ -639,7 +1117,18 @@ func jsxTagName(source []byte, node *sitter.Node) string {
 	return ""
 }
 
-func (p *TypeScriptParser) extractTypeEdges(source []byte, root *sitter.Node, result *ParseResult) {
+// extractReferenceEdges adds a references edge for each known value a
+// function uses without calling it: a callback passed along
+// (`items.map(transform)`), a class handed to `new` or a registry, an enum
+// read. Known values are the file's top-level functions, classes, and enums
+// and its runtime imports; a name the function binds itself (a parameter or
+// local) shadows them everywhere in the function. Each target gets one edge
+// per function, at its first use.
+func (p *TypeScriptParser) extractReferenceEdges(source []byte, root *sitter.Node, result *ParseResult) {
+	known := tsKnownValues(result)
+	if len(known) == 0 {
+		return
+	}
 	for _, node := range result.Nodes {
 		if node.Kind != "function" && node.Kind != "method" {
 			continue
@@ -648,7 +1137,174 @@ func (p *TypeScriptParser) extractTypeEdges(source []byte, root *sitter.Node, re
 		if astNode == nil {
 			continue
 		}
-		types := collectTypeAnnotations(source, astNode)
+		body := findBody(astNode)
+		if body == nil {
+			continue
+		}
+		locals := make(map[string]bool)
+		collectTSBindings(source, astNode, locals)
+		seen := map[string]bool{node.QualifiedName: true}
+		collectTSReferences(source, body, known, func(name string, line int) {
+			base, _, _ := strings.Cut(name, ".")
+			if _, ok := known[base]; !ok || locals[base] || seen[name] {
+				return
+			}
+			seen[name] = true
+			result.Edges = append(result.Edges, EdgeInfo{
+				Source: node.QualifiedName,
+				Target: name,
+				Kind:   "references",
+				Line:   line,
+			})
+		})
+	}
+}
+
+// tsKnownValues returns the names a function body can refer to as values:
+// top-level functions, classes, and enums, and every runtime import binding.
+// Types and interfaces are left out, as they can't be used as values. The
+// value is true for namespace imports (`import * as utils`), whose members
+// are referenced as utils.name.
+func tsKnownValues(result *ParseResult) map[string]bool {
+	known := make(map[string]bool)
+	for _, n := range result.Nodes {
+		switch n.Kind {
+		case "function", "class", "enum":
+			if n.QualifiedName == n.Name {
+				known[n.Name] = false
+			}
+		}
+	}
+	for _, e := range result.Edges {
+		if e.Kind != "imports" || e.TypeOnly {
+			continue
+		}
+		typeOnly := make(map[string]bool, len(e.TypeOnlySymbols))
+		for _, s := range e.TypeOnlySymbols {
+			typeOnly[s] = true
+		}
+		for _, s := range e.Symbols {
+			if ns, ok := strings.CutPrefix(s, "* as "); ok {
+				known[ns] = true
+			} else if !typeOnly[s] {
+				known[s] = false
+			}
+		}
+	}
+	return known
+}
+
+// collectTSBindings records every name node declares: parameters, variables,
+// nested function names, and catch parameters, including ones bound by
+// destructuring.
+func collectTSBindings(source []byte, node *sitter.Node, names map[string]bool) {
+	switch node.Type() {
+	case "required_parameter", "optional_parameter":
+		if pattern := node.ChildByFieldName("pattern"); pattern != nil {
+			collectTSPatternNames(source, pattern, names)
+		}
+	case "variable_declarator":
+		if name := node.ChildByFieldName("name"); name != nil {
+			collectTSPatternNames(source, name, names)
+		}
+	case "arrow_function":
+		// A lone unparenthesized parameter: x => ...
+		if param := node.ChildByFieldName("parameter"); param != nil {
+			collectTSPatternNames(source, param, names)
+		}
+	case "catch_clause":
+		if param := node.ChildByFieldName("parameter"); param != nil {
+			collectTSPatternNames(source, param, names)
+		}
+	case "function_declaration", "function_expression", "class_declaration":
+		if name := node.ChildByFieldName("name"); name != nil {
+			names[nodeContent(source, name)] = true
+		}
+	}
+	for i := 0; i < int(node.NamedChildCount()); i++ {
+		collectTSBindings(source, node.NamedChild(i), names)
+	}
+}
+
+// collectTSPatternNames records the identifiers a binding pattern binds,
+// skipping default values and the keys of renamed properties.
+func collectTSPatternNames(source []byte, node *sitter.Node, names map[string]bool) {
+	switch node.Type() {
+	case "identifier", "shorthand_property_identifier_pattern":
+		names[nodeContent(source, node)] = true
+	case "assignment_pattern", "object_assignment_pattern":
+		if left := node.ChildByFieldName("left"); left != nil {
+			collectTSPatternNames(source, left, names)
+		}
+	case "pair_pattern":
+		if value := node.ChildByFieldName("value"); value != nil {
+			collectTSPatternNames(source, value, names)
+		}
+	default:
+		for i := 0; i < int(node.NamedChildCount()); i++ {
+			collectTSPatternNames(source, node.NamedChild(i), names)
+		}
+	}
+}
+
+// collectTSReferences reports identifiers used as values under node, and
+// members of namespace imports as ns.name. Callees and JSX tags are skipped,
+// since they already produce calls edges, and so are type positions.
+func collectTSReferences(source []byte, node *sitter.Node, known map[string]bool, report func(name string, line int)) {
+	switch node.Type() {
+	case "identifier", "shorthand_property_identifier":
+		report(nodeContent(source, node), int(node.StartPoint().Row)+1)
+		return
+	case "call_expression":
+		if fn := node.ChildByFieldName("function"); fn != nil && extractCalleeName(source, fn) != "" {
+			if args := node.ChildByFieldName("arguments"); args != nil {
+				collectTSReferences(source, args, known, report)
+			}
+			return
+		}
+	case "member_expression":
+		// Only the object is a value; the property belongs to it, unless the
+		// object is a namespace
+		obj := node.ChildByFieldName("object")
+		if obj == nil {
+			return
+		}
+		if prop := node.ChildByFieldName("property"); prop != nil && obj.Type() == "identifier" && known[nodeContent(source, obj)] {
+			report(nodeContent(source, obj)+"."+nodeContent(source, prop), int(node.StartPoint().Row)+1)
+			return
+		}
+		collectTSReferences(source, obj, known, report)
+		return
+	case "jsx_opening_element", "jsx_self_closing_element":
+		for i := 0; i < int(node.NamedChildCount()); i++ {
+			if child := node.NamedChild(i); child.Type() == "jsx_attribute" {
+				collectTSReferences(source, child, known, report)
+			}
+		}
+		return
+	case "jsx_closing_element", "type_annotation", "type_arguments", "type_parameters", "function_declaration":
+		return
+	}
+	for i := 0; i < int(node.NamedChildCount()); i++ {
+		collectTSReferences(source, node.NamedChild(i), known, report)
+	}
+}
+
+func (p *TypeScriptParser) extractTypeEdges(source []byte, root *sitter.Node, result *ParseResult) {
+	for _, node := range result.Nodes {
+		if node.Kind != "function" && node.Kind != "method" && node.Kind != "class" {
+			continue
+		}
+		astNode := findDeclAtLine(root, node.StartLine-1)
+		if astNode == nil {
+			continue
+		}
+		var types []typeRef
+		if node.Kind == "class" {
+			types = collectFieldTypes(source, astNode)
+		} else {
+			types = collectTypeAnnotations(source, astNode)
+		}
 		seen := make(map[string]bool)
 		for _, t := range types {
 			if seen[t.name] {
@@ -687,8 +1343,8 @@ func collectTypeAnnotations(source []byte, node *sitter.Node) []typeRef {
 		}
 	}
 
-	// For arrow functions inside variable declarators, check the declarator
-	if node.Type() == "variable_declarator" {
+	// For arrow functions inside variable declarators or class fields, check the value
+	if node.Type() == "variable_declarator" || classFieldFunction(node) != nil {
 		value := node.ChildByFieldName("value")
 		if value != nil {
 			params := value.ChildByFieldName("parameters")
@@ -707,6 +1363,27 @@ func collectTypeAnnotations(source []byte, node *sitter.Node) []typeRef {
 	return refs
 }
 
+// collectFieldTypes returns the types annotated on a class's data fields
+// (`state: State`). Function-valued fields are methods and carry their own
+// uses_type edges.
+func collectFieldTypes(source []byte, classNode *sitter.Node) []typeRef {
+	body := classNode.ChildByFieldName("body")
+	if body == nil {
+		return nil
+	}
+	var refs []typeRef
+	for i := 0; i < int(body.NamedChildCount()); i++ {
+		field := body.NamedChild(i)
+		if field.Type() != "public_field_definition" || classFieldFunction(field) != nil {
+			continue
+		}
+		if typ := field.ChildByFieldName("type"); typ != nil {
+			refs = append(refs, findTypeIdentifiers(source, typ)...)
+		}
+	}
+	return refs
+}
+
 func findTypeIdentifiers(source []byte, node *sitter.Node) []typeRef {
 	var refs []typeRef
 	if node.Type() == "type_identifier" {
@@ -743,6 +1420,31 @@ func findChildByType(node *sitter.Node, nodeType string) *sitter.Node {
 	return nil
 }
 
+// tsModifiers collects the keyword modifiers that precede a declaration's name:
+// accessibility (public/private/protected), static, async, readonly, abstract,
+// override, get/set accessors, and "generator" for function*.
+func tsModifiers(node *sitter.Node) []string {
+	var mods []string
+	for i := 0; i < int(node.ChildCount()); i++ {
+		child := node.Child(i)
+		switch child.Type() {
+		case "accessibility_modifier":
+			if child.ChildCount() > 0 {
+				mods = append(mods, child.Child(0).Type())
+			}
+		case "static", "async", "readonly", "abstract", "override", "get", "set":
+			if !child.IsNamed() {
+				mods = append(mods, child.Type())
+			}
+		case "*":
+			mods = append(mods, "generator")
+		case "formal_parameters", "statement_block":
+			return mods
+		}
+	}
+	return mods
+}
+
 func stripQuotes(s string) string {
 	s = strings.TrimPrefix(s, "\"")
 	s = strings.TrimSuffix(s, "\"")
@@ -779,7 +1481,7 @@ func findDeclAtLine(root *sitter.Node, row int) *sitter.Node {
 			if body != nil {
 				for j := 0; j < int(body.NamedChildCount()); j++ {
 					method := body.NamedChild(j)
-					if method.Type() == "method_definition" && int(method.StartPoint().Row) == row {
+					if (method.Type() == "method_definition" || classFieldFunction(method) != nil) && int(method.StartPoint().Row) == row {
 						return method
 					}
 				}
@@ -803,5 +1505,10 @@ func findBody(node *sitter.Node) *sitter.Node {
 		}
 		return nil
 	}
+	// For function-valued class fields, walk the whole function so concise
+	// arrow bodies are covered too
+	if fn := classFieldFunction(node); fn != nil {
+		return fn
+	}
 	return node.ChildByFieldName("body")
 }
@@ -4,6 +4,8 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
+	"strings"
 	"testing"
 )
 
@@ -290,6 +292,45 @@ func TestSyntaxErrors(t *testing.T) {
 	_ = result
 }
 
+func TestModifiers(t *testing.T) {
+	src := []byte(`class Repo {
+  private static async load(): Promise<void> {}
+  public save(): void {}
+  get size() { return 0 }
+  *items() {}
+}
+async function fetchAll() {}
+const run = async () => {}
+function plain() {}`)
+	result, err := ParseFile("repo.ts", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"load", []string{"private", "static", "async"}},
+		{"save", []string{"public"}},
+		{"size", []string{"get"}},
+		{"items", []string{"generator"}},
+		{"fetchAll", []string{"async"}},
+		{"run", []string{"async"}},
+		{"plain", nil},
+	}
+	for _, tt := range tests {
+		n := findNode(result.Nodes, tt.name)
+		if n == nil {
+			t.Errorf("expected node %q", tt.name)
+			continue
+		}
+		if strings.Join(n.Modifiers, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%s.Modifiers = %v, want %v", tt.name, n.Modifiers, tt.want)
+		}
+	}
+}
+
 func TestBodyHashDeterminism(t *testing.T) {
 	path, src := readFixture(t, "typescript", "functions.ts")
 	r1, _ := ParseFile(path, src)
@@ -351,3 +392,343 @@ func findNodes(nodes []NodeInfo, name string) []NodeInfo {
 	}
 	return found
 }
+
+func TestExportedAndReleaseTags(t *testing.T) {
+	src := []byte(`/** @internal */
+export function helper() { return 1 }
+
+/**
+ * Public entry.
+ * @public
+ * @deprecated use start instead
+ */
+export function run() {}
+
+function local() {}
+const other = () => 1
+export { other }
+
+export class Svc {
+  private secret() {}
+  open() {}
+}`)
+	result, err := ParseFile("api.ts", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		exported   bool
+		releaseTag string
+		deprecated bool
+	}{
+		{"helper", true, "internal", false},
+		{"run", true, "public", true},
+		{"local", false, "", false},
+		{"other", true, "", false},
+		{"Svc", true, "", false},
+		{"secret", false, "", false},
+		{"open", true, "", false},
+	}
+	for _, tt := range tests {
+		n := findNode(result.Nodes, tt.name)
+		if n == nil {
+			t.Errorf("expected node %q", tt.name)
+			continue
+		}
+		if n.Exported != tt.exported || n.ReleaseTag != tt.releaseTag || n.Deprecated != tt.deprecated {
+			t.Errorf("%s: got exported=%v releaseTag=%q deprecated=%v, want %v %q %v",
+				tt.name, n.Exported, n.ReleaseTag, n.Deprecated, tt.exported, tt.releaseTag, tt.deprecated)
+		}
+	}
+}
+
+func TestAnonymousDefaultExport(t *testing.T) {
+	tests := []struct {
+		path  string
+		src   string
+		qname string
+	}{
+		{"src/components/Button.tsx", "export default function() { return null }", "Button.default"},
+		{"src/components/Card/index.ts", "export default () => 1", "Card.default"},
+	}
+	for _, tt := range tests {
+		result, err := ParseFile(tt.path, []byte(tt.src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result.Nodes) != 1 {
+			t.Fatalf("%s: expected 1 node, got %d", tt.path, len(result.Nodes))
+		}
+		n := result.Nodes[0]
+		if n.QualifiedName != tt.qname || !n.DefaultExport || !n.Exported {
+			t.Errorf("%s: got qname=%q default=%v exported=%v, want %q true true",
+				tt.path, n.QualifiedName, n.DefaultExport, n.Exported, tt.qname)
+		}
+	}
+}
+
+func TestNamedDefaultExport(t *testing.T) {
+	src := []byte(`export default function Page() {}
+export function helper() {}
+
+const Widget = () => 1
+export default Widget`)
+	result, err := ParseFile("page.ts", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]bool{"Page": true, "helper": false, "Widget": true} {
+		n := findNode(result.Nodes, name)
+		if n == nil {
+			t.Errorf("expected node %q", name)
+			continue
+		}
+		if n.DefaultExport != want {
+			t.Errorf("%s: DefaultExport = %v, want %v", name, n.DefaultExport, want)
+		}
+	}
+}
+
+func TestDefaultImportEdge(t *testing.T) {
+	src := []byte(`import Button, { size } from './Button'
+import { other } from './other'`)
+	result, err := ParseFile("app.ts", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[string]string{}
+	for _, e := range result.Edges {
+		if e.Kind == "imports" {
+			got[e.Target] = e.DefaultImport
+		}
+	}
+	if got["./Button"] != "Button" {
+		t.Errorf("expected default import Button, got %q", got["./Button"])
+	}
+	if got["./other"] != "" {
+		t.Errorf("expected no default import for ./other, got %q", got["./other"])
+	}
+}
+
+func TestCommonJSRequire(t *testing.T) {
+	src := []byte(`const { readFile, join: pathJoin } = require('./fs-utils')
+const logger = require("./logger")
+require('./polyfill')
+
+function load(name) {
+  const mod = require('./plugins/' + name)
+  const cfg = require('./config')
+  return readFile(cfg.path)
+}`)
+	result, err := ParseFile("app.js", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	imports := map[string]EdgeInfo{}
+	for _, e := range result.Edges {
+		if e.Kind == "imports" {
+			imports[e.Target] = e
+		}
+	}
+	if len(imports) != 4 {
+		t.Fatalf("expected 4 require imports, got %d: %v", len(imports), imports)
+	}
+
+	fsUtils := imports["./fs-utils"]
+	if len(fsUtils.Symbols) != 2 || fsUtils.Symbols[0] != "readFile" || fsUtils.Symbols[1] != "join" {
+		t.Errorf("expected destructured symbols [readFile join], got %v", fsUtils.Symbols)
+	}
+	if fsUtils.DefaultImport != "" {
+		t.Errorf("destructured require should have no default import, got %q", fsUtils.DefaultImport)
+	}
+	if logger := imports["./logger"]; len(logger.Symbols) != 1 || logger.Symbols[0] != "logger" || logger.DefaultImport != "logger" {
+		t.Errorf("expected logger bound as default import, got %+v", logger)
+	}
+	if polyfill := imports["./polyfill"]; len(polyfill.Symbols) != 0 || polyfill.Line != 3 {
+		t.Errorf("expected bare side-effect require on line 3, got %+v", polyfill)
+	}
+	if cfg := imports["./config"]; cfg.DefaultImport != "cfg" || cfg.Line != 7 {
+		t.Errorf("expected nested require of ./config bound to cfg on line 7, got %+v", cfg)
+	}
+}
+
+func TestCommonJSExports(t *testing.T) {
+	src := []byte(`function main() {}
+function helper() {}
+function internal() {}
+class Store {}
+const fmt = () => {}
+
+module.exports = main
+module.exports.helper = helper
+exports.Store = Store
+module.exports = { fmt }`)
+	result, err := ParseFile("lib.js", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exported := map[string]bool{}
+	defaults := map[string]bool{}
+	for _, n := range result.Nodes {
+		exported[n.QualifiedName] = n.Exported
+		defaults[n.QualifiedName] = n.DefaultExport
+	}
+	for _, name := range []string{"main", "helper", "Store", "fmt"} {
+		if !exported[name] {
+			t.Errorf("expected %s to be exported", name)
+		}
+	}
+	if exported["internal"] {
+		t.Error("expected internal not to be exported")
+	}
+	if !defaults["main"] {
+		t.Error("expected module.exports = main to mark main as the default export")
+	}
+}
+
+func TestImportRequireAndExportAssignment(t *testing.T) {
+	src := []byte(`import Foo = require('./foo');
+import type Opts = require("./opts");
+import Alias = NS.Inner;
+
+class Client {
+  send(opts: Opts) { return Foo.run(opts); }
+}
+function helper() {}
+
+export = Client;`)
+	result, err := ParseFile("client.ts", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	foo := findEdge(result.Edges, "imports", "client.ts", "./foo")
+	if foo == nil || foo.DefaultImport != "Foo" || !slices.Equal(foo.Symbols, []string{"Foo"}) || foo.TypeOnly || foo.Line != 1 {
+		t.Errorf("expected a default import of Foo from ./foo, got %+v", foo)
+	}
+	opts := findEdge(result.Edges, "imports", "client.ts", "./opts")
+	if opts == nil || opts.DefaultImport != "Opts" || !opts.TypeOnly || !slices.Equal(opts.TypeOnlySymbols, []string{"Opts"}) {
+		t.Errorf("expected a type-only import of Opts from ./opts, got %+v", opts)
+	}
+	// An internal alias names a namespace member, not a module
+	if n := len(findEdges(result.Edges, "imports")); n != 2 {
+		t.Errorf("expected 2 imports edges, got %d", n)
+	}
+
+	client := findNode(result.Nodes, "Client")
+	if client == nil || !client.Exported || !client.DefaultExport {
+		t.Errorf("expected export = Client to mark Client as the default export, got %+v", client)
+	}
+	if send := findNode(result.Nodes, "send"); send == nil || !send.Exported {
+		t.Error("expected Client's members to be exported")
+	}
+	if h := findNode(result.Nodes, "helper"); h == nil || h.Exported {
+		t.Error("expected helper not to be exported")
+	}
+}
+
+func TestGeneratedFileTS(t *testing.T) {
+	generated := []byte(`/* eslint-disable */
+/**
+ * @generated by graphql-codegen
+ */
+export function useUserQuery() {}`)
+	r, err := ParseFile("graphql.ts", generated)
+	if err != nil || len(r.Nodes) == 0 {
+		t.Fatalf("parse: err=%v", err)
+	}
+	if !r.Nodes[0].Generated {
+		t.Error("expected node in @generated file to be marked generated")
+	}
+
+	handwritten := []byte(`export function build() {}
+// @generated markers below the first statement don't count
+export function other() {}`)
+	r, err = ParseFile("build.ts", handwritten)
+	if err != nil || len(r.Nodes) == 0 {
+		t.Fatalf("parse: err=%v", err)
+	}
+	for _, n := range r.Nodes {
+		if n.Generated {
+			t.Errorf("expected %s not to be marked generated", n.QualifiedName)
+		}
+	}
+}
+
+func TestTypeOnlyImports(t *testing.T) {
+	src := []byte(`import type { User, Role } from './types'
+import { type Config, load } from './config'
+import { type Id } from './ids'
+import { run } from './run'`)
+	result, err := ParseFile("app.ts", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edges := map[string]EdgeInfo{}
+	for _, e := range result.Edges {
+		if e.Kind == "imports" {
+			edges[e.Target] = e
+		}
+	}
+
+	if e := edges["./types"]; !e.TypeOnly || len(e.TypeOnlySymbols) != 2 {
+		t.Errorf("expected ./types to be type-only with 2 type symbols, got %+v", e)
+	}
+	if e := edges["./config"]; e.TypeOnly || len(e.TypeOnlySymbols) != 1 || e.TypeOnlySymbols[0] != "Config" {
+		t.Errorf("expected ./config to be a runtime import with type symbol Config, got %+v", e)
+	}
+	if e := edges["./ids"]; !e.TypeOnly {
+		t.Errorf("expected ./ids (only inline type specifiers) to be type-only, got %+v", e)
+	}
+	if e := edges["./run"]; e.TypeOnly || len(e.TypeOnlySymbols) != 0 {
+		t.Errorf("expected ./run to be a runtime import, got %+v", e)
+	}
+}
+
+func TestReExportEdges(t *testing.T) {
+	src := []byte(`export { Button, size as buttonSize } from './Button'
+export * from './hooks'
+export * as icons from './icons'
+export type { Theme } from './theme'
+export { local }
+const local = 1`)
+	result, err := ParseFile("index.ts", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edges := map[string]EdgeInfo{}
+	for _, e := range result.Edges {
+		if e.Kind == "re_exports" {
+			edges[e.Target] = e
+		}
+	}
+	if len(edges) != 4 {
+		t.Fatalf("expected 4 re_exports edges, got %d: %+v", len(edges), edges)
+	}
+
+	if got := edges["./Button"].Symbols; !slices.Equal(got, []string{"Button", "size as buttonSize"}) {
+		t.Errorf("expected ./Button symbols [Button, size as buttonSize], got %v", got)
+	}
+	if e := edges["./hooks"]; len(e.Symbols) != 0 || e.Line != 2 {
+		t.Errorf("expected a symbol-less star re-export on line 2, got %+v", e)
+	}
+	if got := edges["./icons"].Symbols; !slices.Equal(got, []string{"* as icons"}) {
+		t.Errorf("expected ./icons symbols [* as icons], got %v", got)
+	}
+	if e := edges["./theme"]; !e.TypeOnly || !slices.Equal(e.TypeOnlySymbols, []string{"Theme"}) {
+		t.Errorf("expected ./theme to be a type-only re-export, got %+v", e)
+	}
+	for _, e := range result.Edges {
+		if e.Kind == "imports" {
+			t.Errorf("re-exports must not also produce imports edges, got %+v", e)
+		}
+	}
+}
@@ -2,14 +2,19 @@ package indexer
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	openai "github.com/sashabaranov/go-openai"
 	"golang.org/x/sync/errgroup"
@@ -30,15 +35,26 @@ type IndexResult struct {
 	TotalEdges       int           `json:"totalEdges"`
 	TotalEmbedded    int           `json:"totalEmbedded"`
 	TotalDeleted     int           `json:"totalDeleted"`
+	TotalRenamed     int           `json:"totalRenamed"`
 	Duration         time.Duration `json:"duration"`
 	Errors           []string      `json:"errors,omitempty"`
+	// SyntaxErrors lists the files that parsed with syntax errors, with the
+	// lines affected. Their well-formed declarations were still indexed.
+	SyntaxErrors []string `json:"syntaxErrors,omitempty"`
+	// TouchedPackages are the workspace packages, by name and sorted, that
+	// hold a file this run added, modified, or deleted. A full index
+	// touches every package.
+	TouchedPackages []string `json:"touchedPackages,omitempty"`
+	// Cancelled is set when the run stopped early because its context was
+	// cancelled (see StatusStore.CancelJob).
+	Cancelled bool `json:"cancelled,omitempty"`
 }
 
 // IndexStatus tracks the progress of an ongoing or completed indexing job.
 type IndexStatus struct {
 	JobID     string       `json:"jobId"`
 	ProjectID string       `json:"projectId"`
-	Status    string       `json:"status"` // "running", "completed", "failed"
+	Status    string       `json:"status"` // "running", "completed", "failed", "cancelled"
 	Stage     string       `json:"stage"`
 	Progress  string       `json:"progress"`
 	Result    *IndexResult `json:"result,omitempty"`
@@ -47,15 +63,52 @@ type IndexStatus struct {
 	DoneAt    *time.Time   `json:"doneAt,omitempty"`
 }
 
-// StatusStore is a thread-safe in-memory store for indexing job status.
+// StatusStore is a thread-safe in-memory store for indexing job status and
+// the cancel functions of running jobs.
 type StatusStore struct {
-	mu   sync.RWMutex
-	jobs map[string]*IndexStatus
+	mu      sync.RWMutex
+	jobs    map[string]*IndexStatus
+	cancels map[string]context.CancelFunc
 }
 
 // NewStatusStore creates a new empty status store.
 func NewStatusStore() *StatusStore {
-	return &StatusStore{jobs: make(map[string]*IndexStatus)}
+	return &StatusStore{
+		jobs:    make(map[string]*IndexStatus),
+		cancels: make(map[string]context.CancelFunc),
+	}
+}
+
+// SetCancel registers the cancel function of a running job's context.
+func (s *StatusStore) SetCancel(jobID string, cancel context.CancelFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cancels[jobID] = cancel
+}
+
+// ClearCancel releases a finished job's cancel function.
+func (s *StatusStore) ClearCancel(jobID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cancel, ok := s.cancels[jobID]; ok {
+		cancel()
+		delete(s.cancels, jobID)
+	}
+}
+
+// CancelJob cancels a running job's context. The pipeline stops at the next
+// stage boundary and rolls back its open transaction. Returns false if the
+// job is unknown or already finished.
+func (s *StatusStore) CancelJob(jobID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cancel, ok := s.cancels[jobID]
+	if !ok {
+		return false
+	}
+	cancel()
+	delete(s.cancels, jobID)
+	return true
 }
 
 func (s *StatusStore) Set(jobID string, status *IndexStatus) {
@@ -88,6 +141,40 @@ func (s *StatusStore) GetByProject(projectID string) *IndexStatus {
 	return latest
 }
 
+// applyIndexConfig sets the package-level parser and indexer settings every
+// entry point (IndexProject, IndexFile, RebuildEdges) runs with.
+func applyIndexConfig(cfg *config.Config) {
+	parsers.SetSQLEnabled(cfg.ParseSQL)
+	parsers.SetProtoEnabled(cfg.ParseProto)
+	parsers.SetMaxNodeSourceBytes(cfg.MaxNodeSourceBytes)
+	parsers.SetMaxParseDuration(cfg.MaxParseDuration)
+	parsers.SetAssetExtensions(cfg.AssetExtensions)
+	SetFixtures(cfg.FixtureDirs, ParseFixtureMode(cfg.Fixtures))
+	SetSoftDelete(cfg.SoftDelete)
+	if err := parsers.SetRouteDetectors(cfg.RouteDetectors); err != nil {
+		slog.Warn("route detection", "error", err)
+	}
+}
+
+// selectResolved picks the edges of a resolve to store, as configured: the
+// resolved edges, plus the normalized-name guesses under
+// NORMALIZED_CALL_MATCHING, and the depends_on edges, traced through barrels
+// or limited to runtime imports when those options are on.
+func selectResolved(cfg *config.Config, r *ResolveResult) (resolved, dependsOn []ResolvedEdge) {
+	resolved = r.Resolved
+	if cfg.NormalizedCallMatching {
+		resolved = append(resolved, r.NormalizedCalls...)
+	}
+	dependsOn = r.DependsOn
+	if cfg.DependsOnThroughBarrels {
+		dependsOn = r.DependsOnThroughBarrels
+	}
+	if cfg.DependsOnExcludeTypeOnly {
+		dependsOn = RuntimeDependsOn(dependsOn)
+	}
+	return resolved, dependsOn
+}
+
 // activeJobs tracks which projects are currently being indexed to prevent concurrent runs.
 var activeJobs sync.Map
 
@@ -96,6 +183,7 @@ var activeJobs sync.Map
 func IndexProject(ctx context.Context, pool *pgxpool.Pool, cfg *config.Config, oaiClient *openai.Client, projectID string, status *IndexStatus, force bool) *IndexResult {
 	start := time.Now()
 	result := &IndexResult{}
+	applyIndexConfig(cfg)
 
 	updateStatus := func(stage, progress string) {
 		if status != nil {
@@ -137,6 +225,9 @@ func IndexProject(ctx context.Context, pool *pgxpool.Pool, cfg *config.Config, o
 
 	// 2. Process each source
 	for i, source := range sources {
+		if ctx.Err() != nil {
+			break
+		}
 		if !source.IsCode {
 			result.SourcesSkipped++
 			continue
@@ -145,6 +236,9 @@ func IndexProject(ctx context.Context, pool *pgxpool.Pool, cfg *config.Config, o
 		updateStatus("indexing", fmt.Sprintf("source %d/%d: %s", i+1, len(sources), source.Alias))
 
 		sourceResult, err := indexSource(ctx, pool, cfg, oaiClient, project.ID, &source, updateStatus, force)
+		if ctx.Err() != nil {
+			break
+		}
 		if err != nil {
 			result.Errors = append(result.Errors, fmt.Sprintf("source %s: %v", source.Alias, err))
 			continue
@@ -155,7 +249,17 @@ func IndexProject(ctx context.Context, pool *pgxpool.Pool, cfg *config.Config, o
 		result.TotalEdges += sourceResult.EdgesUpserted
 		result.TotalEmbedded += sourceResult.NodesEmbedded
 		result.TotalDeleted += sourceResult.NodesDeleted
+		result.TotalRenamed += sourceResult.NodesRenamed
+		for _, e := range sourceResult.SyntaxErrors {
+			result.SyntaxErrors = append(result.SyntaxErrors, fmt.Sprintf("source %s: %s", source.Alias, e))
+		}
+		for _, name := range sourceResult.TouchedPackages {
+			if !slices.Contains(result.TouchedPackages, name) {
+				result.TouchedPackages = append(result.TouchedPackages, name)
+			}
+		}
 	}
+	slices.Sort(result.TouchedPackages)
 
 	// Stage 4b: Cross-source import resolution
 	// Run when the project has multiple code sources — even if only one was processed
@@ -166,7 +270,7 @@ func IndexProject(ctx context.Context, pool *pgxpool.Pool, cfg *config.Config, o
 			codeSources++
 		}
 	}
-	if codeSources > 1 && result.SourcesProcessed > 0 {
+	if codeSources > 1 && result.SourcesProcessed > 0 && ctx.Err() == nil {
 		updateStatus("cross-resolving", "resolving cross-source imports")
 		crossResult, err := ResolveCrossSources(ctx, pool, projectID)
 		if err != nil {
@@ -180,7 +284,22 @@ func IndexProject(ctx context.Context, pool *pgxpool.Pool, cfg *config.Config, o
 		}
 	}
 
+	if cfg.SoftDelete && cfg.TombstoneRetentionDays > 0 && ctx.Err() == nil {
+		retention := time.Duration(cfg.TombstoneRetentionDays) * 24 * time.Hour
+		if purged, err := PurgeTombstones(ctx, pool, projectID, retention); err != nil {
+			slog.Warn("failed to purge tombstones", "project", projectID, "error", err)
+		} else if purged > 0 {
+			slog.Info("tombstones purged", "project", projectID, "nodes", purged)
+		}
+	}
+
 	result.Duration = time.Since(start)
+	if err := ctx.Err(); err != nil {
+		result.Cancelled = true
+		result.Errors = append(result.Errors, fmt.Sprintf("indexing cancelled: %v", err))
+		slog.Info("pipeline cancelled", "project", projectID, "sources", result.SourcesProcessed, "duration", result.Duration)
+		return result
+	}
 	slog.Info("pipeline complete",
 		"project", projectID,
 		"sources", result.SourcesProcessed,
@@ -194,10 +313,13 @@ func IndexProject(ctx context.Context, pool *pgxpool.Pool, cfg *config.Config, o
 
 // sourceResult holds the outcome of indexing a single source.
 type sourceResult struct {
-	NodesUpserted int
-	EdgesUpserted int
-	NodesEmbedded int
-	NodesDeleted  int
+	NodesUpserted   int
+	EdgesUpserted   int
+	NodesEmbedded   int
+	NodesDeleted    int
+	NodesRenamed    int
+	SyntaxErrors    []string
+	TouchedPackages []string
 }
 
 func indexSource(
@@ -212,9 +334,30 @@ func indexSource(
 ) (*sourceResult, error) {
 	result := &sourceResult{}
 
+	// Remote git sources are cloned/fetched into the cache; everything below runs against the local copy
+	updateStatus("preparing", fmt.Sprintf("preparing source %s", source.Alias))
+	sourcePath, err := PrepareSource(ctx, cfg, source)
+	if err != nil {
+		return nil, fmt.Errorf("preparing source: %w", err)
+	}
+
+	// With BRANCH_WORKSPACES the checked-out branch has its own workspace,
+	// and changes are detected against that branch's last indexed commit
+	branch := indexBranch(ctx, cfg, sourcePath)
+	workspaceID := BranchWorkspaceID(projectID, source.ID, branch)
+	lastCommit, lastAt := source.LastIndexedCommit, source.LastIndexedAt
+	if branch != "" {
+		if lastCommit, lastAt, err = loadWorkspaceIndexState(ctx, pool, workspaceID); err != nil {
+			return nil, err
+		}
+	}
+
 	// Stage 0: Change detection
 	updateStatus("changes", fmt.Sprintf("detecting changes for %s", source.Alias))
-	changeSet, err := DetectChanges(ctx, source.Path, source.LastIndexedCommit, source.LastIndexedAt, cfg.MaxAutoReindexFiles, force)
+	stageDone := timeStage("changes")
+	submodules := ParseSubmoduleMode(cfg.Submodules)
+	changeSet, err := DetectChanges(ctx, sourcePath, lastCommit, lastAt, cfg.MaxAutoReindexFiles, force, submodules)
+	stageDone()
 	if err != nil {
 		return nil, fmt.Errorf("change detection: %w", err)
 	}
@@ -229,22 +372,66 @@ func indexSource(
 	totalChanged := len(changeSet.AddedFiles) + len(changeSet.ModifiedFiles) + len(changeSet.DeletedFiles)
 	if !changeSet.IsFullIndex && totalChanged == 0 {
 		slog.Info("no changes detected, skipping", "source", source.Alias)
+		// HEAD may have moved without touching code — the stored graph is still accurate for it
+		recordCommitManifest(ctx, pool, cfg, source, workspaceID, changeSet)
+		if changeSet.CodeownersChanged {
+			refreshOwners(ctx, pool, source, workspaceID, sourcePath)
+		}
 		return result, nil
 	}
 
 	// Stage 1: Workspace detection
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	updateStatus("workspace", fmt.Sprintf("detecting workspace for %s", source.Alias))
-	wsInfo, err := detectors.DetectWorkspace(source.Path)
+	stageDone = timeStage("workspace")
+	wsInfo, cached, err := detectWorkspaceCached(workspaceID, sourcePath, changeSet)
+	stageDone()
 	if err != nil {
 		return nil, fmt.Errorf("workspace detection: %w", err)
 	}
+	if cached {
+		slog.Info("reusing cached workspace info", "source", source.Alias)
+	}
+	result.TouchedPackages = touchedPackages(changeSet, wsInfo)
+
+	// A package found under a new path with the same name was moved; its
+	// stored nodes are remapped in place once the new files are parsed
+	var packageRenames []PackageRename
+	if storedPaths, err := loadStoredPackagePaths(ctx, pool, workspaceID); err != nil {
+		slog.Warn("could not load stored packages, skipping package rename detection", "source", source.Alias, "error", err)
+	} else {
+		packageRenames = detectPackageRenames(storedPaths, wsInfo)
+	}
 
 	// Stage 2: File crawling
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	updateStatus("crawling", fmt.Sprintf("crawling files for %s", source.Alias))
-	crawlResult, err := CrawlDirectory(source.Path, source.IsCode)
+	stageDone = timeStage("crawling")
+	crawlResult, err := crawlSource(sourcePath, source.IsCode, submodules)
+	stageDone()
 	if err != nil {
 		return nil, fmt.Errorf("crawling: %w", err)
 	}
+	if subs := readSubmodulePaths(sourcePath); submodules == SubmodulesSkip && len(subs) > 0 {
+		slog.Info("skipping git submodules; add them as their own sources or set SUBMODULES=include",
+			"source", source.Alias, "submodules", subs)
+	}
+
+	// Files that imported or called into a deleted file are re-parsed so their
+	// now-dangling references get reclassified as unresolved
+	if !changeSet.IsFullIndex && len(changeSet.DeletedFiles) > 0 {
+		dependents, err := findDependentFiles(ctx, pool, workspaceID, changeSet.DeletedFiles)
+		if err != nil {
+			slog.Warn("could not find dependents of deleted files", "source", source.Alias, "error", err)
+		} else if len(dependents) > 0 {
+			slog.Info("re-resolving dependents of deleted files", "source", source.Alias, "count", len(dependents))
+			changeSet.DependentFiles = dependents
+		}
+	}
 
 	// Build the set of files to parse based on change set
 	filesToParse := buildFilesToParse(crawlResult, changeSet)
@@ -260,49 +447,130 @@ func indexSource(
 		"fullIndex", changeSet.IsFullIndex,
 	)
 
+	// Stages 3–6 for a huge full index go shard by shard, bounding memory
+	if changeSet.IsFullIndex && cfg.ShardFiles > 0 && len(filesToParse) > cfg.ShardFiles {
+		run := &shardRun{
+			projectID:      projectID,
+			source:         source,
+			sourcePath:     sourcePath,
+			branch:         branch,
+			workspaceID:    workspaceID,
+			ws:             wsInfo,
+			crawl:          crawlResult,
+			allRelPaths:    allRelPaths,
+			changeSet:      changeSet,
+			packageRenames: packageRenames,
+		}
+		if err := indexShards(ctx, pool, cfg, oaiClient, run, result, updateStatus); err != nil {
+			return nil, err
+		}
+		finishSource(ctx, pool, cfg, source, workspaceID, branch, sourcePath, changeSet, updateStatus)
+		return result, nil
+	}
+
 	// Stage 3: Parsing (parallel)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	updateStatus("parsing", fmt.Sprintf("parsing %d files for %s", len(filesToParse), source.Alias))
-	allNodes, allEdges, parseErrors := parseFiles(ctx, filesToParse, source.Path)
+	stageDone = timeStage("parsing")
+	allNodes, allEdges, parseErrors, syntaxErrors := parseFiles(ctx, filesToParse, sourcePath, newParseCache(cfg.ParseCacheDir))
+	allNodes, allEdges, filtered := applyNodeFilter(newNodeFilter(cfg), allNodes, allEdges)
+	stageDone()
+	if filtered > 0 {
+		slog.Info("nodes filtered", "count", filtered, "source", source.Alias)
+	}
 	if len(parseErrors) > 0 {
 		slog.Warn("parse errors", "count", len(parseErrors), "source", source.Alias)
 	}
+	if len(syntaxErrors) > 0 {
+		slog.Warn("syntax errors", "files", len(syntaxErrors), "source", source.Alias)
+		result.SyntaxErrors = formatSyntaxErrors(syntaxErrors)
+	}
 
 	// Stage 4: Import resolution
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	updateStatus("resolving", fmt.Sprintf("resolving imports for %s", source.Alias))
+	stageDone = timeStage("resolving")
 	resolveResult := ResolveImports(
 		allEdges,
 		wsInfo.AliasMap,
 		wsInfo.TSConfigPaths,
 		allNodes,
 		allRelPaths,
-		source.Path,
+		sourcePath,
+		WithPackages(wsInfo.Packages),
+		WithTSBaseURLs(wsInfo.TSBaseURLs),
+		WithPackageMarkerDirs(cfg.PackageMarkerDirs),
 	)
+	resolved, dependsOn := selectResolved(cfg, resolveResult)
+	stageDone()
+
+	// Go package nodes span files, so they're added once parsing is done.
+	// After resolution, so calls never resolve to a package by name.
+	pkgNodes, pkgEdges := goPackageNodes(crawlResult.Files, filesToParse, wsInfo, allEdges)
+	allNodes = append(allNodes, pkgNodes...)
+	allEdges = append(allEdges, pkgEdges...)
+
+	// Stage 5a: Rename/move detection. Nodes of moved packages are remapped in
+	// place first; then vanished nodes whose content reappears elsewhere
+	remapped, err := remapRenamedPackages(ctx, pool, workspaceID, packageRenames, allNodes, allEdges)
+	if err != nil {
+		slog.Warn("could not remap renamed packages", "source", source.Alias, "error", err)
+	}
+
+	var renames map[string]Rename
+	existing, err := loadExistingNodes(ctx, pool, workspaceID, touchedFiles(changeSet))
+	if err != nil {
+		slog.Warn("could not load existing nodes, skipping rename detection", "error", err)
+	} else {
+		renames = detectRenames(existing, allNodes, allEdges)
+	}
+	result.NodesRenamed = len(renames) + remapped
 
-	// Stage 5: Body hash comparison + embedding
+	// Stage 5b: Body hash comparison + embedding
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	updateStatus("embedding", fmt.Sprintf("embedding nodes for %s", source.Alias))
-	embeddings, embeddedCount, err := embedChangedNodes(ctx, pool, oaiClient, cfg, projectID, source.ID, allNodes, updateStatus)
+	stageDone = timeStage("embedding")
+	embeddings, signatureEmbeddings, embeddedCount, err := embedChangedNodes(ctx, pool, oaiClient, cfg, workspaceID, source.ID, allNodes, renames, updateStatus)
+	stageDone()
 	if err != nil {
 		return nil, fmt.Errorf("embedding: %w", err)
 	}
 	result.NodesEmbedded = embeddedCount
 
 	// Stage 6: Build graph (storage)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	updateStatus("storing", fmt.Sprintf("writing graph for %s", source.Alias))
 	buildInput := &BuildInput{
 		ProjectID:  projectID,
 		SourceID:   source.ID,
-		SourcePath: source.Path,
+		SourcePath: sourcePath,
+		Branch:     branch,
 		Workspace:  wsInfo,
 		Nodes:      allNodes,
 		Edges:      allEdges,
-		Resolved:   resolveResult.Resolved,
+		Resolved:   resolved,
 		Unresolved: resolveResult.Unresolved,
-		DependsOn:  resolveResult.DependsOn,
+		DependsOn:  dependsOn,
 		Embeddings: embeddings,
 		FilePaths:  allRelPaths,
+		Renames:    renames,
+
+		SignatureEmbeddings: signatureEmbeddings,
+
+		CommitTimes: changeSet.FileCommitTimes,
 	}
 
+	stageDone = timeStage("storing")
 	buildResult, err := BuildGraph(ctx, pool, buildInput)
+	stageDone()
 	if err != nil {
 		return nil, fmt.Errorf("building graph: %w", err)
 	}
@@ -311,13 +579,53 @@ func indexSource(
 	result.EdgesUpserted = buildResult.EdgesUpserted
 	result.NodesDeleted = buildResult.NodesDeleted
 
+	finishSource(ctx, pool, cfg, source, workspaceID, branch, sourcePath, changeSet, updateStatus)
+	return result, nil
+}
+
+// finishSource runs what follows storage: owners, the commit manifest, and
+// source metadata.
+func finishSource(ctx context.Context, pool *pgxpool.Pool, cfg *config.Config, source *projects.ProjectSource, workspaceID, branch, sourcePath string, changeSet *ChangeSet, updateStatus func(stage, progress string)) {
+	// Stage 6a: Annotate nodes with their CODEOWNERS owners
+	refreshOwners(ctx, pool, source, workspaceID, sourcePath)
+
+	// Stage 6b: Snapshot the graph for this commit so runs can be diffed later
+	recordCommitManifest(ctx, pool, cfg, source, workspaceID, changeSet)
+
 	// Update source metadata (last indexed commit/branch/time)
 	updateStatus("metadata", fmt.Sprintf("updating metadata for %s", source.Alias))
 	if err := updateSourceMetadata(ctx, pool, source.ID, changeSet); err != nil {
 		slog.Error("failed to update source metadata", "source", source.Alias, "error", err)
 	}
+	if branch != "" {
+		if err := updateWorkspaceIndexState(ctx, pool, workspaceID, changeSet); err != nil {
+			slog.Error("failed to update branch metadata", "source", source.Alias, "branch", branch, "error", err)
+		}
+		if err := dropPlainWorkspace(ctx, pool, source.ProjectID, source.ID); err != nil {
+			slog.Warn("failed to drop the source's branchless workspace", "source", source.Alias, "error", err)
+		}
+	}
+}
+
+// recordCommitManifest stores the graph manifest for the change set's commit,
+// keeping cfg.ManifestRetention manifests per source. Non-git sources have
+// no commit to key on and are skipped. Failures are logged, not fatal — the
+// index itself is already written.
+func recordCommitManifest(ctx context.Context, pool *pgxpool.Pool, cfg *config.Config, source *projects.ProjectSource, workspaceID string, cs *ChangeSet) {
+	if cs.CurrentCommit == "" {
+		return
+	}
+	if err := RecordManifest(ctx, pool, source.ID, workspaceID, cs.CurrentCommit, cfg.ManifestRetention); err != nil {
+		slog.Warn("failed to record index manifest", "source", source.Alias, "commit", cs.CurrentCommit, "error", err)
+	}
+}
 
-	return result, nil
+// refreshOwners applies the source's CODEOWNERS file to its nodes. Failures
+// are logged, not fatal — owners are annotations on an index already written.
+func refreshOwners(ctx context.Context, pool *pgxpool.Pool, source *projects.ProjectSource, workspaceID, sourcePath string) {
+	if err := applyCodeowners(ctx, pool, workspaceID, sourcePath); err != nil {
+		slog.Warn("failed to apply CODEOWNERS", "source", source.Alias, "error", err)
+	}
 }
 
 // buildFilesToParse determines which files need parsing based on the change set.
@@ -333,6 +641,9 @@ func buildFilesToParse(crawlResult *CrawlResult, changeSet *ChangeSet) []FileInf
 	for _, f := range changeSet.ModifiedFiles {
 		changedSet[f] = true
 	}
+	for _, f := range changeSet.DependentFiles {
+		changedSet[f] = true
+	}
 
 	var files []FileInfo
 	for _, f := range crawlResult.Files {
@@ -343,34 +654,96 @@ func buildFilesToParse(crawlResult *CrawlResult, changeSet *ChangeSet) []FileInf
 	return files
 }
 
+// findDependentFiles returns the files outside deleted that have an edge
+// (import, call, type use, ...) into a node defined in one of the deleted files.
+func findDependentFiles(ctx context.Context, pool *pgxpool.Pool, workspaceID string, deleted []string) ([]string, error) {
+	rows, err := pool.Query(ctx, `
+		SELECT DISTINCT s.file_path
+		FROM edges e
+		JOIN nodes t ON e.target_id = t.id
+		JOIN nodes s ON e.source_id = s.id
+		WHERE t.workspace_id = $1 AND t.file_path = ANY($2)
+		  AND s.workspace_id = $1 AND NOT (s.file_path = ANY($2))
+		  AND e.kind <> 'contains'
+		ORDER BY s.file_path`,
+		workspaceID, deleted,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("querying dependent files: %w", err)
+	}
+	defer rows.Close()
+
+	var files []string
+	for rows.Next() {
+		var f string
+		if err := rows.Scan(&f); err != nil {
+			return nil, fmt.Errorf("scanning dependent file: %w", err)
+		}
+		files = append(files, f)
+	}
+	return files, rows.Err()
+}
+
 // parseFiles parses files in parallel using an errgroup with a worker limit.
-func parseFiles(ctx context.Context, files []FileInfo, rootPath string) ([]parsers.NodeInfo, []parsers.EdgeInfo, []string) {
+// A non-nil cache serves unchanged files without re-parsing them. A file
+// whose parse runs past parsers.MaxParseDuration becomes a parse error
+// instead of holding up a worker.
+func parseFiles(ctx context.Context, files []FileInfo, rootPath string, cache *parseCache) ([]parsers.NodeInfo, []parsers.EdgeInfo, []string, map[string][]parsers.LineRange) {
 	type parseOutput struct {
-		nodes  []parsers.NodeInfo
-		edges  []parsers.EdgeInfo
-		relErr string
+		nodes        []parsers.NodeInfo
+		edges        []parsers.EdgeInfo
+		syntaxErrors []parsers.LineRange
+		relErr       string
 	}
 
 	results := make([]parseOutput, len(files))
+	var cacheHits atomic.Int64
 	g, ctx := errgroup.WithContext(ctx)
 	g.SetLimit(parseWorkers)
 
 	for i, f := range files {
 		i, f := i, f
 		g.Go(func() error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
 			source, err := os.ReadFile(f.AbsPath)
 			if err != nil {
 				results[i] = parseOutput{relErr: fmt.Sprintf("%s: %v", f.RelPath, err)}
 				return nil
 			}
 
-			pr, err := parsers.ParseFile(f.AbsPath, source)
-			if err != nil {
-				results[i] = parseOutput{relErr: fmt.Sprintf("%s: %v", f.RelPath, err)}
-				return nil
+			var cacheKey string
+			var pr *parsers.ParseResult
+			if cache != nil {
+				cacheKey = cache.key(f.AbsPath, source)
+				if cached, ok := cache.get(cacheKey); ok {
+					pr = cached
+					cacheHits.Add(1)
+				}
+			}
+			if pr == nil {
+				pr, err = parsers.ParseFileCtx(ctx, f.AbsPath, source)
+				if err != nil {
+					results[i] = parseOutput{relErr: fmt.Sprintf("%s: %v", f.RelPath, err)}
+					return nil
+				}
+				if cache != nil {
+					cache.put(cacheKey, pr)
+				}
 			}
 
-			// Rewrite absolute paths in edges to relative
+			// Rewrite absolute paths in node placements and edges to relative
+			nodes := make([]parsers.NodeInfo, len(pr.Nodes))
+			copy(nodes, pr.Nodes)
+			for j := range nodes {
+				nodes[j].Fixture = f.Fixture
+				if strings.HasPrefix(nodes[j].FilePath, "/") {
+					if rel, relErr := filepath.Rel(rootPath, nodes[j].FilePath); relErr == nil {
+						nodes[j].FilePath = rel
+					}
+				}
+			}
 			edges := make([]parsers.EdgeInfo, len(pr.Edges))
 			copy(edges, pr.Edges)
 			for j := range edges {
@@ -383,7 +756,7 @@ func parseFiles(ctx context.Context, files []FileInfo, rootPath string) ([]parse
 				}
 			}
 
-			results[i] = parseOutput{nodes: pr.Nodes, edges: edges}
+			results[i] = parseOutput{nodes: nodes, edges: edges, syntaxErrors: pr.SyntaxErrors}
 			return nil
 		})
 	}
@@ -393,89 +766,238 @@ func parseFiles(ctx context.Context, files []FileInfo, rootPath string) ([]parse
 	var allNodes []parsers.NodeInfo
 	var allEdges []parsers.EdgeInfo
 	var parseErrors []string
+	syntaxErrors := make(map[string][]parsers.LineRange)
 
-	for _, r := range results {
+	for i, r := range results {
 		if r.relErr != "" {
 			parseErrors = append(parseErrors, r.relErr)
 			continue
 		}
 		allNodes = append(allNodes, r.nodes...)
 		allEdges = append(allEdges, r.edges...)
+		if len(r.syntaxErrors) > 0 {
+			syntaxErrors[files[i].RelPath] = r.syntaxErrors
+		}
 	}
 
-	return allNodes, allEdges, parseErrors
+	if cache != nil {
+		slog.Info("parse cache", "hits", cacheHits.Load(), "files", len(files))
+	}
+
+	metrics().FilesParsed(len(files) - len(parseErrors))
+	metrics().ParseErrors(len(parseErrors))
+
+	return allNodes, allEdges, parseErrors, syntaxErrors
+}
+
+// formatSyntaxErrors renders parseFiles' syntax errors as sorted
+// "path: lines 5-11, 14" entries.
+func formatSyntaxErrors(syntaxErrors map[string][]parsers.LineRange) []string {
+	entries := make([]string, 0, len(syntaxErrors))
+	for _, relPath := range slices.Sorted(maps.Keys(syntaxErrors)) {
+		lines := make([]string, len(syntaxErrors[relPath]))
+		for i, r := range syntaxErrors[relPath] {
+			lines[i] = r.String()
+		}
+		entries = append(entries, fmt.Sprintf("%s: lines %s", relPath, strings.Join(lines, ", ")))
+	}
+	return entries
+}
+
+// touchedPackages returns the sorted names of the packages in ws holding an
+// added, modified, or deleted file of cs: the deepest package containing the
+// file, or the root package when none does. Dependent files are re-parsed
+// but unchanged, so they don't count.
+func touchedPackages(cs *ChangeSet, ws *detectors.WorkspaceInfo) []string {
+	touched := make(map[string]bool)
+	if cs.IsFullIndex {
+		for _, pkg := range ws.Packages {
+			touched[pkg.Name] = true
+		}
+		return slices.Sorted(maps.Keys(touched))
+	}
+
+	var resolveCfg resolveConfig
+	WithPackages(ws.Packages)(&resolveCfg)
+	grouping := resolveCfg.packages
+	names := make(map[string]string, len(ws.Packages))
+	root := ""
+	for _, pkg := range ws.Packages {
+		p := filepath.ToSlash(filepath.Clean(pkg.Path))
+		if p == "." || p == "" {
+			root = pkg.Name
+			continue
+		}
+		names[p] = pkg.Name
+	}
+
+	for _, files := range [][]string{cs.AddedFiles, cs.ModifiedFiles, cs.DeletedFiles} {
+		for _, f := range files {
+			if name, ok := names[grouping.packageFor(f)]; ok {
+				touched[name] = true
+			} else if root != "" {
+				touched[root] = true
+			}
+		}
+	}
+	return slices.Sorted(maps.Keys(touched))
 }
 
 // embedChangedNodes compares body hashes against existing DB data and only
-// embeds nodes whose content has changed.
+// embeds nodes whose content has changed. With cfg.EmbedSignatures it does
+// the same for the signature-only variant, whose stored vectors are reused
+// on the same body hash; embedded counts full-variant nodes only.
 func embedChangedNodes(
 	ctx context.Context,
 	pool *pgxpool.Pool,
 	oaiClient *openai.Client,
 	cfg *config.Config,
-	projectID, sourceID string,
+	workspaceID, sourceID string,
 	allNodes []parsers.NodeInfo,
+	renames map[string]Rename,
 	updateStatus func(stage, progress string),
-) (map[string][]float32, int, error) {
-	embeddings := make(map[string][]float32)
-
+) (embeddings, signatures map[string][]float32, embedded int, err error) {
 	if oaiClient == nil {
 		slog.Warn("no OpenAI client configured, skipping embeddings")
-		return embeddings, 0, nil
+		return make(map[string][]float32), nil, 0, nil
 	}
 
 	// Load existing body hashes from DB
-	workspaceID := makeWorkspaceID(projectID, sourceID)
 	existingHashes, err := loadExistingHashes(ctx, pool, workspaceID)
 	if err != nil {
 		slog.Warn("could not load existing hashes, will embed all nodes", "error", err)
 		existingHashes = make(map[string]string)
 	}
 
-	// Also load existing embeddings so we can reuse them for unchanged nodes
-	existingEmbeddings, err := loadExistingEmbeddings(ctx, pool, workspaceID)
+	// Boilerplate is checked first so turning the filter on also drops
+	// previously stored vectors.
+	filter := newBoilerplateFilter(cfg)
+	skipped := make(map[string]int)
+	var candidates []parsers.NodeInfo
+	for _, node := range allNodes {
+		// Assets are imported data and fixtures stand-ins, not code anyone
+		// searches for
+		if node.Kind == "asset" || node.Fixture {
+			continue
+		}
+		if skip, reason := filter.skip(node); skip {
+			skipped[reason]++
+			continue
+		}
+		candidates = append(candidates, node)
+	}
+	if len(skipped) > 0 {
+		slog.Info("skipped boilerplate nodes from embedding", "source", sourceID,
+			"generated", skipped["generated"], "trivialName", skipped["trivial_name"], "smallBody", skipped["small_body"])
+	}
+
+	embed := func(v embeddingVariant) (map[string][]float32, int, error) {
+		// Load existing embeddings so we can reuse them for unchanged nodes
+		existing, err := loadExistingEmbeddings(ctx, pool, workspaceID, v.column)
+		if err != nil {
+			slog.Warn("could not load existing embeddings", "variant", v.name, "error", err)
+			existing = make(map[string][]float32)
+		}
+		return embedVariant(ctx, oaiClient, cfg, sourceID, v, candidates, existingHashes, existing, renames, updateStatus)
+	}
+
+	embeddings, embedded, err = embed(fullEmbedding)
 	if err != nil {
-		slog.Warn("could not load existing embeddings", "error", err)
-		existingEmbeddings = make(map[string][]float32)
+		return nil, nil, 0, err
+	}
+	if cfg.EmbedSignatures {
+		if signatures, _, err = embed(signatureEmbedding); err != nil {
+			return nil, nil, 0, err
+		}
 	}
+	return embeddings, signatures, embedded, nil
+}
+
+// embeddingVariant is one of the vectors stored per node: the full
+// signature-docstring-body embedding, or the signature-only one.
+type embeddingVariant struct {
+	name   string // for logs and status
+	column string // nodes column holding it
+	input  func(parsers.NodeInfo) (*ChunkResult, error)
+}
+
+var (
+	fullEmbedding = embeddingVariant{
+		name:   "full",
+		column: "embedding",
+		input: func(n parsers.NodeInfo) (*ChunkResult, error) {
+			return PrepareEmbeddingInput(n.Signature, n.Docstring, n.SourceCode)
+		},
+	}
+	signatureEmbedding = embeddingVariant{
+		name:   "signature",
+		column: "embedding_sig",
+		input: func(n parsers.NodeInfo) (*ChunkResult, error) {
+			return PrepareEmbeddingInput(signatureEmbeddingText(n.QualifiedName, n.Signature), "", "")
+		},
+	}
+)
+
+// embedVariant embeds the nodes whose body hash changed since their stored
+// vector of this variant was computed, and reuses the rest.
+func embedVariant(
+	ctx context.Context,
+	oaiClient *openai.Client,
+	cfg *config.Config,
+	sourceID string,
+	v embeddingVariant,
+	nodes []parsers.NodeInfo,
+	existingHashes map[string]string,
+	existingEmbeddings map[string][]float32,
+	renames map[string]Rename,
+	updateStatus func(stage, progress string),
+) (map[string][]float32, int, error) {
+	embeddings := make(map[string][]float32)
 
 	// Determine which nodes need new embeddings
 	var toEmbed []parsers.NodeInfo
-	for _, node := range allNodes {
+	for _, node := range nodes {
 		oldHash, exists := existingHashes[node.QualifiedName]
 		if exists && oldHash == node.BodyHash && len(existingEmbeddings[node.QualifiedName]) > 0 {
 			// Unchanged — reuse existing embedding
 			embeddings[node.QualifiedName] = existingEmbeddings[node.QualifiedName]
 			continue
 		}
+		if r, ok := renames[node.QualifiedName]; ok && len(existingEmbeddings[r.OldQualifiedName]) > 0 {
+			// Moved or renamed without a content change — carry the embedding over
+			embeddings[node.QualifiedName] = existingEmbeddings[r.OldQualifiedName]
+			continue
+		}
 		toEmbed = append(toEmbed, node)
 	}
 
 	if len(toEmbed) == 0 {
-		slog.Info("all nodes unchanged, skipping embedding", "source", sourceID)
+		slog.Info("all nodes unchanged, skipping embedding", "source", sourceID, "variant", v.name)
 		return embeddings, 0, nil
 	}
 
-	slog.Info("embedding changed nodes", "changed", len(toEmbed), "total", len(allNodes), "reused", len(allNodes)-len(toEmbed))
+	slog.Info("embedding changed nodes", "variant", v.name, "changed", len(toEmbed), "total", len(nodes), "reused", len(nodes)-len(toEmbed))
 
 	// Prepare embedding inputs
 	texts := make([]string, len(toEmbed))
+	tokens := 0
 	for i, node := range toEmbed {
-		chunk, err := PrepareEmbeddingInput(node.Signature, node.Docstring, node.SourceCode)
+		chunk, err := v.input(node)
 		if err != nil {
 			slog.Warn("failed to prepare embedding input", "node", node.QualifiedName, "error", err)
 			texts[i] = node.QualifiedName
 			continue
 		}
 		texts[i] = chunk.Text
+		tokens += chunk.TokenCount
 	}
 
 	// Batch embed
 	vectors, err := EmbedBatched(ctx, oaiClient, texts, cfg.MaxEmbeddingBatch, func(pct int) {
-		updateStatus("embedding", fmt.Sprintf("embedding %d nodes — %d%%", len(toEmbed), pct))
+		updateStatus("embedding", fmt.Sprintf("embedding %d nodes (%s) — %d%%", len(toEmbed), v.name, pct))
 	})
 	if err != nil {
-		return nil, 0, fmt.Errorf("batch embedding: %w", err)
+		return nil, 0, fmt.Errorf("batch embedding %s: %w", v.name, err)
 	}
 
 	for i, node := range toEmbed {
@@ -484,6 +1006,11 @@ func embedChangedNodes(
 		}
 	}
 
+	if v.name == fullEmbedding.name {
+		metrics().NodesEmbedded(len(toEmbed))
+	}
+	metrics().TokensEmbedded(tokens)
+
 	return embeddings, len(toEmbed), nil
 }
 
@@ -509,10 +1036,11 @@ func loadExistingHashes(ctx context.Context, pool *pgxpool.Pool, workspaceID str
 	return hashes, nil
 }
 
-// loadExistingEmbeddings returns a map of qualifiedName -> embedding vector for reuse.
-func loadExistingEmbeddings(ctx context.Context, pool *pgxpool.Pool, workspaceID string) (map[string][]float32, error) {
+// loadExistingEmbeddings returns a map of qualifiedName -> vector from one
+// embedding column, for reuse.
+func loadExistingEmbeddings(ctx context.Context, pool *pgxpool.Pool, workspaceID, column string) (map[string][]float32, error) {
 	rows, err := pool.Query(ctx,
-		`SELECT qualified_name, embedding FROM nodes WHERE workspace_id = $1 AND embedding IS NOT NULL`,
+		fmt.Sprintf(`SELECT qualified_name, %s FROM nodes WHERE workspace_id = $1 AND %s IS NOT NULL`, column, column),
 		workspaceID,
 	)
 	if err != nil {
@@ -554,3 +1082,75 @@ func updateSourceMetadata(ctx context.Context, pool *pgxpool.Pool, sourceID stri
 	}
 	return nil
 }
+
+// indexBranch is the branch a source is indexed under: with
+// cfg.BranchWorkspaces its checked-out git branch, otherwise none. Detached
+// HEADs and non-git sources have no branch and use the source's workspace.
+func indexBranch(ctx context.Context, cfg *config.Config, sourcePath string) string {
+	if !cfg.BranchWorkspaces || !isGitRepo(ctx, sourcePath) {
+		return ""
+	}
+	return gitCurrentBranch(ctx, sourcePath)
+}
+
+// loadWorkspaceIndexState returns the commit and time a branch workspace was
+// last indexed at, both nil before its first index.
+func loadWorkspaceIndexState(ctx context.Context, pool *pgxpool.Pool, workspaceID string) (commit *string, at *time.Time, err error) {
+	err = pool.QueryRow(ctx,
+		`SELECT last_indexed_commit, last_indexed_at FROM workspaces WHERE id = $1`, workspaceID,
+	).Scan(&commit, &at)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("loading branch workspace state: %w", err)
+	}
+	return commit, at, nil
+}
+
+// dropPlainWorkspace deletes the branchless workspace a source was indexed
+// into before BRANCH_WORKSPACES was turned on, once one of its branches has
+// a workspace of its own. Left in place, its stale copy of the graph would
+// sit next to the branch workspaces in every query. Its nodes, edges, and
+// packages go with it, including other sources' cross-source edges into
+// it, which come back when those sources are next indexed in full.
+func dropPlainWorkspace(ctx context.Context, pool *pgxpool.Pool, projectID, sourceID string) error {
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `DELETE FROM workspaces WHERE id = $1 AND branch IS NULL`, WorkspaceID(projectID, sourceID))
+	if err != nil {
+		return fmt.Errorf("deleting branchless workspace: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return nil
+	}
+	if err := bumpGraphVersion(ctx, tx, projectID); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("committing transaction: %w", err)
+	}
+	slog.Info("dropped branchless workspace", "source", sourceID)
+	return nil
+}
+
+// updateWorkspaceIndexState writes the indexed commit/time back to a branch
+// workspace, the per-branch counterpart of updateSourceMetadata.
+func updateWorkspaceIndexState(ctx context.Context, pool *pgxpool.Pool, workspaceID string, cs *ChangeSet) error {
+	var commit *string
+	if cs.CurrentCommit != "" {
+		commit = &cs.CurrentCommit
+	}
+	_, err := pool.Exec(ctx,
+		`UPDATE workspaces SET last_indexed_commit = $1, last_indexed_at = $2 WHERE id = $3`,
+		commit, time.Now(), workspaceID,
+	)
+	if err != nil {
+		return fmt.Errorf("updating workspace metadata: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package indexer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/maximilianfalco/mycelium/internal/indexer/detectors"
+	"github.com/maximilianfalco/mycelium/internal/indexer/parsers"
+)
+
+func TestStatusStore_CancelJob(t *testing.T) {
+	store := NewStatusStore()
+	ctx, cancel := context.WithCancel(context.Background())
+	store.Set("job-1", &IndexStatus{JobID: "job-1", Status: "running"})
+	store.SetCancel("job-1", cancel)
+
+	if !store.CancelJob("job-1") {
+		t.Fatal("expected CancelJob to succeed for a running job")
+	}
+	if ctx.Err() == nil {
+		t.Error("expected job context to be cancelled")
+	}
+	if store.CancelJob("job-1") {
+		t.Error("expected second CancelJob to report not running")
+	}
+	if store.CancelJob("unknown") {
+		t.Error("expected CancelJob on unknown job to fail")
+	}
+}
+
+func TestStatusStore_ClearCancel(t *testing.T) {
+	store := NewStatusStore()
+	ctx, cancel := context.WithCancel(context.Background())
+	store.SetCancel("job-1", cancel)
+
+	store.ClearCancel("job-1")
+	if ctx.Err() == nil {
+		t.Error("expected ClearCancel to release the job context")
+	}
+	if store.CancelJob("job-1") {
+		t.Error("expected finished job to no longer be cancellable")
+	}
+}
+
+func TestParseFiles_StopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	files := []FileInfo{{AbsPath: "/nonexistent/a.ts", RelPath: "a.ts"}}
+	nodes, _, errs, _ := parseFiles(ctx, files, "/nonexistent", nil)
+	if len(nodes) != 0 || len(errs) != 0 {
+		t.Errorf("expected cancelled parse to skip work, got %d nodes, %d errors", len(nodes), len(errs))
+	}
+}
+
+func TestParseFiles_SyntaxErrors(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "ok.ts"), []byte("export function ok() {}\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "broken.ts"), []byte("export function a() {}\n\nfunction broken( {\n  foo(\n}\n\nexport function c() {}\n"), 0o644)
+	files := []FileInfo{
+		{AbsPath: filepath.Join(dir, "ok.ts"), RelPath: "ok.ts"},
+		{AbsPath: filepath.Join(dir, "broken.ts"), RelPath: "broken.ts"},
+	}
+
+	nodes, _, errs, syntaxErrors := parseFiles(context.Background(), files, dir, nil)
+	if len(errs) != 0 {
+		t.Fatalf("expected syntax errors not to fail the parse, got %v", errs)
+	}
+	var names []string
+	for _, n := range nodes {
+		names = append(names, n.Name)
+	}
+	slices.Sort(names)
+	if !slices.Equal(names, []string{"a", "c", "ok"}) {
+		t.Errorf("expected the well-formed declarations around the error, got %v", names)
+	}
+	if got := formatSyntaxErrors(syntaxErrors); !slices.Equal(got, []string{"broken.ts: lines 3-7"}) {
+		t.Errorf("unexpected syntax errors %v", got)
+	}
+}
+
+func TestParseFiles_AssetImports(t *testing.T) {
+	parsers.SetAssetExtensions([]string{".json"})
+	defer parsers.SetAssetExtensions(nil)
+
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "src"), 0o755)
+	os.WriteFile(filepath.Join(dir, "src", "app.ts"), []byte("import config from './config.json';\nexport function start() { return config; }\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "src", "config.json"), []byte("{\"port\": 8080}\n"), 0o644)
+	files := []FileInfo{
+		{AbsPath: filepath.Join(dir, "src", "app.ts"), RelPath: "src/app.ts"},
+		{AbsPath: filepath.Join(dir, "src", "config.json"), RelPath: "src/config.json"},
+	}
+
+	nodes, edges, errs, _ := parseFiles(context.Background(), files, dir, nil)
+	if len(errs) != 0 {
+		t.Fatalf("unexpected parse errors %v", errs)
+	}
+	var asset *parsers.NodeInfo
+	for i := range nodes {
+		if nodes[i].Kind == "asset" {
+			asset = &nodes[i]
+		}
+	}
+	if asset == nil || asset.FilePath != "src/config.json" {
+		t.Fatalf("expected an asset node placed at src/config.json, got %+v", asset)
+	}
+
+	result := ResolveImports(edges, nil, nil, nodes, []string{"src/app.ts", "src/config.json"}, dir)
+	var resolved bool
+	for _, e := range result.Resolved {
+		if e.Kind == "imports" && e.Source == "src/app.ts" && e.ResolvedPath == "src/config.json" {
+			resolved = true
+		}
+	}
+	if !resolved || len(result.Unresolved) != 0 {
+		t.Errorf("expected the JSON import to resolve, got resolved=%+v unresolved=%+v", result.Resolved, result.Unresolved)
+	}
+}
+
+func TestBuildFilesToParse_IncludesDependents(t *testing.T) {
+	crawl := &CrawlResult{Files: []FileInfo{
+		{RelPath: "src/a.ts"},
+		{RelPath: "src/b.ts"},
+		{RelPath: "src/c.ts"},
+	}}
+	cs := &ChangeSet{
+		ModifiedFiles:  []string{"src/a.ts"},
+		DeletedFiles:   []string{"src/gone.ts"},
+		DependentFiles: []string{"src/c.ts"},
+	}
+
+	files := buildFilesToParse(crawl, cs)
+	got := map[string]bool{}
+	for _, f := range files {
+		got[f.RelPath] = true
+	}
+	if len(files) != 2 || !got["src/a.ts"] || !got["src/c.ts"] {
+		t.Errorf("expected src/a.ts and src/c.ts, got %v", got)
+	}
+}
+
+func TestTouchedPackages(t *testing.T) {
+	ws := &detectors.WorkspaceInfo{Packages: []detectors.PackageInfo{
+		{Name: "monorepo", Path: "."},
+		{Name: "@acme/auth", Path: "packages/auth"},
+		{Name: "@acme/auth-oauth", Path: "./packages/auth/oauth"},
+		{Name: "@acme/web", Path: "apps/web"},
+	}}
+	cs := &ChangeSet{
+		AddedFiles:    []string{"packages/auth/oauth/google.ts"},
+		ModifiedFiles: []string{"packages/auth/src/index.ts", "scripts/release.ts"},
+		DeletedFiles:  []string{"packages/auth/src/old.ts"},
+		// Re-parsed, not changed
+		DependentFiles: []string{"apps/web/src/login.tsx"},
+	}
+	got := touchedPackages(cs, ws)
+	if want := []string{"@acme/auth", "@acme/auth-oauth", "monorepo"}; !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	// Without a root package, files outside every package touch nothing
+	ws.Packages = ws.Packages[1:]
+	cs = &ChangeSet{ModifiedFiles: []string{"scripts/release.ts"}}
+	if got := touchedPackages(cs, ws); len(got) != 0 {
+		t.Errorf("expected no touched packages, got %v", got)
+	}
+
+	cs = &ChangeSet{IsFullIndex: true}
+	if got := touchedPackages(cs, ws); len(got) != 3 {
+		t.Errorf("expected a full index to touch every package, got %v", got)
+	}
+}
@@ -0,0 +1,69 @@
+package indexer
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// tokenBucket is a rate limiter allowing perMinute units per minute, with
+// up to a minute's worth in a burst. Callers reserve before they wait, so
+// concurrent callers queue in arrival order instead of racing for refills.
+// A nil bucket is unlimited.
+type tokenBucket struct {
+	mu       sync.Mutex
+	rate     float64 // units per second
+	capacity float64
+	tokens   float64
+	last     time.Time
+}
+
+// newTokenBucket returns a full bucket, or nil (unlimited) when perMinute
+// is not positive.
+func newTokenBucket(perMinute int) *tokenBucket {
+	if perMinute <= 0 {
+		return nil
+	}
+	return &tokenBucket{
+		rate:     float64(perMinute) / 60,
+		capacity: float64(perMinute),
+		tokens:   float64(perMinute),
+		last:     time.Now(),
+	}
+}
+
+// reserve takes n units and returns how long the caller must wait before
+// using them. A request larger than the bucket is capped at its capacity,
+// so it goes through once the bucket has refilled instead of never.
+func (b *tokenBucket) reserve(n int) time.Duration {
+	if b == nil {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.tokens = min(b.capacity, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	b.tokens -= min(float64(n), b.capacity)
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// wait blocks until n units are available or ctx is done.
+func (b *tokenBucket) wait(ctx context.Context, n int) error {
+	delay := b.reserve(n)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
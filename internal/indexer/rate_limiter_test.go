@@ -0,0 +1,54 @@
+package indexer
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket_Unlimited(t *testing.T) {
+	var b *tokenBucket
+	if b = newTokenBucket(0); b != nil {
+		t.Fatal("expected no bucket for a zero limit")
+	}
+	if d := b.reserve(1_000_000); d != 0 {
+		t.Errorf("expected a nil bucket never to wait, got %v", d)
+	}
+}
+
+func TestTokenBucket_Reserve(t *testing.T) {
+	b := newTokenBucket(600) // 10 per second
+
+	// A full minute's burst goes through at once
+	if d := b.reserve(600); d != 0 {
+		t.Fatalf("expected the first burst to pass, got %v", d)
+	}
+	// The next 5 units take half a second to refill
+	if d := b.reserve(5); d < 400*time.Millisecond || d > 500*time.Millisecond {
+		t.Errorf("expected ~500ms wait, got %v", d)
+	}
+	// Waiters queue behind earlier reservations
+	if d := b.reserve(5); d < 900*time.Millisecond || d > time.Second {
+		t.Errorf("expected ~1s wait behind the previous reservation, got %v", d)
+	}
+}
+
+func TestTokenBucket_OversizedRequest(t *testing.T) {
+	b := newTokenBucket(60)
+	if d := b.reserve(1000); d != 0 {
+		t.Errorf("expected an oversized request to pass on a full bucket, got %v", d)
+	}
+	if d := b.reserve(1000); d < 59*time.Second || d > time.Minute {
+		t.Errorf("expected an oversized request to wait for a full refill, got %v", d)
+	}
+}
+
+func TestTokenBucket_WaitCanceled(t *testing.T) {
+	b := newTokenBucket(1)
+	b.reserve(1)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := b.wait(ctx, 1); err == nil {
+		t.Error("expected wait to return the context error")
+	}
+}
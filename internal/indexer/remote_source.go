@@ -0,0 +1,141 @@
+package indexer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/maximilianfalco/mycelium/internal/config"
+	"github.com/maximilianfalco/mycelium/internal/projects"
+)
+
+// PrepareSource returns the local directory the pipeline should index for a source.
+// Local sources are returned as-is. Remote git sources are cloned into the managed
+// cache on first use and fetched + hard-reset to the remote tip on subsequent runs,
+// so DetectChanges can diff the clone's commits like any other git repo.
+func PrepareSource(ctx context.Context, cfg *config.Config, source *projects.ProjectSource) (string, error) {
+	if !projects.IsRemotePath(source.Path) {
+		return source.Path, nil
+	}
+
+	cacheDir := cfg.SourceCacheDir
+	if cacheDir == "" {
+		return "", fmt.Errorf("remote source %s requires SOURCE_CACHE_DIR", source.Alias)
+	}
+	clonePath := remoteCachePath(cacheDir, source.Path)
+
+	if isGitRepo(ctx, clonePath) {
+		if err := fetchRemote(ctx, cfg, clonePath); err != nil {
+			return "", fmt.Errorf("fetching %s: %w", source.Path, err)
+		}
+		return clonePath, nil
+	}
+
+	// Leftover non-git directory (e.g. an interrupted clone) — start fresh
+	if err := os.RemoveAll(clonePath); err != nil {
+		return "", fmt.Errorf("clearing cache dir: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(clonePath), 0o755); err != nil {
+		return "", fmt.Errorf("creating cache dir: %w", err)
+	}
+	if err := cloneRemote(ctx, cfg, source.Path, clonePath); err != nil {
+		_ = os.RemoveAll(clonePath)
+		return "", fmt.Errorf("cloning %s: %w", source.Path, err)
+	}
+	return clonePath, nil
+}
+
+// remoteCachePath maps a git URL to a stable directory under cacheDir.
+// The readable prefix is the repo name; the hash suffix disambiguates forks.
+func remoteCachePath(cacheDir, url string) string {
+	sum := sha256.Sum256([]byte(url))
+	name := strings.TrimSuffix(strings.TrimRight(url, "/"), ".git")
+	if i := strings.LastIndexAny(name, "/:"); i >= 0 {
+		name = name[i+1:]
+	}
+	if name == "" {
+		name = "repo"
+	}
+	return filepath.Join(cacheDir, name+"-"+hex.EncodeToString(sum[:])[:12])
+}
+
+func cloneRemote(ctx context.Context, cfg *config.Config, url, dest string) error {
+	args := []string{"clone", "--single-branch"}
+	if cfg.GitCloneDepth > 0 {
+		args = append(args, "--depth", strconv.Itoa(cfg.GitCloneDepth))
+	}
+	args = append(args, url, dest)
+
+	slog.Info("cloning remote source", "url", url, "dest", dest, "depth", cfg.GitCloneDepth)
+	return runGit(ctx, cfg, url, "", args...)
+}
+
+// fetchRemote brings an existing clone up to date with its tracked branch.
+// A shallow fetch keeps the previously indexed commit's objects in the store,
+// so the tree-to-tree diff in DetectChanges still works across updates.
+func fetchRemote(ctx context.Context, cfg *config.Config, dir string) error {
+	ref := gitCurrentBranch(ctx, dir)
+	if ref == "" {
+		ref = "HEAD"
+	}
+
+	args := []string{"fetch", "origin", ref}
+	if cfg.GitCloneDepth > 0 {
+		args = []string{"fetch", "--depth", strconv.Itoa(cfg.GitCloneDepth), "origin", ref}
+	}
+
+	url, err := gitRemoteURL(ctx, dir)
+	if err != nil {
+		return err
+	}
+	if err := runGit(ctx, cfg, url, dir, args...); err != nil {
+		return err
+	}
+	return runGit(ctx, cfg, url, dir, "reset", "--hard", "FETCH_HEAD")
+}
+
+func gitRemoteURL(ctx context.Context, dir string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", "remote", "get-url", "origin")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git remote get-url: %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// runGit executes a git command with auth applied for HTTP(S) remotes.
+// The token is passed per-invocation via http.extraHeader so it is never
+// written into the clone's .git/config.
+func runGit(ctx context.Context, cfg *config.Config, url, dir string, args ...string) error {
+	full := append(gitAuthArgs(cfg, url), args...)
+	cmd := exec.CommandContext(ctx, "git", full...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// gitAuthArgs returns the -c flags that authenticate against an HTTP(S) remote.
+// SSH remotes rely on the user's SSH agent/keys and get no extra flags.
+func gitAuthArgs(cfg *config.Config, url string) []string {
+	if cfg.GitAuthToken == "" {
+		return nil
+	}
+	if !strings.HasPrefix(url, "https://") && !strings.HasPrefix(url, "http://") {
+		return nil
+	}
+	creds := base64.StdEncoding.EncodeToString([]byte(cfg.GitAuthUser + ":" + cfg.GitAuthToken))
+	return []string{"-c", "http.extraHeader=Authorization: Basic " + creds}
+}
@@ -0,0 +1,117 @@
+package indexer
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/maximilianfalco/mycelium/internal/config"
+	"github.com/maximilianfalco/mycelium/internal/projects"
+)
+
+func TestIsRemotePath(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"https://github.com/acme/repo.git", true},
+		{"http://git.internal/acme/repo", true},
+		{"ssh://git@github.com/acme/repo.git", true},
+		{"git://example.com/repo.git", true},
+		{"git@github.com:acme/repo.git", true},
+		{"/Users/me/code/repo", false},
+		{"relative/dir", false},
+		{"/tmp/user@host:weird", false},
+	}
+	for _, tt := range tests {
+		if got := projects.IsRemotePath(tt.path); got != tt.want {
+			t.Errorf("IsRemotePath(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestRemoteCachePath(t *testing.T) {
+	a := remoteCachePath("/cache", "https://github.com/acme/repo.git")
+	b := remoteCachePath("/cache", "https://github.com/fork/repo.git")
+
+	if filepath.Dir(a) != "/cache" {
+		t.Errorf("expected path under /cache, got %s", a)
+	}
+	if !strings.HasPrefix(filepath.Base(a), "repo-") {
+		t.Errorf("expected repo- prefix, got %s", filepath.Base(a))
+	}
+	if a == b {
+		t.Error("expected forks with the same repo name to get distinct cache dirs")
+	}
+	if a != remoteCachePath("/cache", "https://github.com/acme/repo.git") {
+		t.Error("expected cache path to be stable for the same URL")
+	}
+
+	scp := remoteCachePath("/cache", "git@github.com:acme/tool.git")
+	if !strings.HasPrefix(filepath.Base(scp), "tool-") {
+		t.Errorf("expected tool- prefix for scp-style URL, got %s", filepath.Base(scp))
+	}
+}
+
+func TestGitAuthArgs(t *testing.T) {
+	cfg := &config.Config{GitAuthUser: "x-access-token", GitAuthToken: "secret"}
+
+	args := gitAuthArgs(cfg, "https://github.com/acme/repo.git")
+	if len(args) != 2 || args[0] != "-c" || !strings.HasPrefix(args[1], "http.extraHeader=Authorization: Basic ") {
+		t.Errorf("unexpected auth args for https: %v", args)
+	}
+
+	if args := gitAuthArgs(cfg, "git@github.com:acme/repo.git"); args != nil {
+		t.Errorf("expected no auth args for ssh remote, got %v", args)
+	}
+
+	if args := gitAuthArgs(&config.Config{}, "https://github.com/acme/repo.git"); args != nil {
+		t.Errorf("expected no auth args without token, got %v", args)
+	}
+}
+
+func TestCloneAndFetchRemote(t *testing.T) {
+	ctx := context.Background()
+	upstream := t.TempDir()
+	initGitRepo(t, upstream)
+	writeFile(t, filepath.Join(upstream, "main.go"), 100)
+	gitAdd(t, upstream, ".")
+	first := gitCommit(t, upstream, "initial")
+
+	cfg := &config.Config{GitCloneDepth: 1}
+	dest := filepath.Join(t.TempDir(), "clone")
+	url := "file://" + upstream
+
+	if err := cloneRemote(ctx, cfg, url, dest); err != nil {
+		t.Fatalf("clone failed: %v", err)
+	}
+	head, err := gitCurrentCommit(ctx, dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head != first {
+		t.Errorf("expected clone HEAD %s, got %s", first, head)
+	}
+
+	writeFile(t, filepath.Join(upstream, "util.go"), 50)
+	gitAdd(t, upstream, ".")
+	second := gitCommit(t, upstream, "add util")
+
+	if err := fetchRemote(ctx, cfg, dest); err != nil {
+		t.Fatalf("fetch failed: %v", err)
+	}
+	head, _ = gitCurrentCommit(ctx, dest)
+	if head != second {
+		t.Errorf("expected fetched HEAD %s, got %s", second, head)
+	}
+
+	// The previously indexed commit must still be diffable after a shallow fetch
+	added, _, _, err := gitDiff(ctx, dest, first, second)
+	if err != nil {
+		t.Fatalf("diff across shallow fetch failed: %v", err)
+	}
+	if len(added) != 1 || added[0] != "util.go" {
+		t.Errorf("expected util.go added, got %v", added)
+	}
+}
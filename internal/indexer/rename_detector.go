@@ -0,0 +1,193 @@
+package indexer
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/maximilianfalco/mycelium/internal/indexer/parsers"
+)
+
+// existingNode is the stored state of a node, used to detect renames and moves.
+type existingNode struct {
+	ID            string
+	Name          string
+	QualifiedName string
+	FilePath      string
+	BodyHash      string
+	SourceCode    string
+}
+
+// Rename records that a newly parsed node is the same code as a node that
+// disappeared in this run — either moved to another file or renamed.
+type Rename struct {
+	OldNodeID        string
+	OldQualifiedName string
+	NewQualifiedName string
+}
+
+// loadExistingNodes returns the stored nodes of a workspace that live in the
+// given files. When files is nil, all nodes in the workspace are returned.
+func loadExistingNodes(ctx context.Context, pool *pgxpool.Pool, workspaceID string, files []string) ([]existingNode, error) {
+	sql := `SELECT id, name, COALESCE(qualified_name, name), file_path, COALESCE(body_hash, ''), COALESCE(source_code, '')
+		FROM nodes WHERE workspace_id = $1`
+	args := []any{workspaceID}
+	if files != nil {
+		sql += ` AND file_path = ANY($2)`
+		args = append(args, files)
+	}
+
+	rows, err := pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("querying existing nodes: %w", err)
+	}
+	defer rows.Close()
+
+	var nodes []existingNode
+	for rows.Next() {
+		var n existingNode
+		if err := rows.Scan(&n.ID, &n.Name, &n.QualifiedName, &n.FilePath, &n.BodyHash, &n.SourceCode); err != nil {
+			return nil, fmt.Errorf("scanning existing node: %w", err)
+		}
+		nodes = append(nodes, n)
+	}
+	return nodes, rows.Err()
+}
+
+// detectRenames pairs nodes that vanished from re-parsed files with nodes that
+// newly appeared, matching on content. A move keeps the body hash as-is; a
+// rename changes only the identifier, so it is matched on a hash of the source
+// with the node's own name blanked out. Only unambiguous 1:1 matches count —
+// duplicated code is left alone rather than guessed at.
+//
+// existing must contain only nodes from files that were re-parsed or deleted
+// this run; nodes in untouched files are not candidates for having vanished.
+// The result is keyed by the new node's qualified name.
+func detectRenames(existing []existingNode, parsed []parsers.NodeInfo, edges []parsers.EdgeInfo) map[string]Rename {
+	type nodeKey struct{ file, qname string }
+
+	fileOf := buildNodeFileMap(edges)
+	parsedKeys := make(map[nodeKey]bool, len(parsed))
+	for _, n := range parsed {
+		parsedKeys[nodeKey{fileOf(n.QualifiedName), n.QualifiedName}] = true
+	}
+	existingKeys := make(map[nodeKey]bool, len(existing))
+	for _, n := range existing {
+		existingKeys[nodeKey{n.FilePath, n.QualifiedName}] = true
+	}
+
+	var vanished []existingNode
+	for _, n := range existing {
+		if !parsedKeys[nodeKey{n.FilePath, n.QualifiedName}] {
+			vanished = append(vanished, n)
+		}
+	}
+	var appeared []parsers.NodeInfo
+	for _, n := range parsed {
+		if !existingKeys[nodeKey{fileOf(n.QualifiedName), n.QualifiedName}] {
+			appeared = append(appeared, n)
+		}
+	}
+	if len(vanished) == 0 || len(appeared) == 0 {
+		return nil
+	}
+
+	renames := make(map[string]Rename)
+	matchedOld := make(map[string]bool)
+
+	match := func(oldKey func(existingNode) string, newKey func(parsers.NodeInfo) string) {
+		oldByKey := make(map[string][]existingNode)
+		for _, n := range vanished {
+			if matchedOld[n.ID] {
+				continue
+			}
+			if k := oldKey(n); k != "" {
+				oldByKey[k] = append(oldByKey[k], n)
+			}
+		}
+		newByKey := make(map[string][]parsers.NodeInfo)
+		for _, n := range appeared {
+			if _, done := renames[n.QualifiedName]; done {
+				continue
+			}
+			if k := newKey(n); k != "" {
+				newByKey[k] = append(newByKey[k], n)
+			}
+		}
+		for k, olds := range oldByKey {
+			news := newByKey[k]
+			if len(olds) != 1 || len(news) != 1 {
+				continue
+			}
+			renames[news[0].QualifiedName] = Rename{
+				OldNodeID:        olds[0].ID,
+				OldQualifiedName: olds[0].QualifiedName,
+				NewQualifiedName: news[0].QualifiedName,
+			}
+			matchedOld[olds[0].ID] = true
+		}
+	}
+
+	// Pass 1: identical bytes — the node moved files
+	match(
+		func(n existingNode) string { return n.BodyHash },
+		func(n parsers.NodeInfo) string { return n.BodyHash },
+	)
+	// Pass 2: identical apart from the identifier — the node was renamed
+	match(
+		func(n existingNode) string { return nameInsensitiveHash(n.Name, n.SourceCode) },
+		func(n parsers.NodeInfo) string { return nameInsensitiveHash(n.Name, n.SourceCode) },
+	)
+
+	if len(renames) == 0 {
+		return nil
+	}
+	return renames
+}
+
+// nameInsensitiveHash hashes source code with every occurrence of the node's
+// own name blanked, so `function foo() {}` and `function bar() {}` collide.
+func nameInsensitiveHash(name, source string) string {
+	if name == "" || source == "" {
+		return ""
+	}
+	h := sha256.Sum256([]byte(strings.ReplaceAll(source, name, "\x00")))
+	return fmt.Sprintf("%x", h)
+}
+
+// buildNodeFileMap returns a lookup with the same semantics as nodeFilePath,
+// precomputed once instead of scanning edges per node.
+func buildNodeFileMap(edges []parsers.EdgeInfo) func(qname string) string {
+	files := make(map[string]string)
+	for _, e := range edges {
+		if e.Kind != "contains" {
+			continue
+		}
+		if _, ok := files[e.Target]; !ok {
+			files[e.Target] = e.Source
+		}
+	}
+	return func(qname string) string {
+		if f, ok := files[qname]; ok {
+			return f
+		}
+		return qname
+	}
+}
+
+// touchedFiles lists files whose stored nodes may have vanished this run.
+// Returns nil for a full index, meaning every stored node is a candidate.
+func touchedFiles(cs *ChangeSet) []string {
+	if cs.IsFullIndex {
+		return nil
+	}
+	files := make([]string, 0, len(cs.AddedFiles)+len(cs.ModifiedFiles)+len(cs.DeletedFiles)+len(cs.DependentFiles))
+	files = append(files, cs.AddedFiles...)
+	files = append(files, cs.ModifiedFiles...)
+	files = append(files, cs.DeletedFiles...)
+	files = append(files, cs.DependentFiles...)
+	return files
+}
@@ -0,0 +1,103 @@
+package indexer
+
+import (
+	"testing"
+
+	"github.com/maximilianfalco/mycelium/internal/indexer/parsers"
+)
+
+func containsEdge(file, qname string) parsers.EdgeInfo {
+	return parsers.EdgeInfo{Source: file, Target: qname, Kind: "contains"}
+}
+
+func TestDetectRenames_Move(t *testing.T) {
+	existing := []existingNode{
+		{ID: "ws/old.ts::helper", Name: "helper", QualifiedName: "helper", FilePath: "old.ts", BodyHash: "h1", SourceCode: "function helper() {}"},
+	}
+	parsed := []parsers.NodeInfo{
+		{Name: "helper", QualifiedName: "helper", BodyHash: "h1", SourceCode: "function helper() {}"},
+	}
+	edges := []parsers.EdgeInfo{containsEdge("new.ts", "helper")}
+
+	renames := detectRenames(existing, parsed, edges)
+	r, ok := renames["helper"]
+	if !ok {
+		t.Fatalf("expected move to be detected, got %v", renames)
+	}
+	if r.OldNodeID != "ws/old.ts::helper" {
+		t.Errorf("OldNodeID = %q", r.OldNodeID)
+	}
+}
+
+func TestDetectRenames_Rename(t *testing.T) {
+	existing := []existingNode{
+		{ID: "ws/a.ts::getUser", Name: "getUser", QualifiedName: "getUser", FilePath: "a.ts", BodyHash: "h1", SourceCode: "function getUser(id) { return db.find(id) }"},
+	}
+	parsed := []parsers.NodeInfo{
+		{Name: "fetchUser", QualifiedName: "fetchUser", BodyHash: "h2", SourceCode: "function fetchUser(id) { return db.find(id) }"},
+	}
+	edges := []parsers.EdgeInfo{containsEdge("a.ts", "fetchUser")}
+
+	renames := detectRenames(existing, parsed, edges)
+	r, ok := renames["fetchUser"]
+	if !ok {
+		t.Fatalf("expected rename to be detected, got %v", renames)
+	}
+	if r.OldQualifiedName != "getUser" {
+		t.Errorf("OldQualifiedName = %q, want getUser", r.OldQualifiedName)
+	}
+}
+
+func TestDetectRenames_ContentChangeIsNotRename(t *testing.T) {
+	existing := []existingNode{
+		{ID: "ws/a.ts::getUser", Name: "getUser", QualifiedName: "getUser", FilePath: "a.ts", BodyHash: "h1", SourceCode: "function getUser(id) { return db.find(id) }"},
+	}
+	parsed := []parsers.NodeInfo{
+		{Name: "fetchUser", QualifiedName: "fetchUser", BodyHash: "h2", SourceCode: "function fetchUser(id) { return cache.get(id) }"},
+	}
+	edges := []parsers.EdgeInfo{containsEdge("a.ts", "fetchUser")}
+
+	if renames := detectRenames(existing, parsed, edges); len(renames) != 0 {
+		t.Errorf("expected no renames for changed content, got %v", renames)
+	}
+}
+
+func TestDetectRenames_UnchangedNodeIgnored(t *testing.T) {
+	existing := []existingNode{
+		{ID: "ws/a.ts::foo", Name: "foo", QualifiedName: "foo", FilePath: "a.ts", BodyHash: "h1", SourceCode: "function foo() {}"},
+	}
+	parsed := []parsers.NodeInfo{
+		{Name: "foo", QualifiedName: "foo", BodyHash: "h1", SourceCode: "function foo() {}"},
+	}
+	edges := []parsers.EdgeInfo{containsEdge("a.ts", "foo")}
+
+	if renames := detectRenames(existing, parsed, edges); len(renames) != 0 {
+		t.Errorf("expected no renames for a node that stayed put, got %v", renames)
+	}
+}
+
+func TestDetectRenames_AmbiguousSkipped(t *testing.T) {
+	// Two identical vanished nodes and one new copy — can't tell which moved
+	existing := []existingNode{
+		{ID: "ws/a.ts::noop", Name: "noop", QualifiedName: "noop", FilePath: "a.ts", BodyHash: "h1", SourceCode: "function noop() {}"},
+		{ID: "ws/b.ts::noop", Name: "noop", QualifiedName: "noop", FilePath: "b.ts", BodyHash: "h1", SourceCode: "function noop() {}"},
+	}
+	parsed := []parsers.NodeInfo{
+		{Name: "noop", QualifiedName: "noop", BodyHash: "h1", SourceCode: "function noop() {}"},
+	}
+	edges := []parsers.EdgeInfo{containsEdge("c.ts", "noop")}
+
+	if renames := detectRenames(existing, parsed, edges); len(renames) != 0 {
+		t.Errorf("expected ambiguous match to be skipped, got %v", renames)
+	}
+}
+
+func TestTouchedFiles(t *testing.T) {
+	if files := touchedFiles(&ChangeSet{IsFullIndex: true}); files != nil {
+		t.Errorf("expected nil for full index, got %v", files)
+	}
+	cs := &ChangeSet{AddedFiles: []string{"a.ts"}, ModifiedFiles: []string{"b.ts"}, DeletedFiles: []string{"c.ts"}}
+	if files := touchedFiles(cs); len(files) != 3 {
+		t.Errorf("expected 3 touched files, got %v", files)
+	}
+}
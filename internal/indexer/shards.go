@@ -0,0 +1,364 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	openai "github.com/sashabaranov/go-openai"
+
+	"github.com/maximilianfalco/mycelium/internal/config"
+	"github.com/maximilianfalco/mycelium/internal/indexer/detectors"
+	"github.com/maximilianfalco/mycelium/internal/indexer/parsers"
+	"github.com/maximilianfalco/mycelium/internal/projects"
+)
+
+// shard is one slice of a sharded full index: whole packages, packed up to
+// the SHARD_FILES target.
+type shard struct {
+	files []FileInfo
+	// stubs are the shard's nodes placed in their files but without source
+	// or docstring, as the shard index holds them.
+	stubs []parsers.NodeInfo
+}
+
+// shardIndex is the cross-shard view of a sharded run: every shard's stubs,
+// built up in the first pass and shared by reference in the second, so no
+// shard copies or re-indexes the others'.
+type shardIndex struct {
+	// nodes is what each shard resolves against, in place of its own nodes
+	nodes *nodeIndex
+	// ids and fileIDs place edge endpoints like nodeLookup: qualified name
+	// (or placedKey) -> node ID, and file -> its first node's ID
+	ids     map[string]string
+	fileIDs map[string]string
+	// shardOfName and shardOfFile say which shard stores a node, for
+	// holding back edges into later shards
+	shardOfName map[string]int
+	shardOfFile map[string]int
+}
+
+func newShardIndex() *shardIndex {
+	return &shardIndex{
+		nodes:       newNodeIndex(),
+		ids:         make(map[string]string),
+		fileIDs:     make(map[string]string),
+		shardOfName: make(map[string]int),
+		shardOfFile: make(map[string]int),
+	}
+}
+
+// add indexes shard i of the workspace, its stubs already taken.
+func (x *shardIndex) add(workspaceID string, i int, s *shard, edges []parsers.EdgeInfo) {
+	x.nodes.add(s.stubs, edges)
+	for _, n := range s.stubs {
+		id := NodeID(workspaceID, n.FilePath, n.QualifiedName)
+		x.ids[n.QualifiedName] = id
+		x.ids[placedKey(n.FilePath, n.QualifiedName)] = id
+		if _, ok := x.fileIDs[n.FilePath]; !ok {
+			x.fileIDs[n.FilePath] = id
+		}
+		x.shardOfName[n.QualifiedName] = i
+	}
+	for _, f := range s.files {
+		x.shardOfFile[f.RelPath] = i
+	}
+}
+
+// splitShards groups files by package — the grouping depends_on uses, or
+// the directory for files in none — and packs whole groups, in path order,
+// into shards of about size files. A package is never split, so one larger
+// than size gets a shard to itself.
+func splitShards(files []FileInfo, ws *detectors.WorkspaceInfo, markers []string, size int) []*shard {
+	cfg := resolveConfig{}
+	if ws != nil {
+		WithPackages(ws.Packages)(&cfg)
+	}
+	WithPackageMarkerDirs(markers)(&cfg)
+
+	groups := make(map[string][]FileInfo)
+	for _, f := range files {
+		key := cfg.packages.packageFor(f.RelPath)
+		if key == "" {
+			key = filepath.ToSlash(filepath.Dir(f.RelPath))
+		}
+		groups[key] = append(groups[key], f)
+	}
+
+	var shards []*shard
+	current := &shard{}
+	for _, key := range slices.Sorted(maps.Keys(groups)) {
+		group := groups[key]
+		if len(current.files) > 0 && len(current.files)+len(group) > size {
+			shards = append(shards, current)
+			current = &shard{}
+		}
+		current.files = append(current.files, group...)
+	}
+	if len(current.files) > 0 {
+		shards = append(shards, current)
+	}
+	return shards
+}
+
+// index keeps the shard's stubs from its first parse.
+func (s *shard) index(nodes []parsers.NodeInfo, edges []parsers.EdgeInfo) {
+	fileOf := buildNodeFileMap(edges)
+	s.stubs = make([]parsers.NodeInfo, 0, len(nodes))
+	for _, n := range nodes {
+		if n.FilePath == "" {
+			n.FilePath = fileOf(n.QualifiedName)
+		}
+		n.SourceCode, n.Docstring = "", ""
+		s.stubs = append(s.stubs, n)
+	}
+}
+
+// shardRun is the per-source state indexShards works from, gathered by
+// indexSource before parsing.
+type shardRun struct {
+	projectID      string
+	source         *projects.ProjectSource
+	sourcePath     string
+	branch         string
+	workspaceID    string
+	ws             *detectors.WorkspaceInfo
+	crawl          *CrawlResult
+	allRelPaths    []string
+	changeSet      *ChangeSet
+	packageRenames []PackageRename
+}
+
+// indexShards runs stages 3–6 of a full index shard by shard, for sources
+// with more than SHARD_FILES files. A first pass parses every shard and
+// adds its stubs to the shard index. Then each shard is parsed again, from
+// the parse cache, resolved against the index, embedded, and committed by
+// its own BuildGraph. Full nodes, vectors, and edges are only held for one
+// shard at a time.
+//
+// Edges must point at stored nodes, so a resolved edge into a later shard
+// waits and is written with that shard. depends_on edges are gathered from
+// every shard and written with the last. Each shard's commit is visible on
+// its own, so the graph is partly stale until the last one lands.
+func indexShards(
+	ctx context.Context,
+	pool *pgxpool.Pool,
+	cfg *config.Config,
+	oaiClient *openai.Client,
+	run *shardRun,
+	result *sourceResult,
+	updateStatus func(stage, progress string),
+) error {
+	alias := run.source.Alias
+	shards := splitShards(run.crawl.Files, run.ws, cfg.PackageMarkerDirs, cfg.ShardFiles)
+	slog.Info("sharded index", "source", alias, "files", len(run.crawl.Files), "shards", len(shards))
+
+	// Every file is parsed twice, so without PARSE_CACHE_DIR the run caches
+	// into a directory of its own, removed when it ends
+	cacheDir := cfg.ParseCacheDir
+	if cacheDir == "" {
+		dir, err := os.MkdirTemp("", "mycelium-shards-")
+		if err != nil {
+			slog.Warn("could not create a parse cache, parsing every file twice", "source", alias, "error", err)
+		} else {
+			defer os.RemoveAll(dir)
+			cacheDir = dir
+		}
+	}
+	cache := newParseCache(cacheDir)
+	filter := newNodeFilter(cfg)
+	parse := func(s *shard) ([]parsers.NodeInfo, []parsers.EdgeInfo, []string, map[string][]parsers.LineRange) {
+		nodes, edges, parseErrors, syntaxErrors := parseFiles(ctx, s.files, run.sourcePath, cache)
+		nodes, edges, _ = applyNodeFilter(filter, nodes, edges)
+		return nodes, edges, parseErrors, syntaxErrors
+	}
+
+	// Pass 1: the shard index
+	index := newShardIndex()
+	syntaxErrors := make(map[string][]parsers.LineRange)
+	parseErrorCount := 0
+	stageDone := timeStage("parsing")
+	for i, s := range shards {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		updateStatus("parsing", fmt.Sprintf("indexing shard %d/%d (%d files) for %s", i+1, len(shards), len(s.files), alias))
+		nodes, edges, parseErrors, errs := parse(s)
+		s.index(nodes, edges)
+		index.add(run.workspaceID, i, s, edges)
+		parseErrorCount += len(parseErrors)
+		maps.Copy(syntaxErrors, errs)
+	}
+	stageDone()
+	if parseErrorCount > 0 {
+		slog.Warn("parse errors", "count", parseErrorCount, "source", alias)
+	}
+	if len(syntaxErrors) > 0 {
+		slog.Warn("syntax errors", "files", len(syntaxErrors), "source", alias)
+		result.SyntaxErrors = formatSyntaxErrors(syntaxErrors)
+	}
+
+	// Pass 2: resolve, embed, and store each shard
+	waiting := make(map[int][]ResolvedEdge)
+	var dependsOn []ResolvedEdge
+	for i, s := range shards {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		progress := fmt.Sprintf("shard %d/%d for %s", i+1, len(shards), alias)
+		nodes, edges, _, _ := parse(s)
+
+		updateStatus("resolving", "resolving imports, "+progress)
+		stageDone = timeStage("resolving")
+		resolveResult := ResolveImports(
+			edges,
+			run.ws.AliasMap,
+			run.ws.TSConfigPaths,
+			nil,
+			run.allRelPaths,
+			run.sourcePath,
+			WithPackages(run.ws.Packages),
+			WithTSBaseURLs(run.ws.TSBaseURLs),
+			WithPackageMarkerDirs(cfg.PackageMarkerDirs),
+			withNodeIndex(index.nodes),
+		)
+		resolved, shardDependsOn := selectResolved(cfg, resolveResult)
+		dependsOn = append(dependsOn, shardDependsOn...)
+		stageDone()
+
+		// Hold back edges into shards not stored yet; take the ones
+		// earlier shards held back for this one
+		own := make(map[string]bool, len(nodes))
+		for _, n := range nodes {
+			own[n.QualifiedName] = true
+		}
+		var ready []ResolvedEdge
+		for _, e := range resolved {
+			if j := edgeTargetShard(e, i, own, index.shardOfName, index.shardOfFile); j > i {
+				waiting[j] = append(waiting[j], e)
+			} else {
+				ready = append(ready, e)
+			}
+		}
+		ready = append(ready, waiting[i]...)
+		delete(waiting, i)
+
+		pkgNodes, pkgEdges := goPackageNodes(run.crawl.Files, s.files, run.ws, edges)
+		nodes = append(nodes, pkgNodes...)
+		edges = append(edges, pkgEdges...)
+
+		// Renames are only detected within a shard
+		remapped, err := remapRenamedPackages(ctx, pool, run.workspaceID, run.packageRenames, nodes, edges)
+		if err != nil {
+			slog.Warn("could not remap renamed packages", "source", alias, "error", err)
+		}
+		var renames map[string]Rename
+		existing, err := loadExistingNodes(ctx, pool, run.workspaceID, shardStoredPaths(s))
+		if err != nil {
+			slog.Warn("could not load existing nodes, skipping rename detection", "error", err)
+		} else {
+			renames = detectRenames(existing, nodes, edges)
+		}
+		result.NodesRenamed += len(renames) + remapped
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		updateStatus("embedding", "embedding nodes, "+progress)
+		stageDone = timeStage("embedding")
+		embeddings, signatureEmbeddings, embeddedCount, err := embedChangedNodes(ctx, pool, oaiClient, cfg, run.workspaceID, run.source.ID, nodes, renames, updateStatus)
+		stageDone()
+		if err != nil {
+			return fmt.Errorf("embedding: %w", err)
+		}
+		result.NodesEmbedded += embeddedCount
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		updateStatus("storing", "writing graph, "+progress)
+		buildInput := &BuildInput{
+			ProjectID:  run.projectID,
+			SourceID:   run.source.ID,
+			SourcePath: run.sourcePath,
+			Branch:     run.branch,
+			Workspace:  run.ws,
+			Nodes:      nodes,
+			Edges:      edges,
+			Resolved:   ready,
+			Unresolved: resolveResult.Unresolved,
+			Embeddings: embeddings,
+			FilePaths:  run.allRelPaths,
+			Renames:    renames,
+
+			SignatureEmbeddings: signatureEmbeddings,
+
+			CommitTimes: run.changeSet.FileCommitTimes,
+
+			Sharded:    true,
+			shardIndex: index,
+		}
+		if i == len(shards)-1 {
+			buildInput.DependsOn = dependsOn
+		}
+
+		stageDone = timeStage("storing")
+		buildResult, err := BuildGraph(ctx, pool, buildInput)
+		stageDone()
+		if err != nil {
+			return fmt.Errorf("building graph for shard %d/%d: %w", i+1, len(shards), err)
+		}
+		result.NodesUpserted += buildResult.NodesUpserted
+		result.EdgesUpserted += buildResult.EdgesUpserted
+		result.NodesDeleted += buildResult.NodesDeleted
+	}
+	return nil
+}
+
+// edgeTargetShard returns the shard whose node a resolved edge from shard
+// points at, matching how nodeLookup places it: the shard of the resolved
+// file first, then a node of the shard itself, then any node by name, then
+// an import's resolved file. -1 means the target isn't indexed.
+func edgeTargetShard(e ResolvedEdge, shard int, own map[string]bool, shardOfName, shardOfFile map[string]int) int {
+	if j, ok := shardOfFile[e.ResolvedPath]; ok && e.Kind != "imports" && e.Kind != "re_exports" {
+		return j
+	}
+	if own[e.Target] {
+		return shard
+	}
+	if j, ok := shardOfName[e.Target]; ok {
+		return j
+	}
+	if j, ok := shardOfFile[e.Target]; ok {
+		return j
+	}
+	if e.Kind == "imports" || e.Kind == "re_exports" {
+		if j, ok := shardOfFile[e.ResolvedPath]; ok {
+			return j
+		}
+	}
+	return -1
+}
+
+// shardStoredPaths lists the file_path values a shard's stored nodes can
+// have: its files, plus the classes its members are stored under.
+func shardStoredPaths(s *shard) []string {
+	paths := make([]string, 0, len(s.files))
+	seen := make(map[string]bool, len(s.files))
+	for _, f := range s.files {
+		seen[f.RelPath] = true
+		paths = append(paths, f.RelPath)
+	}
+	for _, n := range s.stubs {
+		if !seen[n.FilePath] {
+			seen[n.FilePath] = true
+			paths = append(paths, n.FilePath)
+		}
+	}
+	return paths
+}
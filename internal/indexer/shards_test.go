@@ -0,0 +1,119 @@
+package indexer
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/maximilianfalco/mycelium/internal/config"
+	"github.com/maximilianfalco/mycelium/internal/indexer/detectors"
+	"github.com/maximilianfalco/mycelium/internal/indexer/parsers"
+)
+
+func TestSplitShards(t *testing.T) {
+	var files []FileInfo
+	for _, p := range []string{
+		"packages/auth/src/a.ts", "packages/auth/src/b.ts", "packages/auth/src/c.ts",
+		"packages/db/index.ts",
+		"tools/x.go", "tools/y.go",
+		"main.go",
+	} {
+		files = append(files, FileInfo{RelPath: p})
+	}
+	ws := &detectors.WorkspaceInfo{Packages: []detectors.PackageInfo{{Name: "db", Path: "packages/db"}}}
+
+	shards := splitShards(files, ws, config.DefaultPackageMarkerDirs, 2)
+	var got [][]string
+	for _, s := range shards {
+		var paths []string
+		for _, f := range s.files {
+			paths = append(paths, f.RelPath)
+		}
+		got = append(got, paths)
+	}
+	// Groups in path order: ".", packages/auth (kept whole, over size),
+	// packages/db, tools
+	want := [][]string{
+		{"main.go"},
+		{"packages/auth/src/a.ts", "packages/auth/src/b.ts", "packages/auth/src/c.ts"},
+		{"packages/db/index.ts"},
+		{"tools/x.go", "tools/y.go"},
+	}
+	if !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("shards = %v, want %v", got, want)
+	}
+
+	if all := splitShards(files, ws, nil, 100); len(all) != 1 || len(all[0].files) != len(files) {
+		t.Errorf("expected one shard holding every file, got %d", len(all))
+	}
+}
+
+func TestEdgeTargetShard(t *testing.T) {
+	own := map[string]bool{"Shared": true}
+	byName := map[string]int{"Shared": 2, "Later": 2, "Earlier": 0}
+	byFile := map[string]int{"src/later.ts": 2}
+
+	cases := []struct {
+		name string
+		edge ResolvedEdge
+		want int
+	}{
+		{"own node wins over a same-named one elsewhere", ResolvedEdge{Target: "Shared", Kind: "calls"}, 1},
+		{"later shard by name", ResolvedEdge{Target: "Later", Kind: "calls"}, 2},
+		{"earlier shard by name", ResolvedEdge{Target: "Earlier", Kind: "calls"}, 0},
+		{"import by resolved file", ResolvedEdge{Target: "./later", Kind: "imports", ResolvedPath: "src/later.ts"}, 2},
+		{"resolved file wins over a same-named own node", ResolvedEdge{Target: "Shared", Kind: "uses_type", ResolvedPath: "src/later.ts"}, 2},
+		{"unknown target", ResolvedEdge{Target: "Missing", Kind: "calls"}, -1},
+	}
+	for _, tc := range cases {
+		if got := edgeTargetShard(tc.edge, 1, own, byName, byFile); got != tc.want {
+			t.Errorf("%s: got %d, want %d", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestShardIndex_ResolvesAcrossShards(t *testing.T) {
+	shards := []*shard{
+		{files: []FileInfo{{RelPath: "a.ts"}}},
+		{files: []FileInfo{{RelPath: "b.ts"}}},
+	}
+	parsed := []struct {
+		nodes []parsers.NodeInfo
+		edges []parsers.EdgeInfo
+	}{
+		{
+			nodes: []parsers.NodeInfo{{Name: "helper", QualifiedName: "helper", Kind: "function", SourceCode: "function helper() {}"}},
+			edges: []parsers.EdgeInfo{{Source: "a.ts", Target: "helper", Kind: "contains"}},
+		},
+		{
+			nodes: []parsers.NodeInfo{{Name: "main", QualifiedName: "main", Kind: "function"}},
+			edges: []parsers.EdgeInfo{
+				{Source: "b.ts", Target: "main", Kind: "contains"},
+				{Source: "b.ts", Target: "./a", Kind: "imports", Symbols: []string{"helper"}},
+				{Source: "main", Target: "helper", Kind: "calls", Line: 3},
+			},
+		},
+	}
+	index := newShardIndex()
+	for i, s := range shards {
+		s.index(parsed[i].nodes, parsed[i].edges)
+		index.add("ws", i, s, parsed[i].edges)
+	}
+	if s := shards[0].stubs[0]; s.SourceCode != "" || s.FilePath != "a.ts" {
+		t.Errorf("expected a placed stub without source, got %+v", s)
+	}
+	if id := index.ids["helper"]; id != NodeID("ws", "a.ts", "helper") || index.shardOfName["helper"] != 0 {
+		t.Errorf("helper indexed as %q in shard %d", id, index.shardOfName["helper"])
+	}
+
+	// The second shard resolves its own edges against the shared index
+	result := ResolveImports(parsed[1].edges, nil, nil, nil, []string{"a.ts", "b.ts"}, "", withNodeIndex(index.nodes))
+	var calls []ResolvedEdge
+	for _, r := range result.Resolved {
+		if r.Kind == "calls" {
+			calls = append(calls, r)
+		}
+	}
+	if len(calls) != 1 || calls[0].Target != "helper" || calls[0].ResolvedPath != "a.ts" {
+		t.Errorf("expected main -> helper in a.ts, got %+v", calls)
+	}
+}
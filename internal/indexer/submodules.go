@@ -0,0 +1,160 @@
+package indexer
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// SubmoduleMode controls what indexing does with git submodules declared in
+// a source's .gitmodules.
+type SubmoduleMode string
+
+const (
+	// SubmodulesSkip leaves submodule paths out of crawling and change
+	// detection. To index a submodule, add its checkout as its own source,
+	// which then gets its own commit tracking. This is the default.
+	SubmodulesSkip SubmoduleMode = "skip"
+	// SubmodulesInclude indexes submodule contents as part of the parent
+	// source. Changes are detected by diffing each submodule between the
+	// commits its gitlink pointed to before and after.
+	SubmodulesInclude SubmoduleMode = "include"
+)
+
+// ParseSubmoduleMode maps a config value to a mode. Anything other than
+// "include" skips submodules.
+func ParseSubmoduleMode(s string) SubmoduleMode {
+	if strings.EqualFold(strings.TrimSpace(s), string(SubmodulesInclude)) {
+		return SubmodulesInclude
+	}
+	return SubmodulesSkip
+}
+
+// readSubmodulePaths returns the submodule paths declared in rootPath's
+// .gitmodules, relative to rootPath. A missing file means no submodules.
+func readSubmodulePaths(rootPath string) []string {
+	f, err := os.Open(filepath.Join(rootPath, ".gitmodules"))
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var paths []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !ok || strings.TrimSpace(key) != "path" {
+			continue
+		}
+		if p := filepath.Clean(strings.TrimSpace(value)); p != "." && p != "" {
+			paths = append(paths, filepath.ToSlash(p))
+		}
+	}
+	return paths
+}
+
+// submoduleFor returns the submodule that contains relPath, or "".
+func submoduleFor(relPath string, submodules []string) string {
+	relPath = filepath.ToSlash(relPath)
+	for _, sub := range submodules {
+		if relPath == sub || strings.HasPrefix(relPath, sub+"/") {
+			return sub
+		}
+	}
+	return ""
+}
+
+// crawlSource crawls rootPath like CrawlDirectory, which never descends into
+// submodules, and in SubmodulesInclude mode appends each checked-out
+// submodule's files with paths relative to rootPath.
+func crawlSource(rootPath string, isCode bool, mode SubmoduleMode) (*CrawlResult, error) {
+	result, err := CrawlDirectory(rootPath, isCode)
+	if err != nil {
+		return nil, err
+	}
+	if mode != SubmodulesInclude {
+		return result, nil
+	}
+
+	for _, sub := range readSubmodulePaths(rootPath) {
+		subPath := filepath.Join(rootPath, filepath.FromSlash(sub))
+		if info, err := os.Stat(subPath); err != nil || !info.IsDir() {
+			continue // declared but not checked out
+		}
+		subResult, err := CrawlDirectory(subPath, isCode)
+		if err != nil {
+			return nil, fmt.Errorf("crawling submodule %s: %w", sub, err)
+		}
+		for _, f := range subResult.Files {
+			f.RelPath = filepath.Join(filepath.FromSlash(sub), f.RelPath)
+			result.Files = append(result.Files, f)
+		}
+		result.Stats.Total += subResult.Stats.Total
+		result.Stats.Skipped += subResult.Stats.Skipped
+		for ext, n := range subResult.Stats.ByExtension {
+			result.Stats.ByExtension[ext] += n
+		}
+	}
+	return result, nil
+}
+
+// gitSubmoduleCommit returns the commit a submodule's gitlink points to at
+// commit, or "" if the submodule doesn't exist there.
+func gitSubmoduleCommit(ctx context.Context, dir, commit, sub string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", "ls-tree", commit, "--", sub)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git ls-tree %s: %w", sub, err)
+	}
+	// "160000 commit <sha>\t<path>"
+	fields := strings.Fields(string(out))
+	if len(fields) < 3 || fields[1] != "commit" {
+		return "", nil
+	}
+	return fields[2], nil
+}
+
+// diffSubmodules expands submodule gitlink changes between two parent
+// commits into file-level changes, with paths relative to the parent. It
+// errors when a submodule was added or removed in between, or when its
+// checkout lacks either commit; the caller falls back to a full index.
+func diffSubmodules(ctx context.Context, dir, fromCommit, toCommit string, submodules []string) (added, modified, deleted []string, err error) {
+	for _, sub := range submodules {
+		from, err := gitSubmoduleCommit(ctx, dir, fromCommit, sub)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		to, err := gitSubmoduleCommit(ctx, dir, toCommit, sub)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if from == to {
+			continue
+		}
+		if from == "" || to == "" {
+			return nil, nil, nil, fmt.Errorf("submodule %s was added or removed", sub)
+		}
+
+		a, m, d, err := gitDiff(ctx, filepath.Join(dir, filepath.FromSlash(sub)), from, to)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("submodule %s: %w", sub, err)
+		}
+		added = append(added, prefixPaths(sub, a)...)
+		modified = append(modified, prefixPaths(sub, m)...)
+		deleted = append(deleted, prefixPaths(sub, d)...)
+	}
+	return added, modified, deleted, nil
+}
+
+func prefixPaths(prefix string, paths []string) []string {
+	out := make([]string, len(paths))
+	for i, p := range paths {
+		out[i] = prefix + "/" + p
+	}
+	return out
+}
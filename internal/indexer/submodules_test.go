@@ -0,0 +1,141 @@
+package indexer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestReadSubmodulePaths(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, ".gitmodules"), []byte(`[submodule "lib"]
+	path = libs/shared
+	url = https://example.com/shared.git
+[submodule "proto"]
+	path=proto
+	url = ../proto.git
+`), 0o644)
+
+	got := readSubmodulePaths(dir)
+	if !slices.Equal(got, []string{"libs/shared", "proto"}) {
+		t.Errorf("readSubmodulePaths = %v", got)
+	}
+	if got := readSubmodulePaths(t.TempDir()); got != nil {
+		t.Errorf("expected nil without .gitmodules, got %v", got)
+	}
+}
+
+func TestParseSubmoduleMode(t *testing.T) {
+	for in, want := range map[string]SubmoduleMode{
+		"include": SubmodulesInclude,
+		"Include": SubmodulesInclude,
+		"skip":    SubmodulesSkip,
+		"":        SubmodulesSkip,
+		"bogus":   SubmodulesSkip,
+	} {
+		if got := ParseSubmoduleMode(in); got != want {
+			t.Errorf("ParseSubmoduleMode(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestCrawlSource_Submodules(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "main.go"), 100)
+	writeFile(t, filepath.Join(dir, "libs", "shared", "util.go"), 100)
+	writeFile(t, filepath.Join(dir, "libs", "own.go"), 100)
+	os.WriteFile(filepath.Join(dir, ".gitmodules"), []byte("[submodule \"shared\"]\n\tpath = libs/shared\n"), 0o644)
+
+	skipped, err := crawlSource(dir, true, SubmodulesSkip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := relPaths(skipped); !slices.Equal(got, []string{"libs/own.go", "main.go"}) {
+		t.Errorf("skip mode crawled %v", got)
+	}
+
+	included, err := crawlSource(dir, true, SubmodulesInclude)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := relPaths(included); !slices.Equal(got, []string{"libs/own.go", "libs/shared/util.go", "main.go"}) {
+		t.Errorf("include mode crawled %v", got)
+	}
+	if included.Stats.Total != 3 {
+		t.Errorf("expected Total=3, got %d", included.Stats.Total)
+	}
+}
+
+func TestDetectChanges_SubmoduleInclude(t *testing.T) {
+	ctx := context.Background()
+
+	lib := t.TempDir()
+	initGitRepo(t, lib)
+	writeFile(t, filepath.Join(lib, "util.go"), 100)
+	gitAdd(t, lib, ".")
+	gitCommit(t, lib, "lib initial")
+
+	dir := t.TempDir()
+	initGitRepo(t, dir)
+	writeFile(t, filepath.Join(dir, "main.go"), 100)
+	run(t, dir, "git", "-c", "protocol.file.allow=always", "submodule", "add", lib, "lib")
+	gitAdd(t, dir, ".")
+	commit1 := gitCommit(t, dir, "initial")
+
+	cs, err := DetectChanges(ctx, dir, nil, nil, 100, false, SubmodulesSkip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if slices.Contains(cs.AddedFiles, "lib/util.go") {
+		t.Errorf("skip mode should not index submodule files, got %v", cs.AddedFiles)
+	}
+
+	cs, err = DetectChanges(ctx, dir, nil, nil, 100, false, SubmodulesInclude)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Contains(cs.AddedFiles, "lib/util.go") {
+		t.Errorf("include mode should index submodule files, got %v", cs.AddedFiles)
+	}
+
+	// Commit inside the submodule, then bump the gitlink in the parent.
+	sub := filepath.Join(dir, "lib")
+	run(t, sub, "git", "config", "user.email", "test@test.com")
+	run(t, sub, "git", "config", "user.name", "Test")
+	writeFile(t, filepath.Join(sub, "util.go"), 200)
+	writeFile(t, filepath.Join(sub, "extra.go"), 100)
+	gitAdd(t, sub, ".")
+	gitCommit(t, sub, "lib change")
+	gitAdd(t, dir, "lib")
+	gitCommit(t, dir, "bump lib")
+
+	cs, err = DetectChanges(ctx, dir, &commit1, nil, 100, false, SubmodulesInclude)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cs.IsFullIndex {
+		t.Fatal("expected an incremental change set")
+	}
+	if !slices.Equal(cs.AddedFiles, []string{"lib/extra.go"}) || !slices.Equal(cs.ModifiedFiles, []string{"lib/util.go"}) {
+		t.Errorf("expected added=[lib/extra.go] modified=[lib/util.go], got added=%v modified=%v", cs.AddedFiles, cs.ModifiedFiles)
+	}
+
+	cs, err = DetectChanges(ctx, dir, &commit1, nil, 100, false, SubmodulesSkip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cs.AddedFiles)+len(cs.ModifiedFiles) != 0 {
+		t.Errorf("skip mode should ignore the gitlink bump, got added=%v modified=%v", cs.AddedFiles, cs.ModifiedFiles)
+	}
+}
+
+func relPaths(r *CrawlResult) []string {
+	var out []string
+	for _, f := range r.Files {
+		out = append(out, filepath.ToSlash(f.RelPath))
+	}
+	slices.Sort(out)
+	return out
+}
@@ -0,0 +1,107 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var softDelete atomic.Bool
+
+// SetSoftDelete turns tombstoning on or off. While on, nodes removed as stale
+// are copied to deleted_nodes, and the edges touching them to deleted_edges,
+// before they're deleted, so "this function used to exist" stays answerable.
+// SOFT_DELETE sets it.
+func SetSoftDelete(enabled bool) {
+	softDelete.Store(enabled)
+}
+
+// staleNodeIDs returns the IDs cleanupStale would delete: the workspace's
+// nodes outside currentFilePaths, or all of them when it's empty.
+func staleNodeIDs(ctx context.Context, tx pgx.Tx, workspaceID string, currentFilePaths []string) ([]string, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT id FROM nodes
+		WHERE workspace_id = $1 AND (cardinality($2::text[]) = 0 OR NOT (file_path = ANY($2)))`,
+		workspaceID, currentFilePaths)
+	if err != nil {
+		return nil, fmt.Errorf("finding stale nodes: %w", err)
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("scanning stale nodes: %w", err)
+	}
+	return ids, nil
+}
+
+// tombstoneStale records tombstones for the nodes cleanupStale is about to
+// delete, plus the also nodes (the old side of renames) not already among
+// them. BuildGraph calls it before rewriting the workspace's edges, while
+// the nodes' outgoing edges still exist. No-op unless soft delete is on.
+func tombstoneStale(ctx context.Context, tx pgx.Tx, workspaceID string, currentFilePaths []string, also ...string) error {
+	if !softDelete.Load() {
+		return nil
+	}
+	ids, err := staleNodeIDs(ctx, tx, workspaceID, currentFilePaths)
+	if err != nil {
+		return err
+	}
+	for _, id := range also {
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return tombstoneNodes(ctx, tx, workspaceID, ids)
+}
+
+// tombstoneNodes copies the nodes of ids, and every edge into or out of
+// them, to the tombstone tables. It must run before the nodes are deleted,
+// since their edges cascade. No-op unless soft delete is on.
+func tombstoneNodes(ctx context.Context, tx pgx.Tx, workspaceID string, ids []string) error {
+	if !softDelete.Load() || len(ids) == 0 {
+		return nil
+	}
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO deleted_nodes (id, workspace_id, file_path, name, qualified_name, kind,
+		                           signature, start_line, end_line, source_code, docstring, body_hash)
+		SELECT id, workspace_id, file_path, name, qualified_name, kind,
+		       signature, start_line, end_line, source_code, docstring, body_hash
+		FROM nodes
+		WHERE workspace_id = $1 AND id = ANY($2)
+		ON CONFLICT DO NOTHING`, workspaceID, ids); err != nil {
+		return fmt.Errorf("tombstoning nodes: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO deleted_edges (workspace_id, source_id, target_id, kind, line_number)
+		SELECT $1, source_id, target_id, kind, line_number
+		FROM edges
+		WHERE source_id = ANY($2) OR target_id = ANY($2)`, workspaceID, ids); err != nil {
+		return fmt.Errorf("tombstoning edges: %w", err)
+	}
+	return nil
+}
+
+// PurgeTombstones deletes the project's tombstones older than retention and
+// returns how many nodes were purged. The pipeline calls it after each run
+// with TOMBSTONE_RETENTION_DAYS, so history doesn't grow without bound.
+func PurgeTombstones(ctx context.Context, pool *pgxpool.Pool, projectID string, retention time.Duration) (int, error) {
+	secs := retention.Seconds()
+	if _, err := pool.Exec(ctx, `
+		DELETE FROM deleted_edges
+		WHERE deleted_at < CURRENT_TIMESTAMP - make_interval(secs => $2)
+		  AND workspace_id IN (SELECT id FROM workspaces WHERE project_id = $1)`, projectID, secs); err != nil {
+		return 0, fmt.Errorf("purging edge tombstones: %w", err)
+	}
+	tag, err := pool.Exec(ctx, `
+		DELETE FROM deleted_nodes
+		WHERE deleted_at < CURRENT_TIMESTAMP - make_interval(secs => $2)
+		  AND workspace_id IN (SELECT id FROM workspaces WHERE project_id = $1)`, projectID, secs)
+	if err != nil {
+		return 0, fmt.Errorf("purging node tombstones: %w", err)
+	}
+	return int(tag.RowsAffected()), nil
+}
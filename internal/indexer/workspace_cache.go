@@ -0,0 +1,149 @@
+package indexer
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/maximilianfalco/mycelium/internal/indexer/detectors"
+)
+
+// workspaceConfigFiles are the files workspace detection reads. A change to
+// any of them, at any depth, invalidates the cached WorkspaceInfo.
+var workspaceConfigFiles = map[string]bool{
+	"package.json":        true,
+	"tsconfig.json":       true,
+	"jsconfig.json":       true,
+	"pnpm-workspace.yaml": true,
+	"lerna.json":          true,
+	"go.mod":              true,
+	"go.work":             true,
+}
+
+type workspaceCacheEntry struct {
+	configHash string
+	info       *detectors.WorkspaceInfo
+}
+
+// workspaceCache holds the last detected WorkspaceInfo per source for the
+// lifetime of the process, so frequent incremental runs (watcher, auto-reindex)
+// skip re-reading every package.json and tsconfig.
+var workspaceCache = struct {
+	sync.Mutex
+	entries map[string]workspaceCacheEntry
+}{entries: make(map[string]workspaceCacheEntry)}
+
+// detectWorkspaceCached returns the cached WorkspaceInfo for workspaceID when the
+// change set touched no config files and the root config hash is unchanged.
+// Otherwise it re-detects and refreshes the cache. The bool reports a cache hit.
+func detectWorkspaceCached(workspaceID, sourcePath string, cs *ChangeSet) (*detectors.WorkspaceInfo, bool, error) {
+	workspaceCache.Lock()
+	entry, ok := workspaceCache.entries[workspaceID]
+	workspaceCache.Unlock()
+
+	if ok && !cs.IsFullIndex && !cs.ConfigChanged && entry.configHash == workspaceConfigHash(sourcePath, entry.info) {
+		return entry.info, true, nil
+	}
+
+	info, err := detectors.DetectWorkspace(sourcePath)
+	if err != nil {
+		return nil, false, err
+	}
+
+	workspaceCache.Lock()
+	workspaceCache.entries[workspaceID] = workspaceCacheEntry{configHash: workspaceConfigHash(sourcePath, info), info: info}
+	workspaceCache.Unlock()
+
+	return info, false, nil
+}
+
+// workspaceConfigHash hashes the config files at the source root, plus the
+// configs the tsconfig of the root and of each package in info extends
+// (tsconfig.base.json, shared configs in node_modules). Nested package
+// configs themselves are covered by ChangeSet.ConfigChanged, but an extends
+// target has no name that check knows. This stays a handful of reads per
+// package rather than a walk.
+func workspaceConfigHash(sourcePath string, info *detectors.WorkspaceInfo) string {
+	h := sha256.New()
+	hashFile := func(name, path string) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00", name, len(data))
+		h.Write(data)
+	}
+	for _, name := range slices.Sorted(maps.Keys(workspaceConfigFiles)) {
+		hashFile(name, filepath.Join(sourcePath, name))
+	}
+
+	dirs := []string{"."}
+	if info != nil {
+		for _, pkg := range info.Packages {
+			dirs = append(dirs, pkg.Path)
+		}
+	}
+	for _, dir := range dirs {
+		for _, path := range detectors.TSConfigExtends(filepath.Join(sourcePath, dir)) {
+			hashFile(path, path)
+		}
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// hasWorkspaceConfigFile reports whether any of the given relative paths is a
+// workspace config file outside the skipped directories.
+func hasWorkspaceConfigFile(files []string) bool {
+	for _, f := range files {
+		if !workspaceConfigFiles[filepath.Base(f)] {
+			continue
+		}
+		if !inSkippedDir(f) {
+			return true
+		}
+	}
+	return false
+}
+
+// workspaceConfigModifiedSince walks the source for config files newer than
+// since. Used by mtime change detection, where there is no diff to inspect.
+func workspaceConfigModifiedSince(rootPath string, since time.Time) bool {
+	changed := false
+	filepath.WalkDir(rootPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != rootPath && (skipDirs[d.Name()] || strings.HasPrefix(d.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !workspaceConfigFiles[d.Name()] {
+			return nil
+		}
+		if info, err := d.Info(); err == nil && info.ModTime().After(since) {
+			changed = true
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	return changed
+}
+
+func inSkippedDir(relPath string) bool {
+	parts := strings.Split(relPath, "/")
+	for _, p := range parts[:len(parts)-1] {
+		if skipDirs[p] || strings.HasPrefix(p, ".") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,157 @@
+package indexer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHasWorkspaceConfigFile(t *testing.T) {
+	tests := []struct {
+		files []string
+		want  bool
+	}{
+		{[]string{"src/index.ts"}, false},
+		{[]string{"src/index.ts", "package.json"}, true},
+		{[]string{"packages/auth/tsconfig.json"}, true},
+		{[]string{"node_modules/foo/package.json"}, false},
+		{[]string{"go.work"}, true},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := hasWorkspaceConfigFile(tt.files); got != tt.want {
+			t.Errorf("hasWorkspaceConfigFile(%v) = %v, want %v", tt.files, got, tt.want)
+		}
+	}
+}
+
+func TestDetectChanges_ConfigChanged(t *testing.T) {
+	dir := t.TempDir()
+	initGitRepo(t, dir)
+
+	os.WriteFile(filepath.Join(dir, "package.json"), []byte(`{"name":"app"}`), 0o644)
+	writeFile(t, filepath.Join(dir, "index.ts"), 100)
+	gitAdd(t, dir, ".")
+	commit1 := gitCommit(t, dir, "initial")
+
+	writeFile(t, filepath.Join(dir, "index.ts"), 200)
+	gitAdd(t, dir, ".")
+	commit2 := gitCommit(t, dir, "code only")
+
+	ctx := context.Background()
+	cs, err := DetectChanges(ctx, dir, &commit1, nil, 100, false, SubmodulesSkip)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cs.ConfigChanged {
+		t.Error("expected ConfigChanged=false for a code-only change")
+	}
+
+	os.WriteFile(filepath.Join(dir, "package.json"), []byte(`{"name":"app","version":"2.0.0"}`), 0o644)
+	gitAdd(t, dir, ".")
+	gitCommit(t, dir, "bump version")
+
+	cs, err = DetectChanges(ctx, dir, &commit2, nil, 100, false, SubmodulesSkip)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cs.ConfigChanged {
+		t.Error("expected ConfigChanged=true after package.json change")
+	}
+}
+
+func TestDetectChanges_MtimeConfigChanged(t *testing.T) {
+	dir := t.TempDir()
+	pkgJSON := filepath.Join(dir, "packages", "auth", "package.json")
+	writeFile(t, pkgJSON, 10)
+
+	past := time.Now().Add(-1 * time.Hour)
+	os.Chtimes(pkgJSON, past, past)
+	indexTime := time.Now().Add(-30 * time.Minute)
+
+	cs, err := DetectChanges(context.Background(), dir, nil, &indexTime, 100, false, SubmodulesSkip)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cs.ConfigChanged {
+		t.Error("expected ConfigChanged=false for untouched package.json")
+	}
+
+	os.Chtimes(pkgJSON, time.Now(), time.Now())
+	cs, err = DetectChanges(context.Background(), dir, nil, &indexTime, 100, false, SubmodulesSkip)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cs.ConfigChanged {
+		t.Error("expected ConfigChanged=true for nested package.json touched after last index")
+	}
+}
+
+func TestDetectWorkspaceCached(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "package.json"), []byte(`{"name":"app"}`), 0o644)
+	sourceID := "cache-test-" + t.Name()
+	incremental := &ChangeSet{ModifiedFiles: []string{"index.ts"}}
+
+	info, cached, err := detectWorkspaceCached(sourceID, dir, incremental)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cached {
+		t.Error("expected miss on first detection")
+	}
+	if len(info.Packages) != 1 || info.Packages[0].Name != "app" {
+		t.Fatalf("unexpected packages: %+v", info.Packages)
+	}
+
+	if _, cached, _ = detectWorkspaceCached(sourceID, dir, incremental); !cached {
+		t.Error("expected hit when nothing changed")
+	}
+
+	if _, cached, _ = detectWorkspaceCached(sourceID, dir, &ChangeSet{ConfigChanged: true}); cached {
+		t.Error("expected miss when change set flags a config change")
+	}
+	if _, cached, _ = detectWorkspaceCached(sourceID, dir, &ChangeSet{IsFullIndex: true}); cached {
+		t.Error("expected miss on full index")
+	}
+
+	// Root config edited without the change set noticing — the hash catches it
+	os.WriteFile(filepath.Join(dir, "package.json"), []byte(`{"name":"renamed"}`), 0o644)
+	info, cached, _ = detectWorkspaceCached(sourceID, dir, incremental)
+	if cached {
+		t.Error("expected miss after root package.json changed")
+	}
+	if info.Packages[0].Name != "renamed" {
+		t.Errorf("expected re-detected package name, got %q", info.Packages[0].Name)
+	}
+}
+
+func TestDetectWorkspaceCached_TSConfigExtends(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "package.json"), []byte(`{"name":"root","workspaces":["packages/*"]}`), 0o644)
+	os.WriteFile(filepath.Join(dir, "tsconfig.base.json"), []byte(`{"compilerOptions":{"paths":{"@a/*":["a/*"]}}}`), 0o644)
+	os.MkdirAll(filepath.Join(dir, "packages", "web"), 0o755)
+	os.WriteFile(filepath.Join(dir, "packages", "web", "package.json"), []byte(`{"name":"web"}`), 0o644)
+	os.WriteFile(filepath.Join(dir, "packages", "web", "tsconfig.json"), []byte(`{"extends":"../../tsconfig.base.json"}`), 0o644)
+	sourceID := "cache-test-" + t.Name()
+	incremental := &ChangeSet{ModifiedFiles: []string{"tsconfig.base.json"}}
+
+	if _, cached, err := detectWorkspaceCached(sourceID, dir, incremental); err != nil || cached {
+		t.Fatalf("expected a miss on first detection, got cached=%v err=%v", cached, err)
+	}
+	if _, cached, _ := detectWorkspaceCached(sourceID, dir, incremental); !cached {
+		t.Error("expected hit when nothing changed")
+	}
+
+	// Only a package's config extends it, and its name isn't a config file's
+	os.WriteFile(filepath.Join(dir, "tsconfig.base.json"), []byte(`{"compilerOptions":{"paths":{"@b/*":["b/*"]}}}`), 0o644)
+	info, cached, _ := detectWorkspaceCached(sourceID, dir, incremental)
+	if cached {
+		t.Error("expected miss after the extended tsconfig.base.json changed")
+	}
+	if _, ok := info.TSConfigPaths["@b/*"]; !ok {
+		t.Errorf("expected re-detected paths, got %v", info.TSConfigPaths)
+	}
+}
@@ -15,14 +15,15 @@ import (
 )
 
 // NewServer creates an MCP server with mycelium's code intelligence tools.
-func NewServer(pool *pgxpool.Pool, client *openai.Client) *server.MCPServer {
+// cache, if non-nil, serves repeated explore queries.
+func NewServer(pool *pgxpool.Pool, client *openai.Client, cache engine.ContextCache) *server.MCPServer {
 	s := server.NewMCPServer(
 		"mycelium",
 		"0.1.0",
 		server.WithToolCapabilities(false),
 	)
 
-	s.AddTool(exploreTool(), exploreHandler(pool, client))
+	s.AddTool(exploreTool(), exploreHandler(pool, client, cache))
 	s.AddTool(listProjectsTool(), listProjectsHandler(pool))
 	s.AddTool(detectProjectTool(), detectProjectHandler(pool))
 
@@ -72,6 +73,34 @@ func exploreTool() mcp.Tool {
 		mcp.WithNumber("max_tokens",
 			mcp.Description("Token budget for the response (default 8000)."),
 		),
+		mcp.WithString("format",
+			mcp.Description("Output layout: 'markdown' (default), 'xml', or 'json'."),
+		),
+		mcp.WithNumber("alpha",
+			mcp.Description("Keyword vs. semantic weight from 0 (pure keyword, exact symbol lookup) to 1 (pure semantic, conceptual questions). Default 0.5."),
+		),
+		mcp.WithArray("edge_kinds",
+			mcp.Description("Edge kinds to follow when expanding hits through the graph, e.g. ['calls'] for call flow or ['uses_type', 'implements'] for type impact. Default: calls, imports, uses_type."),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		mcp.WithNumber("recency_boost",
+			mcp.Description("Rank code in recently committed files higher, e.g. 0.5 scores a file committed today 1.5x, fading over ~2 weeks. Useful on an active feature branch. Default 0 (off)."),
+		),
+		mcp.WithNumber("min_score",
+			mcp.Description("Leave out graph-expanded results whose combined score (similarity x hop weight) is below this, e.g. 0.3, so weak 2-hop neighbours don't use up the budget. The best search hit is always kept. Default 0 (off)."),
+		),
+		mcp.WithBoolean("compact",
+			mcp.Description("Annotate only the top 5 results with callers/callees/imports and show the rest signature-only. Use with large max_tokens to save tokens. Default false."),
+		),
+		mcp.WithString("branch",
+			mcp.Description("Git branch to search when the server indexes branches separately (BRANCH_WORKSPACES). Default: the branch each source had checked out when last indexed."),
+		),
+		mcp.WithBoolean("decompose",
+			mcp.Description("Split a compound question (e.g. 'how does auth work and where are sessions stored') into sub-queries, search each, and merge the hits into one context. Default false."),
+		),
+		mcp.WithBoolean("expand_generated",
+			mcp.Description("Let graph expansion around hits walk into code from generated files (protobuf stubs, codegen output). Default: the server's EXPAND_GENERATED setting, off unless set."),
+		),
 	)
 }
 
@@ -153,7 +182,7 @@ func detectProjectHandler(pool *pgxpool.Pool) server.ToolHandlerFunc {
 	}
 }
 
-func exploreHandler(pool *pgxpool.Pool, client *openai.Client) server.ToolHandlerFunc {
+func exploreHandler(pool *pgxpool.Pool, client *openai.Client, cache engine.ContextCache) server.ToolHandlerFunc {
 	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		// Accept either "query" (single) or "queries" (batch)
 		var queries []string
@@ -174,34 +203,58 @@ func exploreHandler(pool *pgxpool.Pool, client *openai.Client) server.ToolHandle
 
 		maxTokens := req.GetInt("max_tokens", 8000)
 
+		formatter, err := engine.FormatterByName(req.GetString("format", ""))
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		opts := []engine.AssembleOption{
+			engine.WithFormatter(formatter),
+			engine.WithAlpha(req.GetFloat("alpha", engine.DefaultHybridAlpha)),
+			engine.WithEdgeKinds(req.GetStringSlice("edge_kinds", nil)),
+			engine.WithRecencyBoost(req.GetFloat("recency_boost", 0), engine.DefaultRecencyHalfLife),
+			engine.WithMinIncludedScore(req.GetFloat("min_score", 0)),
+			engine.WithBranch(req.GetString("branch", "")),
+		}
+		if req.GetBool("compact", false) {
+			opts = append(opts, engine.WithCompactContext())
+		}
+		if req.GetBool("decompose", false) {
+			opts = append(opts, engine.WithQueryDecomposition())
+		}
+		if expand, ok := req.GetArguments()["expand_generated"].(bool); ok {
+			opts = append(opts, engine.WithGeneratedExpansion(expand))
+		}
+		if cache != nil {
+			opts = append(opts, engine.WithContextCache(cache))
+		}
+
 		// Single query — simple path
 		if len(queries) == 1 {
-			assembled, err := engine.AssembleContext(ctx, pool, client, queries[0], projectID, maxTokens)
+			assembled, err := engine.AssembleContext(ctx, pool, client, queries[0], projectID, maxTokens, opts...)
 			if err != nil {
 				return mcp.NewToolResultError(fmt.Sprintf("explore failed: %v", err)), nil
 			}
 			return mcp.NewToolResultText(assembled.Text), nil
 		}
 
-		// Multiple queries — run each, concatenate results with headers
+		// Multiple queries — run each, join the results in the chosen format
 		perQueryBudget := maxTokens / len(queries)
 		if perQueryBudget < 2000 {
 			perQueryBudget = 2000
 		}
 
-		var b strings.Builder
-		for i, q := range queries {
-			assembled, err := engine.AssembleContext(ctx, pool, client, q, projectID, perQueryBudget)
-			if err != nil {
-				b.WriteString(fmt.Sprintf("## Query %d: %s\n\nError: %v\n\n", i+1, q, err))
-				continue
+		batch := make([]engine.QueryContext, 0, len(queries))
+		for _, q := range queries {
+			qc := engine.QueryContext{Query: q}
+			if assembled, err := engine.AssembleContext(ctx, pool, client, q, projectID, perQueryBudget, opts...); err != nil {
+				qc.Err = err
+			} else {
+				qc.Text = assembled.Text
 			}
-			b.WriteString(fmt.Sprintf("## Query %d: %s\n\n", i+1, q))
-			b.WriteString(assembled.Text)
-			b.WriteString("\n\n---\n\n")
+			batch = append(batch, qc)
 		}
 
-		return mcp.NewToolResultText(b.String()), nil
+		return mcp.NewToolResultText(engine.FormatBatch(formatter, batch)), nil
 	}
 }
 
@@ -110,15 +110,38 @@ func DeleteProject(ctx context.Context, pool *pgxpool.Pool, id string) error {
 	return nil
 }
 
+// IsRemotePath reports whether a source path is a git URL rather than a local directory.
+// Supports https://, http://, ssh://, git:// and scp-style (git@host:org/repo) forms.
+func IsRemotePath(path string) bool {
+	for _, prefix := range []string{"https://", "http://", "ssh://", "git://"} {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	// scp-style: user@host:path (but not a Windows drive or local path)
+	if at := strings.Index(path, "@"); at > 0 && !strings.HasPrefix(path, "/") {
+		colon := strings.Index(path[at:], ":")
+		return colon > 1
+	}
+	return false
+}
+
 func AddSource(ctx context.Context, pool *pgxpool.Pool, projectID, path, sourceType string, isCode bool, alias string) (*ProjectSource, error) {
-	if _, err := os.Stat(path); err != nil {
-		return nil, fmt.Errorf("path does not exist: %s", path)
+	remote := IsRemotePath(path)
+	if !remote {
+		if _, err := os.Stat(path); err != nil {
+			return nil, fmt.Errorf("path does not exist: %s", path)
+		}
 	}
 
 	id := fmt.Sprintf("%s/%s", projectID, toSlug(alias))
 	if alias == "" {
 		// Use the last path segment as alias
-		parts := strings.Split(strings.TrimRight(path, "/"), "/")
+		trimmed := strings.TrimRight(path, "/")
+		if remote {
+			trimmed = strings.TrimSuffix(trimmed, ".git")
+		}
+		parts := strings.FieldsFunc(trimmed, func(r rune) bool { return r == '/' || r == ':' })
 		alias = parts[len(parts)-1]
 		id = fmt.Sprintf("%s/%s", projectID, toSlug(alias))
 	}
@@ -190,6 +213,23 @@ func DetectProjectByPath(ctx context.Context, pool *pgxpool.Pool, dirPath string
 	return nil, nil, nil
 }
 
+func GetSource(ctx context.Context, pool *pgxpool.Pool, sourceID string) (*ProjectSource, error) {
+	var s ProjectSource
+	err := pool.QueryRow(ctx,
+		`SELECT id, project_id, path, source_type, is_code, alias,
+		        last_indexed_commit, last_indexed_branch, last_indexed_at, added_at
+		 FROM project_sources WHERE id = $1`, sourceID,
+	).Scan(&s.ID, &s.ProjectID, &s.Path, &s.SourceType, &s.IsCode, &s.Alias,
+		&s.LastIndexedCommit, &s.LastIndexedBranch, &s.LastIndexedAt, &s.AddedAt)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting source: %w", err)
+	}
+	return &s, nil
+}
+
 func ListSources(ctx context.Context, pool *pgxpool.Pool, projectID string) ([]ProjectSource, error) {
 	rows, err := pool.Query(ctx,
 		`SELECT id, project_id, path, source_type, is_code, alias,
@@ -0,0 +1,51 @@
+/* Accounts and their orders.
+   /* nested comments are fine */ */
+
+-- Everyone who can sign in.
+CREATE TABLE IF NOT EXISTS users (
+    id BIGSERIAL PRIMARY KEY,
+    email TEXT NOT NULL UNIQUE -- lowercased on write
+);
+
+CREATE TABLE public."Orders" (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users (id),
+    note TEXT DEFAULT 'it''s; fine'
+);
+
+CREATE INDEX idx_orders_user ON public."Orders" (user_id);
+
+CREATE OR REPLACE VIEW active_users AS
+SELECT u.* FROM users u
+WHERE EXISTS (SELECT 1 FROM public."Orders" o WHERE o.user_id = u.id);
+
+-- Archives a user's orders.
+-- @deprecated use archive_orders_v2
+CREATE FUNCTION archive_orders(uid BIGINT) RETURNS void AS $body$
+DECLARE
+    n INT;
+BEGIN
+    SELECT count(*) INTO n FROM public."Orders" WHERE user_id = uid;
+    INSERT INTO order_archive SELECT * FROM public."Orders" WHERE user_id = uid;
+    DELETE FROM public."Orders" WHERE user_id = uid;
+END;
+$body$ LANGUAGE plpgsql;
+
+-- name: GetUser :one
+-- Looks a user up by email.
+SELECT id, email
+FROM users
+WHERE email = $1 AND extract(epoch FROM now()) > 0;
+
+-- name: ListRecentOrders :many
+WITH recent AS (
+    SELECT * FROM public."Orders" ORDER BY id DESC LIMIT 10
+)
+SELECT r.id, u.email
+FROM recent r
+JOIN users u ON u.id = r.user_id, generate_series(1, 2) g
+FOR UPDATE SKIP LOCKED;
+
+-- name: UpsertUser :exec
+INSERT INTO users (email) VALUES ($1)
+ON CONFLICT (email) DO UPDATE SET email = EXCLUDED.email;
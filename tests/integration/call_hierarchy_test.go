@@ -0,0 +1,197 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/maximilianfalco/mycelium/internal/engine"
+	"github.com/maximilianfalco/mycelium/internal/indexer"
+	"github.com/maximilianfalco/mycelium/internal/indexer/detectors"
+	"github.com/maximilianfalco/mycelium/internal/indexer/parsers"
+)
+
+// setupCallHierarchyTest builds a graph where one caller hits the same callee
+// from several lines:
+//
+//	main --calls(3, 5, 7)--> retry
+//	main --calls(4)--------> log
+//	loop --calls(12)-------> retry
+//	loop --calls(13)-------> axios.get (external)
+func setupCallHierarchyTest(t *testing.T) (context.Context, *pgxpool.Pool) {
+	t.Helper()
+	ctx, pool := setupGraphTest(t)
+
+	projectID := "test-callhier"
+	createTestProject(t, ctx, pool, projectID)
+	createTestSource(t, ctx, pool, projectID+"/src", projectID, "/tmp/test-callhier")
+
+	input := &indexer.BuildInput{
+		ProjectID:  projectID,
+		SourceID:   projectID + "/src",
+		SourcePath: "/tmp/test-callhier",
+		Workspace: &detectors.WorkspaceInfo{
+			WorkspaceType:  "standalone",
+			PackageManager: "npm",
+			Packages: []detectors.PackageInfo{
+				{Name: "app", Path: "src", Version: "1.0.0"},
+			},
+		},
+		Nodes: []parsers.NodeInfo{
+			{Name: "main", QualifiedName: "main", Kind: "function", StartLine: 1, EndLine: 8, BodyHash: "ch-1"},
+			{Name: "loop", QualifiedName: "loop", Kind: "function", StartLine: 10, EndLine: 14, BodyHash: "ch-2"},
+			{Name: "retry", QualifiedName: "retry", Kind: "function", StartLine: 1, EndLine: 6, BodyHash: "ch-3"},
+			{Name: "log", QualifiedName: "log", Kind: "function", StartLine: 8, EndLine: 9, BodyHash: "ch-4"},
+		},
+		Edges: []parsers.EdgeInfo{
+			{Source: "src/main.ts", Target: "main", Kind: "contains", Line: 1},
+			{Source: "src/main.ts", Target: "loop", Kind: "contains", Line: 10},
+			{Source: "src/util.ts", Target: "retry", Kind: "contains", Line: 1},
+			{Source: "src/util.ts", Target: "log", Kind: "contains", Line: 8},
+		},
+		Resolved: []indexer.ResolvedEdge{
+			{Source: "main", Target: "retry", Kind: "calls", Line: 7},
+			{Source: "main", Target: "retry", Kind: "calls", Line: 3},
+			{Source: "main", Target: "log", Kind: "calls", Line: 4},
+			{Source: "main", Target: "retry", Kind: "calls", Line: 5},
+			{Source: "main", Target: "retry", Kind: "calls", Line: 3},
+			{Source: "loop", Target: "retry", Kind: "calls", Line: 12},
+		},
+		Unresolved: []indexer.UnresolvedRef{
+			{Source: "loop", RawImport: "axios.get", Kind: "calls", Line: 13},
+		},
+		Embeddings: map[string][]float32{},
+		FilePaths:  []string{"src/main.ts", "src/util.ts"},
+	}
+
+	if _, err := indexer.BuildGraph(ctx, pool, input); err != nil {
+		t.Fatalf("BuildGraph: %v", err)
+	}
+	return ctx, pool
+}
+
+func TestIncomingCalls(t *testing.T) {
+	ctx, pool := setupCallHierarchyTest(t)
+
+	node, err := engine.FindNodeByQualifiedName(ctx, pool, "test-callhier", "retry")
+	if err != nil || node == nil {
+		t.Fatalf("FindNodeByQualifiedName: err=%v, node=%v", err, node)
+	}
+
+	calls, err := engine.IncomingCalls(ctx, pool, node.NodeID)
+	if err != nil {
+		t.Fatalf("IncomingCalls: %v", err)
+	}
+	if len(calls) != 2 {
+		t.Fatalf("expected 2 callers of retry, got %d", len(calls))
+	}
+
+	byCaller := make(map[string][]engine.CallRange)
+	for _, c := range calls {
+		byCaller[c.From.QualifiedName] = c.FromRanges
+	}
+	mainSites := byCaller["main"]
+	if len(mainSites) != 3 || mainSites[0].StartLine != 3 || mainSites[1].StartLine != 5 || mainSites[2].StartLine != 7 {
+		t.Errorf("expected main call sites [3 5 7], got %+v", mainSites)
+	}
+	if loop := byCaller["loop"]; len(loop) != 1 || loop[0].StartLine != 12 {
+		t.Errorf("expected loop call site [12], got %+v", loop)
+	}
+}
+
+func TestOutgoingCalls(t *testing.T) {
+	ctx, pool := setupCallHierarchyTest(t)
+
+	node, err := engine.FindNodeByQualifiedName(ctx, pool, "test-callhier", "main")
+	if err != nil || node == nil {
+		t.Fatalf("FindNodeByQualifiedName: err=%v, node=%v", err, node)
+	}
+
+	calls, err := engine.OutgoingCalls(ctx, pool, node.NodeID)
+	if err != nil {
+		t.Fatalf("OutgoingCalls: %v", err)
+	}
+	if len(calls) != 2 {
+		t.Fatalf("expected 2 callees of main, got %d", len(calls))
+	}
+
+	for _, c := range calls {
+		switch c.To.QualifiedName {
+		case "retry":
+			if len(c.FromRanges) != 3 {
+				t.Errorf("expected 3 call sites for retry, got %+v", c.FromRanges)
+			}
+			if c.To.FilePath != "src/util.ts" || c.To.StartLine != 1 || c.To.EndLine != 6 {
+				t.Errorf("unexpected callee item: %+v", c.To)
+			}
+		case "log":
+			if len(c.FromRanges) != 1 || c.FromRanges[0].StartLine != 4 {
+				t.Errorf("expected log call site [4], got %+v", c.FromRanges)
+			}
+		default:
+			t.Errorf("unexpected callee %q", c.To.QualifiedName)
+		}
+	}
+}
+
+func TestIncomingCalls_NoneExist(t *testing.T) {
+	ctx, pool := setupCallHierarchyTest(t)
+
+	node, err := engine.FindNodeByQualifiedName(ctx, pool, "test-callhier", "main")
+	if err != nil || node == nil {
+		t.Fatalf("FindNodeByQualifiedName: err=%v, node=%v", err, node)
+	}
+
+	calls, err := engine.IncomingCalls(ctx, pool, node.NodeID)
+	if err != nil {
+		t.Fatalf("IncomingCalls: %v", err)
+	}
+	if len(calls) != 0 {
+		t.Errorf("expected no callers of main, got %d", len(calls))
+	}
+}
+
+func TestFindCallersMatching(t *testing.T) {
+	ctx, pool := setupCallHierarchyTest(t)
+
+	site := func(c engine.CallEdge) string {
+		return fmt.Sprintf("%s:%d %s->%s", c.FilePath, c.Line, c.CallerQName, c.Callee)
+	}
+	tests := []struct {
+		pattern string
+		opts    []engine.CallerMatchOption
+		want    []string
+	}{
+		{"retry", nil, []string{
+			"src/main.ts:3 main->retry", "src/main.ts:5 main->retry", "src/main.ts:7 main->retry", "src/main.ts:12 loop->retry",
+		}},
+		{"re?ry", nil, []string{
+			"src/main.ts:3 main->retry", "src/main.ts:5 main->retry", "src/main.ts:7 main->retry", "src/main.ts:12 loop->retry",
+		}},
+		{"l*", nil, []string{"src/main.ts:4 main->log"}},
+		// External calls match as written, and by last segment without a dot
+		{"axios.*", nil, []string{"src/main.ts:13 loop->axios.get"}},
+		{"get", nil, []string{"src/main.ts:13 loop->axios.get"}},
+		{"*", []engine.CallerMatchOption{engine.ExternalCalleesOnly()}, []string{"src/main.ts:13 loop->axios.get"}},
+		// LIKE wildcards in the pattern are literal
+		{"re%", nil, nil},
+	}
+	for _, tt := range tests {
+		calls, err := engine.FindCallersMatching(ctx, pool, "test-callhier", tt.pattern, tt.opts...)
+		if err != nil {
+			t.Fatalf("FindCallersMatching(%q): %v", tt.pattern, err)
+		}
+		var got []string
+		for _, c := range calls {
+			got = append(got, site(c))
+			if c.External != (c.CalleeID == "") {
+				t.Errorf("%s: External=%v but CalleeID=%q", site(c), c.External, c.CalleeID)
+			}
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("FindCallersMatching(%q) = %v, want %v", tt.pattern, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package integration
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/maximilianfalco/mycelium/internal/config"
+	"github.com/maximilianfalco/mycelium/internal/engine"
+	"github.com/maximilianfalco/mycelium/internal/indexer"
+)
+
+func TestGetNodesByOwner(t *testing.T) {
+	ctx, pool := setupGraphTest(t)
+	dir := t.TempDir()
+	write := func(rel, content string) {
+		t.Helper()
+		abs := filepath.Join(dir, rel)
+		os.MkdirAll(filepath.Dir(abs), 0o755)
+		if err := os.WriteFile(abs, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(".github/CODEOWNERS", "*  @org/core\n/src/api/  @org/backend @alice\n")
+	write("src/api/server.ts", "export class Server {\n  start() {\n    return 1;\n  }\n}\n")
+	write("src/util.ts", "export function helper() {\n  return 1;\n}\n")
+
+	projectID, sourceID := "test-owners", "test-owners-source"
+	createTestProject(t, ctx, pool, projectID)
+	createTestSource(t, ctx, pool, sourceID, projectID, dir)
+
+	if result := indexer.IndexProject(ctx, pool, &config.Config{}, nil, projectID, nil, true); len(result.Errors) > 0 {
+		t.Fatalf("index failed: %v", result.Errors)
+	}
+
+	qnames := func(nodes []engine.NodeResult) []string {
+		var names []string
+		for _, n := range nodes {
+			names = append(names, n.QualifiedName)
+		}
+		slices.Sort(names)
+		return names
+	}
+
+	// Methods take the owners of their class's file; the @ is optional
+	backend, err := engine.GetNodesByOwner(ctx, pool, projectID, "ORG/backend")
+	if err != nil {
+		t.Fatalf("GetNodesByOwner: %v", err)
+	}
+	if got := qnames(backend); !slices.Equal(got, []string{"Server", "Server.start"}) {
+		t.Errorf("expected the api file's class and method, got %v", got)
+	}
+	for _, n := range backend {
+		if !slices.Equal(n.Owners, []string{"@org/backend", "@alice"}) {
+			t.Errorf("expected %s owned by backend and alice, got %v", n.QualifiedName, n.Owners)
+		}
+	}
+
+	core, err := engine.GetNodesByOwner(ctx, pool, projectID, "@org/core")
+	if err != nil {
+		t.Fatalf("GetNodesByOwner: %v", err)
+	}
+	if got := qnames(core); !slices.Equal(got, []string{"helper"}) {
+		t.Errorf("expected the last match to win for src/api, got %v", got)
+	}
+
+	node, err := engine.FindNodeByQualifiedName(ctx, pool, projectID, "helper")
+	if err != nil || node == nil {
+		t.Fatalf("FindNodeByQualifiedName: %v", err)
+	}
+	if !slices.Equal(node.Owners, []string{"@org/core"}) {
+		t.Errorf("expected NodeResult to carry owners, got %v", node.Owners)
+	}
+
+	// Removing CODEOWNERS clears owners on the next run
+	os.Remove(filepath.Join(dir, ".github/CODEOWNERS"))
+	if result := indexer.IndexProject(ctx, pool, &config.Config{}, nil, projectID, nil, true); len(result.Errors) > 0 {
+		t.Fatalf("reindex failed: %v", result.Errors)
+	}
+	core, err = engine.GetNodesByOwner(ctx, pool, projectID, "@org/core")
+	if err != nil {
+		t.Fatalf("GetNodesByOwner: %v", err)
+	}
+	if len(core) != 0 {
+		t.Errorf("expected no owned nodes without CODEOWNERS, got %v", qnames(core))
+	}
+}
@@ -0,0 +1,95 @@
+package integration
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/maximilianfalco/mycelium/internal/indexer"
+	"github.com/maximilianfalco/mycelium/internal/indexer/detectors"
+	"github.com/maximilianfalco/mycelium/internal/indexer/parsers"
+)
+
+func TestGetNodesModifiedInCommitRange(t *testing.T) {
+	ctx, pool := setupGraphTest(t)
+
+	repo := t.TempDir()
+	git := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(repo, "src", "app.ts"), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git("init")
+	git("config", "user.email", "test@test.com")
+	git("config", "user.name", "Test")
+	os.MkdirAll(filepath.Join(repo, "src"), 0o755)
+
+	write("export function main() {\n  return helper()\n}\n\nexport function helper() {\n  return 1\n}\n\nexport class Store {\n  save() {\n    return 1\n  }\n}\n")
+	git("add", ".")
+	git("commit", "-m", "initial")
+	from := git("rev-parse", "HEAD")
+
+	// Change helper's body and Store.save's, leave main alone
+	write("export function main() {\n  return helper()\n}\n\nexport function helper() {\n  return 2\n}\n\nexport class Store {\n  save() {\n    return 2\n  }\n}\n")
+	git("commit", "-am", "change helper and save")
+	to := git("rev-parse", "HEAD")
+
+	projectID := "test-commit-range"
+	sourceID := projectID + "/src"
+	createTestProject(t, ctx, pool, projectID)
+	createTestSource(t, ctx, pool, sourceID, projectID, repo)
+	result, err := indexer.BuildGraph(ctx, pool, &indexer.BuildInput{
+		ProjectID:  projectID,
+		SourceID:   sourceID,
+		SourcePath: repo,
+		Workspace: &detectors.WorkspaceInfo{
+			WorkspaceType: "standalone",
+			Packages:      []detectors.PackageInfo{{Name: "app", Path: "."}},
+		},
+		Nodes: []parsers.NodeInfo{
+			{Name: "main", QualifiedName: "main", Kind: "function", StartLine: 1, EndLine: 3, BodyHash: "h1"},
+			{Name: "helper", QualifiedName: "helper", Kind: "function", StartLine: 5, EndLine: 7, BodyHash: "h2"},
+			{Name: "Store", QualifiedName: "Store", Kind: "class", StartLine: 9, EndLine: 13, BodyHash: "h3"},
+			{Name: "save", QualifiedName: "Store.save", Kind: "method", StartLine: 10, EndLine: 12, BodyHash: "h4"},
+		},
+		Edges: []parsers.EdgeInfo{
+			{Source: "src/app.ts", Target: "main", Kind: "contains", Line: 1},
+			{Source: "src/app.ts", Target: "helper", Kind: "contains", Line: 5},
+			{Source: "src/app.ts", Target: "Store", Kind: "contains", Line: 9},
+			{Source: "Store", Target: "Store.save", Kind: "contains", Line: 10},
+		},
+		Embeddings: map[string][]float32{},
+		FilePaths:  []string{"src/app.ts"},
+	})
+	if err != nil {
+		t.Fatalf("BuildGraph: %v", err)
+	}
+
+	ids, err := indexer.GetNodesModifiedInCommitRange(ctx, pool, result.WorkspaceID, repo, from, to)
+	if err != nil {
+		t.Fatalf("GetNodesModifiedInCommitRange: %v", err)
+	}
+	want := []string{
+		indexer.NodeID(result.WorkspaceID, "Store", "Store.save"),
+		indexer.NodeID(result.WorkspaceID, "src/app.ts", "Store"),
+		indexer.NodeID(result.WorkspaceID, "src/app.ts", "helper"),
+	}
+	slices.Sort(want)
+	if !slices.Equal(ids, want) {
+		t.Errorf("modified = %v, want %v", ids, want)
+	}
+}
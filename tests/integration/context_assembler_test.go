@@ -305,6 +305,37 @@ func TestAssembleContext_Annotations(t *testing.T) {
 	}
 }
 
+func TestAssembleContext_AnnotationsPerNode(t *testing.T) {
+	ctx, pool := setupContextTest(t)
+
+	// Annotations are fetched in bulk, so each node must get only its own
+	queryVec := makeUnitVector(1536, 0)
+	result, err := engine.AssembleContextWithVector(ctx, pool, queryVec, "test-ctx", 8000)
+	if err != nil {
+		t.Fatalf("AssembleContextWithVector: %v", err)
+	}
+
+	byName := make(map[string]engine.ContextNode)
+	for _, n := range result.Nodes {
+		byName[n.QualifiedName] = n
+	}
+	auth, ok := byName["authenticate"]
+	if !ok || !auth.Annotated {
+		t.Fatalf("expected authenticate annotated, got %+v", result.Nodes)
+	}
+	if len(auth.CalledBy) != 1 || auth.CalledBy[0] != "queryUsers" {
+		t.Errorf("authenticate called by = %v, want [queryUsers]", auth.CalledBy)
+	}
+	if len(auth.Calls) != 2 {
+		t.Errorf("authenticate calls = %v, want verifyPassword and generateToken", auth.Calls)
+	}
+	if verify, ok := byName["verifyPassword"]; ok && verify.Annotated {
+		if len(verify.CalledBy) != 1 || verify.CalledBy[0] != "authenticate" || len(verify.Calls) != 0 {
+			t.Errorf("verifyPassword annotations = %v / %v, want called by authenticate only", verify.CalledBy, verify.Calls)
+		}
+	}
+}
+
 func TestAssembleContext_DefaultMaxTokens(t *testing.T) {
 	ctx, pool := setupContextTest(t)
 
@@ -348,6 +379,44 @@ func TestAssembleContext_SimilarityInOutput(t *testing.T) {
 	}
 }
 
+func TestAssembleContextForChange(t *testing.T) {
+	ctx, pool := setupContextTest(t)
+
+	// A diff touching auth.ts seeds authenticate, expands to its callees and
+	// its caller, and leaves the unrelated Logger out.
+	result, err := engine.AssembleContextForChange(ctx, pool, "test-ctx", []string{"./src/auth.ts"}, 8000)
+	if err != nil {
+		t.Fatalf("AssembleContextForChange: %v", err)
+	}
+	if len(result.Nodes) == 0 || result.Nodes[0].QualifiedName != "authenticate" {
+		t.Fatalf("expected the changed node to rank first, got %+v", result.Nodes)
+	}
+	names := make(map[string]bool)
+	for _, n := range result.Nodes {
+		names[n.QualifiedName] = true
+	}
+	for _, want := range []string{"verifyPassword", "generateToken", "queryUsers"} {
+		if !names[want] {
+			t.Errorf("expected graph expansion to include %q", want)
+		}
+	}
+	if names["Logger"] {
+		t.Error("expected Logger to stay out of a diff that doesn't touch it")
+	}
+
+	// Files with no indexed nodes give an empty context, no files an error
+	empty, err := engine.AssembleContextForChange(ctx, pool, "test-ctx", []string{"README.md"}, 8000)
+	if err != nil {
+		t.Fatalf("AssembleContextForChange: %v", err)
+	}
+	if len(empty.Nodes) != 0 {
+		t.Errorf("expected no nodes for an unindexed file, got %d", len(empty.Nodes))
+	}
+	if _, err := engine.AssembleContextForChange(ctx, pool, "test-ctx", nil, 8000); err == nil {
+		t.Error("expected an error without changed files")
+	}
+}
+
 // setupMultiSourceContextTest creates a project with TWO sources (repo-a, repo-b).
 // repo-a has: exportedFunc (dim 0)
 // repo-b has: consumerFunc (dim 1) which calls exportedFunc (cross-source edge)
@@ -0,0 +1,88 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/maximilianfalco/mycelium/internal/engine"
+	"github.com/maximilianfalco/mycelium/internal/indexer"
+	"github.com/maximilianfalco/mycelium/internal/indexer/detectors"
+	"github.com/maximilianfalco/mycelium/internal/indexer/parsers"
+)
+
+func TestFindDuplicateClusters(t *testing.T) {
+	ctx, pool := setupGraphTest(t)
+
+	projectID := "test-dupes"
+	createTestProject(t, ctx, pool, projectID)
+	createTestSource(t, ctx, pool, projectID+"/src", projectID, "/tmp/test-dupes")
+
+	// parseA, parseB are identical; parseC is close to both; render is unrelated.
+	nearVec := makeUnitVector(1536, 0)
+	nearVec[1] = 0.2
+
+	input := &indexer.BuildInput{
+		ProjectID:  projectID,
+		SourceID:   projectID + "/src",
+		SourcePath: "/tmp/test-dupes",
+		Workspace: &detectors.WorkspaceInfo{
+			WorkspaceType:  "standalone",
+			PackageManager: "npm",
+			Packages: []detectors.PackageInfo{
+				{Name: "app", Path: "src", Version: "1.0.0"},
+			},
+		},
+		Nodes: []parsers.NodeInfo{
+			{Name: "parseA", QualifiedName: "parseA", Kind: "function", StartLine: 1, EndLine: 20, BodyHash: "d-1"},
+			{Name: "parseB", QualifiedName: "parseB", Kind: "function", StartLine: 1, EndLine: 20, BodyHash: "d-2"},
+			{Name: "parseC", QualifiedName: "parseC", Kind: "function", StartLine: 1, EndLine: 18, BodyHash: "d-3"},
+			{Name: "render", QualifiedName: "render", Kind: "function", StartLine: 1, EndLine: 30, BodyHash: "d-4"},
+		},
+		Edges: []parsers.EdgeInfo{
+			{Source: "src/a.ts", Target: "parseA", Kind: "contains", Line: 1},
+			{Source: "src/b.ts", Target: "parseB", Kind: "contains", Line: 1},
+			{Source: "src/c.ts", Target: "parseC", Kind: "contains", Line: 1},
+			{Source: "src/view.ts", Target: "render", Kind: "contains", Line: 1},
+		},
+		Embeddings: map[string][]float32{
+			"parseA": makeUnitVector(1536, 0),
+			"parseB": makeUnitVector(1536, 0),
+			"parseC": nearVec,
+			"render": makeUnitVector(1536, 5),
+		},
+		FilePaths: []string{"src/a.ts", "src/b.ts", "src/c.ts", "src/view.ts"},
+	}
+	if _, err := indexer.BuildGraph(ctx, pool, input); err != nil {
+		t.Fatalf("BuildGraph: %v", err)
+	}
+
+	clusters, err := engine.FindDuplicateClusters(ctx, pool, projectID, 0.9, 2)
+	if err != nil {
+		t.Fatalf("FindDuplicateClusters: %v", err)
+	}
+	if len(clusters) != 1 {
+		t.Fatalf("expected 1 cluster, got %d: %+v", len(clusters), clusters)
+	}
+	c := clusters[0]
+	if len(c.Nodes) != 3 {
+		t.Fatalf("expected 3 members, got %+v", c.Nodes)
+	}
+	if c.Nodes[0].QualifiedName != "parseA" || c.Nodes[0].FilePath != "src/a.ts" || c.Nodes[0].EndLine != 20 {
+		t.Errorf("unexpected first member: %+v", c.Nodes[0])
+	}
+	if c.MaxSimilarity < 0.99 || c.MinSimilarity >= c.MaxSimilarity {
+		t.Errorf("unexpected similarity range %v-%v", c.MinSimilarity, c.MaxSimilarity)
+	}
+
+	// Only the identical pair survives a stricter threshold.
+	strict, err := engine.FindDuplicateClusters(ctx, pool, projectID, 0.999, 2)
+	if err != nil {
+		t.Fatalf("FindDuplicateClusters: %v", err)
+	}
+	if len(strict) != 1 || len(strict[0].Nodes) != 2 {
+		t.Errorf("expected one pair at threshold 0.999, got %+v", strict)
+	}
+
+	if none, _ := engine.FindDuplicateClusters(ctx, pool, projectID, 0.9, 4); len(none) != 0 {
+		t.Errorf("expected no clusters of 4+, got %+v", none)
+	}
+}
@@ -0,0 +1,82 @@
+package integration
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/maximilianfalco/mycelium/internal/config"
+	"github.com/maximilianfalco/mycelium/internal/indexer"
+)
+
+func TestRebuildEdges_OnlySelectedKinds(t *testing.T) {
+	ctx, pool := setupGraphTest(t)
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "util.ts"), []byte("export function helper() {\n  return 1;\n}\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "main.ts"), []byte("import { helper } from './util';\n\nexport function run() {\n  return helper();\n}\n"), 0o644)
+
+	projectID, sourceID := "test-rebuild-edges", "test-rebuild-edges-source"
+	createTestProject(t, ctx, pool, projectID)
+	createTestSource(t, ctx, pool, sourceID, projectID, dir)
+
+	cfg := &config.Config{}
+	if result := indexer.IndexProject(ctx, pool, cfg, nil, projectID, nil, true); len(result.Errors) > 0 {
+		t.Fatalf("index failed: %v", result.Errors)
+	}
+
+	countEdges := func(kind string) int {
+		t.Helper()
+		var n int
+		if err := pool.QueryRow(ctx, `
+			SELECT COUNT(*) FROM edges e
+			JOIN nodes s ON e.source_id = s.id
+			WHERE s.workspace_id = $1 AND e.kind = $2`,
+			indexer.WorkspaceID(projectID, sourceID), kind).Scan(&n); err != nil {
+			t.Fatalf("counting %s edges: %v", kind, err)
+		}
+		return n
+	}
+	calls, imports := countEdges("calls"), countEdges("imports")
+	if calls == 0 || imports == 0 {
+		t.Fatalf("expected calls and imports edges after indexing, got %d and %d", calls, imports)
+	}
+
+	// Lose the calls edges and tamper with the imports ones, as an index
+	// built before an extractor existed would look
+	if _, err := pool.Exec(ctx, `DELETE FROM edges WHERE kind = 'calls'`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pool.Exec(ctx, `UPDATE edges SET weight = 0.01 WHERE kind = 'imports'`); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := indexer.RebuildEdges(ctx, pool, cfg, projectID, []string{"calls"})
+	if err != nil {
+		t.Fatalf("RebuildEdges: %v", err)
+	}
+	if result.SourcesProcessed != 1 || result.EdgesUpserted != calls {
+		t.Errorf("expected %d calls edges rewritten in 1 source, got %+v", calls, result)
+	}
+	if got := countEdges("calls"); got != calls {
+		t.Errorf("expected %d calls edges back, got %d", calls, got)
+	}
+
+	var weight float64
+	if err := pool.QueryRow(ctx, `SELECT MAX(weight) FROM edges WHERE kind = 'imports'`).Scan(&weight); err != nil {
+		t.Fatal(err)
+	}
+	if weight != 0.01 {
+		t.Errorf("expected imports edges to be left alone, got weight %v", weight)
+	}
+
+	// Without kinds every edge is rebuilt
+	if _, err := indexer.RebuildEdges(ctx, pool, cfg, projectID, nil); err != nil {
+		t.Fatalf("RebuildEdges: %v", err)
+	}
+	if err := pool.QueryRow(ctx, `SELECT MAX(weight) FROM edges WHERE kind = 'imports'`).Scan(&weight); err != nil {
+		t.Fatal(err)
+	}
+	if weight == 0.01 {
+		t.Error("expected a full rebuild to rewrite imports edges")
+	}
+}
@@ -0,0 +1,98 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/csv"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/maximilianfalco/mycelium/internal/engine"
+	"github.com/maximilianfalco/mycelium/internal/indexer"
+	"github.com/maximilianfalco/mycelium/internal/indexer/detectors"
+	"github.com/maximilianfalco/mycelium/internal/indexer/parsers"
+)
+
+func TestExportCSV(t *testing.T) {
+	ctx, pool := setupGraphTest(t)
+
+	projectID := "test-export-csv"
+	createTestProject(t, ctx, pool, projectID)
+	createTestSource(t, ctx, pool, projectID+"/src", projectID, "/tmp/test-export-csv")
+
+	input := &indexer.BuildInput{
+		ProjectID:  projectID,
+		SourceID:   projectID + "/src",
+		SourcePath: "/tmp/test-export-csv",
+		Workspace: &detectors.WorkspaceInfo{
+			WorkspaceType: "standalone",
+			Packages:      []detectors.PackageInfo{{Name: "core", Path: "."}},
+		},
+		Nodes: []parsers.NodeInfo{
+			{Name: "createClient", QualifiedName: "createClient", Kind: "function", StartLine: 1, EndLine: 5, BodyHash: "h1", Exported: true,
+				SourceCode: "function createClient(a, b) {\n  if (a && b) {\n    return helper();\n  }\n}"},
+			{Name: "helper", QualifiedName: "helper", Kind: "function", StartLine: 7, EndLine: 7, BodyHash: "h2"},
+			{Name: "testClient", QualifiedName: "testClient", Kind: "function", StartLine: 1, EndLine: 3, BodyHash: "h3"},
+		},
+		Edges: []parsers.EdgeInfo{
+			{Source: "src/client.ts", Target: "createClient", Kind: "contains", Line: 1},
+			{Source: "src/client.ts", Target: "helper", Kind: "contains", Line: 7},
+			{Source: "src/client.test.ts", Target: "testClient", Kind: "contains", Line: 1},
+		},
+		Resolved: []indexer.ResolvedEdge{
+			{Source: "createClient", Target: "helper", Kind: "calls", Line: 3},
+			{Source: "testClient", Target: "createClient", Kind: "calls", Line: 2},
+		},
+		Embeddings: map[string][]float32{},
+		FilePaths:  []string{"src/client.ts", "src/client.test.ts"},
+	}
+	if _, err := indexer.BuildGraph(ctx, pool, input); err != nil {
+		t.Fatalf("BuildGraph: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := engine.ExportNodesCSV(ctx, pool, projectID, &buf); err != nil {
+		t.Fatalf("ExportNodesCSV: %v", err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("reading nodes CSV: %v", err)
+	}
+	if got := strings.Join(records[0], ","); got != "id,name,qualified_name,kind,file,package,start_line,end_line,lines,complexity,in_degree,out_degree,centrality,exported,is_test" {
+		t.Fatalf("unexpected header %s", got)
+	}
+	rows := records[1:]
+	if len(rows) != 3 {
+		t.Fatalf("expected 3 rows, got %d: %v", len(rows), rows)
+	}
+	if !slices.IsSortedFunc(rows, func(a, b []string) int { return strings.Compare(a[0], b[0]) }) {
+		t.Error("expected rows ordered by id")
+	}
+
+	byName := make(map[string][]string)
+	for _, row := range rows {
+		byName[row[2]] = row
+	}
+	// createClient: called once, calls once; 1 + if + && decision points
+	if got := byName["createClient"]; strings.Join(got[4:], ",") != "src/client.ts,core,1,5,5,3,1,1,1.000000,true,false" {
+		t.Errorf("unexpected createClient row %v", got)
+	}
+	if got := byName["testClient"]; got[10] != "0" || got[11] != "1" || got[14] != "true" {
+		t.Errorf("expected testClient with one outgoing call in a test file, got %v", got)
+	}
+	if got := byName["helper"]; got[9] != "1" || got[10] != "1" || got[11] != "0" || got[13] != "false" {
+		t.Errorf("expected an unexported helper of complexity 1 called once, got %v", got)
+	}
+
+	buf.Reset()
+	if err := engine.ExportEdgesCSV(ctx, pool, projectID, &buf); err != nil {
+		t.Fatalf("ExportEdgesCSV: %v", err)
+	}
+	records, err = csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("reading edges CSV: %v", err)
+	}
+	if len(records) != 3 || records[1][2] != "calls" || records[1][4] == "0" {
+		t.Errorf("expected 2 calls edges with lines, got %v", records)
+	}
+}
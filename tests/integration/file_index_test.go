@@ -0,0 +1,115 @@
+package integration
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/maximilianfalco/mycelium/internal/config"
+	"github.com/maximilianfalco/mycelium/internal/indexer"
+)
+
+func TestIndexFile(t *testing.T) {
+	ctx, pool := setupGraphTest(t)
+	dir := t.TempDir()
+	write := func(rel, content string) {
+		t.Helper()
+		abs := filepath.Join(dir, rel)
+		os.MkdirAll(filepath.Dir(abs), 0o755)
+		if err := os.WriteFile(abs, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("src/util.ts", "export function helper() {\n  return 1;\n}\n")
+	write("src/main.ts", `import { helper } from "./util";
+
+export function run() {
+  return helper();
+}
+
+export function old() {
+  return 2;
+}
+`)
+
+	projectID, sourceID := "test-fi", "test-fi-source"
+	createTestProject(t, ctx, pool, projectID)
+	createTestSource(t, ctx, pool, sourceID, projectID, dir)
+	cfg := &config.Config{}
+
+	if result := indexer.IndexProject(ctx, pool, cfg, nil, projectID, nil, true); len(result.Errors) > 0 {
+		t.Fatalf("initial index failed: %v", result.Errors)
+	}
+
+	fileNodes := func(file string) []string {
+		t.Helper()
+		rows, err := pool.Query(ctx,
+			`SELECT qualified_name FROM nodes WHERE workspace_id = $1 AND file_path = $2 ORDER BY qualified_name`,
+			projectID+"/"+sourceID, file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+		var names []string
+		for rows.Next() {
+			var n string
+			rows.Scan(&n)
+			names = append(names, n)
+		}
+		return names
+	}
+
+	write("src/main.ts", `import { helper } from "./util";
+
+export function run() {
+  return helper();
+}
+
+export function fresh() {
+  return 3;
+}
+`)
+	result, err := indexer.IndexFile(ctx, pool, cfg, nil, sourceID, "src/main.ts")
+	if err != nil {
+		t.Fatalf("IndexFile: %v", err)
+	}
+	if result.NodesUpserted != 2 || result.NodesDeleted != 1 {
+		t.Errorf("expected 2 upserted and 1 deleted, got %+v", result)
+	}
+	if got := fileNodes("src/main.ts"); !slices.Equal(got, []string{"fresh", "run"}) {
+		t.Errorf("expected main.ts to hold fresh and run, got %v", got)
+	}
+	if got := fileNodes("src/util.ts"); !slices.Equal(got, []string{"helper"}) {
+		t.Errorf("expected util.ts to be untouched, got %v", got)
+	}
+
+	// The call into the unchanged file resolves against the stored graph
+	var calls int
+	pool.QueryRow(ctx, `
+		SELECT COUNT(*) FROM edges e
+		JOIN nodes s ON s.id = e.source_id
+		JOIN nodes t ON t.id = e.target_id
+		WHERE s.workspace_id = $1 AND s.qualified_name = 'run' AND t.qualified_name = 'helper' AND e.kind = 'calls'`,
+		projectID+"/"+sourceID,
+	).Scan(&calls)
+	if calls != 1 {
+		t.Errorf("expected run -> helper calls edge, found %d", calls)
+	}
+
+	os.Remove(filepath.Join(dir, "src/main.ts"))
+	result, err = indexer.IndexFile(ctx, pool, cfg, nil, sourceID, "src/main.ts")
+	if err != nil {
+		t.Fatalf("IndexFile after delete: %v", err)
+	}
+	if !result.Deleted || result.NodesDeleted != 2 {
+		t.Errorf("expected both nodes deleted, got %+v", result)
+	}
+	if got := fileNodes("src/main.ts"); len(got) != 0 {
+		t.Errorf("expected no nodes left in main.ts, got %v", got)
+	}
+
+	if _, err := indexer.IndexFile(ctx, pool, cfg, nil, sourceID, "../outside.ts"); err == nil {
+		t.Error("expected paths outside the source to be rejected")
+	}
+}
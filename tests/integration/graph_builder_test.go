@@ -4,9 +4,11 @@ import (
 	"context"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/maximilianfalco/mycelium/internal/db"
+	"github.com/maximilianfalco/mycelium/internal/engine"
 	"github.com/maximilianfalco/mycelium/internal/indexer"
 	"github.com/maximilianfalco/mycelium/internal/indexer/detectors"
 	"github.com/maximilianfalco/mycelium/internal/indexer/parsers"
@@ -147,6 +149,39 @@ func TestBuildGraph_Basic(t *testing.T) {
 	}
 }
 
+func TestBuildGraph_BumpsGraphVersion(t *testing.T) {
+	ctx, pool := setupGraphTest(t)
+	createTestProject(t, ctx, pool, "test-gb")
+	createTestSource(t, ctx, pool, "test-gb/test-source", "test-gb", "/tmp/test-repo")
+
+	version := func() int64 {
+		t.Helper()
+		var v int64
+		if err := pool.QueryRow(ctx, "SELECT graph_version FROM projects WHERE id = $1", "test-gb").Scan(&v); err != nil {
+			t.Fatalf("reading graph version: %v", err)
+		}
+		return v
+	}
+
+	before := version()
+	result, err := indexer.BuildGraph(ctx, pool, testBuildInput())
+	if err != nil {
+		t.Fatalf("BuildGraph: %v", err)
+	}
+	afterBuild := version()
+	if afterBuild <= before {
+		t.Errorf("expected BuildGraph to bump the graph version, got %d then %d", before, afterBuild)
+	}
+
+	// Deletes with no BuildGraph still bump it, so other processes' caches notice
+	if _, err := indexer.CleanupStale(ctx, pool, result.WorkspaceID, []string{"src/index.ts"}); err != nil {
+		t.Fatalf("CleanupStale: %v", err)
+	}
+	if after := version(); after <= afterBuild {
+		t.Errorf("expected CleanupStale to bump the graph version, got %d then %d", afterBuild, after)
+	}
+}
+
 func TestBuildGraph_Idempotent(t *testing.T) {
 	ctx, pool := setupGraphTest(t)
 	createTestProject(t, ctx, pool, "test-gb-idem")
@@ -177,6 +212,61 @@ func TestBuildGraph_Idempotent(t *testing.T) {
 	}
 }
 
+func TestBuildGraph_BulkLoadMatchesBatched(t *testing.T) {
+	ctx, pool := setupGraphTest(t)
+	createTestProject(t, ctx, pool, "test-gb-bulk")
+	createTestSource(t, ctx, pool, "test-gb-bulk/test-source", "test-gb-bulk", "/tmp/test-repo")
+
+	input := testBuildInput()
+	input.ProjectID = "test-gb-bulk"
+	input.SourceID = "test-gb-bulk/test-source"
+	// A repeated node must not trip the single-statement merge
+	input.Nodes = append(input.Nodes, input.Nodes[0])
+
+	counts := func(workspaceID string) (nodes, edges int) {
+		t.Helper()
+		if err := pool.QueryRow(ctx, "SELECT COUNT(*) FROM nodes WHERE workspace_id = $1", workspaceID).Scan(&nodes); err != nil {
+			t.Fatalf("counting nodes: %v", err)
+		}
+		if err := pool.QueryRow(ctx, `
+			SELECT COUNT(*) FROM edges e JOIN nodes n ON n.id = e.source_id
+			WHERE n.workspace_id = $1`, workspaceID).Scan(&edges); err != nil {
+			t.Fatalf("counting edges: %v", err)
+		}
+		return nodes, edges
+	}
+
+	// The workspace starts empty, so this build takes the COPY path
+	first, err := indexer.BuildGraph(ctx, pool, input)
+	if err != nil {
+		t.Fatalf("bulk BuildGraph: %v", err)
+	}
+	bulkNodes, bulkEdges := counts(first.WorkspaceID)
+	if bulkNodes != 3 {
+		t.Errorf("expected 3 nodes after bulk load, got %d", bulkNodes)
+	}
+
+	// Rebuilding the now-populated workspace goes through the batches
+	if _, err := indexer.BuildGraph(ctx, pool, input); err != nil {
+		t.Fatalf("batched BuildGraph: %v", err)
+	}
+	batchNodes, batchEdges := counts(first.WorkspaceID)
+	if bulkNodes != batchNodes || bulkEdges != batchEdges {
+		t.Errorf("bulk wrote %d nodes/%d edges, batched %d/%d", bulkNodes, bulkEdges, batchNodes, batchEdges)
+	}
+
+	var line int
+	err = pool.QueryRow(ctx, `
+		SELECT e.line_number FROM edges e JOIN nodes n ON n.id = e.source_id
+		WHERE n.workspace_id = $1 AND e.kind = 'calls'`, first.WorkspaceID).Scan(&line)
+	if err != nil {
+		t.Fatalf("reading calls edge: %v", err)
+	}
+	if line != 2 {
+		t.Errorf("expected the calls edge on line 2, got %d", line)
+	}
+}
+
 func TestBuildGraph_UpdateChanged(t *testing.T) {
 	ctx, pool := setupGraphTest(t)
 	createTestProject(t, ctx, pool, "test-gb-upd")
@@ -199,19 +289,15 @@ func TestBuildGraph_UpdateChanged(t *testing.T) {
 		t.Fatalf("second BuildGraph: %v", err)
 	}
 
-	wsID := input.ProjectID + "/" + input.SourceID
 	var bodyHash string
-	nodeID := wsID + "/test-gb-upd/test-source/test-pkg/src/greetings.ts::greet"
-	err = pool.QueryRow(ctx, "SELECT body_hash FROM nodes WHERE id = $1", nodeID).Scan(&bodyHash)
-	if err != nil {
-		// Try without the double workspace prefix — ID generation varies
-		// Just check that exactly 3 nodes exist and one has the updated hash
-		var updatedCount int
-		pool.QueryRow(ctx, "SELECT COUNT(*) FROM nodes WHERE workspace_id = $1 AND body_hash = 'updated-hash'", wsID).Scan(&updatedCount)
-		if updatedCount != 1 {
-			t.Errorf("expected 1 node with updated hash, got %d", updatedCount)
-		}
-	} else if bodyHash != "updated-hash" {
+	nodeID := indexer.NodeID(indexer.WorkspaceID(input.ProjectID, input.SourceID), "src/greetings.ts", "greet")
+	if nodeID != "test-gb-upd/test-gb-upd/test-source::src/greetings.ts::greet" {
+		t.Fatalf("unexpected node ID format %q", nodeID)
+	}
+	if err := pool.QueryRow(ctx, "SELECT body_hash FROM nodes WHERE id = $1", nodeID).Scan(&bodyHash); err != nil {
+		t.Fatalf("looking up %s: %v", nodeID, err)
+	}
+	if bodyHash != "updated-hash" {
 		t.Errorf("expected updated body_hash, got %q", bodyHash)
 	}
 }
@@ -362,3 +448,98 @@ func TestCleanupStale_Standalone(t *testing.T) {
 		t.Errorf("expected 2 remaining nodes (from greetings.ts), got %d", remaining)
 	}
 }
+
+func TestBuildGraph_SoftDelete(t *testing.T) {
+	ctx, pool := setupGraphTest(t)
+	createTestProject(t, ctx, pool, "test-gb-soft-delete")
+	createTestSource(t, ctx, pool, "test-gb-soft-delete/test-source", "test-gb-soft-delete", "/tmp/test-repo")
+	indexer.SetSoftDelete(true)
+	t.Cleanup(func() { indexer.SetSoftDelete(false) })
+
+	input := testBuildInput()
+	input.ProjectID = "test-gb-soft-delete"
+	input.SourceID = "test-gb-soft-delete/test-source"
+	result, err := indexer.BuildGraph(ctx, pool, input)
+	if err != nil {
+		t.Fatalf("first BuildGraph: %v", err)
+	}
+
+	// Drop utils.ts, whose helper greet calls
+	input.FilePaths = []string{"src/greetings.ts"}
+	input.Nodes = input.Nodes[:2]
+	input.Edges = input.Edges[:2]
+	input.Resolved = nil
+	if _, err := indexer.BuildGraph(ctx, pool, input); err != nil {
+		t.Fatalf("second BuildGraph: %v", err)
+	}
+
+	if node, _ := engine.FindNodeByQualifiedName(ctx, pool, input.ProjectID, "helper"); node != nil {
+		t.Errorf("expected helper gone from live queries, got %+v", node)
+	}
+	deleted, err := engine.GetDeletedNodes(ctx, pool, input.ProjectID, time.Time{})
+	if err != nil {
+		t.Fatalf("GetDeletedNodes: %v", err)
+	}
+	if len(deleted) != 1 || deleted[0].QualifiedName != "helper" || deleted[0].FilePath != "src/utils.ts" || deleted[0].Restored {
+		t.Fatalf("expected one tombstone for helper, got %+v", deleted)
+	}
+	if later, _ := engine.GetDeletedNodes(ctx, pool, input.ProjectID, deleted[0].DeletedAt.Add(time.Hour)); len(later) != 0 {
+		t.Errorf("expected no tombstones after since, got %+v", later)
+	}
+
+	var edges int
+	pool.QueryRow(ctx, "SELECT COUNT(*) FROM deleted_edges WHERE workspace_id = $1 AND kind = 'calls'", result.WorkspaceID).Scan(&edges)
+	if edges != 1 {
+		t.Errorf("expected the greet → helper call tombstoned, got %d", edges)
+	}
+
+	purged, err := indexer.PurgeTombstones(ctx, pool, input.ProjectID, 0)
+	if err != nil {
+		t.Fatalf("PurgeTombstones: %v", err)
+	}
+	if purged != 1 {
+		t.Errorf("expected 1 tombstone purged, got %d", purged)
+	}
+}
+
+func TestBuildGraph_SoftDeleteRename(t *testing.T) {
+	ctx, pool := setupGraphTest(t)
+	createTestProject(t, ctx, pool, "test-gb-soft-rename")
+	createTestSource(t, ctx, pool, "test-gb-soft-rename/test-source", "test-gb-soft-rename", "/tmp/test-repo")
+	indexer.SetSoftDelete(true)
+	t.Cleanup(func() { indexer.SetSoftDelete(false) })
+
+	input := testBuildInput()
+	input.ProjectID = "test-gb-soft-rename"
+	input.SourceID = "test-gb-soft-rename/test-source"
+	result, err := indexer.BuildGraph(ctx, pool, input)
+	if err != nil {
+		t.Fatalf("first BuildGraph: %v", err)
+	}
+
+	// Rename helper to assist; utils.ts stays, so cleanupStale keeps it
+	input.Nodes[2].Name, input.Nodes[2].QualifiedName = "assist", "assist"
+	input.Edges[2].Target = "assist"
+	input.Resolved = []indexer.ResolvedEdge{{Source: "greet", Target: "assist", Kind: "calls", Line: 2}}
+	input.Renames = map[string]indexer.Rename{"assist": {
+		OldNodeID:        indexer.NodeID(result.WorkspaceID, "src/utils.ts", "helper"),
+		OldQualifiedName: "helper",
+		NewQualifiedName: "assist",
+	}}
+	if _, err := indexer.BuildGraph(ctx, pool, input); err != nil {
+		t.Fatalf("second BuildGraph: %v", err)
+	}
+
+	deleted, err := engine.GetDeletedNodes(ctx, pool, input.ProjectID, time.Time{})
+	if err != nil {
+		t.Fatalf("GetDeletedNodes: %v", err)
+	}
+	if len(deleted) != 1 || deleted[0].QualifiedName != "helper" {
+		t.Fatalf("expected one tombstone for the renamed helper, got %+v", deleted)
+	}
+	var edges int
+	pool.QueryRow(ctx, "SELECT COUNT(*) FROM deleted_edges WHERE workspace_id = $1 AND kind = 'calls'", result.WorkspaceID).Scan(&edges)
+	if edges != 1 {
+		t.Errorf("expected the greet → helper call tombstoned, got %d", edges)
+	}
+}
@@ -0,0 +1,168 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/maximilianfalco/mycelium/internal/engine"
+	"github.com/maximilianfalco/mycelium/internal/indexer"
+	"github.com/maximilianfalco/mycelium/internal/indexer/detectors"
+	"github.com/maximilianfalco/mycelium/internal/indexer/parsers"
+)
+
+func TestDiffIndexRuns(t *testing.T) {
+	ctx, pool := setupGraphTest(t)
+
+	projectID := "test-graph-diff"
+	sourceID := projectID + "/src"
+	createTestProject(t, ctx, pool, projectID)
+	createTestSource(t, ctx, pool, sourceID, projectID, "/tmp/test-graph-diff")
+
+	build := func(nodes []parsers.NodeInfo, resolved []indexer.ResolvedEdge) string {
+		t.Helper()
+		var edges []parsers.EdgeInfo
+		for _, n := range nodes {
+			edges = append(edges, parsers.EdgeInfo{Source: "src/app.ts", Target: n.QualifiedName, Kind: "contains"})
+		}
+		result, err := indexer.BuildGraph(ctx, pool, &indexer.BuildInput{
+			ProjectID:  projectID,
+			SourceID:   sourceID,
+			SourcePath: "/tmp/test-graph-diff",
+			Workspace: &detectors.WorkspaceInfo{
+				WorkspaceType: "standalone",
+				Packages:      []detectors.PackageInfo{{Name: "app", Path: "."}},
+			},
+			Nodes:      nodes,
+			Edges:      edges,
+			Resolved:   resolved,
+			Embeddings: map[string][]float32{},
+			FilePaths:  []string{"src/app.ts"},
+		})
+		if err != nil {
+			t.Fatalf("BuildGraph: %v", err)
+		}
+		return result.WorkspaceID
+	}
+
+	wsID := build([]parsers.NodeInfo{
+		{Name: "main", QualifiedName: "main", Kind: "function", StartLine: 1, EndLine: 3, BodyHash: "main-1"},
+		{Name: "legacy", QualifiedName: "legacy", Kind: "function", StartLine: 5, EndLine: 7, BodyHash: "legacy-1"},
+	}, []indexer.ResolvedEdge{{Source: "main", Target: "legacy", Kind: "calls", Line: 2}})
+	if err := indexer.RecordManifest(ctx, pool, sourceID, wsID, "commit-a", 0); err != nil {
+		t.Fatalf("RecordManifest a: %v", err)
+	}
+
+	build([]parsers.NodeInfo{
+		{Name: "main", QualifiedName: "main", Kind: "function", StartLine: 1, EndLine: 3, BodyHash: "main-2"},
+		{Name: "modern", QualifiedName: "modern", Kind: "function", StartLine: 5, EndLine: 7, BodyHash: "modern-1"},
+	}, []indexer.ResolvedEdge{{Source: "main", Target: "modern", Kind: "calls", Line: 2}})
+	if err := indexer.RecordManifest(ctx, pool, sourceID, wsID, "commit-b", 0); err != nil {
+		t.Fatalf("RecordManifest b: %v", err)
+	}
+
+	diff, err := engine.DiffIndexRuns(ctx, pool, projectID, "commit-a", "commit-b")
+	if err != nil {
+		t.Fatalf("DiffIndexRuns: %v", err)
+	}
+	if diff == nil {
+		t.Fatal("expected diff, got nil")
+	}
+
+	if len(diff.AddedNodes) != 1 || diff.AddedNodes[0].QualifiedName != "modern" {
+		t.Errorf("expected modern added, got %+v", diff.AddedNodes)
+	}
+	if len(diff.RemovedNodes) != 1 || diff.RemovedNodes[0].QualifiedName != "legacy" {
+		t.Errorf("expected legacy removed, got %+v", diff.RemovedNodes)
+	}
+	if len(diff.ModifiedNodes) != 1 || diff.ModifiedNodes[0].QualifiedName != "main" {
+		t.Errorf("expected main modified, got %+v", diff.ModifiedNodes)
+	}
+	if len(diff.AddedEdges) != 1 || diff.AddedEdges[0].Target != "modern" {
+		t.Errorf("expected main→modern edge added, got %+v", diff.AddedEdges)
+	}
+	if len(diff.RemovedEdges) != 1 || diff.RemovedEdges[0].Target != "legacy" {
+		t.Errorf("expected main→legacy edge removed, got %+v", diff.RemovedEdges)
+	}
+}
+
+func TestDiffIndexRuns_UnknownCommit(t *testing.T) {
+	ctx, pool := setupGraphTest(t)
+
+	diff, err := engine.DiffIndexRuns(ctx, pool, "nonexistent-project", "a", "b")
+	if err != nil {
+		t.Fatalf("DiffIndexRuns: %v", err)
+	}
+	if diff != nil {
+		t.Errorf("expected nil for unknown commits, got %+v", diff)
+	}
+}
+
+func TestRecordManifest_SharesUnchangedFiles(t *testing.T) {
+	ctx, pool := setupGraphTest(t)
+
+	projectID := "test-manifest-sharing"
+	sourceID := projectID + "/src"
+	createTestProject(t, ctx, pool, projectID)
+	createTestSource(t, ctx, pool, sourceID, projectID, "/tmp/test-manifest-sharing")
+
+	build := func(mainHash string) string {
+		t.Helper()
+		result, err := indexer.BuildGraph(ctx, pool, &indexer.BuildInput{
+			ProjectID:  projectID,
+			SourceID:   sourceID,
+			SourcePath: "/tmp/test-manifest-sharing",
+			Workspace:  &detectors.WorkspaceInfo{WorkspaceType: "standalone"},
+			Nodes: []parsers.NodeInfo{
+				{Name: "main", QualifiedName: "main", Kind: "function", StartLine: 1, EndLine: 3, BodyHash: mainHash},
+				{Name: "helper", QualifiedName: "helper", Kind: "function", StartLine: 1, EndLine: 3, BodyHash: "helper-1"},
+			},
+			Edges: []parsers.EdgeInfo{
+				{Source: "src/app.ts", Target: "main", Kind: "contains"},
+				{Source: "src/util.ts", Target: "helper", Kind: "contains"},
+			},
+			Resolved:   []indexer.ResolvedEdge{{Source: "main", Target: "helper", Kind: "calls", Line: 2}},
+			Embeddings: map[string][]float32{},
+			FilePaths:  []string{"src/app.ts", "src/util.ts"},
+		})
+		if err != nil {
+			t.Fatalf("BuildGraph: %v", err)
+		}
+		return result.WorkspaceID
+	}
+	count := func(table string) int {
+		t.Helper()
+		var n int
+		if err := pool.QueryRow(ctx, `SELECT COUNT(*) FROM `+table+` WHERE source_id = $1`, sourceID).Scan(&n); err != nil {
+			t.Fatalf("counting %s: %v", table, err)
+		}
+		return n
+	}
+
+	wsID := build("main-1")
+	if err := indexer.RecordManifest(ctx, pool, sourceID, wsID, "commit-a", 0); err != nil {
+		t.Fatalf("RecordManifest a: %v", err)
+	}
+	build("main-2")
+	if err := indexer.RecordManifest(ctx, pool, sourceID, wsID, "commit-b", 0); err != nil {
+		t.Fatalf("RecordManifest b: %v", err)
+	}
+
+	// util.ts didn't change, so only app.ts's new content was stored
+	if files, nodes, edges := count("manifest_files"), count("manifest_nodes"), count("manifest_edges"); files != 4 || nodes != 3 || edges != 2 {
+		t.Errorf("expected 4 files, 3 nodes, 2 edges, got %d, %d, %d", files, nodes, edges)
+	}
+	diff, err := engine.DiffIndexRuns(ctx, pool, projectID, "commit-a", "commit-b")
+	if err != nil || diff == nil {
+		t.Fatalf("DiffIndexRuns: %v, %v", diff, err)
+	}
+	if len(diff.ModifiedNodes) != 1 || diff.ModifiedNodes[0].QualifiedName != "main" || len(diff.AddedEdges)+len(diff.RemovedEdges) != 0 {
+		t.Errorf("expected only main modified, got %+v", diff)
+	}
+
+	// Pruning commit-a drops the app.ts content only it had
+	if err := indexer.RecordManifest(ctx, pool, sourceID, wsID, "commit-c", 1); err != nil {
+		t.Fatalf("RecordManifest c: %v", err)
+	}
+	if files, nodes, edges := count("manifest_files"), count("manifest_nodes"), count("manifest_edges"); files != 2 || nodes != 2 || edges != 1 {
+		t.Errorf("expected 2 files, 2 nodes, 1 edge after pruning, got %d, %d, %d", files, nodes, edges)
+	}
+}
@@ -0,0 +1,122 @@
+package integration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/maximilianfalco/mycelium/internal/engine"
+	"github.com/maximilianfalco/mycelium/internal/indexer"
+	"github.com/maximilianfalco/mycelium/internal/indexer/detectors"
+	"github.com/maximilianfalco/mycelium/internal/indexer/parsers"
+)
+
+// setupImplementersTest builds a small type hierarchy:
+//
+//	Circle --implements--> Shape
+//	Square --implements--> Shape
+//	Square --extends-----> Polygon
+func setupImplementersTest(t *testing.T) (context.Context, *pgxpool.Pool) {
+	t.Helper()
+	ctx, pool := setupGraphTest(t)
+
+	projectID := "test-impl"
+	createTestProject(t, ctx, pool, projectID)
+	createTestSource(t, ctx, pool, projectID+"/src", projectID, "/tmp/test-impl")
+
+	input := &indexer.BuildInput{
+		ProjectID:  projectID,
+		SourceID:   projectID + "/src",
+		SourcePath: "/tmp/test-impl",
+		Workspace: &detectors.WorkspaceInfo{
+			WorkspaceType:  "standalone",
+			PackageManager: "npm",
+			Packages: []detectors.PackageInfo{
+				{Name: "shapes", Path: "src", Version: "1.0.0"},
+			},
+		},
+		Nodes: []parsers.NodeInfo{
+			{Name: "Shape", QualifiedName: "Shape", Kind: "interface", StartLine: 1, EndLine: 3, BodyHash: "impl-1"},
+			{Name: "Polygon", QualifiedName: "Polygon", Kind: "class", StartLine: 5, EndLine: 9, BodyHash: "impl-2"},
+			{Name: "Circle", QualifiedName: "Circle", Kind: "class", StartLine: 1, EndLine: 6, BodyHash: "impl-3"},
+			{Name: "Square", QualifiedName: "Square", Kind: "class", StartLine: 8, EndLine: 14, BodyHash: "impl-4"},
+		},
+		Edges: []parsers.EdgeInfo{
+			{Source: "src/types.ts", Target: "Shape", Kind: "contains", Line: 1},
+			{Source: "src/types.ts", Target: "Polygon", Kind: "contains", Line: 5},
+			{Source: "src/shapes.ts", Target: "Circle", Kind: "contains", Line: 1},
+			{Source: "src/shapes.ts", Target: "Square", Kind: "contains", Line: 8},
+		},
+		Resolved: []indexer.ResolvedEdge{
+			{Source: "Circle", Target: "Shape", Kind: "implements", Line: 1},
+			{Source: "Square", Target: "Shape", Kind: "implements", Line: 8},
+			{Source: "Square", Target: "Polygon", Kind: "extends", Line: 8},
+		},
+		Embeddings: map[string][]float32{},
+		FilePaths:  []string{"src/types.ts", "src/shapes.ts"},
+	}
+
+	if _, err := indexer.BuildGraph(ctx, pool, input); err != nil {
+		t.Fatalf("BuildGraph: %v", err)
+	}
+	return ctx, pool
+}
+
+func TestGetImplementers(t *testing.T) {
+	ctx, pool := setupImplementersTest(t)
+
+	shape, err := engine.FindNodeByQualifiedName(ctx, pool, "test-impl", "Shape")
+	if err != nil || shape == nil {
+		t.Fatalf("FindNodeByQualifiedName: err=%v, node=%v", err, shape)
+	}
+
+	impls, err := engine.GetImplementers(ctx, pool, shape.NodeID)
+	if err != nil {
+		t.Fatalf("GetImplementers: %v", err)
+	}
+	if len(impls) != 2 || impls[0].QualifiedName != "Circle" || impls[1].QualifiedName != "Square" {
+		t.Errorf("expected [Circle Square], got %v", impls)
+	}
+
+	polygon, _ := engine.FindNodeByQualifiedName(ctx, pool, "test-impl", "Polygon")
+	if polygon == nil {
+		t.Fatal("expected to find Polygon")
+	}
+	subs, err := engine.GetImplementers(ctx, pool, polygon.NodeID)
+	if err != nil {
+		t.Fatalf("GetImplementers: %v", err)
+	}
+	if len(subs) != 1 || subs[0].QualifiedName != "Square" {
+		t.Errorf("expected [Square] extending Polygon, got %v", subs)
+	}
+}
+
+func TestGetInterfacesImplemented(t *testing.T) {
+	ctx, pool := setupImplementersTest(t)
+
+	square, err := engine.FindNodeByQualifiedName(ctx, pool, "test-impl", "Square")
+	if err != nil || square == nil {
+		t.Fatalf("FindNodeByQualifiedName: err=%v, node=%v", err, square)
+	}
+
+	supers, err := engine.GetInterfacesImplemented(ctx, pool, square.NodeID)
+	if err != nil {
+		t.Fatalf("GetInterfacesImplemented: %v", err)
+	}
+	names := make(map[string]bool)
+	for _, n := range supers {
+		names[n.QualifiedName] = true
+	}
+	if len(supers) != 2 || !names["Shape"] || !names["Polygon"] {
+		t.Errorf("expected Shape and Polygon, got %v", supers)
+	}
+
+	shape, _ := engine.FindNodeByQualifiedName(ctx, pool, "test-impl", "Shape")
+	none, err := engine.GetInterfacesImplemented(ctx, pool, shape.NodeID)
+	if err != nil {
+		t.Fatalf("GetInterfacesImplemented: %v", err)
+	}
+	if len(none) != 0 {
+		t.Errorf("expected Shape to implement nothing, got %v", none)
+	}
+}
@@ -0,0 +1,68 @@
+package integration
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/maximilianfalco/mycelium/internal/config"
+	"github.com/maximilianfalco/mycelium/internal/indexer"
+)
+
+func TestIndexProject_RenamedPackageRemapsInPlace(t *testing.T) {
+	ctx, pool := setupGraphTest(t)
+	dir := t.TempDir()
+	write := func(rel, content string) {
+		t.Helper()
+		abs := filepath.Join(dir, rel)
+		os.MkdirAll(filepath.Dir(abs), 0o755)
+		if err := os.WriteFile(abs, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("package.json", `{"name": "root", "private": true, "workspaces": ["packages/*"]}`)
+	write("packages/old-lib/package.json", `{"name": "@t/lib", "version": "1.0.0"}`)
+	// Identical bodies in two files: too ambiguous for content-based rename
+	// detection, but each file maps to its own new path
+	write("packages/old-lib/src/a.ts", "export function noop() {\n  return 0;\n}\n")
+	write("packages/old-lib/src/b.ts", "export function noop() {\n  return 0;\n}\n")
+
+	projectID, sourceID := "test-pkg-rename", "test-pkg-rename-source"
+	createTestProject(t, ctx, pool, projectID)
+	createTestSource(t, ctx, pool, sourceID, projectID, dir)
+	cfg := &config.Config{}
+	workspaceID := projectID + "/" + sourceID
+
+	if result := indexer.IndexProject(ctx, pool, cfg, nil, projectID, nil, true); len(result.Errors) > 0 {
+		t.Fatalf("initial index failed: %v", result.Errors)
+	}
+
+	if err := os.Rename(filepath.Join(dir, "packages/old-lib"), filepath.Join(dir, "packages/lib")); err != nil {
+		t.Fatal(err)
+	}
+	result := indexer.IndexProject(ctx, pool, cfg, nil, projectID, nil, true)
+	if len(result.Errors) > 0 {
+		t.Fatalf("reindex failed: %v", result.Errors)
+	}
+	if result.TotalRenamed != 2 {
+		t.Errorf("expected both nodes counted as renamed, got %d", result.TotalRenamed)
+	}
+
+	for _, file := range []string{"a.ts", "b.ts"} {
+		newID := indexer.NodeID(workspaceID, "packages/lib/src/"+file, "noop")
+		oldID := indexer.NodeID(workspaceID, "packages/old-lib/src/"+file, "noop")
+		var renamedFrom string
+		if err := pool.QueryRow(ctx, `SELECT COALESCE(renamed_from, '') FROM nodes WHERE id = $1`, newID).Scan(&renamedFrom); err != nil {
+			t.Fatalf("expected %s to exist: %v", newID, err)
+		}
+		if renamedFrom != oldID {
+			t.Errorf("expected %s remapped from %s, got renamed_from %q", newID, oldID, renamedFrom)
+		}
+	}
+
+	var stale int
+	pool.QueryRow(ctx, `SELECT COUNT(*) FROM nodes WHERE workspace_id = $1 AND file_path LIKE 'packages/old-lib/%'`, workspaceID).Scan(&stale)
+	if stale != 0 {
+		t.Errorf("expected no nodes left under the old path, got %d", stale)
+	}
+}
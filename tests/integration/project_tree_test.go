@@ -0,0 +1,66 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/maximilianfalco/mycelium/internal/engine"
+)
+
+func TestGetProjectTree(t *testing.T) {
+	ctx, pool, _ := setupStructuralTest(t)
+
+	tree, err := engine.GetProjectTree(ctx, pool, "test-structural")
+	if err != nil {
+		t.Fatalf("GetProjectTree: %v", err)
+	}
+	if tree == nil || tree.Type != "project" {
+		t.Fatalf("expected project root, got %+v", tree)
+	}
+	if len(tree.Children) != 1 || tree.Children[0].Type != "workspace" {
+		t.Fatalf("expected 1 workspace, got %+v", tree.Children)
+	}
+
+	ws := tree.Children[0]
+	pkgs := map[string]*engine.TreeNode{}
+	for _, c := range ws.Children {
+		if c.Type == "package" {
+			pkgs[c.Name] = c
+		}
+	}
+	auth, ok := pkgs["auth"]
+	if !ok {
+		t.Fatalf("expected auth package, got %+v", ws.Children)
+	}
+
+	var authFile *engine.TreeNode
+	for _, f := range auth.Children {
+		if f.Name == "packages/auth/src/auth.ts" {
+			authFile = f
+		}
+	}
+	if authFile == nil {
+		t.Fatalf("expected auth.ts under auth package, got %+v", auth.Children)
+	}
+	if len(authFile.Children) != 2 {
+		t.Fatalf("expected 2 symbols in auth.ts, got %d", len(authFile.Children))
+	}
+	// Symbols are ordered by start line
+	if authFile.Children[0].Name != "authenticate" || authFile.Children[1].Name != "validateToken" {
+		t.Errorf("unexpected symbol order: %s, %s", authFile.Children[0].Name, authFile.Children[1].Name)
+	}
+	if authFile.Children[0].Signature == "" || authFile.Children[0].Kind != "function" {
+		t.Errorf("expected signature and kind on symbol, got %+v", authFile.Children[0])
+	}
+}
+
+func TestGetProjectTree_NotFound(t *testing.T) {
+	ctx, pool := setupGraphTest(t)
+
+	tree, err := engine.GetProjectTree(ctx, pool, "nonexistent-project")
+	if err != nil {
+		t.Fatalf("GetProjectTree: %v", err)
+	}
+	if tree != nil {
+		t.Errorf("expected nil for missing project, got %+v", tree)
+	}
+}
@@ -0,0 +1,360 @@
+package integration
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/maximilianfalco/mycelium/internal/engine"
+	"github.com/maximilianfalco/mycelium/internal/indexer"
+	"github.com/maximilianfalco/mycelium/internal/indexer/detectors"
+	"github.com/maximilianfalco/mycelium/internal/indexer/parsers"
+)
+
+func TestGetPublicAPI(t *testing.T) {
+	ctx, pool := setupGraphTest(t)
+
+	projectID := "test-public-api"
+	createTestProject(t, ctx, pool, projectID)
+	createTestSource(t, ctx, pool, projectID+"/src", projectID, "/tmp/test-public-api")
+
+	input := &indexer.BuildInput{
+		ProjectID:  projectID,
+		SourceID:   projectID + "/src",
+		SourcePath: "/tmp/test-public-api",
+		Workspace: &detectors.WorkspaceInfo{
+			WorkspaceType: "standalone",
+			Packages:      []detectors.PackageInfo{{Name: "core", Path: "."}},
+		},
+		Nodes: []parsers.NodeInfo{
+			{Name: "createClient", QualifiedName: "createClient", Kind: "function", StartLine: 1, EndLine: 3, BodyHash: "h1", Exported: true, ReleaseTag: "public"},
+			{Name: "oldClient", QualifiedName: "oldClient", Kind: "function", StartLine: 5, EndLine: 7, BodyHash: "h2", Exported: true, Deprecated: true},
+			{Name: "buildHeaders", QualifiedName: "buildHeaders", Kind: "function", StartLine: 9, EndLine: 11, BodyHash: "h3", Exported: true, ReleaseTag: "internal"},
+			{Name: "retry", QualifiedName: "retry", Kind: "function", StartLine: 13, EndLine: 15, BodyHash: "h4"},
+			{Name: "Open", QualifiedName: "Open", Kind: "function", StartLine: 3, EndLine: 5, BodyHash: "h5", Exported: true},
+		},
+		Edges: []parsers.EdgeInfo{
+			{Source: "src/client.ts", Target: "createClient", Kind: "contains", Line: 1},
+			{Source: "src/client.ts", Target: "oldClient", Kind: "contains", Line: 5},
+			{Source: "src/client.ts", Target: "buildHeaders", Kind: "contains", Line: 9},
+			{Source: "src/client.ts", Target: "retry", Kind: "contains", Line: 13},
+			{Source: "internal/store/store.go", Target: "Open", Kind: "contains", Line: 3},
+		},
+		Embeddings: map[string][]float32{},
+		FilePaths:  []string{"src/client.ts", "internal/store/store.go"},
+	}
+	if _, err := indexer.BuildGraph(ctx, pool, input); err != nil {
+		t.Fatalf("BuildGraph: %v", err)
+	}
+
+	var packageID string
+	if err := pool.QueryRow(ctx, `SELECT id FROM packages WHERE name = 'core'`).Scan(&packageID); err != nil {
+		t.Fatalf("looking up package: %v", err)
+	}
+
+	api, err := engine.GetPublicAPI(ctx, pool, packageID)
+	if err != nil {
+		t.Fatalf("GetPublicAPI: %v", err)
+	}
+
+	// Unexported retry and @internal buildHeaders are left out; the
+	// module's own internal/ package stays in
+	if len(api) != 3 {
+		t.Fatalf("expected 3 public nodes, got %d: %+v", len(api), api)
+	}
+	if api[0].QualifiedName != "Open" || api[0].ReleaseTag != indexer.GoInternalReleaseTag {
+		t.Errorf("expected Open tagged go_internal first, got %+v", api[0])
+	}
+	if api[1].QualifiedName != "createClient" || api[1].ReleaseTag != "public" {
+		t.Errorf("expected createClient tagged public second, got %+v", api[1])
+	}
+	if api[2].QualifiedName != "oldClient" || !api[2].Deprecated {
+		t.Errorf("expected deprecated oldClient third, got %+v", api[2])
+	}
+}
+
+func TestGetUnusedExports(t *testing.T) {
+	ctx, pool := setupGraphTest(t)
+
+	projectID := "test-unused-exports"
+	createTestProject(t, ctx, pool, projectID)
+	createTestSource(t, ctx, pool, projectID+"/src", projectID, "/tmp/test-unused-exports")
+
+	input := &indexer.BuildInput{
+		ProjectID:  projectID,
+		SourceID:   projectID + "/src",
+		SourcePath: "/tmp/test-unused-exports",
+		Workspace: &detectors.WorkspaceInfo{
+			WorkspaceType: "standalone",
+			Packages:      []detectors.PackageInfo{{Name: "core", Path: "."}},
+		},
+		Nodes: []parsers.NodeInfo{
+			{Name: "createClient", QualifiedName: "createClient", Kind: "function", StartLine: 1, EndLine: 3, BodyHash: "h1", Exported: true, ReleaseTag: "public"},
+			{Name: "helper", QualifiedName: "helper", Kind: "function", StartLine: 5, EndLine: 7, BodyHash: "h2", Exported: true},
+			{Name: "localOnly", QualifiedName: "localOnly", Kind: "function", StartLine: 9, EndLine: 11, BodyHash: "h3", Exported: true},
+			{Name: "unusedThing", QualifiedName: "unusedThing", Kind: "function", StartLine: 13, EndLine: 15, BodyHash: "h4", Exported: true},
+			{Name: "start", QualifiedName: "start", Kind: "function", StartLine: 1, EndLine: 3, BodyHash: "h5", Exported: true},
+			{Name: "run", QualifiedName: "run", Kind: "function", StartLine: 1, EndLine: 3, BodyHash: "h6"},
+			{Name: "mockClient", QualifiedName: "mockClient", Kind: "function", StartLine: 1, EndLine: 3, BodyHash: "h7", Exported: true, Fixture: true},
+		},
+		Edges: []parsers.EdgeInfo{
+			{Source: "src/client.ts", Target: "createClient", Kind: "contains", Line: 1},
+			{Source: "src/client.ts", Target: "helper", Kind: "contains", Line: 5},
+			{Source: "src/client.ts", Target: "localOnly", Kind: "contains", Line: 9},
+			{Source: "src/client.ts", Target: "unusedThing", Kind: "contains", Line: 13},
+			{Source: "src/index.ts", Target: "start", Kind: "contains", Line: 1},
+			{Source: "src/main.ts", Target: "run", Kind: "contains", Line: 1},
+			{Source: "src/__mocks__/client.ts", Target: "mockClient", Kind: "contains", Line: 1},
+		},
+		Resolved: []indexer.ResolvedEdge{
+			{Source: "run", Target: "helper", Kind: "calls", Line: 2},
+			// A use from within the same file doesn't count
+			{Source: "unusedThing", Target: "localOnly", Kind: "calls", Line: 14},
+		},
+		Embeddings: map[string][]float32{},
+		FilePaths:  []string{"src/client.ts", "src/index.ts", "src/main.ts", "src/__mocks__/client.ts"},
+	}
+	if _, err := indexer.BuildGraph(ctx, pool, input); err != nil {
+		t.Fatalf("BuildGraph: %v", err)
+	}
+
+	unused, err := engine.GetUnusedExports(ctx, pool, projectID)
+	if err != nil {
+		t.Fatalf("GetUnusedExports: %v", err)
+	}
+
+	// helper is called from main.ts, createClient is @public, start lives in
+	// the entry point, run isn't exported, and mockClient is a fixture
+	var names []string
+	for _, n := range unused {
+		names = append(names, n.QualifiedName)
+	}
+	if len(names) != 2 || names[0] != "localOnly" || names[1] != "unusedThing" {
+		t.Errorf("expected localOnly and unusedThing, got %v", names)
+	}
+
+	withFixtures, err := engine.GetUnusedExports(ctx, pool, projectID, engine.IncludeFixtures())
+	if err != nil {
+		t.Fatalf("GetUnusedExports: %v", err)
+	}
+	if !slices.ContainsFunc(withFixtures, func(n engine.NodeResult) bool { return n.QualifiedName == "mockClient" }) {
+		t.Errorf("expected mockClient with IncludeFixtures, got %+v", withFixtures)
+	}
+}
+
+func TestGetOrphanFiles(t *testing.T) {
+	ctx, pool := setupGraphTest(t)
+
+	projectID := "test-orphan-files"
+	createTestProject(t, ctx, pool, projectID)
+	createTestSource(t, ctx, pool, projectID+"/src", projectID, "/tmp/test-orphan-files")
+
+	input := &indexer.BuildInput{
+		ProjectID:  projectID,
+		SourceID:   projectID + "/src",
+		SourcePath: "/tmp/test-orphan-files",
+		Workspace: &detectors.WorkspaceInfo{
+			WorkspaceType: "standalone",
+			Packages:      []detectors.PackageInfo{{Name: "core", Path: "."}},
+		},
+		Nodes: []parsers.NodeInfo{
+			{Name: "start", QualifiedName: "start", Kind: "function", StartLine: 1, EndLine: 3, BodyHash: "h1"},
+			{Name: "Store", QualifiedName: "Store", Kind: "class", StartLine: 1, EndLine: 9, BodyHash: "h2"},
+			{Name: "save", QualifiedName: "Store.save", Kind: "method", StartLine: 2, EndLine: 4, BodyHash: "h3"},
+			{Name: "format", QualifiedName: "format", Kind: "function", StartLine: 1, EndLine: 3, BodyHash: "h4"},
+			{Name: "legacy", QualifiedName: "legacy", Kind: "function", StartLine: 1, EndLine: 3, BodyHash: "h5"},
+			{Name: "legacyHelper", QualifiedName: "legacyHelper", Kind: "function", StartLine: 5, EndLine: 7, BodyHash: "h6"},
+			{Name: "testStore", QualifiedName: "testStore", Kind: "function", StartLine: 1, EndLine: 3, BodyHash: "h7"},
+			{Name: "config", QualifiedName: "config", Kind: "variable", StartLine: 1, EndLine: 3, BodyHash: "h8"},
+			{Name: "Client", QualifiedName: "Client", Kind: "class", StartLine: 1, EndLine: 3, BodyHash: "h9", Generated: true},
+			{Name: "fakeStore", QualifiedName: "fakeStore", Kind: "function", StartLine: 1, EndLine: 3, BodyHash: "h10", Fixture: true},
+		},
+		Edges: []parsers.EdgeInfo{
+			{Source: "src/index.ts", Target: "start", Kind: "contains", Line: 1},
+			{Source: "src/store.ts", Target: "Store", Kind: "contains", Line: 1},
+			{Source: "Store", Target: "Store.save", Kind: "contains", Line: 2},
+			{Source: "src/format.ts", Target: "format", Kind: "contains", Line: 1},
+			{Source: "src/legacy.ts", Target: "legacy", Kind: "contains", Line: 1},
+			{Source: "src/legacy.ts", Target: "legacyHelper", Kind: "contains", Line: 5},
+			{Source: "src/store.test.ts", Target: "testStore", Kind: "contains", Line: 1},
+			{Source: "vite.config.ts", Target: "config", Kind: "contains", Line: 1},
+			{Source: "src/client.gen.ts", Target: "Client", Kind: "contains", Line: 1},
+			{Source: "src/__mocks__/store.ts", Target: "fakeStore", Kind: "contains", Line: 1},
+		},
+		Resolved: []indexer.ResolvedEdge{
+			{Source: "start", Target: "Store", Kind: "imports", Line: 1},
+			// Only a method links format.ts, through its class's file
+			{Source: "Store.save", Target: "format", Kind: "calls", Line: 3},
+			// Calls within a file don't link it
+			{Source: "legacy", Target: "legacyHelper", Kind: "calls", Line: 2},
+		},
+		Embeddings: map[string][]float32{},
+		FilePaths:  []string{"src/index.ts", "src/store.ts", "src/format.ts", "src/legacy.ts", "src/store.test.ts", "vite.config.ts", "src/client.gen.ts", "src/__mocks__/store.ts"},
+	}
+	if _, err := indexer.BuildGraph(ctx, pool, input); err != nil {
+		t.Fatalf("BuildGraph: %v", err)
+	}
+
+	orphans, err := engine.GetOrphanFiles(ctx, pool, projectID)
+	if err != nil {
+		t.Fatalf("GetOrphanFiles: %v", err)
+	}
+	if len(orphans) != 1 || orphans[0] != "src/legacy.ts" {
+		t.Errorf("expected only src/legacy.ts, got %v", orphans)
+	}
+
+	orphans, err = engine.GetOrphanFiles(ctx, pool, projectID, engine.IncludeFixtures())
+	if err != nil {
+		t.Fatalf("GetOrphanFiles: %v", err)
+	}
+	if len(orphans) != 2 || !slices.Contains(orphans, "src/__mocks__/store.ts") {
+		t.Errorf("expected the fixture file with IncludeFixtures, got %v", orphans)
+	}
+}
+
+func TestGetMostImported(t *testing.T) {
+	ctx, pool := setupGraphTest(t)
+
+	projectID := "test-most-imported"
+	createTestProject(t, ctx, pool, projectID)
+	createTestSource(t, ctx, pool, projectID+"/src", projectID, "/tmp/test-most-imported")
+
+	input := &indexer.BuildInput{
+		ProjectID:  projectID,
+		SourceID:   projectID + "/src",
+		SourcePath: "/tmp/test-most-imported",
+		Workspace: &detectors.WorkspaceInfo{
+			WorkspaceType: "standalone",
+			Packages:      []detectors.PackageInfo{{Name: "core", Path: "."}},
+		},
+		Nodes: []parsers.NodeInfo{
+			{Name: "a", QualifiedName: "a", Kind: "function", StartLine: 1, EndLine: 3, BodyHash: "h1"},
+			{Name: "b", QualifiedName: "b", Kind: "function", StartLine: 1, EndLine: 3, BodyHash: "h2"},
+			{Name: "c", QualifiedName: "c", Kind: "function", StartLine: 1, EndLine: 3, BodyHash: "h3"},
+			{Name: "format", QualifiedName: "format", Kind: "function", StartLine: 1, EndLine: 3, BodyHash: "h4"},
+			{Name: "parse", QualifiedName: "parse", Kind: "function", StartLine: 5, EndLine: 7, BodyHash: "h5"},
+		},
+		Edges: []parsers.EdgeInfo{
+			{Source: "src/a.ts", Target: "a", Kind: "contains", Line: 1},
+			{Source: "src/b.ts", Target: "b", Kind: "contains", Line: 1},
+			{Source: "src/c.ts", Target: "c", Kind: "contains", Line: 1},
+			{Source: "src/utils.ts", Target: "format", Kind: "contains", Line: 1},
+			{Source: "src/utils.ts", Target: "parse", Kind: "contains", Line: 5},
+		},
+		Resolved: []indexer.ResolvedEdge{
+			{Source: "src/a.ts", Target: "src/utils.ts", Kind: "imports", Line: 1},
+			{Source: "src/b.ts", Target: "src/utils.ts", Kind: "imports", Line: 1},
+			{Source: "src/c.ts", Target: "src/b.ts", Kind: "imports", Line: 1},
+		},
+		Unresolved: []indexer.UnresolvedRef{
+			{Source: "src/a.ts", RawImport: "react", Kind: "imports", Line: 2},
+			{Source: "src/b.ts", RawImport: "react", Kind: "imports", Line: 2},
+			{Source: "src/c.ts", RawImport: "react", Kind: "imports", Line: 2},
+			// A broken relative import isn't an external
+			{Source: "src/c.ts", RawImport: "./missing", Kind: "imports", Line: 3},
+		},
+		Embeddings: map[string][]float32{},
+		FilePaths:  []string{"src/a.ts", "src/b.ts", "src/c.ts", "src/utils.ts"},
+	}
+	if _, err := indexer.BuildGraph(ctx, pool, input); err != nil {
+		t.Fatalf("BuildGraph: %v", err)
+	}
+
+	targets := func(counts []engine.ImportCount) []string {
+		var out []string
+		for _, c := range counts {
+			out = append(out, fmt.Sprintf("%s:%d:%v", c.Target, c.Importers, c.External))
+		}
+		return out
+	}
+
+	internal, err := engine.GetMostImported(ctx, pool, projectID, 10)
+	if err != nil {
+		t.Fatalf("GetMostImported: %v", err)
+	}
+	if got, want := targets(internal), []string{"src/utils.ts:2:false", "src/b.ts:1:false"}; !slices.Equal(got, want) {
+		t.Errorf("internal = %v, want %v", got, want)
+	}
+
+	all, err := engine.GetMostImported(ctx, pool, projectID, 10, engine.IncludeExternals())
+	if err != nil {
+		t.Fatalf("GetMostImported with externals: %v", err)
+	}
+	if got, want := targets(all), []string{"react:3:true", "src/utils.ts:2:false", "src/b.ts:1:false"}; !slices.Equal(got, want) {
+		t.Errorf("with externals = %v, want %v", got, want)
+	}
+}
+
+func TestGetLargestFiles(t *testing.T) {
+	ctx, pool := setupGraphTest(t)
+
+	projectID := "test-largest-files"
+	createTestProject(t, ctx, pool, projectID)
+	createTestSource(t, ctx, pool, projectID+"/src", projectID, "/tmp/test-largest-files")
+
+	input := &indexer.BuildInput{
+		ProjectID:  projectID,
+		SourceID:   projectID + "/src",
+		SourcePath: "/tmp/test-largest-files",
+		Workspace: &detectors.WorkspaceInfo{
+			WorkspaceType: "standalone",
+			Packages: []detectors.PackageInfo{
+				{Name: "app", Path: "src/app"},
+				{Name: "lib", Path: "src/lib"},
+			},
+		},
+		Nodes: []parsers.NodeInfo{
+			{Name: "Widget", QualifiedName: "Widget", Kind: "class", StartLine: 1, EndLine: 20, BodyHash: "h1"},
+			{Name: "render", QualifiedName: "Widget.render", Kind: "method", StartLine: 5, EndLine: 10, BodyHash: "h2"},
+			{Name: "helper", QualifiedName: "helper", Kind: "function", StartLine: 22, EndLine: 25, BodyHash: "h3"},
+			{Name: "format", QualifiedName: "format", Kind: "function", StartLine: 1, EndLine: 3, BodyHash: "h4"},
+			{Name: "parse", QualifiedName: "parse", Kind: "function", StartLine: 1, EndLine: 4, BodyHash: "h5"},
+		},
+		Edges: []parsers.EdgeInfo{
+			{Source: "src/app/big.ts", Target: "Widget", Kind: "contains", Line: 1},
+			{Source: "Widget", Target: "Widget.render", Kind: "contains", Line: 5},
+			{Source: "src/app/big.ts", Target: "helper", Kind: "contains", Line: 22},
+			{Source: "src/lib/util.ts", Target: "format", Kind: "contains", Line: 1},
+			{Source: "src/lib/parse.ts", Target: "parse", Kind: "contains", Line: 1},
+		},
+		Resolved: []indexer.ResolvedEdge{
+			{Source: "src/app/big.ts", Target: "src/lib/util.ts", Kind: "imports", Line: 1},
+			{Source: "src/app/big.ts", Target: "src/lib/parse.ts", Kind: "imports", Line: 2},
+			// Imports within a package don't count
+			{Source: "src/lib/util.ts", Target: "src/lib/parse.ts", Kind: "imports", Line: 1},
+		},
+		Unresolved: []indexer.UnresolvedRef{
+			{Source: "src/app/big.ts", RawImport: "react", Kind: "imports", Line: 3},
+		},
+		Embeddings: map[string][]float32{},
+		FilePaths:  []string{"src/app/big.ts", "src/lib/util.ts", "src/lib/parse.ts"},
+	}
+	if _, err := indexer.BuildGraph(ctx, pool, input); err != nil {
+		t.Fatalf("BuildGraph: %v", err)
+	}
+
+	sizes := func(files []engine.FileSize) []string {
+		var out []string
+		for _, f := range files {
+			out = append(out, fmt.Sprintf("%s:%s:%d:%d:%d", f.FilePath, f.Package, f.Nodes, f.Lines, f.ImportedPackages))
+		}
+		return out
+	}
+
+	files, err := engine.GetLargestFiles(ctx, pool, projectID, 10)
+	if err != nil {
+		t.Fatalf("GetLargestFiles: %v", err)
+	}
+	want := []string{"src/app/big.ts:app:3:24:1", "src/lib/parse.ts:lib:1:4:0", "src/lib/util.ts:lib:1:3:0"}
+	if got := sizes(files); !slices.Equal(got, want) {
+		t.Errorf("largest files = %v, want %v", got, want)
+	}
+
+	top, err := engine.GetLargestFiles(ctx, pool, projectID, 1, engine.IncludeExternals())
+	if err != nil {
+		t.Fatalf("GetLargestFiles with externals: %v", err)
+	}
+	if got := sizes(top); !slices.Equal(got, []string{"src/app/big.ts:app:3:24:2"}) {
+		t.Errorf("with externals = %v, want big.ts importing 2 packages", got)
+	}
+}
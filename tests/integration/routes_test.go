@@ -0,0 +1,83 @@
+package integration
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/maximilianfalco/mycelium/internal/config"
+	"github.com/maximilianfalco/mycelium/internal/indexer"
+)
+
+func TestIndexProject_RouteEndpoints(t *testing.T) {
+	ctx, pool := setupGraphTest(t)
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "handlers.ts"), []byte("export function listUsers(req, res) {\n  res.json([]);\n}\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "server.ts"), []byte("import { listUsers } from './handlers';\n\napp.get('/users', listUsers);\n"), 0o644)
+
+	projectID, sourceID := "test-routes", "test-routes-source"
+	createTestProject(t, ctx, pool, projectID)
+	createTestSource(t, ctx, pool, sourceID, projectID, dir)
+
+	cfg := &config.Config{RouteDetectors: []string{"express"}}
+	if result := indexer.IndexProject(ctx, pool, cfg, nil, projectID, nil, true); len(result.Errors) > 0 {
+		t.Fatalf("index failed: %v", result.Errors)
+	}
+
+	var kind, filePath, handler string
+	err := pool.QueryRow(ctx, `
+		SELECT s.kind, s.file_path, t.qualified_name
+		FROM edges e
+		JOIN nodes s ON e.source_id = s.id
+		JOIN nodes t ON e.target_id = t.id
+		WHERE s.workspace_id = $1 AND e.kind = 'handles' AND s.name = 'GET /users'`,
+		indexer.WorkspaceID(projectID, sourceID)).Scan(&kind, &filePath, &handler)
+	if err != nil {
+		t.Fatalf("expected a handles edge from GET /users: %v", err)
+	}
+	if kind != "endpoint" || filePath != "server.ts" || handler != "listUsers" {
+		t.Errorf("expected endpoint GET /users in server.ts handled by listUsers, got %s in %s handled by %s", kind, filePath, handler)
+	}
+}
+
+func TestIndexProject_SameRouteInTwoRouters(t *testing.T) {
+	ctx, pool := setupGraphTest(t)
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "users.ts"), []byte("export function listUsers(req, res) {}\n\nrouter.get('/', listUsers);\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "admins.ts"), []byte("export function listAdmins(req, res) {}\n\nrouter.get('/', listAdmins);\n"), 0o644)
+
+	projectID, sourceID := "test-routes-two", "test-routes-two-source"
+	createTestProject(t, ctx, pool, projectID)
+	createTestSource(t, ctx, pool, sourceID, projectID, dir)
+
+	cfg := &config.Config{RouteDetectors: []string{"express"}}
+	if result := indexer.IndexProject(ctx, pool, cfg, nil, projectID, nil, true); len(result.Errors) > 0 {
+		t.Fatalf("index failed: %v", result.Errors)
+	}
+
+	rows, err := pool.Query(ctx, `
+		SELECT s.file_path, t.qualified_name
+		FROM edges e
+		JOIN nodes s ON e.source_id = s.id
+		JOIN nodes t ON e.target_id = t.id
+		WHERE s.workspace_id = $1 AND e.kind = 'handles' AND s.name = 'GET /'
+		ORDER BY s.file_path`,
+		indexer.WorkspaceID(projectID, sourceID))
+	if err != nil {
+		t.Fatalf("querying handles edges: %v", err)
+	}
+	defer rows.Close()
+	var got []string
+	for rows.Next() {
+		var filePath, handler string
+		if err := rows.Scan(&filePath, &handler); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, filePath+" -> "+handler)
+	}
+	want := []string{"admins.ts -> listAdmins", "users.ts -> listUsers"}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected each router's GET / handled by its own handler, got %v", got)
+	}
+}
@@ -3,9 +3,11 @@ package integration
 import (
 	"context"
 	"math"
+	"slices"
 	"testing"
 
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/maximilianfalco/mycelium/internal/config"
 	"github.com/maximilianfalco/mycelium/internal/engine"
 	"github.com/maximilianfalco/mycelium/internal/indexer"
 	"github.com/maximilianfalco/mycelium/internal/indexer/detectors"
@@ -146,6 +148,103 @@ func TestSemanticSearch_KindFilter(t *testing.T) {
 	}
 }
 
+func TestSemanticSearch_KindGroupFilter(t *testing.T) {
+	ctx, pool := setupSearchTest(t)
+
+	queryVec := makeUnitVector(1536, 0)
+	results, err := engine.SemanticSearchWithVector(ctx, pool, queryVec, "test-search", 10, []string{"callable"})
+	if err != nil {
+		t.Fatalf("SemanticSearchWithVector: %v", err)
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("expected the 2 functions, got %d", len(results))
+	}
+	for _, r := range results {
+		if r.Kind != "function" || r.KindGroup != "callable" {
+			t.Errorf("unexpected result %s (%s/%s)", r.QualifiedName, r.Kind, r.KindGroup)
+		}
+	}
+}
+
+func TestSearch_DefaultKindScope(t *testing.T) {
+	ctx, pool := setupSearchTest(t)
+
+	// A type alias whose vector sits right on the query outranks the
+	// function it describes unless the default scope leaves it out.
+	input := &indexer.BuildInput{
+		ProjectID:  "test-search",
+		SourceID:   "test-search/src",
+		SourcePath: "/tmp/test-search",
+		Workspace: &detectors.WorkspaceInfo{
+			WorkspaceType:  "standalone",
+			PackageManager: "npm",
+			Packages:       []detectors.PackageInfo{{Name: "app", Path: "src", Version: "1.0.0"}},
+		},
+		Nodes: []parsers.NodeInfo{{
+			Name:          "AuthToken",
+			QualifiedName: "AuthToken",
+			Kind:          "type_alias",
+			Signature:     "type AuthToken = string",
+			StartLine:     1,
+			EndLine:       1,
+			SourceCode:    "type AuthToken = string",
+			BodyHash:      "token-hash",
+		}},
+		Edges:      []parsers.EdgeInfo{{Source: "src/token.ts", Target: "AuthToken", Kind: "contains", Line: 1}},
+		Embeddings: map[string][]float32{"AuthToken": makeUnitVector(1536, 0)},
+		FilePaths:  []string{"src/auth.ts", "src/db.ts", "src/logger.ts", "src/token.ts"},
+	}
+	if _, err := indexer.BuildGraph(ctx, pool, input); err != nil {
+		t.Fatalf("BuildGraph: %v", err)
+	}
+
+	qnames := func(results []engine.SearchResult) []string {
+		var names []string
+		for _, r := range results {
+			names = append(names, r.QualifiedName)
+		}
+		return names
+	}
+	queryVec := makeUnitVector(1536, 0)
+
+	results, err := engine.SemanticSearchWithVector(ctx, pool, queryVec, "test-search", 10, nil)
+	if err != nil {
+		t.Fatalf("SemanticSearchWithVector: %v", err)
+	}
+	if slices.Contains(qnames(results), "AuthToken") || results[0].QualifiedName != "authenticate" {
+		t.Errorf("expected the default scope to skip the type alias, got %v", qnames(results))
+	}
+
+	hybrid, err := engine.HybridSearchWithVector(ctx, pool, queryVec, "auth token", "test-search", 10, nil, engine.DefaultHybridAlpha)
+	if err != nil {
+		t.Fatalf("HybridSearchWithVector: %v", err)
+	}
+	if slices.Contains(qnames(hybrid), "AuthToken") {
+		t.Errorf("expected hybrid search to apply the default scope, got %v", qnames(hybrid))
+	}
+
+	// Naming the kind includes it
+	results, err = engine.SemanticSearchWithVector(ctx, pool, queryVec, "test-search", 10, []string{"type_alias"})
+	if err != nil {
+		t.Fatalf("SemanticSearchWithVector: %v", err)
+	}
+	if got := qnames(results); !slices.Equal(got, []string{"AuthToken"}) {
+		t.Errorf("expected an explicit kind to be searched, got %v", got)
+	}
+
+	// An empty default searches every kind
+	engine.SetSearchKinds(nil)
+	defer engine.SetSearchKinds(config.DefaultSearchKinds)
+	results, err = engine.SemanticSearchWithVector(ctx, pool, queryVec, "test-search", 10, nil)
+	if err != nil {
+		t.Fatalf("SemanticSearchWithVector: %v", err)
+	}
+	if len(results) != 4 {
+		t.Errorf("expected all 4 nodes without a default scope, got %v", qnames(results))
+	}
+}
+
 func TestSemanticSearch_Limit(t *testing.T) {
 	ctx, pool := setupSearchTest(t)
 
@@ -205,6 +304,109 @@ func TestSemanticSearch_ResultFields(t *testing.T) {
 	}
 }
 
+func TestSemanticSearch_SimilarityMetrics(t *testing.T) {
+	ctx, pool := setupSearchTest(t)
+	defer engine.SetSimilarityMetric("")
+
+	// Orthogonal unit vectors: every metric must put the match at 1 and keep
+	// the misses inside [0, 1]
+	misses := map[string]float64{
+		engine.MetricCosine: 0,
+		engine.MetricDot:    0,
+		engine.MetricL2:     1 / (1 + math.Sqrt2),
+	}
+	for metric, miss := range misses {
+		if err := engine.SetSimilarityMetric(metric); err != nil {
+			t.Fatalf("SetSimilarityMetric(%s): %v", metric, err)
+		}
+		results, err := engine.SemanticSearchWithVector(ctx, pool, makeUnitVector(1536, 0), "test-search", 10, nil)
+		if err != nil {
+			t.Fatalf("%s: SemanticSearchWithVector: %v", metric, err)
+		}
+		if len(results) != 3 || results[0].QualifiedName != "authenticate" {
+			t.Fatalf("%s: expected authenticate first of 3, got %+v", metric, results)
+		}
+		if math.Abs(results[0].Similarity-1) > 0.01 {
+			t.Errorf("%s: expected the match at ~1.0, got %f", metric, results[0].Similarity)
+		}
+		for _, r := range results[1:] {
+			if math.Abs(r.Similarity-miss) > 0.01 {
+				t.Errorf("%s: expected %s at ~%.2f, got %f", metric, r.QualifiedName, miss, r.Similarity)
+			}
+		}
+	}
+}
+
+func TestSemanticSearch_SignatureEmbedding(t *testing.T) {
+	ctx, pool := setupSearchTest(t)
+
+	// No signature vectors stored yet: a signature search falls back to the
+	// full embeddings instead of returning nothing
+	results, err := engine.SemanticSearchWithVector(ctx, pool, makeUnitVector(1536, 0), "test-search", 10, nil,
+		engine.WithEmbedding(engine.EmbeddingSignature))
+	if err != nil {
+		t.Fatalf("SemanticSearchWithVector: %v", err)
+	}
+	if len(results) != 3 || results[0].QualifiedName != "authenticate" {
+		t.Fatalf("expected the full-embedding fallback, got %+v", results)
+	}
+
+	input := &indexer.BuildInput{
+		ProjectID:  "test-search",
+		SourceID:   "test-search/src",
+		SourcePath: "/tmp/test-search",
+		Workspace: &detectors.WorkspaceInfo{
+			WorkspaceType:  "standalone",
+			PackageManager: "npm",
+			Packages:       []detectors.PackageInfo{{Name: "app", Path: "src", Version: "1.0.0"}},
+		},
+		Nodes: []parsers.NodeInfo{{
+			Name:          "queryUsers",
+			QualifiedName: "queryUsers",
+			Kind:          "function",
+			Signature:     "function queryUsers(filter: Filter): User[]",
+			StartLine:     1,
+			EndLine:       5,
+			BodyHash:      "db-hash",
+		}},
+		Edges:               []parsers.EdgeInfo{{Source: "src/db.ts", Target: "queryUsers", Kind: "contains", Line: 1}},
+		Embeddings:          map[string][]float32{"queryUsers": makeUnitVector(1536, 1)},
+		SignatureEmbeddings: map[string][]float32{"queryUsers": makeUnitVector(1536, 5)},
+		FilePaths:           []string{"src/db.ts"},
+	}
+	if _, err := indexer.BuildGraph(ctx, pool, input); err != nil {
+		t.Fatalf("BuildGraph: %v", err)
+	}
+
+	sig, err := engine.SemanticSearchWithVector(ctx, pool, makeUnitVector(1536, 5), "test-search", 10, nil,
+		engine.WithEmbedding(engine.EmbeddingSignature))
+	if err != nil {
+		t.Fatalf("SemanticSearchWithVector: %v", err)
+	}
+	if len(sig) != 1 || sig[0].QualifiedName != "queryUsers" || math.Abs(sig[0].Similarity-1) > 0.01 {
+		t.Fatalf("expected only queryUsers to match its signature vector, got %+v", sig)
+	}
+
+	full, err := engine.SemanticSearchWithVector(ctx, pool, makeUnitVector(1536, 5), "test-search", 10, nil)
+	if err != nil {
+		t.Fatalf("SemanticSearchWithVector: %v", err)
+	}
+	for _, r := range full {
+		if r.Similarity > 0.01 {
+			t.Errorf("expected the full embeddings to ignore the signature vector, got %s at %f", r.QualifiedName, r.Similarity)
+		}
+	}
+
+	hybrid, err := engine.HybridSearchWithVector(ctx, pool, makeUnitVector(1536, 5), "queryUsers", "test-search", 10, nil, 1,
+		engine.WithEmbedding(engine.EmbeddingAuto))
+	if err != nil {
+		t.Fatalf("HybridSearchWithVector: %v", err)
+	}
+	if len(hybrid) == 0 || hybrid[0].QualifiedName != "queryUsers" || hybrid[0].SemanticScore < 0.99 {
+		t.Errorf("expected auto to route a symbol query to signature vectors, got %+v", hybrid)
+	}
+}
+
 // --- Hybrid search tests ---
 
 func TestHybridSearch_ExactNameMatch(t *testing.T) {
@@ -213,7 +415,7 @@ func TestHybridSearch_ExactNameMatch(t *testing.T) {
 	// Use a vector pointing at dimension 1 (queryUsers) but search for "authenticate" by keyword.
 	// Keyword match for "authenticate" should boost it above the vector-only favorite.
 	queryVec := makeUnitVector(1536, 1)
-	results, err := engine.HybridSearchWithVector(ctx, pool, queryVec, "authenticate", "test-search", 10, nil)
+	results, err := engine.HybridSearchWithVector(ctx, pool, queryVec, "authenticate", "test-search", 10, nil, engine.DefaultHybridAlpha)
 	if err != nil {
 		t.Fatalf("HybridSearchWithVector: %v", err)
 	}
@@ -241,7 +443,7 @@ func TestHybridSearch_KeywordBoost(t *testing.T) {
 	// Vector points equally away from all nodes (dimension 500, unrelated to any).
 	// Keyword "Logger" should make Logger rank first since vector scores are all ~0.
 	queryVec := makeUnitVector(1536, 500)
-	results, err := engine.HybridSearchWithVector(ctx, pool, queryVec, "Logger", "test-search", 10, nil)
+	results, err := engine.HybridSearchWithVector(ctx, pool, queryVec, "Logger", "test-search", 10, nil, engine.DefaultHybridAlpha)
 	if err != nil {
 		t.Fatalf("HybridSearchWithVector: %v", err)
 	}
@@ -261,7 +463,7 @@ func TestHybridSearch_SemanticFallback(t *testing.T) {
 	// Query with nonsense keyword but a vector pointing at "authenticate".
 	// Should still return results via the vector path.
 	queryVec := makeUnitVector(1536, 0)
-	results, err := engine.HybridSearchWithVector(ctx, pool, queryVec, "xyznonexistent", "test-search", 10, nil)
+	results, err := engine.HybridSearchWithVector(ctx, pool, queryVec, "xyznonexistent", "test-search", 10, nil, engine.DefaultHybridAlpha)
 	if err != nil {
 		t.Fatalf("HybridSearchWithVector: %v", err)
 	}
@@ -279,7 +481,7 @@ func TestHybridSearch_KindFilter(t *testing.T) {
 	ctx, pool := setupSearchTest(t)
 
 	queryVec := makeUnitVector(1536, 2)
-	results, err := engine.HybridSearchWithVector(ctx, pool, queryVec, "Logger", "test-search", 10, []string{"class"})
+	results, err := engine.HybridSearchWithVector(ctx, pool, queryVec, "Logger", "test-search", 10, []string{"class"}, engine.DefaultHybridAlpha)
 	if err != nil {
 		t.Fatalf("HybridSearchWithVector: %v", err)
 	}
@@ -296,7 +498,7 @@ func TestHybridSearch_WrongProject(t *testing.T) {
 	ctx, pool := setupSearchTest(t)
 
 	queryVec := makeUnitVector(1536, 0)
-	results, err := engine.HybridSearchWithVector(ctx, pool, queryVec, "authenticate", "nonexistent", 10, nil)
+	results, err := engine.HybridSearchWithVector(ctx, pool, queryVec, "authenticate", "nonexistent", 10, nil, engine.DefaultHybridAlpha)
 	if err != nil {
 		t.Fatalf("HybridSearchWithVector: %v", err)
 	}
@@ -328,7 +530,7 @@ func TestHybridSearch_SourceAlias(t *testing.T) {
 	ctx, pool := setupSearchTest(t)
 
 	queryVec := makeUnitVector(1536, 0)
-	results, err := engine.HybridSearchWithVector(ctx, pool, queryVec, "authenticate", "test-search", 10, nil)
+	results, err := engine.HybridSearchWithVector(ctx, pool, queryVec, "authenticate", "test-search", 10, nil, engine.DefaultHybridAlpha)
 	if err != nil {
 		t.Fatalf("HybridSearchWithVector: %v", err)
 	}
@@ -348,3 +550,117 @@ func TestHybridSearch_SourceAlias(t *testing.T) {
 		t.Error("expected at least one result to have SourceAlias 'test-source'")
 	}
 }
+
+func TestHybridSearch_AlphaPureKeyword(t *testing.T) {
+	ctx, pool := setupSearchTest(t)
+
+	// Vector points at queryUsers, but alpha=0 ignores the vector side entirely
+	queryVec := makeUnitVector(1536, 1)
+	results, err := engine.HybridSearchWithVector(ctx, pool, queryVec, "Logger", "test-search", 10, nil, 0)
+	if err != nil {
+		t.Fatalf("HybridSearchWithVector: %v", err)
+	}
+
+	if len(results) == 0 {
+		t.Fatal("expected keyword results, got 0")
+	}
+	for _, r := range results {
+		if r.KeywordScore <= 0 {
+			t.Errorf("alpha=0 should only return keyword hits, got %q with keywordScore %f", r.QualifiedName, r.KeywordScore)
+		}
+	}
+	if results[0].QualifiedName != "Logger" {
+		t.Errorf("expected 'Logger' first, got %q", results[0].QualifiedName)
+	}
+}
+
+func TestHybridSearch_AlphaPureSemantic(t *testing.T) {
+	ctx, pool := setupSearchTest(t)
+
+	// Keyword says Logger, vector says queryUsers — alpha=1 follows the vector
+	queryVec := makeUnitVector(1536, 1)
+	results, err := engine.HybridSearchWithVector(ctx, pool, queryVec, "Logger", "test-search", 10, nil, 1)
+	if err != nil {
+		t.Fatalf("HybridSearchWithVector: %v", err)
+	}
+
+	if len(results) == 0 {
+		t.Fatal("expected results, got 0")
+	}
+	if results[0].QualifiedName != "queryUsers" {
+		t.Errorf("expected 'queryUsers' first with alpha=1, got %q", results[0].QualifiedName)
+	}
+}
+
+func TestHybridSearch_SubScores(t *testing.T) {
+	ctx, pool := setupSearchTest(t)
+
+	queryVec := makeUnitVector(1536, 0)
+	results, err := engine.HybridSearchWithVector(ctx, pool, queryVec, "authenticate", "test-search", 10, nil, engine.DefaultHybridAlpha)
+	if err != nil {
+		t.Fatalf("HybridSearchWithVector: %v", err)
+	}
+
+	if len(results) == 0 || results[0].QualifiedName != "authenticate" {
+		t.Fatalf("expected 'authenticate' first, got %+v", results)
+	}
+	top := results[0]
+	if math.Abs(top.SemanticScore-1.0) > 0.01 {
+		t.Errorf("expected semanticScore ~1.0, got %f", top.SemanticScore)
+	}
+	if top.KeywordScore <= 0 {
+		t.Errorf("expected positive keywordScore, got %f", top.KeywordScore)
+	}
+}
+
+func TestHybridSearch_Fixtures(t *testing.T) {
+	ctx, pool := setupGraphTest(t)
+	projectID := "test-search-fixtures"
+	createTestProject(t, ctx, pool, projectID)
+	createTestSource(t, ctx, pool, projectID+"/src", projectID, "/tmp/test-search-fixtures")
+
+	input := &indexer.BuildInput{
+		ProjectID:  projectID,
+		SourceID:   projectID + "/src",
+		SourcePath: "/tmp/test-search-fixtures",
+		Workspace:  &detectors.WorkspaceInfo{WorkspaceType: "standalone"},
+		Nodes: []parsers.NodeInfo{
+			{Name: "authenticate", QualifiedName: "authenticate", Kind: "function", Docstring: "Checks a session token", BodyHash: "h1"},
+			{Name: "fakeAuth", QualifiedName: "fakeAuth", Kind: "function", Docstring: "Stands in for authenticate in tests", BodyHash: "h2", Fixture: true},
+		},
+		Edges: []parsers.EdgeInfo{
+			{Source: "src/auth.ts", Target: "authenticate", Kind: "contains", Line: 1},
+			{Source: "src/__mocks__/auth.ts", Target: "fakeAuth", Kind: "contains", Line: 1},
+		},
+		Embeddings: map[string][]float32{"authenticate": makeUnitVector(1536, 0)},
+		FilePaths:  []string{"src/auth.ts", "src/__mocks__/auth.ts"},
+	}
+	if _, err := indexer.BuildGraph(ctx, pool, input); err != nil {
+		t.Fatalf("BuildGraph: %v", err)
+	}
+
+	names := func(results []engine.SearchResult) []string {
+		var out []string
+		for _, r := range results {
+			out = append(out, r.QualifiedName)
+		}
+		slices.Sort(out)
+		return out
+	}
+	queryVec := makeUnitVector(1536, 0)
+	results, err := engine.HybridSearchWithVector(ctx, pool, queryVec, "authenticate", projectID, 10, nil, 0)
+	if err != nil {
+		t.Fatalf("HybridSearchWithVector: %v", err)
+	}
+	if got := names(results); !slices.Equal(got, []string{"authenticate"}) {
+		t.Errorf("expected the fixture left out of keyword results, got %v", got)
+	}
+
+	results, err = engine.HybridSearchWithVector(ctx, pool, queryVec, "authenticate", projectID, 10, nil, 0, engine.SearchFixtures())
+	if err != nil {
+		t.Fatalf("HybridSearchWithVector: %v", err)
+	}
+	if got := names(results); !slices.Equal(got, []string{"authenticate", "fakeAuth"}) {
+		t.Errorf("expected the fixture with SearchFixtures, got %v", got)
+	}
+}
@@ -2,9 +2,14 @@ package integration
 
 import (
 	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/maximilianfalco/mycelium/internal/config"
 	"github.com/maximilianfalco/mycelium/internal/engine"
 	"github.com/maximilianfalco/mycelium/internal/indexer"
 	"github.com/maximilianfalco/mycelium/internal/indexer/detectors"
@@ -165,6 +170,41 @@ func TestFindNodeByQualifiedName_WrongProject(t *testing.T) {
 	}
 }
 
+func TestFindNodeByQualifiedName_DefaultBranch(t *testing.T) {
+	ctx, pool := setupGraphTest(t)
+	createTestProject(t, ctx, pool, "test-gb")
+	createTestSource(t, ctx, pool, "test-gb/test-source", "test-gb", "/tmp/test-repo")
+
+	// The plain workspace from before BRANCH_WORKSPACES, and two branches
+	for _, branch := range []string{"", "main", "feature"} {
+		input := testBuildInput()
+		input.Branch = branch
+		if _, err := indexer.BuildGraph(ctx, pool, input); err != nil {
+			t.Fatalf("BuildGraph on %q: %v", branch, err)
+		}
+	}
+	if _, err := pool.Exec(ctx, `UPDATE project_sources SET last_indexed_branch = 'main' WHERE id = 'test-gb/test-source'`); err != nil {
+		t.Fatal(err)
+	}
+	engine.SetBranchWorkspaces(true)
+	defer engine.SetBranchWorkspaces(false)
+
+	for _, tc := range []struct{ branch, workspace string }{
+		{"", indexer.BranchWorkspaceID("test-gb", "test-gb/test-source", "main")},
+		{"feature", indexer.BranchWorkspaceID("test-gb", "test-gb/test-source", "feature")},
+	} {
+		for range 3 {
+			node, err := engine.FindNodeByQualifiedNameOnBranch(ctx, pool, "test-gb", tc.branch, "greet")
+			if err != nil {
+				t.Fatalf("FindNodeByQualifiedNameOnBranch: %v", err)
+			}
+			if node == nil || !strings.HasPrefix(node.NodeID, tc.workspace+"::") {
+				t.Fatalf("branch %q: expected greet from %s, got %+v", tc.branch, tc.workspace, node)
+			}
+		}
+	}
+}
+
 func TestGetCallers(t *testing.T) {
 	ctx, pool, _ := setupStructuralTest(t)
 
@@ -261,7 +301,7 @@ func TestGetDependencies_SingleHop(t *testing.T) {
 		t.Fatal("expected to find handleLogin")
 	}
 
-	deps, err := engine.GetDependencies(ctx, pool, node.NodeID, 1, 50)
+	deps, err := engine.GetDependencies(ctx, pool, node.NodeID, 1, 50, nil)
 	if err != nil {
 		t.Fatalf("GetDependencies: %v", err)
 	}
@@ -284,7 +324,7 @@ func TestGetDependencies_Transitive(t *testing.T) {
 		t.Fatal("expected to find handleLogin")
 	}
 
-	deps, err := engine.GetDependencies(ctx, pool, node.NodeID, 5, 50)
+	deps, err := engine.GetDependencies(ctx, pool, node.NodeID, 5, 50, nil)
 	if err != nil {
 		t.Fatalf("GetDependencies: %v", err)
 	}
@@ -312,7 +352,7 @@ func TestGetDependencies_DepthLimit(t *testing.T) {
 		t.Fatal("expected to find handleLogin")
 	}
 
-	deps, err := engine.GetDependencies(ctx, pool, node.NodeID, 1, 50)
+	deps, err := engine.GetDependencies(ctx, pool, node.NodeID, 1, 50, nil)
 	if err != nil {
 		t.Fatalf("GetDependencies: %v", err)
 	}
@@ -332,7 +372,7 @@ func TestGetDependencies_IncludesDepth(t *testing.T) {
 		t.Fatal("expected to find handleLogin")
 	}
 
-	deps, err := engine.GetDependencies(ctx, pool, node.NodeID, 5, 50)
+	deps, err := engine.GetDependencies(ctx, pool, node.NodeID, 5, 50, nil)
 	if err != nil {
 		t.Fatalf("GetDependencies: %v", err)
 	}
@@ -356,7 +396,7 @@ func TestGetDependents(t *testing.T) {
 		t.Fatal("expected to find decodeJWT")
 	}
 
-	dependents, err := engine.GetDependents(ctx, pool, node.NodeID, 5, 50)
+	dependents, err := engine.GetDependents(ctx, pool, node.NodeID, 5, 50, nil)
 	if err != nil {
 		t.Fatalf("GetDependents: %v", err)
 	}
@@ -377,6 +417,32 @@ func TestGetDependents(t *testing.T) {
 	}
 }
 
+func TestGetDependencies_EdgeKinds(t *testing.T) {
+	ctx, pool, _ := setupStructuralTest(t)
+
+	node, _ := engine.FindNodeByQualifiedName(ctx, pool, "test-structural", "handleLogin")
+	if node == nil {
+		t.Fatal("expected to find handleLogin")
+	}
+
+	// handleLogin only imports authenticate; the rest of the chain is calls
+	deps, err := engine.GetDependencies(ctx, pool, node.NodeID, 5, 50, []string{"imports"})
+	if err != nil {
+		t.Fatalf("GetDependencies: %v", err)
+	}
+	if len(deps) != 1 || deps[0].QualifiedName != "authenticate" {
+		t.Errorf("expected only authenticate via imports, got %v", deps)
+	}
+
+	deps, err = engine.GetDependencies(ctx, pool, node.NodeID, 5, 50, []string{"uses_type", "implements"})
+	if err != nil {
+		t.Fatalf("GetDependencies: %v", err)
+	}
+	if len(deps) != 0 {
+		t.Errorf("expected no type dependencies, got %v", deps)
+	}
+}
+
 func TestGetCrossPackageDeps(t *testing.T) {
 	ctx, pool, result := setupStructuralTest(t)
 
@@ -510,3 +576,700 @@ func TestGetCallees_ResultFields(t *testing.T) {
 		}
 	}
 }
+
+func TestGetNodeAtPosition(t *testing.T) {
+	ctx, pool, _ := setupStructuralTest(t)
+
+	node, err := engine.GetNodeAtPosition(ctx, pool, "test-structural", "packages/auth/src/auth.ts", 9)
+	if err != nil {
+		t.Fatalf("GetNodeAtPosition: %v", err)
+	}
+	if node == nil || node.QualifiedName != "validateToken" {
+		t.Fatalf("expected validateToken at auth.ts:9, got %+v", node)
+	}
+
+	// Line 6 falls between authenticate (1-5) and validateToken (7-12).
+	node, err = engine.GetNodeAtPosition(ctx, pool, "test-structural", "packages/auth/src/auth.ts", 6)
+	if err != nil {
+		t.Fatalf("GetNodeAtPosition: %v", err)
+	}
+	if node != nil {
+		t.Errorf("expected no node at auth.ts:6, got %q", node.QualifiedName)
+	}
+}
+
+func TestGotoDefinition(t *testing.T) {
+	ctx, pool, _ := setupStructuralTest(t)
+
+	tests := []struct {
+		file   string
+		line   int
+		symbol string
+		want   string
+	}{
+		// Call edges of the enclosing node
+		{"packages/api/src/login.ts", 5, "authenticate(", "authenticate"},
+		{"packages/auth/src/auth.ts", 3, "validateToken", "validateToken"},
+		{"packages/auth/src/auth.ts", 4, "users.lookupUser", "lookupUser"},
+		// Outside any node, the symbol is looked up in the imported files
+		{"packages/api/src/login.ts", 12, "validateToken", "validateToken"},
+		{"packages/auth/src/auth.ts", 3, "missing", ""},
+	}
+	for _, tt := range tests {
+		node, err := engine.GotoDefinition(ctx, pool, "test-structural", tt.file, tt.line, tt.symbol)
+		if err != nil {
+			t.Fatalf("GotoDefinition(%s:%d, %q): %v", tt.file, tt.line, tt.symbol, err)
+		}
+		got := ""
+		if node != nil {
+			got = node.QualifiedName
+		}
+		if got != tt.want {
+			t.Errorf("GotoDefinition(%s:%d, %q) = %q, want %q", tt.file, tt.line, tt.symbol, got, tt.want)
+		}
+	}
+}
+
+func TestGetNodeAtPosition_PrefersMethod(t *testing.T) {
+	ctx, pool := setupGraphTest(t)
+
+	projectID := "test-position"
+	createTestProject(t, ctx, pool, projectID)
+	createTestSource(t, ctx, pool, projectID+"/src", projectID, "/tmp/test-position")
+
+	input := &indexer.BuildInput{
+		ProjectID:  projectID,
+		SourceID:   projectID + "/src",
+		SourcePath: "/tmp/test-position",
+		Workspace: &detectors.WorkspaceInfo{
+			WorkspaceType:  "standalone",
+			PackageManager: "npm",
+			Packages: []detectors.PackageInfo{
+				{Name: "app", Path: "src", Version: "1.0.0"},
+			},
+		},
+		Nodes: []parsers.NodeInfo{
+			{Name: "Cache", QualifiedName: "Cache", Kind: "class", StartLine: 1, EndLine: 20, BodyHash: "p-1"},
+			{Name: "get", QualifiedName: "Cache.get", Kind: "method", StartLine: 3, EndLine: 8, BodyHash: "p-2"},
+			{Name: "set", QualifiedName: "Cache.set", Kind: "method", StartLine: 10, EndLine: 18, BodyHash: "p-3"},
+		},
+		Edges: []parsers.EdgeInfo{
+			{Source: "src/cache.ts", Target: "Cache", Kind: "contains", Line: 1},
+			{Source: "Cache", Target: "Cache.get", Kind: "contains", Line: 3},
+			{Source: "Cache", Target: "Cache.set", Kind: "contains", Line: 10},
+		},
+		Embeddings: map[string][]float32{},
+		FilePaths:  []string{"src/cache.ts"},
+	}
+	if _, err := indexer.BuildGraph(ctx, pool, input); err != nil {
+		t.Fatalf("BuildGraph: %v", err)
+	}
+
+	tests := []struct {
+		line int
+		want string
+	}{
+		{5, "Cache.get"},
+		{12, "Cache.set"},
+		{9, "Cache"},
+		{1, "Cache"},
+	}
+	for _, tt := range tests {
+		node, err := engine.GetNodeAtPosition(ctx, pool, projectID, "src/cache.ts", tt.line)
+		if err != nil {
+			t.Fatalf("GetNodeAtPosition(%d): %v", tt.line, err)
+		}
+		if node == nil || node.QualifiedName != tt.want {
+			t.Errorf("GetNodeAtPosition(%d) = %+v, want %s", tt.line, node, tt.want)
+		}
+	}
+}
+
+func TestGetSiblings(t *testing.T) {
+	ctx, pool := setupGraphTest(t)
+
+	projectID := "test-siblings"
+	createTestProject(t, ctx, pool, projectID)
+	createTestSource(t, ctx, pool, projectID+"/src", projectID, "/tmp/test-siblings")
+
+	input := &indexer.BuildInput{
+		ProjectID:  projectID,
+		SourceID:   projectID + "/src",
+		SourcePath: "/tmp/test-siblings",
+		Workspace: &detectors.WorkspaceInfo{
+			WorkspaceType:  "standalone",
+			PackageManager: "npm",
+			Packages: []detectors.PackageInfo{
+				{Name: "app", Path: "src", Version: "1.0.0"},
+			},
+		},
+		Nodes: []parsers.NodeInfo{
+			{Name: "Cache", QualifiedName: "Cache", Kind: "class", StartLine: 1, EndLine: 20, BodyHash: "s-1"},
+			{Name: "set", QualifiedName: "Cache.set", Kind: "method", StartLine: 10, EndLine: 14, BodyHash: "s-2"},
+			{Name: "get", QualifiedName: "Cache.get", Kind: "method", StartLine: 3, EndLine: 8, BodyHash: "s-3"},
+			{Name: "clear", QualifiedName: "Cache.clear", Kind: "method", StartLine: 16, EndLine: 18, BodyHash: "s-4"},
+			{Name: "Store", QualifiedName: "Store", Kind: "class", StartLine: 22, EndLine: 30, BodyHash: "s-5"},
+			{Name: "get", QualifiedName: "Store.get", Kind: "method", StartLine: 24, EndLine: 28, BodyHash: "s-6"},
+			{Name: "newCache", QualifiedName: "newCache", Kind: "function", StartLine: 32, EndLine: 34, BodyHash: "s-7"},
+			// Members placed at their file, as sharded runs store them
+			{Name: "Queue", QualifiedName: "Queue", Kind: "class", StartLine: 1, EndLine: 10, BodyHash: "s-8"},
+			{Name: "push", QualifiedName: "Queue.push", Kind: "method", FilePath: "src/queue.ts", StartLine: 2, EndLine: 4, BodyHash: "s-9"},
+			{Name: "pop", QualifiedName: "Queue.pop", Kind: "method", FilePath: "src/queue.ts", StartLine: 6, EndLine: 8, BodyHash: "s-10"},
+			{Name: "Worker", QualifiedName: "Worker", Kind: "class", StartLine: 12, EndLine: 20, BodyHash: "s-11"},
+			{Name: "run", QualifiedName: "Worker.run", Kind: "method", FilePath: "src/queue.ts", StartLine: 14, EndLine: 18, BodyHash: "s-12"},
+		},
+		Edges: []parsers.EdgeInfo{
+			{Source: "src/cache.ts", Target: "Cache", Kind: "contains", Line: 1},
+			{Source: "Cache", Target: "Cache.set", Kind: "contains", Line: 10},
+			{Source: "Cache", Target: "Cache.get", Kind: "contains", Line: 3},
+			{Source: "Cache", Target: "Cache.clear", Kind: "contains", Line: 16},
+			{Source: "src/cache.ts", Target: "Store", Kind: "contains", Line: 22},
+			{Source: "Store", Target: "Store.get", Kind: "contains", Line: 24},
+			{Source: "src/cache.ts", Target: "newCache", Kind: "contains", Line: 32},
+			{Source: "src/queue.ts", Target: "Queue", Kind: "contains", Line: 1},
+			{Source: "Queue", Target: "Queue.push", Kind: "contains", Line: 2},
+			{Source: "Queue", Target: "Queue.pop", Kind: "contains", Line: 6},
+			{Source: "src/queue.ts", Target: "Worker", Kind: "contains", Line: 12},
+			{Source: "Worker", Target: "Worker.run", Kind: "contains", Line: 14},
+		},
+		Embeddings: map[string][]float32{},
+		FilePaths:  []string{"src/cache.ts", "src/queue.ts"},
+	}
+	if _, err := indexer.BuildGraph(ctx, pool, input); err != nil {
+		t.Fatalf("BuildGraph: %v", err)
+	}
+
+	tests := []struct {
+		qname string
+		want  []string
+	}{
+		// A method's peers are its own class's methods, in source order
+		{"Cache.get", []string{"Cache.set", "Cache.clear"}},
+		{"Store.get", nil},
+		// A top-level node's peers are the file's other top-level nodes
+		{"newCache", []string{"Cache", "Store"}},
+		// Sharing a file path with other members doesn't make them peers
+		{"Queue.push", []string{"Queue.pop"}},
+		{"Worker", []string{"Queue"}},
+	}
+	for _, tt := range tests {
+		node, _ := engine.FindNodeByQualifiedName(ctx, pool, projectID, tt.qname)
+		if node == nil {
+			t.Fatalf("expected to find %s", tt.qname)
+		}
+		siblings, err := engine.GetSiblings(ctx, pool, node.NodeID)
+		if err != nil {
+			t.Fatalf("GetSiblings(%s): %v", tt.qname, err)
+		}
+		var got []string
+		for _, n := range siblings {
+			got = append(got, n.QualifiedName)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("GetSiblings(%s) = %v, want %v", tt.qname, got, tt.want)
+		}
+	}
+}
+
+func TestBlastRadius(t *testing.T) {
+	ctx, pool, _ := setupStructuralTest(t)
+
+	node, _ := engine.FindNodeByQualifiedName(ctx, pool, "test-structural", "decodeJWT")
+	if node == nil {
+		t.Fatal("expected to find decodeJWT")
+	}
+
+	dependents, err := engine.GetDependents(ctx, pool, node.NodeID, 5, 100, nil)
+	if err != nil {
+		t.Fatalf("GetDependents: %v", err)
+	}
+	count, err := engine.BlastRadius(ctx, pool, node.NodeID, 5)
+	if err != nil {
+		t.Fatalf("BlastRadius: %v", err)
+	}
+	if count != len(dependents) || count < 3 {
+		t.Errorf("expected blast radius %d (>= 3), got %d", len(dependents), count)
+	}
+
+	// One hop only reaches the direct caller
+	count, err = engine.BlastRadius(ctx, pool, node.NodeID, 1)
+	if err != nil {
+		t.Fatalf("BlastRadius: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("expected blast radius 1 at depth 1, got %d", count)
+	}
+
+	results := []engine.NodeResult{*node}
+	if err := engine.AnnotateBlastRadius(ctx, pool, results, 1); err != nil {
+		t.Fatalf("AnnotateBlastRadius: %v", err)
+	}
+	if results[0].BlastRadius == nil || *results[0].BlastRadius != 1 {
+		t.Errorf("expected annotated blast radius 1, got %v", results[0].BlastRadius)
+	}
+}
+
+func TestGetReachableExternals(t *testing.T) {
+	ctx, pool, _ := setupStructuralTest(t)
+
+	nodeID := func(qname string) string {
+		t.Helper()
+		n, _ := engine.FindNodeByQualifiedName(ctx, pool, "test-structural", qname)
+		if n == nil {
+			t.Fatalf("expected to find %s", qname)
+		}
+		return n.NodeID
+	}
+	for qname, spec := range map[string]string{
+		"handleLogin": "express",
+		"decodeJWT":   "jsonwebtoken",
+		"lookupUser":  "./missing",
+		"Logger":      "winston",
+	} {
+		if _, err := pool.Exec(ctx,
+			`INSERT INTO unresolved_refs (source_node_id, raw_import, kind, line_number) VALUES ($1, $2, 'imports', 1)`,
+			nodeID(qname), spec,
+		); err != nil {
+			t.Fatalf("inserting unresolved ref: %v", err)
+		}
+	}
+
+	// handleLogin → authenticate → validateToken → decodeJWT, and the relative
+	// import of lookupUser's file isn't external; Logger isn't reachable
+	externals, err := engine.GetReachableExternals(ctx, pool, nodeID("handleLogin"), 5)
+	if err != nil {
+		t.Fatalf("GetReachableExternals: %v", err)
+	}
+	if !slices.Equal(externals, []string{"express", "jsonwebtoken"}) {
+		t.Errorf("expected [express jsonwebtoken], got %v", externals)
+	}
+
+	// One hop reaches authenticate, whose file imports nothing external
+	externals, err = engine.GetReachableExternals(ctx, pool, nodeID("handleLogin"), 1)
+	if err != nil {
+		t.Fatalf("GetReachableExternals: %v", err)
+	}
+	if !slices.Equal(externals, []string{"express"}) {
+		t.Errorf("expected [express] at depth 1, got %v", externals)
+	}
+}
+
+func TestGetReachableExternals_Builtins(t *testing.T) {
+	ctx, pool := setupGraphTest(t)
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "loader.ts"), []byte("import { readFileSync } from 'fs';\n\nexport function load(path: string) {\n  return readFileSync(path);\n}\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "main.ts"), []byte("import { load } from './loader';\n\nexport function run() {\n  return load('config.json');\n}\n"), 0o644)
+
+	projectID, sourceID := "test-builtins", "test-builtins-source"
+	createTestProject(t, ctx, pool, projectID)
+	createTestSource(t, ctx, pool, sourceID, projectID, dir)
+	if result := indexer.IndexProject(ctx, pool, &config.Config{}, nil, projectID, nil, true); len(result.Errors) > 0 {
+		t.Fatalf("index failed: %v", result.Errors)
+	}
+
+	run, err := engine.FindNodeByQualifiedName(ctx, pool, projectID, "run")
+	if err != nil || run == nil {
+		t.Fatalf("expected to find run: %v", err)
+	}
+	externals, err := engine.GetReachableExternals(ctx, pool, run.NodeID, 5)
+	if err != nil {
+		t.Fatalf("GetReachableExternals: %v", err)
+	}
+	if !slices.Equal(externals, []string{"fs"}) {
+		t.Errorf("expected run to reach fs through load, got %v", externals)
+	}
+
+	// Built-ins aren't third-party packages for the import reports
+	imported, err := engine.GetMostImported(ctx, pool, projectID, 10, engine.IncludeExternals())
+	if err != nil {
+		t.Fatalf("GetMostImported: %v", err)
+	}
+	for _, c := range imported {
+		if c.External {
+			t.Errorf("expected no external entry for a built-in, got %+v", c)
+		}
+	}
+}
+
+func TestIndexProject_GoTypeUses(t *testing.T) {
+	ctx, pool := setupGraphTest(t)
+	dir := t.TempDir()
+	write := func(rel, content string) {
+		t.Helper()
+		path := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("go.mod", "module example.com/shop\n\ngo 1.22\n")
+	write("store/order.go", "package store\n\ntype Order struct {\n\tID int\n}\n")
+	write("billing/order.go", "package billing\n\ntype Order struct {\n\tTotal int\n}\n")
+	write("api/handler.go", "package api\n\nimport (\n\t\"context\"\n\n\t\"example.com/shop/store\"\n)\n\ntype Response struct {\n\tOK bool\n}\n\nfunc GetOrder(ctx context.Context, o *store.Order) Response {\n\treturn Response{}\n}\n")
+
+	projectID, sourceID := "test-go-types", "test-go-types-source"
+	createTestProject(t, ctx, pool, projectID)
+	createTestSource(t, ctx, pool, sourceID, projectID, dir)
+	if result := indexer.IndexProject(ctx, pool, &config.Config{}, nil, projectID, nil, true); len(result.Errors) > 0 {
+		t.Fatalf("index failed: %v", result.Errors)
+	}
+
+	rows, err := pool.Query(ctx, `
+		SELECT s.qualified_name || ' -> ' || t.qualified_name || ' in ' || t.file_path
+		FROM edges e
+		JOIN nodes s ON e.source_id = s.id
+		JOIN nodes t ON e.target_id = t.id
+		WHERE s.workspace_id = $1 AND e.kind = 'uses_type'
+		ORDER BY 1`,
+		indexer.WorkspaceID(projectID, sourceID))
+	if err != nil {
+		t.Fatalf("querying uses_type edges: %v", err)
+	}
+	defer rows.Close()
+	var got []string
+	for rows.Next() {
+		var edge string
+		if err := rows.Scan(&edge); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, edge)
+	}
+	// store.Order lands on store's Order, not billing's; context.Context
+	// isn't indexed
+	want := []string{"GetOrder -> Order in store/order.go", "GetOrder -> Response in api/handler.go"}
+	if !slices.Equal(got, want) {
+		t.Errorf("uses_type edges = %v, want %v", got, want)
+	}
+}
+
+func TestLowestCommonCaller(t *testing.T) {
+	ctx, pool, _ := setupStructuralTest(t)
+
+	nodeID := func(qname string) string {
+		t.Helper()
+		n, _ := engine.FindNodeByQualifiedName(ctx, pool, "test-structural", qname)
+		if n == nil {
+			t.Fatalf("expected to find %s", qname)
+		}
+		return n.NodeID
+	}
+	decode, lookup := nodeID("decodeJWT"), nodeID("lookupUser")
+
+	// authenticate → validateToken → decodeJWT and authenticate → lookupUser
+	lcc, err := engine.LowestCommonCaller(ctx, pool, decode, lookup, 5)
+	if err != nil {
+		t.Fatalf("LowestCommonCaller: %v", err)
+	}
+	if lcc == nil || lcc.QualifiedName != "authenticate" || lcc.Depth != 3 {
+		t.Errorf("expected authenticate at combined distance 3, got %+v", lcc)
+	}
+
+	// A node that calls the other is its own answer
+	lcc, err = engine.LowestCommonCaller(ctx, pool, decode, nodeID("validateToken"), 5)
+	if err != nil {
+		t.Fatalf("LowestCommonCaller: %v", err)
+	}
+	if lcc == nil || lcc.QualifiedName != "validateToken" || lcc.Depth != 1 {
+		t.Errorf("expected validateToken at distance 1, got %+v", lcc)
+	}
+
+	// Too shallow to reach authenticate from decodeJWT
+	if lcc, _ := engine.LowestCommonCaller(ctx, pool, decode, lookup, 1); lcc != nil {
+		t.Errorf("expected no common caller within 1 hop, got %s", lcc.QualifiedName)
+	}
+	if lcc, _ := engine.LowestCommonCaller(ctx, pool, decode, nodeID("Logger"), 5); lcc != nil {
+		t.Errorf("expected Logger to share no caller, got %s", lcc.QualifiedName)
+	}
+}
+
+func TestGetDependencies_SkipGenerated(t *testing.T) {
+	ctx, pool := setupGraphTest(t)
+	projectID := "test-skip-gen"
+	createTestProject(t, ctx, pool, projectID)
+	createTestSource(t, ctx, pool, projectID+"/src", projectID, "/tmp/test-skip-gen")
+
+	// handle --calls--> save, handle --calls--> Marshal (generated) --calls--> encode (generated)
+	input := &indexer.BuildInput{
+		ProjectID:  projectID,
+		SourceID:   projectID + "/src",
+		SourcePath: "/tmp/test-skip-gen",
+		Workspace:  &detectors.WorkspaceInfo{WorkspaceType: "standalone"},
+		Nodes: []parsers.NodeInfo{
+			{Name: "handle", QualifiedName: "handle", Kind: "function", BodyHash: "h1"},
+			{Name: "save", QualifiedName: "save", Kind: "function", BodyHash: "h2"},
+			{Name: "Marshal", QualifiedName: "Marshal", Kind: "function", BodyHash: "h3", Generated: true},
+			{Name: "encode", QualifiedName: "encode", Kind: "function", BodyHash: "h4", Generated: true},
+		},
+		Edges: []parsers.EdgeInfo{
+			{Source: "handler.go", Target: "handle", Kind: "contains"},
+			{Source: "handler.go", Target: "save", Kind: "contains"},
+			{Source: "user.pb.go", Target: "Marshal", Kind: "contains"},
+			{Source: "user.pb.go", Target: "encode", Kind: "contains"},
+			{Source: "handle", Target: "save", Kind: "calls", Line: 2},
+			{Source: "handle", Target: "Marshal", Kind: "calls", Line: 3},
+			{Source: "Marshal", Target: "encode", Kind: "calls", Line: 7},
+		},
+		FilePaths: []string{"handler.go", "user.pb.go"},
+	}
+	if _, err := indexer.BuildGraph(ctx, pool, input); err != nil {
+		t.Fatalf("BuildGraph: %v", err)
+	}
+	handle, _ := engine.FindNodeByQualifiedName(ctx, pool, projectID, "handle")
+	if handle == nil {
+		t.Fatal("expected to find handle")
+	}
+
+	all, err := engine.GetDependencies(ctx, pool, handle.NodeID, 5, 100, []string{"calls"})
+	if err != nil {
+		t.Fatalf("GetDependencies: %v", err)
+	}
+	if len(all) != 3 {
+		t.Errorf("expected save, Marshal, and encode by default, got %d", len(all))
+	}
+
+	handWritten, err := engine.GetDependencies(ctx, pool, handle.NodeID, 5, 100, []string{"calls"}, engine.SkipGenerated())
+	if err != nil {
+		t.Fatalf("GetDependencies: %v", err)
+	}
+	if len(handWritten) != 1 || handWritten[0].QualifiedName != "save" {
+		t.Errorf("expected only save with generated nodes skipped, got %+v", handWritten)
+	}
+
+	// Generated nodes are still there to query directly
+	marshal, _ := engine.FindNodeByQualifiedName(ctx, pool, projectID, "Marshal")
+	if marshal == nil {
+		t.Fatal("expected Marshal to stay queryable")
+	}
+	dependents, _ := engine.GetDependents(ctx, pool, marshal.NodeID, 1, 10, []string{"calls"}, engine.SkipGenerated())
+	if len(dependents) != 1 || dependents[0].QualifiedName != "handle" {
+		t.Errorf("expected handle as Marshal's hand-written caller, got %+v", dependents)
+	}
+}
+
+func TestGetDependencies_SkipTypeOnly(t *testing.T) {
+	ctx, pool := setupGraphTest(t)
+	projectID := "test-skip-type-only"
+	createTestProject(t, ctx, pool, projectID)
+	createTestSource(t, ctx, pool, projectID+"/src", projectID, "/tmp/test-skip-type-only")
+
+	// app.ts imports db.ts at runtime, types.ts only for types, and
+	// mixed.ts for a value and a type
+	input := &indexer.BuildInput{
+		ProjectID:  projectID,
+		SourceID:   projectID + "/src",
+		SourcePath: "/tmp/test-skip-type-only",
+		Workspace:  &detectors.WorkspaceInfo{WorkspaceType: "standalone"},
+		Nodes: []parsers.NodeInfo{
+			{Name: "main", QualifiedName: "main", Kind: "function", BodyHash: "h1"},
+			{Name: "connect", QualifiedName: "connect", Kind: "function", BodyHash: "h2"},
+			{Name: "User", QualifiedName: "User", Kind: "interface", BodyHash: "h3"},
+			{Name: "format", QualifiedName: "format", Kind: "function", BodyHash: "h4"},
+		},
+		Edges: []parsers.EdgeInfo{
+			{Source: "app.ts", Target: "main", Kind: "contains"},
+			{Source: "db.ts", Target: "connect", Kind: "contains"},
+			{Source: "types.ts", Target: "User", Kind: "contains"},
+			{Source: "mixed.ts", Target: "format", Kind: "contains"},
+		},
+		Resolved: []indexer.ResolvedEdge{
+			{Source: "app.ts", Target: "./db", ResolvedPath: "db.ts", Kind: "imports", Line: 1},
+			{Source: "app.ts", Target: "./types", ResolvedPath: "types.ts", Kind: "imports", Line: 2, TypeOnly: true, TypeOnlySymbols: []string{"User"}},
+			{Source: "app.ts", Target: "./mixed", ResolvedPath: "mixed.ts", Kind: "imports", Line: 3, TypeOnlySymbols: []string{"Options"}},
+		},
+		FilePaths: []string{"app.ts", "db.ts", "types.ts", "mixed.ts"},
+	}
+	if _, err := indexer.BuildGraph(ctx, pool, input); err != nil {
+		t.Fatalf("BuildGraph: %v", err)
+	}
+
+	var meta string
+	err := pool.QueryRow(ctx, `
+		SELECT e.metadata::text FROM edges e
+		JOIN nodes t ON t.id = e.target_id
+		WHERE e.kind = 'imports' AND t.qualified_name = 'User'`).Scan(&meta)
+	if err != nil {
+		t.Fatalf("querying type-only import: %v", err)
+	}
+	if meta != `{"typeOnly": true, "typeOnlySymbols": ["User"]}` {
+		t.Errorf("type-only import metadata = %s", meta)
+	}
+
+	main, _ := engine.FindNodeByQualifiedName(ctx, pool, projectID, "main")
+	if main == nil {
+		t.Fatal("expected to find main")
+	}
+	names := func(results []engine.NodeResult) []string {
+		var out []string
+		for _, r := range results {
+			out = append(out, r.QualifiedName)
+		}
+		slices.Sort(out)
+		return out
+	}
+	all, err := engine.GetDependencies(ctx, pool, main.NodeID, 1, 100, []string{"imports"})
+	if err != nil {
+		t.Fatalf("GetDependencies: %v", err)
+	}
+	if got := names(all); !slices.Equal(got, []string{"User", "connect", "format"}) {
+		t.Errorf("expected every import by default, got %v", got)
+	}
+	runtime, err := engine.GetDependencies(ctx, pool, main.NodeID, 1, 100, []string{"imports"}, engine.SkipTypeOnly())
+	if err != nil {
+		t.Fatalf("GetDependencies: %v", err)
+	}
+	if got := names(runtime); !slices.Equal(got, []string{"connect", "format"}) {
+		t.Errorf("expected runtime imports only, got %v", got)
+	}
+}
+
+func TestGetDependents_HubGuard(t *testing.T) {
+	ctx, pool := setupGraphTest(t)
+	projectID := "test-hub"
+	createTestProject(t, ctx, pool, projectID)
+	createTestSource(t, ctx, pool, projectID+"/src", projectID, "/tmp/test-hub")
+	defer engine.SetHubInDegree(config.DefaultHubInDegree)
+
+	// a, b, c --calls--> log --calls--> write; main --calls--> a
+	input := &indexer.BuildInput{
+		ProjectID:  projectID,
+		SourceID:   projectID + "/src",
+		SourcePath: "/tmp/test-hub",
+		Workspace:  &detectors.WorkspaceInfo{WorkspaceType: "standalone"},
+		Nodes: []parsers.NodeInfo{
+			{Name: "write", QualifiedName: "write", Kind: "function", BodyHash: "w"},
+			{Name: "log", QualifiedName: "log", Kind: "function", BodyHash: "l"},
+			{Name: "a", QualifiedName: "a", Kind: "function", BodyHash: "a"},
+			{Name: "b", QualifiedName: "b", Kind: "function", BodyHash: "b"},
+			{Name: "c", QualifiedName: "c", Kind: "function", BodyHash: "c"},
+			{Name: "main", QualifiedName: "main", Kind: "function", BodyHash: "m"},
+		},
+		Edges: []parsers.EdgeInfo{
+			{Source: "log.go", Target: "write", Kind: "contains"},
+			{Source: "log.go", Target: "log", Kind: "contains"},
+			{Source: "app.go", Target: "a", Kind: "contains"},
+			{Source: "app.go", Target: "b", Kind: "contains"},
+			{Source: "app.go", Target: "c", Kind: "contains"},
+			{Source: "app.go", Target: "main", Kind: "contains"},
+			{Source: "log", Target: "write", Kind: "calls", Line: 2},
+			{Source: "a", Target: "log", Kind: "calls", Line: 5},
+			{Source: "b", Target: "log", Kind: "calls", Line: 9},
+			{Source: "c", Target: "log", Kind: "calls", Line: 13},
+			{Source: "main", Target: "a", Kind: "calls", Line: 17},
+		},
+		FilePaths: []string{"log.go", "app.go"},
+	}
+	if _, err := indexer.BuildGraph(ctx, pool, input); err != nil {
+		t.Fatalf("BuildGraph: %v", err)
+	}
+	write, _ := engine.FindNodeByQualifiedName(ctx, pool, projectID, "write")
+	logNode, _ := engine.FindNodeByQualifiedName(ctx, pool, projectID, "log")
+	if write == nil || logNode == nil {
+		t.Fatal("expected to find write and log")
+	}
+
+	engine.SetHubInDegree(0)
+	all, err := engine.GetDependents(ctx, pool, write.NodeID, 5, 100, []string{"calls"})
+	if err != nil {
+		t.Fatalf("GetDependents: %v", err)
+	}
+	if len(all) != 5 {
+		t.Errorf("expected all 5 dependents with the guard off, got %d", len(all))
+	}
+
+	// log has 3 callers, over the threshold: it is returned and flagged, but
+	// its callers aren't walked
+	engine.SetHubInDegree(2)
+	guarded, err := engine.GetDependents(ctx, pool, write.NodeID, 5, 100, []string{"calls"})
+	if err != nil {
+		t.Fatalf("GetDependents: %v", err)
+	}
+	if len(guarded) != 1 || guarded[0].QualifiedName != "log" || !guarded[0].IsHub || guarded[0].InDegree != 3 {
+		t.Fatalf("expected only log, flagged as a hub with in-degree 3, got %+v", guarded)
+	}
+
+	// The start node is always expanded, even when it is the hub
+	fromHub, err := engine.GetDependents(ctx, pool, logNode.NodeID, 5, 100, []string{"calls"})
+	if err != nil {
+		t.Fatalf("GetDependents: %v", err)
+	}
+	if len(fromHub) != 4 {
+		t.Errorf("expected a, b, c, and main from the hub itself, got %+v", fromHub)
+	}
+	for _, n := range fromHub {
+		if n.IsHub {
+			t.Errorf("expected %s not to be a hub", n.QualifiedName)
+		}
+	}
+}
+
+func TestGetNodeCard(t *testing.T) {
+	ctx, pool := setupGraphTest(t)
+
+	projectID := "test-node-card"
+	createTestProject(t, ctx, pool, projectID)
+	createTestSource(t, ctx, pool, projectID+"/src", projectID, "/tmp/test-node-card")
+
+	input := &indexer.BuildInput{
+		ProjectID:  projectID,
+		SourceID:   projectID + "/src",
+		SourcePath: "/tmp/test-node-card",
+		Workspace: &detectors.WorkspaceInfo{
+			WorkspaceType: "standalone",
+			Packages:      []detectors.PackageInfo{{Name: "app", Path: "src"}},
+		},
+		Nodes: []parsers.NodeInfo{
+			{Name: "handle", QualifiedName: "handle", Kind: "function", Signature: "function handle(req)", StartLine: 1, EndLine: 5, BodyHash: "c-1",
+				Docstring: "Handles a request.", SourceCode: "function handle(req) {\n  return validate(req);\n}"},
+			{Name: "validate", QualifiedName: "validate", Kind: "function", Signature: "function validate(req)", StartLine: 7, EndLine: 9, BodyHash: "c-2"},
+			{Name: "route", QualifiedName: "route", Kind: "function", StartLine: 11, EndLine: 13, BodyHash: "c-3"},
+		},
+		Edges: []parsers.EdgeInfo{
+			{Source: "src/handler.ts", Target: "handle", Kind: "contains", Line: 1},
+			{Source: "src/handler.ts", Target: "validate", Kind: "contains", Line: 7},
+			{Source: "src/handler.ts", Target: "route", Kind: "contains", Line: 11},
+		},
+		Resolved: []indexer.ResolvedEdge{
+			{Source: "handle", Target: "validate", Kind: "calls", Line: 2},
+			{Source: "route", Target: "handle", Kind: "calls", Line: 12},
+		},
+		Embeddings: map[string][]float32{},
+		FilePaths:  []string{"src/handler.ts"},
+	}
+	if _, err := indexer.BuildGraph(ctx, pool, input); err != nil {
+		t.Fatalf("BuildGraph: %v", err)
+	}
+
+	node, _ := engine.FindNodeByQualifiedName(ctx, pool, projectID, "handle")
+	if node == nil {
+		t.Fatal("expected to find handle")
+	}
+	card, err := engine.GetNodeCard(ctx, pool, node.NodeID)
+	if err != nil {
+		t.Fatalf("GetNodeCard: %v", err)
+	}
+	if card == nil {
+		t.Fatal("expected a card")
+	}
+	if card.Signature != "function handle(req)" || card.Docstring != "Handles a request." || card.SourceCode != "" {
+		t.Errorf("expected signature and docstring without source, got %+v", card.NodeResult)
+	}
+	if len(card.Callers) != 1 || card.Callers[0].QualifiedName != "route" || card.CallerCount != 1 {
+		t.Errorf("expected route as the only caller, got %+v (%d)", card.Callers, card.CallerCount)
+	}
+	if len(card.Callees) != 1 || card.Callees[0].Signature != "function validate(req)" || card.CalleeCount != 1 {
+		t.Errorf("expected validate as the only callee, got %+v (%d)", card.Callees, card.CalleeCount)
+	}
+	var siblings []string
+	for _, s := range card.Siblings {
+		siblings = append(siblings, s.QualifiedName)
+	}
+	if want := []string{"validate", "route"}; !slices.Equal(siblings, want) {
+		t.Errorf("expected siblings %v, got %v", want, siblings)
+	}
+
+	if missing, err := engine.GetNodeCard(ctx, pool, "nonexistent-node"); err != nil || missing != nil {
+		t.Errorf("expected nil, nil for an unknown node, got %+v, %v", missing, err)
+	}
+}